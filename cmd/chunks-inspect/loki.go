@@ -59,6 +59,16 @@ const (
 	chunkFormatV1
 	chunkFormatV2
 	chunkFormatV3
+	chunkFormatV4
+	chunkFormatV5
+)
+
+// Kinds of the columns of the blocks of chunkFormatV5 chunks.
+const (
+	_ byte = iota
+	columnTimestamps
+	columnLines
+	columnStructuredMetadata
 )
 
 type LokiChunk struct {
@@ -69,6 +79,9 @@ type LokiChunk struct {
 
 	metadataChecksum         uint32
 	computedMetadataChecksum uint32
+
+	// symbols referenced by the structured metadata of the entries (V4 chunks and greater only)
+	symbols []string
 }
 
 type LokiBlock struct {
@@ -90,8 +103,9 @@ type LokiBlock struct {
 }
 
 type LokiEntry struct {
-	timestamp int64
-	line      string
+	timestamp          int64
+	line               string
+	structuredMetadata []string // name=value pairs
 }
 
 func parseLokiChunk(chunkHeader *ChunkHeader, r io.Reader) (*LokiChunk, error) {
@@ -101,6 +115,8 @@ func parseLokiChunk(chunkHeader *ChunkHeader, r io.Reader) (*LokiChunk, error) {
 	4B magic number
 	1B version
 	1B encoding
+	Structured metadata symbols <--------------- C (V4 chunks and greater only)
+	Structured metadata symbols Checksum
 	Block 1 <------------------------------------B
 	Block 1 Checksum
 	...
@@ -113,7 +129,10 @@ func parseLokiChunk(chunkHeader *ChunkHeader, r io.Reader) (*LokiChunk, error) {
 	Block1 Uvarint length
 	Block1 Meta Checksum
 	...
-	4B Meta offset ----------------------------> A
+	8B Structured metadata symbols length (V4 chunks and greater only)
+	8B Structured metadata symbols offset -----> C (V4 chunks and greater only)
+	8B Meta length (V4 chunks and greater only)
+	8B Meta offset ----------------------------> A
 	*/
 
 	// Loki chunks need to be loaded into memory, because some offsets are actually stored at the end.
@@ -137,10 +156,14 @@ func parseLokiChunk(chunkHeader *ChunkHeader, r io.Reader) (*LokiChunk, error) {
 	// return &LokiChunk{encoding: compression}, nil
 
 	metasOffset := binary.BigEndian.Uint64(data[len(data)-8:])
+	metasLength := uint64(len(data)-(8+4)) - metasOffset
+	if f >= chunkFormatV4 {
+		metasLength = binary.BigEndian.Uint64(data[len(data)-16 : len(data)-8])
+	}
 
-	metadata := data[metasOffset : len(data)-(8+4)]
+	metadata := data[metasOffset : metasOffset+metasLength]
 
-	metaChecksum := binary.BigEndian.Uint32(data[len(data)-12 : len(data)-8])
+	metaChecksum := binary.BigEndian.Uint32(data[metasOffset+metasLength : metasOffset+metasLength+4])
 	computedMetaChecksum := crc32.Checksum(metadata, castagnoliTable)
 
 	blocks, n := binary.Uvarint(metadata)
@@ -156,6 +179,15 @@ func parseLokiChunk(chunkHeader *ChunkHeader, r io.Reader) (*LokiChunk, error) {
 		computedMetadataChecksum: computedMetaChecksum,
 	}
 
+	if f >= chunkFormatV4 {
+		symbolsLength := binary.BigEndian.Uint64(data[len(data)-32 : len(data)-24])
+		symbolsOffset := binary.BigEndian.Uint64(data[len(data)-24 : len(data)-16])
+		lokiChunk.symbols, err = parseSymbols(compression, data[symbolsOffset:symbolsOffset+symbolsLength])
+		if err != nil {
+			return nil, fmt.Errorf("failed to read structured metadata symbols: %w", err)
+		}
+	}
+
 	for ix := 0; ix < int(blocks); ix++ {
 		block := LokiBlock{}
 		block.numEntries, metadata, err = readUvarint(err, metadata)
@@ -175,20 +207,62 @@ func parseLokiChunk(chunkHeader *ChunkHeader, r io.Reader) (*LokiChunk, error) {
 		block.rawData = data[block.dataOffset : block.dataOffset+dataLength]
 		block.storedChecksum = binary.BigEndian.Uint32(data[block.dataOffset+dataLength : block.dataOffset+dataLength+4])
 		block.computedChecksum = crc32.Checksum(block.rawData, castagnoliTable)
-		block.originalData, block.entries, err = parseLokiBlock(compression, block.rawData)
+		if f >= chunkFormatV5 {
+			block.originalData, block.entries, err = parseColumnarLokiBlock(compression, block.rawData, lokiChunk.symbols)
+		} else {
+			block.originalData, block.entries, err = parseLokiBlock(f, compression, block.rawData, lokiChunk.symbols)
+		}
 		lokiChunk.blocks = append(lokiChunk.blocks, block)
 	}
 
 	return lokiChunk, nil
 }
 
-func parseLokiBlock(compression Encoding, data []byte) ([]byte, []LokiEntry, error) {
+func decompress(compression Encoding, data []byte) ([]byte, error) {
 	r, err := compression.readerFn(bytes.NewReader(data))
 	if err != nil {
-		return nil, nil, err
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+func parseSymbols(compression Encoding, data []byte) ([]string, error) {
+	// The number of symbols is not compressed.
+	count, data, err := readUvarint(nil, data)
+	if err != nil {
+		return nil, err
 	}
 
-	decompressed, err := io.ReadAll(r)
+	decompressed, err := decompress(compression, data)
+	if err != nil {
+		return nil, err
+	}
+
+	symbols := make([]string, 0, count)
+	for i := uint64(0); i < count; i++ {
+		var symbolLength uint64
+		symbolLength, decompressed, err = readUvarint(err, decompressed)
+		if err != nil {
+			return nil, err
+		}
+		if len(decompressed) < int(symbolLength) {
+			return nil, fmt.Errorf("not enough symbol data, need %d, got %d", symbolLength, len(decompressed))
+		}
+		symbols = append(symbols, string(decompressed[:symbolLength]))
+		decompressed = decompressed[symbolLength:]
+	}
+	return symbols, nil
+}
+
+func lookupSymbols(symbols []string, name, value uint64) (string, error) {
+	if name >= uint64(len(symbols)) || value >= uint64(len(symbols)) {
+		return "", fmt.Errorf("unknown symbol %d=%d, only %d symbols", name, value, len(symbols))
+	}
+	return symbols[name] + "=" + symbols[value], nil
+}
+
+func parseLokiBlock(format byte, compression Encoding, data []byte, symbols []string) ([]byte, []LokiEntry, error) {
+	decompressed, err := decompress(compression, data)
 	origDecompressed := decompressed
 	if err != nil {
 		return nil, nil, err
@@ -209,12 +283,135 @@ func parseLokiBlock(compression Encoding, data []byte) ([]byte, []LokiEntry, err
 			return origDecompressed, nil, fmt.Errorf("not enough line data, need %d, got %d", lineLength, len(decompressed))
 		}
 
-		entries = append(entries, LokiEntry{
+		entry := LokiEntry{
 			timestamp: timestamp,
 			line:      string(decompressed[0:lineLength]),
-		})
-
+		}
 		decompressed = decompressed[lineLength:]
+
+		if format >= chunkFormatV4 {
+			var symbolsLength, symbolsCount uint64
+			symbolsLength, decompressed, err = readUvarint(err, decompressed)
+			symbolsCount, decompressed, err = readUvarint(err, decompressed)
+			for i := uint64(0); i < symbolsCount && err == nil; i++ {
+				var name, value uint64
+				name, decompressed, err = readUvarint(err, decompressed)
+				value, decompressed, err = readUvarint(err, decompressed)
+				if err == nil {
+					var pair string
+					pair, err = lookupSymbols(symbols, name, value)
+					entry.structuredMetadata = append(entry.structuredMetadata, pair)
+				}
+			}
+			if err != nil {
+				return origDecompressed, nil, fmt.Errorf("failed to read structured metadata (%d bytes): %w", symbolsLength, err)
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return origDecompressed, entries, nil
+}
+
+func parseColumnarLokiBlock(compression Encoding, data []byte, symbols []string) ([]byte, []LokiEntry, error) {
+	/* Columnar block format (V5 chunks)
+
+	Uvarint # entries
+	Uvarint # columns
+	Column1 1B kind
+	Column1 Uvarint name symbol (structured metadata columns only)
+	Column1 Uvarint compressed length
+	Column1 Uvarint uncompressed length
+	...
+	Column1 compressed data
+	...
+	*/
+
+	type column struct {
+		kind              byte
+		name              uint64
+		length, uncompLen uint64
+	}
+
+	var (
+		err                error
+		numEntries, numCol uint64
+	)
+	numEntries, data, err = readUvarint(err, data)
+	numCol, data, err = readUvarint(err, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if numCol > uint64(len(data)) {
+		return nil, nil, fmt.Errorf("invalid number of columns: %d", numCol)
+	}
+
+	columns := make([]column, numCol)
+	for i := range columns {
+		if len(data) == 0 {
+			return nil, nil, fmt.Errorf("not enough column data")
+		}
+		columns[i].kind, data = data[0], data[1:]
+		if columns[i].kind == columnStructuredMetadata {
+			columns[i].name, data, err = readUvarint(err, data)
+		}
+		columns[i].length, data, err = readUvarint(err, data)
+		columns[i].uncompLen, data, err = readUvarint(err, data)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var origDecompressed []byte
+	entries := make([]LokiEntry, numEntries)
+	for _, c := range columns {
+		if uint64(len(data)) < c.length {
+			return origDecompressed, nil, fmt.Errorf("not enough column data, need %d, got %d", c.length, len(data))
+		}
+		decompressed, err := decompress(compression, data[:c.length])
+		if err != nil {
+			return origDecompressed, nil, err
+		}
+		data = data[c.length:]
+		origDecompressed = append(origDecompressed, decompressed...)
+
+		var prevTs, prevDelta int64
+		for i := range entries {
+			switch c.kind {
+			case columnTimestamps:
+				var v int64
+				v, decompressed, err = readVarint(err, decompressed)
+				if i > 0 {
+					prevDelta += v
+					v = prevTs + prevDelta
+				}
+				entries[i].timestamp, prevTs = v, v
+			case columnLines:
+				var lineLength uint64
+				lineLength, decompressed, err = readUvarint(err, decompressed)
+				if err == nil && len(decompressed) < int(lineLength) {
+					err = fmt.Errorf("not enough line data, need %d, got %d", lineLength, len(decompressed))
+				}
+				if err == nil {
+					entries[i].line = string(decompressed[:lineLength])
+					decompressed = decompressed[lineLength:]
+				}
+			case columnStructuredMetadata:
+				var value uint64
+				value, decompressed, err = readUvarint(err, decompressed)
+				if err == nil && value > 0 {
+					var pair string
+					pair, err = lookupSymbols(symbols, c.name, value-1)
+					entries[i].structuredMetadata = append(entries[i].structuredMetadata, pair)
+				}
+			default:
+				err = fmt.Errorf("unknown column kind %d", c.kind)
+			}
+			if err != nil {
+				return origDecompressed, nil, err
+			}
+		}
 	}
 
 	return origDecompressed, entries, nil
@@ -67,6 +67,9 @@ func printFile(filename string, blockDetails, printLines, storeBlocks bool) {
 
 	fmt.Println("Format (Version):", lokiChunk.format)
 	fmt.Println("Encoding:", lokiChunk.encoding)
+	if lokiChunk.format >= chunkFormatV4 {
+		fmt.Println("Structured metadata symbols:", len(lokiChunk.symbols))
+	}
 	fmt.Print("Blocks Metadata Checksum: ", fmt.Sprintf("%08x", lokiChunk.metadataChecksum))
 	if lokiChunk.metadataChecksum == lokiChunk.computedMetadataChecksum {
 		fmt.Println(" OK")
@@ -108,6 +111,10 @@ func printFile(filename string, blockDetails, printLines, storeBlocks bool) {
 
 		if printLines {
 			for _, l := range b.entries {
+				if len(l.structuredMetadata) > 0 {
+					fmt.Printf("%v\t%s\t{%s}\n", time.Unix(0, l.timestamp).In(timezone).Format(format), strings.TrimSpace(l.line), strings.Join(l.structuredMetadata, ", "))
+					continue
+				}
 				fmt.Printf("%v\t%s\n", time.Unix(0, l.timestamp).In(timezone).Format(format), strings.TrimSpace(l.line))
 			}
 		}
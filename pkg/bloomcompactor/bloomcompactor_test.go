@@ -176,6 +176,10 @@ func (m mockLimits) BloomNGramSkip(_ string) int {
 	panic("implement me")
 }
 
+func (m mockLimits) BloomLowercaseNGrams(_ string) bool {
+	panic("implement me")
+}
+
 func (m mockLimits) BloomFalsePositiveRate(_ string) float64 {
 	panic("implement me")
 }
@@ -184,6 +188,10 @@ func (m mockLimits) BloomCompactorMaxBlockSize(_ string) int {
 	panic("implement me")
 }
 
+func (m mockLimits) BloomStructuredMetadataKeys(_ string) []string {
+	panic("implement me")
+}
+
 func TestTokenRangesForInstance(t *testing.T) {
 	desc := func(id int, tokens ...uint32) ring.InstanceDesc {
 		return ring.InstanceDesc{Id: fmt.Sprintf("%d", id), Tokens: tokens}
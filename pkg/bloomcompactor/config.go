@@ -81,6 +81,8 @@ type Limits interface {
 	BloomCompactorEnabled(tenantID string) bool
 	BloomNGramLength(tenantID string) int
 	BloomNGramSkip(tenantID string) int
+	BloomLowercaseNGrams(tenantID string) bool
 	BloomFalsePositiveRate(tenantID string) float64
 	BloomCompactorMaxBlockSize(tenantID string) int
+	BloomStructuredMetadataKeys(tenantID string) []string
 }
@@ -344,8 +344,10 @@ func (s *SimpleBloomController) buildGaps(
 		tsdbCt       = len(work)
 		nGramSize    = uint64(s.limits.BloomNGramLength(tenant))
 		nGramSkip    = uint64(s.limits.BloomNGramSkip(tenant))
+		lowercase    = s.limits.BloomLowercaseNGrams(tenant)
 		maxBlockSize = uint64(s.limits.BloomCompactorMaxBlockSize(tenant))
-		blockOpts    = v1.NewBlockOptions(nGramSize, nGramSkip, maxBlockSize)
+		metadataKeys = s.limits.BloomStructuredMetadataKeys(tenant)
+		blockOpts    = v1.NewBlockOptions(nGramSize, nGramSkip, maxBlockSize, metadataKeys, lowercase)
 		created      []bloomshipper.Meta
 		totalSeries  int
 		bytesAdded   int
@@ -84,7 +84,7 @@ func NewSimpleBloomGenerator(
 		metrics:      metrics,
 		reporter:     reporter,
 
-		tokenizer: v1.NewBloomTokenizer(opts.Schema.NGramLen(), opts.Schema.NGramSkip(), metrics.bloomMetrics),
+		tokenizer: v1.NewBloomTokenizer(opts.Schema.NGramLen(), opts.Schema.NGramSkip(), opts.Schema.LowercaseNGrams(), opts.Schema.StructuredMetadataKeys(), metrics.bloomMetrics),
 	}
 }
 
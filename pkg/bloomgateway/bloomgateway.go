@@ -218,10 +218,12 @@ func (g *Gateway) FilterChunkRefs(ctx context.Context, req *logproto.FilterChunk
 	}
 
 	filters := syntax.ExtractLineFilters(req.Plan.AST)
+	labelFilters := syntax.ExtractLabelFiltersBeforeParsers(req.Plan.AST)
 	g.metrics.receivedFilters.Observe(float64(len(filters)))
+	g.metrics.receivedLabelFilters.Observe(float64(len(labelFilters)))
 
 	// Shortcut if request does not contain filters
-	if len(filters) == 0 {
+	if len(filters) == 0 && len(labelFilters) == 0 {
 		return &logproto.FilterChunkRefResponse{
 			ChunkRefs: req.Refs,
 		}, nil
@@ -238,6 +240,7 @@ func (g *Gateway) FilterChunkRefs(ctx context.Context, req *logproto.FilterChunk
 
 	sp.LogKV(
 		"filters", len(filters),
+		"label_filters", len(labelFilters),
 		"days", len(seriesByDay),
 		"series_requested", len(req.Refs),
 	)
@@ -245,7 +248,7 @@ func (g *Gateway) FilterChunkRefs(ctx context.Context, req *logproto.FilterChunk
 	tasks := make([]Task, 0, len(seriesByDay))
 	responses := make([][]v1.Output, 0, len(seriesByDay))
 	for _, seriesForDay := range seriesByDay {
-		task, err := NewTask(ctx, tenantID, seriesForDay, filters)
+		task, err := NewTask(ctx, tenantID, seriesForDay, filters, labelFilters)
 		if err != nil {
 			return nil, err
 		}
@@ -332,6 +335,10 @@ func (g *Gateway) FilterChunkRefs(ctx context.Context, req *logproto.FilterChunk
 	g.metrics.requestedChunks.Observe(float64(preFilterChunks))
 	g.metrics.filteredChunks.Observe(float64(preFilterChunks - postFilterChunks))
 
+	filterTypes := filterTypesLabel(len(filters), len(labelFilters))
+	g.metrics.requestedChunksByFilterType.WithLabelValues(filterTypes).Add(float64(preFilterChunks))
+	g.metrics.filteredChunksByFilterType.WithLabelValues(filterTypes).Add(float64(preFilterChunks - postFilterChunks))
+
 	level.Info(logger).Log(
 		"msg", "return filtered chunk refs",
 		"requested_series", preFilterSeries,
@@ -342,6 +349,20 @@ func (g *Gateway) FilterChunkRefs(ctx context.Context, req *logproto.FilterChunk
 	return &logproto.FilterChunkRefResponse{ChunkRefs: filtered}, nil
 }
 
+// filterTypesLabel returns the label value of the types of filters of a
+// request, so the share of the chunks filtered out for each type of filters
+// can be told apart.
+func filterTypesLabel(filters, labelFilters int) string {
+	switch {
+	case labelFilters == 0:
+		return filterTypeLine
+	case filters == 0:
+		return filterTypeStructuredMetadata
+	default:
+		return filterTypeLineAndStructuredMetadata
+	}
+}
+
 // consumeTask receives v1.Output yielded from the block querier on the task's
 // result channel and stores them on the task.
 // In case the context task is done, it drains the remaining items until the
@@ -483,8 +483,13 @@ func (s *mockServer) FilterChunkRefs(_ context.Context, _ *logproto.FilterChunkR
 }
 
 type mockLimits struct {
-	cacheFreshness time.Duration
-	cacheInterval  time.Duration
+	cacheFreshness         time.Duration
+	cacheInterval          time.Duration
+	structuredMetadataKeys []string
+}
+
+func (m mockLimits) BloomStructuredMetadataKeys(_ string) []string {
+	return m.structuredMetadataKeys
 }
 
 func (m mockLimits) MaxCacheFreshness(_ context.Context, _ string) time.Duration {
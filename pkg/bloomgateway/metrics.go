@@ -7,6 +7,13 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
+// label values of the types of filters of a request
+const (
+	filterTypeLine                      = "line"
+	filterTypeStructuredMetadata        = "structured_metadata"
+	filterTypeLineAndStructuredMetadata = "line_and_structured_metadata"
+)
+
 type metrics struct {
 	*workerMetrics
 	*serverMetrics
@@ -19,6 +26,10 @@ type serverMetrics struct {
 	requestedChunks  prometheus.Histogram
 	filteredChunks   prometheus.Histogram
 	receivedFilters  prometheus.Histogram
+
+	receivedLabelFilters        prometheus.Histogram
+	requestedChunksByFilterType *prometheus.CounterVec
+	filteredChunksByFilterType  *prometheus.CounterVec
 }
 
 func newMetrics(registerer prometheus.Registerer, namespace, subsystem string) *metrics {
@@ -74,6 +85,25 @@ func newServerMetrics(registerer prometheus.Registerer, namespace, subsystem str
 			Help:      "Number of filters per request.",
 			Buckets:   prometheus.ExponentialBuckets(1, 2, 9), // 1 -> 256
 		}),
+		receivedLabelFilters: promauto.With(registerer).NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "request_label_filters",
+			Help:      "Number of label filters per request tested against the structured metadata of the blooms.",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 9), // 1 -> 256
+		}),
+		requestedChunksByFilterType: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "requested_chunks_by_filter_type_total",
+			Help:      "Total amount of chunk refs sent to bloom-gateway for querying, by the types of filters of the requests.",
+		}, []string{"filter_type"}),
+		filteredChunksByFilterType: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "filtered_chunks_by_filter_type_total",
+			Help:      "Total amount of chunk refs filtered out by bloom-gateway, by the types of filters of the requests. The chunk refs kept by the blooms which match no entries aren't measured.",
+		}, []string{"filter_type"}),
 	}
 }
 
@@ -31,7 +31,7 @@ func TestTask(t *testing.T) {
 			},
 		}
 		swb := partitionRequest(req)[0]
-		task, err := NewTask(context.Background(), "tenant", swb, nil)
+		task, err := NewTask(context.Background(), "tenant", swb, nil, nil)
 		require.NoError(t, err)
 		from, through := task.Bounds()
 		require.Equal(t, ts.Add(-1*time.Hour), from)
@@ -45,7 +45,7 @@ func createTasksForRequests(t *testing.T, tenant string, requests ...*logproto.F
 	tasks := make([]Task, 0, len(requests))
 	for _, r := range requests {
 		for _, swb := range partitionRequest(r) {
-			task, err := NewTask(context.Background(), tenant, swb, nil)
+			task, err := NewTask(context.Background(), tenant, swb, nil, nil)
 			require.NoError(t, err)
 			tasks = append(tasks, task)
 		}
@@ -63,8 +63,8 @@ func TestTask_RequestIterator(t *testing.T) {
 			interval: bloomshipper.Interval{Start: 0, End: math.MaxInt64},
 			series:   []*logproto.GroupedChunkRefs{},
 		}
-		task, _ := NewTask(context.Background(), tenant, swb, []syntax.LineFilterExpr{})
-		it := task.RequestIter(tokenizer)
+		task, _ := NewTask(context.Background(), tenant, swb, []syntax.LineFilterExpr{}, nil)
+		it := task.RequestIter(tokenizer, v1.Schema{})
 		// nothing to iterate over
 		require.False(t, it.Next())
 	})
@@ -107,7 +107,7 @@ func TestTask_RequestIterator(t *testing.T) {
 
 		iters := make([]v1.PeekingIterator[v1.Request], 0, len(tasks))
 		for _, task := range tasks {
-			iters = append(iters, v1.NewPeekingIter(task.RequestIter(tokenizer)))
+			iters = append(iters, v1.NewPeekingIter(task.RequestIter(tokenizer, v1.Schema{})))
 		}
 
 		// merge the request iterators using the heap sort iterator
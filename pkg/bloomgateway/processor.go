@@ -150,7 +150,7 @@ func (p *processor) processBlock(_ context.Context, blockQuerier *v1.BlockQuerie
 		blk := bloomshipper.BlockRefFrom(task.Tenant, task.table.String(), md)
 		sp.LogKV("block", blk.String())
 
-		it := v1.NewPeekingIter(task.RequestIter(tokenizer))
+		it := v1.NewPeekingIter(task.RequestIter(tokenizer, schema))
 		iters = append(iters, it)
 	}
 
@@ -12,6 +12,7 @@ import (
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/logql/syntax"
 	"github.com/grafana/loki/pkg/querier/plan"
+	v1 "github.com/grafana/loki/pkg/storage/bloom/v1"
 	"github.com/grafana/loki/pkg/util/constants"
 )
 
@@ -51,29 +52,47 @@ func newQuerierMetrics(registerer prometheus.Registerer, namespace, subsystem st
 	}
 }
 
+// QuerierLimits are the limits of the BloomQuerier.
+type QuerierLimits interface {
+	BloomStructuredMetadataKeys(tenantID string) []string
+}
+
 // BloomQuerier is a store-level abstraction on top of Client
 // It is used by the index gateway to filter ChunkRefs based on given line fiter expression.
 type BloomQuerier struct {
 	c       Client
+	limits  QuerierLimits
 	logger  log.Logger
 	metrics *querierMetrics
 }
 
-func NewQuerier(c Client, r prometheus.Registerer, logger log.Logger) *BloomQuerier {
+func NewQuerier(c Client, limits QuerierLimits, r prometheus.Registerer, logger log.Logger) *BloomQuerier {
 	return &BloomQuerier{
 		c:       c,
+		limits:  limits,
 		metrics: newQuerierMetrics(r, constants.Loki, querierMetricsSubsystem),
 		logger:  logger,
 	}
 }
 
+// FiltersChunks returns whether the blooms can filter the chunks of the
+// query: it has line filters, or label filters before any parser on the
+// structured metadata keys added to the blooms of the tenant.
+func (bq *BloomQuerier) FiltersChunks(tenant string, queryPlan plan.QueryPlan) bool {
+	if len(syntax.ExtractLineFilters(queryPlan.AST)) > 0 {
+		return true
+	}
+	keys := bq.limits.BloomStructuredMetadataKeys(tenant)
+	return len(keys) > 0 && v1.LabelFiltersOnKeys(keys, syntax.ExtractLabelFiltersBeforeParsers(queryPlan.AST)...)
+}
+
 func convertToShortRef(ref *logproto.ChunkRef) *logproto.ShortRef {
 	return &logproto.ShortRef{From: ref.From, Through: ref.Through, Checksum: ref.Checksum}
 }
 
 func (bq *BloomQuerier) FilterChunkRefs(ctx context.Context, tenant string, from, through model.Time, chunkRefs []*logproto.ChunkRef, queryPlan plan.QueryPlan) ([]*logproto.ChunkRef, error) {
 	// Shortcut that does not require any filtering
-	if len(chunkRefs) == 0 || len(syntax.ExtractLineFilters(queryPlan.AST)) == 0 {
+	if len(chunkRefs) == 0 || !bq.FiltersChunks(tenant, queryPlan) {
 		return chunkRefs, nil
 	}
 
@@ -32,7 +32,7 @@ func TestBloomQuerier(t *testing.T) {
 
 	t.Run("client not called when filters are empty", func(t *testing.T) {
 		c := &noopClient{}
-		bq := NewQuerier(c, nil, logger)
+		bq := NewQuerier(c, mockLimits{}, nil, logger)
 
 		ctx := context.Background()
 		through := model.Now()
@@ -52,7 +52,7 @@ func TestBloomQuerier(t *testing.T) {
 
 	t.Run("client not called when chunkRefs are empty", func(t *testing.T) {
 		c := &noopClient{}
-		bq := NewQuerier(c, nil, logger)
+		bq := NewQuerier(c, mockLimits{}, nil, logger)
 
 		ctx := context.Background()
 		through := model.Now()
@@ -66,9 +66,37 @@ func TestBloomQuerier(t *testing.T) {
 		require.Equal(t, 0, c.callCount)
 	})
 
+	t.Run("client called only for label filters on the structured metadata keys", func(t *testing.T) {
+		ctx := context.Background()
+		through := model.Now()
+		from := through.Add(-12 * time.Hour)
+		chunkRefs := []*logproto.ChunkRef{
+			{Fingerprint: 3000, UserID: tenant, Checksum: 1},
+			{Fingerprint: 1000, UserID: tenant, Checksum: 2},
+		}
+		for _, tc := range []struct {
+			query     string
+			keys      []string
+			callCount int
+		}{
+			{query: `{foo="bar"} | trace_id="abc"`},
+			{query: `{foo="bar"} | level="error"`, keys: []string{"trace_id"}},
+			{query: `{foo="bar"} | trace_id="abc"`, keys: []string{"trace_id"}, callCount: 1},
+		} {
+			c := &noopClient{}
+			bq := NewQuerier(c, mockLimits{structuredMetadataKeys: tc.keys}, nil, logger)
+
+			expr, err := syntax.ParseExpr(tc.query)
+			require.NoError(t, err)
+			_, err = bq.FilterChunkRefs(ctx, tenant, from, through, chunkRefs, plan.QueryPlan{AST: expr})
+			require.NoError(t, err)
+			require.Equal(t, tc.callCount, c.callCount, tc.query)
+		}
+	})
+
 	t.Run("querier propagates error from client", func(t *testing.T) {
 		c := &noopClient{err: errors.New("something went wrong")}
-		bq := NewQuerier(c, nil, logger)
+		bq := NewQuerier(c, mockLimits{}, nil, logger)
 
 		ctx := context.Background()
 		through := model.Now()
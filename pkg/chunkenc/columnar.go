@@ -0,0 +1,426 @@
+package chunkenc
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql/log"
+	"github.com/grafana/loki/pkg/logqlmodel/stats"
+)
+
+/* Columnar block format, used by ChunkFormatV5 chunks.
+
+The entries of a block are stored in columns, each compressed on its own with
+the encoding of the chunk, so reading the timestamps or the structured metadata
+of the entries doesn't require decompressing their lines.
+
+Uvarint # entries
+Uvarint # columns
+Column 1 kind (1 byte)
+Column 1 Uvarint name symbol (structured metadata columns only)
+Column 1 Uvarint compressed length
+Column 1 Uvarint uncompressed length
+...
+Column 1 compressed data
+...
+
+Timestamps column: Varint first timestamp, then Varint delta-of-delta of the
+following timestamps.
+Lines column: Uvarint length and bytes of each line.
+Structured metadata column: Uvarint value symbol + 1 of each entry, 0 when the
+entry has no structured metadata with the name of the column.
+
+There is one structured metadata column per name, or more when an entry has
+several structured metadata with the same name. The columns are sorted by name,
+so the structured metadata of the entries are read back sorted by name.
+*/
+
+const (
+	columnTimestamps byte = iota + 1
+	columnLines
+	columnStructuredMetadata
+)
+
+// columnKey identifies the structured metadata column of the nth structured
+// metadata with a given name of the entries.
+type columnKey struct {
+	name uint32
+	nth  int
+}
+
+type columnBuilder struct {
+	key  columnKey
+	kind byte
+	buf  bytes.Buffer
+}
+
+// serialiseColumns creates a columnar, compressed block from a head block.
+func serialiseColumns(head HeadBlock, symbolizer *symbolizer, pool WriterPool) ([]byte, error) {
+	var (
+		timestamps []int64
+		lines      = &columnBuilder{kind: columnLines}
+		metadata   = map[columnKey][]uint32{}
+		encBuf     = make([]byte, binary.MaxVarintLen64)
+	)
+	add := func(ts int64, line string, structuredMetadataSymbols symbols) {
+		n := binary.PutUvarint(encBuf, uint64(len(line)))
+		lines.buf.Write(encBuf[:n])
+		lines.buf.WriteString(line)
+
+		var nth map[uint32]int
+		for _, s := range structuredMetadataSymbols {
+			key := columnKey{name: s.Name}
+			if nth == nil {
+				nth = map[uint32]int{}
+			}
+			key.nth = nth[s.Name]
+			nth[s.Name]++
+
+			values := metadata[key]
+			for len(values) < len(timestamps) {
+				values = append(values, 0)
+			}
+			metadata[key] = append(values, s.Value+1)
+		}
+		timestamps = append(timestamps, ts)
+	}
+
+	switch hb := head.(type) {
+	case *unorderedHeadBlock:
+		_ = hb.forEntries(context.Background(), logproto.FORWARD, 0, math.MaxInt64, func(_ *stats.Context, ts int64, line string, structuredMetadataSymbols symbols) error {
+			add(ts, line, structuredMetadataSymbols)
+			return nil
+		})
+	case *headBlock:
+		for _, e := range hb.entries {
+			add(e.t, e.s, symbolizer.Add(e.structuredMetadata))
+		}
+	default:
+		return nil, fmt.Errorf("unsupported head block %T", head)
+	}
+
+	ts := &columnBuilder{kind: columnTimestamps}
+	var prevTs, prevDelta int64
+	for i, t := range timestamps {
+		v := t
+		if i > 0 {
+			delta := t - prevTs
+			v = delta - prevDelta
+			prevDelta = delta
+		}
+		prevTs = t
+		n := binary.PutVarint(encBuf, v)
+		ts.buf.Write(encBuf[:n])
+	}
+
+	columns := make([]*columnBuilder, 0, len(metadata)+2)
+	columns = append(columns, ts, lines)
+	for key, values := range metadata {
+		c := &columnBuilder{kind: columnStructuredMetadata, key: key}
+		for i := 0; i < len(timestamps); i++ {
+			var v uint32
+			if i < len(values) {
+				v = values[i]
+			}
+			n := binary.PutUvarint(encBuf, uint64(v))
+			c.buf.Write(encBuf[:n])
+		}
+		columns = append(columns, c)
+	}
+	sortedMetadata := columns[2:]
+	sort.Slice(sortedMetadata, func(i, j int) bool {
+		a, b := sortedMetadata[i].key, sortedMetadata[j].key
+		if a.name != b.name {
+			return symbolizer.lookup(a.name) < symbolizer.lookup(b.name)
+		}
+		return a.nth < b.nth
+	})
+
+	eb := EncodeBufferPool.Get().(*encbuf)
+	defer EncodeBufferPool.Put(eb)
+	eb.reset()
+	eb.putUvarint(len(timestamps))
+	eb.putUvarint(len(columns))
+
+	compressed := make([][]byte, 0, len(columns))
+	size := 0
+	for _, c := range columns {
+		b, err := compressColumn(pool, c.buf.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		compressed = append(compressed, b)
+		size += len(b)
+
+		eb.putByte(c.kind)
+		if c.kind == columnStructuredMetadata {
+			eb.putUvarint(int(c.key.name))
+		}
+		eb.putUvarint(len(b))
+		eb.putUvarint(c.buf.Len())
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(eb.get())+size))
+	out.Write(eb.get())
+	for _, b := range compressed {
+		out.Write(b)
+	}
+	return out.Bytes(), nil
+}
+
+func compressColumn(pool WriterPool, b []byte) ([]byte, error) {
+	var out bytes.Buffer
+	w := pool.GetWriter(&out)
+	defer pool.PutWriter(w)
+	if _, err := w.Write(b); err != nil {
+		return nil, errors.Wrap(err, "appending column")
+	}
+	if err := w.Close(); err != nil {
+		return nil, errors.Wrap(err, "flushing pending compress buffer")
+	}
+	return out.Bytes(), nil
+}
+
+// column is a column of a columnar block, read entry by entry once
+// decompressed.
+type column struct {
+	kind                   byte
+	name                   uint32
+	b                      []byte // the compressed data.
+	size, uncompressedSize int
+
+	buf []byte // the decompressed data, from columnBufferPool.
+	db  decbuf // the decompressed data left to read.
+}
+
+func (c *column) decompress(pool ReaderPool) error {
+	r, err := pool.GetReader(bytes.NewReader(c.b))
+	if err != nil {
+		return err
+	}
+	defer pool.PutReader(r)
+
+	c.buf = columnBufferPool.Get(c.uncompressedSize).([]byte)[:c.uncompressedSize]
+	if _, err := io.ReadFull(r, c.buf); err != nil {
+		return errors.Wrap(err, "decompressing column")
+	}
+	c.db = decbuf{b: c.buf}
+	return nil
+}
+
+func (c *column) release() {
+	if c.buf != nil {
+		columnBufferPool.Put(c.buf[:0])
+		c.buf = nil
+	}
+}
+
+// columnarReader reads the entries of a columnar block. The lines column is
+// only decompressed once a line is read.
+type columnarReader struct {
+	pool ReaderPool
+
+	numEntries int
+	next       int // index of the next entry.
+
+	timestamps         column
+	prevTs, prevDelta  int64
+	lines              column
+	structuredMetadata []column
+}
+
+func newColumnarReader(pool ReaderPool, b []byte) (*columnarReader, error) {
+	db := decbuf{b: b}
+	r := &columnarReader{pool: pool, numEntries: db.uvarint()}
+	numColumns := db.uvarint()
+	if err := db.err(); err != nil {
+		return nil, errors.Wrap(err, "reading columns")
+	}
+	if numColumns > len(db.b) {
+		return nil, fmt.Errorf("invalid number of columns %d", numColumns)
+	}
+
+	columns := make([]column, numColumns)
+	for i := range columns {
+		columns[i].kind = db.byte()
+		if columns[i].kind == columnStructuredMetadata {
+			columns[i].name = uint32(db.uvarint())
+		}
+		columns[i].size = db.uvarint()
+		columns[i].uncompressedSize = db.uvarint()
+		if err := db.err(); err != nil {
+			return nil, errors.Wrap(err, "reading columns")
+		}
+		// Each entry takes at least one byte in every column.
+		if columns[i].uncompressedSize < r.numEntries || columns[i].uncompressedSize >= maxLineLength {
+			return nil, fmt.Errorf("invalid column of %d bytes for %d entries", columns[i].uncompressedSize, r.numEntries)
+		}
+	}
+
+	var hasTimestamps, hasLines bool
+	data := db.b
+	for i := range columns {
+		c := columns[i]
+		if c.size > len(data) {
+			return nil, fmt.Errorf("column of %d bytes exceeds the block", c.size)
+		}
+		c.b, data = data[:c.size], data[c.size:]
+
+		switch c.kind {
+		case columnTimestamps:
+			hasTimestamps = true
+			r.timestamps = c
+		case columnLines:
+			hasLines = true
+			r.lines = c
+		case columnStructuredMetadata:
+			r.structuredMetadata = append(r.structuredMetadata, c)
+		default:
+			return nil, fmt.Errorf("invalid column kind %d", c.kind)
+		}
+	}
+	if !hasTimestamps || !hasLines {
+		return nil, fmt.Errorf("invalid columnar block")
+	}
+
+	if err := r.timestamps.decompress(pool); err != nil {
+		r.release()
+		return nil, err
+	}
+	for i := range r.structuredMetadata {
+		if err := r.structuredMetadata[i].decompress(pool); err != nil {
+			r.release()
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// timestamp returns the timestamp of the next entry.
+func (r *columnarReader) timestamp() (int64, error) {
+	ts := r.timestamps.db.varint64()
+	if r.next > 0 {
+		r.prevDelta += ts
+		ts = r.prevTs + r.prevDelta
+	}
+	r.prevTs = ts
+	return ts, r.timestamps.db.err()
+}
+
+// line returns the line of the next entry.
+func (r *columnarReader) line() ([]byte, error) {
+	if r.lines.buf == nil {
+		if err := r.lines.decompress(r.pool); err != nil {
+			return nil, err
+		}
+	}
+	line := r.lines.db.bytes(r.lines.db.uvarint())
+	return line, r.lines.db.err()
+}
+
+func (r *columnarReader) release() {
+	r.timestamps.release()
+	r.lines.release()
+	for i := range r.structuredMetadata {
+		r.structuredMetadata[i].release()
+	}
+}
+
+// moveNextColumns moves the columns to the next entry. The line is nil when
+// the iterator skips the lines.
+func (si *bufferedIterator) moveNextColumns() (int64, []byte, labels.Labels, bool) {
+	if si.columns == nil {
+		r, err := newColumnarReader(si.pool, si.origBytes)
+		if err != nil {
+			si.err = err
+			return 0, nil, nil, false
+		}
+		si.columns = r
+	}
+	r := si.columns
+	if r.next >= r.numEntries {
+		return 0, nil, nil, false
+	}
+
+	ts, err := r.timestamp()
+	if err != nil {
+		si.err = errors.Wrap(err, "reading timestamps")
+		return 0, nil, nil, false
+	}
+	decompressedBytes := int64(binary.MaxVarintLen64)
+
+	var line []byte
+	if !si.skipLines {
+		if line, err = r.line(); err != nil {
+			si.err = errors.Wrap(err, "reading lines")
+			return 0, nil, nil, false
+		}
+		decompressedBytes += binary.MaxVarintLen64 + int64(len(line))
+	}
+
+	si.symbolsBuf = si.symbolsBuf[:0]
+	for i := range r.structuredMetadata {
+		c := &r.structuredMetadata[i]
+		v := c.db.uvarint()
+		if err := c.db.err(); err != nil {
+			si.err = errors.Wrap(err, "reading structured metadata")
+			return 0, nil, nil, false
+		}
+		if v > 0 {
+			si.symbolsBuf = append(si.symbolsBuf, symbol{Name: c.name, Value: uint32(v - 1)})
+		}
+	}
+	decompressedStructuredMetadataBytes := int64(binary.MaxVarintLen64 + len(si.symbolsBuf)*2*binary.MaxVarintLen64)
+
+	r.next++
+
+	si.stats.AddDecompressedLines(1)
+	si.stats.AddDecompressedStructuredMetadataBytes(decompressedStructuredMetadataBytes)
+	si.stats.AddDecompressedBytes(decompressedBytes + decompressedStructuredMetadataBytes)
+
+	return ts, line, si.symbolizer.Lookup(si.symbolsBuf), true
+}
+
+// withoutLinesPipeline processes the entries with empty lines, so the blocks
+// of columnar chunks are read without decompressing their lines.
+type withoutLinesPipeline struct {
+	log.StreamPipeline
+}
+
+func (p withoutLinesPipeline) Process(ts int64, _ []byte, structuredMetadata ...labels.Label) ([]byte, log.LabelsResult, bool) {
+	return p.StreamPipeline.Process(ts, nil, structuredMetadata...)
+}
+
+func (p withoutLinesPipeline) ProcessString(ts int64, _ string, structuredMetadata ...labels.Label) (string, log.LabelsResult, bool) {
+	return p.StreamPipeline.ProcessString(ts, "", structuredMetadata...)
+}
+
+// withoutLinesExtractor extracts the samples of the entries with empty lines,
+// so the blocks of columnar chunks are read without decompressing their lines.
+type withoutLinesExtractor struct {
+	log.StreamSampleExtractor
+}
+
+// WithoutLines returns an extractor reading the samples of the entries without
+// their lines, for the extractors whose samples don't depend on the lines,
+// e.g. counting the entries matching label filters.
+func WithoutLines(extractor log.StreamSampleExtractor) log.StreamSampleExtractor {
+	return withoutLinesExtractor{extractor}
+}
+
+func (e withoutLinesExtractor) Process(ts int64, _ []byte, structuredMetadata ...labels.Label) (float64, log.LabelsResult, bool) {
+	return e.StreamSampleExtractor.Process(ts, nil, structuredMetadata...)
+}
+
+func (e withoutLinesExtractor) ProcessString(ts int64, _ string, structuredMetadata ...labels.Label) (float64, log.LabelsResult, bool) {
+	return e.StreamSampleExtractor.ProcessString(ts, "", structuredMetadata...)
+}
@@ -0,0 +1,184 @@
+package chunkenc
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/chunkenc/testdata"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logqlmodel/stats"
+)
+
+func TestColumnarBlock(t *testing.T) {
+	for _, enc := range testEncoding {
+		t.Run(enc.String(), func(t *testing.T) {
+			c := NewMemChunk(ChunkFormatV5, enc, UnorderedWithStructuredMetadataHeadBlockFmt, testBlockSize, testTargetSize)
+			for _, e := range []*logproto.Entry{
+				logprotoEntryWithStructuredMetadata(30, "third", []logproto.LabelAdapter{{Name: "trace_id", Value: "3"}, {Name: "app", Value: "b"}}),
+				logprotoEntryWithStructuredMetadata(10, "first", []logproto.LabelAdapter{{Name: "app", Value: "a"}}),
+				logprotoEntryWithStructuredMetadata(20, "", nil),
+				logprotoEntryWithStructuredMetadata(25, "fourth", []logproto.LabelAdapter{{Name: "trace_id", Value: "4"}}),
+			} {
+				require.NoError(t, c.Append(e))
+			}
+			require.NoError(t, c.Close())
+
+			b, err := c.Bytes()
+			require.NoError(t, err)
+			c, err = NewByteChunk(b, testBlockSize, testTargetSize)
+			require.NoError(t, err)
+			require.Equal(t, ChunkFormatV5, c.format)
+
+			expected := []logproto.Entry{
+				{Timestamp: time.Unix(0, 10), Line: "first", StructuredMetadata: []logproto.LabelAdapter{{Name: "app", Value: "a"}}},
+				{Timestamp: time.Unix(0, 20), Line: ""},
+				{Timestamp: time.Unix(0, 25), Line: "fourth", StructuredMetadata: []logproto.LabelAdapter{{Name: "trace_id", Value: "4"}}},
+				// The structured metadata are sorted by name.
+				{Timestamp: time.Unix(0, 30), Line: "third", StructuredMetadata: []logproto.LabelAdapter{{Name: "app", Value: "b"}, {Name: "trace_id", Value: "3"}}},
+			}
+			it, err := c.Iterator(context.Background(), time.Unix(0, 0), time.Unix(0, math.MaxInt64), logproto.FORWARD, noopStreamPipeline)
+			require.NoError(t, err)
+			require.Equal(t, expected, drainEntries(t, it))
+
+			for i := range expected {
+				expected[i].Line = ""
+			}
+			it, err = c.MetadataIterator(context.Background(), time.Unix(0, 0), time.Unix(0, math.MaxInt64), logproto.FORWARD, noopStreamPipeline)
+			require.NoError(t, err)
+			require.Equal(t, expected, drainEntries(t, it))
+		})
+	}
+}
+
+func TestColumnarBlockSkipsLines(t *testing.T) {
+	c := NewMemChunk(ChunkFormatV5, EncSnappy, UnorderedWithStructuredMetadataHeadBlockFmt, testBlockSize, testTargetSize)
+	fillChunk(c)
+
+	blk := c.Blocks(time.Unix(0, 0), time.Unix(0, math.MaxInt64))[0].(encBlock)
+	for _, tc := range []struct {
+		name      string
+		it        *entryBufferedIterator
+		skipLines bool
+	}{
+		{name: "lines", it: blk.Iterator(context.Background(), noopStreamPipeline).(*entryBufferedIterator)},
+		{name: "no lines", it: blk.Iterator(context.Background(), withoutLinesPipeline{noopStreamPipeline}).(*entryBufferedIterator), skipLines: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.True(t, tc.it.Next())
+			require.Equal(t, tc.skipLines, tc.it.columns.lines.buf == nil)
+			require.Equal(t, tc.skipLines, tc.it.Entry().Line == "")
+			require.NoError(t, tc.it.Close())
+		})
+	}
+}
+
+func TestColumnarBlockSamplesSkipLines(t *testing.T) {
+	c := NewMemChunk(ChunkFormatV5, EncSnappy, UnorderedWithStructuredMetadataHeadBlockFmt, testBlockSize, testTargetSize)
+	fillChunk(c)
+
+	blk := c.Blocks(time.Unix(0, 0), time.Unix(0, math.MaxInt64))[0].(encBlock)
+	for _, tc := range []struct {
+		name      string
+		it        *sampleBufferedIterator
+		skipLines bool
+	}{
+		{name: "lines", it: blk.SampleIterator(context.Background(), countExtractor).(*sampleBufferedIterator)},
+		{name: "no lines", it: blk.SampleIterator(context.Background(), WithoutLines(countExtractor)).(*sampleBufferedIterator), skipLines: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.True(t, tc.it.Next())
+			require.Equal(t, tc.skipLines, tc.it.columns.lines.buf == nil)
+			require.Equal(t, 1., tc.it.Sample().Value)
+			require.NoError(t, tc.it.Close())
+		})
+	}
+}
+
+func drainEntries(t *testing.T, it interface {
+	Next() bool
+	Entry() logproto.Entry
+	Error() error
+	Close() error
+}) []logproto.Entry {
+	var entries []logproto.Entry
+	for it.Next() {
+		entries = append(entries, it.Entry())
+	}
+	require.NoError(t, it.Error())
+	require.NoError(t, it.Close())
+	return entries
+}
+
+func BenchmarkColumnarRead(b *testing.B) {
+	for _, enc := range []Encoding{EncSnappy, EncZstd} {
+		for _, format := range []byte{ChunkFormatV4, ChunkFormatV5} {
+			c := NewMemChunk(format, enc, UnorderedWithStructuredMetadataHeadBlockFmt, testBlockSize, testTargetSize)
+			var size int64
+			for i := int64(0); ; i++ {
+				entry := logprotoEntryWithStructuredMetadata(i*int64(time.Millisecond), testdata.LogString(i), []logproto.LabelAdapter{
+					{Name: "level", Value: []string{"debug", "info", "warn", "error"}[i%4]},
+					{Name: "trace_id", Value: fmt.Sprintf("%016x", i/10)},
+				})
+				if !c.SpaceFor(entry) {
+					break
+				}
+				require.NoError(b, c.Append(entry))
+				size += int64(len(entry.Line))
+			}
+			require.NoError(b, c.Close())
+
+			name := fmt.Sprintf("%s_v%d", enc, format)
+			ratio := float64(c.CompressedSize()) / float64(c.UncompressedSize()) * 100
+			for _, tc := range []struct {
+				name    string
+				iterate func(context.Context) error
+			}{
+				{name: "entries", iterate: func(ctx context.Context) error {
+					it, err := c.Iterator(ctx, time.Unix(0, 0), time.Unix(0, math.MaxInt64), logproto.FORWARD, noopStreamPipeline)
+					if err != nil {
+						return err
+					}
+					for it.Next() {
+						_ = it.Entry()
+					}
+					return it.Close()
+				}},
+				{name: "samples", iterate: func(ctx context.Context) error {
+					it := c.SampleIterator(ctx, time.Unix(0, 0), time.Unix(0, math.MaxInt64), countExtractor)
+					for it.Next() {
+						_ = it.Sample()
+					}
+					return it.Close()
+				}},
+				{name: "metadata", iterate: func(ctx context.Context) error {
+					it, err := c.MetadataIterator(ctx, time.Unix(0, 0), time.Unix(0, math.MaxInt64), logproto.FORWARD, noopStreamPipeline)
+					if err != nil {
+						return err
+					}
+					for it.Next() {
+						_ = it.Entry()
+					}
+					return it.Close()
+				}},
+			} {
+				b.Run(name+"_"+tc.name, func(b *testing.B) {
+					_, ctx := stats.NewContext(context.Background())
+					b.ReportAllocs()
+					b.ResetTimer()
+					for n := 0; n < b.N; n++ {
+						if err := tc.iterate(ctx); err != nil {
+							b.Fatal(err)
+						}
+					}
+					b.SetBytes(size)
+					b.ReportMetric(ratio, "%compressed")
+				})
+			}
+		}
+	}
+}
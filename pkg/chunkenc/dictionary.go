@@ -0,0 +1,222 @@
+package chunkenc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/grafana/loki/pkg/storage/chunk"
+)
+
+// Dictionary is a zstd dictionary the blocks of chunks can be compressed
+// with. Small blocks compress poorly on their own, a dictionary trained from
+// the logs of the tenant provides the context they are missing.
+//
+// The chunks compressed with a dictionary have the EncZstdDict encoding, and
+// reference the dictionary by ID in their header: the dictionary has to be
+// in the Dictionaries they are read with.
+type Dictionary struct {
+	ZstdPool
+	id uint32
+}
+
+// ID returns the ID of the dictionary, which is unique across tenants.
+func (d *Dictionary) ID() uint32 {
+	return d.id
+}
+
+// Bytes returns the dictionary in the zstd dictionary format.
+func (d *Dictionary) Bytes() []byte {
+	return d.dict
+}
+
+// DictionaryFetcher fetches the dictionary with the given ID.
+type DictionaryFetcher func(id uint32) ([]byte, error)
+
+// Dictionaries are the dictionaries the chunks compressed with can be read
+// with. The dictionaries not registered yet are fetched with the fetcher, if
+// any, and registered once fetched.
+type Dictionaries struct {
+	mtx     sync.RWMutex
+	byID    map[uint32]*Dictionary
+	fetcher DictionaryFetcher
+	fetches singleflight.Group
+}
+
+func NewDictionaries(fetcher DictionaryFetcher) *Dictionaries {
+	return &Dictionaries{
+		byID:    map[uint32]*Dictionary{},
+		fetcher: fetcher,
+	}
+}
+
+// NewDictionary parses a dictionary in the zstd dictionary format without
+// registering it.
+func NewDictionary(b []byte) (*Dictionary, error) {
+	d, err := zstd.InspectDictionary(b)
+	if err != nil {
+		return nil, fmt.Errorf("invalid zstd dictionary: %w", err)
+	}
+	return &Dictionary{ZstdPool: ZstdPool{dict: b}, id: d.ID()}, nil
+}
+
+// Register registers a dictionary in the zstd dictionary format, so the
+// chunks compressed with it can be read. Registering a dictionary with the
+// ID of a registered one returns the registered dictionary.
+func (ds *Dictionaries) Register(b []byte) (*Dictionary, error) {
+	d, err := NewDictionary(b)
+	if err != nil {
+		return nil, err
+	}
+
+	ds.mtx.Lock()
+	defer ds.mtx.Unlock()
+	if registered, ok := ds.byID[d.id]; ok {
+		return registered, nil
+	}
+	ds.byID[d.id] = d
+	return d, nil
+}
+
+// Get returns the dictionary with the given ID, fetching and registering it
+// if it isn't registered yet. Getting a dictionary from nil Dictionaries
+// always fails.
+func (ds *Dictionaries) Get(id uint32) (*Dictionary, error) {
+	if ds == nil {
+		return nil, fmt.Errorf("unknown zstd dictionary %d", id)
+	}
+
+	ds.mtx.RLock()
+	d, ok := ds.byID[id]
+	ds.mtx.RUnlock()
+	if ok {
+		return d, nil
+	}
+	if ds.fetcher == nil {
+		return nil, fmt.Errorf("unknown zstd dictionary %d", id)
+	}
+
+	v, err, _ := ds.fetches.Do(fmt.Sprint(id), func() (interface{}, error) {
+		b, err := ds.fetcher(id)
+		if err != nil {
+			return nil, fmt.Errorf("fetching zstd dictionary %d: %w", id, err)
+		}
+		d, err := ds.Register(b)
+		if err != nil {
+			return nil, err
+		}
+		if d.id != id {
+			return nil, fmt.Errorf("fetched zstd dictionary %d has ID %d", id, d.id)
+		}
+		return d, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*Dictionary), nil
+}
+
+// NewData creates the data of the chunks with the given encoding, reading
+// the Loki chunks with the dictionaries. It is meant to be given to
+// chunk.DecodeContext.WithData.
+func (ds *Dictionaries) NewData(enc chunk.Encoding) (chunk.Data, error) {
+	switch enc {
+	case GzipLogChunk, LogChunk:
+		return &Facade{dictionaries: ds}, nil
+	default:
+		return chunk.NewForEncoding(enc)
+	}
+}
+
+const (
+	// dictionaryPrefixLen is the length of the prefix of the lines grouped
+	// together when training a dictionary.
+	dictionaryPrefixLen = 16
+	// dictionaryContentSize is the size of the contents the entropy tables
+	// of a dictionary are trained with, which is the size of a small block.
+	dictionaryContentSize = 16 << 10
+)
+
+// TrainDictionary trains a zstd dictionary of at most size bytes with the
+// given ID from sampled log lines.
+//
+// The lines are grouped by prefix, which is where the templates of the lines
+// usually are, and the history of the dictionary is made of one line of each
+// of the largest groups. The largest groups are at the end of the history,
+// where the offsets are the cheapest to encode.
+func TrainDictionary(id uint32, lines [][]byte, size int) (_ []byte, err error) {
+	// zstd.BuildDict divides by zero when the contents have too few
+	// sequences.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("not enough samples to train a dictionary: %v", r)
+		}
+	}()
+
+	type group struct {
+		line  []byte
+		count int
+	}
+	groups := map[string]*group{}
+	for _, l := range lines {
+		prefix := l
+		if len(prefix) > dictionaryPrefixLen {
+			prefix = prefix[:dictionaryPrefixLen]
+		}
+		g, ok := groups[string(prefix)]
+		if !ok {
+			g = &group{line: l}
+			groups[string(prefix)] = g
+		}
+		g.count++
+	}
+	sorted := make([]*group, 0, len(groups))
+	for _, g := range groups {
+		sorted = append(sorted, g)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].count != sorted[j].count {
+			return sorted[i].count > sorted[j].count
+		}
+		return bytes.Compare(sorted[i].line, sorted[j].line) < 0
+	})
+
+	var n int
+	for ; n < len(sorted) && len(sorted[n].line) <= size; n++ {
+		size -= len(sorted[n].line)
+	}
+	history := make([]byte, 0, 1<<10)
+	for i := n - 1; i >= 0; i-- {
+		history = append(history, sorted[i].line...)
+	}
+	if len(history) < 8 {
+		return nil, errors.New("not enough samples to train a dictionary")
+	}
+
+	var contents [][]byte
+	var content []byte
+	for _, l := range lines {
+		content = append(content, l...)
+		if len(content) >= dictionaryContentSize {
+			contents = append(contents, content)
+			content = nil
+		}
+	}
+	if len(content) > 0 {
+		contents = append(contents, content)
+	}
+
+	return zstd.BuildDict(zstd.BuildDictOptions{
+		ID:       id,
+		Contents: contents,
+		History:  history,
+		// The default initial offsets of zstd.
+		Offsets: [3]int{1, 4, 8},
+		Level:   zstd.SpeedDefault,
+	})
+}
@@ -0,0 +1,150 @@
+package chunkenc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/chunkenc/testdata"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/storage/chunk"
+)
+
+func trainTestDictionary(t testing.TB, id uint32) []byte {
+	b, err := TrainDictionary(id, testdata.LogsBytes, 16<<10)
+	require.NoError(t, err)
+	return b
+}
+
+// fillSmallChunk fills a chunk with a few lines, as low volume streams do.
+func fillSmallChunk(t testing.TB, c *MemChunk) {
+	for i := int64(0); i < 20; i++ {
+		require.NoError(t, c.Append(logprotoEntry(i, testdata.LogString(i*7))))
+	}
+	require.NoError(t, c.Close())
+}
+
+func TestDictionaryChunk(t *testing.T) {
+	dictionaries := NewDictionaries(nil)
+	dict, err := dictionaries.Register(trainTestDictionary(t, 42))
+	require.NoError(t, err)
+	require.Equal(t, uint32(42), dict.ID())
+
+	for _, format := range []byte{ChunkFormatV3, ChunkFormatV4, ChunkFormatV5} {
+		t.Run(fmt.Sprintf("v%d", format), func(t *testing.T) {
+			headFmt := ChunkHeadFormatFor(format)
+			c := NewMemChunkWithDictionary(format, dict, headFmt, testBlockSize, testTargetSize)
+			fillSmallChunk(t, c)
+
+			plain := NewMemChunk(format, EncZstd, headFmt, testBlockSize, testTargetSize)
+			fillSmallChunk(t, plain)
+			require.Less(t, c.CompressedSize(), plain.CompressedSize())
+
+			b, err := c.Bytes()
+			require.NoError(t, err)
+			require.GreaterOrEqual(t, c.BytesSize(), len(b))
+
+			read, err := NewByteChunkWithDictionaries(b, testBlockSize, testTargetSize, dictionaries)
+			require.NoError(t, err)
+			require.Equal(t, EncZstdDict, read.Encoding())
+			require.Same(t, dict, read.Dictionary())
+
+			it, err := read.Iterator(context.Background(), time.Unix(0, 0), time.Unix(0, math.MaxInt64), logproto.FORWARD, noopStreamPipeline)
+			require.NoError(t, err)
+			entries := drainEntries(t, it)
+			require.Len(t, entries, 20)
+			for i, e := range entries {
+				require.Equal(t, testdata.LogString(int64(i)*7), e.Line)
+			}
+
+			rebound, err := read.Rebound(time.Unix(0, 5), time.Unix(0, 19), nil)
+			require.NoError(t, err)
+			require.Same(t, dict, rebound.(*MemChunk).Dictionary())
+			require.Equal(t, 15, rebound.Size())
+		})
+	}
+}
+
+func TestGetDictionary(t *testing.T) {
+	_, err := NewDictionaries(nil).Get(1)
+	require.Error(t, err)
+
+	stored := map[uint32][]byte{7: trainTestDictionary(t, 7), 8: trainTestDictionary(t, 9)}
+	var fetches int
+	dictionaries := NewDictionaries(func(id uint32) ([]byte, error) {
+		fetches++
+		b, ok := stored[id]
+		if !ok {
+			return nil, errors.New("not found")
+		}
+		return b, nil
+	})
+
+	for i := 0; i < 2; i++ {
+		dict, err := dictionaries.Get(7)
+		require.NoError(t, err)
+		require.Equal(t, uint32(7), dict.ID())
+	}
+	// The fetched dictionary is registered.
+	require.Equal(t, 1, fetches)
+
+	_, err = dictionaries.Get(1)
+	require.ErrorContains(t, err, "not found")
+	_, err = dictionaries.Get(8)
+	require.ErrorContains(t, err, "has ID 9")
+
+	// Chunks compressed with a dictionary can't be read without it.
+	dict, err := NewDictionary(trainTestDictionary(t, 10))
+	require.NoError(t, err)
+	c := NewMemChunkWithDictionary(ChunkFormatV4, dict, UnorderedWithStructuredMetadataHeadBlockFmt, testBlockSize, testTargetSize)
+	fillSmallChunk(t, c)
+	b, err := c.Bytes()
+	require.NoError(t, err)
+	_, err = NewByteChunkWithDictionaries(b, testBlockSize, testTargetSize, dictionaries)
+	require.ErrorContains(t, err, "fetching zstd dictionary 10")
+	_, err = NewByteChunk(b, testBlockSize, testTargetSize)
+	require.ErrorContains(t, err, "unknown zstd dictionary 10")
+}
+
+func TestDictionariesNewData(t *testing.T) {
+	dictionaries := NewDictionaries(nil)
+	dict, err := dictionaries.Register(trainTestDictionary(t, 43))
+	require.NoError(t, err)
+
+	c := NewMemChunkWithDictionary(ChunkFormatV4, dict, UnorderedWithStructuredMetadataHeadBlockFmt, testBlockSize, testTargetSize)
+	fillSmallChunk(t, c)
+	from, through := c.Bounds()
+	chk := chunk.NewChunk("fake", 0, labels.FromStrings("foo", "bar"), NewFacade(c, testBlockSize, testTargetSize), model.TimeFromUnixNano(from.UnixNano()), model.TimeFromUnixNano(through.UnixNano()))
+	require.NoError(t, chk.Encode())
+	b, err := chk.Encoded()
+	require.NoError(t, err)
+
+	// The chunk can't be decoded without the dictionaries.
+	decoded := chk
+	require.ErrorContains(t, decoded.Decode(chunk.NewDecodeContext(), b), "unknown zstd dictionary 43")
+
+	decoded = chk
+	require.NoError(t, decoded.Decode(chunk.NewDecodeContext().WithData(dictionaries.NewData), b))
+	require.Same(t, dict, decoded.Data.(*Facade).LokiChunk().(*MemChunk).Dictionary())
+}
+
+func TestTrainDictionary(t *testing.T) {
+	b, err := TrainDictionary(11, testdata.LogsBytes, 1<<10)
+	require.NoError(t, err)
+	dict, err := NewDictionary(b)
+	require.NoError(t, err)
+	require.Equal(t, uint32(11), dict.ID())
+	require.LessOrEqual(t, len(b), 1<<10+1<<9) // history and entropy tables
+
+	for _, lines := range [][][]byte{nil, {[]byte("short")}, {[]byte("a single line")}} {
+		_, err := TrainDictionary(12, lines, 1<<10)
+		require.Error(t, err)
+	}
+}
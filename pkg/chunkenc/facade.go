@@ -31,6 +31,8 @@ type Facade struct {
 	c          Chunk
 	blockSize  int
 	targetSize int
+	// dictionaries are the zstd dictionaries the chunk is decoded with.
+	dictionaries *Dictionaries
 	chunk.Data
 }
 
@@ -61,7 +63,7 @@ func (f Facade) Marshal(w io.Writer) error {
 // UnmarshalFromBuf implements chunk.Chunk.
 func (f *Facade) UnmarshalFromBuf(buf []byte) error {
 	var err error
-	f.c, err = NewByteChunk(buf, f.blockSize, f.targetSize)
+	f.c, err = NewByteChunkWithDictionaries(buf, f.blockSize, f.targetSize, f.dictionaries)
 	return err
 }
 
@@ -25,6 +25,10 @@ var (
 
 type errTooFarBehind struct {
 	cutoff time.Time
+
+	// lateness is how far behind the newest entry of its stream the entry is.
+	lateness time.Duration
+	window   time.Duration
 }
 
 func IsErrTooFarBehind(err error) bool {
@@ -36,8 +40,22 @@ func ErrTooFarBehind(cutoff time.Time) error {
 	return &errTooFarBehind{cutoff: cutoff}
 }
 
+// ErrEntryTooFarBehind returns the error of an entry with the given timestamp
+// rejected because it is older than the out-of-order window allows.
+func ErrEntryTooFarBehind(ts, highestTs time.Time, window time.Duration) error {
+	return &errTooFarBehind{
+		cutoff:   highestTs.Add(-window),
+		lateness: highestTs.Sub(ts),
+		window:   window,
+	}
+}
+
 func (m *errTooFarBehind) Error() string {
-	return "entry too far behind, oldest acceptable timestamp is: " + m.cutoff.Format(time.RFC3339)
+	msg := "entry too far behind, oldest acceptable timestamp is: " + m.cutoff.Format(time.RFC3339)
+	if m.lateness > 0 {
+		msg += fmt.Sprintf(", entry is %s behind the newest entry of the stream and the out-of-order window is %s", m.lateness, m.window)
+	}
+	return msg
 }
 
 func IsOutOfOrderErr(err error) bool {
@@ -60,6 +78,10 @@ const (
 	EncLZ4_4M
 	EncFlate
 	EncZstd
+	// EncZstdDict is zstd with a dictionary. It can't be configured but is
+	// used instead of EncZstd by the chunks created with a dictionary, see
+	// NewMemChunkWithDictionary.
+	EncZstdDict
 )
 
 var supportedEncoding = []Encoding{
@@ -96,6 +118,8 @@ func (e Encoding) String() string {
 		return "flate"
 	case EncZstd:
 		return "zstd"
+	case EncZstdDict:
+		return "zstd-dict"
 	default:
 		return "unknown"
 	}
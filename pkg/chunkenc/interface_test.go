@@ -31,7 +31,12 @@ func TestParseEncoding(t *testing.T) {
 }
 
 func TestIsOutOfOrderErr(t *testing.T) {
-	for _, err := range []error{ErrOutOfOrder, ErrTooFarBehind(time.Now())} {
+	for _, err := range []error{ErrOutOfOrder, ErrTooFarBehind(time.Now()), ErrEntryTooFarBehind(time.Unix(0, 0), time.Unix(3600, 0), time.Minute)} {
 		require.Equal(t, true, IsOutOfOrderErr(err))
 	}
 }
+
+func TestErrEntryTooFarBehind(t *testing.T) {
+	err := ErrEntryTooFarBehind(time.Unix(0, 0).UTC(), time.Unix(3600, 0).UTC(), 30*time.Minute)
+	require.EqualError(t, err, "entry too far behind, oldest acceptable timestamp is: 1970-01-01T00:30:00Z, entry is 1h0m0s behind the newest entry of the stream and the out-of-order window is 30m0s")
+}
@@ -32,6 +32,8 @@ const (
 	ChunkFormatV2
 	ChunkFormatV3
 	ChunkFormatV4
+	// ChunkFormatV5 stores the entries of the blocks in columns, see columnar.go.
+	ChunkFormatV5
 
 	blocksPerChunk = 10
 	maxLineLength  = 1024 * 1024 * 1024
@@ -135,6 +137,9 @@ type MemChunk struct {
 	encoding Encoding
 	headFmt  HeadBlockFmt
 
+	// dictionary is the zstd dictionary of the EncZstdDict encoding.
+	dictionary *Dictionary
+
 	// compressed size of chunk. Set when chunk is cut or while decoding chunk from storage.
 	compressedSize int
 }
@@ -358,6 +363,18 @@ func NewMemChunk(chunkFormat byte, enc Encoding, head HeadBlockFmt, blockSize, t
 	return newMemChunkWithFormat(chunkFormat, enc, head, blockSize, targetSize)
 }
 
+// NewMemChunkWithDictionary returns a new in-mem chunk compressed with zstd
+// and the given dictionary. The dictionary is referenced by ID in the header
+// of the chunk, so only chunk formats v2+ are supported.
+func NewMemChunkWithDictionary(chunkFormat byte, dict *Dictionary, head HeadBlockFmt, blockSize, targetSize int) *MemChunk {
+	if chunkFormat < ChunkFormatV2 {
+		panic("dictionaries are only supported for chunk formats v2+")
+	}
+	c := newMemChunkWithFormat(chunkFormat, EncZstdDict, head, blockSize, targetSize)
+	c.dictionary = dict
+	return c
+}
+
 func panicIfInvalidFormat(chunkFmt byte, head HeadBlockFmt) {
 	if chunkFmt == ChunkFormatV2 && head != OrderedHeadBlockFmt {
 		panic("only OrderedHeadBlockFmt is supported for V2 chunks")
@@ -366,6 +383,9 @@ func panicIfInvalidFormat(chunkFmt byte, head HeadBlockFmt) {
 		fmt.Println("received head fmt", head.String())
 		panic("only UnorderedWithStructuredMetadataHeadBlockFmt is supported for V4 chunks")
 	}
+	if chunkFmt == ChunkFormatV5 && head != UnorderedWithStructuredMetadataHeadBlockFmt {
+		panic("only UnorderedWithStructuredMetadataHeadBlockFmt is supported for V5 chunks")
+	}
 }
 
 // NewMemChunk returns a new in-mem chunk.
@@ -389,10 +409,16 @@ func newMemChunkWithFormat(format byte, enc Encoding, head HeadBlockFmt, blockSi
 
 // NewByteChunk returns a MemChunk on the passed bytes.
 func NewByteChunk(b []byte, blockSize, targetSize int) (*MemChunk, error) {
-	return newByteChunk(b, blockSize, targetSize, false)
+	return newByteChunk(b, blockSize, targetSize, nil, false)
 }
 
-func newByteChunk(b []byte, blockSize, targetSize int, fromCheckpoint bool) (*MemChunk, error) {
+// NewByteChunkWithDictionaries returns a MemChunk on the passed bytes, which
+// is read with the given zstd dictionaries if it was compressed with one.
+func NewByteChunkWithDictionaries(b []byte, blockSize, targetSize int, dictionaries *Dictionaries) (*MemChunk, error) {
+	return newByteChunk(b, blockSize, targetSize, dictionaries, false)
+}
+
+func newByteChunk(b []byte, blockSize, targetSize int, dictionaries *Dictionaries, fromCheckpoint bool) (*MemChunk, error) {
 	bc := &MemChunk{
 		head:           &headBlock{}, // Dummy, empty headblock.
 		blockSize:      blockSize,
@@ -414,13 +440,25 @@ func newByteChunk(b []byte, blockSize, targetSize int, fromCheckpoint bool) (*Me
 	switch version {
 	case ChunkFormatV1:
 		bc.encoding = EncGZIP
-	case ChunkFormatV2, ChunkFormatV3, ChunkFormatV4:
+	case ChunkFormatV2, ChunkFormatV3, ChunkFormatV4, ChunkFormatV5:
 		// format v2+ has a byte for block encoding.
 		enc := Encoding(db.byte())
 		if db.err() != nil {
 			return nil, errors.Wrap(db.err(), "verifying encoding")
 		}
 		bc.encoding = enc
+
+		if enc == EncZstdDict {
+			id := db.be32()
+			if db.err() != nil {
+				return nil, errors.Wrap(db.err(), "verifying dictionary")
+			}
+			dict, err := dictionaries.Get(id)
+			if err != nil {
+				return nil, err
+			}
+			bc.dictionary = dict
+		}
 	default:
 		return nil, errors.Errorf("invalid version %d", version)
 	}
@@ -508,7 +546,7 @@ func newByteChunk(b []byte, blockSize, targetSize int, fromCheckpoint bool) (*Me
 		if fromCheckpoint {
 			bc.symbolizer = symbolizerFromCheckpoint(lb)
 		} else {
-			symbolizer, err := symbolizerFromEnc(lb, GetReaderPool(bc.encoding))
+			symbolizer, err := symbolizerFromEnc(lb, bc.readerPool())
 			if err != nil {
 				return nil, err
 			}
@@ -543,6 +581,9 @@ func (c *MemChunk) BytesSize() int {
 	if c.format > ChunkFormatV1 {
 		size++ // chunk format v2+ has a byte for encoding.
 	}
+	if c.dictionary != nil {
+		size += 4 // dictionary ID
+	}
 
 	// blocks
 	for _, b := range c.blocks {
@@ -603,6 +644,9 @@ func (c *MemChunk) writeTo(w io.Writer, forCheckpoint bool) (int64, error) {
 	if c.format > ChunkFormatV1 {
 		// chunk format v2+ has a byte for encoding.
 		eb.putByte(byte(c.encoding))
+		if c.dictionary != nil {
+			eb.putBE32(c.dictionary.ID())
+		}
 	}
 
 	n, err := w.Write(eb.get())
@@ -626,7 +670,7 @@ func (c *MemChunk) writeTo(w io.Writer, forCheckpoint bool) (int64, error) {
 			}
 		} else {
 			var err error
-			n, crcHash, err = c.symbolizer.SerializeTo(w, GetWriterPool(c.encoding))
+			n, crcHash, err = c.symbolizer.SerializeTo(w, c.writerPool())
 			if err != nil {
 				return offset, errors.Wrap(err, "write structured metadata")
 			}
@@ -733,8 +777,8 @@ func (c *MemChunk) CheckpointSize() (chunk, head int) {
 	return c.BytesSize(), c.head.CheckpointSize()
 }
 
-func MemchunkFromCheckpoint(chk, head []byte, desiredIfNotUnordered HeadBlockFmt, blockSize int, targetSize int) (*MemChunk, error) {
-	mc, err := newByteChunk(chk, blockSize, targetSize, true)
+func MemchunkFromCheckpoint(chk, head []byte, desiredIfNotUnordered HeadBlockFmt, blockSize int, targetSize int, dictionaries *Dictionaries) (*MemChunk, error) {
+	mc, err := newByteChunk(chk, blockSize, targetSize, dictionaries, true)
 	if err != nil {
 		return nil, err
 	}
@@ -753,6 +797,25 @@ func (c *MemChunk) Encoding() Encoding {
 	return c.encoding
 }
 
+// Dictionary returns the zstd dictionary the chunk is compressed with, if any.
+func (c *MemChunk) Dictionary() *Dictionary {
+	return c.dictionary
+}
+
+func (c *MemChunk) readerPool() ReaderPool {
+	if c.dictionary != nil {
+		return c.dictionary
+	}
+	return GetReaderPool(c.encoding)
+}
+
+func (c *MemChunk) writerPool() WriterPool {
+	if c.dictionary != nil {
+		return c.dictionary
+	}
+	return GetWriterPool(c.encoding)
+}
+
 // Size implements Chunk.
 func (c *MemChunk) Size() int {
 	ne := 0
@@ -912,7 +975,15 @@ func (c *MemChunk) cut() error {
 		return nil
 	}
 
-	b, err := c.head.Serialise(GetWriterPool(c.encoding))
+	var (
+		b   []byte
+		err error
+	)
+	if c.format >= ChunkFormatV5 {
+		b, err = serialiseColumns(c.head, c.symbolizer, c.writerPool())
+	} else {
+		b, err = c.head.Serialise(c.writerPool())
+	}
 	if err != nil {
 		return err
 	}
@@ -977,7 +1048,7 @@ func (c *MemChunk) Iterator(ctx context.Context, mintT, maxtT time.Time, directi
 		}
 		lastMax = b.maxt
 
-		blockItrs = append(blockItrs, encBlock{c.encoding, c.format, c.symbolizer, b}.Iterator(ctx, pipeline))
+		blockItrs = append(blockItrs, encBlock{c.encoding, c.dictionary, c.format, c.symbolizer, b}.Iterator(ctx, pipeline))
 	}
 
 	if !c.head.IsEmpty() {
@@ -1034,6 +1105,13 @@ func (c *MemChunk) Iterator(ctx context.Context, mintT, maxtT time.Time, directi
 	return iter.NewSortEntryIterator(blockItrs, direction), nil
 }
 
+// MetadataIterator iterates over the entries like Iterator, but with empty
+// lines: the pipeline processes the entries without their lines, and the
+// blocks of ChunkFormatV5 chunks are read without decompressing them.
+func (c *MemChunk) MetadataIterator(ctx context.Context, mintT, maxtT time.Time, direction logproto.Direction, pipeline log.StreamPipeline) (iter.EntryIterator, error) {
+	return c.Iterator(ctx, mintT, maxtT, direction, withoutLinesPipeline{pipeline})
+}
+
 // Iterator implements Chunk.
 func (c *MemChunk) SampleIterator(ctx context.Context, from, through time.Time, extractor log.StreamSampleExtractor) iter.SampleIterator {
 	mint, maxt := from.UnixNano(), through.UnixNano()
@@ -1059,7 +1137,7 @@ func (c *MemChunk) SampleIterator(ctx context.Context, from, through time.Time,
 			ordered = false
 		}
 		lastMax = b.maxt
-		its = append(its, encBlock{c.encoding, c.format, c.symbolizer, b}.SampleIterator(ctx, extractor))
+		its = append(its, encBlock{c.encoding, c.dictionary, c.format, c.symbolizer, b}.SampleIterator(ctx, extractor))
 	}
 
 	if !c.head.IsEmpty() {
@@ -1091,7 +1169,7 @@ func (c *MemChunk) Blocks(mintT, maxtT time.Time) []Block {
 
 	for _, b := range c.blocks {
 		if maxt >= b.mint && b.maxt >= mint {
-			blocks = append(blocks, encBlock{c.encoding, c.format, c.symbolizer, b})
+			blocks = append(blocks, encBlock{c.encoding, c.dictionary, c.format, c.symbolizer, b})
 		}
 	}
 	return blocks
@@ -1116,6 +1194,7 @@ func (c *MemChunk) Rebound(start, end time.Time, filter filter.Func) (Chunk, err
 		// For target chunk size I am using compressed size of original chunk since the newChunk should anyways be lower in size than that.
 		newChunk = NewMemChunk(c.format, c.Encoding(), c.headFmt, defaultBlockSize, c.CompressedSize())
 	}
+	newChunk.dictionary = c.dictionary
 
 	for itr.Next() {
 		entry := itr.Entry()
@@ -1144,6 +1223,7 @@ func (c *MemChunk) Rebound(start, end time.Time, filter filter.Func) (Chunk, err
 // chances of chunk<>block encoding drift in the codebase as the latter is parameterized by the former.
 type encBlock struct {
 	enc        Encoding
+	dictionary *Dictionary
 	format     byte
 	symbolizer *symbolizer
 	block
@@ -1153,14 +1233,21 @@ func (b encBlock) Iterator(ctx context.Context, pipeline log.StreamPipeline) ite
 	if len(b.b) == 0 {
 		return iter.NoopIterator
 	}
-	return newEntryIterator(ctx, GetReaderPool(b.enc), b.b, pipeline, b.format, b.symbolizer)
+	return newEntryIterator(ctx, b.readerPool(), b.b, pipeline, b.format, b.symbolizer)
 }
 
 func (b encBlock) SampleIterator(ctx context.Context, extractor log.StreamSampleExtractor) iter.SampleIterator {
 	if len(b.b) == 0 {
 		return iter.NoopIterator
 	}
-	return newSampleIterator(ctx, GetReaderPool(b.enc), b.b, b.format, extractor, b.symbolizer)
+	return newSampleIterator(ctx, b.readerPool(), b.b, b.format, extractor, b.symbolizer)
+}
+
+func (b encBlock) readerPool() ReaderPool {
+	if b.dictionary != nil {
+		return b.dictionary
+	}
+	return GetReaderPool(b.enc)
 }
 
 func (b block) Offset() int {
@@ -1330,6 +1417,9 @@ type bufferedIterator struct {
 	symbolsBuf             []symbol      // The buffer for a single entry's symbols.
 	currStructuredMetadata labels.Labels // The current labels.
 
+	columns   *columnarReader // The reader of the columns of ChunkFormatV5 blocks.
+	skipLines bool            // Whether the lines of ChunkFormatV5 blocks are skipped.
+
 	closed bool
 }
 
@@ -1351,17 +1441,26 @@ func (si *bufferedIterator) Next() bool {
 		return false
 	}
 
-	if !si.closed && si.reader == nil {
-		// initialize reader now, hopefully reusing one of the previous readers
-		var err error
-		si.reader, err = si.pool.GetReader(bytes.NewBuffer(si.origBytes))
-		if err != nil {
-			si.err = err
-			return false
+	var (
+		ts                 int64
+		line               []byte
+		structuredMetadata labels.Labels
+		ok                 bool
+	)
+	if si.format >= ChunkFormatV5 {
+		ts, line, structuredMetadata, ok = si.moveNextColumns()
+	} else {
+		if si.reader == nil {
+			// initialize reader now, hopefully reusing one of the previous readers
+			var err error
+			si.reader, err = si.pool.GetReader(bytes.NewBuffer(si.origBytes))
+			if err != nil {
+				si.err = err
+				return false
+			}
 		}
+		ts, line, structuredMetadata, ok = si.moveNext()
 	}
-
-	ts, line, structuredMetadata, ok := si.moveNext()
 	if !ok {
 		si.Close()
 		return false
@@ -1586,12 +1685,19 @@ func (si *bufferedIterator) close() {
 		si.symbolsBuf = nil
 	}
 
+	if si.columns != nil {
+		si.columns.release()
+		si.columns = nil
+	}
+
 	si.origBytes = nil
 }
 
 func newEntryIterator(ctx context.Context, pool ReaderPool, b []byte, pipeline log.StreamPipeline, format byte, symbolizer *symbolizer) iter.EntryIterator {
+	it := newBufferedIterator(ctx, pool, b, format, symbolizer)
+	_, it.skipLines = pipeline.(withoutLinesPipeline)
 	return &entryBufferedIterator{
-		bufferedIterator: newBufferedIterator(ctx, pool, b, format, symbolizer),
+		bufferedIterator: it,
 		pipeline:         pipeline,
 		stats:            stats.FromContext(ctx),
 	}
@@ -1647,6 +1753,7 @@ func newSampleIterator(ctx context.Context, pool ReaderPool, b []byte, format by
 		extractor:        extractor,
 		stats:            stats.FromContext(ctx),
 	}
+	_, it.skipLines = extractor.(withoutLinesExtractor)
 	return it
 }
 
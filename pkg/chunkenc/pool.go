@@ -54,6 +54,10 @@ var (
 	// So we will be able to store from 0 to 128 labels.
 	LabelsPool = pool.New(1<<3, 1<<8, 2, func(size int) interface{} { return make([][]byte, 0, size) })
 
+	// columnBufferPool is a pool of buffers used for the columns of columnar blocks decompressed.
+	// Buckets [1KB,4KB,16KB,64KB,256KB,1MB]
+	columnBufferPool = pool.New(1<<10, 1<<20, 4, func(size int) interface{} { return make([]byte, 0, size) })
+
 	SymbolsPool = pool.New(1<<3, 1<<8, 2, func(size int) interface{} { return make([]symbol, 0, size) })
 
 	// SamplesPool pooling array of samples [512,1024,...,16k]
@@ -224,10 +228,13 @@ func (pool *FlatePool) PutWriter(writer io.WriteCloser) {
 	pool.writers.Put(writer)
 }
 
-// GzipPool is a gun zip compression pool
+// ZstdPool is a zstd compression pool
 type ZstdPool struct {
 	readers sync.Pool
 	writers sync.Pool
+
+	// dict is the dictionary the readers and writers are created with, if any.
+	dict []byte
 }
 
 // GetReader gets or creates a new CompressionReader and reset it to read from src
@@ -240,7 +247,11 @@ func (pool *ZstdPool) GetReader(src io.Reader) (io.Reader, error) {
 		}
 		return reader, nil
 	}
-	reader, err := zstd.NewReader(src)
+	var opts []zstd.DOption
+	if pool.dict != nil {
+		opts = append(opts, zstd.WithDecoderDicts(pool.dict))
+	}
+	reader, err := zstd.NewReader(src, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -261,9 +272,13 @@ func (pool *ZstdPool) GetWriter(dst io.Writer) io.WriteCloser {
 		return writer
 	}
 
-	w, err := zstd.NewWriter(dst)
+	var opts []zstd.EOption
+	if pool.dict != nil {
+		opts = append(opts, zstd.WithEncoderDict(pool.dict))
+	}
+	w, err := zstd.NewWriter(dst, opts...)
 	if err != nil {
-		panic(err) // never happens, error is only returned on wrong compression level.
+		panic(err) // never happens, error is only returned on wrong compression level or an invalid dictionary, which is checked when it is registered.
 	}
 	return w
 }
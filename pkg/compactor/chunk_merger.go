@@ -0,0 +1,414 @@
+package compactor
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"golang.org/x/time/rate"
+
+	"github.com/grafana/loki/pkg/chunkenc"
+	"github.com/grafana/loki/pkg/compactor/retention"
+	"github.com/grafana/loki/pkg/logproto"
+	logql_log "github.com/grafana/loki/pkg/logql/log"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/storage/chunk/client"
+	"github.com/grafana/loki/pkg/storage/config"
+	"github.com/grafana/loki/pkg/util"
+)
+
+// mergedChunkBlockSize is the uncompressed size of the blocks of the merged
+// chunks, the default of the ingesters.
+const mergedChunkBlockSize = 256 << 10
+
+// ChunkMergeConfig configures the merging of the small chunks of the streams.
+type ChunkMergeConfig struct {
+	Enabled         bool `yaml:"enabled"`
+	SmallChunkSize  int  `yaml:"small_chunk_size"`
+	TargetChunkSize int  `yaml:"target_chunk_size"`
+}
+
+// RegisterFlagsWithPrefix registers flags.
+func (cfg *ChunkMergeConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, prefix+"chunk-merge.enabled", false, "Experimental. Merge the adjacent small chunks of the streams into larger chunks while applying retention, at the compactor_chunk_merge_rate_mb of the tenants. The merged chunks are deleted after the retention_delete_delay. Requires retention_enabled and is only supported with the TSDB index.")
+	f.IntVar(&cfg.SmallChunkSize, prefix+"chunk-merge.small-chunk-size", 256<<10, "Chunks with an approximate uncompressed size below this size in bytes are merged.")
+	f.IntVar(&cfg.TargetChunkSize, prefix+"chunk-merge.target-chunk-size", 4<<20, "Maximum approximate uncompressed size in bytes of the chunks built by merging small chunks.")
+}
+
+func (cfg *ChunkMergeConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.SmallChunkSize <= 0 {
+		return errors.New("compactor.chunk-merge.small-chunk-size must be greater than 0")
+	}
+	if cfg.TargetChunkSize <= cfg.SmallChunkSize {
+		return errors.New("compactor.chunk-merge.target-chunk-size must be greater than compactor.chunk-merge.small-chunk-size")
+	}
+	return nil
+}
+
+// ChunkMergeLimits are the per-tenant limits of the chunk merging.
+type ChunkMergeLimits interface {
+	ChunkMergeRateBytes(userID string) float64
+	ChunkMergeEncoding(userID string) string
+}
+
+// chunkMerger merges the small chunks of the streams of a table.
+type chunkMerger interface {
+	// MergeChunks merges the small chunks of the streams of the tenant, and
+	// returns the merged chunks, or nil if the index wasn't modified.
+	MergeChunks(ctx context.Context, tableName, userID string, indexProcessor retention.IndexProcessor, logger log.Logger) (*mergedChunks, error)
+}
+
+// mergedChunks are the chunks merged in the compacted index of a tenant,
+// pending until the index is uploaded.
+type mergedChunks struct {
+	merger *storeChunkMerger
+	// created are the chunks built by merging the small ones.
+	created []chunk.Chunk
+	// merged are the IDs of the small chunks removed from the index.
+	merged [][]byte
+	logger log.Logger
+}
+
+// markMerged marks the small chunks for the retention sweeper to delete them.
+// It must only be called once the compacted index referencing the merged
+// chunks is uploaded.
+func (c *mergedChunks) markMerged() error {
+	markerWriter, err := retention.NewMarkerStorageWriter(c.merger.markerDir)
+	if err != nil {
+		return fmt.Errorf("failed to create marker writer: %w", err)
+	}
+	for _, chunkID := range c.merged {
+		if err := markerWriter.Put(chunkID); err != nil {
+			_ = markerWriter.Close()
+			return err
+		}
+	}
+	if err := markerWriter.Close(); err != nil {
+		return fmt.Errorf("failed to close marker writer: %w", err)
+	}
+	return nil
+}
+
+// deleteCreated deletes the merged chunks when the compacted index
+// referencing them isn't uploaded.
+func (c *mergedChunks) deleteCreated(ctx context.Context) {
+	c.merger.deleteChunks(ctx, c.created, c.logger)
+}
+
+type chunkMergerMetrics struct {
+	chunksMerged  prometheus.Counter
+	chunksCreated prometheus.Counter
+	bytesMerged   prometheus.Counter
+}
+
+func newChunkMergerMetrics(r prometheus.Registerer) *chunkMergerMetrics {
+	return &chunkMergerMetrics{
+		chunksMerged: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki_compactor",
+			Name:      "chunks_merged_total",
+			Help:      "Total number of small chunks merged into larger chunks",
+		}),
+		chunksCreated: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki_compactor",
+			Name:      "merged_chunks_created_total",
+			Help:      "Total number of chunks built by merging small chunks",
+		}),
+		bytesMerged: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki_compactor",
+			Name:      "chunk_merge_bytes_total",
+			Help:      "Total approximate uncompressed size in bytes of the small chunks merged into larger chunks",
+		}),
+	}
+}
+
+// mergeCandidate is a small chunk of a stream.
+type mergeCandidate struct {
+	chunkID       string
+	from, through model.Time
+	kb            uint32
+}
+
+// storeChunkMerger merges the adjacent small chunks of the streams of the
+// tables of a period into larger chunks, compressed with the encoding of the
+// tenant.
+//
+// The merged chunks are indexed in place of the small ones in the compacted
+// index of the table. Only once that index is uploaded, the small ones are
+// marked for the retention sweeper to delete them after the
+// retention_delete_delay, so the queriers using the previous index can still
+// read them. The merged chunks are deleted again when merging fails or the
+// compacted index isn't uploaded. Only the chunks indexed in a single table
+// are merged, so the index of the tables stays consistent.
+type storeChunkMerger struct {
+	cfg          ChunkMergeConfig
+	limits       ChunkMergeLimits
+	chunkClient  client.Client
+	schemaCfg    config.SchemaConfig
+	chunkFormat  byte
+	headBlockFmt chunkenc.HeadBlockFmt
+	markerDir    string
+	timeout      time.Duration
+	metrics      *chunkMergerMetrics
+
+	limitersMtx sync.Mutex
+	limiters    map[string]*rate.Limiter
+}
+
+func newStoreChunkMerger(cfg ChunkMergeConfig, limits ChunkMergeLimits, chunkClient client.Client, schemaCfg config.SchemaConfig, chunkFormat byte, headBlockFmt chunkenc.HeadBlockFmt, markerDir string, timeout time.Duration, r prometheus.Registerer) *storeChunkMerger {
+	return &storeChunkMerger{
+		cfg:          cfg,
+		limits:       limits,
+		chunkClient:  chunkClient,
+		schemaCfg:    schemaCfg,
+		chunkFormat:  chunkFormat,
+		headBlockFmt: headBlockFmt,
+		markerDir:    markerDir,
+		timeout:      timeout,
+		metrics:      newChunkMergerMetrics(r),
+		limiters:     map[string]*rate.Limiter{},
+	}
+}
+
+// MergeChunks implements chunkMerger.
+func (m *storeChunkMerger) MergeChunks(ctx context.Context, tableName, userID string, indexProcessor retention.IndexProcessor, logger log.Logger) (*mergedChunks, error) {
+	limiter := m.limiter(userID)
+	if limiter == nil {
+		return nil, nil
+	}
+	enc, err := chunkenc.ParseEncoding(m.limits.ChunkMergeEncoding(userID))
+	if err != nil {
+		return nil, err
+	}
+
+	tableInterval := retention.ExtractIntervalFromTableName(tableName)
+	smallKB := uint32(m.cfg.SmallChunkSize >> 10)
+	series := map[string][]mergeCandidate{}
+	err = indexProcessor.ForEachChunk(ctx, func(ce retention.ChunkEntry) (bool, error) {
+		if ce.KB >= smallKB || ce.From < tableInterval.Start || ce.Through > tableInterval.End {
+			return false, nil
+		}
+		seriesID := string(ce.SeriesID)
+		series[seriesID] = append(series[seriesID], mergeCandidate{
+			chunkID: string(ce.ChunkID),
+			from:    ce.From,
+			through: ce.Through,
+			kb:      ce.KB,
+		})
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Stop merging after the timeout, keeping the chunks merged until then.
+	mergeCtx, cancel := ctxForTimeout(ctx, m.timeout)
+	defer cancel()
+
+	var created []chunk.Chunk
+	merged := map[string]struct{}{}
+mergeSeries:
+	for _, candidates := range series {
+		for _, group := range m.groups(candidates) {
+			newChunk, err := m.mergeGroup(mergeCtx, userID, group, enc, limiter, indexProcessor, logger)
+			if err != nil {
+				if ctx.Err() == nil && mergeCtx.Err() != nil {
+					level.Warn(logger).Log("msg", "timed out while merging chunks")
+					break mergeSeries
+				}
+				// The index isn't uploaded when merging fails, so the chunks
+				// merged so far are deleted along with it.
+				m.deleteChunks(ctx, created, logger)
+				return nil, fmt.Errorf("failed to merge chunks of series: %w", err)
+			}
+			created = append(created, newChunk)
+			for _, c := range group {
+				merged[c.chunkID] = struct{}{}
+			}
+		}
+	}
+	if len(merged) == 0 {
+		return nil, nil
+	}
+
+	// The small chunks are only marked for deletion once the compacted index
+	// is uploaded, see mergedChunks.markMerged.
+	result := &mergedChunks{merger: m, created: created, logger: logger}
+	err = indexProcessor.ForEachChunk(ctx, func(ce retention.ChunkEntry) (bool, error) {
+		if _, ok := merged[string(ce.ChunkID)]; !ok {
+			return false, nil
+		}
+		result.merged = append(result.merged, append([]byte(nil), ce.ChunkID...))
+		return true, nil
+	})
+	if err != nil {
+		m.deleteChunks(ctx, created, logger)
+		return nil, err
+	}
+
+	level.Info(logger).Log("msg", "merged small chunks", "chunks", len(merged))
+	return result, nil
+}
+
+// limiter returns the rate limiter of the chunk merging of the tenant, or nil
+// if chunk merging is disabled for the tenant.
+func (m *storeChunkMerger) limiter(userID string) *rate.Limiter {
+	bytesPerSecond := m.limits.ChunkMergeRateBytes(userID)
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+
+	m.limitersMtx.Lock()
+	defer m.limitersMtx.Unlock()
+	l, ok := m.limiters[userID]
+	if !ok {
+		// The burst allows merging the largest groups of chunks.
+		l = rate.NewLimiter(rate.Limit(bytesPerSecond), m.cfg.TargetChunkSize)
+		m.limiters[userID] = l
+	}
+	l.SetLimit(rate.Limit(bytesPerSecond))
+	return l
+}
+
+// groups returns the groups of adjacent small chunks of a stream to merge,
+// each of them no larger than the target chunk size.
+func (m *storeChunkMerger) groups(candidates []mergeCandidate) [][]mergeCandidate {
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].from != candidates[j].from {
+			return candidates[i].from < candidates[j].from
+		}
+		return candidates[i].through < candidates[j].through
+	})
+
+	var (
+		groups [][]mergeCandidate
+		group  []mergeCandidate
+		size   int
+	)
+	flush := func() {
+		if len(group) > 1 {
+			groups = append(groups, group)
+		}
+		group, size = nil, 0
+	}
+	for _, c := range candidates {
+		kb := int(c.kb) << 10
+		if size+kb > m.cfg.TargetChunkSize {
+			flush()
+		}
+		group = append(group, c)
+		size += kb
+	}
+	flush()
+	return groups
+}
+
+// mergeGroup builds a chunk from the entries of the group of chunks, stores
+// it and indexes it. The chunk is deleted again if it can't be indexed.
+func (m *storeChunkMerger) mergeGroup(ctx context.Context, userID string, group []mergeCandidate, enc chunkenc.Encoding, limiter *rate.Limiter, chunkIndexer retention.IndexProcessor, logger log.Logger) (chunk.Chunk, error) {
+	var size int
+	chks := make([]chunk.Chunk, 0, len(group))
+	for _, c := range group {
+		chk, err := chunk.ParseExternalKey(userID, c.chunkID)
+		if err != nil {
+			return chunk.Chunk{}, err
+		}
+		chks = append(chks, chk)
+		size += int(c.kb) << 10
+	}
+	if err := limiter.WaitN(ctx, min(size, m.cfg.TargetChunkSize)); err != nil {
+		return chunk.Chunk{}, err
+	}
+
+	chks, err := m.chunkClient.GetChunks(ctx, chks)
+	if err != nil {
+		return chunk.Chunk{}, err
+	}
+	if len(chks) != len(group) {
+		return chunk.Chunk{}, fmt.Errorf("expected %d chunks but found %d in storage", len(group), len(chks))
+	}
+
+	mc := chunkenc.NewMemChunk(m.chunkFormat, enc, m.headBlockFmt, mergedChunkBlockSize, 0)
+	for _, chk := range chks {
+		if err := appendChunk(ctx, mc, chk); err != nil {
+			return chunk.Chunk{}, err
+		}
+	}
+	if err := mc.Close(); err != nil {
+		return chunk.Chunk{}, err
+	}
+
+	from, through := util.RoundToMilliseconds(mc.Bounds())
+	newChunk := chunk.NewChunk(userID, chks[0].FingerprintModel(), chks[0].Metric, chunkenc.NewFacade(mc, mergedChunkBlockSize, 0), from, through)
+	if err := newChunk.Encode(); err != nil {
+		return chunk.Chunk{}, err
+	}
+	if err := m.chunkClient.PutChunks(ctx, []chunk.Chunk{newChunk}); err != nil {
+		m.deleteChunks(ctx, []chunk.Chunk{newChunk}, logger)
+		return chunk.Chunk{}, err
+	}
+	indexed, err := chunkIndexer.IndexChunk(newChunk)
+	if err == nil && !indexed {
+		err = fmt.Errorf("merged chunk of series %s not indexed", chks[0].Metric)
+	}
+	if err != nil {
+		m.deleteChunks(ctx, []chunk.Chunk{newChunk}, logger)
+		return chunk.Chunk{}, err
+	}
+
+	m.metrics.chunksMerged.Add(float64(len(group)))
+	m.metrics.chunksCreated.Inc()
+	m.metrics.bytesMerged.Add(float64(size))
+	return newChunk, nil
+}
+
+// deleteChunks deletes the merged chunks which won't be indexed, even once
+// the context is canceled.
+func (m *storeChunkMerger) deleteChunks(ctx context.Context, chks []chunk.Chunk, logger log.Logger) {
+	ctx = context.WithoutCancel(ctx)
+	for _, c := range chks {
+		chunkID := m.schemaCfg.ExternalKey(c.ChunkRef)
+		if err := m.chunkClient.DeleteChunk(ctx, c.UserID, chunkID); err != nil && !m.chunkClient.IsChunkNotFoundErr(err) {
+			level.Warn(logger).Log("msg", "failed to delete merged chunk", "chunk", chunkID, "err", err)
+		}
+	}
+}
+
+// appendChunk appends the entries of the chunk to the MemChunk. The entries
+// of overlapping chunks, such as the ones flushed by several ingesters, are
+// deduplicated by the unordered head block.
+func appendChunk(ctx context.Context, mc *chunkenc.MemChunk, chk chunk.Chunk) error {
+	it, err := chk.Data.(*chunkenc.Facade).LokiChunk().Iterator(ctx, time.Unix(0, 0), time.Unix(0, math.MaxInt64), logproto.FORWARD, logql_log.NewNoopPipeline().ForStream(labels.Labels{}))
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+
+	for it.Next() {
+		e := it.Entry()
+		if err := mc.Append(&e); err != nil {
+			return err
+		}
+	}
+	return it.Error()
+}
+
+func ctxForTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout == 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
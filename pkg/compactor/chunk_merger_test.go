@@ -0,0 +1,263 @@
+package compactor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/chunkenc"
+	"github.com/grafana/loki/pkg/compactor/retention"
+	"github.com/grafana/loki/pkg/logproto"
+	logql_log "github.com/grafana/loki/pkg/logql/log"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/storage/chunk/client"
+	"github.com/grafana/loki/pkg/storage/chunk/client/testutils"
+	"github.com/grafana/loki/pkg/storage/config"
+)
+
+type chunkMergeLimits struct {
+	rateBytes float64
+	encoding  string
+}
+
+func (l chunkMergeLimits) ChunkMergeRateBytes(_ string) float64 {
+	return l.rateBytes
+}
+
+func (l chunkMergeLimits) ChunkMergeEncoding(_ string) string {
+	return l.encoding
+}
+
+// mergeTestIndex is an in-memory IndexProcessor, which like the TSDB compacted
+// index doesn't iterate the chunks lined up for deletion again.
+type mergeTestIndex struct {
+	schemaCfg config.SchemaConfig
+	chunks    []chunk.Chunk
+	deleted   map[string]struct{}
+	indexed   []chunk.Chunk
+	// maxIndexed makes IndexChunk fail once it's reached, if set.
+	maxIndexed int
+}
+
+func (i *mergeTestIndex) ForEachChunk(_ context.Context, callback retention.ChunkEntryCallback) error {
+	for _, c := range i.chunks {
+		chunkID := i.schemaCfg.ExternalKey(c.ChunkRef)
+		if _, ok := i.deleted[chunkID]; ok {
+			continue
+		}
+		deleteChunk, err := callback(retention.ChunkEntry{
+			ChunkRef: retention.ChunkRef{
+				UserID:   []byte(c.UserID),
+				SeriesID: []byte(c.Metric.String()),
+				ChunkID:  []byte(chunkID),
+				From:     c.From,
+				Through:  c.Through,
+				KB:       uint32(math.Round(float64(c.Data.UncompressedSize()) / float64(1<<10))),
+			},
+			Labels: c.Metric,
+		})
+		if err != nil {
+			return err
+		}
+		if deleteChunk {
+			i.deleted[chunkID] = struct{}{}
+		}
+	}
+	return nil
+}
+
+func (i *mergeTestIndex) IndexChunk(c chunk.Chunk) (bool, error) {
+	if i.maxIndexed > 0 && len(i.indexed) >= i.maxIndexed {
+		return false, errors.New("index full")
+	}
+	i.indexed = append(i.indexed, c)
+	return true, nil
+}
+
+func (i *mergeTestIndex) CleanupSeries(_ []byte, _ labels.Labels) error {
+	return nil
+}
+
+func TestChunkMerger(t *testing.T) {
+	ctx := context.Background()
+	schemaCfg := config.SchemaConfig{Configs: []config.PeriodConfig{{
+		From:       config.DayTime{Time: 0},
+		IndexType:  config.TSDBType,
+		ObjectType: "inmemory",
+		Schema:     "v13",
+		IndexTables: config.IndexPeriodicTableConfig{
+			PeriodicTableConfig: config.PeriodicTableConfig{Prefix: "index_", Period: config.ObjectStorageIndexRequiredPeriod},
+		},
+	}}}
+	chunkClient := client.NewClient(testutils.NewInMemoryObjectClient(), nil, schemaCfg)
+
+	tableNumber := time.Now().Add(-48*time.Hour).UnixNano() / int64(config.ObjectStorageIndexRequiredPeriod)
+	tableName := fmt.Sprintf("index_%d", tableNumber)
+	tableStart := model.TimeFromUnixNano(tableNumber * int64(config.ObjectStorageIndexRequiredPeriod))
+
+	idx := &mergeTestIndex{schemaCfg: schemaCfg, deleted: map[string]struct{}{}}
+	putChunk := func(lbs labels.Labels, from model.Time, entries int, lineSize int) {
+		c := chunkenc.NewMemChunk(chunkenc.ChunkFormatV4, chunkenc.EncGZIP, chunkenc.UnorderedWithStructuredMetadataHeadBlockFmt, 256<<10, 0)
+		for i := 0; i < entries; i++ {
+			require.NoError(t, c.Append(&logproto.Entry{
+				Timestamp:          from.Add(time.Duration(i) * time.Second).Time(),
+				Line:               fmt.Sprintf("%s %0*d", lbs.Get("app"), lineSize, i),
+				StructuredMetadata: logproto.FromLabelsToLabelAdapters(labels.FromStrings("line", fmt.Sprint(i))),
+			}))
+		}
+		require.NoError(t, c.Close())
+		chkFrom, chkThrough := c.Bounds()
+		chk := chunk.NewChunk("user", model.Fingerprint(lbs.Hash()), lbs, chunkenc.NewFacade(c, 0, 0), model.TimeFromUnixNano(chkFrom.UnixNano()), model.TimeFromUnixNano(chkThrough.UnixNano()))
+		require.NoError(t, chk.Encode())
+		require.NoError(t, chunkClient.PutChunks(ctx, []chunk.Chunk{chk}))
+		idx.chunks = append(idx.chunks, chk)
+	}
+
+	small := labels.FromStrings("app", "small")
+	for i := 0; i < 5; i++ {
+		putChunk(small, tableStart.Add(time.Duration(i)*time.Hour), 10, 10)
+	}
+	// a large chunk, and a chunk also indexed in the next table.
+	putChunk(small, tableStart.Add(6*time.Hour), 1000, 1000)
+	putChunk(small, tableStart.Add(config.ObjectStorageIndexRequiredPeriod-time.Second), 10, 10)
+	// a stream with a single small chunk.
+	putChunk(labels.FromStrings("app", "single"), tableStart, 10, 10)
+
+	cfg := ChunkMergeConfig{Enabled: true, SmallChunkSize: 256 << 10, TargetChunkSize: 4 << 20}
+	workDir := t.TempDir()
+	newMerger := func(limits ChunkMergeLimits) *storeChunkMerger {
+		return newStoreChunkMerger(cfg, limits, chunkClient, schemaCfg, chunkenc.ChunkFormatV4, chunkenc.UnorderedWithStructuredMetadataHeadBlockFmt, workDir, 0, prometheus.NewRegistry())
+	}
+
+	result, err := newMerger(chunkMergeLimits{encoding: "snappy"}).MergeChunks(ctx, tableName, "user", idx, log.NewNopLogger())
+	require.NoError(t, err)
+	require.Nil(t, result)
+
+	merger := newMerger(chunkMergeLimits{rateBytes: 10 << 20, encoding: "snappy"})
+	result, err = merger.MergeChunks(ctx, tableName, "user", idx, log.NewNopLogger())
+	require.NoError(t, err)
+	require.NotNil(t, result)
+
+	require.Len(t, idx.deleted, 5)
+	for _, c := range idx.chunks[:5] {
+		require.Contains(t, idx.deleted, schemaCfg.ExternalKey(c.ChunkRef))
+	}
+	require.Equal(t, 5.0, testutil.ToFloat64(merger.metrics.chunksMerged))
+	require.Equal(t, 1.0, testutil.ToFloat64(merger.metrics.chunksCreated))
+
+	// The small chunks are only marked for deletion once the compacted index
+	// is uploaded, and the merged chunk is kept then.
+	_, err = os.Stat(filepath.Join(workDir, retention.MarkersFolder))
+	require.True(t, os.IsNotExist(err))
+	is := &indexSet{ctx: ctx, logger: log.NewNopLogger(), mergedChunks: result}
+	require.NoError(t, is.done())
+	is.cleanup()
+	markers, err := os.ReadDir(filepath.Join(workDir, retention.MarkersFolder))
+	require.NoError(t, err)
+	require.Len(t, markers, 1)
+
+	require.Len(t, idx.indexed, 1)
+	merged := idx.indexed[0]
+	require.Equal(t, idx.chunks[0].From, merged.From)
+	require.Equal(t, idx.chunks[4].Through, merged.Through)
+	chks, err := chunkClient.GetChunks(ctx, []chunk.Chunk{merged})
+	require.NoError(t, err)
+	lc := chks[0].Data.(*chunkenc.Facade).LokiChunk()
+	require.Equal(t, chunkenc.EncSnappy, lc.Encoding())
+	it, err := lc.Iterator(ctx, time.Unix(0, 0), time.Unix(0, math.MaxInt64), logproto.FORWARD, logql_log.NewNoopPipeline().ForStream(labels.Labels{}))
+	require.NoError(t, err)
+	defer it.Close()
+	var entries int
+	for it.Next() {
+		require.Len(t, it.Entry().StructuredMetadata, 1)
+		entries++
+	}
+	require.NoError(t, it.Error())
+	require.Equal(t, 50, entries)
+
+	// There are no small chunks of the stream left to merge the merged chunk
+	// with.
+	idx.chunks = append(idx.chunks, merged)
+	result, err = merger.MergeChunks(ctx, tableName, "user", idx, log.NewNopLogger())
+	require.NoError(t, err)
+	require.Nil(t, result)
+}
+
+func TestChunkMerger_Failure(t *testing.T) {
+	ctx := context.Background()
+	schemaCfg := config.SchemaConfig{Configs: []config.PeriodConfig{{
+		From:       config.DayTime{Time: 0},
+		IndexType:  config.TSDBType,
+		ObjectType: "inmemory",
+		Schema:     "v13",
+		IndexTables: config.IndexPeriodicTableConfig{
+			PeriodicTableConfig: config.PeriodicTableConfig{Prefix: "index_", Period: config.ObjectStorageIndexRequiredPeriod},
+		},
+	}}}
+	objects := testutils.NewInMemoryObjectClient()
+	chunkClient := client.NewClient(objects, nil, schemaCfg)
+
+	tableNumber := time.Now().Add(-48*time.Hour).UnixNano() / int64(config.ObjectStorageIndexRequiredPeriod)
+	tableName := fmt.Sprintf("index_%d", tableNumber)
+	tableStart := model.TimeFromUnixNano(tableNumber * int64(config.ObjectStorageIndexRequiredPeriod))
+
+	// The second merged chunk can't be indexed.
+	idx := &mergeTestIndex{schemaCfg: schemaCfg, deleted: map[string]struct{}{}, maxIndexed: 1}
+	for _, app := range []string{"first", "second"} {
+		lbs := labels.FromStrings("app", app)
+		for i := 0; i < 2; i++ {
+			c := chunkenc.NewMemChunk(chunkenc.ChunkFormatV4, chunkenc.EncGZIP, chunkenc.UnorderedWithStructuredMetadataHeadBlockFmt, 256<<10, 0)
+			require.NoError(t, c.Append(&logproto.Entry{Timestamp: tableStart.Add(time.Duration(i) * time.Hour).Time(), Line: app}))
+			require.NoError(t, c.Close())
+			chkFrom, chkThrough := c.Bounds()
+			chk := chunk.NewChunk("user", model.Fingerprint(lbs.Hash()), lbs, chunkenc.NewFacade(c, 0, 0), model.TimeFromUnixNano(chkFrom.UnixNano()), model.TimeFromUnixNano(chkThrough.UnixNano()))
+			require.NoError(t, chk.Encode())
+			require.NoError(t, chunkClient.PutChunks(ctx, []chunk.Chunk{chk}))
+			idx.chunks = append(idx.chunks, chk)
+		}
+	}
+
+	cfg := ChunkMergeConfig{Enabled: true, SmallChunkSize: 256 << 10, TargetChunkSize: 4 << 20}
+	workDir := t.TempDir()
+	merger := newStoreChunkMerger(cfg, chunkMergeLimits{rateBytes: 10 << 20, encoding: "snappy"}, chunkClient, schemaCfg, chunkenc.ChunkFormatV4, chunkenc.UnorderedWithStructuredMetadataHeadBlockFmt, workDir, 0, prometheus.NewRegistry())
+	_, err := merger.MergeChunks(ctx, tableName, "user", idx, log.NewNopLogger())
+	require.Error(t, err)
+
+	// The chunk merged before the failure is deleted, and the small chunks are
+	// kept.
+	require.Len(t, idx.indexed, 1)
+	require.Empty(t, idx.deleted)
+	require.Len(t, objects.Internals(), 4)
+	for _, c := range idx.chunks {
+		require.Contains(t, objects.Internals(), schemaCfg.ExternalKey(c.ChunkRef))
+	}
+	_, err = os.Stat(filepath.Join(workDir, retention.MarkersFolder))
+	require.True(t, os.IsNotExist(err))
+
+	// The merged chunks are deleted when the compacted index isn't uploaded.
+	idx.maxIndexed = 0
+	result, err := merger.MergeChunks(ctx, tableName, "user", idx, log.NewNopLogger())
+	require.NoError(t, err)
+	require.Len(t, idx.indexed, 3)
+	require.Len(t, objects.Internals(), 6)
+	is := &indexSet{ctx: ctx, logger: log.NewNopLogger(), mergedChunks: result}
+	is.cleanup()
+	require.Len(t, objects.Internals(), 4)
+	for _, c := range idx.chunks {
+		require.Contains(t, objects.Internals(), schemaCfg.ExternalKey(c.ChunkRef))
+	}
+	_, err = os.Stat(filepath.Join(workDir, retention.MarkersFolder))
+	require.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,420 @@
+package compactor
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/loki/pkg/compactor/retention"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/storage/config"
+	"github.com/grafana/loki/pkg/storage/packs"
+	"github.com/grafana/loki/pkg/storage/tiering"
+)
+
+const (
+	// packFetchBatchSize is the number of small chunks fetched at once to be
+	// packed.
+	packFetchBatchSize = 100
+	// packIndexCacheTTL is how long the compactor caches the pack indexes for
+	// reading the packed chunks. The compactor writes the pack indexes itself.
+	packIndexCacheTTL = 5 * time.Minute
+	// packReadParallelism is the number of range requests the compactor reads
+	// the packed chunks with in parallel.
+	packReadParallelism = 16
+)
+
+// ChunkPackConfig configures the packing of the small chunks into pack
+// objects.
+type ChunkPackConfig struct {
+	Enabled        bool `yaml:"enabled"`
+	SmallChunkSize int  `yaml:"small_chunk_size"`
+	TargetPackSize int  `yaml:"target_pack_size"`
+}
+
+// RegisterFlagsWithPrefix registers flags.
+func (cfg *ChunkPackConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, prefix+"chunk-pack.enabled", false, "Experimental. Pack the small chunks of the tenants into larger pack objects while applying retention, so they are read with fewer requests to the object store. All the components reading chunks need chunk_packs enabled in the storage_config. Requires retention_enabled and is only supported with the TSDB index.")
+	f.IntVar(&cfg.SmallChunkSize, prefix+"chunk-pack.small-chunk-size", 128<<10, "Chunks with an approximate uncompressed size below this size in bytes are packed.")
+	f.IntVar(&cfg.TargetPackSize, prefix+"chunk-pack.target-pack-size", 16<<20, "Maximum size in bytes of the pack objects.")
+}
+
+func (cfg *ChunkPackConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.SmallChunkSize <= 0 {
+		return errors.New("compactor.chunk-pack.small-chunk-size must be greater than 0")
+	}
+	if cfg.TargetPackSize <= 0 {
+		return errors.New("compactor.chunk-pack.target-pack-size must be greater than 0")
+	}
+	return nil
+}
+
+// chunkPacker packs the small chunks of the tables into pack objects.
+type chunkPacker interface {
+	// PackChunks packs the small chunks of the tenant in the table, rewrites
+	// the packs some of whose chunks are not indexed anymore and deletes the
+	// packs none of whose chunks are. The index is not modified, since the
+	// packed chunks are located with the pack indexes.
+	PackChunks(ctx context.Context, tableName, userID string, indexProcessor retention.IndexProcessor, logger log.Logger) error
+}
+
+type chunkPackerMetrics struct {
+	chunksPacked   prometheus.Counter
+	bytesPacked    prometheus.Counter
+	packsCreated   prometheus.Counter
+	packsRewritten prometheus.Counter
+	packsDeleted   prometheus.Counter
+}
+
+func newChunkPackerMetrics(r prometheus.Registerer) *chunkPackerMetrics {
+	return &chunkPackerMetrics{
+		chunksPacked: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki_compactor",
+			Name:      "chunks_packed_total",
+			Help:      "Total number of small chunks packed into pack objects",
+		}),
+		bytesPacked: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki_compactor",
+			Name:      "chunk_pack_bytes_total",
+			Help:      "Total size in bytes of the small chunks packed into pack objects",
+		}),
+		packsCreated: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki_compactor",
+			Name:      "chunk_packs_created_total",
+			Help:      "Total number of pack objects created",
+		}),
+		packsRewritten: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki_compactor",
+			Name:      "chunk_packs_rewritten_total",
+			Help:      "Total number of pack objects written with the chunks still indexed of a pack some of whose chunks were not indexed anymore",
+		}),
+		packsDeleted: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki_compactor",
+			Name:      "chunk_packs_deleted_total",
+			Help:      "Total number of pack objects deleted once none of their chunks were indexed anymore, or once rewritten",
+		}),
+	}
+}
+
+// storeChunkPacker packs the small chunks of the tenants into pack objects, a
+// table of a tenant at a time.
+//
+// A pack is first written, then added to the pack index of the tenant in the
+// table, which makes the queriers read its chunks from it, and finally the
+// own objects of its chunks are deleted. The queriers using a cached pack
+// index fetch it again once they don't find the chunks. The packs some of
+// whose chunks are not indexed anymore are rewritten with the chunks still
+// indexed. The packs none of whose chunks are indexed anymore, and the
+// rewritten ones, are deleted after the retention_delete_delay, so the
+// queriers using the previous index can still read them.
+type storeChunkPacker struct {
+	cfg         ChunkPackConfig
+	chunkClient *packs.Client
+	coldMarkers *tiering.Markers
+	schemaCfg   config.SchemaConfig
+	deleteDelay time.Duration
+	timeout     time.Duration
+	now         func() model.Time
+	metrics     *chunkPackerMetrics
+}
+
+func newStoreChunkPacker(cfg ChunkPackConfig, chunkClient *packs.Client, coldMarkers *tiering.Markers, schemaCfg config.SchemaConfig, deleteDelay, timeout time.Duration, r prometheus.Registerer) *storeChunkPacker {
+	return &storeChunkPacker{
+		cfg:         cfg,
+		chunkClient: chunkClient,
+		coldMarkers: coldMarkers,
+		schemaCfg:   schemaCfg,
+		deleteDelay: deleteDelay,
+		timeout:     timeout,
+		now:         model.Now,
+		metrics:     newChunkPackerMetrics(r),
+	}
+}
+
+// PackChunks implements chunkPacker.
+func (p *storeChunkPacker) PackChunks(ctx context.Context, tableName, userID string, indexProcessor retention.IndexProcessor, logger log.Logger) error {
+	if p.coldMarkers != nil {
+		state, err := p.coldMarkers.State(ctx, tableName, userID)
+		if err != nil {
+			return err
+		}
+		switch state {
+		case tiering.MarkerCopied:
+			// The chunks are being relocated to the cold object store.
+			return nil
+		case tiering.MarkerComplete:
+			// The chunks were relocated to the cold object store, including
+			// the packed ones.
+			return p.deleteAll(ctx, tableName, userID)
+		}
+	}
+
+	idx, modified, err := p.loadIndex(ctx, tableName, userID, logger)
+	if err != nil {
+		return err
+	}
+
+	// The chunks are packed along with the table their start time falls in.
+	tableInterval := retention.ExtractIntervalFromTableName(tableName)
+	smallKB := uint32(p.cfg.SmallChunkSize >> 10)
+	indexed := map[string]struct{}{}
+	var candidates []chunk.Chunk
+	err = indexProcessor.ForEachChunk(ctx, func(ce retention.ChunkEntry) (bool, error) {
+		if ce.From < tableInterval.Start || ce.From > tableInterval.End {
+			return false, nil
+		}
+		chk, err := chunk.ParseExternalKey(userID, string(ce.ChunkID))
+		if err != nil {
+			return false, err
+		}
+		key := p.schemaCfg.ExternalKey(chk.ChunkRef)
+		indexed[key] = struct{}{}
+		if _, packed := idx.Locate(key); !packed && ce.KB < smallKB {
+			candidates = append(candidates, chk)
+		}
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	compacted, err := p.compactPacks(ctx, tableName, userID, idx, indexed)
+	if err != nil {
+		return err
+	}
+	if compacted {
+		modified = true
+	}
+	if modified {
+		if err := p.chunkClient.Indexes().Put(ctx, tableName, userID, idx); err != nil {
+			return err
+		}
+	}
+
+	// Packing a single chunk would not save any request.
+	if len(candidates) < 2 {
+		return nil
+	}
+
+	// Stop packing after the timeout, resuming with the chunks left on the
+	// next run.
+	packCtx, cancel := ctxForTimeout(ctx, p.timeout)
+	defer cancel()
+
+	created, err := p.pack(packCtx, tableName, userID, idx, candidates)
+	if err != nil {
+		if ctx.Err() == nil && packCtx.Err() != nil {
+			level.Warn(logger).Log("msg", "timed out while packing chunks", "packs", created)
+			return nil
+		}
+		return fmt.Errorf("failed to pack chunks: %w", err)
+	}
+	if created > 0 {
+		level.Info(logger).Log("msg", "packed small chunks", "chunks", len(candidates), "packs", created)
+	}
+	return nil
+}
+
+// loadIndex loads the pack index of the tenant in the table, adding the packs
+// written before the compactor stopped without adding them to the index.
+func (p *storeChunkPacker) loadIndex(ctx context.Context, tableName, userID string, logger log.Logger) (*packs.Index, bool, error) {
+	idx, err := p.chunkClient.Indexes().Load(ctx, tableName, userID)
+	if err != nil {
+		return nil, false, err
+	}
+	names, err := p.chunkClient.Indexes().ListPacks(ctx, tableName, userID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	indexed := make(map[string]struct{}, len(idx.Packs))
+	for _, pack := range idx.Packs {
+		indexed[pack.Name] = struct{}{}
+	}
+	var modified bool
+	for _, name := range names {
+		if _, ok := indexed[name]; ok {
+			continue
+		}
+		pack, err := p.chunkClient.ReadPack(ctx, tableName, userID, name)
+		if err != nil {
+			return nil, false, err
+		}
+		level.Info(logger).Log("msg", "recovered pack missing from the pack index", "pack", name)
+		idx.Add(pack)
+		modified = true
+	}
+	return idx, modified, nil
+}
+
+// packRewrite is a pack of the index to rewrite with its chunks still
+// indexed.
+type packRewrite struct {
+	pack int
+	kept []chunk.Chunk
+}
+
+// compactPacks rewrites the packs some of whose chunks are not indexed
+// anymore with their chunks still indexed, so the chunks removed by the
+// retention or the delete requests don't stay in the object store, and
+// deletes the packs none of whose chunks are indexed anymore after the
+// retention delete delay. It returns whether the pack index was modified.
+//
+// A chunk belongs to the pack it's located in, the other packs it was written
+// to before are left to be deleted.
+func (p *storeChunkPacker) compactPacks(ctx context.Context, tableName, userID string, idx *packs.Index, indexed map[string]struct{}) (bool, error) {
+	now := p.now()
+	toDelete := map[string]struct{}{}
+	var toRewrite []packRewrite
+	var modified bool
+	for i := range idx.Packs {
+		pack := &idx.Packs[i]
+		var kept []chunk.Chunk
+		for _, c := range pack.Chunks {
+			if _, ok := indexed[c.Key]; !ok {
+				continue
+			}
+			if loc, _ := idx.Locate(c.Key); loc.Pack != pack.Name {
+				continue
+			}
+			chk, err := chunk.ParseExternalKey(userID, c.Key)
+			if err != nil {
+				return false, err
+			}
+			kept = append(kept, chk)
+		}
+		switch {
+		case len(kept) == len(pack.Chunks):
+			continue
+		case len(kept) > 0:
+			toRewrite = append(toRewrite, packRewrite{pack: i, kept: kept})
+		case pack.Unreferenced == 0:
+			pack.Unreferenced = int64(now)
+			modified = true
+		case now.Sub(model.Time(pack.Unreferenced)) >= p.deleteDelay:
+			if err := p.chunkClient.DeletePack(ctx, tableName, userID, pack.Name); err != nil {
+				return false, err
+			}
+			toDelete[pack.Name] = struct{}{}
+			p.metrics.packsDeleted.Inc()
+		}
+	}
+
+	// The rewritten packs are deleted after the retention delete delay, so the
+	// queriers using the previous index can still read them.
+	for _, r := range toRewrite {
+		fetched, err := p.chunkClient.GetChunks(ctx, r.kept)
+		if err != nil && !p.chunkClient.IsChunkNotFoundErr(err) {
+			return false, err
+		}
+		if len(fetched) > 0 {
+			pack, err := p.chunkClient.WritePack(ctx, tableName, userID, fetched)
+			if err != nil {
+				return false, err
+			}
+			idx.Add(pack)
+			p.metrics.packsRewritten.Inc()
+		}
+		idx.Packs[r.pack].Unreferenced = int64(now)
+		modified = true
+	}
+
+	if len(toDelete) > 0 {
+		idx.Remove(toDelete)
+		modified = true
+	}
+	return modified, nil
+}
+
+// pack packs the chunks, ordered by start time, into packs of up to the
+// target pack size, and returns the number of packs created.
+func (p *storeChunkPacker) pack(ctx context.Context, tableName, userID string, idx *packs.Index, candidates []chunk.Chunk) (int, error) {
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].From < candidates[j].From })
+
+	var (
+		created     int
+		pending     []chunk.Chunk
+		pendingSize int
+	)
+	flush := func() error {
+		if len(pending) < 2 {
+			return nil
+		}
+		pack, err := p.chunkClient.WritePack(ctx, tableName, userID, pending)
+		if err != nil {
+			return err
+		}
+		idx.Add(pack)
+		if err := p.chunkClient.Indexes().Put(ctx, tableName, userID, idx); err != nil {
+			return err
+		}
+		if err := p.chunkClient.DeleteChunks(ctx, pending); err != nil {
+			return err
+		}
+
+		created++
+		p.metrics.packsCreated.Inc()
+		p.metrics.chunksPacked.Add(float64(len(pending)))
+		p.metrics.bytesPacked.Add(float64(pack.Size()))
+		return nil
+	}
+
+	for i := 0; i < len(candidates); i += packFetchBatchSize {
+		batch := candidates[i:min(i+packFetchBatchSize, len(candidates))]
+		fetched, err := p.chunkClient.GetChunks(ctx, batch)
+		if err != nil && !p.chunkClient.IsChunkNotFoundErr(err) {
+			return created, err
+		}
+		// GetChunks doesn't keep the order of the chunks.
+		sort.Slice(fetched, func(i, j int) bool { return fetched[i].From < fetched[j].From })
+
+		for _, chk := range fetched {
+			encoded, err := chk.Encoded()
+			if err != nil {
+				return created, err
+			}
+			if len(pending) > 0 && pendingSize+len(encoded) > p.cfg.TargetPackSize {
+				if err := flush(); err != nil {
+					return created, err
+				}
+				pending, pendingSize = nil, 0
+			}
+			pending = append(pending, chk)
+			pendingSize += len(encoded)
+		}
+	}
+	return created, flush()
+}
+
+// deleteAll deletes the packs of the tenant in the table and its pack index.
+func (p *storeChunkPacker) deleteAll(ctx context.Context, tableName, userID string) error {
+	names, err := p.chunkClient.Indexes().ListPacks(ctx, tableName, userID)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	if err := p.chunkClient.Indexes().Put(ctx, tableName, userID, packs.NewIndex(nil)); err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := p.chunkClient.DeletePack(ctx, tableName, userID, name); err != nil {
+			return err
+		}
+		p.metrics.packsDeleted.Inc()
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package compactor
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/chunkenc"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/storage/chunk/client"
+	"github.com/grafana/loki/pkg/storage/chunk/client/local"
+	"github.com/grafana/loki/pkg/storage/config"
+	"github.com/grafana/loki/pkg/storage/packs"
+)
+
+func TestChunkPacker(t *testing.T) {
+	ctx := context.Background()
+	schemaCfg := config.SchemaConfig{Configs: []config.PeriodConfig{{
+		From:       config.DayTime{Time: 0},
+		IndexType:  config.TSDBType,
+		ObjectType: "filesystem",
+		Schema:     "v13",
+		IndexTables: config.IndexPeriodicTableConfig{
+			PeriodicTableConfig: config.PeriodicTableConfig{Prefix: "index_", Period: config.ObjectStorageIndexRequiredPeriod},
+		},
+	}}}
+
+	objects, err := local.NewFSObjectClient(local.FSConfig{Directory: t.TempDir()})
+	require.NoError(t, err)
+	newPacksClient := func() *packs.Client {
+		cfg := packs.Config{Enabled: true, IndexCacheTTL: time.Hour, MaxRangeGap: packs.DefaultMaxRangeGap, MaxRangeSize: packs.DefaultMaxRangeSize}
+		return packs.NewClient(newChunkClient(objects, schemaCfg, nil), objects, schemaCfg, cfg, 10, nil, prometheus.NewRegistry())
+	}
+	chunkClient := newPacksClient()
+
+	tableName := "index_19000"
+	tableStart := model.TimeFromUnixNano(19000 * int64(config.ObjectStorageIndexRequiredPeriod))
+
+	idx := &mergeTestIndex{schemaCfg: schemaCfg, deleted: map[string]struct{}{}}
+	putChunk := func(app string, from model.Time) int {
+		lbs := labels.FromStrings("app", app)
+		c := chunkenc.NewMemChunk(chunkenc.ChunkFormatV4, chunkenc.EncSnappy, chunkenc.UnorderedWithStructuredMetadataHeadBlockFmt, 256<<10, 0)
+		for i := 0; i < 10; i++ {
+			require.NoError(t, c.Append(&logproto.Entry{
+				Timestamp: from.Add(time.Duration(i) * time.Second).Time(),
+				Line:      fmt.Sprint(i),
+			}))
+		}
+		require.NoError(t, c.Close())
+		chkFrom, chkThrough := c.Bounds()
+		chk := chunk.NewChunk("user", model.Fingerprint(lbs.Hash()), lbs, chunkenc.NewFacade(c, 0, 0), model.TimeFromUnixNano(chkFrom.UnixNano()), model.TimeFromUnixNano(chkThrough.UnixNano()))
+		require.NoError(t, chk.Encode())
+		require.NoError(t, chunkClient.PutChunks(ctx, []chunk.Chunk{chk}))
+		idx.chunks = append(idx.chunks, chk)
+		encoded, err := chk.Encoded()
+		require.NoError(t, err)
+		return len(encoded)
+	}
+	var size int
+	for i := 0; i < 5; i++ {
+		size = putChunk(fmt.Sprint("app", i), tableStart.Add(time.Duration(i)*time.Hour))
+	}
+	// a chunk of the previous table also indexed in the table.
+	putChunk("previous", tableStart.Add(-time.Second))
+
+	// A querier caches the pack index before the chunks are packed.
+	querierClient := newPacksClient()
+	chks, err := querierClient.GetChunks(ctx, idx.chunks)
+	require.NoError(t, err)
+	require.Len(t, chks, 6)
+
+	// Packs of two chunks, the last chunk of the table is left alone.
+	cfg := ChunkPackConfig{Enabled: true, SmallChunkSize: 128 << 10, TargetPackSize: 2*size + size/2}
+	packer := newStoreChunkPacker(cfg, chunkClient, nil, schemaCfg, time.Hour, 0, prometheus.NewRegistry())
+	require.NoError(t, packer.PackChunks(ctx, tableName, "user", idx, log.NewNopLogger()))
+	require.Equal(t, 2.0, testutil.ToFloat64(packer.metrics.packsCreated))
+	require.Equal(t, 4.0, testutil.ToFloat64(packer.metrics.chunksPacked))
+
+	for i, c := range idx.chunks {
+		exists, _ := objects.ObjectExists(ctx, client.FSEncoder(schemaCfg, c))
+		require.Equal(t, i >= 4, exists)
+	}
+	for _, c := range []*packs.Client{querierClient, newPacksClient()} {
+		chks, err = c.GetChunks(ctx, idx.chunks)
+		require.NoError(t, err)
+		require.Len(t, chks, 6)
+	}
+
+	// The chunks already packed are not packed again.
+	require.NoError(t, packer.PackChunks(ctx, tableName, "user", idx, log.NewNopLogger()))
+	require.Equal(t, 2.0, testutil.ToFloat64(packer.metrics.packsCreated))
+
+	// A pack written before the compactor stopped is added to the pack index.
+	orphan, err := chunkClient.WritePack(ctx, tableName, "user", idx.chunks[4:5])
+	require.NoError(t, err)
+	require.NoError(t, packer.PackChunks(ctx, tableName, "user", idx, log.NewNopLogger()))
+	packIdx, err := chunkClient.Indexes().Load(ctx, tableName, "user")
+	require.NoError(t, err)
+	require.Len(t, packIdx.Packs, 3)
+	loc, ok := packIdx.Locate(schemaCfg.ExternalKey(idx.chunks[4].ChunkRef))
+	require.True(t, ok)
+	require.Equal(t, orphan.Name, loc.Pack)
+
+	// The pack none of whose chunks are indexed anymore is deleted after the
+	// delete delay.
+	for _, c := range idx.chunks[:2] {
+		idx.deleted[schemaCfg.ExternalKey(c.ChunkRef)] = struct{}{}
+	}
+	require.NoError(t, packer.PackChunks(ctx, tableName, "user", idx, log.NewNopLogger()))
+	packIdx, err = chunkClient.Indexes().Load(ctx, tableName, "user")
+	require.NoError(t, err)
+	require.Len(t, packIdx.Packs, 3)
+	require.Equal(t, 0.0, testutil.ToFloat64(packer.metrics.packsDeleted))
+
+	packer.now = func() model.Time { return model.Now().Add(2 * time.Hour) }
+	require.NoError(t, packer.PackChunks(ctx, tableName, "user", idx, log.NewNopLogger()))
+	packIdx, err = chunkClient.Indexes().Load(ctx, tableName, "user")
+	require.NoError(t, err)
+	require.Len(t, packIdx.Packs, 2)
+	require.Equal(t, 1.0, testutil.ToFloat64(packer.metrics.packsDeleted))
+	names, err := chunkClient.Indexes().ListPacks(ctx, tableName, "user")
+	require.NoError(t, err)
+	require.Len(t, names, 2)
+
+	chks, err = newPacksClient().GetChunks(ctx, idx.chunks[2:])
+	require.NoError(t, err)
+	require.Len(t, chks, 4)
+
+	// The pack some of whose chunks are not indexed anymore is rewritten with
+	// its chunks still indexed, and deleted after the delete delay.
+	querierClient = newPacksClient()
+	_, err = querierClient.GetChunks(ctx, idx.chunks[2:])
+	require.NoError(t, err)
+	idx.deleted[schemaCfg.ExternalKey(idx.chunks[2].ChunkRef)] = struct{}{}
+	require.NoError(t, packer.PackChunks(ctx, tableName, "user", idx, log.NewNopLogger()))
+	require.Equal(t, 1.0, testutil.ToFloat64(packer.metrics.packsRewritten))
+	packIdx, err = chunkClient.Indexes().Load(ctx, tableName, "user")
+	require.NoError(t, err)
+	require.Len(t, packIdx.Packs, 3)
+	require.NotZero(t, packIdx.Packs[0].Unreferenced)
+	loc, ok = packIdx.Locate(schemaCfg.ExternalKey(idx.chunks[3].ChunkRef))
+	require.True(t, ok)
+	require.Equal(t, packIdx.Packs[2].Name, loc.Pack)
+	for _, c := range []*packs.Client{querierClient, newPacksClient()} {
+		chks, err = c.GetChunks(ctx, idx.chunks[3:])
+		require.NoError(t, err)
+		require.Len(t, chks, 3)
+	}
+
+	packer.now = func() model.Time { return model.Now().Add(4 * time.Hour) }
+	require.NoError(t, packer.PackChunks(ctx, tableName, "user", idx, log.NewNopLogger()))
+	require.Equal(t, 2.0, testutil.ToFloat64(packer.metrics.packsDeleted))
+	names, err = chunkClient.Indexes().ListPacks(ctx, tableName, "user")
+	require.NoError(t, err)
+	require.Len(t, names, 2)
+	chks, err = newPacksClient().GetChunks(ctx, idx.chunks[3:])
+	require.NoError(t, err)
+	require.Len(t, chks, 3)
+}
@@ -0,0 +1,175 @@
+package compactor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/loki/pkg/compactor/retention"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/storage/tiering"
+)
+
+// relocateBatchSize is the number of chunks copied to the cold object store
+// at once.
+const relocateBatchSize = 100
+
+// coldMarkerCacheTTL is how long the compactor caches the absence of the tier
+// markers for. The compactor writes the tier markers itself, so it does not
+// need to notice the ones written by others quickly.
+const coldMarkerCacheTTL = 5 * time.Minute
+
+// ChunkTieringLimits are the per-tenant limits of the storage tiering.
+type ChunkTieringLimits interface {
+	ColdStorageAfter(userID string) time.Duration
+}
+
+// chunkTierer relocates the chunks of the tables to the cold object store.
+type chunkTierer interface {
+	// TierChunks relocates the chunks of the tenant in the table to the cold
+	// object store once they are old enough. The index is not modified, since
+	// the tier of the chunks is resolved from the tier markers.
+	TierChunks(ctx context.Context, tableName, userID string, indexProcessor retention.IndexProcessor, logger log.Logger) error
+}
+
+type chunkTiererMetrics struct {
+	chunksRelocated prometheus.Counter
+	bytesRelocated  prometheus.Counter
+	tablesRelocated prometheus.Counter
+}
+
+func newChunkTiererMetrics(r prometheus.Registerer) *chunkTiererMetrics {
+	return &chunkTiererMetrics{
+		chunksRelocated: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki_compactor",
+			Name:      "cold_storage_relocated_chunks_total",
+			Help:      "Total number of chunks relocated to the cold object store",
+		}),
+		bytesRelocated: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki_compactor",
+			Name:      "cold_storage_relocated_chunk_bytes_total",
+			Help:      "Total size in bytes of the chunks relocated to the cold object store",
+		}),
+		tablesRelocated: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki_compactor",
+			Name:      "cold_storage_relocated_tenant_tables_total",
+			Help:      "Total number of tables of tenants whose chunks were all relocated to the cold object store",
+		}),
+	}
+}
+
+// storeChunkTierer relocates the chunks of the tenants with cold_storage_after
+// to the cold object store, a whole table of a tenant at a time.
+//
+// The chunks are first copied to the cold object store, then the tier marker
+// of the tenant in the table is written, which makes the queriers read them
+// from the cold object store, and finally their copies in the hot object store
+// are deleted. The queriers still reading from the hot object store because of
+// their cached tier markers fall back to the cold one.
+type storeChunkTierer struct {
+	limits      ChunkTieringLimits
+	chunkClient *tiering.Client
+	timeout     time.Duration
+	now         func() model.Time
+	metrics     *chunkTiererMetrics
+}
+
+func newStoreChunkTierer(limits ChunkTieringLimits, chunkClient *tiering.Client, timeout time.Duration, r prometheus.Registerer) *storeChunkTierer {
+	return &storeChunkTierer{
+		limits:      limits,
+		chunkClient: chunkClient,
+		timeout:     timeout,
+		now:         model.Now,
+		metrics:     newChunkTiererMetrics(r),
+	}
+}
+
+// TierChunks implements chunkTierer.
+func (t *storeChunkTierer) TierChunks(ctx context.Context, tableName, userID string, indexProcessor retention.IndexProcessor, logger log.Logger) error {
+	coldAfter := t.limits.ColdStorageAfter(userID)
+	if coldAfter <= 0 {
+		return nil
+	}
+	tableInterval := retention.ExtractIntervalFromTableName(tableName)
+	if tableInterval.End.After(t.now().Add(-coldAfter)) {
+		return nil
+	}
+
+	markers := t.chunkClient.Markers()
+	state, err := markers.State(ctx, tableName, userID)
+	if err != nil {
+		return err
+	}
+	if state == tiering.MarkerComplete {
+		return nil
+	}
+
+	// The chunks are relocated along with the table their start time falls in.
+	var chunks []chunk.Chunk
+	err = indexProcessor.ForEachChunk(ctx, func(ce retention.ChunkEntry) (bool, error) {
+		if ce.From < tableInterval.Start || ce.From > tableInterval.End {
+			return false, nil
+		}
+		chk, err := chunk.ParseExternalKey(userID, string(ce.ChunkID))
+		if err != nil {
+			return false, err
+		}
+		chunks = append(chunks, chk)
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Stop relocating after the timeout, resuming from the same step on the
+	// next run.
+	tierCtx, cancel := ctxForTimeout(ctx, t.timeout)
+	defer cancel()
+
+	if state == "" {
+		if err := t.relocate(tierCtx, chunks); err != nil {
+			if ctx.Err() == nil && tierCtx.Err() != nil {
+				level.Warn(logger).Log("msg", "timed out while relocating chunks to the cold object store")
+				return nil
+			}
+			return fmt.Errorf("failed to relocate chunks to the cold object store: %w", err)
+		}
+		if err := markers.Mark(ctx, tableName, userID, tiering.MarkerCopied); err != nil {
+			return err
+		}
+	}
+
+	if err := t.chunkClient.DeleteHot(tierCtx, chunks); err != nil {
+		if ctx.Err() == nil && tierCtx.Err() != nil {
+			level.Warn(logger).Log("msg", "timed out while deleting chunks relocated to the cold object store")
+			return nil
+		}
+		return fmt.Errorf("failed to delete chunks relocated to the cold object store: %w", err)
+	}
+	if err := markers.Mark(ctx, tableName, userID, tiering.MarkerComplete); err != nil {
+		return err
+	}
+
+	t.metrics.tablesRelocated.Inc()
+	level.Info(logger).Log("msg", "relocated chunks to the cold object store", "chunks", len(chunks))
+	return nil
+}
+
+func (t *storeChunkTierer) relocate(ctx context.Context, chunks []chunk.Chunk) error {
+	for i := 0; i < len(chunks); i += relocateBatchSize {
+		batch := chunks[i:min(i+relocateBatchSize, len(chunks))]
+		size, err := t.chunkClient.Relocate(ctx, batch)
+		if err != nil {
+			return err
+		}
+		t.metrics.chunksRelocated.Add(float64(len(batch)))
+		t.metrics.bytesRelocated.Add(float64(size))
+	}
+	return nil
+}
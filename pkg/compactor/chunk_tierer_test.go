@@ -0,0 +1,126 @@
+package compactor
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/chunkenc"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/storage/chunk/client"
+	"github.com/grafana/loki/pkg/storage/chunk/client/local"
+	"github.com/grafana/loki/pkg/storage/config"
+	"github.com/grafana/loki/pkg/storage/tiering"
+)
+
+type chunkTieringLimits map[string]time.Duration
+
+func (l chunkTieringLimits) ColdStorageAfter(userID string) time.Duration {
+	return l[userID]
+}
+
+func TestChunkTierer(t *testing.T) {
+	ctx := context.Background()
+	schemaCfg := config.SchemaConfig{Configs: []config.PeriodConfig{{
+		From:       config.DayTime{Time: 0},
+		IndexType:  config.TSDBType,
+		ObjectType: "filesystem",
+		Schema:     "v13",
+		IndexTables: config.IndexPeriodicTableConfig{
+			PeriodicTableConfig: config.PeriodicTableConfig{Prefix: "index_", Period: config.ObjectStorageIndexRequiredPeriod},
+		},
+	}}}
+
+	dir := t.TempDir()
+	hotObjects, err := local.NewFSObjectClient(local.FSConfig{Directory: filepath.Join(dir, "hot")})
+	require.NoError(t, err)
+	coldObjects, err := local.NewFSObjectClient(local.FSConfig{Directory: filepath.Join(dir, "cold")})
+	require.NoError(t, err)
+	newTieredClient := func() *tiering.Client {
+		return tiering.NewClient(newChunkClient(hotObjects, schemaCfg, nil), newChunkClient(coldObjects, schemaCfg, nil), tiering.NewMarkers(coldObjects, time.Hour), schemaCfg, prometheus.NewRegistry())
+	}
+	chunkClient := newTieredClient()
+
+	tableNumber := time.Now().Add(-72*time.Hour).UnixNano() / int64(config.ObjectStorageIndexRequiredPeriod)
+	tableName := fmt.Sprintf("index_%d", tableNumber)
+	tableStart := model.TimeFromUnixNano(tableNumber * int64(config.ObjectStorageIndexRequiredPeriod))
+
+	idx := &mergeTestIndex{schemaCfg: schemaCfg, deleted: map[string]struct{}{}}
+	putChunk := func(from model.Time) {
+		lbs := labels.FromStrings("app", "foo")
+		c := chunkenc.NewMemChunk(chunkenc.ChunkFormatV4, chunkenc.EncSnappy, chunkenc.UnorderedWithStructuredMetadataHeadBlockFmt, 256<<10, 0)
+		for i := 0; i < 10; i++ {
+			require.NoError(t, c.Append(&logproto.Entry{
+				Timestamp: from.Add(time.Duration(i) * time.Second).Time(),
+				Line:      fmt.Sprint(i),
+			}))
+		}
+		require.NoError(t, c.Close())
+		chkFrom, chkThrough := c.Bounds()
+		chk := chunk.NewChunk("user", model.Fingerprint(lbs.Hash()), lbs, chunkenc.NewFacade(c, 0, 0), model.TimeFromUnixNano(chkFrom.UnixNano()), model.TimeFromUnixNano(chkThrough.UnixNano()))
+		require.NoError(t, chk.Encode())
+		require.NoError(t, chunkClient.PutChunks(ctx, []chunk.Chunk{chk}))
+		idx.chunks = append(idx.chunks, chk)
+	}
+	for i := 0; i < 3; i++ {
+		putChunk(tableStart.Add(time.Duration(i) * time.Hour))
+	}
+	// a chunk of the previous table also indexed in the table.
+	putChunk(tableStart.Add(-time.Second))
+
+	// A querier resolves the tier of the chunks before they are relocated.
+	querierClient := newTieredClient()
+	chks, err := querierClient.GetChunks(ctx, idx.chunks)
+	require.NoError(t, err)
+	require.Len(t, chks, 4)
+
+	newTierer := func(limits ChunkTieringLimits) *storeChunkTierer {
+		return newStoreChunkTierer(limits, chunkClient, 0, prometheus.NewRegistry())
+	}
+
+	// The table is not old enough.
+	require.NoError(t, newTierer(chunkTieringLimits{"user": 7 * 24 * time.Hour}).TierChunks(ctx, tableName, "user", idx, log.NewNopLogger()))
+	state, err := chunkClient.Markers().State(ctx, tableName, "user")
+	require.NoError(t, err)
+	require.Empty(t, state)
+
+	tierer := newTierer(chunkTieringLimits{"user": 24 * time.Hour})
+	require.NoError(t, tierer.TierChunks(ctx, tableName, "user", idx, log.NewNopLogger()))
+	state, err = chunkClient.Markers().State(ctx, tableName, "user")
+	require.NoError(t, err)
+	require.Equal(t, tiering.MarkerComplete, state)
+	require.Equal(t, 3.0, testutil.ToFloat64(tierer.metrics.chunksRelocated))
+	require.Equal(t, 1.0, testutil.ToFloat64(tierer.metrics.tablesRelocated))
+
+	for i, c := range idx.chunks {
+		key := client.FSEncoder(schemaCfg, c)
+		hotExists, _ := hotObjects.ObjectExists(ctx, key)
+		coldExists, _ := coldObjects.ObjectExists(ctx, key)
+		// the chunk of the previous table stays in the hot object store.
+		require.Equal(t, i == 3, hotExists)
+		require.Equal(t, i != 3, coldExists)
+	}
+
+	// The querier falls back to the cold object store until the absence of
+	// the tier marker expires from its cache, and a new querier reads the
+	// chunks from the tier they were relocated to.
+	for _, c := range []*tiering.Client{querierClient, newTieredClient()} {
+		chks, err = c.GetChunks(ctx, idx.chunks)
+		require.NoError(t, err)
+		require.Len(t, chks, 4)
+	}
+
+	// The relocation is complete, so the table is skipped.
+	require.NoError(t, tierer.TierChunks(ctx, tableName, "user", idx, log.NewNopLogger()))
+	require.Equal(t, 3.0, testutil.ToFloat64(tierer.metrics.chunksRelocated))
+}
@@ -18,15 +18,20 @@ import (
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
 
 	"github.com/grafana/loki/pkg/analytics"
+	"github.com/grafana/loki/pkg/chunkenc"
 	"github.com/grafana/loki/pkg/compactor/deletion"
 	"github.com/grafana/loki/pkg/compactor/retention"
 	"github.com/grafana/loki/pkg/storage/chunk/client"
 	"github.com/grafana/loki/pkg/storage/chunk/client/local"
 	chunk_util "github.com/grafana/loki/pkg/storage/chunk/client/util"
 	"github.com/grafana/loki/pkg/storage/config"
+	"github.com/grafana/loki/pkg/storage/dictionaries"
+	"github.com/grafana/loki/pkg/storage/packs"
 	"github.com/grafana/loki/pkg/storage/stores/shipper/indexshipper/storage"
+	"github.com/grafana/loki/pkg/storage/tiering"
 	"github.com/grafana/loki/pkg/util/filter"
 	util_log "github.com/grafana/loki/pkg/util/log"
 	lokiring "github.com/grafana/loki/pkg/util/ring"
@@ -88,6 +93,9 @@ type Config struct {
 	RunOnce                     bool                `yaml:"_" doc:"hidden"`
 	TablesToCompact             int                 `yaml:"tables_to_compact"`
 	SkipLatestNTables           int                 `yaml:"skip_latest_n_tables"`
+	ZstdDictionaries            DictionariesConfig  `yaml:"zstd_dictionaries" doc:"description=Configures the training of the zstd dictionaries of the tenants with zstd_dictionaries_enabled."`
+	ChunkMerge                  ChunkMergeConfig    `yaml:"chunk_merge" doc:"description=Configures the merging of the small chunks of the streams."`
+	ChunkPack                   ChunkPackConfig     `yaml:"chunk_pack" doc:"description=Configures the packing of the small chunks into pack objects."`
 }
 
 // RegisterFlags registers flags.
@@ -109,6 +117,9 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&cfg.RunOnce, "compactor.run-once", false, "Run the compactor one time to cleanup and compact index files only (no retention applied)")
 	f.IntVar(&cfg.TablesToCompact, "compactor.tables-to-compact", 0, "Number of tables that compactor will try to compact. Newer tables are chosen when this is less than the number of tables available.")
 	f.IntVar(&cfg.SkipLatestNTables, "compactor.skip-latest-n-tables", 0, "Do not compact N latest tables. Together with -compactor.run-once and -compactor.tables-to-compact, this is useful when clearing compactor backlogs.")
+	cfg.ZstdDictionaries.RegisterFlagsWithPrefix("compactor.", f)
+	cfg.ChunkMerge.RegisterFlagsWithPrefix("compactor.", f)
+	cfg.ChunkPack.RegisterFlagsWithPrefix("compactor.", f)
 
 	// Ring
 	skipFlags := []string{
@@ -134,6 +145,26 @@ func (cfg *Config) Validate() error {
 		return errors.New("Replication factor must not be changed as it will not take effect")
 	}
 
+	if err := cfg.ZstdDictionaries.Validate(); err != nil {
+		return err
+	}
+
+	if err := cfg.ChunkMerge.Validate(); err != nil {
+		return err
+	}
+
+	if cfg.ChunkMerge.Enabled && !cfg.RetentionEnabled {
+		return errors.New("compactor.chunk-merge.enabled requires compactor.retention-enabled")
+	}
+
+	if err := cfg.ChunkPack.Validate(); err != nil {
+		return err
+	}
+
+	if cfg.ChunkPack.Enabled && !cfg.RetentionEnabled {
+		return errors.New("compactor.chunk-pack.enabled requires compactor.retention-enabled")
+	}
+
 	if cfg.RetentionEnabled {
 		if cfg.DeleteRequestStore == "" {
 			return fmt.Errorf("compactor.delete-request-store should be configured when retention is enabled")
@@ -168,6 +199,7 @@ type Compactor struct {
 	DeleteRequestsGRPCHandler *deletion.GRPCRequestHandler
 	deleteRequestsManager     *deletion.DeleteRequestsManager
 	expirationChecker         retention.ExpirationChecker
+	dictionaryTrainer         *dictionaryTrainer
 	metrics                   *metrics
 	running                   bool
 	wg                        sync.WaitGroup
@@ -191,16 +223,31 @@ type Compactor struct {
 type storeContainer struct {
 	tableMarker        retention.TableMarker
 	sweeper            *retention.Sweeper
+	chunkMerger        chunkMerger
+	chunkTierer        chunkTierer
+	chunkPacker        chunkPacker
 	indexStorageClient storage.Client
 }
 
 type Limits interface {
 	deletion.Limits
 	retention.Limits
+	dictionaries.Limits
+	ChunkMergeLimits
+	ChunkTieringLimits
 	DefaultLimits() *validation.Limits
 }
 
-func NewCompactor(cfg Config, objectStoreClients map[config.DayTime]client.ObjectClient, deleteStoreClient client.ObjectClient, schemaConfig config.SchemaConfig, limits Limits, r prometheus.Registerer, metricsNamespace string) (*Compactor, error) {
+// Options are the optional dependencies of the compactor, left nil when the
+// features using them are disabled.
+type Options struct {
+	// DictionaryStoreClient is the object store of the zstd dictionaries.
+	DictionaryStoreClient client.ObjectClient
+	// ColdStoreClient is the cold object store the old chunks are relocated to.
+	ColdStoreClient client.ObjectClient
+}
+
+func NewCompactor(cfg Config, objectStoreClients map[config.DayTime]client.ObjectClient, deleteStoreClient client.ObjectClient, schemaConfig config.SchemaConfig, limits Limits, r prometheus.Registerer, metricsNamespace string, opts Options) (*Compactor, error) {
 	retentionEnabledStats.Set("false")
 	if cfg.RetentionEnabled {
 		retentionEnabledStats.Set("true")
@@ -256,7 +303,7 @@ func NewCompactor(cfg Config, objectStoreClients map[config.DayTime]client.Objec
 	compactor.subservicesWatcher = services.NewFailureWatcher()
 	compactor.subservicesWatcher.WatchManager(compactor.subservices)
 
-	if err := compactor.init(objectStoreClients, deleteStoreClient, schemaConfig, limits, r); err != nil {
+	if err := compactor.init(objectStoreClients, deleteStoreClient, schemaConfig, limits, r, opts); err != nil {
 		return nil, fmt.Errorf("init compactor: %w", err)
 	}
 
@@ -264,7 +311,7 @@ func NewCompactor(cfg Config, objectStoreClients map[config.DayTime]client.Objec
 	return compactor, nil
 }
 
-func (c *Compactor) init(objectStoreClients map[config.DayTime]client.ObjectClient, deleteStoreClient client.ObjectClient, schemaConfig config.SchemaConfig, limits Limits, r prometheus.Registerer) error {
+func (c *Compactor) init(objectStoreClients map[config.DayTime]client.ObjectClient, deleteStoreClient client.ObjectClient, schemaConfig config.SchemaConfig, limits Limits, r prometheus.Registerer, opts Options) error {
 	err := chunk_util.EnsureDirectory(c.cfg.WorkingDirectory)
 	if err != nil {
 		return err
@@ -280,7 +327,23 @@ func (c *Compactor) init(objectStoreClients map[config.DayTime]client.ObjectClie
 		}
 	}
 
+	// the chunks compressed with zstd dictionaries are read with the dictionaries of the store.
+	var (
+		dictionaryStore  *dictionaries.Store
+		zstdDictionaries *chunkenc.Dictionaries
+	)
+	if opts.DictionaryStoreClient != nil {
+		dictionaryStore = dictionaries.NewStore(opts.DictionaryStoreClient)
+		zstdDictionaries = chunkenc.NewDictionaries(dictionaryStore.Fetch)
+	}
+
+	var coldMarkers *tiering.Markers
+	if opts.ColdStoreClient != nil {
+		coldMarkers = tiering.NewMarkers(opts.ColdStoreClient, coldMarkerCacheTTL)
+	}
+
 	legacyMarkerDirs := make(map[string]struct{})
+	chunkClients := make(map[config.DayTime]client.Client, len(objectStoreClients))
 	c.storeContainers = make(map[config.DayTime]storeContainer, len(objectStoreClients))
 	for from, objectClient := range objectStoreClients {
 		period, err := schemaConfig.SchemaForTime(from.Time)
@@ -291,10 +354,26 @@ func (c *Compactor) init(objectStoreClients map[config.DayTime]client.ObjectClie
 		var sc storeContainer
 		sc.indexStorageClient = storage.NewIndexStorageClient(objectClient, period.IndexTables.PathPrefix)
 
+		var (
+			chunkClient  = newChunkClient(objectClient, schemaConfig, zstdDictionaries)
+			packsClient  *packs.Client
+			tieredClient *tiering.Client
+			chunkReg     = prometheus.WrapRegistererWith(prometheus.Labels{"from": fmt.Sprintf("%s_%s", period.ObjectType, period.From.String())}, r)
+		)
+		// the packed chunks are read from the hot object store only, since they are relocated to the cold one individually.
+		if c.cfg.ChunkPack.Enabled {
+			packsCfg := packs.Config{Enabled: true, IndexCacheTTL: packIndexCacheTTL, MaxRangeGap: packs.DefaultMaxRangeGap, MaxRangeSize: packs.DefaultMaxRangeSize}
+			packsClient = packs.NewClient(chunkClient, objectClient, schemaConfig, packsCfg, packReadParallelism, zstdDictionaries, chunkReg)
+			chunkClient = packsClient
+		}
+		if coldMarkers != nil {
+			tieredClient = tiering.NewClient(chunkClient, newChunkClient(opts.ColdStoreClient, schemaConfig, zstdDictionaries), coldMarkers, schemaConfig, chunkReg)
+			chunkClient = tieredClient
+		}
+		chunkClients[from] = chunkClient
+
 		if c.cfg.RetentionEnabled {
 			var (
-				raw              client.ObjectClient
-				encoder          client.KeyEncoder
 				name             = fmt.Sprintf("%s_%s", period.ObjectType, period.From.String())
 				retentionWorkDir = filepath.Join(c.cfg.WorkingDirectory, "retention", name)
 				r                = prometheus.WrapRegistererWith(prometheus.Labels{"from": name}, r)
@@ -313,16 +392,6 @@ func (c *Compactor) init(objectStoreClients map[config.DayTime]client.ObjectClie
 			// remove markers from the store dir after copying them to period specific dirs.
 			legacyMarkerDirs[period.ObjectType] = struct{}{}
 
-			if casted, ok := objectClient.(client.PrefixedObjectClient); ok {
-				raw = casted.GetDownstream()
-			} else {
-				raw = objectClient
-			}
-			if _, ok := raw.(*local.FSObjectClient); ok {
-				encoder = client.FSEncoder
-			}
-			chunkClient := client.NewClient(objectClient, encoder, schemaConfig)
-
 			sc.sweeper, err = retention.NewSweeper(retentionWorkDir, chunkClient, c.cfg.RetentionDeleteWorkCount, c.cfg.RetentionDeleteDelay, r)
 			if err != nil {
 				return fmt.Errorf("failed to init sweeper: %w", err)
@@ -332,6 +401,24 @@ func (c *Compactor) init(objectStoreClients map[config.DayTime]client.ObjectClie
 			if err != nil {
 				return fmt.Errorf("failed to init table marker: %w", err)
 			}
+
+			// chunk merging relies on the sizes of the chunks kept in the TSDB index.
+			if c.cfg.ChunkMerge.Enabled && period.IndexType == config.TSDBType && limits != nil {
+				chunkFormat, headBlockFmt, err := period.ChunkFormat()
+				if err != nil {
+					return fmt.Errorf("failed to init chunk merger: %w", err)
+				}
+				sc.chunkMerger = newStoreChunkMerger(c.cfg.ChunkMerge, limits, chunkClient, schemaConfig, chunkFormat, headBlockFmt, retentionWorkDir, c.cfg.RetentionTableTimeout, r)
+			}
+
+			if tieredClient != nil && limits != nil {
+				sc.chunkTierer = newStoreChunkTierer(limits, tieredClient, c.cfg.RetentionTableTimeout, r)
+			}
+
+			// chunk packing relies on the sizes of the chunks kept in the TSDB index.
+			if packsClient != nil && period.IndexType == config.TSDBType {
+				sc.chunkPacker = newStoreChunkPacker(c.cfg.ChunkPack, packsClient, coldMarkers, schemaConfig, c.cfg.RetentionDeleteDelay, c.cfg.RetentionTableTimeout, r)
+			}
 		}
 
 		c.storeContainers[from] = sc
@@ -350,10 +437,31 @@ func (c *Compactor) init(objectStoreClients map[config.DayTime]client.ObjectClie
 		}
 	}
 
+	if dictionaryStore != nil && limits != nil {
+		c.dictionaryTrainer = newDictionaryTrainer(c.cfg.ZstdDictionaries, dictionaryStore, limits, schemaConfig, chunkClients, r)
+	}
+
 	c.metrics = newMetrics(r)
 	return nil
 }
 
+// newChunkClient returns the chunk client of the chunks in the object store.
+func newChunkClient(objectClient client.ObjectClient, schemaConfig config.SchemaConfig, zstdDictionaries *chunkenc.Dictionaries) client.Client {
+	var (
+		raw     client.ObjectClient
+		encoder client.KeyEncoder
+	)
+	if casted, ok := objectClient.(client.PrefixedObjectClient); ok {
+		raw = casted.GetDownstream()
+	} else {
+		raw = objectClient
+	}
+	if _, ok := raw.(*local.FSObjectClient); ok {
+		encoder = client.FSEncoder
+	}
+	return client.NewClientWithDictionaries(objectClient, encoder, 0, schemaConfig, zstdDictionaries)
+}
+
 func (c *Compactor) initDeletes(objectClient client.ObjectClient, r prometheus.Registerer, limits Limits) error {
 	deletionWorkDir := filepath.Join(c.cfg.WorkingDirectory, "deletion")
 	store, err := deletion.NewDeleteStore(deletionWorkDir, storage.NewIndexStorageClient(objectClient, c.cfg.DeleteRequestStoreKeyPrefix))
@@ -640,8 +748,20 @@ func (c *Compactor) CompactTable(ctx context.Context, tableName string, applyRet
 	}
 	defer c.tableLocker.unlockTable(tableName)
 
+	var opts tableOptions
+	if c.dictionaryTrainer != nil {
+		opts.chunkSampler = c.dictionaryTrainer
+	}
+	// small chunks are merged and packed and chunks are relocated to the cold object store along with applying retention,
+	// which waits for the table lock.
+	if applyRetention {
+		opts.chunkMerger = sc.chunkMerger
+		opts.chunkTierer = sc.chunkTierer
+		opts.chunkPacker = sc.chunkPacker
+	}
+
 	table, err := newTable(ctx, filepath.Join(c.cfg.WorkingDirectory, tableName), sc.indexStorageClient, indexCompactor,
-		schemaCfg, sc.tableMarker, c.expirationChecker, c.cfg.UploadParallelism)
+		schemaCfg, sc.tableMarker, c.expirationChecker, c.cfg.UploadParallelism, opts)
 	if err != nil {
 		level.Error(util_log.Logger).Log("msg", "failed to initialize table for compaction", "table", tableName, "err", err)
 		return err
@@ -805,6 +925,10 @@ func (c *Compactor) RunCompaction(ctx context.Context, applyRetention bool) (err
 		return firstErr
 	}
 
+	if c.dictionaryTrainer != nil && ctx.Err() == nil {
+		c.dictionaryTrainer.train(ctx)
+	}
+
 	return ctx.Err()
 }
 
@@ -818,11 +942,24 @@ func newExpirationChecker(retentionExpiryChecker, deletionExpiryChecker retentio
 }
 
 func (e *expirationChecker) Expired(ref retention.ChunkEntry, now model.Time) (bool, filter.Func) {
-	if expired, nonDeletedIntervals := e.retentionExpiryChecker.Expired(ref, now); expired {
-		return expired, nonDeletedIntervals
+	expired, retentionFilter := e.retentionExpiryChecker.Expired(ref, now)
+	if expired && retentionFilter == nil {
+		return true, nil
+	}
+
+	// Delete requests still have to see the chunks only partially expired, for
+	// them to be processed in this pass.
+	deleted, deleteFilter := e.deletionExpiryChecker.Expired(ref, now)
+	if !expired || (deleted && deleteFilter == nil) {
+		return deleted, deleteFilter
+	}
+	if !deleted {
+		return true, retentionFilter
 	}
 
-	return e.deletionExpiryChecker.Expired(ref, now)
+	return true, func(ts time.Time, s string, structuredMetadata ...labels.Label) bool {
+		return deleteFilter(ts, s, structuredMetadata...) || retentionFilter(ts, s, structuredMetadata...)
+	}
 }
 
 func (e *expirationChecker) MarkPhaseStarted() {
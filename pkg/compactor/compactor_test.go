@@ -13,12 +13,15 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/loki/pkg/compactor/retention"
 	"github.com/grafana/loki/pkg/storage/chunk/client"
 	"github.com/grafana/loki/pkg/storage/chunk/client/local"
 	"github.com/grafana/loki/pkg/storage/config"
 	"github.com/grafana/loki/pkg/util/constants"
+	"github.com/grafana/loki/pkg/util/filter"
 	loki_net "github.com/grafana/loki/pkg/util/net"
 	"github.com/grafana/loki/pkg/validation"
 )
@@ -63,7 +66,7 @@ func setupTestCompactor(t *testing.T, objectClients map[config.DayTime]client.Ob
 
 	c, err := NewCompactor(cfg, objectClients, objectClients[periodConfigs[len(periodConfigs)-1].From], config.SchemaConfig{
 		Configs: periodConfigs,
-	}, overrides, prometheus.NewPedanticRegistry(), constants.Loki)
+	}, overrides, prometheus.NewPedanticRegistry(), constants.Loki, Options{})
 	require.NoError(t, err)
 
 	c.RegisterIndexCompactor("dummy", testIndexCompactor{})
@@ -449,3 +452,56 @@ func TestCompactor_TableLocking(t *testing.T) {
 		})
 	}
 }
+
+type fakeExpirationChecker struct {
+	retention.ExpirationChecker
+	expired bool
+	filter  filter.Func
+}
+
+func (f fakeExpirationChecker) Expired(_ retention.ChunkEntry, _ model.Time) (bool, filter.Func) {
+	return f.expired, f.filter
+}
+
+func TestExpirationChecker_Expired(t *testing.T) {
+	ts := time.Unix(0, 0)
+	retentionFilter := func(_ time.Time, s string, _ ...labels.Label) bool { return s == "expired" }
+	deleteFilter := func(_ time.Time, s string, _ ...labels.Label) bool { return s == "deleted" }
+
+	for _, tc := range []struct {
+		name                string
+		retention, deletion fakeExpirationChecker
+		expired             bool
+		deletedLines        []string
+	}{
+		{name: "nothing expired"},
+		{name: "whole chunk expired", retention: fakeExpirationChecker{expired: true}, deletion: fakeExpirationChecker{expired: true, filter: deleteFilter}, expired: true},
+		{name: "whole chunk deleted", retention: fakeExpirationChecker{expired: true, filter: retentionFilter}, deletion: fakeExpirationChecker{expired: true}, expired: true},
+		{name: "lines expired", retention: fakeExpirationChecker{expired: true, filter: retentionFilter}, expired: true, deletedLines: []string{"expired"}},
+		{name: "lines deleted", deletion: fakeExpirationChecker{expired: true, filter: deleteFilter}, expired: true, deletedLines: []string{"deleted"}},
+		{
+			name:         "lines expired and deleted",
+			retention:    fakeExpirationChecker{expired: true, filter: retentionFilter},
+			deletion:     fakeExpirationChecker{expired: true, filter: deleteFilter},
+			expired:      true,
+			deletedLines: []string{"expired", "deleted"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			expired, ff := newExpirationChecker(tc.retention, tc.deletion).Expired(retention.ChunkEntry{}, model.Now())
+			require.Equal(t, tc.expired, expired)
+			if tc.deletedLines == nil {
+				require.Nil(t, ff)
+				return
+			}
+
+			var deletedLines []string
+			for _, line := range []string{"expired", "deleted", "kept"} {
+				if ff(ts, line) {
+					deletedLines = append(deletedLines, line)
+				}
+			}
+			require.Equal(t, tc.deletedLines, deletedLines)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package deletion
 
 import (
+	"sort"
 	"time"
 
 	"github.com/go-kit/log/level"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/grafana/loki/pkg/compactor/retention"
 	"github.com/grafana/loki/pkg/logql/syntax"
+	"github.com/grafana/loki/pkg/storage/config"
 	"github.com/grafana/loki/pkg/util/filter"
 	util_log "github.com/grafana/loki/pkg/util/log"
 )
@@ -24,6 +26,9 @@ type DeleteRequest struct {
 	Query     string              `json:"query"`
 	Status    DeleteRequestStatus `json:"status"`
 	CreatedAt model.Time          `json:"created_at"`
+	// DryRun requests don't delete anything. They only record the chunks and
+	// lines which would have been deleted.
+	DryRun bool `json:"dry_run,omitempty"`
 
 	UserID          string                 `json:"-"`
 	SequenceNum     int64                  `json:"-"`
@@ -33,6 +38,43 @@ type DeleteRequest struct {
 
 	Metrics      *deleteRequestsManagerMetrics `json:"-"`
 	DeletedLines int32                         `json:"-"`
+
+	// deletedChunks is the audit trail of the request in the current
+	// compaction, listing the first chunks processed by chunk ID.
+	deletedChunks DeletedChunks
+	listedChunks  map[string]*DeletedChunk
+	// spanningChunks are the IDs of the chunks processed spanning multiple
+	// tables, which are processed once per table.
+	spanningChunks map[string]struct{}
+}
+
+// maxListedDeletedChunks is the number of chunks listed in the audit trail of
+// a delete request. The chunks processed after those are only counted.
+const maxListedDeletedChunks = 1000
+
+// DeletedChunks is the audit trail of a delete request.
+type DeletedChunks struct {
+	// Chunks, Lines and Bytes are the totals of the chunks lines were deleted
+	// from.
+	Chunks int64
+	Lines  int64
+	Bytes  int64
+	// Listed are up to maxListedDeletedChunks of the chunks, sorted by chunk
+	// ID.
+	Listed []DeletedChunk
+}
+
+// DeletedChunk is the audit record of a chunk processed by a delete request.
+type DeletedChunk struct {
+	ChunkID string `json:"chunk_id"`
+	// Whole is true if the whole chunk was deleted, rather than rewritten
+	// without the deleted lines.
+	Whole bool `json:"whole"`
+	// Lines and Bytes are the number and uncompressed size of the deleted lines.
+	// They are estimated from the index for whole chunks, and are only known
+	// with the TSDB index.
+	Lines int64 `json:"lines"`
+	Bytes int64 `json:"bytes"`
 }
 
 func (d *DeleteRequest) SetQuery(logQL string) error {
@@ -87,8 +129,10 @@ func (d *DeleteRequest) FilterFunction(lbls labels.Labels) (filter.Func, error)
 
 		result, _, skip := f(0, s, structuredMetadata...)
 		if len(result) != 0 || skip {
-			d.Metrics.deletedLinesTotal.WithLabelValues(d.UserID).Inc()
-			d.DeletedLines++
+			if !d.DryRun {
+				d.Metrics.deletedLinesTotal.WithLabelValues(d.UserID).Inc()
+				d.DeletedLines++
+			}
 			return true
 		}
 		return false
@@ -160,6 +204,76 @@ func (d *DeleteRequest) IsDeleted(entry retention.ChunkEntry) (bool, filter.Func
 	return true, ff
 }
 
+// recordChunk adds the chunk to the audit trail of the request. ff is the
+// filter.Func returned by IsDeleted for the chunk, and when not nil, the returned
+// filter.Func wraps it to count the lines it deletes.
+func (d *DeleteRequest) recordChunk(entry retention.ChunkEntry, ff filter.Func) filter.Func {
+	chunkID := string(entry.ChunkID)
+	if tableNumber(entry.From) != tableNumber(entry.Through) {
+		if _, ok := d.spanningChunks[chunkID]; ok {
+			// the chunk is recorded once.
+			return ff
+		}
+		if d.spanningChunks == nil {
+			d.spanningChunks = map[string]struct{}{}
+		}
+		d.spanningChunks[chunkID] = struct{}{}
+	}
+
+	deletedChunk := &DeletedChunk{ChunkID: chunkID}
+	if ff == nil {
+		deletedChunk.Whole = true
+		d.addDeletedLines(deletedChunk, int64(entry.Entries), int64(entry.KB)<<10)
+		return nil
+	}
+
+	return func(ts time.Time, s string, structuredMetadata ...labels.Label) bool {
+		if !ff(ts, s, structuredMetadata...) {
+			return false
+		}
+		d.addDeletedLines(deletedChunk, 1, int64(len(s)))
+		return true
+	}
+}
+
+// addDeletedLines adds deleted lines to a chunk of the audit trail, listing it
+// with its first deleted lines until enough chunks are listed.
+func (d *DeleteRequest) addDeletedLines(deletedChunk *DeletedChunk, lines, bytes int64) {
+	// whole chunks are added at once.
+	if deletedChunk.Whole || deletedChunk.Lines == 0 {
+		d.deletedChunks.Chunks++
+		if len(d.listedChunks) < maxListedDeletedChunks {
+			if d.listedChunks == nil {
+				d.listedChunks = map[string]*DeletedChunk{}
+			}
+			d.listedChunks[deletedChunk.ChunkID] = deletedChunk
+		}
+	}
+	deletedChunk.Lines += lines
+	deletedChunk.Bytes += bytes
+	d.deletedChunks.Lines += lines
+	d.deletedChunks.Bytes += bytes
+}
+
+// DeletedChunks returns the audit trail of the request in the current
+// compaction.
+func (d *DeleteRequest) DeletedChunks() DeletedChunks {
+	deletedChunks := d.deletedChunks
+	deletedChunks.Listed = make([]DeletedChunk, 0, len(d.listedChunks))
+	for _, deletedChunk := range d.listedChunks {
+		deletedChunks.Listed = append(deletedChunks.Listed, *deletedChunk)
+	}
+	sort.Slice(deletedChunks.Listed, func(i, j int) bool {
+		return deletedChunks.Listed[i].ChunkID < deletedChunks.Listed[j].ChunkID
+	})
+	return deletedChunks
+}
+
+// tableNumber returns the number of the index table the time falls in.
+func tableNumber(t model.Time) int64 {
+	return int64(t) / int64(config.ObjectStorageIndexRequiredPeriod/time.Millisecond)
+}
+
 func intervalsOverlap(interval1, interval2 model.Interval) bool {
 	if interval1.Start > interval2.End || interval2.Start > interval1.End {
 		return false
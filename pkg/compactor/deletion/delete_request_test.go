@@ -1,6 +1,7 @@
 package deletion
 
 import (
+	"fmt"
 	"math"
 	"strings"
 	"testing"
@@ -432,3 +433,35 @@ func TestDeleteRequest_FilterFunction(t *testing.T) {
 		require.Panics(t, func() { testutil.ToFloat64(dr.Metrics.deletedLinesTotal) })
 	})
 }
+
+func TestDeleteRequest_DeletedChunks(t *testing.T) {
+	var dr DeleteRequest
+	chunkEntry := func(chunkID string, from, through model.Time) retention.ChunkEntry {
+		return retention.ChunkEntry{ChunkRef: retention.ChunkRef{ChunkID: []byte(chunkID), From: from, Through: through, Entries: 10, KB: 1}}
+	}
+
+	// a chunk spanning two tables is recorded once.
+	spanning := chunkEntry("spanning", model.TimeFromUnix(0).Add(24*time.Hour-time.Minute), model.TimeFromUnix(0).Add(24*time.Hour+time.Minute))
+	require.Nil(t, dr.recordChunk(spanning, nil))
+	require.Nil(t, dr.recordChunk(spanning, nil))
+
+	// the chunks are only counted once enough of them are listed.
+	for i := 0; i < maxListedDeletedChunks+10; i++ {
+		require.Nil(t, dr.recordChunk(chunkEntry(fmt.Sprintf("whole-%d", i), 0, 1), nil))
+	}
+
+	// a chunk without deleted lines is neither listed nor counted.
+	deleteFizz := func(_ time.Time, s string, _ ...labels.Label) bool { return s == "fizz" }
+	ff := dr.recordChunk(chunkEntry("kept", 0, 1), deleteFizz)
+	require.False(t, ff(time.Unix(0, 0), "buzz"))
+	ff = dr.recordChunk(chunkEntry("filtered", 0, 1), deleteFizz)
+	require.True(t, ff(time.Unix(0, 0), "fizz"))
+	require.True(t, ff(time.Unix(0, 0), "fizz"))
+
+	deletedChunks := dr.DeletedChunks()
+	require.Equal(t, int64(maxListedDeletedChunks+12), deletedChunks.Chunks)
+	require.Equal(t, int64((maxListedDeletedChunks+11)*10+2), deletedChunks.Lines)
+	require.Equal(t, int64((maxListedDeletedChunks+11)<<10+8), deletedChunks.Bytes)
+	require.Len(t, deletedChunks.Listed, maxListedDeletedChunks)
+	require.Contains(t, deletedChunks.Listed, DeletedChunk{ChunkID: "spanning", Whole: true, Lines: 10, Bytes: 1 << 10})
+}
@@ -242,7 +242,7 @@ func (d *DeleteRequestsManager) Expired(ref retention.ChunkEntry, _ model.Time)
 		return false, nil
 	}
 
-	var filterFuncs []filter.Func
+	var filterFuncs, dryRunFilterFuncs []filter.Func
 
 	for _, deleteRequest := range d.deleteRequestsToProcess[userIDStr].requests {
 		isDeleted, ff := deleteRequest.IsDeleted(ref)
@@ -250,6 +250,15 @@ func (d *DeleteRequestsManager) Expired(ref retention.ChunkEntry, _ model.Time)
 			continue
 		}
 
+		if deleteRequest.DryRun {
+			// Dry runs only count the lines they would delete, which for whole
+			// chunks are known from the index without reading the chunk.
+			if ff = deleteRequest.recordChunk(ref, ff); ff != nil {
+				dryRunFilterFuncs = append(dryRunFilterFuncs, ff)
+			}
+			continue
+		}
+
 		if ff == nil {
 			level.Info(util_log.Logger).Log(
 				"msg", "no chunks to retain: the whole chunk is deleted",
@@ -258,18 +267,27 @@ func (d *DeleteRequestsManager) Expired(ref retention.ChunkEntry, _ model.Time)
 				"user", deleteRequest.UserID,
 				"chunkID", string(ref.ChunkID),
 			)
+			deleteRequest.recordChunk(ref, nil)
 			d.metrics.deleteRequestsChunksSelectedTotal.WithLabelValues(string(ref.UserID)).Inc()
 			return true, nil
 		}
-		filterFuncs = append(filterFuncs, ff)
+		filterFuncs = append(filterFuncs, deleteRequest.recordChunk(ref, ff))
 	}
 
-	if len(filterFuncs) == 0 {
+	if len(filterFuncs) == 0 && len(dryRunFilterFuncs) == 0 {
 		return false, nil
 	}
 
-	d.metrics.deleteRequestsChunksSelectedTotal.WithLabelValues(string(ref.UserID)).Inc()
+	if len(filterFuncs) != 0 {
+		d.metrics.deleteRequestsChunksSelectedTotal.WithLabelValues(string(ref.UserID)).Inc()
+	}
+	// A chunk selected only by dry runs is read without deleting any line from
+	// it, so it is kept as it is.
 	return true, func(ts time.Time, s string, structuredMetadata ...labels.Label) bool {
+		for _, ff := range dryRunFilterFuncs {
+			ff(ts, s, structuredMetadata...)
+		}
+
 		for _, ff := range filterFuncs {
 			if ff(ts, s, structuredMetadata...) {
 				return true
@@ -315,6 +333,17 @@ func (d *DeleteRequestsManager) MarkPhaseFinished() {
 		}
 
 		for _, deleteRequest := range userDeleteRequests.requests {
+			deletedChunks := deleteRequest.DeletedChunks()
+			if err := d.deleteRequestsStore.AddDeletedChunks(context.Background(), *deleteRequest, deletedChunks); err != nil {
+				level.Error(util_log.Logger).Log(
+					"msg", "failed to record the chunks processed by delete request for user",
+					"delete_request_id", deleteRequest.RequestID,
+					"sequence_num", deleteRequest.SequenceNum,
+					"user", deleteRequest.UserID,
+					"err", err,
+				)
+			}
+
 			if err := d.deleteRequestsStore.UpdateStatus(context.Background(), *deleteRequest, StatusProcessed); err != nil {
 				level.Error(util_log.Logger).Log(
 					"msg", "failed to mark delete request for user as processed",
@@ -323,6 +352,8 @@ func (d *DeleteRequestsManager) MarkPhaseFinished() {
 					"user", deleteRequest.UserID,
 					"err", err,
 					"deleted_lines", deleteRequest.DeletedLines,
+					"processed_chunks", deletedChunks.Chunks,
+					"dry_run", deleteRequest.DryRun,
 				)
 			} else {
 				level.Info(util_log.Logger).Log(
@@ -331,6 +362,8 @@ func (d *DeleteRequestsManager) MarkPhaseFinished() {
 					"sequence_num", deleteRequest.SequenceNum,
 					"user", deleteRequest.UserID,
 					"deleted_lines", deleteRequest.DeletedLines,
+					"processed_chunks", deletedChunks.Chunks,
+					"dry_run", deleteRequest.DryRun,
 				)
 			}
 			d.metrics.deleteRequestsProcessedTotal.WithLabelValues(deleteRequest.UserID).Inc()
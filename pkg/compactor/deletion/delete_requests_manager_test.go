@@ -776,6 +776,99 @@ func TestDeleteRequestsManager_Expired(t *testing.T) {
 	}
 }
 
+func TestDeleteRequestsManager_DryRun(t *testing.T) {
+	now := model.Now()
+	lblFoo, err := syntax.ParseLabels(`{foo="bar"}`)
+	require.NoError(t, err)
+
+	chunkEntry := retention.ChunkEntry{
+		ChunkRef: retention.ChunkRef{
+			UserID:  []byte(testUserID),
+			ChunkID: []byte("chunk"),
+			From:    now.Add(-12 * time.Hour),
+			Through: now.Add(-time.Hour),
+			KB:      2,
+			Entries: 100,
+		},
+		Labels: lblFoo,
+	}
+
+	t.Run("dry run with line filters", func(t *testing.T) {
+		store := &mockDeleteRequestsStore{deleteRequests: []DeleteRequest{
+			{
+				RequestID: "dry-run",
+				UserID:    testUserID,
+				Query:     lblFoo.String() + `|="fizz"`,
+				StartTime: now.Add(-24 * time.Hour),
+				EndTime:   now,
+				DryRun:    true,
+			},
+			{
+				RequestID: "delete",
+				UserID:    testUserID,
+				Query:     lblFoo.String(),
+				StartTime: now.Add(-6 * time.Hour),
+				EndTime:   now.Add(-5 * time.Hour),
+			},
+		}}
+		mgr := NewDeleteRequestsManager(store, time.Hour, 70, &fakeLimits{mode: deletionmode.FilterAndDelete.String()}, nil)
+		mgr.MarkPhaseStarted()
+
+		isExpired, filterFunc := mgr.Expired(chunkEntry, model.Now())
+		require.True(t, isExpired)
+		require.NotNil(t, filterFunc)
+
+		var fizzLines, fizzBytes, deletedLines, deletedBytes int64
+		for ts := chunkEntry.From; ts <= chunkEntry.Through; ts = ts.Add(time.Minute) {
+			line := "foo bar"
+			if ts.Time().Minute()%2 == 1 {
+				line = "fizz buzz"
+				fizzLines++
+				fizzBytes += int64(len(line))
+			}
+			deleted := ts >= now.Add(-6*time.Hour) && ts <= now.Add(-5*time.Hour)
+			if deleted {
+				deletedLines++
+				deletedBytes += int64(len(line))
+			}
+			// only the actual delete request deletes lines.
+			require.Equal(t, deleted, filterFunc(ts.Time(), line))
+		}
+
+		mgr.MarkPhaseFinished()
+		require.Equal(t, map[string]DeletedChunks{
+			"dry-run": {Chunks: 1, Lines: fizzLines, Bytes: fizzBytes, Listed: []DeletedChunk{{ChunkID: "chunk", Lines: fizzLines, Bytes: fizzBytes}}},
+			"delete":  {Chunks: 1, Lines: deletedLines, Bytes: deletedBytes, Listed: []DeletedChunk{{ChunkID: "chunk", Lines: deletedLines, Bytes: deletedBytes}}},
+		}, store.deletedChunks)
+		require.Len(t, store.updateStatusReqs, 2)
+	})
+
+	t.Run("dry run deleting whole chunks", func(t *testing.T) {
+		store := &mockDeleteRequestsStore{deleteRequests: []DeleteRequest{
+			{
+				RequestID: "dry-run",
+				UserID:    testUserID,
+				Query:     lblFoo.String(),
+				StartTime: now.Add(-24 * time.Hour),
+				EndTime:   now,
+				DryRun:    true,
+			},
+		}}
+		mgr := NewDeleteRequestsManager(store, time.Hour, 70, &fakeLimits{mode: deletionmode.FilterAndDelete.String()}, nil)
+		mgr.MarkPhaseStarted()
+
+		// the chunk is left as it is, without reading it.
+		isExpired, filterFunc := mgr.Expired(chunkEntry, model.Now())
+		require.False(t, isExpired)
+		require.Nil(t, filterFunc)
+
+		mgr.MarkPhaseFinished()
+		require.Equal(t, map[string]DeletedChunks{
+			"dry-run": {Chunks: 1, Lines: 100, Bytes: 2 << 10, Listed: []DeletedChunk{{ChunkID: "chunk", Whole: true, Lines: 100, Bytes: 2 << 10}}},
+		}, store.deletedChunks)
+	})
+}
+
 func TestDeleteRequestsManager_IntervalMayHaveExpiredChunks(t *testing.T) {
 	tt := []struct {
 		deleteRequestsFromStore []DeleteRequest
@@ -821,6 +914,29 @@ type mockDeleteRequestsStore struct {
 	getAllErr    error
 
 	genNumber string
+
+	updateStatusReqs []DeleteRequest
+	deletedChunks    map[string]DeletedChunks
+}
+
+func (m *mockDeleteRequestsStore) UpdateStatus(_ context.Context, req DeleteRequest, _ DeleteRequestStatus) error {
+	m.updateStatusReqs = append(m.updateStatusReqs, req)
+	return nil
+}
+
+func (m *mockDeleteRequestsStore) AddDeletedChunks(_ context.Context, req DeleteRequest, chunks DeletedChunks) error {
+	if chunks.Chunks == 0 {
+		return nil
+	}
+	if m.deletedChunks == nil {
+		m.deletedChunks = map[string]DeletedChunks{}
+	}
+	m.deletedChunks[req.RequestID] = chunks
+	return nil
+}
+
+func (m *mockDeleteRequestsStore) GetDeletedChunks(_ context.Context, req DeleteRequest) (DeletedChunks, error) {
+	return m.deletedChunks[req.RequestID], nil
 }
 
 func (m *mockDeleteRequestsStore) GetDeleteRequestsByStatus(_ context.Context, _ DeleteRequestStatus) ([]DeleteRequest, error) {
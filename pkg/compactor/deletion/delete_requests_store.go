@@ -33,6 +33,8 @@ const (
 	deleteRequestID      indexType = "1"
 	deleteRequestDetails indexType = "2"
 	cacheGenNum          indexType = "3"
+	deletedChunks        indexType = "4"
+	deletedChunksTotals  indexType = "5"
 
 	tempFileSuffix          = ".temp"
 	DeleteRequestsTableName = "delete_requests"
@@ -48,6 +50,8 @@ type DeleteRequestsStore interface {
 	GetDeleteRequestGroup(ctx context.Context, userID, requestID string) ([]DeleteRequest, error)
 	RemoveDeleteRequests(ctx context.Context, req []DeleteRequest) error
 	GetCacheGenerationNumber(ctx context.Context, userID string) (string, error)
+	AddDeletedChunks(ctx context.Context, req DeleteRequest, chunks DeletedChunks) error
+	GetDeletedChunks(ctx context.Context, req DeleteRequest) (DeletedChunks, error)
 	Stop()
 	Name() string
 }
@@ -127,13 +131,28 @@ func (ds *deleteRequestsStore) writeDeleteRequest(req DeleteRequest, writeBatch
 	writeBatch.Add(DeleteRequestsTableName, string(deleteRequestID), []byte(userIDAndRequestID), []byte(StatusReceived))
 
 	// Add another entry with additional details like creation time, time range of delete request and the logQL requests in value
-	rangeValue := fmt.Sprintf("%x:%x:%x", int64(ds.now()), int64(req.StartTime), int64(req.EndTime))
+	rangeValue := deleteRequestDetailsRangeValue(ds.now(), req)
 	writeBatch.Add(DeleteRequestsTableName, fmt.Sprintf("%s:%s", deleteRequestDetails, userIDAndRequestID), []byte(rangeValue), []byte(req.Query))
 
+	if req.DryRun {
+		// dry runs don't delete anything, so queriers don't filter anything for them.
+		return
+	}
+
 	// create a gen number for this result
 	writeBatch.Add(DeleteRequestsTableName, fmt.Sprintf("%s:%s", cacheGenNum, req.UserID), []byte{}, generateCacheGenNumber())
 }
 
+// deleteRequestDetailsRangeValue builds the range value of the details entry of a delete request out of its
+// creation time and time range. Dry runs get an additional part set to 1.
+func deleteRequestDetailsRangeValue(createdAt model.Time, req DeleteRequest) string {
+	rangeValue := fmt.Sprintf("%x:%x:%x", int64(createdAt), int64(req.StartTime), int64(req.EndTime))
+	if req.DryRun {
+		rangeValue += ":1"
+	}
+	return rangeValue
+}
+
 // backwardCompatibleDeleteRequestHash generates the hash key for a delete request.
 // Sequence numbers were added after deletion was in production so any requests made
 // before then won't have one. Ensure backward compatibility by treating the 0th
@@ -188,7 +207,7 @@ func (ds *deleteRequestsStore) UpdateStatus(ctx context.Context, req DeleteReque
 	writeBatch := ds.indexClient.NewWriteBatch()
 	writeBatch.Add(DeleteRequestsTableName, string(deleteRequestID), []byte(userIDAndRequestID), []byte(newStatus))
 
-	if newStatus == StatusProcessed {
+	if newStatus == StatusProcessed && !req.DryRun {
 		// remove runtime filtering for deleted data
 		writeBatch.Add(DeleteRequestsTableName, fmt.Sprintf("%s:%s", cacheGenNum, req.UserID), []byte{}, generateCacheGenNumber())
 	}
@@ -342,20 +361,124 @@ func (ds *deleteRequestsStore) removeRequest(req DeleteRequest, writeBatch index
 	writeBatch.Delete(DeleteRequestsTableName, string(deleteRequestID), []byte(userIDAndRequestID))
 
 	// Add another entry with additional details like creation time, time range of delete request and selectors in value
-	rangeValue := fmt.Sprintf("%x:%x:%x", int64(req.CreatedAt), int64(req.StartTime), int64(req.EndTime))
+	rangeValue := deleteRequestDetailsRangeValue(req.CreatedAt, req)
 	writeBatch.Delete(DeleteRequestsTableName, fmt.Sprintf("%s:%s", deleteRequestDetails, userIDAndRequestID), []byte(rangeValue))
 
 	// ensure caches are invalidated
 	writeBatch.Add(DeleteRequestsTableName, fmt.Sprintf("%s:%s", cacheGenNum, req.UserID), []byte{}, []byte(strconv.FormatInt(time.Now().UnixNano(), 10)))
 }
 
+// AddDeletedChunks records the audit trail of a delete request: its totals, and the chunks listed in it.
+func (ds *deleteRequestsStore) AddDeletedChunks(ctx context.Context, req DeleteRequest, chunks DeletedChunks) error {
+	if chunks.Chunks == 0 {
+		return nil
+	}
+
+	userIDAndRequestID := backwardCompatibleDeleteRequestHash(req.UserID, req.RequestID, req.SequenceNum)
+	hashValue := fmt.Sprintf("%s:%s", deletedChunks, userIDAndRequestID)
+
+	writeBatch := ds.indexClient.NewWriteBatch()
+	totals := fmt.Sprintf("%x:%x:%x", chunks.Chunks, chunks.Lines, chunks.Bytes)
+	writeBatch.Add(DeleteRequestsTableName, fmt.Sprintf("%s:%s", deletedChunksTotals, userIDAndRequestID), []byte{}, []byte(totals))
+	for _, c := range chunks.Listed {
+		whole := 0
+		if c.Whole {
+			whole = 1
+		}
+		value := fmt.Sprintf("%x:%x:%x", whole, c.Lines, c.Bytes)
+		writeBatch.Add(DeleteRequestsTableName, hashValue, []byte(c.ChunkID), []byte(value))
+	}
+
+	return ds.indexClient.BatchWrite(ctx, writeBatch)
+}
+
+// GetDeletedChunks returns the audit trail recorded for a delete request.
+func (ds *deleteRequestsStore) GetDeletedChunks(ctx context.Context, req DeleteRequest) (DeletedChunks, error) {
+	userIDAndRequestID := backwardCompatibleDeleteRequestHash(req.UserID, req.RequestID, req.SequenceNum)
+	queries := []index.Query{
+		{TableName: DeleteRequestsTableName, HashValue: fmt.Sprintf("%s:%s", deletedChunksTotals, userIDAndRequestID)},
+		{TableName: DeleteRequestsTableName, HashValue: fmt.Sprintf("%s:%s", deletedChunks, userIDAndRequestID)},
+	}
+
+	var chunks DeletedChunks
+	var parseErr error
+	err := ds.indexClient.QueryPages(ctx, queries, func(query index.Query, batch index.ReadBatchResult) (shouldContinue bool) {
+		itr := batch.Iterator()
+		for itr.Next() {
+			if query.HashValue == queries[0].HashValue {
+				if chunks, parseErr = parseDeletedChunksTotals(itr.Value(), chunks); parseErr != nil {
+					return false
+				}
+				continue
+			}
+
+			var c DeletedChunk
+			if c, parseErr = parseDeletedChunk(itr.RangeValue(), itr.Value()); parseErr != nil {
+				return false
+			}
+			chunks.Listed = append(chunks.Listed, c)
+		}
+		return true
+	})
+	if err != nil {
+		return DeletedChunks{}, err
+	}
+	if parseErr != nil {
+		return DeletedChunks{}, parseErr
+	}
+
+	return chunks, nil
+}
+
+func parseDeletedChunksTotals(value []byte, chunks DeletedChunks) (DeletedChunks, error) {
+	hexParts := strings.Split(string(value), ":")
+	if len(hexParts) != 3 {
+		return chunks, errors.New("invalid value in parsing deleted chunks totals lookup response")
+	}
+
+	var err error
+	for i, total := range []*int64{&chunks.Chunks, &chunks.Lines, &chunks.Bytes} {
+		if *total, err = strconv.ParseInt(hexParts[i], 16, 64); err != nil {
+			return chunks, err
+		}
+	}
+	return chunks, nil
+}
+
+func parseDeletedChunk(rangeValue, value []byte) (DeletedChunk, error) {
+	hexParts := strings.Split(string(value), ":")
+	if len(hexParts) != 3 {
+		return DeletedChunk{}, errors.New("invalid value in parsing deleted chunk lookup response")
+	}
+
+	whole, err := strconv.ParseInt(hexParts[0], 16, 64)
+	if err != nil {
+		return DeletedChunk{}, err
+	}
+	lines, err := strconv.ParseInt(hexParts[1], 16, 64)
+	if err != nil {
+		return DeletedChunk{}, err
+	}
+	bytes, err := strconv.ParseInt(hexParts[2], 16, 64)
+	if err != nil {
+		return DeletedChunk{}, err
+	}
+
+	return DeletedChunk{
+		ChunkID: string(rangeValue),
+		Whole:   whole == 1,
+		Lines:   lines,
+		Bytes:   bytes,
+	}, nil
+}
+
 func (ds *deleteRequestsStore) Name() string {
 	return "delete_requests_store"
 }
 
 func parseDeleteRequestTimestamps(rangeValue []byte, deleteRequest DeleteRequest) (DeleteRequest, error) {
 	hexParts := strings.Split(string(rangeValue), ":")
-	if len(hexParts) != 3 {
+	if len(hexParts) != 3 && len(hexParts) != 4 {
 		return deleteRequest, errors.New("invalid key in parsing delete request lookup response")
 	}
 
@@ -376,6 +499,7 @@ func parseDeleteRequestTimestamps(rangeValue []byte, deleteRequest DeleteRequest
 	deleteRequest.CreatedAt = model.Time(createdAt)
 	deleteRequest.StartTime = model.Time(from)
 	deleteRequest.EndTime = model.Time(through)
+	deleteRequest.DryRun = len(hexParts) == 4 && hexParts[3] == "1"
 
 	return deleteRequest, nil
 }
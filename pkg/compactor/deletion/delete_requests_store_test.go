@@ -178,6 +178,71 @@ func TestBatchCreateGet(t *testing.T) {
 		require.Equal(t, StatusProcessed, results[1].Status)
 	})
 
+	t.Run("keeps dry runs apart and doesn't invalidate the caches for them", func(t *testing.T) {
+		tc := setup(t)
+		defer tc.store.Stop()
+
+		reqs := tc.user1Requests[:2]
+		for i := range reqs {
+			reqs[i].DryRun = true
+		}
+		savedRequests, err := tc.store.AddDeleteRequestGroup(context.Background(), reqs)
+		require.NoError(t, err)
+
+		genNumber, err := tc.store.GetCacheGenerationNumber(context.Background(), user1)
+		require.NoError(t, err)
+		require.Empty(t, genNumber)
+
+		require.NoError(t, tc.store.UpdateStatus(context.Background(), savedRequests[0], StatusProcessed))
+		genNumber, err = tc.store.GetCacheGenerationNumber(context.Background(), user1)
+		require.NoError(t, err)
+		require.Empty(t, genNumber)
+
+		results, err := tc.store.GetDeleteRequestGroup(context.Background(), user1, savedRequests[0].RequestID)
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		for _, r := range results {
+			require.True(t, r.DryRun)
+		}
+
+		require.NoError(t, tc.store.RemoveDeleteRequests(context.Background(), savedRequests[1:]))
+		results, err = tc.store.GetDeleteRequestGroup(context.Background(), user1, savedRequests[0].RequestID)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+	})
+
+	t.Run("records the chunks processed by a delete request", func(t *testing.T) {
+		tc := setup(t)
+		defer tc.store.Stop()
+
+		savedRequests, err := tc.store.AddDeleteRequestGroup(context.Background(), tc.user1Requests[:2])
+		require.NoError(t, err)
+
+		chunks, err := tc.store.GetDeletedChunks(context.Background(), savedRequests[0])
+		require.NoError(t, err)
+		require.Empty(t, chunks)
+
+		// only some of the chunks are listed.
+		expected := DeletedChunks{
+			Chunks: 3,
+			Lines:  20,
+			Bytes:  2 << 10,
+			Listed: []DeletedChunk{
+				{ChunkID: "user1/1:2:3:4", Whole: true, Lines: 10, Bytes: 1 << 10},
+				{ChunkID: "user1/5:6:7:8", Lines: 3, Bytes: 42},
+			},
+		}
+		require.NoError(t, tc.store.AddDeletedChunks(context.Background(), savedRequests[1], expected))
+
+		chunks, err = tc.store.GetDeletedChunks(context.Background(), savedRequests[1])
+		require.NoError(t, err)
+		require.Equal(t, expected, chunks)
+
+		chunks, err = tc.store.GetDeletedChunks(context.Background(), savedRequests[0])
+		require.NoError(t, err)
+		require.Empty(t, chunks)
+	})
+
 	t.Run("deletes several delete requests", func(t *testing.T) {
 		tc := setup(t)
 		defer tc.store.Stop()
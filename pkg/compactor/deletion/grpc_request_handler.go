@@ -53,17 +53,21 @@ func (g *GRPCRequestHandler) GetDeleteRequests(ctx context.Context, _ *grpc.GetD
 	})
 
 	resp := grpc.GetDeleteRequestsResponse{
-		DeleteRequests: make([]*grpc.DeleteRequest, len(deleteRequests)),
+		DeleteRequests: make([]*grpc.DeleteRequest, 0, len(deleteRequests)),
 	}
-	for i, dr := range deleteRequests {
-		resp.DeleteRequests[i] = &grpc.DeleteRequest{
+	for _, dr := range deleteRequests {
+		if dr.DryRun {
+			// The delete requests are used by queriers to filter deleted data, which dry runs don't delete.
+			continue
+		}
+		resp.DeleteRequests = append(resp.DeleteRequests, &grpc.DeleteRequest{
 			RequestID: dr.RequestID,
 			StartTime: int64(dr.StartTime),
 			EndTime:   int64(dr.EndTime),
 			Query:     dr.Query,
 			Status:    string(dr.Status),
 			CreatedAt: int64(dr.CreatedAt),
-		}
+		})
 	}
 
 	return &resp, nil
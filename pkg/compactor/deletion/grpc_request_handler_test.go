@@ -88,6 +88,19 @@ func TestGRPCGetDeleteRequests(t *testing.T) {
 		require.ElementsMatch(t, store.getAllResult, grpcDeleteRequestsToDeleteRequests(resp.DeleteRequests))
 	})
 
+	t.Run("it leaves out dry runs", func(t *testing.T) {
+		store := &mockDeleteRequestsStore{}
+		store.getAllResult = []DeleteRequest{{RequestID: "test-request-1", Status: StatusReceived}, {RequestID: "test-request-2", Status: StatusReceived, DryRun: true}}
+		h := NewGRPCRequestHandler(store, &fakeLimits{mode: deletionmode.FilterAndDelete.String()})
+		grpcClient, closer := server(t, h)
+		t.Cleanup(closer)
+
+		ctx, _ := user.InjectIntoGRPCRequest(user.InjectOrgID(context.Background(), user1))
+		resp, err := grpcClient.GetDeleteRequests(ctx, &compactor_client_grpc.GetDeleteRequestsRequest{})
+		require.NoError(t, err)
+		require.ElementsMatch(t, store.getAllResult[:1], grpcDeleteRequestsToDeleteRequests(resp.DeleteRequests))
+	})
+
 	t.Run("it merges requests with the same requestID", func(t *testing.T) {
 		store := &mockDeleteRequestsStore{}
 		store.getAllResult = []DeleteRequest{
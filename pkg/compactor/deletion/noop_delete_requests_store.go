@@ -38,6 +38,14 @@ func (d *noOpDeleteRequestsStore) GetCacheGenerationNumber(_ context.Context, _
 	return "", nil
 }
 
+func (d *noOpDeleteRequestsStore) AddDeletedChunks(_ context.Context, _ DeleteRequest, _ DeletedChunks) error {
+	return nil
+}
+
+func (d *noOpDeleteRequestsStore) GetDeletedChunks(_ context.Context, _ DeleteRequest) (DeletedChunks, error) {
+	return DeletedChunks{}, nil
+}
+
 func (d *noOpDeleteRequestsStore) Stop() {}
 
 func (d *noOpDeleteRequestsStore) Name() string {
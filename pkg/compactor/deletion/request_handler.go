@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"sort"
+	"strconv"
 	"time"
 
 	"github.com/grafana/loki/pkg/util"
@@ -73,7 +74,16 @@ func (dm *DeleteRequestHandler) AddDeleteRequestHandler(w http.ResponseWriter, r
 		return
 	}
 
+	dryRun, err := dryRun(params)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	deleteRequests := shardDeleteRequestsByInterval(startTime, endTime, query, userID, interval)
+	for i := range deleteRequests {
+		deleteRequests[i].DryRun = dryRun
+	}
 	createdDeleteRequests, err := dm.deleteRequestsStore.AddDeleteRequestGroup(ctx, deleteRequests)
 	if err != nil {
 		level.Error(util_log.Logger).Log("msg", "error adding delete request to the store", "err", err)
@@ -93,6 +103,7 @@ func (dm *DeleteRequestHandler) AddDeleteRequestHandler(w http.ResponseWriter, r
 		"user", userID,
 		"query", query,
 		"interval", interval.String(),
+		"dry_run", dryRun,
 	)
 
 	dm.metrics.deleteRequestsReceivedTotal.WithLabelValues(userID).Inc()
@@ -293,6 +304,70 @@ func filterProcessed(reqs []DeleteRequest) []DeleteRequest {
 	return unprocessed
 }
 
+// DeletedChunksResponse lists the chunks processed by a delete request, or which would have been processed by a
+// dry run.
+type DeletedChunksResponse struct {
+	RequestID string              `json:"request_id"`
+	Status    DeleteRequestStatus `json:"status"`
+	DryRun    bool                `json:"dry_run"`
+	// Chunks lists the first chunks processed by each of the delete requests the request was split into.
+	Chunks []DeletedChunk `json:"chunks"`
+	// TotalChunks, Lines and Bytes are the totals of all the chunks, including the ones not listed.
+	TotalChunks int64 `json:"total_chunks"`
+	Lines       int64 `json:"lines"`
+	Bytes       int64 `json:"bytes"`
+}
+
+// GetDeletedChunksHandler handles requests for the chunks processed by a delete request
+func (dm *DeleteRequestHandler) GetDeletedChunksHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	requestID := r.URL.Query().Get("request_id")
+	deleteRequests, err := dm.deleteRequestsStore.GetDeleteRequestGroup(ctx, userID, requestID)
+	if err != nil {
+		if errors.Is(err, ErrDeleteRequestNotFound) {
+			http.Error(w, "could not find delete request with given id", http.StatusNotFound)
+			return
+		}
+
+		level.Error(util_log.Logger).Log("msg", "error getting delete request from the store", "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_, _, status := mergeData(deleteRequests)
+	resp := DeletedChunksResponse{
+		RequestID: requestID,
+		Status:    status,
+		DryRun:    deleteRequests[0].DryRun,
+		Chunks:    []DeletedChunk{},
+	}
+	for _, req := range deleteRequests {
+		chunks, err := dm.deleteRequestsStore.GetDeletedChunks(ctx, req)
+		if err != nil {
+			level.Error(util_log.Logger).Log("msg", "error getting deleted chunks from the store", "err", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		resp.Chunks = append(resp.Chunks, chunks.Listed...)
+		resp.TotalChunks += chunks.Chunks
+		resp.Lines += chunks.Lines
+		resp.Bytes += chunks.Bytes
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		level.Error(util_log.Logger).Log("msg", "error marshalling response", "err", err)
+		http.Error(w, fmt.Sprintf("Error marshalling response: %v", err), http.StatusInternalServerError)
+	}
+}
+
 // GetCacheGenerationNumberHandler handles requests for a user's cache generation number
 func (dm *DeleteRequestHandler) GetCacheGenerationNumberHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -328,6 +403,20 @@ func query(params url.Values) (string, error) {
 	return query, nil
 }
 
+func dryRun(params url.Values) (bool, error) {
+	dryRunParam := params.Get("dry_run")
+	if dryRunParam == "" {
+		return false, nil
+	}
+
+	dryRun, err := strconv.ParseBool(dryRunParam)
+	if err != nil {
+		return false, errors.New("invalid dry_run: require a boolean")
+	}
+
+	return dryRun, nil
+}
+
 func startTime(params url.Values) (model.Time, error) {
 	startParam := params.Get("start")
 	if startParam == "" {
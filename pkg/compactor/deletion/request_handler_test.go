@@ -38,6 +38,31 @@ func TestAddDeleteRequestHandler(t *testing.T) {
 		require.Equal(t, toTime("0000000001"), store.addReqs[0].EndTime)
 	})
 
+	t.Run("it adds dry runs", func(t *testing.T) {
+		store := &mockDeleteRequestsStore{}
+		h := NewDeleteRequestHandler(store, 0, nil)
+
+		req := buildRequest("org-id", `{foo="bar"} |= "fizz"`, "0000000000", "0000000001")
+		params := req.URL.Query()
+		params.Set("dry_run", "true")
+		req.URL.RawQuery = params.Encode()
+
+		w := httptest.NewRecorder()
+		h.AddDeleteRequestHandler(w, req)
+
+		require.Equal(t, w.Code, http.StatusNoContent)
+		require.True(t, store.addReqs[0].DryRun)
+
+		params.Set("dry_run", "maybe")
+		req.URL.RawQuery = params.Encode()
+
+		w = httptest.NewRecorder()
+		h.AddDeleteRequestHandler(w, req)
+
+		require.Equal(t, w.Code, http.StatusBadRequest)
+		require.Equal(t, "invalid dry_run: require a boolean\n", w.Body.String())
+	})
+
 	t.Run("an error is returned if adding delete request group returned zero", func(t *testing.T) {
 		store := &mockDeleteRequestsStore{returnZeroDeleteRequests: true}
 		h := NewDeleteRequestHandler(store, 0, nil)
@@ -438,6 +463,66 @@ func TestGetAllDeleteRequestsHandler(t *testing.T) {
 	})
 }
 
+func TestGetDeletedChunksHandler(t *testing.T) {
+	t.Run("it returns the chunks processed by all the requests of the group", func(t *testing.T) {
+		store := &mockDeleteRequestsStore{}
+		store.getResult = []DeleteRequest{
+			{RequestID: "test-request", UserID: "org-id", SequenceNum: 0, Status: StatusProcessed, DryRun: true},
+			{RequestID: "test-request", UserID: "org-id", SequenceNum: 1, Status: StatusProcessed, DryRun: true},
+		}
+		store.deletedChunks = map[string]DeletedChunks{
+			"test-request": {
+				Chunks: 3,
+				Lines:  15,
+				Bytes:  2 << 10,
+				Listed: []DeletedChunk{
+					{ChunkID: "chunk-1", Whole: true, Lines: 10, Bytes: 1 << 10},
+					{ChunkID: "chunk-2", Lines: 2, Bytes: 20},
+				},
+			},
+		}
+		h := NewDeleteRequestHandler(store, 0, nil)
+
+		req := buildRequest("org-id", ``, "", "")
+		params := req.URL.Query()
+		params.Set("request_id", "test-request")
+		req.URL.RawQuery = params.Encode()
+
+		w := httptest.NewRecorder()
+		h.GetDeletedChunksHandler(w, req)
+
+		require.Equal(t, w.Code, http.StatusOK)
+		require.Equal(t, "test-request", store.getID)
+
+		var resp DeletedChunksResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		listed := store.deletedChunks["test-request"].Listed
+		require.Equal(t, DeletedChunksResponse{
+			RequestID:   "test-request",
+			Status:      StatusProcessed,
+			DryRun:      true,
+			Chunks:      append(listed, listed...),
+			TotalChunks: 6,
+			Lines:       30,
+			Bytes:       4 << 10,
+		}, resp)
+	})
+
+	t.Run("it returns 404 when the delete request is not found", func(t *testing.T) {
+		h := NewDeleteRequestHandler(&mockDeleteRequestsStore{getErr: ErrDeleteRequestNotFound}, 0, nil)
+
+		req := buildRequest("org-id", ``, "", "")
+		params := req.URL.Query()
+		params.Set("request_id", "test-request")
+		req.URL.RawQuery = params.Encode()
+
+		w := httptest.NewRecorder()
+		h.GetDeletedChunksHandler(w, req)
+
+		require.Equal(t, w.Code, http.StatusNotFound)
+	})
+}
+
 func buildRequest(orgID, query, start, end string) *http.Request {
 	var req *http.Request
 	if orgID == "" {
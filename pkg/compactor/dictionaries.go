@@ -0,0 +1,381 @@
+package compactor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/pkg/chunkenc"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql/log"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/storage/chunk/client"
+	"github.com/grafana/loki/pkg/storage/config"
+	"github.com/grafana/loki/pkg/storage/dictionaries"
+	util_log "github.com/grafana/loki/pkg/util/log"
+)
+
+const (
+	// dictionarySamplesPerByte is the number of bytes of lines sampled per
+	// byte of dictionary, as recommended by zstd.
+	dictionarySamplesPerByte = 100
+	// dictionaryHeldOutChunks is one in how many sampled chunks are held
+	// out of the training to evaluate the dictionary.
+	dictionaryHeldOutChunks = 5
+)
+
+// DictionariesConfig configures the training of the zstd dictionaries of the
+// tenants with zstd_dictionaries_enabled.
+type DictionariesConfig struct {
+	TrainingInterval time.Duration `yaml:"training_interval"`
+	SampledChunks    int           `yaml:"sampled_chunks"`
+	DictionarySize   int           `yaml:"dictionary_size"`
+}
+
+// RegisterFlagsWithPrefix registers flags.
+func (cfg *DictionariesConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.DurationVar(&cfg.TrainingInterval, prefix+"zstd-dictionaries.training-interval", 24*time.Hour, "How often a new zstd dictionary is trained for the tenants with zstd_dictionaries_enabled, from the chunks of the index tables compacted in the last interval.")
+	f.IntVar(&cfg.SampledChunks, prefix+"zstd-dictionaries.sampled-chunks", 100, "Number of chunks of a tenant sampled to train a zstd dictionary.")
+	f.IntVar(&cfg.DictionarySize, prefix+"zstd-dictionaries.dictionary-size", 64<<10, "Maximum size of the zstd dictionaries in bytes.")
+}
+
+func (cfg *DictionariesConfig) Validate() error {
+	if cfg.TrainingInterval <= 0 {
+		return errors.New("compactor.zstd-dictionaries.training-interval must be greater than 0")
+	}
+	if cfg.SampledChunks <= 0 {
+		return errors.New("compactor.zstd-dictionaries.sampled-chunks must be greater than 0")
+	}
+	if cfg.DictionarySize < 1<<10 || cfg.DictionarySize > 1<<20 {
+		return errors.New("compactor.zstd-dictionaries.dictionary-size must be between 1KiB and 1MiB")
+	}
+	return nil
+}
+
+// chunkSampler samples the chunks of the tenants while their index is
+// compacted.
+type chunkSampler interface {
+	// SampleTenant returns whether the chunks of the tenant indexed in a
+	// table of the given interval should be sampled.
+	SampleTenant(ctx context.Context, userID string, tableInterval model.Interval) bool
+	// Sample is called with the ID of every chunk of the sampled tenants.
+	Sample(userID, chunkID string)
+}
+
+type dictionaryMetrics struct {
+	trainings        *prometheus.CounterVec
+	compressionRatio *prometheus.GaugeVec
+}
+
+func newDictionaryMetrics(r prometheus.Registerer) *dictionaryMetrics {
+	return &dictionaryMetrics{
+		trainings: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki_compactor",
+			Name:      "zstd_dictionary_trainings_total",
+			Help:      "Total number of zstd dictionaries trained by status",
+		}, []string{"status"}),
+		compressionRatio: promauto.With(r).NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "loki_compactor",
+			Name:      "zstd_dictionary_compression_ratio",
+			Help:      "Compression ratio of the chunks held out of the training of the latest zstd dictionary of the tenant, compressed with zstd with and without the dictionary",
+		}, []string{"tenant", "dictionary"}),
+	}
+}
+
+// dictionaryTrainer trains the zstd dictionaries of the tenants from the
+// chunks sampled while their index is compacted, and stores them versioned
+// in object storage for the ingesters to compress the new chunks with.
+type dictionaryTrainer struct {
+	cfg          DictionariesConfig
+	store        *dictionaries.Store
+	limits       dictionaries.Limits
+	schemaConfig config.SchemaConfig
+	chunkClients map[config.DayTime]client.Client
+	metrics      *dictionaryMetrics
+
+	mtx sync.Mutex
+	// trained is when the dictionary of the tenants was last trained, looked
+	// up in the store the first time they are sampled.
+	trained map[string]time.Time
+	// samples are the chunks sampled for the tenants due for training.
+	samples map[string]*chunkSamples
+}
+
+// chunkSamples is a uniform sample of the chunks of a tenant.
+type chunkSamples struct {
+	seen     int
+	chunkIDs []string
+}
+
+func newDictionaryTrainer(cfg DictionariesConfig, store *dictionaries.Store, limits dictionaries.Limits, schemaConfig config.SchemaConfig, chunkClients map[config.DayTime]client.Client, r prometheus.Registerer) *dictionaryTrainer {
+	return &dictionaryTrainer{
+		cfg:          cfg,
+		store:        store,
+		limits:       limits,
+		schemaConfig: schemaConfig,
+		chunkClients: chunkClients,
+		metrics:      newDictionaryMetrics(r),
+		trained:      map[string]time.Time{},
+		samples:      map[string]*chunkSamples{},
+	}
+}
+
+// SampleTenant implements chunkSampler. The chunks of the tables of the last
+// training interval are sampled for the tenants whose dictionary was trained
+// more than a training interval ago.
+func (t *dictionaryTrainer) SampleTenant(ctx context.Context, userID string, tableInterval model.Interval) bool {
+	if !t.limits.ZstdDictionariesEnabled(userID) {
+		return false
+	}
+	now := time.Now()
+	if tableInterval.End.Time().Before(now.Add(-t.cfg.TrainingInterval)) {
+		return false
+	}
+
+	t.mtx.Lock()
+	trained, ok := t.trained[userID]
+	t.mtx.Unlock()
+	if !ok {
+		v, _, err := t.store.Latest(ctx, userID)
+		if err != nil {
+			level.Error(util_log.Logger).Log("msg", "failed to look up the latest zstd dictionary", "tenant", userID, "err", err)
+			return false
+		}
+		trained = v.Trained
+		t.mtx.Lock()
+		t.trained[userID] = trained
+		t.mtx.Unlock()
+	}
+	return now.Sub(trained) >= t.cfg.TrainingInterval
+}
+
+// Sample implements chunkSampler.
+func (t *dictionaryTrainer) Sample(userID, chunkID string) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	s, ok := t.samples[userID]
+	if !ok {
+		s = &chunkSamples{}
+		t.samples[userID] = s
+	}
+
+	// Reservoir sampling
+	s.seen++
+	if len(s.chunkIDs) < t.cfg.SampledChunks {
+		s.chunkIDs = append(s.chunkIDs, chunkID)
+	} else if i := rand.Intn(s.seen); i < len(s.chunkIDs) {
+		s.chunkIDs[i] = chunkID
+	}
+}
+
+// Retrain makes the dictionary of the tenant trained during the next
+// compaction.
+func (t *dictionaryTrainer) Retrain(userID string) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.trained[userID] = time.Time{}
+}
+
+// train trains the dictionaries of the tenants sampled during the last
+// compaction.
+func (t *dictionaryTrainer) train(ctx context.Context) {
+	t.mtx.Lock()
+	samples := t.samples
+	t.samples = map[string]*chunkSamples{}
+	t.mtx.Unlock()
+
+	for userID, s := range samples {
+		status := statusSuccess
+		v, err := t.trainTenant(ctx, userID, s.chunkIDs)
+		if err != nil {
+			status = statusFailure
+			level.Error(util_log.Logger).Log("msg", "failed to train zstd dictionary", "tenant", userID, "err", err)
+		} else {
+			level.Info(util_log.Logger).Log("msg", "trained zstd dictionary", "tenant", userID, "id", v.ID, "sampled_chunks", len(s.chunkIDs))
+		}
+		t.metrics.trainings.WithLabelValues(status).Inc()
+
+		// Don't retry a failed training before the next training interval.
+		t.mtx.Lock()
+		t.trained[userID] = time.Now()
+		t.mtx.Unlock()
+	}
+}
+
+func (t *dictionaryTrainer) trainTenant(ctx context.Context, userID string, chunkIDs []string) (dictionaries.Version, error) {
+	budget := t.cfg.DictionarySize * dictionarySamplesPerByte / len(chunkIDs)
+
+	var (
+		lines    [][]byte
+		heldOut  [][]logproto.Entry
+		training [][]logproto.Entry
+	)
+	for i, chunkID := range chunkIDs {
+		entries, err := t.sampleEntries(ctx, userID, chunkID, budget)
+		if err != nil {
+			return dictionaries.Version{}, err
+		}
+		if i%dictionaryHeldOutChunks == dictionaryHeldOutChunks-1 {
+			heldOut = append(heldOut, entries)
+			continue
+		}
+		training = append(training, entries)
+		for _, e := range entries {
+			lines = append(lines, []byte(e.Line))
+		}
+	}
+	// Too few chunks were sampled to hold some out of the training.
+	if len(heldOut) == 0 {
+		heldOut = training
+	}
+
+	id, err := t.store.NewID(ctx)
+	if err != nil {
+		return dictionaries.Version{}, err
+	}
+	b, err := chunkenc.TrainDictionary(id, lines, t.cfg.DictionarySize)
+	if err != nil {
+		return dictionaries.Version{}, err
+	}
+	dict, err := chunkenc.NewDictionary(b)
+	if err != nil {
+		return dictionaries.Version{}, err
+	}
+
+	plain, withDictionary, err := compressionRatios(heldOut, dict)
+	if err != nil {
+		return dictionaries.Version{}, err
+	}
+	t.metrics.compressionRatio.WithLabelValues(userID, "false").Set(plain)
+	t.metrics.compressionRatio.WithLabelValues(userID, "true").Set(withDictionary)
+
+	return t.store.Put(ctx, userID, time.Now(), dict)
+}
+
+// sampleEntries returns the first entries of the chunk with the given ID, up
+// to budget bytes of lines.
+func (t *dictionaryTrainer) sampleEntries(ctx context.Context, userID, chunkID string, budget int) ([]logproto.Entry, error) {
+	chk, err := chunk.ParseExternalKey(userID, chunkID)
+	if err != nil {
+		return nil, err
+	}
+	period, err := t.schemaConfig.SchemaForTime(chk.From)
+	if err != nil {
+		return nil, err
+	}
+	chunkClient, ok := t.chunkClients[period.From]
+	if !ok {
+		return nil, fmt.Errorf("chunk client not found for period starting at %s", period.From.String())
+	}
+	chks, err := chunkClient.GetChunks(ctx, []chunk.Chunk{chk})
+	if err != nil {
+		return nil, err
+	}
+	if len(chks) != 1 {
+		return nil, fmt.Errorf("expected 1 entry for chunk %s but found %d in storage", chunkID, len(chks))
+	}
+
+	it, err := chks[0].Data.(*chunkenc.Facade).LokiChunk().Iterator(ctx, time.Unix(0, 0), time.Unix(0, math.MaxInt64), logproto.FORWARD, log.NewNoopPipeline().ForStream(labels.Labels{}))
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	var entries []logproto.Entry
+	for budget > 0 && it.Next() {
+		e := it.Entry()
+		entries = append(entries, e)
+		budget -= len(e.Line)
+	}
+	return entries, it.Error()
+}
+
+// compressionRatios returns the compression ratio of the entries of the
+// chunks compressed with zstd, without and with the dictionary.
+func compressionRatios(chunks [][]logproto.Entry, dict *chunkenc.Dictionary) (plain, withDictionary float64, err error) {
+	var uncompressed, compressed, compressedWithDictionary int
+	for _, entries := range chunks {
+		c := chunkenc.NewMemChunk(chunkenc.ChunkFormatV4, chunkenc.EncZstd, chunkenc.UnorderedWithStructuredMetadataHeadBlockFmt, 0, 0)
+		withDict := chunkenc.NewMemChunkWithDictionary(chunkenc.ChunkFormatV4, dict, chunkenc.UnorderedWithStructuredMetadataHeadBlockFmt, 0, 0)
+		for i := range entries {
+			if err := c.Append(&entries[i]); err != nil {
+				return 0, 0, err
+			}
+			if err := withDict.Append(&entries[i]); err != nil {
+				return 0, 0, err
+			}
+		}
+		if err := c.Close(); err != nil {
+			return 0, 0, err
+		}
+		if err := withDict.Close(); err != nil {
+			return 0, 0, err
+		}
+		uncompressed += c.UncompressedSize()
+		compressed += c.CompressedSize()
+		compressedWithDictionary += withDict.CompressedSize()
+	}
+	if compressed == 0 || compressedWithDictionary == 0 {
+		return 0, 0, errors.New("no entries sampled")
+	}
+	return float64(uncompressed) / float64(compressed), float64(uncompressed) / float64(compressedWithDictionary), nil
+}
+
+// ZstdDictionaryVersionsHandler lists the versions of the zstd dictionary of
+// the tenant.
+func (c *Compactor) ZstdDictionaryVersionsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if c.dictionaryTrainer == nil {
+		http.Error(w, "zstd dictionaries are not available", http.StatusNotFound)
+		return
+	}
+
+	versions, err := c.dictionaryTrainer.store.Versions(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(versions); err != nil {
+		level.Error(util_log.Logger).Log("msg", "error marshalling zstd dictionary versions", "err", err)
+	}
+}
+
+// RetrainZstdDictionaryHandler makes the zstd dictionary of the tenant
+// trained during the next compaction, instead of after the training interval.
+func (c *Compactor) RetrainZstdDictionaryHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if c.dictionaryTrainer == nil {
+		http.Error(w, "zstd dictionaries are not available", http.StatusNotFound)
+		return
+	}
+	if !c.dictionaryTrainer.limits.ZstdDictionariesEnabled(userID) {
+		http.Error(w, "zstd dictionaries are not enabled for the tenant", http.StatusForbidden)
+		return
+	}
+
+	c.dictionaryTrainer.Retrain(userID)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,97 @@
+package compactor
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/chunkenc"
+	"github.com/grafana/loki/pkg/chunkenc/testdata"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/storage/chunk/client"
+	"github.com/grafana/loki/pkg/storage/chunk/client/testutils"
+	"github.com/grafana/loki/pkg/storage/config"
+	"github.com/grafana/loki/pkg/storage/dictionaries"
+)
+
+type zstdDictionariesLimits map[string]bool
+
+func (l zstdDictionariesLimits) ZstdDictionariesEnabled(userID string) bool {
+	return l[userID]
+}
+
+func TestDictionaryTrainer(t *testing.T) {
+	ctx := context.Background()
+	schemaCfg := config.SchemaConfig{Configs: []config.PeriodConfig{{
+		From:       config.DayTime{Time: 0},
+		IndexType:  config.TSDBType,
+		ObjectType: "inmemory",
+		Schema:     "v13",
+		IndexTables: config.IndexPeriodicTableConfig{
+			PeriodicTableConfig: config.PeriodicTableConfig{Prefix: "index_", Period: config.ObjectStorageIndexRequiredPeriod},
+		},
+	}}}
+	objectClient := testutils.NewInMemoryObjectClient()
+	chunkClient := client.NewClient(objectClient, nil, schemaCfg)
+	store := dictionaries.NewStore(objectClient)
+
+	cfg := DictionariesConfig{TrainingInterval: 24 * time.Hour, SampledChunks: 10, DictionarySize: 16 << 10}
+	r := prometheus.NewRegistry()
+	trainer := newDictionaryTrainer(cfg, store, zstdDictionariesLimits{"enabled": true}, schemaCfg, map[config.DayTime]client.Client{{Time: 0}: chunkClient}, r)
+
+	now := model.Now()
+	recent := model.Interval{Start: now.Add(-time.Hour), End: now}
+	require.False(t, trainer.SampleTenant(ctx, "disabled", recent))
+	require.False(t, trainer.SampleTenant(ctx, "enabled", model.Interval{Start: now.Add(-72 * time.Hour), End: now.Add(-48 * time.Hour)}))
+	require.True(t, trainer.SampleTenant(ctx, "enabled", recent))
+
+	// Low volume streams, with small chunks.
+	for i := 0; i < 30; i++ {
+		c := chunkenc.NewMemChunk(chunkenc.ChunkFormatV4, chunkenc.EncZstd, chunkenc.UnorderedWithStructuredMetadataHeadBlockFmt, 256<<10, 0)
+		for j := 0; j < 100; j++ {
+			require.NoError(t, c.Append(&logproto.Entry{
+				Timestamp: now.Add(time.Duration(j) * time.Second).Time(),
+				Line:      testdata.LogString(int64(i*100 + j)),
+			}))
+		}
+		require.NoError(t, c.Close())
+		from, through := c.Bounds()
+		lbs := labels.FromStrings("app", fmt.Sprintf("app-%d", i))
+		chk := chunk.NewChunk("enabled", model.Fingerprint(lbs.Hash()), lbs, chunkenc.NewFacade(c, 0, 0), model.TimeFromUnixNano(from.UnixNano()), model.TimeFromUnixNano(through.UnixNano()))
+		require.NoError(t, chk.Encode())
+		require.NoError(t, chunkClient.PutChunks(ctx, []chunk.Chunk{chk}))
+		trainer.Sample("enabled", schemaCfg.ExternalKey(chk.ChunkRef))
+	}
+	require.Len(t, trainer.samples["enabled"].chunkIDs, 10)
+
+	trainer.train(ctx)
+	require.Equal(t, 1.0, testutil.ToFloat64(trainer.metrics.trainings.WithLabelValues(statusSuccess)))
+	require.Greater(t,
+		testutil.ToFloat64(trainer.metrics.compressionRatio.WithLabelValues("enabled", "true")),
+		testutil.ToFloat64(trainer.metrics.compressionRatio.WithLabelValues("enabled", "false")),
+	)
+
+	v, ok, err := store.Latest(ctx, "enabled")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.GreaterOrEqual(t, v.ID, uint32(1<<15))
+	b, err := store.Get(ctx, v.ID)
+	require.NoError(t, err)
+	dict, err := chunkenc.NewDictionary(b)
+	require.NoError(t, err)
+	require.Equal(t, v.ID, dict.ID())
+
+	// The dictionary isn't trained again before the training interval, unless
+	// retraining is requested.
+	require.False(t, trainer.SampleTenant(ctx, "enabled", recent))
+	trainer.Retrain("enabled")
+	require.True(t, trainer.SampleTenant(ctx, "enabled", recent))
+}
@@ -63,6 +63,10 @@ type indexSet struct {
 	compactedIndex CompactedIndex
 	sourceObjects  []storage.IndexFile
 	logger         log.Logger
+
+	// mergedChunks are the chunks merged in the compacted index, pending
+	// until it is uploaded.
+	mergedChunks *mergedChunks
 }
 
 // newUserIndexSet intializes a new index set for user index.
@@ -182,6 +186,26 @@ func (is *indexSet) runRetention(tableMarker retention.TableMarker) error {
 	return nil
 }
 
+// runChunkMerge merges the small chunks of the index set
+func (is *indexSet) runChunkMerge(merger chunkMerger) error {
+	if is.compactedIndex == nil {
+		return nil
+	}
+
+	merged, err := merger.MergeChunks(is.ctx, is.tableName, is.userID, is.compactedIndex, is.logger)
+	if err != nil {
+		return err
+	}
+
+	if merged != nil {
+		is.mergedChunks = merged
+		is.uploadCompactedDB = true
+		is.removeSourceObjects = true
+	}
+
+	return nil
+}
+
 // upload uploads the compacted index in compressed format.
 func (is *indexSet) upload() error {
 	if is.compactedIndex == nil {
@@ -281,6 +305,7 @@ func (is *indexSet) removeFilesFromStorage() error {
 // done takes care of file operations which includes:
 // - recreate the compacted db if required.
 // - upload the compacted db if required.
+// - mark the chunks merged away for deletion once the compacted db is uploaded.
 // - remove the source objects from storage if required.
 func (is *indexSet) done() error {
 	if is.uploadCompactedDB {
@@ -289,6 +314,16 @@ func (is *indexSet) done() error {
 		}
 	}
 
+	if is.mergedChunks != nil {
+		// The merged chunks are referenced by the uploaded index now, so they
+		// must not be deleted by cleanup even if marking the small ones fails.
+		merged := is.mergedChunks
+		is.mergedChunks = nil
+		if err := merged.markMerged(); err != nil {
+			return err
+		}
+	}
+
 	if is.removeSourceObjects {
 		return is.removeFilesFromStorage()
 	}
@@ -297,6 +332,12 @@ func (is *indexSet) done() error {
 }
 
 func (is *indexSet) cleanup() {
+	if is.mergedChunks != nil {
+		// The compacted index referencing the merged chunks wasn't uploaded.
+		is.mergedChunks.deleteCreated(is.ctx)
+		is.mergedChunks = nil
+	}
+
 	if is.compactedIndex == nil {
 		return
 	}
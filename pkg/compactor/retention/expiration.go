@@ -2,12 +2,16 @@ package retention
 
 import (
 	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/go-kit/log/level"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
 
+	"github.com/grafana/loki/pkg/logql/log"
 	"github.com/grafana/loki/pkg/util/filter"
 	util_log "github.com/grafana/loki/pkg/util/log"
 	"github.com/grafana/loki/pkg/validation"
@@ -34,6 +38,7 @@ type ExpirationChecker interface {
 type expirationChecker struct {
 	tenantsRetention         *TenantsRetention
 	latestRetentionStartTime latestRetentionStartTime
+	filteredChunks           *filteredChunks
 }
 
 type Limits interface {
@@ -46,18 +51,46 @@ type Limits interface {
 func NewExpirationChecker(limits Limits) ExpirationChecker {
 	return &expirationChecker{
 		tenantsRetention: NewTenantsRetention(limits),
+		filteredChunks:   newFilteredChunks(),
 	}
 }
 
 // Expired tells if a ref chunk is expired based on retention rules.
+// When retention rules with filters apply to the stream of the chunk, the lines
+// of the chunk can expire at different times, and the returned filter.Func
+// selects the expired lines of a partially expired chunk. A partially expired
+// chunk is only filtered again once more of its lines may have expired since
+// the last pass filtered it.
 func (e *expirationChecker) Expired(ref ChunkEntry, now model.Time) (bool, filter.Func) {
 	userID := unsafeGetString(ref.UserID)
-	period := e.tenantsRetention.RetentionPeriodFor(userID, ref.Labels)
+	sr := e.tenantsRetention.streamRetentionFor(userID, ref.Labels)
+	shortest, longest := sr.periodBounds()
 	// The 0 value should disable retention
-	if period <= 0 {
+	if shortest <= 0 {
 		return false, nil
 	}
-	return now.Sub(ref.Through) > period, nil
+	if longest > 0 && now.Sub(ref.Through) > longest {
+		return true, nil
+	}
+	if !sr.hasFilters() || now.Sub(ref.From) <= shortest {
+		return false, nil
+	}
+
+	chunkID, rules := string(ref.ChunkID), sr.hash()
+	if last, ok := e.filteredChunks.lastFiltered(chunkID); ok && last.rules == rules && !sr.mayHaveExpiredSince(ref, last.at, now) {
+		e.filteredChunks.record(chunkID, last)
+		return false, nil
+	}
+
+	ff, err := sr.filterFunction(ref.Labels, now)
+	if err != nil {
+		// The selectors of the rules are checked when the limits are loaded.
+		// So this error should not occur.
+		level.Error(util_log.Logger).Log("msg", "unexpected error getting retention filter function", "user", userID, "err", err)
+		return false, nil
+	}
+	e.filteredChunks.record(chunkID, filteredChunk{at: now, rules: rules})
+	return true, ff
 }
 
 // DropFromIndex tells if it is okay to drop the chunk entry from index table.
@@ -65,15 +98,16 @@ func (e *expirationChecker) Expired(ref ChunkEntry, now model.Time) (bool, filte
 // If the tableEndTime is out of retention then we can drop the chunk entry without removing the chunk from the store.
 func (e *expirationChecker) DropFromIndex(ref ChunkEntry, tableEndTime model.Time, now model.Time) bool {
 	userID := unsafeGetString(ref.UserID)
-	period := e.tenantsRetention.RetentionPeriodFor(userID, ref.Labels)
+	_, longest := e.tenantsRetention.streamRetentionFor(userID, ref.Labels).periodBounds()
 	// The 0 value should disable retention
-	if period <= 0 {
+	if longest <= 0 {
 		return false
 	}
-	return now.Sub(tableEndTime) > period
+	return now.Sub(tableEndTime) > longest
 }
 
 func (e *expirationChecker) MarkPhaseStarted() {
+	e.filteredChunks.start()
 	e.latestRetentionStartTime = findLatestRetentionStartTime(model.Now(), e.tenantsRetention.limits)
 	level.Info(util_log.Logger).Log("msg", fmt.Sprintf("overall smallest retention period %v, default smallest retention period %v",
 		e.latestRetentionStartTime.overall, e.latestRetentionStartTime.defaults))
@@ -81,7 +115,9 @@ func (e *expirationChecker) MarkPhaseStarted() {
 
 func (e *expirationChecker) MarkPhaseFailed()   {}
 func (e *expirationChecker) MarkPhaseTimedOut() {}
-func (e *expirationChecker) MarkPhaseFinished() {}
+func (e *expirationChecker) MarkPhaseFinished() {
+	e.filteredChunks.finish()
+}
 
 func (e *expirationChecker) IntervalMayHaveExpiredChunks(interval model.Interval, userID string) bool {
 	// when userID is empty, it means we are checking for common index table. In this case we use e.overallLatestRetentionStartTime.
@@ -131,38 +167,205 @@ func NewTenantsRetention(l Limits) *TenantsRetention {
 	}
 }
 
+// RetentionPeriodFor returns the retention period of the given stream. Retention
+// rules with filters are not considered, as they only apply to some of the lines
+// of the stream.
 func (tr *TenantsRetention) RetentionPeriodFor(userID string, lbs labels.Labels) time.Duration {
-	streamRetentions := tr.limits.StreamRetention(userID)
-	globalRetention := tr.limits.RetentionPeriod(userID)
-	var (
-		matchedRule validation.StreamRetention
-		found       bool
-	)
+	return tr.streamRetentionFor(userID, lbs).streamPeriod()
+}
+
+func (tr *TenantsRetention) streamRetentionFor(userID string, lbs labels.Labels) streamRetention {
+	var rules []validation.StreamRetention
 Outer:
-	for _, streamRetention := range streamRetentions {
+	for _, streamRetention := range tr.limits.StreamRetention(userID) {
 		for _, m := range streamRetention.Matchers {
 			if !m.Matches(lbs.Get(m.Name)) {
 				continue Outer
 			}
 		}
 		// the rule is matched.
-		if found {
-			// if the current matched rule has a higher priority we keep it.
-			if matchedRule.Priority > streamRetention.Priority {
-				continue
-			}
-			// if priority is equal we keep the lowest retention.
-			if matchedRule.Priority == streamRetention.Priority && matchedRule.Period <= streamRetention.Period {
-				continue
-			}
+		rules = append(rules, streamRetention)
+	}
+
+	// The rule with the highest priority wins. If priority is equal we keep the
+	// lowest retention.
+	sort.SliceStable(rules, func(i, j int) bool {
+		if rules[i].Priority != rules[j].Priority {
+			return rules[i].Priority > rules[j].Priority
+		}
+		return rules[i].Period < rules[j].Period
+	})
+	// The rules after the first one without filters never apply, as it applies
+	// to all the lines of the stream.
+	for i, rule := range rules {
+		if rule.LogSelector == nil {
+			rules = rules[:i+1]
+			break
+		}
+	}
+
+	return streamRetention{
+		rules:         rules,
+		defaultPeriod: tr.limits.RetentionPeriod(userID),
+	}
+}
+
+// streamRetention holds the retention rules applying to a stream.
+type streamRetention struct {
+	// rules are the matching rules by precedence. Only the last one can be
+	// without filters.
+	rules []validation.StreamRetention
+	// defaultPeriod applies to the lines none of the rules apply to.
+	defaultPeriod time.Duration
+}
+
+func (sr streamRetention) hasFilters() bool {
+	return len(sr.rules) > 0 && sr.rules[0].LogSelector != nil
+}
+
+// streamPeriod returns the retention period of the lines no rule with filters applies to.
+func (sr streamRetention) streamPeriod() time.Duration {
+	if len(sr.rules) > 0 && sr.rules[len(sr.rules)-1].LogSelector == nil {
+		return time.Duration(sr.rules[len(sr.rules)-1].Period)
+	}
+	return sr.defaultPeriod
+}
+
+// periodBounds returns the shortest and the longest retention periods of the
+// lines of the stream. The longest is 0 when some lines are never deleted, and
+// the shortest is 0 when no line is ever deleted.
+func (sr streamRetention) periodBounds() (shortest, longest time.Duration) {
+	periods := []time.Duration{sr.streamPeriod()}
+	for _, rule := range sr.rules {
+		if rule.LogSelector != nil {
+			periods = append(periods, time.Duration(rule.Period))
+		}
+	}
+
+	for i, period := range periods {
+		if period > 0 && (shortest == 0 || period < shortest) {
+			shortest = period
+		}
+		if i == 0 || (longest > 0 && (period <= 0 || period > longest)) {
+			longest = period
+		}
+	}
+	return shortest, longest
+}
+
+// hash identifies the retention rules the lines of the stream are filtered
+// with.
+func (sr streamRetention) hash() uint64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d", sr.defaultPeriod)
+	for _, rule := range sr.rules {
+		fmt.Fprintf(h, "\xff%s\xff%d\xff%d", rule.Selector, rule.Period, rule.Priority)
+	}
+	return h.Sum64()
+}
+
+// mayHaveExpiredSince returns whether some lines of the chunk may have expired
+// between since and now.
+func (sr streamRetention) mayHaveExpiredSince(ref ChunkEntry, since, now model.Time) bool {
+	if !since.Before(now) {
+		return false
+	}
+
+	periods := []time.Duration{sr.streamPeriod()}
+	for _, rule := range sr.rules {
+		if rule.LogSelector != nil {
+			periods = append(periods, time.Duration(rule.Period))
+		}
+	}
+
+	for _, period := range periods {
+		// the lines expiring between since and now are in (since-period, now-period].
+		if period > 0 && ref.From <= now.Add(-period) && ref.Through > since.Add(-period) {
+			return true
 		}
-		found = true
-		matchedRule = streamRetention
 	}
-	if found {
-		return time.Duration(matchedRule.Period)
+	return false
+}
+
+// filteredChunks tracks when the partially expired chunks were last filtered,
+// and with which retention rules. The chunks filtered by a pass are tracked
+// once the pass finishes, and the chunks a pass doesn't see anymore, such as
+// the rewritten ones, are forgotten.
+type filteredChunks struct {
+	mtx sync.Mutex
+	// previous holds the chunks of the last finished pass, and current the ones
+	// of the running pass, by chunk ID.
+	previous, current map[string]filteredChunk
+}
+
+type filteredChunk struct {
+	at    model.Time
+	rules uint64
+}
+
+func newFilteredChunks() *filteredChunks {
+	return &filteredChunks{
+		previous: map[string]filteredChunk{},
+		current:  map[string]filteredChunk{},
+	}
+}
+
+func (f *filteredChunks) start() {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.current = map[string]filteredChunk{}
+}
+
+func (f *filteredChunks) finish() {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.previous, f.current = f.current, map[string]filteredChunk{}
+}
+
+func (f *filteredChunks) lastFiltered(chunkID string) (filteredChunk, bool) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	c, ok := f.previous[chunkID]
+	return c, ok
+}
+
+func (f *filteredChunks) record(chunkID string, c filteredChunk) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.current[chunkID] = c
+}
+
+type lineRetentionRule struct {
+	period   time.Duration
+	pipeline log.StreamPipeline
+}
+
+// filterFunction returns a filter.Func which returns true for the lines of the
+// stream out of retention at the given time.
+func (sr streamRetention) filterFunction(lbs labels.Labels, now model.Time) (filter.Func, error) {
+	streamPeriod := sr.streamPeriod()
+	rules := make([]lineRetentionRule, 0, len(sr.rules))
+	for _, rule := range sr.rules {
+		if rule.LogSelector == nil {
+			break
+		}
+		p, err := rule.LogSelector.Pipeline()
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, lineRetentionRule{period: time.Duration(rule.Period), pipeline: p.ForStream(lbs)})
 	}
-	return globalRetention
+
+	return func(ts time.Time, s string, structuredMetadata ...labels.Label) bool {
+		period := streamPeriod
+		for _, rule := range rules {
+			if _, _, matches := rule.pipeline.ProcessString(ts.UnixNano(), s, structuredMetadata...); matches {
+				period = rule.period
+				break
+			}
+		}
+		return period > 0 && now.Sub(model.TimeFromUnixNano(ts.UnixNano())) > period
+	}, nil
 }
 
 type latestRetentionStartTime struct {
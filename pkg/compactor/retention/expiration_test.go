@@ -9,6 +9,7 @@ import (
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/loki/pkg/logql/syntax"
 	"github.com/grafana/loki/pkg/validation"
 )
 
@@ -123,6 +124,122 @@ func Test_expirationChecker_Expired(t *testing.T) {
 	}
 }
 
+func Test_expirationChecker_Expired_lineFilters(t *testing.T) {
+	streamRetention := func(selector string, priority int, period time.Duration) validation.StreamRetention {
+		logSelector, err := syntax.ParseLogSelector(selector, true)
+		require.NoError(t, err)
+		rule := validation.StreamRetention{Period: model.Duration(period), Priority: priority, Selector: selector, Matchers: logSelector.Matchers()}
+		if logSelector.HasFilter() {
+			rule.LogSelector = logSelector
+		}
+		return rule
+	}
+
+	d := defaultLimitsTestConfig()
+	d.RetentionPeriod = model.Duration(24 * time.Hour)
+	d.StreamRetention = []validation.StreamRetention{
+		streamRetention(`{app="foo"} | level="error"`, 1, 90*time.Hour),
+		streamRetention(`{app="foo"} | level="debug"`, 1, 3*time.Hour),
+		streamRetention(`{app="foo"} |= "healthcheck"`, 2, 2*time.Hour),
+		streamRetention(`{app="foo", env="prod"}`, 5, 48*time.Hour),
+		streamRetention(`{app="bar"} | level="error"`, 1, 48*time.Hour),
+		streamRetention(`{app="bar"}`, 1, 48*time.Hour),
+	}
+	o, err := overridesTestConfig(d, nil)
+	require.NoError(t, err)
+
+	e := NewExpirationChecker(o)
+	now := model.Now()
+
+	for _, tc := range []struct {
+		name          string
+		ref           ChunkEntry
+		expired       bool
+		dropFromIndex bool
+	}{
+		{"all lines expired", newChunkEntry("1", `{app="foo"}`, now.Add(-100*time.Hour), now.Add(-91*time.Hour)), true, true},
+		{"no line expired", newChunkEntry("1", `{app="foo"}`, now.Add(-2*time.Hour), now.Add(-time.Hour)), false, false},
+		{"stream rule with a higher priority", newChunkEntry("1", `{app="foo", env="prod"}`, now.Add(-30*time.Hour), now.Add(-time.Hour)), false, false},
+		{"stream rule with the same period", newChunkEntry("1", `{app="bar"}`, now.Add(-30*time.Hour), now.Add(-time.Hour)), false, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			expired, filterFunc := e.Expired(tc.ref, now)
+			require.Equal(t, tc.expired, expired)
+			require.Nil(t, filterFunc)
+			require.Equal(t, tc.dropFromIndex, e.DropFromIndex(tc.ref, tc.ref.Through, now))
+		})
+	}
+
+	require.Equal(t, 24*time.Hour, NewTenantsRetention(o).RetentionPeriodFor("1", labels.FromStrings("app", "foo")))
+	require.Equal(t, 48*time.Hour, NewTenantsRetention(o).RetentionPeriodFor("1", labels.FromStrings("app", "foo", "env", "prod")))
+
+	ref := newChunkEntry("1", `{app="foo"}`, now.Add(-30*time.Hour), now.Add(-time.Hour))
+	expired, filterFunc := e.Expired(ref, now)
+	require.True(t, expired)
+	require.NotNil(t, filterFunc)
+	require.False(t, e.DropFromIndex(ref, ref.Through, now))
+
+	for _, tc := range []struct {
+		age                time.Duration
+		line               string
+		structuredMetadata []labels.Label
+		expired            bool
+	}{
+		{30 * time.Hour, "error", []labels.Label{{Name: "level", Value: "error"}}, false},
+		{30 * time.Hour, "info", []labels.Label{{Name: "level", Value: "info"}}, true},
+		{10 * time.Hour, "info", []labels.Label{{Name: "level", Value: "info"}}, false},
+		{4 * time.Hour, "debug", []labels.Label{{Name: "level", Value: "debug"}}, true},
+		{2 * time.Hour, "debug", []labels.Label{{Name: "level", Value: "debug"}}, false},
+		// the healthcheck rule has a higher priority than the error rule.
+		{3 * time.Hour, "healthcheck", []labels.Label{{Name: "level", Value: "error"}}, true},
+		{time.Hour, "healthcheck", nil, false},
+	} {
+		require.Equal(t, tc.expired, filterFunc(now.Add(-tc.age).Time(), tc.line, tc.structuredMetadata...), "line", tc.line, "age", tc.age)
+	}
+}
+
+func Test_expirationChecker_Expired_alreadyFiltered(t *testing.T) {
+	d := defaultLimitsTestConfig()
+	d.RetentionPeriod = model.Duration(24 * time.Hour)
+	d.StreamRetention = []validation.StreamRetention{
+		{Period: model.Duration(90 * time.Hour), Priority: 1, Selector: `{app="foo"} | level="error"`},
+	}
+	for i, rule := range d.StreamRetention {
+		logSelector, err := syntax.ParseLogSelector(rule.Selector, true)
+		require.NoError(t, err)
+		d.StreamRetention[i].Matchers, d.StreamRetention[i].LogSelector = logSelector.Matchers(), logSelector
+	}
+	o, err := overridesTestConfig(d, nil)
+	require.NoError(t, err)
+
+	e := NewExpirationChecker(o)
+	now := model.Now()
+	ref := newChunkEntry("1", `{app="foo"}`, now.Add(-30*time.Hour), now.Add(-26*time.Hour))
+	ref.ChunkID = []byte("chunk")
+
+	pass := func(now model.Time) bool {
+		e.MarkPhaseStarted()
+		defer e.MarkPhaseFinished()
+		expired, _ := e.Expired(ref, now)
+		return expired
+	}
+	require.True(t, pass(now))
+	// No line of the chunk expires until its error lines reach 90h.
+	require.False(t, pass(now.Add(time.Hour)))
+	require.False(t, pass(now.Add(2*time.Hour)))
+	require.True(t, pass(now.Add(61*time.Hour)))
+	require.False(t, pass(now.Add(61*time.Hour)))
+
+	// A failed pass doesn't forget the chunks filtered by the previous one.
+	e.MarkPhaseStarted()
+	e.MarkPhaseFailed()
+	require.False(t, pass(now.Add(61*time.Hour)))
+
+	// The chunks are filtered again when the rules change.
+	d.StreamRetention[0].Priority = 2
+	require.True(t, pass(now.Add(61*time.Hour)))
+}
+
 func Test_expirationChecker_Expired_zeroValue(t *testing.T) {
 
 	// Default retention should be zero
@@ -36,6 +36,11 @@ type ChunkRef struct {
 	ChunkID  []byte
 	From     model.Time
 	Through  model.Time
+	// KB is the approximate uncompressed size of the chunk in KB, and Entries
+	// its number of entries. They are only known with the TSDB index, and are
+	// 0 otherwise.
+	KB      uint32
+	Entries uint32
 }
 
 func (c ChunkRef) String() string {
@@ -67,7 +67,18 @@ type TableCompactor interface {
 
 type MakeEmptyUserIndexSetFunc func(userID string) (IndexSet, error)
 
+// tableOptions holds the optional processors of the chunks of the user index
+// sets of a table, left nil when disabled.
+type tableOptions struct {
+	chunkSampler chunkSampler
+	chunkMerger  chunkMerger
+	chunkTierer  chunkTierer
+	chunkPacker  chunkPacker
+}
+
 type table struct {
+	tableOptions
+
 	name               string
 	workingDirectory   string
 	uploadConcurrency  int
@@ -89,7 +100,7 @@ type table struct {
 func newTable(ctx context.Context, workingDirectory string, indexStorageClient storage.Client,
 	indexCompactor IndexCompactor, periodConfig config.PeriodConfig,
 	tableMarker retention.TableMarker, expirationChecker tableExpirationChecker,
-	uploadConcurrency int,
+	uploadConcurrency int, opts tableOptions,
 ) (*table, error) {
 	err := chunk_util.EnsureDirectory(workingDirectory)
 	if err != nil {
@@ -97,6 +108,7 @@ func newTable(ctx context.Context, workingDirectory string, indexStorageClient s
 	}
 
 	table := table{
+		tableOptions:       opts,
 		ctx:                ctx,
 		name:               filepath.Base(workingDirectory),
 		workingDirectory:   workingDirectory,
@@ -181,9 +193,95 @@ func (t *table) compact(applyRetention bool) error {
 		}
 	}
 
+	if t.chunkMerger != nil {
+		if err := t.mergeChunks(); err != nil {
+			return err
+		}
+	}
+
+	if t.chunkTierer != nil {
+		if err := t.tierChunks(); err != nil {
+			return err
+		}
+	}
+
+	if t.chunkPacker != nil {
+		if err := t.packChunks(); err != nil {
+			return err
+		}
+	}
+
+	if t.chunkSampler != nil {
+		if err := t.sampleChunks(); err != nil {
+			return err
+		}
+	}
+
 	return t.done()
 }
 
+// mergeChunks merges the small chunks of the streams of the user index sets.
+func (t *table) mergeChunks() error {
+	return t.forEachUserIndexSet(nil, func(_ string, is *indexSet) error {
+		return is.runChunkMerge(t.chunkMerger)
+	})
+}
+
+// tierChunks relocates the chunks of the user index sets to the cold object store.
+func (t *table) tierChunks() error {
+	return t.forEachUserIndexSet(nil, func(userID string, is *indexSet) error {
+		return t.chunkTierer.TierChunks(t.ctx, t.name, userID, is.compactedIndex, is.logger)
+	})
+}
+
+// packChunks packs the small chunks of the user index sets into pack objects.
+func (t *table) packChunks() error {
+	return t.forEachUserIndexSet(nil, func(userID string, is *indexSet) error {
+		return t.chunkPacker.PackChunks(t.ctx, t.name, userID, is.compactedIndex, is.logger)
+	})
+}
+
+// sampleChunks samples the chunks of the tenants the chunkSampler asks for.
+func (t *table) sampleChunks() error {
+	tableInterval := retention.ExtractIntervalFromTableName(t.name)
+	sampleTenant := func(userID string) bool {
+		return t.chunkSampler.SampleTenant(t.ctx, userID, tableInterval)
+	}
+	return t.forEachUserIndexSet(sampleTenant, func(userID string, is *indexSet) error {
+		return is.compactedIndex.ForEachChunk(t.ctx, func(ce retention.ChunkEntry) (bool, error) {
+			t.chunkSampler.Sample(userID, string(ce.ChunkID))
+			return false, nil
+		})
+	})
+}
+
+// forEachUserIndexSet calls f with the user index sets of the tenants include
+// returns true for, or of all the tenants if include is nil, opening their
+// compacted index if it's not open yet. The user index sets without a
+// compacted index are skipped.
+func (t *table) forEachUserIndexSet(include func(userID string) bool, f func(userID string, is *indexSet) error) error {
+	for userID, is := range t.indexSets {
+		// the chunks of the common index set are compacted away to the user index sets.
+		if userID == "" || (include != nil && !include(userID)) {
+			continue
+		}
+
+		if is.compactedIndex == nil && len(is.ListSourceFiles()) == 1 {
+			if err := t.openCompactedIndexForRetention(is); err != nil {
+				return err
+			}
+		}
+		if is.compactedIndex == nil {
+			continue
+		}
+
+		if err := f(userID, is); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (t *table) done() error {
 	userIDs := make([]string, 0, len(t.indexSets))
 	for userID := range t.indexSets {
@@ -76,6 +76,13 @@ type Config struct {
 	WriteFailuresLogging writefailures.Cfg `yaml:"write_failures_logging" doc:"description=Experimental. Customize the logging of write failures."`
 
 	OTLPConfig push.GlobalOTLPConfig `yaml:"otlp_config"`
+
+	// MaxRecvMsgSize bounds the decompressed body of the push requests of
+	// third-party protocols. It is set to the max size of the gRPC messages
+	// received by the server.
+	MaxRecvMsgSize int `yaml:"-"`
+
+	Tees []TeeConfig `yaml:"tees" doc:"description=Experimental. Tees duplicating the pushed streams to another Loki push endpoint ('loki.url') or to local newline delimited JSON files ('file.path', rotated once they reach 'file.max_size'). Each tee has a bounded queue of 'queue_size' pushes, and drops pushes when its queue is full. Streams are routed to the tees by the 'tee_rules' limit of every tenant."`
 }
 
 // RegisterFlags registers distributor-related flags.
@@ -104,9 +111,12 @@ type Distributor struct {
 	validator        *Validator
 	pool             *ring_client.Pool
 	tee              Tee
+	writeAheadTee    WriteAheadTee
 
-	rateStore    RateStore
-	shardTracker *ShardTracker
+	rateStore        RateStore
+	shardTracker     *ShardTracker
+	labelCardinality *labelCardinalityTracker
+	streamPolicies   *streamPolicyTracker
 
 	// The global rate limiter requires a distributors ring to count
 	// the number of healthy instances.
@@ -132,6 +142,8 @@ type Distributor struct {
 	ingesterAppendTimeouts *prometheus.CounterVec
 	replicationFactor      prometheus.Gauge
 	streamShardCount       prometheus.Counter
+	relabelDroppedStreams  *prometheus.CounterVec
+	relabelDroppedBytes    *prometheus.CounterVec
 
 	usageTracker push.UsageTracker
 }
@@ -180,6 +192,20 @@ func New(
 		return nil, err
 	}
 
+	if len(cfg.Tees) > 0 {
+		if tee != nil {
+			return nil, errors.New("distributor tees can't be configured along with another tee")
+		}
+		tees, err := NewTees(cfg.Tees, overrides, logger, registerer)
+		if err != nil {
+			return nil, errors.Wrap(err, "distributor tees")
+		}
+		tee = tees
+		servs = append(servs, tees)
+	} else if s, ok := tee.(services.Service); ok {
+		servs = append(servs, s)
+	}
+
 	d := &Distributor{
 		cfg:                   cfg,
 		logger:                logger,
@@ -191,6 +217,8 @@ func New(
 		pool:                  clientpool.NewPool("ingester", clientCfg.PoolConfig, ingestersRing, factory, logger, metricsNamespace),
 		labelCache:            labelCache,
 		shardTracker:          NewShardTracker(),
+		labelCardinality:      newLabelCardinalityTracker(registerer, logger),
+		streamPolicies:        newStreamPolicyTracker(registerer),
 		healthyInstancesCount: atomic.NewUint32(0),
 		rateLimitStrat:        rateLimitStrat,
 		tee:                   tee,
@@ -215,9 +243,23 @@ func New(
 			Name:      "stream_sharding_count",
 			Help:      "Total number of times the distributor has sharded streams",
 		}),
+		relabelDroppedStreams: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: constants.Loki,
+			Name:      "distributor_relabel_dropped_streams_total",
+			Help:      "The total number of streams dropped by relabel rules, per tenant and relabel action.",
+		}, []string{"tenant", "action"}),
+		relabelDroppedBytes: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: constants.Loki,
+			Name:      "distributor_relabel_dropped_bytes_total",
+			Help:      "The total number of bytes of the streams dropped by relabel rules, per tenant and relabel action.",
+		}, []string{"tenant", "action"}),
 		writeFailuresManager: writefailures.NewManager(logger, registerer, cfg.WriteFailuresLogging, configs, "distributor"),
 	}
 
+	if wat, ok := tee.(WriteAheadTee); ok {
+		d.writeAheadTee = wat
+	}
+
 	if overrides.IngestionRateStrategy() == validation.GlobalIngestionRateStrategy {
 		d.rateLimitStrat = validation.GlobalIngestionRateStrategy
 
@@ -329,6 +371,7 @@ func (d *Distributor) Push(ctx context.Context, req *logproto.PushRequest) (*log
 
 	var validationErrors util.GroupedErrors
 	validationContext := d.validator.getValidationContextForTime(time.Now(), tenantID)
+	rejected := rejectedEntriesFromContext(ctx)
 
 	func() {
 		sp := opentracing.SpanFromContext(ctx)
@@ -338,7 +381,7 @@ func (d *Distributor) Push(ctx context.Context, req *logproto.PushRequest) (*log
 				sp.LogKV("event", "finished to validate request")
 			}()
 		}
-		for _, stream := range req.Streams {
+		for i, stream := range req.Streams {
 			// Return early if stream does not contain any entries
 			if len(stream.Entries) == 0 {
 				continue
@@ -349,9 +392,15 @@ func (d *Distributor) Push(ctx context.Context, req *logproto.PushRequest) (*log
 
 			var lbs labels.Labels
 			lbs, stream.Labels, stream.Hash, err = d.parseStreamLabels(validationContext, stream.Labels, &stream)
+			var relabelDropped errStreamRelabelDropped
+			if errors.As(err, &relabelDropped) {
+				d.discardRelabelDroppedStream(tenantID, relabelDropped.action, stream)
+				continue
+			}
 			if err != nil {
 				d.writeFailuresManager.Log(tenantID, err)
 				validationErrors.Add(err)
+				rejected.rejectStream(i, err)
 				validation.DiscardedSamples.WithLabelValues(validation.InvalidLabels, tenantID).Add(float64(len(stream.Entries)))
 				bytes := 0
 				for _, e := range stream.Entries {
@@ -361,13 +410,24 @@ func (d *Distributor) Push(ctx context.Context, req *logproto.PushRequest) (*log
 				continue
 			}
 
+			if validationContext.demoteLabelsThreshold > 0 && validationContext.allowStructuredMetadata {
+				lbs, stream.Labels, stream.Hash = d.demoteHighCardinalityLabels(validationContext, lbs, &stream)
+			}
+
 			n := 0
 			pushSize := 0
 			prevTs := stream.Entries[0].Timestamp
-			for _, entry := range stream.Entries {
+			dropRules := validationContext.dropRules.ForStream(lbs)
+			for j, entry := range stream.Entries {
+				if _, drop := dropRules.Drop(entry.Line); drop {
+					d.discardIngestDropRuleMatchedEntry(tenantID, lbs, entry)
+					continue
+				}
+
 				if err := d.validator.ValidateEntry(validationContext, lbs, entry); err != nil {
 					d.writeFailuresManager.Log(tenantID, err)
 					validationErrors.Add(err)
+					rejected.rejectEntry(i, j, err)
 					continue
 				}
 
@@ -395,6 +455,10 @@ func (d *Distributor) Push(ctx context.Context, req *logproto.PushRequest) (*log
 			}
 			stream.Entries = stream.Entries[:n]
 
+			if len(validationContext.streamRateLimitPolicies) > 0 {
+				d.observeStreamRateLimitPolicy(validationContext, lbs, stream.Hash, pushSize)
+			}
+
 			shardStreamsCfg := d.validator.Limits.ShardStreams(tenantID)
 			if shardStreamsCfg.Enabled {
 				streams = append(streams, d.shardStream(stream, pushSize, tenantID)...)
@@ -410,6 +474,9 @@ func (d *Distributor) Push(ctx context.Context, req *logproto.PushRequest) (*log
 	var validationErr error
 	if validationErrors.Err() != nil {
 		validationErr = httpgrpc.Errorf(http.StatusBadRequest, validationErrors.Error())
+		if rejected != nil {
+			rejected.validationErr = validationErr
+		}
 	}
 
 	// Return early if none of the streams contained entries
@@ -446,6 +513,14 @@ func (d *Distributor) Push(ctx context.Context, req *logproto.PushRequest) (*log
 		return nil, httpgrpc.Errorf(http.StatusTooManyRequests, err.Error())
 	}
 
+	if d.writeAheadTee != nil {
+		if err := d.writeAheadTee.Write(ctx, tenantID, streams); err != nil {
+			d.writeFailuresManager.Log(tenantID, err)
+			return nil, httpgrpc.Errorf(http.StatusServiceUnavailable, err.Error())
+		}
+		return &logproto.PushResponse{}, validationErr
+	}
+
 	// Nil check for performance reasons, to avoid dynamic lookup and/or no-op
 	// function calls that cannot be inlined.
 	if d.tee != nil {
@@ -712,12 +787,37 @@ func (d *Distributor) sendStreamsErr(ctx context.Context, ingester ring.Instance
 	return err
 }
 
+func (d *Distributor) discardRelabelDroppedStream(tenantID, action string, stream logproto.Stream) {
+	bytes := 0
+	for _, e := range stream.Entries {
+		bytes += len(e.Line)
+	}
+	d.relabelDroppedStreams.WithLabelValues(tenantID, action).Inc()
+	d.relabelDroppedBytes.WithLabelValues(tenantID, action).Add(float64(bytes))
+	validation.DiscardedSamples.WithLabelValues(validation.RelabelDropped, tenantID).Add(float64(len(stream.Entries)))
+	validation.DiscardedBytes.WithLabelValues(validation.RelabelDropped, tenantID).Add(float64(bytes))
+}
+
+func (d *Distributor) discardIngestDropRuleMatchedEntry(tenantID string, lbs labels.Labels, entry logproto.Entry) {
+	validation.DiscardedSamples.WithLabelValues(validation.IngestDropRuleMatched, tenantID).Inc()
+	validation.DiscardedBytes.WithLabelValues(validation.IngestDropRuleMatched, tenantID).Add(float64(len(entry.Line)))
+	if d.usageTracker != nil {
+		d.usageTracker.DiscardedBytesAdd(tenantID, validation.IngestDropRuleMatched, lbs, float64(len(entry.Line)))
+	}
+}
+
 type labelData struct {
 	ls   labels.Labels
 	hash uint64
 }
 
 func (d *Distributor) parseStreamLabels(vContext validationContext, key string, stream *logproto.Stream) (labels.Labels, string, uint64, error) {
+	// The label cache is shared by all tenants, so it can't hold the result of
+	// per-tenant relabeling.
+	if len(vContext.relabelRules) > 0 {
+		return d.parseAndRelabelStreamLabels(vContext, key, stream)
+	}
+
 	if val, ok := d.labelCache.Get(key); ok {
 		labelVal := val.(labelData)
 		return labelVal.ls, labelVal.ls.String(), labelVal.hash, nil
@@ -738,6 +838,56 @@ func (d *Distributor) parseStreamLabels(vContext validationContext, key string,
 	return ls, ls.String(), lsHash, nil
 }
 
+// errStreamRelabelDropped is returned when a relabel rule of the tenant drops a stream.
+type errStreamRelabelDropped struct {
+	action string
+}
+
+func (e errStreamRelabelDropped) Error() string {
+	return fmt.Sprintf("stream dropped by %s relabel action", e.action)
+}
+
+// parseAndRelabelStreamLabels applies the relabel rules of the tenant to the
+// stream labels before validating them. Labels moved to structured metadata
+// are added to every entry of the stream, unless the entry already has
+// structured metadata with the same name.
+func (d *Distributor) parseAndRelabelStreamLabels(vContext validationContext, key string, stream *logproto.Stream) (labels.Labels, string, uint64, error) {
+	ls, err := syntax.ParseLabels(key)
+	if err != nil {
+		return nil, "", 0, fmt.Errorf(validation.InvalidLabelsErrorMsg, key, err)
+	}
+
+	ls, structuredMetadata, droppedBy := vContext.relabelRules.Process(ls)
+	if droppedBy != "" {
+		return nil, "", 0, errStreamRelabelDropped{action: droppedBy}
+	}
+
+	if len(structuredMetadata) > 0 {
+		for i := range stream.Entries {
+			stream.Entries[i].StructuredMetadata = addStructuredMetadata(stream.Entries[i].StructuredMetadata, structuredMetadata)
+		}
+	}
+
+	if err := d.validator.ValidateLabels(vContext, ls, *stream); err != nil {
+		return nil, "", 0, err
+	}
+
+	return ls, ls.String(), ls.Hash(), nil
+}
+
+func addStructuredMetadata(existing []logproto.LabelAdapter, lbs labels.Labels) []logproto.LabelAdapter {
+Outer:
+	for _, l := range lbs {
+		for _, e := range existing {
+			if e.Name == l.Name {
+				continue Outer
+			}
+		}
+		existing = append(existing, logproto.LabelAdapter{Name: l.Name, Value: l.Value})
+	}
+	return existing
+}
+
 // shardCountFor returns the right number of shards to be used by the given stream.
 //
 // It first checks if the number of shards is present in the shard store. If it isn't it will calculate it
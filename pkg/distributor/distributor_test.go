@@ -2,10 +2,13 @@ package distributor
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"math/rand"
 	"net/http"
+	"net/http/httptest"
 	"strconv"
 	"strings"
 	"sync"
@@ -22,16 +25,21 @@ import (
 	"github.com/grafana/dskit/services"
 	"github.com/grafana/dskit/user"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/health/grpc_health_v1"
 
+	"github.com/grafana/loki/pkg/distributor/relabel"
 	"github.com/grafana/loki/pkg/ingester"
 	"github.com/grafana/loki/pkg/ingester/client"
+	"github.com/grafana/loki/pkg/kafka"
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/logql/syntax"
+	"github.com/grafana/loki/pkg/push"
+	rulerutil "github.com/grafana/loki/pkg/ruler/util"
 	"github.com/grafana/loki/pkg/runtime"
 	"github.com/grafana/loki/pkg/util/constants"
 	fe "github.com/grafana/loki/pkg/util/flagext"
@@ -529,6 +537,107 @@ func Test_TruncateLogLines(t *testing.T) {
 	})
 }
 
+func Test_RelabelStreamsOnPush(t *testing.T) {
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.AllowStructuredMetadata = true
+	limits.StreamRelabelConfigs = []*rulerutil.RelabelConfig{
+		{SourceLabels: []string{"env"}, Regex: "dev", Action: "drop"},
+		{Regex: "pod_uid", Action: relabel.StructuredMetadata},
+	}
+	require.NoError(t, limits.Validate())
+
+	ingester := &mockIngester{}
+	distributors, _ := prepare(t, 1, 5, limits, func(addr string) (ring_client.PoolClient, error) { return ingester, nil })
+
+	t.Run("it drops streams", func(t *testing.T) {
+		request := makeWriteRequestWithLabels(10, 10, []string{`{app="foo", env="dev"}`})
+		_, err := distributors[0].Push(ctx, request)
+		require.NoError(t, err)
+		require.Nil(t, ingester.Peek())
+	})
+
+	t.Run("it moves labels to structured metadata", func(t *testing.T) {
+		request := makeWriteRequestWithLabels(1, 10, []string{`{app="foo", pod_uid="abc"}`})
+		_, err := distributors[0].Push(ctx, request)
+		require.NoError(t, err)
+		topVal := ingester.Peek()
+		require.Equal(t, `{app="foo"}`, topVal.Streams[0].Labels)
+		require.Equal(t, push.LabelsAdapter{{Name: "pod_uid", Value: "abc"}}, topVal.Streams[0].Entries[0].StructuredMetadata)
+	})
+}
+
+func Test_IngestDropRulesOnPush(t *testing.T) {
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.IngestDropRules = []string{`{foo="bar"} |~ "^1"`}
+	require.NoError(t, limits.Validate())
+
+	ingester := &mockIngester{}
+	distributors, _ := prepare(t, 1, 5, limits, func(addr string) (ring_client.PoolClient, error) { return ingester, nil })
+
+	request := makeWriteRequest(3, 10)
+	_, err := distributors[0].Push(ctx, request)
+	require.NoError(t, err)
+	topVal := ingester.Peek()
+	require.Len(t, topVal.Streams[0].Entries, 2)
+	for _, e := range topVal.Streams[0].Entries {
+		require.NotEqual(t, "1", e.Line[:1])
+	}
+}
+
+func Test_DemoteHighCardinalityLabelsOnPush(t *testing.T) {
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.AllowStructuredMetadata = true
+	limits.DemoteHighCardinalityLabelsThreshold = 5
+
+	ingester := &mockIngester{}
+	distributors, _ := prepare(t, 1, 5, limits, func(addr string) (ring_client.PoolClient, error) { return ingester, nil })
+
+	for i := 0; i < 20; i++ {
+		request := makeWriteRequestWithLabels(1, 10, []string{fmt.Sprintf(`{app="foo", request_id="%d"}`, i)})
+		_, err := distributors[0].Push(ctx, request)
+		require.NoError(t, err)
+	}
+
+	ingester.mu.Lock()
+	topVal := ingester.pushed[len(ingester.pushed)-1]
+	ingester.mu.Unlock()
+	require.Equal(t, `{app="foo"}`, topVal.Streams[0].Labels)
+	require.Equal(t, push.LabelsAdapter{{Name: "request_id", Value: "19"}}, topVal.Streams[0].Entries[0].StructuredMetadata)
+	require.Contains(t, distributors[0].labelCardinality.DemotedLabels(), "test")
+}
+
+func Test_StreamRateLimitPoliciesOnPush(t *testing.T) {
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.StreamRateLimitPolicies = []validation.StreamRateLimitPolicy{
+		{Name: "foo", Selector: `{app="foo"}`, Rate: 100, Burst: 200},
+	}
+	require.NoError(t, limits.Validate())
+
+	ingester := &mockIngester{}
+	distributors, _ := prepare(t, 1, 5, limits, func(addr string) (ring_client.PoolClient, error) { return ingester, nil })
+
+	request := makeWriteRequestWithLabels(10, 10, []string{`{app="foo"}`, `{app="bar"}`})
+	_, err := distributors[0].Push(ctx, request)
+	require.NoError(t, err)
+	require.Equal(t, 100.0, testutil.ToFloat64(distributors[0].streamPolicies.receivedBytes.WithLabelValues("test", "foo")))
+
+	rec := httptest.NewRecorder()
+	distributors[0].StreamPoliciesHandler(rec, httptest.NewRequest(http.MethodGet, "/distributor/policies", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp struct {
+		Tenants map[string][]StreamPolicyStatus `json:"tenants"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Equal(t, map[string][]StreamPolicyStatus{
+		"test": {{Name: "foo", Selector: `{app="foo"}`, Rate: 100, Burst: 200}},
+	}, resp.Tenants)
+}
+
 func TestStreamShard(t *testing.T) {
 	// setup base stream.
 	baseStream := logproto.Stream{}
@@ -1143,6 +1252,7 @@ func prepare(t *testing.T, numDistributors, numIngesters int, limits *validation
 		var clientConfig client.Config
 		flagext.DefaultValues(&distributorConfig, &clientConfig)
 
+		distributorConfig.MaxRecvMsgSize = 4 << 20
 		distributorConfig.DistributorRing.HeartbeatPeriod = 100 * time.Millisecond
 		distributorConfig.DistributorRing.InstanceID = strconv.Itoa(rand.Int())
 		distributorConfig.DistributorRing.KVStore.Mock = kvStore
@@ -1330,3 +1440,55 @@ func TestDistributorTee(t *testing.T) {
 		require.Equal(t, "test", tee.tenant)
 	}
 }
+
+type mockWriteAheadTee struct {
+	mockTee
+	err error
+}
+
+func (mt *mockWriteAheadTee) Write(_ context.Context, tenant string, streams []KeyedStream) error {
+	if mt.err != nil {
+		return mt.err
+	}
+	mt.Duplicate(tenant, streams)
+	return nil
+}
+
+func TestDistributorWriteAheadTee(t *testing.T) {
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+
+	ingester := &mockIngester{}
+	distributors, _ := prepare(t, 1, 5, limits, func(addr string) (ring_client.PoolClient, error) { return ingester, nil })
+
+	tee := &mockWriteAheadTee{}
+	distributors[0].writeAheadTee = tee
+
+	request := makeWriteRequest(10, 10)
+	_, err := distributors[0].Push(ctx, request)
+	require.NoError(t, err)
+	require.Len(t, tee.duplicated, 1)
+	require.Equal(t, request.Streams[0].Entries, tee.duplicated[0][0].Stream.Entries)
+	require.Nil(t, ingester.Peek(), "streams must not be pushed to the ingesters")
+
+	tee.err = errors.New("kafka unavailable")
+	_, err = distributors[0].Push(ctx, makeWriteRequest(10, 10))
+	resp, ok := httpgrpc.HTTPResponseFromError(err)
+	require.True(t, ok)
+	require.Equal(t, int32(http.StatusServiceUnavailable), resp.Code)
+}
+
+func TestKafkaTeeDropsWhenQueueFull(t *testing.T) {
+	cfg := kafka.Config{}
+	flagext.DefaultValues(&cfg)
+	cfg.QueueSize = 1
+
+	// The tee isn't running, so the pushes stay queued.
+	tee := NewKafkaTee(nil, cfg, log.NewNopLogger(), prometheus.NewRegistry()).(*KafkaTee)
+	streams := []KeyedStream{{HashKey: 1, Stream: logproto.Stream{Labels: `{app="foo"}`, Entries: []logproto.Entry{{Line: "foo"}, {Line: "bar"}}}}}
+	tee.Duplicate("tenant", streams)
+	tee.Duplicate("tenant", streams)
+
+	require.Equal(t, 1.0, testutil.ToFloat64(tee.queueLength))
+	require.Equal(t, 2.0, testutil.ToFloat64(tee.droppedEntries.WithLabelValues(teeDropReasonQueueFull)))
+}
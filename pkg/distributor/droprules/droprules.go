@@ -0,0 +1,105 @@
+package droprules
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/pkg/logql/log"
+	"github.com/grafana/loki/pkg/logql/syntax"
+)
+
+// Rule is a validated ingest drop rule. It drops the lines of the streams
+// matching its selector that also pass its line filters.
+type Rule struct {
+	Expr string
+
+	matchers []*labels.Matcher
+	filter   log.Filterer
+}
+
+// Rules is a list of ingest drop rules.
+type Rules []*Rule
+
+// NewRules parses the given LogQL expressions into drop rules.
+// Each expression is a stream selector optionally followed by line filters,
+// e.g. `{env="dev"} |= "healthcheck"`. A selector without line filters drops
+// all the lines of the matching streams.
+func NewRules(exprs []string) (Rules, error) {
+	if len(exprs) == 0 {
+		return nil, nil
+	}
+
+	rules := make(Rules, 0, len(exprs))
+	for i, expr := range exprs {
+		rule, err := newRule(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid drop rule at index %d: %w", i, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func newRule(expr string) (*Rule, error) {
+	sel, err := syntax.ParseLogSelector(expr, true)
+	if err != nil {
+		return nil, err
+	}
+
+	rule := &Rule{Expr: expr, matchers: sel.Matchers()}
+
+	p, ok := sel.(*syntax.PipelineExpr)
+	if !ok {
+		return rule, nil
+	}
+
+	filters := make([]log.Filterer, 0, len(p.MultiStages))
+	for _, stage := range p.MultiStages {
+		lf, ok := stage.(*syntax.LineFilterExpr)
+		if !ok {
+			return nil, fmt.Errorf("only line filters are supported, found %q", stage.String())
+		}
+		f, err := lf.Filter()
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+	}
+
+	if len(filters) == 1 {
+		rule.filter = filters[0]
+	} else {
+		rule.filter = log.NewAndFilters(filters)
+	}
+	return rule, nil
+}
+
+// ForStream returns the rules whose selector matches the given stream labels.
+func (r Rules) ForStream(lbs labels.Labels) Rules {
+	var matching Rules
+Outer:
+	for _, rule := range r {
+		for _, m := range rule.matchers {
+			if !m.Matches(lbs.Get(m.Name)) {
+				continue Outer
+			}
+		}
+		matching = append(matching, rule)
+	}
+	return matching
+}
+
+// Drop returns the first rule dropping the given line, if any.
+// The rules are expected to have already been selected with ForStream.
+func (r Rules) Drop(line string) (*Rule, bool) {
+	// Filters don't retain nor modify the line, so there is no need to copy it.
+	b := unsafe.Slice(unsafe.StringData(line), len(line))
+	for _, rule := range r {
+		if rule.filter == nil || rule.filter.Filter(b) {
+			return rule, true
+		}
+	}
+	return nil, false
+}
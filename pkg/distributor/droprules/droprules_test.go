@@ -0,0 +1,119 @@
+package droprules
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRules(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		exprs []string
+		err   bool
+	}{
+		{
+			name:  "no rules",
+			exprs: nil,
+		},
+		{
+			name: "valid rules",
+			exprs: []string{
+				`{env="dev"}`,
+				`{env="dev"} |= "healthcheck"`,
+				`{app=~"api|web"} |~ "GET /(ready|live)" != "error" or "fatal"`,
+			},
+		},
+		{
+			name:  "invalid selector",
+			exprs: []string{`{env="dev"`},
+			err:   true,
+		},
+		{
+			name:  "invalid regex",
+			exprs: []string{`{env="dev"} |~ "("`},
+			err:   true,
+		},
+		{
+			name:  "parser stage",
+			exprs: []string{`{env="dev"} | json`},
+			err:   true,
+		},
+		{
+			name:  "label filter stage",
+			exprs: []string{`{env="dev"} | level="debug"`},
+			err:   true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rules, err := NewRules(tc.exprs)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, rules, len(tc.exprs))
+		})
+	}
+}
+
+func TestRules_Drop(t *testing.T) {
+	rules, err := NewRules([]string{
+		`{env="dev"} |= "healthcheck"`,
+		`{app="noisy"}`,
+		`{env="prod"} |~ "level=(debug|trace)" != "keep"`,
+	})
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name   string
+		labels labels.Labels
+		line   string
+		drop   string
+	}{
+		{
+			name:   "line filter matches",
+			labels: labels.FromStrings("env", "dev"),
+			line:   "GET /healthcheck 200",
+			drop:   `{env="dev"} |= "healthcheck"`,
+		},
+		{
+			name:   "line filter doesn't match",
+			labels: labels.FromStrings("env", "dev"),
+			line:   "GET /api 200",
+		},
+		{
+			name:   "selector doesn't match",
+			labels: labels.FromStrings("env", "staging"),
+			line:   "GET /healthcheck 200",
+		},
+		{
+			name:   "selector without line filter",
+			labels: labels.FromStrings("app", "noisy", "env", "staging"),
+			line:   "anything",
+			drop:   `{app="noisy"}`,
+		},
+		{
+			name:   "chained line filters match",
+			labels: labels.FromStrings("env", "prod"),
+			line:   "level=debug msg=hello",
+			drop:   `{env="prod"} |~ "level=(debug|trace)" != "keep"`,
+		},
+		{
+			name:   "chained line filters don't match",
+			labels: labels.FromStrings("env", "prod"),
+			line:   "level=debug msg=keep",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rule, drop := rules.ForStream(tc.labels).Drop(tc.line)
+			if tc.drop == "" {
+				require.False(t, drop)
+				return
+			}
+			require.True(t, drop)
+			require.Equal(t, tc.drop, rule.Expr)
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package distributor
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -13,20 +15,114 @@ import (
 	"github.com/grafana/dskit/tenant"
 
 	"github.com/grafana/loki/pkg/loghttp/push"
+	"github.com/grafana/loki/pkg/logproto"
 	util_log "github.com/grafana/loki/pkg/util/log"
 	"github.com/grafana/loki/pkg/validation"
 )
 
+// elasticsearchCompatibleVersion is the Elasticsearch version reported to the
+// clients of the bulk API endpoint.
+const elasticsearchCompatibleVersion = "8.11.0"
+
 // PushHandler reads a snappy-compressed proto from the HTTP body.
 func (d *Distributor) PushHandler(w http.ResponseWriter, r *http.Request) {
-	d.pushHandler(w, r, push.ParseLokiRequest)
+	d.pushHandler(w, r, push.ParseLokiRequest, writeNoContent)
 }
 
 func (d *Distributor) OTLPPushHandler(w http.ResponseWriter, r *http.Request) {
-	d.pushHandler(w, r, push.ParseOTLPRequest)
+	d.pushHandler(w, r, push.ParseOTLPRequest, writeNoContent)
+}
+
+// ElasticsearchBulkHandler accepts the requests of the Elasticsearch bulk API,
+// so that shippers sending logs to Elasticsearch can push them to Loki. The
+// response lists the status of every item in the order of the request, with
+// the entries rejected by the validation reported as failed items.
+func (d *Distributor) ElasticsearchBulkHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		items    []push.ElasticsearchBulkItem
+		rejected = &rejectedEntries{}
+	)
+	parse := func(userID string, r *http.Request, tenantsRetention push.TenantsRetention, limits push.Limits, tracker push.UsageTracker) (*logproto.PushRequest, *push.Stats, error) {
+		req, parsedItems, stats, err := push.ParseElasticsearchBulkRequest(userID, r, d.cfg.MaxRecvMsgSize, tenantsRetention, limits, tracker)
+		items = parsedItems
+		return req, stats, err
+	}
+	writeResponse := func(w http.ResponseWriter, _ *logproto.PushRequest) {
+		writeElasticsearchBulkResponse(w, items, rejected)
+	}
+	d.pushHandler(w, r.WithContext(withRejectedEntries(r.Context(), rejected)), parse, writeResponse)
+}
+
+// ElasticsearchInfoHandler returns the cluster information Elasticsearch
+// clients check the version of before sending bulk requests.
+func (d *Distributor) ElasticsearchInfoHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("X-Elastic-Product", "Elasticsearch")
+	util.WriteJSONResponse(w, map[string]interface{}{
+		"name":         "loki",
+		"cluster_name": "loki",
+		"version": map[string]string{
+			"number":         elasticsearchCompatibleVersion,
+			"build_flavor":   "default",
+			"lucene_version": "9.8.0",
+		},
+		"tagline": "You Know, for Search",
+	})
+}
+
+// SplunkHECHandler accepts the requests of the event endpoint of the Splunk
+// HTTP Event Collector.
+func (d *Distributor) SplunkHECHandler(w http.ResponseWriter, r *http.Request) {
+	parse := func(userID string, r *http.Request, tenantsRetention push.TenantsRetention, limits push.Limits, tracker push.UsageTracker) (*logproto.PushRequest, *push.Stats, error) {
+		return push.ParseSplunkHECRequest(userID, r, d.cfg.MaxRecvMsgSize, tenantsRetention, limits, tracker)
+	}
+	d.pushHandler(w, r, parse, writeSplunkHECResponse)
+}
+
+func writeNoContent(w http.ResponseWriter, _ *logproto.PushRequest) {
+	w.WriteHeader(http.StatusNoContent)
 }
 
-func (d *Distributor) pushHandler(w http.ResponseWriter, r *http.Request, pushRequestParser push.RequestParser) {
+func writeElasticsearchBulkResponse(w http.ResponseWriter, items []push.ElasticsearchBulkItem, rejected *rejectedEntries) {
+	type itemError struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	}
+	type itemStatus struct {
+		Status int        `json:"status"`
+		Error  *itemError `json:"error,omitempty"`
+	}
+	var (
+		statuses = make([]map[string]itemStatus, 0, len(items))
+		failed   bool
+	)
+	for _, item := range items {
+		status := itemStatus{Status: http.StatusCreated}
+		if err := rejected.err(item.Stream, item.Entry); err != nil {
+			status = itemStatus{
+				Status: http.StatusBadRequest,
+				Error:  &itemError{Type: "illegal_argument_exception", Reason: err.Error()},
+			}
+			failed = true
+		}
+		statuses = append(statuses, map[string]itemStatus{item.Op: status})
+	}
+
+	w.Header().Set("X-Elastic-Product", "Elasticsearch")
+	util.WriteJSONResponse(w, map[string]interface{}{
+		"took":   0,
+		"errors": failed,
+		"items":  statuses,
+	})
+}
+
+func writeSplunkHECResponse(w http.ResponseWriter, _ *logproto.PushRequest) {
+	util.WriteJSONResponse(w, map[string]interface{}{
+		"text": "Success",
+		"code": 0,
+	})
+}
+
+func (d *Distributor) pushHandler(w http.ResponseWriter, r *http.Request, pushRequestParser push.RequestParser, writeResponse func(http.ResponseWriter, *logproto.PushRequest)) {
 	logger := util_log.WithContext(r.Context(), util_log.Logger)
 	tenantID, err := tenant.TenantID(r.Context())
 	if err != nil {
@@ -41,16 +137,25 @@ func (d *Distributor) pushHandler(w http.ResponseWriter, r *http.Request, pushRe
 
 	req, err := push.ParseRequest(logger, tenantID, r, d.tenantsRetention, d.validator.Limits, pushRequestParser, d.usageTracker)
 	if err != nil {
+		code := http.StatusBadRequest
+		switch {
+		case errors.Is(err, push.ErrUnknownLabelSet):
+			// Tells the client to start a new session, sending the label sets again.
+			code = http.StatusConflict
+		case errors.Is(err, push.ErrTooManyPushSessions), errors.Is(err, push.ErrTooManyPushLabelSets):
+			// Tells the client to retry later, once the idle sessions are forgotten.
+			code = http.StatusTooManyRequests
+		}
 		if d.tenantConfigs.LogPushRequest(tenantID) {
 			level.Debug(logger).Log(
 				"msg", "push request failed",
-				"code", http.StatusBadRequest,
+				"code", code,
 				"err", err,
 			)
 		}
 		d.writeFailuresManager.Log(tenantID, fmt.Errorf("couldn't parse push request: %w", err))
 
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		http.Error(w, err.Error(), code)
 		return
 	}
 
@@ -66,13 +171,18 @@ func (d *Distributor) pushHandler(w http.ResponseWriter, r *http.Request, pushRe
 	}
 
 	_, err = d.Push(r.Context(), req)
+	if rejected := rejectedEntriesFromContext(r.Context()); rejected != nil && err != nil && errors.Is(err, rejected.validationErr) {
+		// The rejected entries are reported per item instead of failing the
+		// whole request, which would make the client retry the accepted ones.
+		err = nil
+	}
 	if err == nil {
 		if d.tenantConfigs.LogPushRequest(tenantID) {
 			level.Debug(logger).Log(
 				"msg", "push request successful",
 			)
 		}
-		w.WriteHeader(http.StatusNoContent)
+		writeResponse(w, req)
 		return
 	}
 
@@ -99,6 +209,61 @@ func (d *Distributor) pushHandler(w http.ResponseWriter, r *http.Request, pushRe
 	}
 }
 
+// rejectedEntries records the entries of a push request rejected by the
+// validation, for the handlers reporting the status of every entry.
+type rejectedEntries struct {
+	streams map[int]error
+	entries map[push.EntryRef]error
+
+	// validationErr is the error returned by the push for the rejected entries.
+	validationErr error
+}
+
+type rejectedEntriesKey struct{}
+
+func withRejectedEntries(ctx context.Context, rejected *rejectedEntries) context.Context {
+	return context.WithValue(ctx, rejectedEntriesKey{}, rejected)
+}
+
+// rejectedEntriesFromContext returns the rejected entries of the context, or
+// nil if the rejected entries aren't recorded.
+func rejectedEntriesFromContext(ctx context.Context) *rejectedEntries {
+	rejected, _ := ctx.Value(rejectedEntriesKey{}).(*rejectedEntries)
+	return rejected
+}
+
+// rejectStream records that all the entries of a stream were rejected.
+func (r *rejectedEntries) rejectStream(stream int, err error) {
+	if r == nil {
+		return
+	}
+	if r.streams == nil {
+		r.streams = map[int]error{}
+	}
+	r.streams[stream] = err
+}
+
+func (r *rejectedEntries) rejectEntry(stream, entry int, err error) {
+	if r == nil {
+		return
+	}
+	if r.entries == nil {
+		r.entries = map[push.EntryRef]error{}
+	}
+	r.entries[push.EntryRef{Stream: stream, Entry: entry}] = err
+}
+
+// err returns the error an entry was rejected with, or nil if it wasn't.
+func (r *rejectedEntries) err(stream, entry int) error {
+	if r == nil {
+		return nil
+	}
+	if err, ok := r.streams[stream]; ok {
+		return err
+	}
+	return r.entries[push.EntryRef{Stream: stream, Entry: entry}]
+}
+
 // ServeHTTP implements the distributor ring status page.
 //
 // If the rate limiting strategy is local instead of global, no ring is used by
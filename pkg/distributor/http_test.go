@@ -2,15 +2,20 @@ package distributor
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/grafana/dskit/user"
 	"github.com/grafana/loki/pkg/loghttp/push"
 	"github.com/grafana/loki/pkg/logproto"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/grafana/dskit/flagext"
+	ring_client "github.com/grafana/dskit/ring/client"
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/loki/pkg/validation"
@@ -75,11 +80,129 @@ func TestRequestParserWrapping(t *testing.T) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "fake-path", nil)
 	require.NoError(t, err)
 
-	distributors[0].pushHandler(httptest.NewRecorder(), req, stubParser)
+	distributors[0].pushHandler(httptest.NewRecorder(), req, stubParser, writeNoContent)
 
 	require.True(t, called)
 }
 
+func TestPushHandlerBatchSessionErrors(t *testing.T) {
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	distributors, _ := prepare(t, 1, 3, limits, nil)
+
+	ctx := user.InjectOrgID(context.Background(), "test-user")
+	for _, tc := range []struct {
+		err  error
+		code int
+	}{
+		{err: fmt.Errorf("%w: 1", push.ErrUnknownLabelSet), code: http.StatusConflict},
+		{err: fmt.Errorf("%w, the limit is 1", push.ErrTooManyPushSessions), code: http.StatusTooManyRequests},
+		{err: fmt.Errorf("%w, the limit is 1", push.ErrTooManyPushLabelSets), code: http.StatusTooManyRequests},
+		{err: errors.New("invalid request"), code: http.StatusBadRequest},
+	} {
+		t.Run(tc.err.Error(), func(t *testing.T) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, "fake-path", nil)
+			require.NoError(t, err)
+
+			parser := func(_ string, _ *http.Request, _ push.TenantsRetention, _ push.Limits, _ push.UsageTracker) (*logproto.PushRequest, *push.Stats, error) {
+				return nil, nil, tc.err
+			}
+			rec := httptest.NewRecorder()
+			distributors[0].pushHandler(rec, req, parser, writeNoContent)
+			require.Equal(t, tc.code, rec.Code)
+		})
+	}
+}
+
+func TestThirdPartyPushHandlers(t *testing.T) {
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.RejectOldSamples = false
+	ingester := &mockIngester{}
+	distributors, _ := prepare(t, 1, 3, limits, func(addr string) (ring_client.PoolClient, error) { return ingester, nil })
+
+	ctx := user.InjectOrgID(context.Background(), "test-user")
+	for _, tc := range []struct {
+		name     string
+		handler  http.HandlerFunc
+		body     string
+		expected string
+	}{
+		{
+			name:     "elasticsearch bulk",
+			handler:  distributors[0].ElasticsearchBulkHandler,
+			body:     "{\"index\":{}}\n{\"message\":\"foo\"}\n{\"create\":{}}\n{\"message\":\"bar\"}\n",
+			expected: `{"errors":false,"items":[{"index":{"status":201}},{"create":{"status":201}}],"took":0}`,
+		},
+		{
+			name:     "splunk hec",
+			handler:  distributors[0].SplunkHECHandler,
+			body:     `{"event":"foo"}{"event":"bar"}`,
+			expected: `{"code":0,"text":"Success"}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/", strings.NewReader(tc.body))
+			require.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			tc.handler(rec, req)
+			require.Equal(t, http.StatusOK, rec.Code)
+			require.JSONEq(t, tc.expected, rec.Body.String())
+
+			ingester.mu.Lock()
+			pushed := ingester.pushed[len(ingester.pushed)-1]
+			ingester.mu.Unlock()
+			require.Len(t, pushed.Streams, 1)
+			require.Equal(t, `{service_name="unknown_service"}`, pushed.Streams[0].Labels)
+			require.Len(t, pushed.Streams[0].Entries, 2)
+		})
+	}
+}
+
+func TestElasticsearchBulkHandlerRejectedItems(t *testing.T) {
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.RejectOldSamples = false
+	limits.MaxLineSize = 5
+	ingester := &mockIngester{}
+	distributors, _ := prepare(t, 1, 3, limits, func(addr string) (ring_client.PoolClient, error) { return ingester, nil })
+
+	body := "{\"index\":{}}\n{\"message\":\"foo\"}\n" +
+		"{\"create\":{}}\n{\"message\":\"too long\"}\n" +
+		"{\"index\":{}}\n{\"message\":\"bar\"}\n"
+	ctx := user.InjectOrgID(context.Background(), "test-user")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/", strings.NewReader(body))
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	distributors[0].ElasticsearchBulkHandler(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			Status int `json:"status"`
+			Error  struct {
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.True(t, resp.Errors)
+	require.Len(t, resp.Items, 3)
+	require.Equal(t, http.StatusCreated, resp.Items[0]["index"].Status)
+	require.Equal(t, http.StatusBadRequest, resp.Items[1]["create"].Status)
+	require.Contains(t, resp.Items[1]["create"].Error.Reason, "Max entry size")
+	require.Equal(t, http.StatusCreated, resp.Items[2]["index"].Status)
+
+	ingester.mu.Lock()
+	pushed := ingester.pushed[len(ingester.pushed)-1]
+	ingester.mu.Unlock()
+	require.Len(t, pushed.Streams, 1)
+	require.Len(t, pushed.Streams[0].Entries, 2)
+}
+
 func stubParser(_ string, _ *http.Request, _ push.TenantsRetention, _ push.Limits, _ push.UsageTracker) (*logproto.PushRequest, *push.Stats, error) {
 	return &logproto.PushRequest{}, &push.Stats{}, nil
 }
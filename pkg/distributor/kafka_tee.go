@@ -0,0 +1,123 @@
+package distributor
+
+import (
+	"context"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/services"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/loki/pkg/kafka"
+	"github.com/grafana/loki/pkg/util/constants"
+)
+
+type kafkaTeeItem struct {
+	tenant  string
+	records []kafka.Record
+}
+
+// KafkaTee duplicates the log streams to Kafka. The streams are queued and
+// produced by a worker, so Kafka doesn't slow down the pushes to the
+// ingesters: the streams are dropped instead when the queue is full.
+type KafkaTee struct {
+	services.Service
+
+	writer *kafka.Writer
+	logger log.Logger
+	items  chan kafkaTeeItem
+
+	droppedEntries *prometheus.CounterVec
+	queueLength    prometheus.Gauge
+}
+
+// NewKafkaTee returns a Tee producing the log streams to Kafka. If the Kafka
+// config enables write_ahead, the returned Tee is a WriteAheadTee. The Tee is
+// a service closing the writer once stopped.
+func NewKafkaTee(writer *kafka.Writer, cfg kafka.Config, logger log.Logger, registerer prometheus.Registerer) Tee {
+	t := &KafkaTee{
+		writer: writer,
+		logger: logger,
+		items:  make(chan kafkaTeeItem, cfg.QueueSize),
+		droppedEntries: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: constants.Loki,
+			Name:      "distributor_kafka_tee_dropped_entries_total",
+			Help:      "The total number of entries which couldn't be duplicated to Kafka, per reason.",
+		}, []string{"reason"}),
+		queueLength: promauto.With(registerer).NewGauge(prometheus.GaugeOpts{
+			Namespace: constants.Loki,
+			Name:      "distributor_kafka_tee_queue_length",
+			Help:      "The number of pushes waiting to be duplicated to Kafka.",
+		}),
+	}
+	t.Service = services.NewBasicService(nil, t.running, t.stopping)
+	if cfg.WriteAhead {
+		return &kafkaWriteAheadTee{t}
+	}
+	return t
+}
+
+// Duplicate implements Tee. Failures are only logged, since the log streams
+// are still pushed to the ingesters.
+func (t *KafkaTee) Duplicate(tenant string, streams []KeyedStream) {
+	select {
+	case t.items <- kafkaTeeItem{tenant: tenant, records: toKafkaRecords(streams)}:
+		t.queueLength.Inc()
+	default:
+		t.droppedEntries.WithLabelValues(teeDropReasonQueueFull).Add(float64(countKeyedEntries(streams)))
+	}
+}
+
+func (t *KafkaTee) running(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case item := <-t.items:
+			t.queueLength.Dec()
+			if err := t.writer.Write(item.tenant, item.records); err != nil {
+				level.Warn(t.logger).Log("msg", "failed to duplicate streams to kafka", "tenant", item.tenant, "err", err)
+				t.droppedEntries.WithLabelValues(teeDropReasonSendFailed).Add(float64(countRecordEntries(item.records)))
+			}
+		}
+	}
+}
+
+func (t *KafkaTee) stopping(_ error) error {
+	return t.writer.Close()
+}
+
+type kafkaWriteAheadTee struct {
+	*KafkaTee
+}
+
+// Write implements WriteAheadTee. The streams are produced synchronously, as
+// the push is only acknowledged once Kafka stored them.
+func (t *kafkaWriteAheadTee) Write(_ context.Context, tenant string, streams []KeyedStream) error {
+	return t.writer.Write(tenant, toKafkaRecords(streams))
+}
+
+func toKafkaRecords(streams []KeyedStream) []kafka.Record {
+	records := make([]kafka.Record, 0, len(streams))
+	for _, s := range streams {
+		records = append(records, kafka.Record{HashKey: s.HashKey, Stream: s.Stream})
+	}
+	return records
+}
+
+func countKeyedEntries(streams []KeyedStream) int {
+	n := 0
+	for _, s := range streams {
+		n += len(s.Stream.Entries)
+	}
+	return n
+}
+
+func countRecordEntries(records []kafka.Record) int {
+	n := 0
+	for _, r := range records {
+		n += len(r.Stream.Entries)
+	}
+	return n
+}
@@ -0,0 +1,170 @@
+package distributor
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/axiomhq/hyperloglog"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/util"
+	"github.com/grafana/loki/pkg/util/constants"
+)
+
+// DemotedLabel is a stream label demoted to structured metadata because of
+// its high cardinality.
+type DemotedLabel struct {
+	Name                 string    `json:"name"`
+	EstimatedCardinality uint64    `json:"estimated_cardinality"`
+	DemotedAt            time.Time `json:"demoted_at"`
+}
+
+// labelCardinalityTracker estimates the number of distinct values of every
+// stream label of every tenant, and keeps track of the labels exceeding the
+// tenant threshold.
+type labelCardinalityTracker struct {
+	mtx     sync.RWMutex
+	tenants map[string]*tenantLabelCardinality
+
+	demotions *prometheus.CounterVec
+	logger    log.Logger
+}
+
+type tenantLabelCardinality struct {
+	mtx         sync.Mutex
+	windowStart time.Time
+	sketches    map[string]*hyperloglog.Sketch
+	demoted     map[string]DemotedLabel
+}
+
+func newLabelCardinalityTracker(registerer prometheus.Registerer, logger log.Logger) *labelCardinalityTracker {
+	return &labelCardinalityTracker{
+		tenants: map[string]*tenantLabelCardinality{},
+		demotions: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: constants.Loki,
+			Name:      "distributor_label_demotions_total",
+			Help:      "The total number of stream labels demoted to structured metadata because of their high cardinality, per tenant and label name.",
+		}, []string{"tenant", "label"}),
+		logger: logger,
+	}
+}
+
+func (t *labelCardinalityTracker) tenant(tenantID string) *tenantLabelCardinality {
+	t.mtx.RLock()
+	tc, ok := t.tenants[tenantID]
+	t.mtx.RUnlock()
+	if ok {
+		return tc
+	}
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if tc, ok := t.tenants[tenantID]; ok {
+		return tc
+	}
+	tc = &tenantLabelCardinality{
+		sketches: map[string]*hyperloglog.Sketch{},
+		demoted:  map[string]DemotedLabel{},
+	}
+	t.tenants[tenantID] = tc
+	return tc
+}
+
+// Observe records the values of the given stream labels, and returns the
+// names of the labels of the stream which are demoted.
+func (t *labelCardinalityTracker) Observe(tenantID string, lbs labels.Labels, threshold int, window time.Duration, now time.Time) []string {
+	tc := t.tenant(tenantID)
+
+	tc.mtx.Lock()
+	defer tc.mtx.Unlock()
+
+	if now.Sub(tc.windowStart) > window {
+		tc.windowStart = now
+		tc.sketches = map[string]*hyperloglog.Sketch{}
+	}
+
+	var demoted []string
+	for _, l := range lbs {
+		if _, ok := tc.demoted[l.Name]; ok {
+			demoted = append(demoted, l.Name)
+			continue
+		}
+
+		sk, ok := tc.sketches[l.Name]
+		if !ok {
+			sk = hyperloglog.New14()
+			tc.sketches[l.Name] = sk
+		}
+		sk.Insert([]byte(l.Value))
+
+		if estimate := sk.Estimate(); estimate > uint64(threshold) {
+			tc.demoted[l.Name] = DemotedLabel{Name: l.Name, EstimatedCardinality: estimate, DemotedAt: now}
+			delete(tc.sketches, l.Name)
+			t.demotions.WithLabelValues(tenantID, l.Name).Inc()
+			level.Info(t.logger).Log("msg", "demoting high-cardinality label to structured metadata", "tenant", tenantID, "label", l.Name, "estimated_cardinality", estimate, "threshold", threshold)
+			demoted = append(demoted, l.Name)
+		}
+	}
+	return demoted
+}
+
+// DemotedLabels returns the demoted labels of every tenant.
+func (t *labelCardinalityTracker) DemotedLabels() map[string][]DemotedLabel {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+
+	res := make(map[string][]DemotedLabel, len(t.tenants))
+	for tenantID, tc := range t.tenants {
+		tc.mtx.Lock()
+		if len(tc.demoted) > 0 {
+			demoted := make([]DemotedLabel, 0, len(tc.demoted))
+			for _, l := range tc.demoted {
+				demoted = append(demoted, l)
+			}
+			sort.Slice(demoted, func(i, j int) bool { return demoted[i].Name < demoted[j].Name })
+			res[tenantID] = demoted
+		}
+		tc.mtx.Unlock()
+	}
+	return res
+}
+
+// demoteHighCardinalityLabels moves the demoted labels of the stream to the
+// structured metadata of its entries. Streams are left untouched when all of
+// their labels are demoted, since a stream requires at least one label.
+func (d *Distributor) demoteHighCardinalityLabels(vContext validationContext, lbs labels.Labels, stream *logproto.Stream) (labels.Labels, string, uint64) {
+	demoted := d.labelCardinality.Observe(vContext.userID, lbs, vContext.demoteLabelsThreshold, vContext.demoteLabelsWindow, time.Now())
+	if len(demoted) == 0 || len(demoted) == len(lbs) {
+		return lbs, stream.Labels, stream.Hash
+	}
+
+	lb := labels.NewBuilder(lbs)
+	structuredMetadata := make(labels.Labels, 0, len(demoted))
+	for _, name := range demoted {
+		structuredMetadata = append(structuredMetadata, labels.Label{Name: name, Value: lbs.Get(name)})
+		lb.Del(name)
+	}
+	for i := range stream.Entries {
+		stream.Entries[i].StructuredMetadata = addStructuredMetadata(stream.Entries[i].StructuredMetadata, structuredMetadata)
+	}
+
+	ls := lb.Labels()
+	return ls, ls.String(), ls.Hash()
+}
+
+// DemotedLabelsHandler lists the stream labels demoted to structured metadata
+// by this distributor, per tenant.
+func (d *Distributor) DemotedLabelsHandler(w http.ResponseWriter, _ *http.Request) {
+	util.WriteJSONResponse(w, struct {
+		Tenants map[string][]DemotedLabel `json:"tenants"`
+	}{
+		Tenants: d.labelCardinality.DemotedLabels(),
+	})
+}
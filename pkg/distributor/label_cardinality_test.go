@@ -0,0 +1,46 @@
+package distributor
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLabelCardinalityTracker(t *testing.T) {
+	tracker := newLabelCardinalityTracker(prometheus.NewRegistry(), log.NewNopLogger())
+	now := time.Now()
+
+	for i := 0; i < 10; i++ {
+		demoted := tracker.Observe("tenant", labels.FromStrings("app", "foo", "pod_uid", fmt.Sprint(i)), 20, time.Hour, now)
+		require.Empty(t, demoted)
+	}
+
+	// A new window forgets about the previously seen values.
+	for i := 10; i < 25; i++ {
+		demoted := tracker.Observe("tenant", labels.FromStrings("app", "foo", "pod_uid", fmt.Sprint(i)), 20, time.Hour, now.Add(2*time.Hour))
+		require.Empty(t, demoted)
+	}
+
+	var demoted []string
+	for i := 25; i < 35 && len(demoted) == 0; i++ {
+		demoted = tracker.Observe("tenant", labels.FromStrings("app", "foo", "pod_uid", fmt.Sprint(i)), 20, time.Hour, now.Add(2*time.Hour))
+	}
+	require.Equal(t, []string{"pod_uid"}, demoted)
+	require.Equal(t, 1.0, testutil.ToFloat64(tracker.demotions.WithLabelValues("tenant", "pod_uid")))
+
+	// Demoted labels stay demoted, and are tracked per tenant.
+	require.Equal(t, []string{"pod_uid"}, tracker.Observe("tenant", labels.FromStrings("app", "foo", "pod_uid", "0"), 20, time.Hour, now.Add(4*time.Hour)))
+	require.Empty(t, tracker.Observe("other", labels.FromStrings("app", "foo", "pod_uid", "0"), 20, time.Hour, now))
+
+	all := tracker.DemotedLabels()
+	require.Len(t, all, 1)
+	require.Len(t, all["tenant"], 1)
+	require.Equal(t, "pod_uid", all["tenant"][0].Name)
+	require.Greater(t, all["tenant"][0].EstimatedCardinality, uint64(20))
+}
@@ -4,8 +4,11 @@ import (
 	"time"
 
 	"github.com/grafana/loki/pkg/compactor/retention"
+	"github.com/grafana/loki/pkg/distributor/droprules"
+	"github.com/grafana/loki/pkg/distributor/relabel"
 	"github.com/grafana/loki/pkg/distributor/shardstreams"
 	"github.com/grafana/loki/pkg/loghttp/push"
+	"github.com/grafana/loki/pkg/validation"
 )
 
 // Limits is an interface for distributor limits/related configs
@@ -31,4 +34,14 @@ type Limits interface {
 	MaxStructuredMetadataSize(userID string) int
 	MaxStructuredMetadataCount(userID string) int
 	OTLPConfig(userID string) push.OTLPConfig
+	ElasticsearchConfig(userID string) push.FieldMappingConfig
+	SplunkHECConfig(userID string) push.FieldMappingConfig
+	MaxPushBatchSessions(userID string) int
+	MaxPushBatchLabelSets(userID string) int
+	StreamRelabelRules(userID string) relabel.Rules
+	IngestDropRules(userID string) droprules.Rules
+	DemoteHighCardinalityLabelsThreshold(userID string) int
+	DemoteHighCardinalityLabelsWindow(userID string) time.Duration
+	TeeRules(userID string) []validation.TeeRule
+	StreamRateLimitPolicies(userID string) []validation.StreamRateLimitPolicy
 }
@@ -0,0 +1,101 @@
+package relabel
+
+import (
+	"fmt"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+	"gopkg.in/yaml.v2"
+
+	"github.com/grafana/loki/pkg/ruler/util"
+)
+
+// StructuredMetadata is a Loki specific relabel action which moves the stream
+// labels whose names match the regex to the structured metadata of every entry
+// of the stream.
+const StructuredMetadata = "structured_metadata"
+
+// Rule is a validated stream relabeling rule.
+type Rule struct {
+	Action string
+
+	cfg   *relabel.Config
+	regex relabel.Regexp
+}
+
+// Rules is an ordered list of stream relabeling rules.
+type Rules []*Rule
+
+// NewRules validates the given relabel configs and converts them into rules.
+// Besides the Prometheus relabel actions, configs can use the
+// structured_metadata action.
+func NewRules(configs []*util.RelabelConfig) (Rules, error) {
+	if len(configs) == 0 {
+		return nil, nil
+	}
+
+	rules := make(Rules, 0, len(configs))
+	for i, cfg := range configs {
+		if cfg.Action == StructuredMetadata {
+			rule, err := newStructuredMetadataRule(cfg)
+			if err != nil {
+				return nil, fmt.Errorf("invalid relabel config at index %d: %w", i, err)
+			}
+			rules = append(rules, rule)
+			continue
+		}
+
+		// Round-trip through YAML to get the defaults and validation of the
+		// Prometheus relabel configs.
+		out, err := yaml.Marshal(cfg)
+		if err != nil {
+			return nil, err
+		}
+		var rc relabel.Config
+		if err := yaml.Unmarshal(out, &rc); err != nil {
+			return nil, fmt.Errorf("invalid relabel config at index %d: %w", i, err)
+		}
+		rules = append(rules, &Rule{Action: string(rc.Action), cfg: &rc})
+	}
+	return rules, nil
+}
+
+func newStructuredMetadataRule(cfg *util.RelabelConfig) (*Rule, error) {
+	if len(cfg.SourceLabels) > 0 || cfg.TargetLabel != "" || cfg.Separator != "" || cfg.Replacement != "" || cfg.Modulus != 0 {
+		return nil, fmt.Errorf("%s action requires only 'regex', and no other fields", StructuredMetadata)
+	}
+	if cfg.Regex == "" {
+		return nil, fmt.Errorf("%s action requires a 'regex' matching the label names to move", StructuredMetadata)
+	}
+	re, err := relabel.NewRegexp(cfg.Regex)
+	if err != nil {
+		return nil, err
+	}
+	return &Rule{Action: StructuredMetadata, regex: re}, nil
+}
+
+// Process applies the rules in order to the given stream labels.
+// It returns the relabeled stream labels and the labels to be moved to the
+// structured metadata of the stream entries. If a rule drops the stream, the
+// action of that rule is returned as droppedBy.
+func (r Rules) Process(lbs labels.Labels) (result labels.Labels, structuredMetadata labels.Labels, droppedBy string) {
+	lb := labels.NewBuilder(lbs)
+	for _, rule := range r {
+		if rule.Action == StructuredMetadata {
+			lb.Range(func(l labels.Label) {
+				if rule.regex.MatchString(l.Name) {
+					structuredMetadata = append(structuredMetadata, l)
+				}
+			})
+			for _, l := range structuredMetadata {
+				lb.Del(l.Name)
+			}
+			continue
+		}
+
+		if !relabel.ProcessBuilder(lb, rule.cfg) {
+			return nil, nil, rule.Action
+		}
+	}
+	return lb.Labels(), structuredMetadata, ""
+}
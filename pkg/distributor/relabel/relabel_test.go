@@ -0,0 +1,103 @@
+package relabel
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/ruler/util"
+)
+
+func TestNewRules(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		configs []*util.RelabelConfig
+		err     bool
+	}{
+		{
+			name:    "no configs",
+			configs: nil,
+		},
+		{
+			name: "valid configs",
+			configs: []*util.RelabelConfig{
+				{SourceLabels: []string{"env"}, Regex: "dev", Action: "drop"},
+				{Regex: "pod_uid", Action: StructuredMetadata},
+				{SourceLabels: []string{"app"}, TargetLabel: "shard", Modulus: 4, Action: "hashmod"},
+			},
+		},
+		{
+			name:    "hashmod without modulus",
+			configs: []*util.RelabelConfig{{SourceLabels: []string{"app"}, TargetLabel: "shard", Action: "hashmod"}},
+			err:     true,
+		},
+		{
+			name:    "unknown action",
+			configs: []*util.RelabelConfig{{Action: "explode"}},
+			err:     true,
+		},
+		{
+			name:    "structured metadata without regex",
+			configs: []*util.RelabelConfig{{Action: StructuredMetadata}},
+			err:     true,
+		},
+		{
+			name:    "structured metadata with target label",
+			configs: []*util.RelabelConfig{{Regex: "pod_uid", TargetLabel: "foo", Action: StructuredMetadata}},
+			err:     true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rules, err := NewRules(tc.configs)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, rules, len(tc.configs))
+		})
+	}
+}
+
+func TestRules_Process(t *testing.T) {
+	rules, err := NewRules([]*util.RelabelConfig{
+		{SourceLabels: []string{"env"}, Regex: "dev", Action: "drop"},
+		{SourceLabels: []string{"namespace"}, Regex: "(.*)-prod", TargetLabel: "namespace", Replacement: "$1", Action: "replace"},
+		{Regex: "pod_uid|request_id", Action: StructuredMetadata},
+		{Regex: "tmp_.*", Action: "labeldrop"},
+	})
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name                       string
+		lbs                        labels.Labels
+		expected                   labels.Labels
+		expectedStructuredMetadata labels.Labels
+		expectedDroppedBy          string
+	}{
+		{
+			name:              "dropped",
+			lbs:               labels.FromStrings("app", "foo", "env", "dev"),
+			expectedDroppedBy: "drop",
+		},
+		{
+			name:     "replaced and label dropped",
+			lbs:      labels.FromStrings("app", "foo", "namespace", "shop-prod", "tmp_id", "1"),
+			expected: labels.FromStrings("app", "foo", "namespace", "shop"),
+		},
+		{
+			name:                       "moved to structured metadata",
+			lbs:                        labels.FromStrings("app", "foo", "pod_uid", "abc", "request_id", "123"),
+			expected:                   labels.FromStrings("app", "foo"),
+			expectedStructuredMetadata: labels.FromStrings("pod_uid", "abc", "request_id", "123"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			result, structuredMetadata, droppedBy := rules.Process(tc.lbs)
+			require.Equal(t, tc.expectedDroppedBy, droppedBy)
+			require.Equal(t, tc.expected, result)
+			require.Equal(t, tc.expectedStructuredMetadata, structuredMetadata)
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package distributor
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/pkg/util"
+	"github.com/grafana/loki/pkg/util/constants"
+	"github.com/grafana/loki/pkg/validation"
+)
+
+const streamPolicyRateWindow = 30 * time.Second
+
+// StreamPolicyStatus is the current state of a stream rate limit policy of a
+// tenant, as seen by this distributor.
+type StreamPolicyStatus struct {
+	Name     string `json:"name"`
+	Selector string `json:"selector"`
+	Rate     int    `json:"rate"`
+	Burst    int    `json:"burst"`
+
+	// ReceivedRate is the rate in bytes per second of all the streams
+	// matching the policy, over the last complete window.
+	ReceivedRate float64 `json:"received_rate"`
+	// Streams is the number of streams matching the policy received over the
+	// last complete window.
+	Streams int `json:"streams"`
+	// MaxStreamRate is the highest rate in bytes per second reported by the
+	// ingesters for one of these streams.
+	MaxStreamRate int64 `json:"max_stream_rate"`
+}
+
+// streamPolicyTracker keeps track of the streams matching the stream rate
+// limit policies of every tenant, and of the rate they are received at.
+type streamPolicyTracker struct {
+	mtx     sync.Mutex
+	tenants map[string]map[string]*streamPolicyRate // tenant -> policy -> rate
+
+	receivedBytes *prometheus.CounterVec
+}
+
+type streamPolicyRate struct {
+	windowStart time.Time
+	bytes       int
+	streams     map[uint64]struct{}
+
+	rate        float64
+	prevStreams map[uint64]struct{}
+}
+
+func newStreamPolicyTracker(registerer prometheus.Registerer) *streamPolicyTracker {
+	return &streamPolicyTracker{
+		tenants: map[string]map[string]*streamPolicyRate{},
+		receivedBytes: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: constants.Loki,
+			Name:      "distributor_stream_policy_received_bytes_total",
+			Help:      "The total number of bytes received for the streams matching a stream rate limit policy, per tenant and policy.",
+		}, []string{"tenant", "policy"}),
+	}
+}
+
+// Observe records the bytes received for a stream matching the given policy.
+func (t *streamPolicyTracker) Observe(tenantID, policy string, streamHash uint64, bytes int, now time.Time) {
+	t.receivedBytes.WithLabelValues(tenantID, policy).Add(float64(bytes))
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	policies, ok := t.tenants[tenantID]
+	if !ok {
+		policies = map[string]*streamPolicyRate{}
+		t.tenants[tenantID] = policies
+	}
+	r, ok := policies[policy]
+	if !ok {
+		r = &streamPolicyRate{windowStart: now, streams: map[uint64]struct{}{}}
+		policies[policy] = r
+	}
+	r.roll(now)
+	r.bytes += bytes
+	r.streams[streamHash] = struct{}{}
+}
+
+// roll starts a new window once the current one is complete.
+func (r *streamPolicyRate) roll(now time.Time) {
+	elapsed := now.Sub(r.windowStart)
+	if elapsed < streamPolicyRateWindow {
+		return
+	}
+	if elapsed < 2*streamPolicyRateWindow {
+		r.rate = float64(r.bytes) / elapsed.Seconds()
+		r.prevStreams = r.streams
+	} else {
+		// Nothing was received during the last complete window.
+		r.rate = 0
+		r.prevStreams = nil
+	}
+	r.windowStart = now
+	r.bytes = 0
+	r.streams = map[uint64]struct{}{}
+}
+
+// Status returns the status of the given policies of a tenant. The highest
+// stream rates are looked up in the rate store.
+func (t *streamPolicyTracker) Status(tenantID string, policies []validation.StreamRateLimitPolicy, rates RateStore, now time.Time) []StreamPolicyStatus {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	res := make([]StreamPolicyStatus, 0, len(policies))
+	for _, p := range policies {
+		status := StreamPolicyStatus{
+			Name:     p.Name,
+			Selector: p.Selector,
+			Rate:     p.Rate.Val(),
+			Burst:    p.Burst.Val(),
+		}
+		if r, ok := t.tenants[tenantID][p.Name]; ok {
+			r.roll(now)
+			status.ReceivedRate = r.rate
+			status.Streams = len(r.prevStreams)
+			for hash := range r.prevStreams {
+				if rate, _ := rates.RateFor(tenantID, hash); rate > status.MaxStreamRate {
+					status.MaxStreamRate = rate
+				}
+			}
+		}
+		res = append(res, status)
+	}
+	return res
+}
+
+// Tenants returns the tenants for which streams matching a policy were received.
+func (t *streamPolicyTracker) Tenants() []string {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	tenants := make([]string, 0, len(t.tenants))
+	for tenantID := range t.tenants {
+		tenants = append(tenants, tenantID)
+	}
+	sort.Strings(tenants)
+	return tenants
+}
+
+// observeStreamRateLimitPolicy records the bytes of the stream against the
+// first stream rate limit policy of the tenant matching its labels, the same
+// policy the ingesters enforce the rate limit of.
+func (d *Distributor) observeStreamRateLimitPolicy(vContext validationContext, lbs labels.Labels, streamHash uint64, bytes int) {
+	policy := validation.MatchStreamRateLimitPolicy(vContext.streamRateLimitPolicies, lbs)
+	if policy == nil {
+		return
+	}
+	d.streamPolicies.Observe(vContext.userID, policy.Name, streamHash, bytes, time.Now())
+}
+
+// StreamPoliciesHandler lists the stream rate limit policies of the tenants
+// which pushed streams matching them to this distributor, along with the
+// current rates of these streams.
+func (d *Distributor) StreamPoliciesHandler(w http.ResponseWriter, _ *http.Request) {
+	now := time.Now()
+	tenants := map[string][]StreamPolicyStatus{}
+	for _, tenantID := range d.streamPolicies.Tenants() {
+		tenants[tenantID] = d.streamPolicies.Status(tenantID, d.validator.Limits.StreamRateLimitPolicies(tenantID), d.rateStore, now)
+	}
+
+	util.WriteJSONResponse(w, struct {
+		Tenants map[string][]StreamPolicyStatus `json:"tenants"`
+	}{
+		Tenants: tenants,
+	})
+}
@@ -0,0 +1,51 @@
+package distributor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/validation"
+)
+
+func TestStreamPolicyTracker(t *testing.T) {
+	tracker := newStreamPolicyTracker(prometheus.NewRegistry())
+	policies := []validation.StreamRateLimitPolicy{
+		{Name: "batch", Selector: `{namespace="batch"}`, Rate: 100, Burst: 200},
+		{Name: "prod", Selector: `{namespace="prod"}`, Rate: 1000, Burst: 2000},
+	}
+	rates := &fakeRateStore{rate: 42}
+	now := time.Now()
+
+	tracker.Observe("tenant", "batch", 1, 300, now)
+	tracker.Observe("tenant", "batch", 2, 300, now.Add(time.Second))
+	tracker.Observe("tenant", "batch", 1, 300, now.Add(2*time.Second))
+	require.Equal(t, 900.0, testutil.ToFloat64(tracker.receivedBytes.WithLabelValues("tenant", "batch")))
+	require.Equal(t, []string{"tenant"}, tracker.Tenants())
+
+	// No window is complete yet.
+	status := tracker.Status("tenant", policies, rates, now.Add(10*time.Second))
+	require.Equal(t, []StreamPolicyStatus{
+		{Name: "batch", Selector: `{namespace="batch"}`, Rate: 100, Burst: 200},
+		{Name: "prod", Selector: `{namespace="prod"}`, Rate: 1000, Burst: 2000},
+	}, status)
+
+	status = tracker.Status("tenant", policies, rates, now.Add(streamPolicyRateWindow))
+	require.Equal(t, StreamPolicyStatus{
+		Name:          "batch",
+		Selector:      `{namespace="batch"}`,
+		Rate:          100,
+		Burst:         200,
+		ReceivedRate:  900 / streamPolicyRateWindow.Seconds(),
+		Streams:       2,
+		MaxStreamRate: 42,
+	}, status[0])
+
+	// Nothing was received during the last complete window.
+	status = tracker.Status("tenant", policies, rates, now.Add(3*streamPolicyRateWindow))
+	require.Zero(t, status[0].ReceivedRate)
+	require.Zero(t, status[0].Streams)
+}
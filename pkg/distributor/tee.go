@@ -1,6 +1,17 @@
 package distributor
 
+import "context"
+
 // Tee implementations can duplicate the log streams to another endpoint.
 type Tee interface {
 	Duplicate(tenant string, streams []KeyedStream)
 }
+
+// WriteAheadTee implementations durably store the log streams on behalf of
+// the ingesters, which read them asynchronously. When the Tee of a distributor
+// is a WriteAheadTee, the log streams are written to it instead of being
+// pushed to the ingesters, and pushes fail if they can't be written.
+type WriteAheadTee interface {
+	Tee
+	Write(ctx context.Context, tenant string, streams []KeyedStream) error
+}
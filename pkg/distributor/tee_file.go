@@ -0,0 +1,138 @@
+package distributor
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+// fileTeeRecord is the JSON representation of an entry written by the file tee.
+type fileTeeRecord struct {
+	Tenant             string            `json:"tenant"`
+	Labels             string            `json:"labels"`
+	Timestamp          time.Time         `json:"timestamp"`
+	Line               string            `json:"line"`
+	StructuredMetadata map[string]string `json:"structured_metadata,omitempty"`
+}
+
+// fileTeeSink writes the entries of the streams as newline delimited JSON.
+// Once the file reaches its max size, it is renamed to <path>.1, the previous
+// <path>.1 to <path>.2, and so on up to max_backups files.
+type fileTeeSink struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	f    *os.File
+	w    *bufio.Writer
+	size int64
+}
+
+func newFileTeeSink(cfg FileTeeConfig) (*fileTeeSink, error) {
+	s := &fileTeeSink{
+		path:       cfg.Path,
+		maxSize:    int64(cfg.MaxSize),
+		maxBackups: cfg.MaxBackups,
+	}
+	if s.maxSize <= 0 {
+		s.maxSize = defaultTeeFileMaxSize
+	}
+	if s.maxBackups <= 0 {
+		s.maxBackups = defaultTeeFileBackups
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o750); err != nil {
+		return nil, err
+	}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *fileTeeSink) open() error {
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	s.f, s.w, s.size = f, bufio.NewWriter(f), info.Size()
+	return nil
+}
+
+func (s *fileTeeSink) rotate() error {
+	if err := s.closeFile(); err != nil {
+		return err
+	}
+	for i := s.maxBackups - 1; i > 0; i-- {
+		if err := os.Rename(s.backupPath(i), s.backupPath(i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(s.path, s.backupPath(1)); err != nil {
+		return err
+	}
+	return s.open()
+}
+
+func (s *fileTeeSink) backupPath(i int) string {
+	return fmt.Sprintf("%s.%d", s.path, i)
+}
+
+func (s *fileTeeSink) Send(_ context.Context, tenant string, streams []logproto.Stream) error {
+	for _, stream := range streams {
+		for _, e := range stream.Entries {
+			rec := fileTeeRecord{
+				Tenant:    tenant,
+				Labels:    stream.Labels,
+				Timestamp: e.Timestamp,
+				Line:      e.Line,
+			}
+			if len(e.StructuredMetadata) > 0 {
+				rec.StructuredMetadata = make(map[string]string, len(e.StructuredMetadata))
+				for _, l := range e.StructuredMetadata {
+					rec.StructuredMetadata[l.Name] = l.Value
+				}
+			}
+			b, err := json.Marshal(rec)
+			if err != nil {
+				return err
+			}
+			b = append(b, '\n')
+
+			if s.size > 0 && s.size+int64(len(b)) > s.maxSize {
+				if err := s.rotate(); err != nil {
+					return err
+				}
+			}
+			n, err := s.w.Write(b)
+			s.size += int64(n)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return s.w.Flush()
+}
+
+func (s *fileTeeSink) closeFile() error {
+	if err := s.w.Flush(); err != nil {
+		s.f.Close()
+		return err
+	}
+	return s.f.Close()
+}
+
+func (s *fileTeeSink) Close() error {
+	return s.closeFile()
+}
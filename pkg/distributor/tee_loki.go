@@ -0,0 +1,81 @@
+package distributor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/golang/snappy"
+
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+const maxErrMsgLen = 1024
+
+// lokiTeeSink forwards the streams to the push endpoint of another Loki.
+type lokiTeeSink struct {
+	url      string
+	tenantID string
+	timeout  time.Duration
+	client   *http.Client
+}
+
+func newLokiTeeSink(cfg LokiTeeConfig) *lokiTeeSink {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultTeeLokiTimeout
+	}
+	return &lokiTeeSink{
+		url:      cfg.URL.String(),
+		tenantID: cfg.TenantID,
+		timeout:  timeout,
+		client:   &http.Client{},
+	}
+}
+
+func (s *lokiTeeSink) Send(ctx context.Context, tenant string, streams []logproto.Stream) error {
+	req := logproto.PushRequest{Streams: streams}
+	buf, err := req.Marshal()
+	if err != nil {
+		return err
+	}
+	buf = snappy.Encode(nil, buf)
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/x-protobuf")
+	if s.tenantID != "" {
+		tenant = s.tenantID
+	}
+	httpReq.Header.Set("X-Scope-OrgID", tenant)
+
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		scanner := bufio.NewScanner(io.LimitReader(resp.Body, maxErrMsgLen))
+		line := ""
+		if scanner.Scan() {
+			line = scanner.Text()
+		}
+		return fmt.Errorf("server returned HTTP status %s: %s", resp.Status, line)
+	}
+	return nil
+}
+
+func (s *lokiTeeSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,265 @@
+package distributor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/dskit/services"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql/syntax"
+	"github.com/grafana/loki/pkg/util/constants"
+	lokiflagext "github.com/grafana/loki/pkg/util/flagext"
+	"github.com/grafana/loki/pkg/validation"
+)
+
+const (
+	defaultTeeQueueSize   = 1000
+	defaultTeeLokiTimeout = 10 * time.Second
+	defaultTeeFileMaxSize = 100 << 20
+	defaultTeeFileBackups = 5
+
+	teeDropReasonQueueFull  = "queue_full"
+	teeDropReasonSendFailed = "send_failed"
+	teeDropReasonUnknownTee = "unknown_tee"
+)
+
+// TeeConfig configures a tee duplicating the pushed streams to either another
+// Loki or local files. The streams of a tenant are routed to the tees by the
+// tee_rules of the tenant.
+type TeeConfig struct {
+	Name      string        `yaml:"name"`
+	QueueSize int           `yaml:"queue_size"`
+	Loki      LokiTeeConfig `yaml:"loki"`
+	File      FileTeeConfig `yaml:"file"`
+}
+
+// LokiTeeConfig configures a tee forwarding the streams to the push endpoint
+// of another Loki.
+type LokiTeeConfig struct {
+	URL      flagext.URLValue `yaml:"url"`
+	TenantID string           `yaml:"tenant_id"`
+	Timeout  time.Duration    `yaml:"timeout"`
+}
+
+// FileTeeConfig configures a tee writing the entries of the streams as
+// newline delimited JSON to a local file, rotated once it reaches max_size.
+type FileTeeConfig struct {
+	Path       string               `yaml:"path"`
+	MaxSize    lokiflagext.ByteSize `yaml:"max_size"`
+	MaxBackups int                  `yaml:"max_backups"`
+}
+
+func (cfg *TeeConfig) validate() error {
+	if cfg.Name == "" {
+		return errors.New("tee name must be set")
+	}
+	if (cfg.Loki.URL.URL == nil) == (cfg.File.Path == "") {
+		return fmt.Errorf("tee %s must configure exactly one of loki.url and file.path", cfg.Name)
+	}
+	return nil
+}
+
+// teeSink is the destination of a tee.
+type teeSink interface {
+	Send(ctx context.Context, tenant string, streams []logproto.Stream) error
+	Close() error
+}
+
+type teeItem struct {
+	tenant  string
+	streams []logproto.Stream
+}
+
+type teeQueue struct {
+	name  string
+	sink  teeSink
+	items chan teeItem
+}
+
+// Tees duplicates the pushed streams to the configured tees. Every tee has a
+// bounded queue and its own worker, so a slow tee doesn't slow down pushes:
+// the streams are dropped instead when its queue is full.
+type Tees struct {
+	services.Service
+
+	limits Limits
+	queues map[string]*teeQueue
+	logger log.Logger
+
+	sentEntries    *prometheus.CounterVec
+	droppedEntries *prometheus.CounterVec
+	queueLength    *prometheus.GaugeVec
+}
+
+// NewTees creates the tees described by the given configs.
+func NewTees(cfgs []TeeConfig, limits Limits, logger log.Logger, registerer prometheus.Registerer) (*Tees, error) {
+	t := &Tees{
+		limits: limits,
+		queues: make(map[string]*teeQueue, len(cfgs)),
+		logger: log.With(logger, "component", "tees"),
+		sentEntries: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: constants.Loki,
+			Name:      "distributor_tee_sent_entries_total",
+			Help:      "The total number of entries duplicated to a tee.",
+		}, []string{"tee"}),
+		droppedEntries: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: constants.Loki,
+			Name:      "distributor_tee_dropped_entries_total",
+			Help:      "The total number of entries which couldn't be duplicated to a tee, per reason.",
+		}, []string{"tee", "reason"}),
+		queueLength: promauto.With(registerer).NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: constants.Loki,
+			Name:      "distributor_tee_queue_length",
+			Help:      "The number of pushes waiting to be duplicated to a tee.",
+		}, []string{"tee"}),
+	}
+
+	for _, cfg := range cfgs {
+		if err := cfg.validate(); err != nil {
+			return nil, err
+		}
+		if _, ok := t.queues[cfg.Name]; ok {
+			return nil, fmt.Errorf("duplicate tee %s", cfg.Name)
+		}
+
+		var (
+			sink teeSink
+			err  error
+		)
+		if cfg.Loki.URL.URL != nil {
+			sink = newLokiTeeSink(cfg.Loki)
+		} else {
+			sink, err = newFileTeeSink(cfg.File)
+			if err != nil {
+				return nil, fmt.Errorf("tee %s: %w", cfg.Name, err)
+			}
+		}
+
+		queueSize := cfg.QueueSize
+		if queueSize <= 0 {
+			queueSize = defaultTeeQueueSize
+		}
+		t.queues[cfg.Name] = &teeQueue{name: cfg.Name, sink: sink, items: make(chan teeItem, queueSize)}
+	}
+
+	t.Service = services.NewBasicService(nil, t.running, t.stopping)
+	return t, nil
+}
+
+// Duplicate implements Tee.
+func (t *Tees) Duplicate(tenant string, streams []KeyedStream) {
+	rules := t.limits.TeeRules(tenant)
+	if len(rules) == 0 {
+		return
+	}
+
+	var byTee map[string][]logproto.Stream
+	for _, s := range streams {
+		lbs, err := syntax.ParseLabels(s.Stream.Labels)
+		if err != nil {
+			continue
+		}
+		for i, rule := range rules {
+			if !matchesAll(rule.Matchers, lbs) || matchedBefore(rules[:i], rule.Tee, lbs) {
+				continue
+			}
+			if byTee == nil {
+				byTee = map[string][]logproto.Stream{}
+			}
+			byTee[rule.Tee] = append(byTee[rule.Tee], s.Stream)
+		}
+	}
+
+	for name, streams := range byTee {
+		q, ok := t.queues[name]
+		if !ok {
+			t.droppedEntries.WithLabelValues(name, teeDropReasonUnknownTee).Add(float64(countEntries(streams)))
+			continue
+		}
+		select {
+		case q.items <- teeItem{tenant: tenant, streams: streams}:
+			t.queueLength.WithLabelValues(name).Inc()
+		default:
+			t.droppedEntries.WithLabelValues(name, teeDropReasonQueueFull).Add(float64(countEntries(streams)))
+		}
+	}
+}
+
+func (t *Tees) running(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for _, q := range t.queues {
+		wg.Add(1)
+		go func(q *teeQueue) {
+			defer wg.Done()
+			t.runQueue(ctx, q)
+		}(q)
+	}
+	wg.Wait()
+	return nil
+}
+
+func (t *Tees) runQueue(ctx context.Context, q *teeQueue) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case item := <-q.items:
+			t.queueLength.WithLabelValues(q.name).Dec()
+			entries := float64(countEntries(item.streams))
+			if err := q.sink.Send(ctx, item.tenant, item.streams); err != nil {
+				level.Warn(t.logger).Log("msg", "failed to duplicate streams", "tee", q.name, "tenant", item.tenant, "err", err)
+				t.droppedEntries.WithLabelValues(q.name, teeDropReasonSendFailed).Add(entries)
+				continue
+			}
+			t.sentEntries.WithLabelValues(q.name).Add(entries)
+		}
+	}
+}
+
+func (t *Tees) stopping(_ error) error {
+	var firstErr error
+	for _, q := range t.queues {
+		if err := q.sink.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func matchesAll(matchers []*labels.Matcher, lbs labels.Labels) bool {
+	for _, m := range matchers {
+		if !m.Matches(lbs.Get(m.Name)) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchedBefore returns true if one of the given rules already routed the
+// stream to the tee, so streams are duplicated at most once per tee.
+func matchedBefore(rules []validation.TeeRule, tee string, lbs labels.Labels) bool {
+	for _, rule := range rules {
+		if rule.Tee == tee && matchesAll(rule.Matchers, lbs) {
+			return true
+		}
+	}
+	return false
+}
+
+func countEntries(streams []logproto.Stream) int {
+	n := 0
+	for _, s := range streams {
+		n += len(s.Entries)
+	}
+	return n
+}
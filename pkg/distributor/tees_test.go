@@ -0,0 +1,193 @@
+package distributor
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/golang/snappy"
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/dskit/services"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/validation"
+)
+
+type mockTeeSink struct {
+	mtx     sync.Mutex
+	tenants []string
+	streams [][]logproto.Stream
+}
+
+func (s *mockTeeSink) Send(_ context.Context, tenant string, streams []logproto.Stream) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.tenants = append(s.tenants, tenant)
+	s.streams = append(s.streams, streams)
+	return nil
+}
+
+func (s *mockTeeSink) Close() error { return nil }
+
+func (s *mockTeeSink) received() [][]logproto.Stream {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.streams
+}
+
+func newTestTees(t *testing.T, rules []validation.TeeRule, queueSize int, sinks map[string]teeSink) *Tees {
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.TeeRules = rules
+	require.NoError(t, limits.Validate())
+	overrides, err := validation.NewOverrides(*limits, nil)
+	require.NoError(t, err)
+
+	tees, err := NewTees(nil, overrides, log.NewNopLogger(), prometheus.NewRegistry())
+	require.NoError(t, err)
+	for name, sink := range sinks {
+		tees.queues[name] = &teeQueue{name: name, sink: sink, items: make(chan teeItem, queueSize)}
+	}
+	return tees
+}
+
+func keyedStreams(labels ...string) []KeyedStream {
+	streams := make([]KeyedStream, 0, len(labels))
+	for _, l := range labels {
+		streams = append(streams, KeyedStream{Stream: logproto.Stream{Labels: l, Entries: []logproto.Entry{{Timestamp: time.Unix(1, 0), Line: "line"}}}})
+	}
+	return streams
+}
+
+func TestTees_Duplicate(t *testing.T) {
+	shadow, audit := &mockTeeSink{}, &mockTeeSink{}
+	tees := newTestTees(t, []validation.TeeRule{
+		{Tee: "shadow", Selector: `{env="prod"}`},
+		{Tee: "shadow", Selector: `{app="api"}`},
+		{Tee: "audit", Selector: `{app="api"}`},
+		{Tee: "unknown", Selector: `{app="api"}`},
+	}, 10, map[string]teeSink{"shadow": shadow, "audit": audit})
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), tees))
+	defer services.StopAndAwaitTerminated(context.Background(), tees) //nolint:errcheck
+
+	tees.Duplicate("tenant", keyedStreams(`{env="prod", app="api"}`, `{env="dev", app="api"}`, `{env="dev", app="web"}`))
+
+	require.Eventually(t, func() bool { return len(shadow.received()) == 1 && len(audit.received()) == 1 }, time.Second, 10*time.Millisecond)
+	// Streams matching several rules of the same tee are only duplicated once.
+	require.Len(t, shadow.received()[0], 2)
+	require.Equal(t, `{env="prod", app="api"}`, shadow.received()[0][0].Labels)
+	require.Equal(t, `{env="dev", app="api"}`, shadow.received()[0][1].Labels)
+	require.Len(t, audit.received()[0], 2)
+	require.Equal(t, []string{"tenant"}, shadow.tenants)
+	require.Equal(t, 2.0, testutil.ToFloat64(tees.droppedEntries.WithLabelValues("unknown", teeDropReasonUnknownTee)))
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(tees.sentEntries.WithLabelValues("shadow")) == 2
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestTees_DropsWhenQueueIsFull(t *testing.T) {
+	sink := &mockTeeSink{}
+	tees := newTestTees(t, []validation.TeeRule{{Tee: "slow", Selector: `{app="api"}`}}, 1, map[string]teeSink{"slow": sink})
+
+	// Not running, so nothing is dequeued.
+	tees.Duplicate("tenant", keyedStreams(`{app="api"}`))
+	tees.Duplicate("tenant", keyedStreams(`{app="api"}`))
+	require.Equal(t, 1.0, testutil.ToFloat64(tees.queueLength.WithLabelValues("slow")))
+	require.Equal(t, 1.0, testutil.ToFloat64(tees.droppedEntries.WithLabelValues("slow", teeDropReasonQueueFull)))
+}
+
+func TestLokiTeeSink(t *testing.T) {
+	var (
+		tenant string
+		req    logproto.PushRequest
+		reject bool
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if reject {
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+			return
+		}
+		tenant = r.Header.Get("X-Scope-OrgID")
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		buf, err := snappy.Decode(nil, body)
+		require.NoError(t, err)
+		require.NoError(t, req.Unmarshal(buf))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	var cfg LokiTeeConfig
+	require.NoError(t, cfg.URL.Set(server.URL+"/loki/api/v1/push"))
+	sink := newLokiTeeSink(cfg)
+	defer sink.Close()
+
+	streams := []logproto.Stream{{Labels: `{app="api"}`, Entries: []logproto.Entry{{Timestamp: time.Unix(1, 0).UTC(), Line: "line"}}}}
+	require.NoError(t, sink.Send(context.Background(), "tenant", streams))
+	require.Equal(t, "tenant", tenant)
+	require.Equal(t, streams[0].Labels, req.Streams[0].Labels)
+	require.Equal(t, "line", req.Streams[0].Entries[0].Line)
+
+	// The tenant can be overridden, e.g. when migrating to another cluster.
+	sink.tenantID = "migrated"
+	require.NoError(t, sink.Send(context.Background(), "tenant", streams))
+	require.Equal(t, "migrated", tenant)
+
+	reject = true
+	require.ErrorContains(t, sink.Send(context.Background(), "tenant", streams), "slow down")
+}
+
+func TestFileTeeSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tee", "audit.ndjson")
+	sink, err := newFileTeeSink(FileTeeConfig{Path: path, MaxSize: 200, MaxBackups: 2})
+	require.NoError(t, err)
+
+	stream := logproto.Stream{
+		Labels: `{app="api"}`,
+		Entries: []logproto.Entry{{
+			Timestamp:          time.Unix(1, 0).UTC(),
+			Line:               "line",
+			StructuredMetadata: logproto.FromLabelsToLabelAdapters(nil),
+		}},
+	}
+	for i := 0; i < 10; i++ {
+		require.NoError(t, sink.Send(context.Background(), "tenant", []logproto.Stream{stream}))
+	}
+	require.NoError(t, sink.Close())
+
+	// Every record is ~100 bytes, so the files hold 2 records each and only
+	// the last 2 rotated files are kept.
+	for _, p := range []string{path, path + ".1", path + ".2"} {
+		f, err := os.Open(p)
+		require.NoError(t, err)
+		scanner := bufio.NewScanner(f)
+		n := 0
+		for scanner.Scan() {
+			var rec fileTeeRecord
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &rec))
+			require.Equal(t, "tenant", rec.Tenant)
+			require.Equal(t, `{app="api"}`, rec.Labels)
+			require.Equal(t, "line", rec.Line)
+			n++
+		}
+		require.NoError(t, f.Close())
+		require.NotZero(t, n)
+		fi, err := os.Stat(p)
+		require.NoError(t, err)
+		require.LessOrEqual(t, fi.Size(), int64(200))
+	}
+	_, err = os.Stat(path + ".3")
+	require.True(t, os.IsNotExist(err))
+}
@@ -8,6 +8,8 @@ import (
 
 	"github.com/prometheus/prometheus/model/labels"
 
+	"github.com/grafana/loki/pkg/distributor/droprules"
+	"github.com/grafana/loki/pkg/distributor/relabel"
 	"github.com/grafana/loki/pkg/loghttp/push"
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/validation"
@@ -47,6 +49,14 @@ type validationContext struct {
 	maxStructuredMetadataSize  int
 	maxStructuredMetadataCount int
 
+	relabelRules relabel.Rules
+	dropRules    droprules.Rules
+
+	demoteLabelsThreshold int
+	demoteLabelsWindow    time.Duration
+
+	streamRateLimitPolicies []validation.StreamRateLimitPolicy
+
 	userID string
 }
 
@@ -65,6 +75,11 @@ func (v Validator) getValidationContextForTime(now time.Time, userID string) val
 		allowStructuredMetadata:      v.AllowStructuredMetadata(userID),
 		maxStructuredMetadataSize:    v.MaxStructuredMetadataSize(userID),
 		maxStructuredMetadataCount:   v.MaxStructuredMetadataCount(userID),
+		relabelRules:                 v.StreamRelabelRules(userID),
+		dropRules:                    v.IngestDropRules(userID),
+		demoteLabelsThreshold:        v.DemoteHighCardinalityLabelsThreshold(userID),
+		demoteLabelsWindow:           v.DemoteHighCardinalityLabelsWindow(userID),
+		streamRateLimitPolicies:      v.StreamRateLimitPolicies(userID),
 	}
 }
 
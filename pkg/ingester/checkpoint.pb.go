@@ -5,6 +5,7 @@ package ingester
 
 import (
 	bytes "bytes"
+	context "context"
 	fmt "fmt"
 	_ "github.com/gogo/protobuf/gogoproto"
 	proto "github.com/gogo/protobuf/proto"
@@ -12,6 +13,9 @@ import (
 	github_com_gogo_protobuf_types "github.com/gogo/protobuf/types"
 	_ "github.com/grafana/loki/pkg/logproto"
 	github_com_grafana_loki_pkg_logproto "github.com/grafana/loki/pkg/logproto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
 	io "io"
 	math "math"
 	math_bits "math/bits"
@@ -234,48 +238,127 @@ func (m *Series) GetHighestTs() time.Time {
 	return time.Time{}
 }
 
+// TransferSeriesResponse reports what the receiving ingester got, so the
+// leaving ingester can verify the transfer.
+type TransferSeriesResponse struct {
+	Series int64 `protobuf:"varint,1,opt,name=series,proto3" json:"series,omitempty"`
+	Chunks int64 `protobuf:"varint,2,opt,name=chunks,proto3" json:"chunks,omitempty"`
+	// size of the data and head of the received chunks.
+	Bytes int64 `protobuf:"varint,3,opt,name=bytes,proto3" json:"bytes,omitempty"`
+	// series which were not imported because they exceeded the stream limits
+	// of the receiving ingester.
+	Skipped int64 `protobuf:"varint,4,opt,name=skipped,proto3" json:"skipped,omitempty"`
+}
+
+func (m *TransferSeriesResponse) Reset()      { *m = TransferSeriesResponse{} }
+func (*TransferSeriesResponse) ProtoMessage() {}
+func (*TransferSeriesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00f4b7152db9bdb5, []int{2}
+}
+func (m *TransferSeriesResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *TransferSeriesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_TransferSeriesResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *TransferSeriesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TransferSeriesResponse.Merge(m, src)
+}
+func (m *TransferSeriesResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *TransferSeriesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_TransferSeriesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TransferSeriesResponse proto.InternalMessageInfo
+
+func (m *TransferSeriesResponse) GetSeries() int64 {
+	if m != nil {
+		return m.Series
+	}
+	return 0
+}
+
+func (m *TransferSeriesResponse) GetChunks() int64 {
+	if m != nil {
+		return m.Chunks
+	}
+	return 0
+}
+
+func (m *TransferSeriesResponse) GetBytes() int64 {
+	if m != nil {
+		return m.Bytes
+	}
+	return 0
+}
+
+func (m *TransferSeriesResponse) GetSkipped() int64 {
+	if m != nil {
+		return m.Skipped
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*Chunk)(nil), "loki_ingester.Chunk")
 	proto.RegisterType((*Series)(nil), "loki_ingester.Series")
+	proto.RegisterType((*TransferSeriesResponse)(nil), "loki_ingester.TransferSeriesResponse")
 }
 
 func init() { proto.RegisterFile("pkg/ingester/checkpoint.proto", fileDescriptor_00f4b7152db9bdb5) }
 
 var fileDescriptor_00f4b7152db9bdb5 = []byte{
-	// 517 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x52, 0x31, 0x8f, 0xd3, 0x30,
-	0x14, 0x8e, 0xdb, 0x5c, 0xae, 0x75, 0x61, 0xb1, 0x4e, 0xc8, 0x14, 0xe1, 0x56, 0x37, 0x75, 0x4a,
-	0x44, 0x61, 0x80, 0x05, 0xe9, 0x7a, 0x08, 0x09, 0xa9, 0x03, 0x0a, 0xc7, 0xc2, 0x82, 0xdc, 0xc4,
-	0x75, 0xa2, 0xa6, 0x71, 0x64, 0xbb, 0x43, 0x37, 0x7e, 0xc2, 0x0d, 0xfc, 0x08, 0x7e, 0xca, 0x8d,
-	0x1d, 0x4f, 0x20, 0x1d, 0x34, 0x5d, 0x18, 0xef, 0x27, 0x20, 0x3b, 0x49, 0x29, 0x1b, 0xdd, 0xde,
-	0xf7, 0x9e, 0xbf, 0xf7, 0xc9, 0xdf, 0xfb, 0xe0, 0xd3, 0x62, 0xc1, 0x83, 0x34, 0xe7, 0x4c, 0x69,
-	0x26, 0x83, 0x28, 0x61, 0xd1, 0xa2, 0x10, 0x69, 0xae, 0xfd, 0x42, 0x0a, 0x2d, 0xd0, 0xc3, 0x4c,
-	0x2c, 0xd2, 0xcf, 0xcd, 0xbc, 0x7f, 0xc6, 0x05, 0x17, 0x76, 0x12, 0x98, 0xaa, 0x7a, 0xd4, 0x1f,
-	0x70, 0x21, 0x78, 0xc6, 0x02, 0x8b, 0x66, 0xab, 0x79, 0xa0, 0xd3, 0x25, 0x53, 0x9a, 0x2e, 0x8b,
-	0xfa, 0xc1, 0x13, 0x23, 0x92, 0x09, 0x5e, 0x31, 0x9b, 0xa2, 0x1a, 0x9e, 0xff, 0x68, 0xc1, 0x93,
-	0xcb, 0x64, 0x95, 0x2f, 0xd0, 0x4b, 0xe8, 0xce, 0xa5, 0x58, 0x62, 0x30, 0x04, 0xa3, 0xde, 0xb8,
-	0xef, 0x57, 0x6b, 0xfd, 0x66, 0xad, 0x7f, 0xd5, 0xac, 0x9d, 0x74, 0x6e, 0xee, 0x06, 0xce, 0xf5,
-	0xcf, 0x01, 0x08, 0x2d, 0x03, 0xbd, 0x80, 0x2d, 0x2d, 0x70, 0xeb, 0x08, 0x5e, 0x4b, 0x0b, 0x34,
-	0x81, 0xdd, 0x79, 0xb6, 0x52, 0x09, 0x8b, 0x2f, 0x34, 0x6e, 0x1f, 0x41, 0xfe, 0x4b, 0x43, 0x6f,
-	0x61, 0x2f, 0xa3, 0x4a, 0x7f, 0x2c, 0x62, 0xaa, 0x59, 0x8c, 0xdd, 0x23, 0xb6, 0x1c, 0x12, 0xd1,
-	0x23, 0xe8, 0x45, 0x99, 0x50, 0x2c, 0xc6, 0x27, 0x43, 0x30, 0xea, 0x84, 0x35, 0x32, 0x7d, 0xb5,
-	0xce, 0x23, 0x16, 0x63, 0xaf, 0xea, 0x57, 0x08, 0x21, 0xe8, 0xc6, 0x54, 0x53, 0x7c, 0x3a, 0x04,
-	0xa3, 0x07, 0xa1, 0xad, 0x4d, 0x2f, 0x61, 0x34, 0xc6, 0x9d, 0xaa, 0x67, 0xea, 0xf3, 0xaf, 0x6d,
-	0xe8, 0x7d, 0x60, 0x32, 0x65, 0xca, 0xac, 0x5a, 0x29, 0x26, 0xdf, 0xbd, 0xb1, 0x06, 0x77, 0xc3,
-	0x1a, 0xa1, 0x21, 0xec, 0xcd, 0xcd, 0x85, 0x65, 0x21, 0xd3, 0x5c, 0x5b, 0x17, 0xdd, 0xf0, 0xb0,
-	0x85, 0x32, 0xe8, 0x65, 0x74, 0xc6, 0x32, 0x85, 0xdb, 0xc3, 0xf6, 0xa8, 0x37, 0x7e, 0xec, 0xef,
-	0x6f, 0x38, 0x65, 0x9c, 0x46, 0xeb, 0xa9, 0x99, 0xbe, 0xa7, 0xa9, 0x9c, 0xbc, 0x32, 0xdf, 0xfb,
-	0x7e, 0x37, 0x78, 0xc6, 0x53, 0x9d, 0xac, 0x66, 0x7e, 0x24, 0x96, 0x01, 0x97, 0x74, 0x4e, 0x73,
-	0x1a, 0x98, 0x2c, 0x05, 0x87, 0x51, 0xf0, 0x2d, 0xef, 0x22, 0xa6, 0x85, 0x66, 0x32, 0xac, 0x35,
-	0xd0, 0x18, 0x7a, 0x91, 0xc9, 0x83, 0xc2, 0xae, 0x55, 0x3b, 0xf3, 0xff, 0x09, 0xa1, 0x6f, 0xc3,
-	0x32, 0x71, 0x8d, 0x50, 0x58, 0xbf, 0xac, 0x03, 0x70, 0x72, 0x64, 0x00, 0xfa, 0xb0, 0x63, 0x6e,
-	0x30, 0x4d, 0x73, 0x66, 0xed, 0xed, 0x86, 0x7b, 0x8c, 0x30, 0x3c, 0x65, 0xb9, 0x96, 0xeb, 0x4b,
-	0x6d, 0x3d, 0x6e, 0x87, 0x0d, 0x34, 0xb1, 0x49, 0x52, 0x9e, 0x30, 0xa5, 0xaf, 0x94, 0xf5, 0xfa,
-	0xbf, 0x63, 0xb3, 0xa7, 0x4d, 0x5e, 0x6f, 0xb6, 0xc4, 0xb9, 0xdd, 0x12, 0xe7, 0x7e, 0x4b, 0xc0,
-	0x97, 0x92, 0x80, 0x6f, 0x25, 0x01, 0x37, 0x25, 0x01, 0x9b, 0x92, 0x80, 0x5f, 0x25, 0x01, 0xbf,
-	0x4b, 0xe2, 0xdc, 0x97, 0x04, 0x5c, 0xef, 0x88, 0xb3, 0xd9, 0x11, 0xe7, 0x76, 0x47, 0x9c, 0x4f,
-	0x9d, 0xc6, 0x83, 0x99, 0x67, 0x85, 0x9e, 0xff, 0x09, 0x00, 0x00, 0xff, 0xff, 0xda, 0xcc, 0xb5,
-	0xf6, 0xbf, 0x03, 0x00, 0x00,
+	// 609 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x52, 0xcd, 0x6e, 0x13, 0x3d,
+	0x14, 0x1d, 0x67, 0x92, 0x34, 0x75, 0xbe, 0x8f, 0x85, 0x55, 0xaa, 0x21, 0x08, 0x27, 0x8a, 0x84,
+	0x94, 0xd5, 0x8c, 0x08, 0x2c, 0x60, 0x83, 0xd4, 0x14, 0x21, 0x90, 0xba, 0x40, 0xa6, 0x6c, 0x90,
+	0x10, 0x72, 0x66, 0x3c, 0x3f, 0xca, 0x64, 0x3c, 0xb2, 0x1d, 0x89, 0xec, 0x78, 0x84, 0x2e, 0x78,
+	0x08, 0x1e, 0xa5, 0xcb, 0x2e, 0x2b, 0x90, 0x0a, 0x9d, 0x6e, 0x58, 0xf6, 0x11, 0x90, 0xed, 0x99,
+	0xd2, 0x56, 0x2c, 0xc8, 0xce, 0xe7, 0x5c, 0x9f, 0x7b, 0xed, 0x73, 0x0f, 0x7c, 0x50, 0x2e, 0x92,
+	0x20, 0x2b, 0x12, 0x26, 0x15, 0x13, 0x41, 0x98, 0xb2, 0x70, 0x51, 0xf2, 0xac, 0x50, 0x7e, 0x29,
+	0xb8, 0xe2, 0xe8, 0xff, 0x9c, 0x2f, 0xb2, 0x8f, 0x4d, 0x7d, 0xb0, 0x93, 0xf0, 0x84, 0x9b, 0x4a,
+	0xa0, 0x4f, 0xf6, 0xd2, 0x60, 0x98, 0x70, 0x9e, 0xe4, 0x2c, 0x30, 0x68, 0xbe, 0x8a, 0x03, 0x95,
+	0x2d, 0x99, 0x54, 0x74, 0x59, 0xd6, 0x17, 0xee, 0xeb, 0x21, 0x39, 0x4f, 0xac, 0xb2, 0x39, 0xd8,
+	0xe2, 0xf8, 0x7b, 0x0b, 0x76, 0xf6, 0xd3, 0x55, 0xb1, 0x40, 0x4f, 0x61, 0x3b, 0x16, 0x7c, 0xe9,
+	0x81, 0x11, 0x98, 0xf4, 0xa7, 0x03, 0xdf, 0xb6, 0xf5, 0x9b, 0xb6, 0xfe, 0x61, 0xd3, 0x76, 0xd6,
+	0x3b, 0x3e, 0x1b, 0x3a, 0x47, 0x3f, 0x86, 0x80, 0x18, 0x05, 0x7a, 0x02, 0x5b, 0x8a, 0x7b, 0xad,
+	0x0d, 0x74, 0x2d, 0xc5, 0xd1, 0x0c, 0x6e, 0xc7, 0xf9, 0x4a, 0xa6, 0x2c, 0xda, 0x53, 0x9e, 0xbb,
+	0x81, 0xf8, 0x8f, 0x0c, 0xbd, 0x84, 0xfd, 0x9c, 0x4a, 0xf5, 0xae, 0x8c, 0xa8, 0x62, 0x91, 0xd7,
+	0xde, 0xa0, 0xcb, 0x75, 0x21, 0xda, 0x85, 0xdd, 0x30, 0xe7, 0x92, 0x45, 0x5e, 0x67, 0x04, 0x26,
+	0x3d, 0x52, 0x23, 0xcd, 0xcb, 0x75, 0x11, 0xb2, 0xc8, 0xeb, 0x5a, 0xde, 0x22, 0x84, 0x60, 0x3b,
+	0xa2, 0x8a, 0x7a, 0x5b, 0x23, 0x30, 0xf9, 0x8f, 0x98, 0xb3, 0xe6, 0x52, 0x46, 0x23, 0xaf, 0x67,
+	0x39, 0x7d, 0x1e, 0x7f, 0x71, 0x61, 0xf7, 0x2d, 0x13, 0x19, 0x93, 0xba, 0xd5, 0x4a, 0x32, 0xf1,
+	0xfa, 0x85, 0x31, 0x78, 0x9b, 0xd4, 0x08, 0x8d, 0x60, 0x3f, 0xd6, 0x1b, 0x16, 0xa5, 0xc8, 0x0a,
+	0x65, 0x5c, 0x6c, 0x93, 0xeb, 0x14, 0xca, 0x61, 0x37, 0xa7, 0x73, 0x96, 0x4b, 0xcf, 0x1d, 0xb9,
+	0x93, 0xfe, 0xf4, 0x9e, 0x7f, 0xb5, 0xc3, 0x03, 0x96, 0xd0, 0x70, 0x7d, 0xa0, 0xab, 0x6f, 0x68,
+	0x26, 0x66, 0xcf, 0xf4, 0xf7, 0xbe, 0x9d, 0x0d, 0x1f, 0x25, 0x99, 0x4a, 0x57, 0x73, 0x3f, 0xe4,
+	0xcb, 0x20, 0x11, 0x34, 0xa6, 0x05, 0x0d, 0x74, 0x96, 0x82, 0xeb, 0x51, 0xf0, 0x8d, 0x6e, 0x2f,
+	0xa2, 0xa5, 0x62, 0x82, 0xd4, 0x33, 0xd0, 0x14, 0x76, 0x43, 0x9d, 0x07, 0xe9, 0xb5, 0xcd, 0xb4,
+	0x1d, 0xff, 0x46, 0x08, 0x7d, 0x13, 0x96, 0x59, 0x5b, 0x0f, 0x22, 0xf5, 0xcd, 0x3a, 0x00, 0x9d,
+	0x0d, 0x03, 0x30, 0x80, 0x3d, 0xbd, 0x83, 0x83, 0xac, 0x60, 0xc6, 0xde, 0x6d, 0x72, 0x85, 0x91,
+	0x07, 0xb7, 0x58, 0xa1, 0xc4, 0x7a, 0x5f, 0x19, 0x8f, 0x5d, 0xd2, 0x40, 0x1d, 0x9b, 0x34, 0x4b,
+	0x52, 0x26, 0xd5, 0xa1, 0x34, 0x5e, 0xff, 0x73, 0x6c, 0xae, 0x64, 0xe3, 0x4f, 0x70, 0xf7, 0x50,
+	0xd0, 0x42, 0xc6, 0x4c, 0xd8, 0xed, 0x10, 0x26, 0x4b, 0x5e, 0x48, 0x66, 0x16, 0x6e, 0x18, 0xb3,
+	0x25, 0x97, 0xd4, 0xc8, 0x04, 0xc4, 0xba, 0xd2, 0xb2, 0x7c, 0xfd, 0xf3, 0x1d, 0xd8, 0x99, 0xaf,
+	0x15, 0x93, 0x26, 0xc0, 0x2e, 0xb1, 0x40, 0xbf, 0x5e, 0x2e, 0xb2, 0xb2, 0xac, 0x23, 0xe9, 0x92,
+	0x06, 0x4e, 0x3f, 0xc0, 0xad, 0x57, 0xb4, 0x88, 0x78, 0x1c, 0x23, 0x02, 0xef, 0xdc, 0x7c, 0x04,
+	0xba, 0x7b, 0xcb, 0x6a, 0x4b, 0x0f, 0x1e, 0xde, 0xa2, 0xff, 0xfe, 0xf4, 0xb1, 0x33, 0x01, 0xb3,
+	0xe7, 0x27, 0xe7, 0xd8, 0x39, 0x3d, 0xc7, 0xce, 0xe5, 0x39, 0x06, 0x9f, 0x2b, 0x0c, 0xbe, 0x56,
+	0x18, 0x1c, 0x57, 0x18, 0x9c, 0x54, 0x18, 0xfc, 0xac, 0x30, 0xf8, 0x55, 0x61, 0xe7, 0xb2, 0xc2,
+	0xe0, 0xe8, 0x02, 0x3b, 0x27, 0x17, 0xd8, 0x39, 0xbd, 0xc0, 0xce, 0xfb, 0x5e, 0xd3, 0x7a, 0xde,
+	0x35, 0x0e, 0x3e, 0xfe, 0x1d, 0x00, 0x00, 0xff, 0xff, 0x05, 0xe3, 0x0d, 0xd7, 0x98, 0x04, 0x00,
+	0x00,
 }
 
 func (this *Chunk) Equal(that interface{}) bool {
@@ -378,6 +461,39 @@ func (this *Series) Equal(that interface{}) bool {
 	}
 	return true
 }
+func (this *TransferSeriesResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*TransferSeriesResponse)
+	if !ok {
+		that2, ok := that.(TransferSeriesResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Series != that1.Series {
+		return false
+	}
+	if this.Chunks != that1.Chunks {
+		return false
+	}
+	if this.Bytes != that1.Bytes {
+		return false
+	}
+	if this.Skipped != that1.Skipped {
+		return false
+	}
+	return true
+}
 func (this *Chunk) GoString() string {
 	if this == nil {
 		return "nil"
@@ -418,6 +534,19 @@ func (this *Series) GoString() string {
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
+func (this *TransferSeriesResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 8)
+	s = append(s, "&ingester.TransferSeriesResponse{")
+	s = append(s, "Series: "+fmt.Sprintf("%#v", this.Series)+",\n")
+	s = append(s, "Chunks: "+fmt.Sprintf("%#v", this.Chunks)+",\n")
+	s = append(s, "Bytes: "+fmt.Sprintf("%#v", this.Bytes)+",\n")
+	s = append(s, "Skipped: "+fmt.Sprintf("%#v", this.Skipped)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
 func valueToGoStringCheckpoint(v interface{}, typ string) string {
 	rv := reflect.ValueOf(v)
 	if rv.IsNil() {
@@ -426,6 +555,121 @@ func valueToGoStringCheckpoint(v interface{}, typ string) string {
 	pv := reflect.Indirect(rv).Interface()
 	return fmt.Sprintf("func(v %v) *%v { return &v } ( %#v )", typ, typ, pv)
 }
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// HandoffClient is the client API for Handoff service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type HandoffClient interface {
+	TransferSeries(ctx context.Context, opts ...grpc.CallOption) (Handoff_TransferSeriesClient, error)
+}
+
+type handoffClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewHandoffClient(cc *grpc.ClientConn) HandoffClient {
+	return &handoffClient{cc}
+}
+
+func (c *handoffClient) TransferSeries(ctx context.Context, opts ...grpc.CallOption) (Handoff_TransferSeriesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Handoff_serviceDesc.Streams[0], "/loki_ingester.Handoff/TransferSeries", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &handoffTransferSeriesClient{stream}
+	return x, nil
+}
+
+type Handoff_TransferSeriesClient interface {
+	Send(*Series) error
+	CloseAndRecv() (*TransferSeriesResponse, error)
+	grpc.ClientStream
+}
+
+type handoffTransferSeriesClient struct {
+	grpc.ClientStream
+}
+
+func (x *handoffTransferSeriesClient) Send(m *Series) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *handoffTransferSeriesClient) CloseAndRecv() (*TransferSeriesResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(TransferSeriesResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// HandoffServer is the server API for Handoff service.
+type HandoffServer interface {
+	TransferSeries(Handoff_TransferSeriesServer) error
+}
+
+// UnimplementedHandoffServer can be embedded to have forward compatible implementations.
+type UnimplementedHandoffServer struct {
+}
+
+func (*UnimplementedHandoffServer) TransferSeries(srv Handoff_TransferSeriesServer) error {
+	return status.Errorf(codes.Unimplemented, "method TransferSeries not implemented")
+}
+
+func RegisterHandoffServer(s *grpc.Server, srv HandoffServer) {
+	s.RegisterService(&_Handoff_serviceDesc, srv)
+}
+
+func _Handoff_TransferSeries_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(HandoffServer).TransferSeries(&handoffTransferSeriesServer{stream})
+}
+
+type Handoff_TransferSeriesServer interface {
+	SendAndClose(*TransferSeriesResponse) error
+	Recv() (*Series, error)
+	grpc.ServerStream
+}
+
+type handoffTransferSeriesServer struct {
+	grpc.ServerStream
+}
+
+func (x *handoffTransferSeriesServer) SendAndClose(m *TransferSeriesResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *handoffTransferSeriesServer) Recv() (*Series, error) {
+	m := new(Series)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+var _Handoff_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "loki_ingester.Handoff",
+	HandlerType: (*HandoffServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "TransferSeries",
+			Handler:       _Handoff_TransferSeries_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "pkg/ingester/checkpoint.proto",
+}
+
 func (m *Chunk) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -606,6 +850,49 @@ func (m *Series) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *TransferSeriesResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *TransferSeriesResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *TransferSeriesResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Skipped != 0 {
+		i = encodeVarintCheckpoint(dAtA, i, uint64(m.Skipped))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.Bytes != 0 {
+		i = encodeVarintCheckpoint(dAtA, i, uint64(m.Bytes))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.Chunks != 0 {
+		i = encodeVarintCheckpoint(dAtA, i, uint64(m.Chunks))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.Series != 0 {
+		i = encodeVarintCheckpoint(dAtA, i, uint64(m.Series))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
 func encodeVarintCheckpoint(dAtA []byte, offset int, v uint64) int {
 	offset -= sovCheckpoint(v)
 	base := offset
@@ -687,6 +974,27 @@ func (m *Series) Size() (n int) {
 	return n
 }
 
+func (m *TransferSeriesResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Series != 0 {
+		n += 1 + sovCheckpoint(uint64(m.Series))
+	}
+	if m.Chunks != 0 {
+		n += 1 + sovCheckpoint(uint64(m.Chunks))
+	}
+	if m.Bytes != 0 {
+		n += 1 + sovCheckpoint(uint64(m.Bytes))
+	}
+	if m.Skipped != 0 {
+		n += 1 + sovCheckpoint(uint64(m.Skipped))
+	}
+	return n
+}
+
 func sovCheckpoint(x uint64) (n int) {
 	return (math_bits.Len64(x|1) + 6) / 7
 }
@@ -732,6 +1040,19 @@ func (this *Series) String() string {
 	}, "")
 	return s
 }
+func (this *TransferSeriesResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&TransferSeriesResponse{`,
+		`Series:` + fmt.Sprintf("%v", this.Series) + `,`,
+		`Chunks:` + fmt.Sprintf("%v", this.Chunks) + `,`,
+		`Bytes:` + fmt.Sprintf("%v", this.Bytes) + `,`,
+		`Skipped:` + fmt.Sprintf("%v", this.Skipped) + `,`,
+		`}`,
+	}, "")
+	return s
+}
 func valueToStringCheckpoint(v interface{}) string {
 	rv := reflect.ValueOf(v)
 	if rv.IsNil() {
@@ -1322,6 +1643,135 @@ func (m *Series) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *TransferSeriesResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowCheckpoint
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: TransferSeriesResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: TransferSeriesResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Series", wireType)
+			}
+			m.Series = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCheckpoint
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Series |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Chunks", wireType)
+			}
+			m.Chunks = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCheckpoint
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Chunks |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Bytes", wireType)
+			}
+			m.Bytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCheckpoint
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Bytes |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Skipped", wireType)
+			}
+			m.Skipped = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCheckpoint
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Skipped |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipCheckpoint(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthCheckpoint
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthCheckpoint
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipCheckpoint(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
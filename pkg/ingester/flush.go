@@ -8,7 +8,6 @@ import (
 	"time"
 
 	"github.com/go-kit/log/level"
-	"github.com/grafana/dskit/ring"
 	"github.com/grafana/dskit/user"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
@@ -31,11 +30,12 @@ const (
 	nameLabel = "__name__"
 	logsValue = "logs"
 
-	flushReasonIdle   = "idle"
-	flushReasonMaxAge = "max_age"
-	flushReasonForced = "forced"
-	flushReasonFull   = "full"
-	flushReasonSynced = "synced"
+	flushReasonIdle    = "idle"
+	flushReasonMaxAge  = "max_age"
+	flushReasonForced  = "forced"
+	flushReasonFull    = "full"
+	flushReasonSynced  = "synced"
+	flushReasonHandoff = "handoff"
 )
 
 // Note: this is called both during the WAL replay (zero or more times)
@@ -55,13 +55,6 @@ func (i *Ingester) Flush() {
 	i.flush(true)
 }
 
-// TransferOut implements ring.FlushTransferer
-// Noop implemenetation because ingesters have a WAL now that does not require transferring chunks any more.
-// We return ErrTransferDisabled to indicate that we don't support transfers, and therefore we may flush on shutdown if configured to do so.
-func (i *Ingester) TransferOut(_ context.Context) error {
-	return ring.ErrTransferDisabled
-}
-
 func (i *Ingester) flush(mayRemoveStreams bool) {
 	i.sweepUsers(true, mayRemoveStreams)
 
@@ -109,6 +102,9 @@ func (i *Ingester) sweepInstance(instance *instance, immediate, mayRemoveStreams
 	_ = instance.streams.ForEach(func(s *stream) (bool, error) {
 		i.sweepStream(instance, s, immediate)
 		i.removeFlushedChunks(instance, s, mayRemoveStreams)
+		if i.spiller != nil {
+			i.spillStream(s)
+		}
 		return true, nil
 	})
 }
@@ -226,6 +222,10 @@ func (i *Ingester) shouldFlushChunk(chunk *chunkDesc) (bool, string) {
 		if chunk.synced {
 			return true, flushReasonSynced
 		}
+		// The reason the chunk was cut for, or flushed for before a failure.
+		if chunk.reason != "" {
+			return true, chunk.reason
+		}
 		return true, flushReasonFull
 	}
 
@@ -253,6 +253,10 @@ func (i *Ingester) removeFlushedChunks(instance *instance, stream *stream, mayRe
 		}
 
 		subtracted += stream.chunks[0].chunk.UncompressedSize()
+		if seg := stream.chunks[0].spilled; seg != nil {
+			seg.releaseChunk(stream.chunks[0].chunk.CompressedSize())
+			stream.chunks[0].spilled = nil
+		}
 		stream.chunks[0].chunk = nil // erase reference so the chunk can be garbage-collected
 		stream.chunks = stream.chunks[1:]
 	}
@@ -296,20 +300,21 @@ func (i *Ingester) flushChunks(ctx context.Context, fp model.Fingerprint, labelP
 	countPerTenant := i.metrics.chunksPerTenant.WithLabelValues(userID)
 
 	for j, c := range cs {
-		if err := i.closeChunk(c, chunkMtx); err != nil {
+		mc, err := i.closeChunk(c, chunkMtx)
+		if err != nil {
 			return fmt.Errorf("chunk close for flushing: %w", err)
 		}
 
-		firstTime, lastTime := util.RoundToMilliseconds(c.chunk.Bounds())
+		firstTime, lastTime := util.RoundToMilliseconds(mc.Bounds())
 		ch := chunk.NewChunk(
 			userID, fp, metric,
-			chunkenc.NewFacade(c.chunk, i.cfg.BlockSize, i.cfg.TargetChunkSize),
+			chunkenc.NewFacade(mc, i.cfg.BlockSize, i.cfg.TargetChunkSize),
 			firstTime,
 			lastTime,
 		)
 
 		// encodeChunk mutates the chunk so we must pass by reference
-		if err := i.encodeChunk(ctx, &ch, c); err != nil {
+		if err := i.encodeChunk(ctx, &ch, mc); err != nil {
 			return err
 		}
 
@@ -324,7 +329,7 @@ func (i *Ingester) flushChunks(ctx context.Context, fp model.Fingerprint, labelP
 			return c.reason
 		}()
 
-		i.reportFlushedChunkStatistics(&ch, c, sizePerTenant, countPerTenant, reason)
+		i.reportFlushedChunkStatistics(&ch, mc, sizePerTenant, countPerTenant, reason)
 		i.markChunkAsFlushed(cs[j], chunkMtx)
 	}
 
@@ -339,25 +344,26 @@ func (i *Ingester) markChunkAsFlushed(desc *chunkDesc, chunkMtx sync.Locker) {
 }
 
 // closeChunk closes the given chunk while locking it to ensure that new blocks are cut before flushing.
+// It returns the chunk to flush, since the chunk of a chunkDesc is replaced when it is spilled to disk.
 //
 // If the chunk isn't closed, data in the head block isn't included.
-func (i *Ingester) closeChunk(desc *chunkDesc, chunkMtx sync.Locker) error {
+func (i *Ingester) closeChunk(desc *chunkDesc, chunkMtx sync.Locker) (*chunkenc.MemChunk, error) {
 	chunkMtx.Lock()
 	defer chunkMtx.Unlock()
 
-	return desc.chunk.Close()
+	return desc.chunk, desc.chunk.Close()
 }
 
-// encodeChunk encodes a chunk.Chunk based on the given chunkDesc.
+// encodeChunk encodes a chunk.Chunk based on the given chunk.
 //
 // If the encoding is unsuccessful the flush operation is reinserted in the queue which will cause
 // the encoding for a given chunk to be evaluated again.
-func (i *Ingester) encodeChunk(ctx context.Context, ch *chunk.Chunk, desc *chunkDesc) error {
+func (i *Ingester) encodeChunk(ctx context.Context, ch *chunk.Chunk, mc *chunkenc.MemChunk) error {
 	if err := ctx.Err(); err != nil {
 		return err
 	}
 	start := time.Now()
-	chunkBytesSize := desc.chunk.BytesSize() + 4*1024 // size + 4kB should be enough room for cortex header
+	chunkBytesSize := mc.BytesSize() + 4*1024 // size + 4kB should be enough room for cortex header
 	if err := ch.EncodeTo(bytes.NewBuffer(make([]byte, 0, chunkBytesSize))); err != nil {
 		return fmt.Errorf("chunk encoding: %w", err)
 	}
@@ -379,7 +385,7 @@ func (i *Ingester) flushChunk(ctx context.Context, ch *chunk.Chunk) error {
 }
 
 // reportFlushedChunkStatistics calculate overall statistics of flushed chunks without compromising the flush process.
-func (i *Ingester) reportFlushedChunkStatistics(ch *chunk.Chunk, desc *chunkDesc, sizePerTenant prometheus.Counter, countPerTenant prometheus.Counter, reason string) {
+func (i *Ingester) reportFlushedChunkStatistics(ch *chunk.Chunk, mc *chunkenc.MemChunk, sizePerTenant prometheus.Counter, countPerTenant prometheus.Counter, reason string) {
 	byt, err := ch.Encoded()
 	if err != nil {
 		level.Error(i.logger).Log("msg", "failed to encode flushed wire chunk", "err", err)
@@ -397,13 +403,13 @@ func (i *Ingester) reportFlushedChunkStatistics(ch *chunk.Chunk, desc *chunkDesc
 
 	utilization := ch.Data.Utilization()
 	i.metrics.chunkUtilization.Observe(utilization)
-	numEntries := desc.chunk.Size()
+	numEntries := mc.Size()
 	i.metrics.chunkEntries.Observe(float64(numEntries))
 	i.metrics.chunkSize.Observe(compressedSize)
 	sizePerTenant.Add(compressedSize)
 	countPerTenant.Inc()
 
-	boundsFrom, boundsTo := desc.chunk.Bounds()
+	boundsFrom, boundsTo := mc.Bounds()
 	i.metrics.chunkAge.Observe(time.Since(boundsFrom).Seconds())
 	i.metrics.chunkLifespan.Observe(boundsTo.Sub(boundsFrom).Hours())
 
@@ -0,0 +1,394 @@
+package ingester
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/httpgrpc"
+	"github.com/grafana/dskit/ring"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	tsdb_record "github.com/prometheus/prometheus/tsdb/record"
+
+	"github.com/grafana/loki/pkg/chunkenc"
+	"github.com/grafana/loki/pkg/ingester/client"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql/log"
+	util_log "github.com/grafana/loki/pkg/util/log"
+	lokiring "github.com/grafana/loki/pkg/util/ring"
+	"github.com/grafana/loki/pkg/validation"
+)
+
+// HandoffConfig configures the transfer of the in-memory streams of an
+// ingester leaving the ring to the ingesters owning them afterwards.
+type HandoffConfig struct {
+	Enabled bool          `yaml:"enabled"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// RegisterFlags adds the flags required to config this to the given FlagSet
+func (cfg *HandoffConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "ingester.handoff.enabled", false, "When the ingester is shut down with flushing enabled, for instance after a call to /ingester/prepare_shutdown or /ingester/shutdown, transfer its in-memory streams to the ingesters owning them once it left the ring instead of flushing them to storage. The ingesters which already owned the streams along with it are skipped, as they were pushed the streams too. The streams are flushed if the transfer fails.")
+	f.DurationVar(&cfg.Timeout, "ingester.handoff.timeout", time.Minute, "Maximum duration of the transfer of the streams. The streams are flushed when it is exceeded.")
+}
+
+func (cfg *HandoffConfig) Validate() error {
+	if cfg.Enabled && cfg.Timeout <= 0 {
+		return errors.New("ingester.handoff.timeout must be greater than 0")
+	}
+	return nil
+}
+
+// TransferOut implements ring.FlushTransferer. When handoff is enabled and
+// the ingester would flush on shutdown, it transfers the in-memory streams to
+// the ingesters owning them now that this ingester is leaving the ring. The
+// lifecycler skips the flush when the transfer succeeds, and flushes the
+// streams otherwise.
+func (i *Ingester) TransferOut(ctx context.Context) error {
+	if i.handoffRing == nil || !i.lifecycler.FlushOnShutdown() {
+		return ring.ErrTransferDisabled
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, i.cfg.Handoff.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	if err := i.transferOut(ctx); err != nil {
+		return err
+	}
+	level.Info(i.logger).Log("msg", "transferred streams to other ingesters", "elapsed", time.Since(start))
+	return nil
+}
+
+// handoffTarget is the transfer of streams to one ingester.
+type handoffTarget struct {
+	addr   string
+	conn   io.Closer
+	stream Handoff_TransferSeriesClient
+
+	series, chunks, bytes int64
+}
+
+func (t *handoffTarget) send(series *Series) error {
+	if err := t.stream.Send(series); err != nil {
+		return fmt.Errorf("sending stream to %s: %w", t.addr, err)
+	}
+	t.series++
+	t.chunks += int64(len(series.Chunks))
+	t.bytes += seriesBytes(series)
+	return nil
+}
+
+// verify waits for the ingester to receive all the streams, and checks it
+// received everything that was sent.
+func (t *handoffTarget) verify() (*TransferSeriesResponse, error) {
+	resp, err := t.stream.CloseAndRecv()
+	if err != nil {
+		return nil, fmt.Errorf("transferring streams to %s: %w", t.addr, err)
+	}
+	if resp.Series != t.series || resp.Chunks != t.chunks || resp.Bytes != t.bytes {
+		return nil, fmt.Errorf("transfer to %s not verified: sent %d series, %d chunks and %d bytes but %d series, %d chunks and %d bytes were received",
+			t.addr, t.series, t.chunks, t.bytes, resp.Series, resp.Chunks, resp.Bytes)
+	}
+	return resp, nil
+}
+
+func (i *Ingester) transferOut(ctx context.Context) error {
+	if err := i.waitLeaving(ctx); err != nil {
+		return err
+	}
+
+	targets := map[string]*handoffTarget{}
+	defer func() {
+		for _, t := range targets {
+			_ = t.conn.Close()
+		}
+	}()
+
+	var bufDescs, bufOwners [5]ring.InstanceDesc
+	it := newStreamsIterator(i)
+	for it.Next() {
+		series := it.Stream()
+		lbs := logproto.FromLabelAdaptersToLabels(series.Labels).String()
+		token := lokiring.TokenFor(series.UserID, lbs)
+
+		// The new owners of a stream are the ingesters the distributors now
+		// push it to. The ones which owned it along with this ingester were
+		// pushed the stream too, so it is only sent to the others.
+		rs, err := i.handoffRing.Get(token, ring.Write, bufDescs[:0], nil, nil)
+		if err != nil {
+			return fmt.Errorf("finding the ingesters owning stream %s of tenant %s: %w", lbs, series.UserID, err)
+		}
+		owners, err := i.handoffRing.Get(token, ring.Reporting, bufOwners[:0], nil, nil)
+		if err != nil {
+			return fmt.Errorf("finding the ingesters owning stream %s of tenant %s: %w", lbs, series.UserID, err)
+		}
+		if !owners.Includes(i.lifecycler.Addr) {
+			// The stream was pushed to this ingester before the ring changed,
+			// so none of its owners may have it.
+			owners = ring.ReplicationSet{}
+		}
+
+		var sent, replicated bool
+		for _, inst := range rs.Instances {
+			if inst.Id == i.lifecycler.ID {
+				continue
+			}
+			if owners.Includes(inst.Addr) {
+				replicated = true
+				continue
+			}
+			t, ok := targets[inst.Addr]
+			if !ok {
+				if t, err = i.newHandoffTarget(ctx, inst.Addr); err != nil {
+					return err
+				}
+				targets[inst.Addr] = t
+			}
+			if err := t.send(series); err != nil {
+				return err
+			}
+			sent = true
+		}
+		if !sent {
+			if replicated {
+				continue
+			}
+			return fmt.Errorf("no ingester to transfer stream %s of tenant %s to", lbs, series.UserID)
+		}
+		i.metrics.handoffSentSeries.Inc()
+		i.metrics.handoffSentChunks.Add(float64(len(series.Chunks)))
+	}
+	if err := it.Error(); err != nil {
+		return fmt.Errorf("iterating over the streams to transfer: %w", err)
+	}
+
+	for _, t := range targets {
+		resp, err := t.verify()
+		if err != nil {
+			return err
+		}
+		level.Info(i.logger).Log("msg", "transferred streams", "to", t.addr, "series", resp.Series, "chunks", resp.Chunks, "bytes", resp.Bytes, "skipped", resp.Skipped)
+		if resp.Skipped > 0 {
+			// The streams must be flushed not to lose the skipped ones.
+			return fmt.Errorf("%d streams were not imported by %s because of its stream limits", resp.Skipped, t.addr)
+		}
+	}
+	return nil
+}
+
+// waitLeaving waits for the ring to show this ingester as leaving, so the
+// ring doesn't return it as an owner of its own streams anymore.
+func (i *Ingester) waitLeaving(ctx context.Context) error {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		if state, err := i.handoffRing.GetInstanceState(i.lifecycler.ID); err == nil && state == ring.LEAVING {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for the ring to show the ingester as leaving: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+func (i *Ingester) newHandoffTarget(ctx context.Context, addr string) (*handoffTarget, error) {
+	// The streams of all the tenants are transferred by a single call.
+	cfg := i.clientConfig
+	cfg.Internal = true
+	conn, err := client.Dial(cfg, addr)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to %s: %w", addr, err)
+	}
+	stream, err := NewHandoffClient(conn).TransferSeries(ctx)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("transferring streams to %s: %w", addr, err)
+	}
+	return &handoffTarget{addr: addr, conn: conn, stream: stream}, nil
+}
+
+// TransferSeries implements HandoffServer. It imports the streams of a
+// leaving ingester, merging them into the streams this ingester already has.
+func (i *Ingester) TransferSeries(stream Handoff_TransferSeriesServer) error {
+	if state := i.lifecycler.GetState(); state != ring.ACTIVE {
+		return fmt.Errorf("streams can only be transferred to an active ingester, this ingester is %s", state)
+	}
+
+	var resp TransferSeriesResponse
+	for {
+		series, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(&resp)
+		}
+		if err != nil {
+			return err
+		}
+
+		inst, err := i.GetOrCreateInstance(series.UserID)
+		if err != nil {
+			return err
+		}
+		outcome, err := inst.importSeries(stream.Context(), series)
+		if err != nil {
+			return fmt.Errorf("importing stream of tenant %s: %w", series.UserID, err)
+		}
+
+		resp.Series++
+		resp.Chunks += int64(len(series.Chunks))
+		resp.Bytes += seriesBytes(series)
+		i.metrics.handoffReceivedSeries.WithLabelValues(outcome).Inc()
+		if outcome == seriesSkipped {
+			resp.Skipped++
+			continue
+		}
+		i.metrics.memoryChunks.Add(float64(len(series.Chunks)))
+	}
+}
+
+// Outcomes of the import of a transferred series.
+const (
+	seriesImported = "imported"
+	seriesMerged   = "merged"
+	seriesSkipped  = "skipped"
+)
+
+// importSeries adds a series transferred by a leaving ingester to the
+// instance. The series becomes a new stream, or its chunks are merged into the
+// existing stream, which is the one the distributors push to. It is skipped
+// when creating the stream would exceed the stream limits of the tenant.
+func (i *instance) importSeries(ctx context.Context, series *Series) (string, error) {
+	_, headfmt, err := i.chunkFormatAt(model.Now())
+	if err != nil {
+		return "", err
+	}
+	descs, err := fromWireChunks(i.cfg, headfmt, series.Chunks)
+	if err != nil {
+		return "", err
+	}
+
+	record := recordPool.GetRecord()
+	record.UserID = i.instanceID
+	defer recordPool.PutRecord(record)
+
+	ls := logproto.FromLabelAdaptersToLabels(series.Labels)
+	s, loaded, err := i.streams.LoadOrStoreNew(ls.String(),
+		func() (*stream, error) {
+			if err := i.limiter.AssertMaxStreamsPerUser(i.instanceID, i.streams.Len()); err != nil {
+				if i.configs.LogStreamCreation(i.instanceID) {
+					level.Debug(util_log.Logger).Log(
+						"msg", "failed to import stream, exceeded limit",
+						"org_id", i.instanceID,
+						"err", err,
+						"stream", ls.String(),
+					)
+				}
+				return nil, httpgrpc.Errorf(http.StatusTooManyRequests, validation.StreamLimitErrorMsg, i.instanceID)
+			}
+
+			fp := i.getHashForLabels(ls)
+			s, err := i.createStreamByFP(ls, fp)
+			if err != nil {
+				return nil, err
+			}
+			s.chunkMtx.Lock() // Lock before return, because we have defer that unlocks it.
+
+			record.Series = append(record.Series, tsdb_record.RefSeries{
+				Ref:    chunks.HeadSeriesRef(fp),
+				Labels: s.labels,
+			})
+			return s, nil
+		},
+		func(s *stream) error {
+			s.chunkMtx.Lock()
+			return nil
+		},
+	)
+	if err != nil {
+		if resp, ok := httpgrpc.HTTPResponseFromError(err); ok && resp.Code == http.StatusTooManyRequests {
+			return seriesSkipped, nil
+		}
+		return "", err
+	}
+	defer s.chunkMtx.Unlock()
+
+	outcome := seriesImported
+	if loaded {
+		outcome = seriesMerged
+		// No more entries are appended to the transferred chunks, the
+		// distributors push to the head chunk of the existing stream.
+		for j := range descs {
+			if !descs[j].closed {
+				descs[j].closed = true
+				descs[j].reason = flushReasonHandoff
+			}
+		}
+		s.chunks = append(descs, s.chunks...)
+		if len(s.chunks) == len(descs) {
+			s.lastLine.ts = series.To
+			s.lastLine.content = series.LastLine
+		}
+		if series.HighestTs.After(s.highestTs) {
+			s.highestTs = series.HighestTs
+		}
+	} else {
+		s.chunks = descs
+		s.lastLine.ts = series.To
+		s.lastLine.content = series.LastLine
+		s.highestTs = series.HighestTs
+	}
+
+	// Log the entries of the chunks which aren't flushed yet to the WAL, so
+	// they are replayed if this ingester restarts before the next checkpoint.
+	// The replay of the entries merged into an existing stream follows the
+	// ordering rules of the stream.
+	for _, d := range descs {
+		if !d.flushed.IsZero() {
+			continue
+		}
+		entries, err := chunkEntries(ctx, d.chunk, s.labels)
+		if err != nil {
+			return "", err
+		}
+		if len(entries) == 0 {
+			continue
+		}
+		s.entryCt += int64(len(entries))
+		record.AddEntries(uint64(s.fp), s.entryCt, entries...)
+	}
+	return outcome, i.wal.Log(record)
+}
+
+// chunkEntries returns the entries of an in-memory chunk.
+func chunkEntries(ctx context.Context, c *chunkenc.MemChunk, ls labels.Labels) ([]logproto.Entry, error) {
+	from, through := c.Bounds()
+	it, err := c.Iterator(ctx, from, through.Add(time.Nanosecond), logproto.FORWARD, log.NewNoopPipeline().ForStream(ls))
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	var entries []logproto.Entry
+	for it.Next() {
+		entries = append(entries, it.Entry())
+	}
+	return entries, it.Error()
+}
+
+// seriesBytes returns the size of the data and head of the chunks of a series.
+func seriesBytes(series *Series) int64 {
+	var n int64
+	for _, c := range series.Chunks {
+		n += int64(len(c.Data) + len(c.Head))
+	}
+	return n
+}
@@ -0,0 +1,234 @@
+package ingester
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	gokit_log "github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/dskit/ring"
+	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/user"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	tsdb_record "github.com/prometheus/prometheus/tsdb/record"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/grafana/loki/pkg/distributor/writefailures"
+	"github.com/grafana/loki/pkg/ingester/client"
+	"github.com/grafana/loki/pkg/ingester/wal"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql/log"
+	"github.com/grafana/loki/pkg/runtime"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/util/constants"
+	"github.com/grafana/loki/pkg/util/test"
+	"github.com/grafana/loki/pkg/validation"
+)
+
+func TestHandoff(t *testing.T) {
+	for _, tc := range []struct {
+		name              string
+		replicationFactor int
+		stopReceiver      bool
+	}{
+		{name: "transferred", replicationFactor: 1},
+		// The receiver already owns the streams along with the leaving
+		// ingester, so they are neither transferred nor flushed.
+		{name: "replicated", replicationFactor: 2},
+		// The leaving ingester is alone in the ring, so it flushes its streams.
+		{name: "flushed", replicationFactor: 1, stopReceiver: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			limits, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
+			require.NoError(t, err)
+
+			lis, err := net.Listen("tcp", "localhost:0")
+			require.NoError(t, err)
+			_, port, err := net.SplitHostPort(lis.Addr().String())
+			require.NoError(t, err)
+
+			receiverCfg := defaultIngesterTestConfig(t)
+			receiverCfg.LifecyclerConfig.ID = "receiver"
+			receiverCfg.LifecyclerConfig.Port, err = strconv.Atoi(port)
+			require.NoError(t, err)
+			receiverCfg.LifecyclerConfig.RingConfig.ReplicationFactor = tc.replicationFactor
+
+			receiverStore := &mockStore{chunks: map[string][]chunk.Chunk{}}
+			receiver, err := New(receiverCfg, client.Config{}, receiverStore, limits, runtime.DefaultTenantConfigs(), prometheus.NewRegistry(), writefailures.Cfg{}, constants.Loki, gokit_log.NewNopLogger())
+			require.NoError(t, err)
+			require.NoError(t, services.StartAndAwaitRunning(context.Background(), receiver))
+			defer services.StopAndAwaitTerminated(context.Background(), receiver) //nolint:errcheck
+
+			srv := grpc.NewServer()
+			RegisterHandoffServer(srv, receiver)
+			go func() { _ = srv.Serve(lis) }()
+			defer srv.Stop()
+
+			leavingCfg := defaultIngesterTestConfig(t)
+			leavingCfg.LifecyclerConfig.ID = "leaving"
+			leavingCfg.LifecyclerConfig.RingConfig.KVStore.Mock = receiverCfg.LifecyclerConfig.RingConfig.KVStore.Mock
+			leavingCfg.LifecyclerConfig.RingConfig.ReplicationFactor = tc.replicationFactor
+			leavingCfg.Handoff = HandoffConfig{Enabled: true, Timeout: 10 * time.Second}
+
+			var clientCfg client.Config
+			flagext.DefaultValues(&clientCfg)
+			leavingStore := &mockStore{chunks: map[string][]chunk.Chunk{}}
+			leaving, err := New(leavingCfg, clientCfg, leavingStore, limits, runtime.DefaultTenantConfigs(), prometheus.NewRegistry(), writefailures.Cfg{}, constants.Loki, gokit_log.NewNopLogger())
+			require.NoError(t, err)
+			require.NoError(t, services.StartAndAwaitRunning(context.Background(), leaving))
+
+			test.Poll(t, 5*time.Second, 2, func() interface{} {
+				return leaving.handoffRing.InstancesCount()
+			})
+			test.Poll(t, 5*time.Second, ring.ACTIVE, func() interface{} {
+				return receiver.lifecycler.GetState()
+			})
+
+			req := logproto.PushRequest{
+				Streams: []logproto.Stream{
+					{Labels: `{foo="bar",bar="baz1"}`},
+					{Labels: `{foo="bar",bar="baz2"}`},
+				},
+			}
+			start := time.Now()
+			steps := 10
+			end := start.Add(time.Duration(steps) * time.Second)
+			for i := 0; i < steps; i++ {
+				for j := range req.Streams {
+					req.Streams[j].Entries = append(req.Streams[j].Entries, logproto.Entry{
+						Timestamp: start.Add(time.Duration(i) * time.Second),
+						Line:      fmt.Sprintf("line %d", i),
+					})
+				}
+			}
+			ctx := user.InjectOrgID(context.Background(), "test")
+			_, err = leaving.Push(ctx, &req)
+			require.NoError(t, err)
+
+			if tc.stopReceiver {
+				require.NoError(t, services.StopAndAwaitTerminated(context.Background(), receiver))
+			}
+			require.NoError(t, services.StopAndAwaitTerminated(context.Background(), leaving))
+
+			if tc.stopReceiver {
+				require.Len(t, leavingStore.chunks["test"], 2)
+				return
+			}
+
+			require.Empty(t, leavingStore.chunks)
+			if tc.replicationFactor > 1 {
+				require.Equal(t, 0.0, testutil.ToFloat64(leaving.metrics.handoffSentSeries))
+				require.Equal(t, 0.0, testutil.ToFloat64(receiver.metrics.handoffReceivedSeries.WithLabelValues("imported")))
+				return
+			}
+			require.Equal(t, 2.0, testutil.ToFloat64(leaving.metrics.handoffSentSeries))
+			require.Equal(t, 2.0, testutil.ToFloat64(receiver.metrics.handoffReceivedSeries.WithLabelValues("imported")))
+			ensureIngesterData(ctx, t, start, end, receiver)
+		})
+	}
+}
+
+// seriesRecorderWAL records the series and entries logged to the WAL.
+type seriesRecorderWAL struct {
+	noopWAL
+	series  []tsdb_record.RefSeries
+	entries []wal.RefEntries
+}
+
+func (w *seriesRecorderWAL) Log(record *wal.Record) error {
+	w.series = append(w.series, record.Series...)
+	w.entries = append(w.entries, record.RefEntries...)
+	return nil
+}
+
+func TestImportSeries(t *testing.T) {
+	limits, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
+	require.NoError(t, err)
+	limiter := NewLimiter(limits, NilMetrics, &ringCountMock{count: 1}, 1)
+
+	src, err := newInstance(defaultConfig(), defaultPeriodConfigs, "test", limiter, runtime.DefaultTenantConfigs(), noopWAL{}, NilMetrics, &OnceSwitch{}, nil, nil, nil, NewStreamRateCalculator(), nil)
+	require.NoError(t, err)
+	require.NoError(t, src.Push(context.Background(), &logproto.PushRequest{Streams: []logproto.Stream{stream1, stream2}}))
+
+	w := &seriesRecorderWAL{}
+	dst, err := newInstance(defaultConfig(), defaultPeriodConfigs, "test", limiter, runtime.DefaultTenantConfigs(), w, NilMetrics, &OnceSwitch{}, nil, nil, nil, NewStreamRateCalculator(), nil)
+	require.NoError(t, err)
+	existing := logproto.Stream{Labels: stream1.Labels, Entries: []logproto.Entry{{Timestamp: time.Unix(0, 3), Line: "existing"}}}
+	require.NoError(t, dst.Push(context.Background(), &logproto.PushRequest{Streams: []logproto.Stream{existing}}))
+	w.series, w.entries = nil, nil
+
+	outcomes := map[string]string{}
+	it := newStreamsIterator(ingesterInstancesFunc(func() []*instance { return []*instance{src} }))
+	for it.Next() {
+		outcome, err := dst.importSeries(context.Background(), it.Stream())
+		require.NoError(t, err)
+		outcomes[logproto.FromLabelAdaptersToLabels(it.Stream().Labels).String()] = outcome
+	}
+	require.NoError(t, it.Error())
+	require.Equal(t, map[string]string{stream1.Labels: seriesMerged, stream2.Labels: seriesImported}, outcomes)
+
+	// The imported stream and the entries of the transferred chunks are
+	// logged to the WAL, so they can be replayed.
+	require.Len(t, w.series, 1)
+	require.Equal(t, stream2.Labels, w.series[0].Labels.String())
+	var logged int
+	for _, e := range w.entries {
+		logged += len(e.Entries)
+	}
+	require.Equal(t, len(stream1.Entries)+len(stream2.Entries), logged)
+
+	merged := logproto.Stream{Labels: stream1.Labels, Entries: append(append([]logproto.Entry{}, stream1.Entries...), existing.Entries...)}
+	for _, want := range []logproto.Stream{merged, stream2} {
+		s, ok := dst.streams.Load(want.Labels)
+		require.True(t, ok)
+		entryIt, err := s.Iterator(context.Background(), nil, time.Unix(0, 0), time.Unix(0, 100), logproto.FORWARD, log.NewNoopPipeline().ForStream(s.labels))
+		require.NoError(t, err)
+		var entries []logproto.Entry
+		for entryIt.Next() {
+			entries = append(entries, entryIt.Entry())
+		}
+		require.NoError(t, entryIt.Close())
+		require.Equal(t, want.Entries, entries)
+	}
+
+	// The transferred chunks merged into the existing stream are closed, new
+	// entries go to its head chunk.
+	s, _ := dst.streams.Load(stream1.Labels)
+	require.True(t, s.chunks[0].closed)
+	require.Equal(t, flushReasonHandoff, s.chunks[0].reason)
+	require.False(t, s.chunks[len(s.chunks)-1].closed)
+}
+
+func TestImportSeries_StreamLimit(t *testing.T) {
+	srcLimits, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
+	require.NoError(t, err)
+	limitsCfg := defaultLimitsTestConfig()
+	limitsCfg.MaxLocalStreamsPerUser = 1
+	limits, err := validation.NewOverrides(limitsCfg, nil)
+	require.NoError(t, err)
+	limiter := NewLimiter(limits, NilMetrics, &ringCountMock{count: 1}, 1)
+
+	src, err := newInstance(defaultConfig(), defaultPeriodConfigs, "test", NewLimiter(srcLimits, NilMetrics, &ringCountMock{count: 1}, 1), runtime.DefaultTenantConfigs(), noopWAL{}, NilMetrics, &OnceSwitch{}, nil, nil, nil, NewStreamRateCalculator(), nil)
+	require.NoError(t, err)
+	require.NoError(t, src.Push(context.Background(), &logproto.PushRequest{Streams: []logproto.Stream{stream1, stream2}}))
+
+	dst, err := newInstance(defaultConfig(), defaultPeriodConfigs, "test", limiter, runtime.DefaultTenantConfigs(), noopWAL{}, NilMetrics, &OnceSwitch{}, nil, nil, nil, NewStreamRateCalculator(), nil)
+	require.NoError(t, err)
+
+	var outcomes []string
+	it := newStreamsIterator(ingesterInstancesFunc(func() []*instance { return []*instance{src} }))
+	for it.Next() {
+		outcome, err := dst.importSeries(context.Background(), it.Stream())
+		require.NoError(t, err)
+		outcomes = append(outcomes, outcome)
+	}
+	require.NoError(t, it.Error())
+	require.ElementsMatch(t, []string{seriesImported, seriesSkipped}, outcomes)
+	require.Equal(t, 1, dst.streams.Len())
+}
@@ -35,6 +35,7 @@ import (
 	"github.com/grafana/loki/pkg/ingester/client"
 	"github.com/grafana/loki/pkg/ingester/index"
 	"github.com/grafana/loki/pkg/iter"
+	"github.com/grafana/loki/pkg/kafka"
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/logql"
 	"github.com/grafana/loki/pkg/logql/syntax"
@@ -90,6 +91,8 @@ type Config struct {
 	// Synchronization settings. Used to make sure that ingesters cut their chunks at the same moments.
 	SyncPeriod         time.Duration `yaml:"sync_period"`
 	SyncMinUtilization float64       `yaml:"sync_min_utilization"`
+	// Cut chunks once their entries span the max chunk age, so the replicas of a stream flush identical chunks.
+	DeterministicChunkCuts bool `yaml:"deterministic_chunk_cuts"`
 
 	MaxReturnedErrors int `yaml:"max_returned_stream_errors"`
 
@@ -101,13 +104,26 @@ type Config struct {
 
 	WAL WALConfig `yaml:"wal,omitempty" doc:"description=The ingester WAL (Write Ahead Log) records incoming logs and stores them on the local file systems in order to guarantee persistence of acknowledged data in the event of a process crash."`
 
+	Spill SpillConfig `yaml:"spill,omitempty" doc:"description=Spilling of the closed chunks of idle streams to local disk, to reduce the memory footprint of the ingester."`
+
+	Handoff HandoffConfig `yaml:"handoff,omitempty" doc:"description=Transfer of the in-memory streams to other ingesters when the ingester leaves the ring, instead of flushing them to storage."`
+
 	ChunkFilterer          chunk.RequestChunkFilterer     `yaml:"-"`
 	PipelineWrapper        lokilog.PipelineWrapper        `yaml:"-"`
 	SampleExtractorWrapper lokilog.SampleExtractorWrapper `yaml:"-"`
 
+	// ZstdDictionaries provides the zstd dictionaries the new chunks are
+	// compressed with when the chunk encoding is zstd, set from the top-level
+	// Loki config.
+	ZstdDictionaries ZstdDictionaryProvider `yaml:"-"`
+
 	// Optional wrapper that can be used to modify the behaviour of the ingester
 	Wrapper Wrapper `yaml:"-"`
 
+	// Kafka config, set from the top-level Loki config. When Kafka is used as a
+	// write-ahead queue, the ingester consumes the pushed streams from Kafka.
+	KafkaConfig kafka.Config `yaml:"-"`
+
 	IndexShards int `yaml:"index_shards"`
 
 	MaxDroppedStreams int `yaml:"max_dropped_streams"`
@@ -115,10 +131,30 @@ type Config struct {
 	ShutdownMarkerPath string `yaml:"shutdown_marker_path"`
 }
 
+// ZstdDictionaryProvider provides the zstd dictionaries of the tenants.
+type ZstdDictionaryProvider interface {
+	// Dictionary returns the dictionary the new chunks of the tenant are
+	// compressed with, or nil if there is none.
+	Dictionary(tenant string) *chunkenc.Dictionary
+	// Dictionaries returns the dictionaries the chunks are read with.
+	Dictionaries() *chunkenc.Dictionaries
+}
+
+// zstdDictionaries returns the dictionaries the chunks compressed with zstd
+// dictionaries are read with, if any.
+func (cfg *Config) zstdDictionaries() *chunkenc.Dictionaries {
+	if cfg.ZstdDictionaries == nil {
+		return nil
+	}
+	return cfg.ZstdDictionaries.Dictionaries()
+}
+
 // RegisterFlags registers the flags.
 func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.LifecyclerConfig.RegisterFlags(f, util_log.Logger)
 	cfg.WAL.RegisterFlags(f)
+	cfg.Spill.RegisterFlags(f)
+	cfg.Handoff.RegisterFlags(f)
 
 	f.IntVar(&cfg.ConcurrentFlushes, "ingester.concurrent-flushes", 32, "How many flushes can happen concurrently from each stream.")
 	f.DurationVar(&cfg.FlushCheckPeriod, "ingester.flush-check-period", 30*time.Second, "How often should the ingester see if there are any blocks to flush. The first flush check is delayed by a random time up to 0.8x the flush check period. Additionally, there is +/- 1% jitter added to the interval.")
@@ -130,6 +166,7 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.StringVar(&cfg.ChunkEncoding, "ingester.chunk-encoding", chunkenc.EncGZIP.String(), fmt.Sprintf("The algorithm to use for compressing chunk. (%s)", chunkenc.SupportedEncoding()))
 	f.DurationVar(&cfg.SyncPeriod, "ingester.sync-period", 1*time.Hour, "Parameters used to synchronize ingesters to cut chunks at the same moment. Sync period is used to roll over incoming entry to a new chunk. If chunk's utilization isn't high enough (eg. less than 50% when sync_min_utilization is set to 0.5), then this chunk rollover doesn't happen.")
 	f.Float64Var(&cfg.SyncMinUtilization, "ingester.sync-min-utilization", 0.1, "Minimum utilization of chunk when doing synchronization.")
+	f.BoolVar(&cfg.DeterministicChunkCuts, "ingester.deterministic-chunk-cuts", false, "Cut a chunk before appending an entry which would make it span more than the max chunk age, instead of closing the chunk when the flush loop finds it too old. The replicas of a stream then cut their chunks at the same entries and flush identical chunks, which the chunk store deduplicates.")
 	f.IntVar(&cfg.MaxReturnedErrors, "ingester.max-ignored-stream-errors", 10, "The maximum number of errors a stream will report to the user when a push fails. 0 to make unlimited.")
 	f.DurationVar(&cfg.MaxChunkAge, "ingester.max-chunk-age", 2*time.Hour, "The maximum duration of a timeseries chunk in memory. If a timeseries runs for longer than this, the current chunk will be flushed to the store and a new chunk created.")
 	f.DurationVar(&cfg.QueryStoreMaxLookBackPeriod, "ingester.query-store-max-look-back-period", 0, "How far back should an ingester be allowed to query the store for data, for use only with boltdb-shipper/tsdb index and filesystem object store. -1 for infinite.")
@@ -150,6 +187,14 @@ func (cfg *Config) Validate() error {
 		return err
 	}
 
+	if err = cfg.Spill.Validate(); err != nil {
+		return err
+	}
+
+	if err = cfg.Handoff.Validate(); err != nil {
+		return err
+	}
+
 	if cfg.IndexShards <= 0 {
 		return fmt.Errorf("invalid ingester index shard factor: %d", cfg.IndexShards)
 	}
@@ -177,6 +222,7 @@ type Interface interface {
 	logproto.PusherServer
 	logproto.QuerierServer
 	logproto.StreamDataServer
+	HandoffServer
 
 	CheckReady(ctx context.Context) error
 	FlushHandler(w http.ResponseWriter, _ *http.Request)
@@ -232,6 +278,13 @@ type Ingester struct {
 
 	wal WAL
 
+	// spiller is nil when spilling the chunks of idle streams is disabled.
+	spiller *spiller
+
+	// handoffRing is used to find the new owners of the streams when leaving
+	// the ring. It is nil when handoff is disabled.
+	handoffRing *ring.Ring
+
 	chunkFilter      chunk.RequestChunkFilterer
 	extractorWrapper lokilog.SampleExtractorWrapper
 	pipelineWrapper  lokilog.PipelineWrapper
@@ -239,6 +292,8 @@ type Ingester struct {
 	streamRateCalculator *StreamRateCalculator
 
 	writeLogManager *writefailures.Manager
+
+	kafkaReader *kafka.Reader
 }
 
 // New makes a new Ingester.
@@ -285,6 +340,14 @@ func New(cfg Config, clientConfig client.Config, store Store, limits Limits, con
 		}
 	}
 
+	if cfg.Spill.Enabled {
+		spiller, err := newSpiller(cfg.Spill, cfg.zstdDictionaries(), metrics)
+		if err != nil {
+			return nil, err
+		}
+		i.spiller = spiller
+	}
+
 	wal, err := newWAL(cfg.WAL, registerer, metrics, newIngesterSeriesIter(i))
 	if err != nil {
 		return nil, err
@@ -299,6 +362,15 @@ func New(cfg Config, clientConfig client.Config, store Store, limits Limits, con
 	i.lifecyclerWatcher = services.NewFailureWatcher()
 	i.lifecyclerWatcher.WatchService(i.lifecycler)
 
+	if cfg.Handoff.Enabled {
+		// The streams are transferred to all the healthy owners, the write
+		// quorum of the distributors doesn't apply.
+		i.handoffRing, err = ring.NewWithStoreClientAndStrategy(cfg.LifecyclerConfig.RingConfig, "ingester", RingKey, i.lifecycler.KVStore, ring.NewIgnoreUnhealthyInstancesReplicationStrategy(), nil, logger)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Now that the lifecycler has been created, we can create the limiter
 	// which depends on it.
 	i.limiter = NewLimiter(limits, metrics, i.lifecycler, cfg.LifecyclerConfig.RingConfig.ReplicationFactor)
@@ -307,6 +379,20 @@ func New(cfg Config, clientConfig client.Config, store Store, limits Limits, con
 
 	i.setupAutoForget()
 
+	if cfg.KafkaConfig.WriteAhead {
+		// Every record is only appended by a single ingester of the consumer
+		// group of a zone, so the records are replicated by the zones.
+		if cfg.LifecyclerConfig.Zone == "" && cfg.LifecyclerConfig.RingConfig.ReplicationFactor > 1 {
+			return nil, fmt.Errorf("kafka write_ahead with a replication factor of %d requires the ingester zone to be set, otherwise the records are only appended by a single ingester", cfg.LifecyclerConfig.RingConfig.ReplicationFactor)
+		}
+		kafkaCfg := cfg.KafkaConfig
+		kafkaCfg.ConsumerGroup = kafkaCfg.ConsumerGroupFor(cfg.LifecyclerConfig.Zone)
+		i.kafkaReader, err = kafka.NewReader(kafkaCfg, i, logger, registerer)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if i.cfg.ChunkFilterer != nil {
 		i.SetChunkFilterer(i.cfg.ChunkFilterer)
 	}
@@ -501,6 +587,12 @@ func (i *Ingester) starting(ctx context.Context) error {
 		return err
 	}
 
+	if i.handoffRing != nil {
+		if err := services.StartAndAwaitRunning(ctx, i.handoffRing); err != nil {
+			return errors.Wrap(err, "failed to start handoff ring client")
+		}
+	}
+
 	shutdownMarkerPath := path.Join(i.cfg.ShutdownMarkerPath, shutdownMarkerFilename)
 	shutdownMarker, err := shutdownMarkerExists(shutdownMarkerPath)
 	if err != nil {
@@ -515,6 +607,14 @@ func (i *Ingester) starting(ctx context.Context) error {
 	// start our loop
 	i.loopDone.Add(1)
 	go i.loop()
+
+	// Only consume from Kafka once the WAL has been replayed, so the consumed
+	// streams are appended after the replayed ones.
+	if i.kafkaReader != nil {
+		if err := services.StartAndAwaitRunning(ctx, i.kafkaReader); err != nil {
+			return errors.Wrap(err, "failed to start kafka reader")
+		}
+	}
 	return nil
 }
 
@@ -543,14 +643,24 @@ func (i *Ingester) running(ctx context.Context) error {
 //
 // At this point, loop no longer runs, but flushers are still running.
 func (i *Ingester) stopping(_ error) error {
-	i.stopIncomingRequests()
 	var errs util.MultiError
+	// Stop consuming before the ingester becomes read-only, so the offsets of
+	// all the appended streams get committed.
+	if i.kafkaReader != nil {
+		errs.Add(services.StopAndAwaitTerminated(context.Background(), i.kafkaReader))
+	}
+
+	i.stopIncomingRequests()
 	errs.Add(i.wal.Stop())
 
 	if i.flushOnShutdownSwitch.Get() {
 		i.lifecycler.SetFlushOnShutdown(true)
 	}
 	errs.Add(services.StopAndAwaitTerminated(context.Background(), i.lifecycler))
+	// The handoff ring client is used by the lifecycler when it stops.
+	if i.handoffRing != nil {
+		errs.Add(services.StopAndAwaitTerminated(context.Background(), i.handoffRing))
+	}
 
 	for _, flushQueue := range i.flushQueues {
 		flushQueue.Close()
@@ -1306,7 +1416,7 @@ func (i *Ingester) Tail(req *logproto.TailRequest, queryServer logproto.Querier_
 		return fmt.Errorf("unsupported query expression: want (LogSelectorExpr), got (%T)", req.Plan.AST)
 	}
 
-	tailer, err := newTailer(instanceID, expr, queryServer, i.cfg.MaxDroppedStreams)
+	tailer, err := newTailer(instanceID, expr, queryServer, i.cfg.MaxDroppedStreams, i.metrics)
 	if err != nil {
 		return err
 	}
@@ -65,6 +65,28 @@ func TestPrepareShutdownMarkerPathNotSet(t *testing.T) {
 	require.Equal(t, 500, resp.Code)
 }
 
+func TestKafkaWriteAheadRequiresZone(t *testing.T) {
+	limits, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
+	require.NoError(t, err)
+	store := &mockStore{
+		chunks: map[string][]chunk.Chunk{},
+	}
+
+	ingesterConfig := defaultIngesterTestConfig(t)
+	flagext.DefaultValues(&ingesterConfig.KafkaConfig)
+	ingesterConfig.KafkaConfig.Enabled = true
+	ingesterConfig.KafkaConfig.WriteAhead = true
+	ingesterConfig.LifecyclerConfig.RingConfig.ReplicationFactor = 3
+
+	_, err = New(ingesterConfig, client.Config{}, store, limits, runtime.DefaultTenantConfigs(), nil, writefailures.Cfg{}, constants.Loki, log.NewNopLogger())
+	require.ErrorContains(t, err, "requires the ingester zone to be set")
+
+	ingesterConfig.LifecyclerConfig.Zone = "zone-a"
+	i, err := New(ingesterConfig, client.Config{}, store, limits, runtime.DefaultTenantConfigs(), nil, writefailures.Cfg{}, constants.Loki, log.NewNopLogger())
+	require.NoError(t, err)
+	require.NotNil(t, i.kafkaReader)
+}
+
 func TestPrepareShutdown(t *testing.T) {
 	tempDir := t.TempDir()
 	ingesterConfig := defaultIngesterTestConfig(t)
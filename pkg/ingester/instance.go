@@ -181,13 +181,13 @@ func newInstance(
 func (i *instance) consumeChunk(ctx context.Context, ls labels.Labels, chunk *logproto.Chunk) error {
 	fp := i.getHashForLabels(ls)
 
-	s, _, _ := i.streams.LoadOrStoreNewByFP(fp,
+	s, _, err := i.streams.LoadOrStoreNewByFP(fp,
 		func() (*stream, error) {
 			s, err := i.createStreamByFP(ls, fp)
-			s.chunkMtx.Lock() // Lock before return, because we have defer that unlocks it.
 			if err != nil {
 				return nil, err
 			}
+			s.chunkMtx.Lock() // Lock before return, because we have defer that unlocks it.
 			return s, nil
 		},
 		func(s *stream) error {
@@ -195,9 +195,12 @@ func (i *instance) consumeChunk(ctx context.Context, ls labels.Labels, chunk *lo
 			return nil
 		},
 	)
+	if err != nil {
+		return err
+	}
 	defer s.chunkMtx.Unlock()
 
-	err := s.consumeChunk(ctx, chunk)
+	err = s.consumeChunk(ctx, chunk)
 	if err == nil {
 		i.metrics.memoryChunks.Inc()
 	}
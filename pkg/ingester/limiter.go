@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/prometheus/prometheus/model/labels"
 	"golang.org/x/time/rate"
 
 	"github.com/grafana/loki/pkg/distributor/shardstreams"
@@ -27,6 +28,9 @@ type Limits interface {
 	MaxLocalStreamsPerUser(userID string) int
 	MaxGlobalStreamsPerUser(userID string) int
 	PerStreamRateLimit(userID string) validation.RateLimit
+	StreamRateLimitPolicies(userID string) []validation.StreamRateLimitPolicy
+	OutOfOrderWindow(userID string) time.Duration
+	OutOfOrderWindowPolicies(userID string) []validation.OutOfOrderWindowPolicy
 	ShardStreams(userID string) *shardstreams.Config
 }
 
@@ -140,15 +144,41 @@ func (l *Limiter) minNonZero(first, second int) int {
 }
 
 type RateLimiterStrategy interface {
-	RateLimit(tenant string) validation.RateLimit
+	// RateLimit returns the rate limit of the given stream, along with the
+	// name of the rate limit policy it comes from, if any.
+	RateLimit(tenant string, lbs labels.Labels) (validation.RateLimit, string)
 }
 
-func (l *Limiter) RateLimit(tenant string) validation.RateLimit {
+func (l *Limiter) RateLimit(tenant string, lbs labels.Labels) (validation.RateLimit, string) {
 	if l.disabled {
-		return validation.Unlimited
+		return validation.Unlimited, ""
 	}
 
-	return l.limits.PerStreamRateLimit(tenant)
+	if policy := validation.MatchStreamRateLimitPolicy(l.limits.StreamRateLimitPolicies(tenant), lbs); policy != nil {
+		return policy.RateLimit(), policy.Name
+	}
+	return l.limits.PerStreamRateLimit(tenant), ""
+}
+
+type OutOfOrderWindowStrategy interface {
+	// OutOfOrderWindow returns how far behind the newest entry of the given
+	// stream entries are accepted, along with the name of the out-of-order
+	// window policy it comes from, if any. Zero means the default window.
+	OutOfOrderWindow(tenant string, lbs labels.Labels) (time.Duration, string)
+}
+
+func (l *Limiter) OutOfOrderWindow(tenant string, lbs labels.Labels) (time.Duration, string) {
+	if policy := validation.MatchOutOfOrderWindowPolicy(l.limits.OutOfOrderWindowPolicies(tenant), lbs); policy != nil {
+		return time.Duration(policy.Window), policy.Name
+	}
+	return l.limits.OutOfOrderWindow(tenant), ""
+}
+
+// StreamLimiterStrategy provides the limits applied to the entries pushed to
+// a stream.
+type StreamLimiterStrategy interface {
+	RateLimiterStrategy
+	OutOfOrderWindowStrategy
 }
 
 type StreamRateLimiter struct {
@@ -156,19 +186,29 @@ type StreamRateLimiter struct {
 	recheckAt     time.Time
 	strategy      RateLimiterStrategy
 	tenant        string
+	labels        labels.Labels
+	policy        string
 	lim           *rate.Limiter
 }
 
-func NewStreamRateLimiter(strategy RateLimiterStrategy, tenant string, recheckPeriod time.Duration) *StreamRateLimiter {
-	rl := strategy.RateLimit(tenant)
+func NewStreamRateLimiter(strategy RateLimiterStrategy, tenant string, lbs labels.Labels, recheckPeriod time.Duration) *StreamRateLimiter {
+	rl, policy := strategy.RateLimit(tenant, lbs)
 	return &StreamRateLimiter{
 		recheckPeriod: recheckPeriod,
 		strategy:      strategy,
 		tenant:        tenant,
+		labels:        lbs,
+		policy:        policy,
 		lim:           rate.NewLimiter(rl.Limit, rl.Burst),
 	}
 }
 
+// Policy returns the name of the rate limit policy currently applied to the
+// stream, or an empty string if the tenant's per-stream rate limit applies.
+func (l *StreamRateLimiter) Policy() string {
+	return l.policy
+}
+
 func (l *StreamRateLimiter) AllowN(at time.Time, n int) bool {
 	now := time.Now()
 	if now.After(l.recheckAt) {
@@ -177,7 +217,8 @@ func (l *StreamRateLimiter) AllowN(at time.Time, n int) bool {
 		oldLim := l.lim.Limit()
 		oldBurst := l.lim.Burst()
 
-		next := l.strategy.RateLimit(l.tenant)
+		next, policy := l.strategy.RateLimit(l.tenant, l.labels)
+		l.policy = policy
 
 		if oldLim != next.Limit || oldBurst != next.Burst {
 			// Edge case: rate.Inf doesn't advance nicely when reconfigured.
@@ -6,10 +6,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/time/rate"
 
+	"github.com/grafana/loki/pkg/logql/syntax"
 	"github.com/grafana/loki/pkg/validation"
 )
 
@@ -163,6 +165,47 @@ func TestLimiter_minNonZero(t *testing.T) {
 	}
 }
 
+func TestLimiter_RateLimit(t *testing.T) {
+	limits := defaultLimitsTestConfig()
+	limits.PerStreamRateLimit = 10
+	limits.PerStreamRateLimitBurst = 20
+	limits.StreamRateLimitPolicies = []validation.StreamRateLimitPolicy{
+		{Name: "batch", Selector: `{namespace="batch"}`, Rate: 1, Burst: 2},
+		{Name: "batch-and-debug", Selector: `{namespace="batch", level="debug"}`, Rate: 3, Burst: 4},
+		{Name: "prod", Selector: `{namespace=~"prod-.+"}`, Rate: 100, Burst: 200},
+	}
+	require.NoError(t, limits.Validate())
+	overrides, err := validation.NewOverrides(limits, nil)
+	require.NoError(t, err)
+
+	limiter := NewLimiter(overrides, NilMetrics, &ringCountMock{count: 1}, 1)
+
+	for _, tc := range []struct {
+		lbs            string
+		expectedPolicy string
+		expected       validation.RateLimit
+	}{
+		{lbs: `{namespace="batch"}`, expectedPolicy: "batch", expected: validation.RateLimit{Limit: 1, Burst: 2}},
+		{lbs: `{namespace="batch", level="debug"}`, expectedPolicy: "batch", expected: validation.RateLimit{Limit: 1, Burst: 2}},
+		{lbs: `{namespace="prod-eu"}`, expectedPolicy: "prod", expected: validation.RateLimit{Limit: 100, Burst: 200}},
+		{lbs: `{namespace="dev"}`, expectedPolicy: "", expected: validation.RateLimit{Limit: 10, Burst: 20}},
+	} {
+		t.Run(tc.lbs, func(t *testing.T) {
+			lbs, err := syntax.ParseLabels(tc.lbs)
+			require.NoError(t, err)
+
+			rl, policy := limiter.RateLimit("test", lbs)
+			require.Equal(t, tc.expectedPolicy, policy)
+			require.Equal(t, tc.expected, rl)
+		})
+	}
+
+	limiter.DisableForWALReplay()
+	rl, policy := limiter.RateLimit("test", labels.FromStrings("namespace", "batch"))
+	require.Equal(t, "", policy)
+	require.Equal(t, validation.Unlimited, rl)
+}
+
 type ringCountMock struct {
 	count int
 }
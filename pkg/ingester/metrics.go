@@ -36,6 +36,22 @@ type ingesterMetrics struct {
 
 	limiterEnabled prometheus.Gauge
 
+	streamPolicyRateLimitedSamples *prometheus.CounterVec
+	streamPolicyRateLimitedBytes   *prometheus.CounterVec
+	outOfOrderLateness             *prometheus.HistogramVec
+
+	spilledChunks     prometheus.Gauge
+	spilledBytes      prometheus.Gauge
+	spilledBytesTotal prometheus.Counter
+	spillSegments     prometheus.Gauge
+	chunkReads        *prometheus.CounterVec
+
+	tailDroppedEntries *prometheus.CounterVec
+
+	handoffSentSeries     prometheus.Counter
+	handoffSentChunks     prometheus.Counter
+	handoffReceivedSeries *prometheus.CounterVec
+
 	autoForgetUnhealthyIngestersTotal prometheus.Counter
 
 	chunkUtilization              prometheus.Histogram
@@ -281,6 +297,70 @@ func newIngesterMetrics(r prometheus.Registerer, metricsNamespace string) *inges
 			Help:      "1 if prepare shutdown has been called, 0 otherwise",
 		}),
 
+		streamPolicyRateLimitedSamples: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: constants.Loki,
+			Name:      "ingester_stream_policy_rate_limited_samples_total",
+			Help:      "The total number of samples discarded because of the per-stream rate limit of a stream rate limit policy.",
+		}, []string{"tenant", "policy"}),
+		streamPolicyRateLimitedBytes: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: constants.Loki,
+			Name:      "ingester_stream_policy_rate_limited_bytes_total",
+			Help:      "The total number of bytes discarded because of the per-stream rate limit of a stream rate limit policy.",
+		}, []string{"tenant", "policy"}),
+		outOfOrderLateness: promauto.With(r).NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: constants.Loki,
+			Name:      "ingester_out_of_order_lateness_seconds",
+			Help:      "How far behind the newest entry of their stream the out-of-order entries are, whether they are accepted or not, per tenant and out-of-order window policy.",
+			// 1s to 72h.
+			Buckets: prometheus.ExponentialBuckets(1, 4, 9),
+		}, []string{"tenant", "policy"}),
+
+		spilledChunks: promauto.With(r).NewGauge(prometheus.GaugeOpts{
+			Namespace: constants.Loki,
+			Name:      "ingester_spilled_chunks",
+			Help:      "The number of chunks currently spilled to disk.",
+		}),
+		spilledBytes: promauto.With(r).NewGauge(prometheus.GaugeOpts{
+			Namespace: constants.Loki,
+			Name:      "ingester_spilled_bytes",
+			Help:      "The size in bytes of the chunks currently spilled to disk.",
+		}),
+		spilledBytesTotal: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: constants.Loki,
+			Name:      "ingester_spilled_bytes_total",
+			Help:      "The total number of bytes of the chunks spilled to disk.",
+		}),
+		spillSegments: promauto.With(r).NewGauge(prometheus.GaugeOpts{
+			Namespace: constants.Loki,
+			Name:      "ingester_spill_segments",
+			Help:      "The number of files the chunks are currently spilled to.",
+		}),
+		chunkReads: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: constants.Loki,
+			Name:      "ingester_chunk_reads_total",
+			Help:      "The total number of chunks read by queries, by source: memory or spill. The ratio of spill reads is the hit rate of the spilled chunks.",
+		}, []string{"source"}),
+		tailDroppedEntries: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: constants.Loki,
+			Name:      "ingester_tail_dropped_entries_total",
+			Help:      "The total number of entries dropped by tailers because the querier could not keep up, per tenant.",
+		}, []string{"tenant"}),
+		handoffSentSeries: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: constants.Loki,
+			Name:      "ingester_handoff_sent_series_total",
+			Help:      "The total number of series sent to other ingesters when leaving the ring.",
+		}),
+		handoffSentChunks: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: constants.Loki,
+			Name:      "ingester_handoff_sent_chunks_total",
+			Help:      "The total number of chunks sent to other ingesters when leaving the ring.",
+		}),
+		handoffReceivedSeries: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: constants.Loki,
+			Name:      "ingester_handoff_received_series_total",
+			Help:      "The total number of series received from leaving ingesters, by outcome: imported as a new stream, merged into an existing stream, or skipped because of the stream limits.",
+		}, []string{"outcome"}),
+
 		flushQueueLength: promauto.With(r).NewGauge(prometheus.GaugeOpts{
 			Namespace: metricsNamespace,
 			Subsystem: "ingester",
@@ -289,3 +369,15 @@ func newIngesterMetrics(r prometheus.Registerer, metricsNamespace string) *inges
 		}),
 	}
 }
+
+// observeChunkRead records a chunk read by a query.
+func (m *ingesterMetrics) observeChunkRead(spilled bool) {
+	if m == nil {
+		return
+	}
+	if spilled {
+		m.chunkReads.WithLabelValues("spill").Inc()
+		return
+	}
+	m.chunkReads.WithLabelValues("memory").Inc()
+}
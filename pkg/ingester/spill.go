@@ -0,0 +1,253 @@
+package ingester
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/prometheus/tsdb/fileutil"
+
+	"github.com/grafana/loki/pkg/chunkenc"
+	"github.com/grafana/loki/pkg/iter"
+	util_log "github.com/grafana/loki/pkg/util/log"
+)
+
+// SpillConfig configures the spilling of the closed chunks of idle streams
+// to memory-mapped files on local disk.
+type SpillConfig struct {
+	Enabled     bool          `yaml:"enabled"`
+	Dir         string        `yaml:"dir"`
+	IdlePeriod  time.Duration `yaml:"idle_period"`
+	SegmentSize int           `yaml:"segment_size"`
+}
+
+// RegisterFlags adds the flags required to config this to the given FlagSet
+func (cfg *SpillConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "ingester.spill.enabled", false, "Spill the closed chunks of idle streams to memory-mapped files on local disk, instead of keeping them in memory until they are flushed and their retain period is over. Queries read the spilled chunks from the files.")
+	f.StringVar(&cfg.Dir, "ingester.spill.dir", "spill", "Directory of the files the chunks are spilled to. Its content is deleted on startup.")
+	f.DurationVar(&cfg.IdlePeriod, "ingester.spill.idle-period", 5*time.Minute, "How long a stream must not receive any entry for its closed chunks to be spilled.")
+	f.IntVar(&cfg.SegmentSize, "ingester.spill.segment-size", 64<<20, "Size in bytes of the files the chunks are spilled to. A file is deleted once all its chunks are removed from memory.")
+}
+
+func (cfg *SpillConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.Dir == "" {
+		return errors.New("ingester.spill.dir must be set when spilling is enabled")
+	}
+	if cfg.IdlePeriod <= 0 {
+		return errors.New("ingester.spill.idle-period must be greater than 0")
+	}
+	if cfg.SegmentSize <= 0 {
+		return errors.New("ingester.spill.segment-size must be greater than 0")
+	}
+	return nil
+}
+
+// spiller writes chunks to segment files mapped in memory. The chunks spilled
+// to a segment reference its mapped bytes, so a segment is only unmapped and
+// deleted once none of its chunks is kept by a stream or read by a query.
+type spiller struct {
+	cfg          SpillConfig
+	dictionaries *chunkenc.Dictionaries
+	metrics      *ingesterMetrics
+
+	mtx     sync.Mutex
+	current *spillSegment
+	nextID  int
+}
+
+func newSpiller(cfg SpillConfig, dictionaries *chunkenc.Dictionaries, metrics *ingesterMetrics) (*spiller, error) {
+	// The spilled chunks are recovered from the WAL after a restart, so the
+	// segments of a previous run are useless.
+	if err := os.RemoveAll(cfg.Dir); err != nil {
+		return nil, fmt.Errorf("removing spill folder at %q: %w", cfg.Dir, err)
+	}
+	if err := os.MkdirAll(cfg.Dir, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("creating spill folder at %q: %w", cfg.Dir, err)
+	}
+	return &spiller{cfg: cfg, dictionaries: dictionaries, metrics: metrics}, nil
+}
+
+// Spill writes the given closed chunk to a segment, and returns an
+// equivalent chunk backed by the mapped bytes of the segment.
+func (s *spiller) Spill(c *chunkenc.MemChunk, blockSize, targetSize int) (*chunkenc.MemChunk, *spillSegment, error) {
+	b, err := c.Bytes()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	seg := s.current
+	if seg == nil || seg.written+len(b) > len(seg.mmap.Bytes()) {
+		size := s.cfg.SegmentSize
+		if len(b) > size {
+			size = len(b)
+		}
+		if seg, err = s.newSegment(size); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if _, err := seg.file.WriteAt(b, int64(seg.written)); err != nil {
+		return nil, nil, fmt.Errorf("writing to spill segment %s: %w", seg.path, err)
+	}
+	spilled, err := chunkenc.NewByteChunkWithDictionaries(seg.mmap.Bytes()[seg.written:seg.written+len(b)], blockSize, targetSize, s.dictionaries)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	seg.written += len(b)
+	seg.acquire()
+
+	s.metrics.spilledChunks.Inc()
+	s.metrics.spilledBytes.Add(float64(len(b)))
+	s.metrics.spilledBytesTotal.Add(float64(len(b)))
+	return spilled, seg, nil
+}
+
+// newSegment replaces the current segment with a new one of the given size.
+// It must be called with the lock held.
+func (s *spiller) newSegment(size int) (*spillSegment, error) {
+	path := filepath.Join(s.cfg.Dir, fmt.Sprintf("%08d", s.nextID))
+	s.nextID++
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("creating spill segment: %w", err)
+	}
+	if err := f.Truncate(int64(size)); err != nil {
+		_ = f.Close()
+		_ = os.Remove(path)
+		return nil, fmt.Errorf("allocating spill segment %s: %w", path, err)
+	}
+	mf, err := fileutil.OpenMmapFileWithSize(path, size)
+	if err != nil {
+		_ = f.Close()
+		_ = os.Remove(path)
+		return nil, fmt.Errorf("mapping spill segment %s: %w", path, err)
+	}
+
+	seg := &spillSegment{spiller: s, path: path, file: f, mmap: mf}
+	// The current segment is referenced until it is replaced, so it isn't
+	// deleted while chunks can still be written to it.
+	seg.acquire()
+	if prev := s.current; prev != nil {
+		defer prev.release()
+	}
+	s.current = seg
+	s.metrics.spillSegments.Inc()
+	return seg, nil
+}
+
+// spillSegment is a file chunks are spilled to.
+type spillSegment struct {
+	spiller *spiller
+	path    string
+	file    *os.File
+	mmap    *fileutil.MmapFile
+	written int
+
+	mtx  sync.Mutex
+	refs int
+}
+
+func (seg *spillSegment) acquire() {
+	seg.mtx.Lock()
+	defer seg.mtx.Unlock()
+	seg.refs++
+}
+
+// release removes a reference to the segment, and deletes the segment once
+// it isn't referenced anymore.
+func (seg *spillSegment) release() {
+	seg.mtx.Lock()
+	defer seg.mtx.Unlock()
+	seg.refs--
+	if seg.refs > 0 {
+		return
+	}
+
+	if err := seg.mmap.Close(); err != nil {
+		level.Warn(util_log.Logger).Log("msg", "failed to unmap spill segment", "path", seg.path, "err", err)
+	}
+	if err := seg.file.Close(); err != nil {
+		level.Warn(util_log.Logger).Log("msg", "failed to close spill segment", "path", seg.path, "err", err)
+	}
+	if err := os.Remove(seg.path); err != nil {
+		level.Warn(util_log.Logger).Log("msg", "failed to remove spill segment", "path", seg.path, "err", err)
+	}
+	seg.spiller.metrics.spillSegments.Dec()
+}
+
+// releaseChunk removes the reference of a chunk removed from its stream.
+func (seg *spillSegment) releaseChunk(size int) {
+	seg.spiller.metrics.spilledChunks.Dec()
+	seg.spiller.metrics.spilledBytes.Sub(float64(size))
+	seg.release()
+}
+
+// spillStream spills the closed chunks of the stream once the stream has been
+// idle for the configured period.
+func (i *Ingester) spillStream(stream *stream) {
+	stream.chunkMtx.Lock()
+	defer stream.chunkMtx.Unlock()
+
+	if len(stream.chunks) == 0 || time.Since(stream.chunks[len(stream.chunks)-1].lastUpdated) < i.cfg.Spill.IdlePeriod {
+		return
+	}
+
+	for j := range stream.chunks {
+		c := &stream.chunks[j]
+		if !c.closed || c.spilled != nil {
+			continue
+		}
+		// A chunk is marked as closed before its head block is cut when it is
+		// flushed, so make sure the head block is part of the spilled bytes.
+		if err := c.chunk.Close(); err != nil {
+			level.Warn(i.logger).Log("msg", "failed to close chunk before spilling it", "stream", stream.labelsString, "err", err)
+			return
+		}
+		spilled, seg, err := i.spiller.Spill(c.chunk, i.cfg.BlockSize, i.cfg.TargetChunkSize)
+		if err != nil {
+			level.Warn(i.logger).Log("msg", "failed to spill chunk", "stream", stream.labelsString, "err", err)
+			return
+		}
+		c.chunk = spilled
+		c.spilled = seg
+	}
+}
+
+// spilledEntryIterator keeps the segment of a spilled chunk until it is closed.
+type spilledEntryIterator struct {
+	iter.EntryIterator
+	seg  *spillSegment
+	once sync.Once
+}
+
+func (it *spilledEntryIterator) Close() error {
+	err := it.EntryIterator.Close()
+	it.once.Do(it.seg.release)
+	return err
+}
+
+// spilledSampleIterator keeps the segment of a spilled chunk until it is closed.
+type spilledSampleIterator struct {
+	iter.SampleIterator
+	seg  *spillSegment
+	once sync.Once
+}
+
+func (it *spilledSampleIterator) Close() error {
+	err := it.SampleIterator.Close()
+	it.once.Do(it.seg.release)
+	return err
+}
@@ -0,0 +1,90 @@
+package ingester
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	gokit_log "github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/iter"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql/log"
+	"github.com/grafana/loki/pkg/util/constants"
+	"github.com/grafana/loki/pkg/validation"
+)
+
+func TestSpillStream(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.Spill = SpillConfig{Enabled: true, Dir: t.TempDir(), IdlePeriod: time.Minute, SegmentSize: 1 << 20}
+	require.NoError(t, cfg.Validate())
+
+	metrics := newIngesterMetrics(prometheus.NewRegistry(), constants.Loki)
+	spiller, err := newSpiller(cfg.Spill, nil, metrics)
+	require.NoError(t, err)
+	i := &Ingester{
+		cfg:              *cfg,
+		logger:           gokit_log.NewNopLogger(),
+		metrics:          metrics,
+		spiller:          spiller,
+		replayController: newReplayController(metrics, cfg.WAL, nil),
+	}
+
+	limits, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
+	require.NoError(t, err)
+	limiter := NewLimiter(limits, NilMetrics, &ringCountMock{count: 1}, 1)
+	chunkfmt, headfmt := defaultChunkFormat(t)
+	s := newStream(chunkfmt, headfmt, &i.cfg, limiter, "fake", model.Fingerprint(0), labels.Labels{{Name: "foo", Value: "bar"}}, true, NewStreamRateCalculator(), metrics, nil)
+
+	var entries []logproto.Entry
+	for j := 0; j < 100; j++ {
+		entries = append(entries, logproto.Entry{Timestamp: time.Unix(int64(j), 0), Line: "line"})
+	}
+	_, err = s.Push(context.Background(), entries, recordPool.GetRecord(), 0, true, false)
+	require.NoError(t, err)
+
+	// The stream isn't idle yet.
+	s.chunks[0].closed = true
+	i.spillStream(s)
+	require.Nil(t, s.chunks[0].spilled)
+
+	s.chunks[0].lastUpdated = time.Now().Add(-time.Hour)
+	i.spillStream(s)
+	seg := s.chunks[0].spilled
+	require.NotNil(t, seg)
+	require.FileExists(t, seg.path)
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.spilledChunks))
+	require.Equal(t, float64(s.chunks[0].chunk.CompressedSize()), testutil.ToFloat64(metrics.spilledBytes))
+
+	// Queries read the spilled chunk.
+	it, err := s.Iterator(context.Background(), nil, time.Unix(0, 0), time.Unix(100, 0), logproto.FORWARD, log.NewNoopPipeline().ForStream(s.labels))
+	require.NoError(t, err)
+
+	// The chunk is removed from the stream while the query still reads it, and
+	// the current segment is replaced.
+	s.chunks[0].flushed = time.Now().Add(-time.Hour)
+	i.removeFlushedChunks(nil, s, false)
+	require.Empty(t, s.chunks)
+	require.Equal(t, 0.0, testutil.ToFloat64(metrics.spilledChunks))
+	_, err = spiller.newSegment(cfg.Spill.SegmentSize)
+	require.NoError(t, err)
+	require.FileExists(t, seg.path)
+
+	read, _, err := iter.ReadBatch(it, 1000)
+	require.NoError(t, err)
+	require.Len(t, read.Streams, 1)
+	require.Equal(t, entries, read.Streams[0].Entries)
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.chunkReads.WithLabelValues("spill")))
+
+	// The segment is deleted once the query is done.
+	require.NoError(t, it.Close())
+	_, err = os.Stat(seg.path)
+	require.True(t, os.IsNotExist(err))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.spillSegments))
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/grafana/dskit/httpgrpc"
 	"github.com/opentracing/opentracing-go"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
 
@@ -71,6 +72,7 @@ type stream struct {
 	entryCt int64
 
 	unorderedWrites      bool
+	outOfOrderWindow     OutOfOrderWindowStrategy
 	streamRateCalculator *StreamRateCalculator
 
 	writeFailures *writefailures.Manager
@@ -87,6 +89,9 @@ type chunkDesc struct {
 	reason  string
 
 	lastUpdated time.Time
+
+	// spilled is the segment the chunk is spilled to, if any.
+	spilled *spillSegment
 }
 
 type entryWithError struct {
@@ -98,7 +103,7 @@ func newStream(
 	chunkFormat byte,
 	headBlockFmt chunkenc.HeadBlockFmt,
 	cfg *Config,
-	limits RateLimiterStrategy,
+	limits StreamLimiterStrategy,
 	tenant string,
 	fp model.Fingerprint,
 	labels labels.Labels,
@@ -109,7 +114,7 @@ func newStream(
 ) *stream {
 	hashNoShard, _ := labels.HashWithoutLabels(make([]byte, 0, 1024), ShardLbName)
 	return &stream{
-		limiter:              NewStreamRateLimiter(limits, tenant, 10*time.Second),
+		limiter:              NewStreamRateLimiter(limits, tenant, labels, 10*time.Second),
 		cfg:                  cfg,
 		fp:                   fp,
 		labels:               labels,
@@ -122,6 +127,7 @@ func newStream(
 		streamRateCalculator: streamRateCalculator,
 
 		unorderedWrites:      unorderedWrites,
+		outOfOrderWindow:     limits,
 		writeFailures:        writeFailures,
 		chunkFormat:          chunkFormat,
 		chunkHeadBlockFormat: headBlockFmt,
@@ -133,7 +139,7 @@ func newStream(
 // Must hold chunkMtx
 // DEPRECATED: chunk transfers are no longer suggested and remain for compatibility.
 func (s *stream) consumeChunk(_ context.Context, chunk *logproto.Chunk) error {
-	c, err := chunkenc.NewByteChunk(chunk.Data, s.cfg.BlockSize, s.cfg.TargetChunkSize)
+	c, err := chunkenc.NewByteChunkWithDictionaries(chunk.Data, s.cfg.BlockSize, s.cfg.TargetChunkSize, s.cfg.zstdDictionaries())
 	if err != nil {
 		return err
 	}
@@ -162,6 +168,11 @@ func (s *stream) setChunks(chunks []Chunk) (bytesAdded, entriesAdded int, err er
 }
 
 func (s *stream) NewChunk() *chunkenc.MemChunk {
+	if s.cfg.parsedEncoding == chunkenc.EncZstd && s.cfg.ZstdDictionaries != nil {
+		if dict := s.cfg.ZstdDictionaries.Dictionary(s.tenant); dict != nil {
+			return chunkenc.NewMemChunkWithDictionary(s.chunkFormat, dict, s.chunkHeadBlockFormat, s.cfg.BlockSize, s.cfg.TargetChunkSize)
+		}
+	}
 	return chunkenc.NewMemChunk(s.chunkFormat, s.cfg.parsedEncoding, s.chunkHeadBlockFormat, s.cfg.BlockSize, s.cfg.TargetChunkSize)
 }
 
@@ -325,7 +336,7 @@ func (s *stream) storeEntries(ctx context.Context, entries []logproto.Entry) (in
 	storedEntries := make([]logproto.Entry, 0, len(entries))
 	for i := 0; i < len(entries); i++ {
 		chunk := &s.chunks[len(s.chunks)-1]
-		if chunk.closed || !chunk.chunk.SpaceFor(&entries[i]) || s.cutChunkForSynchronization(entries[i].Timestamp, s.highestTs, chunk, s.cfg.SyncPeriod, s.cfg.SyncMinUtilization) {
+		if chunk.closed || !chunk.chunk.SpaceFor(&entries[i]) || s.cutChunkForSynchronization(entries[i].Timestamp, s.highestTs, chunk, s.cfg.SyncPeriod, s.cfg.SyncMinUtilization) || s.cutChunkForMaxAge(entries[i].Timestamp, chunk) {
 			chunk = s.cutChunk(ctx)
 		}
 
@@ -364,7 +375,19 @@ func (s *stream) validateEntries(entries []logproto.Entry, isReplay, rateLimitWh
 		lastLine                             = s.lastLine
 		highestTs                            = s.highestTs
 		toStore                              = make([]logproto.Entry, 0, len(entries))
+		checkWindow                          = !isReplay && s.unorderedWrites
+		window                               time.Duration
+		lateness                             prometheus.Observer
 	)
+	if checkWindow {
+		var windowPolicy string
+		window, windowPolicy = s.outOfOrderWindow.OutOfOrderWindow(s.tenant, s.labels)
+		if window == 0 {
+			// The default validity window for unordered writes is 1/2 * max-chunk-age.
+			window = s.cfg.MaxChunkAge / 2
+		}
+		lateness = s.metrics.outOfOrderLateness.WithLabelValues(s.tenant, windowPolicy)
+	}
 
 	for i := range entries {
 		// If this entry matches our last appended line's timestamp and contents,
@@ -391,14 +414,17 @@ func (s *stream) validateEntries(entries []logproto.Entry, isReplay, rateLimitWh
 			continue
 		}
 
-		// The validity window for unordered writes is the highest timestamp present minus 1/2 * max-chunk-age.
-		cutoff := highestTs.Add(-s.cfg.MaxChunkAge / 2)
-		if !isReplay && s.unorderedWrites && !highestTs.IsZero() && cutoff.After(entries[i].Timestamp) {
-			failedEntriesWithError = append(failedEntriesWithError, entryWithError{&entries[i], chunkenc.ErrTooFarBehind(cutoff)})
-			s.writeFailures.Log(s.tenant, fmt.Errorf("%w for stream %s", failedEntriesWithError[len(failedEntriesWithError)-1].e, s.labels))
-			outOfOrderSamples++
-			outOfOrderBytes += lineBytes
-			continue
+		// The validity window for unordered writes is the highest timestamp present minus the out-of-order window.
+		if checkWindow && !highestTs.IsZero() && entries[i].Timestamp.Before(highestTs) {
+			lateness.Observe(highestTs.Sub(entries[i].Timestamp).Seconds())
+
+			if highestTs.Add(-window).After(entries[i].Timestamp) {
+				failedEntriesWithError = append(failedEntriesWithError, entryWithError{&entries[i], chunkenc.ErrEntryTooFarBehind(entries[i].Timestamp, highestTs, window)})
+				s.writeFailures.Log(s.tenant, fmt.Errorf("%w for stream %s", failedEntriesWithError[len(failedEntriesWithError)-1].e, s.labels))
+				outOfOrderSamples++
+				outOfOrderBytes += lineBytes
+				continue
+			}
 		}
 
 		validBytes += lineBytes
@@ -443,6 +469,10 @@ func (s *stream) reportMetrics(outOfOrderSamples, outOfOrderBytes, rateLimitedSa
 	if rateLimitedSamples > 0 {
 		validation.DiscardedSamples.WithLabelValues(validation.StreamRateLimit, s.tenant).Add(float64(rateLimitedSamples))
 		validation.DiscardedBytes.WithLabelValues(validation.StreamRateLimit, s.tenant).Add(float64(rateLimitedBytes))
+		if policy := s.limiter.Policy(); policy != "" {
+			s.metrics.streamPolicyRateLimitedSamples.WithLabelValues(s.tenant, policy).Add(float64(rateLimitedSamples))
+			s.metrics.streamPolicyRateLimitedBytes.WithLabelValues(s.tenant, policy).Add(float64(rateLimitedBytes))
+		}
 	}
 }
 
@@ -502,6 +532,20 @@ func (s *stream) cutChunkForSynchronization(entryTimestamp, latestTs time.Time,
 	return false
 }
 
+// Returns true, if the entry would make the chunk span more than the max chunk age. The flush
+// loop closes such chunks whenever it checks them, while cutting them before adding the entry
+// makes the ingesters cut the chunk for this stream at the same entry.
+func (s *stream) cutChunkForMaxAge(entryTimestamp time.Time, c *chunkDesc) bool {
+	if !s.cfg.DeterministicChunkCuts || c.chunk.Size() == 0 {
+		return false
+	}
+	if from, _ := c.chunk.Bounds(); entryTimestamp.Sub(from) <= s.cfg.MaxChunkAge {
+		return false
+	}
+	c.reason = flushReasonMaxAge
+	return true
+}
+
 func (s *stream) Bounds() (from, to time.Time) {
 	s.chunkMtx.RLock()
 	defer s.chunkMtx.RUnlock()
@@ -539,6 +583,11 @@ func (s *stream) Iterator(ctx context.Context, statsCtx *stats.Context, from, th
 			return nil, err
 		}
 		if itr != nil {
+			s.metrics.observeChunkRead(c.spilled != nil)
+			if c.spilled != nil {
+				c.spilled.acquire()
+				itr = &spilledEntryIterator{EntryIterator: itr, seg: c.spilled}
+			}
 			iterators = append(iterators, itr)
 		}
 	}
@@ -582,6 +631,11 @@ func (s *stream) SampleIterator(ctx context.Context, statsCtx *stats.Context, fr
 		lastMax = maxt
 
 		if itr := c.chunk.SampleIterator(ctx, from, through, extractor); itr != nil {
+			s.metrics.observeChunkRead(c.spilled != nil)
+			if c.spilled != nil {
+				c.spilled.acquire()
+				itr = &spilledSampleIterator{SampleIterator: itr, seg: c.spilled}
+			}
 			iterators = append(iterators, itr)
 		}
 	}
@@ -10,15 +10,20 @@ import (
 	"time"
 
 	"github.com/grafana/dskit/httpgrpc"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/loki/pkg/chunkenc"
+	"github.com/grafana/loki/pkg/chunkenc/testdata"
 	"github.com/grafana/loki/pkg/iter"
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/logql/log"
 	"github.com/grafana/loki/pkg/logql/syntax"
+	"github.com/grafana/loki/pkg/util/constants"
 	"github.com/grafana/loki/pkg/util/flagext"
 	"github.com/grafana/loki/pkg/validation"
 )
@@ -84,9 +89,10 @@ func TestMaxReturnedStreamsErrors(t *testing.T) {
 			var expected bytes.Buffer
 			for i := 0; i < tc.expectErrs; i++ {
 				fmt.Fprintf(&expected,
-					"entry with timestamp %s ignored, reason: 'entry too far behind, oldest acceptable timestamp is: %s',\n",
+					"entry with timestamp %s ignored, reason: 'entry too far behind, oldest acceptable timestamp is: %s, entry is %s behind the newest entry of the stream and the out-of-order window is 0s',\n",
 					time.Unix(int64(i), 0).String(),
 					time.Unix(int64(numLogs), 0).Format(time.RFC3339),
+					time.Duration(numLogs-i)*time.Second,
 				)
 			}
 
@@ -411,6 +417,110 @@ func TestPushRateLimit(t *testing.T) {
 	require.Contains(t, err.Error(), (&validation.ErrStreamRateLimit{RateLimit: l.PerStreamRateLimit, Labels: s.labelsString, Bytes: flagext.ByteSize(len(entries[1].Line))}).Error())
 }
 
+func TestPushRateLimitPolicy(t *testing.T) {
+	l := defaultLimitsTestConfig()
+	l.PerStreamRateLimit = 100
+	l.PerStreamRateLimitBurst = 100
+	l.StreamRateLimitPolicies = []validation.StreamRateLimitPolicy{
+		{Name: "foo", Selector: `{foo="bar"}`, Rate: 10, Burst: 10},
+	}
+	require.NoError(t, l.Validate())
+	limits, err := validation.NewOverrides(l, nil)
+	require.NoError(t, err)
+	limiter := NewLimiter(limits, NilMetrics, &ringCountMock{count: 1}, 1)
+	metrics := newIngesterMetrics(prometheus.NewRegistry(), constants.Loki)
+
+	chunkfmt, headfmt := defaultChunkFormat(t)
+
+	s := newStream(
+		chunkfmt,
+		headfmt,
+		defaultConfig(),
+		limiter,
+		"fake",
+		model.Fingerprint(0),
+		labels.Labels{
+			{Name: "foo", Value: "bar"},
+		},
+		true,
+		NewStreamRateCalculator(),
+		metrics,
+		nil,
+	)
+
+	entries := []logproto.Entry{
+		{Timestamp: time.Unix(1, 0), Line: "aaaaaaaaaa"},
+		{Timestamp: time.Unix(1, 0), Line: "aaaaaaaaab"},
+	}
+	_, err = s.Push(context.Background(), entries, recordPool.GetRecord(), 0, true, false)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), (&validation.ErrStreamRateLimit{RateLimit: 10, Labels: s.labelsString, Bytes: flagext.ByteSize(len(entries[1].Line))}).Error())
+	require.Equal(t, "foo", s.limiter.Policy())
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.streamPolicyRateLimitedSamples.WithLabelValues("fake", "foo")))
+	require.Equal(t, 10.0, testutil.ToFloat64(metrics.streamPolicyRateLimitedBytes.WithLabelValues("fake", "foo")))
+}
+
+func TestPushOutOfOrderWindow(t *testing.T) {
+	l := defaultLimitsTestConfig()
+	l.OutOfOrderWindow = model.Duration(time.Minute)
+	l.OutOfOrderWindowPolicies = []validation.OutOfOrderWindowPolicy{
+		{Name: "agents", Selector: `{agent="late"}`, Window: model.Duration(time.Hour)},
+	}
+	require.NoError(t, l.Validate())
+	limits, err := validation.NewOverrides(l, nil)
+	require.NoError(t, err)
+	limiter := NewLimiter(limits, NilMetrics, &ringCountMock{count: 1}, 1)
+
+	chunkfmt, headfmt := defaultChunkFormat(t)
+
+	for _, tc := range []struct {
+		name           string
+		labels         labels.Labels
+		policy         string
+		expectRejected int
+	}{
+		{"tenant window", labels.Labels{{Name: "agent", Value: "fast"}}, "", 2},
+		{"policy window", labels.Labels{{Name: "agent", Value: "late"}}, "agents", 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			metrics := newIngesterMetrics(prometheus.NewRegistry(), constants.Loki)
+			s := newStream(
+				chunkfmt,
+				headfmt,
+				defaultConfig(),
+				limiter,
+				"fake",
+				model.Fingerprint(0),
+				tc.labels,
+				true,
+				NewStreamRateCalculator(),
+				metrics,
+				nil,
+			)
+
+			newest := time.Unix(10000, 0)
+			_, err := s.Push(context.Background(), []logproto.Entry{{Timestamp: newest, Line: "newest"}}, recordPool.GetRecord(), 0, true, false)
+			require.NoError(t, err)
+
+			_, err = s.Push(context.Background(), []logproto.Entry{
+				{Timestamp: newest.Add(-30 * time.Second), Line: "late"},
+				{Timestamp: newest.Add(-30 * time.Minute), Line: "later"},
+				{Timestamp: newest.Add(-2 * time.Hour), Line: "too late"},
+			}, recordPool.GetRecord(), 0, true, false)
+			require.Error(t, err)
+			window, _ := limiter.OutOfOrderWindow("fake", tc.labels)
+			require.Contains(t, err.Error(), chunkenc.ErrEntryTooFarBehind(newest.Add(-2*time.Hour), newest, window).Error())
+			require.Contains(t, err.Error(), fmt.Sprintf("total ignored: %d out of 3", tc.expectRejected))
+
+			// The lateness of both the accepted and rejected entries is observed.
+			var m dto.Metric
+			require.NoError(t, metrics.outOfOrderLateness.WithLabelValues("fake", tc.policy).(prometheus.Histogram).Write(&m))
+			require.Equal(t, uint64(3), m.GetHistogram().GetSampleCount())
+			require.Equal(t, 1, testutil.CollectAndCount(metrics.outOfOrderLateness))
+		})
+	}
+}
+
 func TestPushRateLimitAllOrNothing(t *testing.T) {
 	l := validation.Limits{
 		PerStreamRateLimit:      10,
@@ -527,7 +637,7 @@ func Benchmark_PushStream(b *testing.B) {
 	s := newStream(chunkfmt, headfmt, &Config{MaxChunkAge: 24 * time.Hour}, limiter, "fake", model.Fingerprint(0), ls, true, NewStreamRateCalculator(), NilMetrics, nil)
 	expr, err := syntax.ParseLogSelector(`{namespace="loki-dev"}`, true)
 	require.NoError(b, err)
-	t, err := newTailer("foo", expr, &fakeTailServer{}, 10)
+	t, err := newTailer("foo", expr, &fakeTailServer{}, 10, NilMetrics)
 	require.NoError(b, err)
 
 	go t.loop()
@@ -558,3 +668,99 @@ func defaultChunkFormat(t testing.TB) (byte, chunkenc.HeadBlockFmt) {
 
 	return chunkfmt, headfmt
 }
+
+func TestDeterministicChunkCuts(t *testing.T) {
+	limits, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
+	require.NoError(t, err)
+	limiter := NewLimiter(limits, NilMetrics, &ringCountMock{count: 1}, 1)
+	chunkfmt, headfmt := defaultChunkFormat(t)
+
+	cfg := defaultConfig()
+	cfg.MaxChunkAge = time.Minute
+	cfg.DeterministicChunkCuts = true
+
+	var entries []logproto.Entry
+	for i := 0; i < 180; i++ {
+		entries = append(entries, logproto.Entry{Timestamp: time.Unix(int64(i), 0), Line: fmt.Sprintf("line %d", i)})
+	}
+
+	// Replicas receive the same entries in different batches, and cut their
+	// chunks at the same entries.
+	var bounds [][]time.Time
+	for _, batchSize := range []int{1, 7, 180} {
+		s := newStream(chunkfmt, headfmt, cfg, limiter, "fake", model.Fingerprint(0), labels.Labels{{Name: "foo", Value: "bar"}}, true, NewStreamRateCalculator(), NilMetrics, nil)
+		for i := 0; i < len(entries); i += batchSize {
+			_, err := s.Push(context.Background(), entries[i:min(i+batchSize, len(entries))], recordPool.GetRecord(), 0, true, false)
+			require.NoError(t, err)
+		}
+
+		var b []time.Time
+		for _, c := range s.chunks {
+			from, to := c.chunk.Bounds()
+			b = append(b, from, to)
+		}
+		bounds = append(bounds, b)
+
+		i := &Ingester{cfg: *cfg}
+		require.Len(t, s.chunks, 3)
+		for _, c := range s.chunks[:2] {
+			shouldFlush, reason := i.shouldFlushChunk(&c)
+			require.True(t, shouldFlush)
+			require.Equal(t, flushReasonMaxAge, reason)
+		}
+	}
+	require.Equal(t, []time.Time{time.Unix(0, 0), time.Unix(60, 0), time.Unix(61, 0), time.Unix(121, 0), time.Unix(122, 0), time.Unix(179, 0)}, bounds[0])
+	require.Equal(t, bounds[0], bounds[1])
+	require.Equal(t, bounds[0], bounds[2])
+}
+
+type zstdDictionaryProviderFunc func(tenant string) *chunkenc.Dictionary
+
+func (f zstdDictionaryProviderFunc) Dictionary(tenant string) *chunkenc.Dictionary {
+	return f(tenant)
+}
+
+func (f zstdDictionaryProviderFunc) Dictionaries() *chunkenc.Dictionaries {
+	return nil
+}
+
+func TestNewChunkWithZstdDictionary(t *testing.T) {
+	limits, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
+	require.NoError(t, err)
+	limiter := NewLimiter(limits, NilMetrics, &ringCountMock{count: 1}, 1)
+	chunkfmt, headfmt := defaultChunkFormat(t)
+
+	b, err := chunkenc.TrainDictionary(100000, testdata.LogsBytes, 4<<10)
+	require.NoError(t, err)
+	dict, err := chunkenc.NewDictionary(b)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name     string
+		encoding chunkenc.Encoding
+		tenant   string
+		expected chunkenc.Encoding
+	}{
+		{name: "dictionary", encoding: chunkenc.EncZstd, tenant: "trained", expected: chunkenc.EncZstdDict},
+		{name: "no dictionary", encoding: chunkenc.EncZstd, tenant: "untrained", expected: chunkenc.EncZstd},
+		{name: "not zstd", encoding: chunkenc.EncSnappy, tenant: "trained", expected: chunkenc.EncSnappy},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := defaultConfig()
+			cfg.parsedEncoding = tc.encoding
+			cfg.ZstdDictionaries = zstdDictionaryProviderFunc(func(tenant string) *chunkenc.Dictionary {
+				if tenant == "trained" {
+					return dict
+				}
+				return nil
+			})
+
+			s := newStream(chunkfmt, headfmt, cfg, limiter, tc.tenant, model.Fingerprint(0), labels.Labels{{Name: "foo", Value: "bar"}}, true, NewStreamRateCalculator(), NilMetrics, nil)
+			c := s.NewChunk()
+			require.Equal(t, tc.expected, c.Encoding())
+			if tc.expected == chunkenc.EncZstdDict {
+				require.Same(t, dict, c.Dictionary())
+			}
+		})
+	}
+}
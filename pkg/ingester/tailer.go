@@ -52,10 +52,11 @@ type tailer struct {
 	droppedStreams    []*logproto.DroppedStream
 	maxDroppedStreams int
 
-	conn TailServer
+	conn    TailServer
+	metrics *ingesterMetrics
 }
 
-func newTailer(orgID string, expr syntax.LogSelectorExpr, conn TailServer, maxDroppedStreams int) (*tailer, error) {
+func newTailer(orgID string, expr syntax.LogSelectorExpr, conn TailServer, maxDroppedStreams int, metrics *ingesterMetrics) (*tailer, error) {
 	// Make sure we can build a pipeline. The stream processing code doesn't have a place to handle
 	// this error so make sure we handle it here.
 	pipeline, err := expr.Pipeline()
@@ -75,6 +76,7 @@ func newTailer(orgID string, expr syntax.LogSelectorExpr, conn TailServer, maxDr
 		id:                generateUniqueID(orgID, expr.String()),
 		closeChan:         make(chan struct{}),
 		pipeline:          pipeline,
+		metrics:           metrics,
 	}, nil
 }
 
@@ -267,6 +269,8 @@ func (t *tailer) dropStream(stream logproto.Stream) {
 		t.blockedAt = &blockedAt
 	}
 
+	t.metrics.tailDroppedEntries.WithLabelValues(t.orgID).Add(float64(len(stream.Entries)))
+
 	if len(t.droppedStreams) >= t.maxDroppedStreams {
 		level.Info(util_log.Logger).Log("msg", "tailer dropped streams is reset", "length", len(t.droppedStreams))
 		t.droppedStreams = nil
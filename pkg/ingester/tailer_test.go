@@ -22,7 +22,7 @@ func TestTailer_RoundTrip(t *testing.T) {
 	lbs := makeRandomLabels()
 	expr, err := syntax.ParseLogSelector(lbs.String(), true)
 	require.NoError(t, err)
-	tail, err := newTailer("org-id", expr, server, 10)
+	tail, err := newTailer("org-id", expr, server, 10, NilMetrics)
 	require.NoError(t, err)
 	var wg sync.WaitGroup
 	wg.Add(1)
@@ -79,7 +79,7 @@ func TestTailer_sendRaceConditionOnSendWhileClosing(t *testing.T) {
 	for run := 0; run < runs; run++ {
 		expr, err := syntax.ParseLogSelector(stream.Labels, true)
 		require.NoError(t, err)
-		tailer, err := newTailer("org-id", expr, nil, 10)
+		tailer, err := newTailer("org-id", expr, nil, 10, NilMetrics)
 		require.NoError(t, err)
 		require.NotNil(t, tailer)
 
@@ -133,7 +133,7 @@ func Test_dropstream(t *testing.T) {
 		t.Run(c.name, func(t *testing.T) {
 			expr, err := syntax.ParseLogSelector(`{app="foo"} |= "foo"`, true)
 			require.NoError(t, err)
-			tail, err := newTailer("foo", expr, &fakeTailServer{}, maxDroppedStreams)
+			tail, err := newTailer("foo", expr, &fakeTailServer{}, maxDroppedStreams, NilMetrics)
 			require.NoError(t, err)
 
 			for i := 0; i < c.drop; i++ {
@@ -201,7 +201,7 @@ func (f *fakeTailServer) Reset() {
 func Test_TailerSendRace(t *testing.T) {
 	expr, err := syntax.ParseLogSelector(`{app="foo"} |= "foo"`, true)
 	require.NoError(t, err)
-	tail, err := newTailer("foo", expr, &fakeTailServer{}, 10)
+	tail, err := newTailer("foo", expr, &fakeTailServer{}, 10, NilMetrics)
 	require.NoError(t, err)
 
 	var wg sync.WaitGroup
@@ -339,7 +339,7 @@ func Test_StructuredMetadata(t *testing.T) {
 			var server fakeTailServer
 			expr, err := syntax.ParseLogSelector(tc.query, true)
 			require.NoError(t, err)
-			tail, err := newTailer("foo", expr, &server, 10)
+			tail, err := newTailer("foo", expr, &server, 10, NilMetrics)
 			require.NoError(t, err)
 
 			var wg sync.WaitGroup
@@ -0,0 +1,98 @@
+package kafka
+
+import (
+	"errors"
+	"flag"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/grafana/dskit/flagext"
+)
+
+// Config configures the Kafka-backed write path, where distributors produce
+// the pushed log streams to a Kafka topic and ingesters consume them.
+type Config struct {
+	Enabled    bool `yaml:"enabled"`
+	WriteAhead bool `yaml:"write_ahead"`
+
+	Address       flagext.StringSliceCSV `yaml:"address"`
+	Topic         string                 `yaml:"topic"`
+	ClientID      string                 `yaml:"client_id"`
+	Version       string                 `yaml:"version"`
+	ConsumerGroup string                 `yaml:"consumer_group"`
+	QueueSize     int                    `yaml:"queue_size"`
+
+	DialTimeout    time.Duration `yaml:"dial_timeout"`
+	WriteTimeout   time.Duration `yaml:"write_timeout"`
+	CommitInterval time.Duration `yaml:"commit_interval"`
+}
+
+func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "kafka.enabled", false, "Enable producing the pushed log streams to Kafka. Unless write_ahead is enabled, the streams are still pushed to the ingesters, and Kafka only receives a copy of them.")
+	f.BoolVar(&cfg.WriteAhead, "kafka.write-ahead", false, "Use Kafka as a write-ahead queue between distributors and ingesters. Distributors produce the pushed log streams to Kafka instead of pushing them to the ingesters, and ingesters consume them from Kafka. Requires kafka.enabled.")
+	f.Var(&cfg.Address, "kafka.address", "Comma separated list of Kafka broker addresses.")
+	f.StringVar(&cfg.Topic, "kafka.topic", "loki", "Kafka topic the log streams are produced to and consumed from. The stream hash is used as the record key, so all the entries of a stream go to the same partition.")
+	f.StringVar(&cfg.ClientID, "kafka.client-id", "loki", "Client ID used when connecting to Kafka.")
+	f.StringVar(&cfg.Version, "kafka.version", "2.1.0", "Kafka protocol version used by the client.")
+	f.StringVar(&cfg.ConsumerGroup, "kafka.consumer-group", "loki-ingesters", "Consumer group shared by the ingesters. The partitions of the topic are balanced across the members of the group. When zone awareness is enabled, the ingesters of every zone use their own group, suffixed with the zone, so each record is appended by one ingester per zone. Ingesters with a replication factor greater than 1 must set their zone.")
+	f.IntVar(&cfg.QueueSize, "kafka.queue-size", 1000, "Number of pushes a distributor queues to be produced to Kafka when write_ahead is disabled. Pushes are dropped when the queue is full, so Kafka doesn't slow down the pushes to the ingesters.")
+	f.DurationVar(&cfg.DialTimeout, "kafka.dial-timeout", 2*time.Second, "Timeout when connecting to a Kafka broker.")
+	f.DurationVar(&cfg.WriteTimeout, "kafka.write-timeout", 10*time.Second, "Timeout when producing records to Kafka.")
+	f.DurationVar(&cfg.CommitInterval, "kafka.commit-interval", time.Second, "How often ingesters commit the offsets of the records appended to their WAL.")
+}
+
+func (cfg *Config) Validate() error {
+	if !cfg.Enabled {
+		if cfg.WriteAhead {
+			return errors.New("kafka write_ahead requires kafka to be enabled")
+		}
+		return nil
+	}
+	if len(cfg.Address) == 0 {
+		return errors.New("kafka address must be set")
+	}
+	if cfg.Topic == "" {
+		return errors.New("kafka topic must be set")
+	}
+	if _, err := sarama.ParseKafkaVersion(cfg.Version); err != nil {
+		return err
+	}
+	if !cfg.WriteAhead && cfg.QueueSize <= 0 {
+		return errors.New("kafka queue_size must be positive")
+	}
+	return nil
+}
+
+// ConsumerGroupFor returns the consumer group of the ingesters of the given
+// zone. Every zone consumes all the records, so the records are replicated
+// once per zone.
+func (cfg *Config) ConsumerGroupFor(zone string) string {
+	if zone == "" {
+		return cfg.ConsumerGroup
+	}
+	return cfg.ConsumerGroup + "-" + zone
+}
+
+func (cfg *Config) saramaConfig() (*sarama.Config, error) {
+	version, err := sarama.ParseKafkaVersion(cfg.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	config := sarama.NewConfig()
+	config.ClientID = cfg.ClientID
+	config.Version = version
+	config.Net.DialTimeout = cfg.DialTimeout
+	config.Net.WriteTimeout = cfg.WriteTimeout
+
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Producer.Return.Successes = true
+	config.Producer.Timeout = cfg.WriteTimeout
+	config.Producer.Partitioner = sarama.NewHashPartitioner
+
+	config.Consumer.Offsets.Initial = sarama.OffsetOldest
+	config.Consumer.Offsets.AutoCommit.Enable = true
+	config.Consumer.Offsets.AutoCommit.Interval = cfg.CommitInterval
+	config.Consumer.Group.Rebalance.Strategy = sarama.BalanceStrategySticky
+	return config, nil
+}
@@ -0,0 +1,63 @@
+package kafka
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/Shopify/sarama"
+
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+// tenantHeader is the record header holding the tenant of the log stream.
+const tenantHeader = "tenant"
+
+// Record is a log stream to be produced to Kafka, along with the hash used to
+// pick its partition.
+type Record struct {
+	HashKey uint32
+	Stream  logproto.Stream
+}
+
+// Encode converts a log stream of the given tenant into a Kafka message.
+// The message key is the stream hash, so all the entries of a stream end up in
+// the same partition and are consumed in order.
+func Encode(topic, tenant string, record Record) (*sarama.ProducerMessage, error) {
+	req := logproto.PushRequest{Streams: []logproto.Stream{record.Stream}}
+	value, err := req.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	key := make([]byte, 4)
+	binary.BigEndian.PutUint32(key, record.HashKey)
+
+	return &sarama.ProducerMessage{
+		Topic:   topic,
+		Key:     sarama.ByteEncoder(key),
+		Value:   sarama.ByteEncoder(value),
+		Headers: []sarama.RecordHeader{{Key: []byte(tenantHeader), Value: []byte(tenant)}},
+	}, nil
+}
+
+// Decode converts a consumed Kafka message back into the tenant and the push
+// request it was produced from.
+func Decode(msg *sarama.ConsumerMessage) (string, *logproto.PushRequest, error) {
+	var tenant string
+	for _, h := range msg.Headers {
+		if h != nil && string(h.Key) == tenantHeader {
+			tenant = string(h.Value)
+			break
+		}
+	}
+	if tenant == "" {
+		return "", nil, errors.New("missing tenant header")
+	}
+
+	var req logproto.PushRequest
+	if err := req.Unmarshal(msg.Value); err != nil {
+		return "", nil, fmt.Errorf("failed to unmarshal push request: %w", err)
+	}
+	return tenant, &req, nil
+}
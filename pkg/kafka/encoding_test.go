@@ -0,0 +1,51 @@
+package kafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	stream := logproto.Stream{
+		Labels: `{app="foo"}`,
+		Entries: []logproto.Entry{
+			{Timestamp: time.Unix(0, 1).UTC(), Line: "line 1"},
+			{Timestamp: time.Unix(0, 2).UTC(), Line: "line 2", StructuredMetadata: logproto.FromLabelsToLabelAdapters(nil)},
+		},
+	}
+
+	msg, err := Encode("topic", "tenant", Record{HashKey: 42, Stream: stream})
+	require.NoError(t, err)
+	require.Equal(t, "topic", msg.Topic)
+
+	key, err := msg.Key.Encode()
+	require.NoError(t, err)
+	require.Equal(t, []byte{0, 0, 0, 42}, key)
+
+	value, err := msg.Value.Encode()
+	require.NoError(t, err)
+
+	headers := make([]*sarama.RecordHeader, 0, len(msg.Headers))
+	for i := range msg.Headers {
+		headers = append(headers, &msg.Headers[i])
+	}
+
+	tenant, req, err := Decode(&sarama.ConsumerMessage{Key: key, Value: value, Headers: headers})
+	require.NoError(t, err)
+	require.Equal(t, "tenant", tenant)
+	require.Len(t, req.Streams, 1)
+	require.Equal(t, stream.Labels, req.Streams[0].Labels)
+	require.Equal(t, len(stream.Entries), len(req.Streams[0].Entries))
+	for i, e := range stream.Entries {
+		require.Equal(t, e.Line, req.Streams[0].Entries[i].Line)
+		require.True(t, e.Timestamp.Equal(req.Streams[0].Entries[i].Timestamp))
+	}
+
+	_, _, err = Decode(&sarama.ConsumerMessage{Key: key, Value: value})
+	require.Error(t, err)
+}
@@ -0,0 +1,209 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/backoff"
+	"github.com/grafana/dskit/httpgrpc"
+	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/user"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/util/constants"
+)
+
+var (
+	consumeBackoff = backoff.Config{
+		MinBackoff: time.Second,
+		MaxBackoff: time.Minute,
+	}
+	pushBackoff = backoff.Config{
+		MinBackoff: 100 * time.Millisecond,
+		MaxBackoff: 10 * time.Second,
+		MaxRetries: 10,
+	}
+)
+
+// Pusher appends the consumed log streams, typically to the WAL of an ingester.
+type Pusher interface {
+	Push(ctx context.Context, req *logproto.PushRequest) (*logproto.PushResponse, error)
+}
+
+// Reader consumes the log streams produced by the distributors and pushes
+// them to the Pusher. The offset of a record is only committed once the
+// Pusher accepted it, so after a restart the Reader resumes from the first
+// record which was not appended yet.
+type Reader struct {
+	services.Service
+
+	cfg    Config
+	pusher Pusher
+	logger log.Logger
+
+	newConsumerGroup func() (sarama.ConsumerGroup, error)
+	group            sarama.ConsumerGroup
+
+	consumedRecords *prometheus.CounterVec
+	droppedRecords  *prometheus.CounterVec
+	pushRetries     prometheus.Counter
+	lag             *prometheus.GaugeVec
+}
+
+// NewReader creates a Reader. It only connects to Kafka when started.
+func NewReader(cfg Config, pusher Pusher, logger log.Logger, registerer prometheus.Registerer) (*Reader, error) {
+	config, err := cfg.saramaConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Reader{
+		cfg:    cfg,
+		pusher: pusher,
+		logger: log.With(logger, "component", "kafka-reader"),
+		newConsumerGroup: func() (sarama.ConsumerGroup, error) {
+			return sarama.NewConsumerGroup(cfg.Address, cfg.ConsumerGroup, config)
+		},
+		consumedRecords: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: constants.Loki,
+			Name:      "kafka_reader_consumed_records_total",
+			Help:      "The total number of records consumed from Kafka and appended, per tenant.",
+		}, []string{"tenant"}),
+		droppedRecords: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: constants.Loki,
+			Name:      "kafka_reader_dropped_records_total",
+			Help:      "The total number of records consumed from Kafka which were dropped, per reason.",
+		}, []string{"reason"}),
+		pushRetries: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Namespace: constants.Loki,
+			Name:      "kafka_reader_push_retries_total",
+			Help:      "The total number of times appending a consumed record was retried.",
+		}),
+		lag: promauto.With(registerer).NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: constants.Loki,
+			Name:      "kafka_reader_partition_lag",
+			Help:      "The number of records of a partition which are not consumed yet.",
+		}, []string{"partition"}),
+	}
+	r.Service = services.NewBasicService(r.starting, r.running, r.stopping)
+	return r, nil
+}
+
+func (r *Reader) starting(_ context.Context) error {
+	group, err := r.newConsumerGroup()
+	if err != nil {
+		return fmt.Errorf("error creating kafka consumer group: %w", err)
+	}
+	r.group = group
+	return nil
+}
+
+func (r *Reader) running(ctx context.Context) error {
+	level.Info(r.logger).Log("msg", "starting to consume", "topic", r.cfg.Topic, "group", r.cfg.ConsumerGroup)
+
+	b := backoff.New(ctx, consumeBackoff)
+	for ctx.Err() == nil {
+		// Consume returns whenever the group is rebalanced, in which case the
+		// partitions are claimed again.
+		if err := r.group.Consume(ctx, []string{r.cfg.Topic}, r); err != nil && !errors.Is(err, context.Canceled) {
+			level.Error(r.logger).Log("msg", "error consuming from kafka, retrying", "err", err)
+			b.Wait()
+			continue
+		}
+		b.Reset()
+	}
+	return nil
+}
+
+func (r *Reader) stopping(_ error) error {
+	if r.group == nil {
+		return nil
+	}
+	// Closing the group commits the offsets marked so far.
+	return r.group.Close()
+}
+
+// Setup implements sarama.ConsumerGroupHandler.
+func (r *Reader) Setup(session sarama.ConsumerGroupSession) error {
+	level.Info(r.logger).Log("msg", "claimed partitions", "partitions", fmt.Sprint(session.Claims()[r.cfg.Topic]))
+	return nil
+}
+
+// Cleanup implements sarama.ConsumerGroupHandler.
+func (r *Reader) Cleanup(_ sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// ConsumeClaim implements sarama.ConsumerGroupHandler. The records of a
+// partition are appended in order, and marked as consumed only once appended.
+func (r *Reader) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	partition := fmt.Sprint(claim.Partition())
+	defer r.lag.DeleteLabelValues(partition)
+
+	for msg := range claim.Messages() {
+		if !r.process(session.Context(), msg) {
+			// The session ended before the record could be appended. It will
+			// be consumed again by the next owner of the partition.
+			return nil
+		}
+		session.MarkMessage(msg, "")
+		r.lag.WithLabelValues(partition).Set(float64(claim.HighWaterMarkOffset() - msg.Offset - 1))
+	}
+	return nil
+}
+
+// process pushes a consumed record, retrying a limited number of times until
+// it is appended. It returns false if the record wasn't processed because the
+// context was canceled.
+func (r *Reader) process(ctx context.Context, msg *sarama.ConsumerMessage) bool {
+	tenant, req, err := Decode(msg)
+	if err != nil {
+		level.Warn(r.logger).Log("msg", "dropping invalid record", "partition", msg.Partition, "offset", msg.Offset, "err", err)
+		r.droppedRecords.WithLabelValues("invalid").Inc()
+		return true
+	}
+
+	b := backoff.New(ctx, pushBackoff)
+	for b.Ongoing() {
+		_, err = r.pusher.Push(user.InjectOrgID(ctx, tenant), req)
+		if err == nil {
+			r.consumedRecords.WithLabelValues(tenant).Inc()
+			return true
+		}
+
+		// Client errors, e.g. out of order entries, won't succeed on retry, so
+		// the record is dropped like a rejected push. The ingester rate limits
+		// pushes exceeding the stream limits of the tenant, which won't succeed
+		// on retry either, and would block the partition for all the tenants.
+		// The streams of the record which were appended would also be
+		// duplicated by a retry.
+		if resp, ok := httpgrpc.HTTPResponseFromError(err); ok && resp.Code/100 == 4 {
+			reason := "rejected"
+			if resp.Code == http.StatusTooManyRequests {
+				reason = "limited"
+			}
+			level.Debug(r.logger).Log("msg", "dropping rejected record", "tenant", tenant, "partition", msg.Partition, "offset", msg.Offset, "reason", reason, "err", err)
+			r.droppedRecords.WithLabelValues(reason).Inc()
+			return true
+		}
+
+		level.Warn(r.logger).Log("msg", "failed to append record, retrying", "tenant", tenant, "partition", msg.Partition, "offset", msg.Offset, "err", err)
+		r.pushRetries.Inc()
+		b.Wait()
+	}
+	if ctx.Err() != nil {
+		return false
+	}
+
+	level.Error(r.logger).Log("msg", "dropping record after failing to append it", "tenant", tenant, "partition", msg.Partition, "offset", msg.Offset, "retries", b.NumRetries(), "err", err)
+	r.droppedRecords.WithLabelValues("failed").Inc()
+	return true
+}
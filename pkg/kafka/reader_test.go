@@ -0,0 +1,178 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/backoff"
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/dskit/httpgrpc"
+	"github.com/grafana/dskit/user"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+type mockPusher struct {
+	mtx    sync.Mutex
+	errs   []error
+	pushed []*logproto.PushRequest
+}
+
+func (p *mockPusher) Push(ctx context.Context, req *logproto.PushRequest) (*logproto.PushResponse, error) {
+	if _, err := user.ExtractOrgID(ctx); err != nil {
+		return nil, err
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	if len(p.errs) > 0 {
+		err := p.errs[0]
+		p.errs = p.errs[1:]
+		if err != nil {
+			return nil, err
+		}
+	}
+	p.pushed = append(p.pushed, req)
+	return &logproto.PushResponse{}, nil
+}
+
+type mockSession struct {
+	sarama.ConsumerGroupSession
+	ctx    context.Context
+	marked []int64
+}
+
+func (s *mockSession) Context() context.Context { return s.ctx }
+
+func (s *mockSession) MarkMessage(msg *sarama.ConsumerMessage, _ string) {
+	s.marked = append(s.marked, msg.Offset)
+}
+
+type mockClaim struct {
+	sarama.ConsumerGroupClaim
+	msgs chan *sarama.ConsumerMessage
+}
+
+func (c *mockClaim) Partition() int32                         { return 0 }
+func (c *mockClaim) HighWaterMarkOffset() int64               { return int64(cap(c.msgs)) }
+func (c *mockClaim) Messages() <-chan *sarama.ConsumerMessage { return c.msgs }
+
+func newMockClaim(msgs ...*sarama.ConsumerMessage) *mockClaim {
+	c := &mockClaim{msgs: make(chan *sarama.ConsumerMessage, len(msgs))}
+	for i, msg := range msgs {
+		msg.Offset = int64(i)
+		c.msgs <- msg
+	}
+	close(c.msgs)
+	return c
+}
+
+func consumerMessage(t *testing.T, tenant, line string) *sarama.ConsumerMessage {
+	msg, err := Encode("loki", tenant, Record{Stream: logproto.Stream{Labels: `{app="foo"}`, Entries: []logproto.Entry{{Line: line}}}})
+	require.NoError(t, err)
+	value, err := msg.Value.Encode()
+	require.NoError(t, err)
+	headers := []*sarama.RecordHeader{}
+	for i := range msg.Headers {
+		headers = append(headers, &msg.Headers[i])
+	}
+	return &sarama.ConsumerMessage{Topic: "loki", Value: value, Headers: headers}
+}
+
+func newTestReader(t *testing.T, pusher Pusher) *Reader {
+	cfg := Config{}
+	flagext.DefaultValues(&cfg)
+	r, err := NewReader(cfg, pusher, log.NewNopLogger(), prometheus.NewRegistry())
+	require.NoError(t, err)
+	return r
+}
+
+func TestReader_ConsumeClaim(t *testing.T) {
+	defer func(b time.Duration) { pushBackoff.MinBackoff = b }(pushBackoff.MinBackoff)
+	pushBackoff.MinBackoff = time.Millisecond
+
+	pusher := &mockPusher{errs: []error{
+		nil,
+		errors.New("ingester unavailable"), // retried
+		nil,
+		httpgrpc.Errorf(http.StatusBadRequest, "entry out of order"),       // dropped
+		httpgrpc.Errorf(http.StatusTooManyRequests, "stream rate limited"), // dropped
+	}}
+	r := newTestReader(t, pusher)
+
+	invalid := consumerMessage(t, "tenant", "invalid")
+	invalid.Headers = nil
+
+	session := &mockSession{ctx: context.Background()}
+	claim := newMockClaim(
+		consumerMessage(t, "tenant", "line 1"),
+		consumerMessage(t, "tenant", "line 2"),
+		invalid,
+		consumerMessage(t, "tenant", "line 3"),
+		consumerMessage(t, "tenant", "line 4"),
+	)
+	require.NoError(t, r.ConsumeClaim(session, claim))
+
+	// All the records are marked, once appended or dropped.
+	require.Equal(t, []int64{0, 1, 2, 3, 4}, session.marked)
+	require.Len(t, pusher.pushed, 2)
+	require.Equal(t, "line 1", pusher.pushed[0].Streams[0].Entries[0].Line)
+	require.Equal(t, "line 2", pusher.pushed[1].Streams[0].Entries[0].Line)
+	require.Equal(t, 2.0, testutil.ToFloat64(r.consumedRecords.WithLabelValues("tenant")))
+	require.Equal(t, 1.0, testutil.ToFloat64(r.pushRetries))
+	require.Equal(t, 1.0, testutil.ToFloat64(r.droppedRecords.WithLabelValues("invalid")))
+	require.Equal(t, 1.0, testutil.ToFloat64(r.droppedRecords.WithLabelValues("rejected")))
+	require.Equal(t, 1.0, testutil.ToFloat64(r.droppedRecords.WithLabelValues("limited")))
+}
+
+func TestReader_ConsumeClaimDropsAfterRetries(t *testing.T) {
+	defer func(cfg backoff.Config) { pushBackoff = cfg }(pushBackoff)
+	pushBackoff = backoff.Config{MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond, MaxRetries: 3}
+
+	pusher := &mockPusher{errs: []error{
+		errors.New("ingester unavailable"),
+		errors.New("ingester unavailable"),
+		errors.New("ingester unavailable"),
+	}}
+	r := newTestReader(t, pusher)
+
+	session := &mockSession{ctx: context.Background()}
+	claim := newMockClaim(
+		consumerMessage(t, "tenant", "line 1"),
+		consumerMessage(t, "tenant", "line 2"),
+	)
+	require.NoError(t, r.ConsumeClaim(session, claim))
+
+	// The first record is dropped once the retries are exhausted, so it
+	// doesn't block the partition.
+	require.Equal(t, []int64{0, 1}, session.marked)
+	require.Len(t, pusher.pushed, 1)
+	require.Equal(t, "line 2", pusher.pushed[0].Streams[0].Entries[0].Line)
+	require.Equal(t, 3.0, testutil.ToFloat64(r.pushRetries))
+	require.Equal(t, 1.0, testutil.ToFloat64(r.droppedRecords.WithLabelValues("failed")))
+}
+
+func TestReader_ConsumeClaimStopsWhenSessionEnds(t *testing.T) {
+	pusher := &mockPusher{errs: []error{errors.New("ingester unavailable")}}
+	r := newTestReader(t, pusher)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	session := &mockSession{ctx: ctx}
+	claim := newMockClaim(consumerMessage(t, "tenant", "line 1"))
+	require.NoError(t, r.ConsumeClaim(session, claim))
+
+	// The record wasn't appended, so it must not be marked and will be
+	// consumed again by the next owner of the partition.
+	require.Empty(t, session.marked)
+	require.Empty(t, pusher.pushed)
+}
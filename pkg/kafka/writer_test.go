@@ -0,0 +1,52 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+func TestWriter(t *testing.T) {
+	// In-process broker standing in for a Kafka cluster.
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("loki", 0, broker.BrokerID()).
+			SetLeader("loki", 1, broker.BrokerID()),
+		"ProduceRequest": sarama.NewMockProduceResponse(t).SetVersion(3),
+	})
+
+	cfg := Config{}
+	flagext.DefaultValues(&cfg)
+	cfg.Enabled = true
+	cfg.Address = []string{broker.Addr()}
+	require.NoError(t, cfg.Validate())
+
+	w, err := NewWriter(cfg, prometheus.NewRegistry())
+	require.NoError(t, err)
+	defer w.Close()
+
+	err = w.Write("tenant", []Record{
+		{HashKey: 1, Stream: logproto.Stream{Labels: `{app="foo"}`, Entries: []logproto.Entry{{Line: "foo"}}}},
+		{HashKey: 2, Stream: logproto.Stream{Labels: `{app="bar"}`, Entries: []logproto.Entry{{Line: "bar"}}}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2.0, testutil.ToFloat64(w.producedRecords.WithLabelValues("tenant")))
+	require.Equal(t, 0.0, testutil.ToFloat64(w.failedRecords.WithLabelValues("tenant")))
+
+	var produceRequests int
+	for _, rr := range broker.History() {
+		if _, ok := rr.Request.(*sarama.ProduceRequest); ok {
+			produceRequests++
+		}
+	}
+	require.NotZero(t, produceRequests)
+}
@@ -16,4 +16,6 @@ type DroppedEntry struct {
 type TailResponse struct {
 	Streams        []logproto.Stream `json:"streams"`
 	DroppedEntries []DroppedEntry    `json:"dropped_entries"`
+	// Cursor points to the last entry of Streams. It is only exposed by the v1 API.
+	Cursor *logproto.TailCursor `json:"-"`
 }
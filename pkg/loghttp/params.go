@@ -118,6 +118,14 @@ func tailDelay(r *http.Request) (uint32, error) {
 	return uint32(l), nil
 }
 
+func tailCursor(r *http.Request) (*logproto.TailCursor, error) {
+	value := r.Form.Get("cursor")
+	if value == "" {
+		return nil, nil
+	}
+	return ParseTailCursor(value)
+}
+
 // parseInt parses an int from a string
 // if the value is empty it returns a default value passed as second parameter
 func parseInt(value string, def int) (int, error) {
@@ -0,0 +1,169 @@
+package push
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/loki/pkg/push"
+	"github.com/grafana/loki/pkg/util/constants"
+)
+
+const (
+	// batchedPushVersion is the version parameter of the protobuf content type
+	// used by batched push requests, e.g. "application/x-protobuf; version=2".
+	batchedPushVersion = "2"
+
+	// pushSessionHeader identifies the session of a batched push request. The
+	// label sets defined by the requests of a session are kept until the
+	// session is idle for batchSessionIdleTimeout. Clients typically use a new
+	// session per connection.
+	pushSessionHeader = "X-Loki-Push-Session"
+
+	// defaultMaxPushBatchSessions and defaultMaxPushBatchLabelSets are the
+	// number of sessions and label sets of a tenant allowed by EmptyLimits.
+	defaultMaxPushBatchSessions  = 1000
+	defaultMaxPushBatchLabelSets = 10000
+
+	batchSessionIdleTimeout    = 10 * time.Minute
+	batchSessionsSweepInterval = time.Minute
+)
+
+var (
+	// ErrUnknownLabelSet is returned when a batched push request references a
+	// label set unknown to this server. The client must start a new session.
+	ErrUnknownLabelSet = push.ErrUnknownLabelSet
+
+	// ErrTooManyPushSessions and ErrTooManyPushLabelSets are returned when a
+	// batched push request exceeds the limits of the tenant. The client can
+	// retry once the idle sessions are forgotten.
+	ErrTooManyPushSessions  = errors.New("too many batched push sessions")
+	ErrTooManyPushLabelSets = errors.New("too many label sets defined by the batched push sessions")
+
+	errMissingPushSession = errors.New("batched push requests require the " + pushSessionHeader + " header")
+
+	batchSessionsActive = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: constants.Loki,
+		Name:      "distributor_push_batch_sessions",
+		Help:      "The number of sessions of batched push requests whose label sets are currently kept.",
+	})
+
+	batchSessions = newBatchSessionStore(batchSessionIdleTimeout)
+)
+
+type batchSessionKey struct {
+	tenant  string
+	session string
+}
+
+type batchSession struct {
+	mtx     sync.Mutex
+	decoder *push.BatchDecoder
+
+	// Guarded by the lock of the store.
+	lastSeen  time.Time
+	labelSets int
+}
+
+// batchSessionStore keeps the label sets of the sessions of batched push
+// requests, per tenant.
+type batchSessionStore struct {
+	mtx             sync.Mutex
+	sessions        map[batchSessionKey]*batchSession
+	tenantSessions  map[string]int
+	tenantLabelSets map[string]int
+	lastSweep       time.Time
+
+	idleTimeout time.Duration
+}
+
+func newBatchSessionStore(idleTimeout time.Duration) *batchSessionStore {
+	return &batchSessionStore{
+		sessions:        map[batchSessionKey]*batchSession{},
+		tenantSessions:  map[string]int{},
+		tenantLabelSets: map[string]int{},
+		idleTimeout:     idleTimeout,
+	}
+}
+
+// Decode resolves the label sets of a batched push request of the given
+// session of the tenant. A new session is only created if the tenant has
+// less than maxSessions sessions, and the sessions of the tenant define at
+// most maxLabelSets label sets in total.
+func (s *batchSessionStore) Decode(tenant, session string, maxSessions, maxLabelSets int, req *push.BatchedPushRequest, now time.Time) (*push.PushRequest, error) {
+	if session == "" {
+		return nil, errMissingPushSession
+	}
+
+	bs, err := s.session(batchSessionKey{tenant: tenant, session: session}, maxSessions, now)
+	if err != nil {
+		return nil, err
+	}
+
+	bs.mtx.Lock()
+	defer bs.mtx.Unlock()
+	if err := s.addLabelSets(tenant, bs, bs.decoder.NewLabelSets(req), maxLabelSets); err != nil {
+		return nil, err
+	}
+	return bs.decoder.Decode(req)
+}
+
+// addLabelSets accounts for the label sets newly defined by a session of the
+// tenant, unless they exceed maxLabelSets.
+func (s *batchSessionStore) addLabelSets(tenant string, bs *batchSession, n, maxLabelSets int) error {
+	if n == 0 {
+		return nil
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.tenantLabelSets[tenant]+n > maxLabelSets {
+		return fmt.Errorf("%w, the limit is %d", ErrTooManyPushLabelSets, maxLabelSets)
+	}
+	s.tenantLabelSets[tenant] += n
+	bs.labelSets += n
+	return nil
+}
+
+func (s *batchSessionStore) session(key batchSessionKey, maxSessions int, now time.Time) (*batchSession, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if now.Sub(s.lastSweep) >= batchSessionsSweepInterval {
+		s.sweep(now)
+	}
+
+	if bs, ok := s.sessions[key]; ok {
+		bs.lastSeen = now
+		return bs, nil
+	}
+	if s.tenantSessions[key.tenant] >= maxSessions {
+		return nil, fmt.Errorf("%w, the limit is %d", ErrTooManyPushSessions, maxSessions)
+	}
+	bs := &batchSession{decoder: push.NewBatchDecoder(0), lastSeen: now}
+	s.sessions[key] = bs
+	s.tenantSessions[key.tenant]++
+	batchSessionsActive.Set(float64(len(s.sessions)))
+	return bs, nil
+}
+
+// sweep forgets about the idle sessions. It must be called with the lock held.
+func (s *batchSessionStore) sweep(now time.Time) {
+	s.lastSweep = now
+	for key, bs := range s.sessions {
+		if now.Sub(bs.lastSeen) >= s.idleTimeout {
+			delete(s.sessions, key)
+			s.tenantSessions[key.tenant]--
+			s.tenantLabelSets[key.tenant] -= bs.labelSets
+			if s.tenantSessions[key.tenant] == 0 {
+				delete(s.tenantSessions, key.tenant)
+				delete(s.tenantLabelSets, key.tenant)
+			}
+		}
+	}
+	batchSessionsActive.Set(float64(len(s.sessions)))
+}
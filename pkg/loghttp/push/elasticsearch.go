@@ -0,0 +1,103 @@
+package push
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+const (
+	defaultElasticsearchLineField      = "message"
+	defaultElasticsearchTimestampField = "@timestamp"
+
+	// elasticsearchIndexField is the field holding the index of the bulk
+	// action of a document, which can be mapped like the document fields.
+	elasticsearchIndexField = "elasticsearch.index"
+)
+
+type elasticsearchBulkAction struct {
+	Index string `json:"_index"`
+}
+
+// ElasticsearchBulkItem is an action of a bulk request and the position of the
+// entry of its document in the push request.
+type ElasticsearchBulkItem struct {
+	Op string
+	EntryRef
+}
+
+// ParseElasticsearchBulkRequest parses the body of a request to the
+// Elasticsearch bulk API, made of newline delimited pairs of an action and a
+// document. Only the index and create actions are supported. Bodies larger
+// than maxBodySize once decompressed are rejected.
+//
+// It returns the items of the request in order, which the bulk API responses
+// list the status of.
+func ParseElasticsearchBulkRequest(userID string, r *http.Request, maxBodySize int, tenantsRetention TenantsRetention, limits Limits, tracker UsageTracker) (*logproto.PushRequest, []ElasticsearchBulkItem, *Stats, error) {
+	stats := newPushStats()
+	body, err := readRequestBody(r, maxBodySize, stats)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var (
+		mapper = newFieldMapper(limits.ElasticsearchConfig(userID), defaultElasticsearchLineField, defaultElasticsearchTimestampField, time.Millisecond)
+		now    = time.Now()
+		docs   []mappedDocument
+		ops    []string
+		dec    = json.NewDecoder(bytes.NewReader(body))
+	)
+	for {
+		var action map[string]elasticsearchBulkAction
+		if err := dec.Decode(&action); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid bulk action: %w", err)
+		}
+		if len(action) != 1 {
+			return nil, nil, nil, fmt.Errorf("bulk actions must have exactly one operation, got %d", len(action))
+		}
+
+		var (
+			op   string
+			meta elasticsearchBulkAction
+		)
+		for op, meta = range action {
+			if op != "index" && op != "create" {
+				return nil, nil, nil, fmt.Errorf("unsupported bulk action %q", op)
+			}
+		}
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid bulk document: %w", err)
+		}
+		doc, err := decodeDocument(raw)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid bulk document: %w", err)
+		}
+		if meta.Index != "" {
+			doc[elasticsearchIndexField] = meta.Index
+		}
+
+		mapped, err := mapper.Map(doc, raw, now)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		docs = append(docs, mapped)
+		ops = append(ops, op)
+	}
+
+	req, refs := documentsToPushRequest(docs, userID, tenantsRetention, tracker, stats)
+	items := make([]ElasticsearchBulkItem, 0, len(refs))
+	for i, ref := range refs {
+		items = append(items, ElasticsearchBulkItem{Op: ops[i], EntryRef: ref})
+	}
+	return req, items, stats, nil
+}
@@ -0,0 +1,110 @@
+package push
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/push"
+)
+
+const testMaxBodySize = 1 << 20
+
+type fieldMappingLimits struct {
+	EmptyLimits
+	elasticsearch FieldMappingConfig
+	splunkHEC     FieldMappingConfig
+}
+
+func (l fieldMappingLimits) ElasticsearchConfig(string) FieldMappingConfig {
+	return l.elasticsearch
+}
+
+func (l fieldMappingLimits) SplunkHECConfig(string) FieldMappingConfig {
+	return l.splunkHEC
+}
+
+func TestParseElasticsearchBulkRequest(t *testing.T) {
+	limits := fieldMappingLimits{elasticsearch: FieldMappingConfig{
+		Fields: []AttributesConfig{
+			{Action: IndexLabel, Attributes: []string{"elasticsearch.index", "kubernetes.namespace"}},
+			{Action: Drop, Attributes: []string{"agent.id"}},
+		},
+	}}
+
+	body := `{"create":{"_index":"logs-app"}}
+{"@timestamp":"2024-01-30T12:04:05.123Z","message":"hello","kubernetes":{"namespace":"prod","pod":"app-1"},"agent":{"id":"abc"},"status":200}
+{"index":{"_index":"logs-app"}}
+{"@timestamp":1706616246000,"message":"world","kubernetes":{"namespace":"prod"}}
+{"index":{}}
+{"no_message":"raw"}
+`
+	request := httptest.NewRequest("POST", "/elasticsearch/_bulk", strings.NewReader(body))
+	request.Header.Add("Content-Type", "application/x-ndjson")
+
+	tracker := NewMockTracker()
+	req, items, stats, err := ParseElasticsearchBulkRequest("fake", request, testMaxBodySize, fakeRetention{}, limits, tracker)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), stats.NumLines)
+	require.Equal(t, []ElasticsearchBulkItem{
+		{Op: "create", EntryRef: EntryRef{Stream: 0, Entry: 0}},
+		{Op: "index", EntryRef: EntryRef{Stream: 0, Entry: 1}},
+		{Op: "index", EntryRef: EntryRef{Stream: 1, Entry: 0}},
+	}, items)
+	require.Equal(t, []logproto.Stream{
+		{
+			Labels: `{elasticsearch_index="logs-app", kubernetes_namespace="prod"}`,
+			Entries: []logproto.Entry{
+				{
+					Timestamp:          time.Date(2024, 1, 30, 12, 4, 5, 123000000, time.UTC),
+					Line:               "hello",
+					StructuredMetadata: push.LabelsAdapter{{Name: "kubernetes_pod", Value: "app-1"}, {Name: "status", Value: "200"}},
+				},
+				{
+					Timestamp: time.Unix(1706616246, 0),
+					Line:      "world",
+				},
+			},
+		},
+		{
+			Labels: `{service_name="unknown_service"}`,
+			Entries: []logproto.Entry{
+				{
+					Timestamp:          req.Streams[1].Entries[0].Timestamp,
+					Line:               `{"no_message":"raw"}`,
+					StructuredMetadata: push.LabelsAdapter{{Name: "no_message", Value: "raw"}},
+				},
+			},
+		},
+	}, req.Streams)
+	require.Equal(t, float64(len("hello")+len("kubernetes_pod")+len("app-1")+len("status")+len("200")+len("world")), tracker.receivedBytes[`{elasticsearch_index="logs-app", kubernetes_namespace="prod"}`])
+}
+
+func TestParseElasticsearchBulkRequestErrors(t *testing.T) {
+	for name, body := range map[string]string{
+		"delete action":  `{"delete":{"_index":"logs","_id":"1"}}`,
+		"missing doc":    `{"index":{}}`,
+		"invalid doc":    "{\"index\":{}}\n[1, 2]",
+		"invalid action": `not json`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			request := httptest.NewRequest("POST", "/elasticsearch/_bulk", strings.NewReader(body))
+			_, _, _, err := ParseElasticsearchBulkRequest("fake", request, testMaxBodySize, nil, fieldMappingLimits{}, nil)
+			require.Error(t, err)
+		})
+	}
+
+	body := "{\"index\":{}}\n{\"message\":\"hello\"}\n"
+	request := httptest.NewRequest("POST", "/elasticsearch/_bulk", strings.NewReader(body))
+	_, _, _, err := ParseElasticsearchBulkRequest("fake", request, len(body)-1, nil, fieldMappingLimits{}, nil)
+	require.EqualError(t, err, fmt.Sprintf("received message larger than max (%d vs %d)", len(body), len(body)-1))
+
+	request = httptest.NewRequest("POST", "/elasticsearch/_bulk", strings.NewReader(body))
+	_, _, _, err = ParseElasticsearchBulkRequest("fake", request, len(body), nil, fieldMappingLimits{}, nil)
+	require.NoError(t, err)
+}
@@ -0,0 +1,331 @@
+package push
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	prometheustranslator "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/translator/prometheus"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/push"
+	loki_util "github.com/grafana/loki/pkg/util"
+)
+
+const defaultServiceName = "unknown_service"
+
+// FieldMappingConfig configures how the JSON documents pushed through a
+// third-party protocol, such as the Elasticsearch bulk API or the Splunk HTTP
+// Event Collector, are converted into log entries.
+type FieldMappingConfig struct {
+	LineField      string             `yaml:"line_field,omitempty" doc:"description=Field used as the log line. Objects are encoded as JSON. When empty, the default field of the protocol is used. When the document doesn't have the field, the whole document is used as the log line."`
+	TimestampField string             `yaml:"timestamp_field,omitempty" doc:"description=Field used as the timestamp of the log entry, either an RFC3339 string or a number in the unit of the protocol. When empty, the default field of the protocol is used. When the document doesn't have the field, the time of the push is used."`
+	Fields         []AttributesConfig `yaml:"fields,omitempty" doc:"description=Configuration for the fields of the documents to store them as index labels or Structured Metadata or drop them altogether. Nested fields are referenced by their dot separated path. The fields without configuration are stored as Structured Metadata."`
+}
+
+// Validate checks the actions of the fields, which are otherwise only checked
+// when the config is loaded from YAML.
+func (c *FieldMappingConfig) Validate() error {
+	for i := range c.Fields {
+		f := &c.Fields[i]
+		if f.Action == "" {
+			f.Action = StructuredMetadata
+		}
+		if f.Action != IndexLabel && f.Action != StructuredMetadata && f.Action != Drop {
+			return fmt.Errorf("%w, got %q", errUnsupportedAction, f.Action)
+		}
+		if len(f.Attributes) == 0 && f.Regex.Regexp == nil {
+			return errAttributesAndRegexNotSet
+		}
+		if len(f.Attributes) != 0 && f.Regex.Regexp != nil {
+			return errAttributesAndRegexBothSet
+		}
+	}
+	return nil
+}
+
+func (c *FieldMappingConfig) actionForField(field string) Action {
+	for i := 0; i < len(c.Fields); i++ {
+		if c.Fields[i].Regex.Regexp != nil && c.Fields[i].Regex.MatchString(field) {
+			return c.Fields[i].Action
+		}
+		for _, cfgField := range c.Fields[i].Attributes {
+			if cfgField == field {
+				return c.Fields[i].Action
+			}
+		}
+	}
+
+	return StructuredMetadata
+}
+
+// fieldMapper converts documents according to the field mapping of a tenant
+// and the defaults of a protocol.
+type fieldMapper struct {
+	cfg            FieldMappingConfig
+	lineField      string
+	timestampField string
+	// timestampUnit is the unit of the numeric timestamps of the protocol.
+	timestampUnit time.Duration
+}
+
+func newFieldMapper(cfg FieldMappingConfig, defaultLineField, defaultTimestampField string, timestampUnit time.Duration) fieldMapper {
+	m := fieldMapper{
+		cfg:            cfg,
+		lineField:      cfg.LineField,
+		timestampField: cfg.TimestampField,
+		timestampUnit:  timestampUnit,
+	}
+	if m.lineField == "" {
+		m.lineField = defaultLineField
+	}
+	if m.timestampField == "" {
+		m.timestampField = defaultTimestampField
+	}
+	return m
+}
+
+// mappedDocument is a document converted into a log entry of a stream.
+type mappedDocument struct {
+	labels model.LabelSet
+	entry  push.Entry
+}
+
+// Map converts a decoded JSON document. The raw document is used as the log
+// line when the document doesn't have the line field.
+func (m fieldMapper) Map(doc map[string]interface{}, raw []byte, now time.Time) (mappedDocument, error) {
+	res := mappedDocument{
+		labels: model.LabelSet{},
+		entry:  push.Entry{Timestamp: now},
+	}
+
+	if v, ok := lookupField(doc, m.timestampField); ok {
+		ts, err := m.parseTimestamp(v)
+		if err != nil {
+			return res, fmt.Errorf("invalid %s field: %w", m.timestampField, err)
+		}
+		res.entry.Timestamp = ts
+	}
+
+	if v, ok := lookupField(doc, m.lineField); ok {
+		line, err := fieldValueString(v)
+		if err != nil {
+			return res, err
+		}
+		res.entry.Line = line
+	} else {
+		res.entry.Line = string(raw)
+	}
+
+	m.mapFields(doc, "", &res)
+
+	if len(res.labels) == 0 {
+		res.labels[model.LabelName(prometheustranslator.NormalizeLabel(attrServiceName))] = defaultServiceName
+	}
+	if err := res.labels.Validate(); err != nil {
+		return res, fmt.Errorf("invalid labels: %w", err)
+	}
+	return res, nil
+}
+
+func (m fieldMapper) mapFields(doc map[string]interface{}, prefix string, res *mappedDocument) {
+	// Iterate in a stable order, so the structured metadata of the entries
+	// doesn't depend on the map iteration order.
+	keys := make([]string, 0, len(doc))
+	for k := range doc {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		field := k
+		if prefix != "" {
+			field = prefix + "." + k
+		}
+		if field == m.lineField || field == m.timestampField {
+			continue
+		}
+		if nested, ok := doc[k].(map[string]interface{}); ok {
+			m.mapFields(nested, field, res)
+			continue
+		}
+
+		action := m.cfg.actionForField(field)
+		if action == Drop {
+			continue
+		}
+		value, err := fieldValueString(doc[k])
+		if err != nil {
+			continue
+		}
+		name := prometheustranslator.NormalizeLabel(field)
+		if action == IndexLabel {
+			res.labels[model.LabelName(name)] = model.LabelValue(value)
+		} else {
+			res.entry.StructuredMetadata = append(res.entry.StructuredMetadata, push.LabelAdapter{Name: name, Value: value})
+		}
+	}
+}
+
+func (m fieldMapper) parseTimestamp(v interface{}) (time.Time, error) {
+	switch ts := v.(type) {
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+			return t, nil
+		}
+		// Some clients send numeric timestamps as strings.
+		return m.parseTimestamp(json.Number(ts))
+	case json.Number:
+		f, err := ts.Float64()
+		if err != nil {
+			return time.Time{}, err
+		}
+		sec, frac := math.Modf(f * m.timestampUnit.Seconds())
+		return time.Unix(int64(sec), int64(frac*float64(time.Second))), nil
+	default:
+		return time.Time{}, fmt.Errorf("unsupported timestamp %v", v)
+	}
+}
+
+// readRequestBody reads the body of a request of a third-party protocol,
+// decompressing it according to its content encoding. The decompressed body is
+// rejected if it is larger than maxSize.
+func readRequestBody(r *http.Request, maxSize int, stats *Stats) ([]byte, error) {
+	stats.ContentType = r.Header.Get(contentType)
+	stats.ContentEncoding = r.Header.Get(contentEnc)
+	// bodySize should always reflect the compressed size of the request body
+	bodySize := loki_util.NewSizeReader(r.Body)
+	var body io.Reader = bodySize
+	switch stats.ContentEncoding {
+	case "":
+	case gzipContentEncoding:
+		gzipReader, err := gzip.NewReader(bodySize)
+		if err != nil {
+			return nil, err
+		}
+		defer gzipReader.Close()
+		body = gzipReader
+	default:
+		return nil, fmt.Errorf("Content-Encoding %q not supported", stats.ContentEncoding)
+	}
+
+	// Read one byte past the limit to tell a body of the max size from a larger one.
+	buf, err := io.ReadAll(io.LimitReader(body, int64(maxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(buf) > maxSize {
+		return nil, fmt.Errorf("received message larger than max (%d vs %d)", len(buf), maxSize)
+	}
+	stats.BodySize = bodySize.Size()
+	return buf, nil
+}
+
+// decodeDocument decodes a JSON object, keeping numbers as json.Number so
+// that they aren't altered when stored as strings.
+func decodeDocument(raw []byte) (map[string]interface{}, error) {
+	var doc map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, fmt.Errorf("document must be a JSON object")
+	}
+	return doc, nil
+}
+
+// lookupField returns the value of a field, either a top-level key or the dot
+// separated path of a nested field.
+func lookupField(doc map[string]interface{}, field string) (interface{}, bool) {
+	if field == "" {
+		return nil, false
+	}
+	if v, ok := doc[field]; ok {
+		return v, true
+	}
+	parts := strings.SplitN(field, ".", 2)
+	if len(parts) != 2 {
+		return nil, false
+	}
+	nested, ok := doc[parts[0]].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	return lookupField(nested, parts[1])
+}
+
+func fieldValueString(v interface{}) (string, error) {
+	switch value := v.(type) {
+	case string:
+		return value, nil
+	case json.Number:
+		return value.String(), nil
+	case nil:
+		return "", nil
+	default:
+		b, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+}
+
+// EntryRef is the position of an entry in a push request.
+type EntryRef struct {
+	Stream, Entry int
+}
+
+// documentsToPushRequest groups the mapped documents by stream and records
+// the push stats and usage of the tenant. It returns the position of the
+// entry of every document in the push request, in the order of the documents.
+func documentsToPushRequest(docs []mappedDocument, userID string, tenantsRetention TenantsRetention, tracker UsageTracker, stats *Stats) (*logproto.PushRequest, []EntryRef) {
+	streams := make(map[string]int, len(docs))
+	req := &logproto.PushRequest{}
+	refs := make([]EntryRef, 0, len(docs))
+
+	for _, doc := range docs {
+		labelsStr := doc.labels.String()
+		lbs := modelLabelsSetToLabelsList(doc.labels)
+
+		i, ok := streams[labelsStr]
+		if !ok {
+			i = len(req.Streams)
+			streams[labelsStr] = i
+			req.Streams = append(req.Streams, logproto.Stream{Labels: labelsStr})
+			stats.StreamLabelsSize += int64(labelsSize(logproto.FromLabelsToLabelAdapters(lbs)))
+		}
+		refs = append(refs, EntryRef{Stream: i, Entry: len(req.Streams[i].Entries)})
+		req.Streams[i].Entries = append(req.Streams[i].Entries, doc.entry)
+
+		var retentionPeriod time.Duration
+		if tenantsRetention != nil {
+			retentionPeriod = tenantsRetention.RetentionPeriodFor(userID, lbs)
+		}
+		metadataSize := int64(labelsSize(doc.entry.StructuredMetadata))
+		stats.StructuredMetadataBytes[retentionPeriod] += metadataSize
+		stats.LogLinesBytes[retentionPeriod] += int64(len(doc.entry.Line))
+
+		if tracker != nil {
+			tracker.ReceivedBytesAdd(userID, retentionPeriod, lbs, float64(len(doc.entry.Line)))
+			tracker.ReceivedBytesAdd(userID, retentionPeriod, lbs, float64(metadataSize))
+		}
+
+		stats.NumLines++
+		if doc.entry.Timestamp.After(stats.MostRecentEntryTimestamp) {
+			stats.MostRecentEntryTimestamp = doc.entry.Timestamp
+		}
+	}
+
+	return req, refs
+}
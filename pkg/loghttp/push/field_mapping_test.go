@@ -0,0 +1,63 @@
+package push
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFieldMappingConfigValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		cfg         FieldMappingConfig
+		expectedErr error
+	}{
+		{
+			name: "empty config",
+		},
+		{
+			name: "supported actions",
+			cfg: FieldMappingConfig{
+				Fields: []AttributesConfig{
+					{Action: IndexLabel, Attributes: []string{"host"}},
+					{Action: Drop, Regex: relabel.MustNewRegexp("debug_.*")},
+					{Attributes: []string{"trace_id"}},
+				},
+			},
+		},
+		{
+			name: "unsupported action",
+			cfg: FieldMappingConfig{
+				Fields: []AttributesConfig{{Action: "index_labels", Attributes: []string{"host"}}},
+			},
+			expectedErr: errUnsupportedAction,
+		},
+		{
+			name: "no attributes or regex",
+			cfg: FieldMappingConfig{
+				Fields: []AttributesConfig{{Action: Drop}},
+			},
+			expectedErr: errAttributesAndRegexNotSet,
+		},
+		{
+			name: "both attributes and regex",
+			cfg: FieldMappingConfig{
+				Fields: []AttributesConfig{{Action: Drop, Attributes: []string{"host"}, Regex: relabel.MustNewRegexp("debug_.*")}},
+			},
+			expectedErr: errAttributesAndRegexBothSet,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cfg.Validate()
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			for _, f := range tc.cfg.Fields {
+				require.NotEmpty(t, f.Action)
+			}
+		})
+	}
+}
@@ -139,15 +139,43 @@ func otlpToLokiPushRequest(ld plog.Logs, userID string, tenantsRetention Tenants
 
 		lbs := modelLabelsSetToLabelsList(streamLabels)
 
-		if _, ok := pushRequestsByStream[labelsStr]; !ok {
-			pushRequestsByStream[labelsStr] = logproto.Stream{
-				Labels: labelsStr,
+		resourceAttributesAsStructuredMetadataSize := labelsSize(resourceAttributesAsStructuredMetadata)
+		retentionPeriodForUser := tenantsRetention.RetentionPeriodFor(userID, lbs)
+
+		// the log records of the resource are split into one stream per level when the severity is used as level,
+		// so the streams are only created once they have a log record.
+		levelStreams := make(map[string]otlpStream, 1)
+		streamFor := func(level string) otlpStream {
+			if s, ok := levelStreams[level]; ok {
+				return s
+			}
+
+			s := otlpStream{labelsStr: labelsStr, lbs: lbs, retentionPeriod: retentionPeriodForUser}
+			if level != "" {
+				levelLabels := streamLabels.Clone()
+				levelLabels[levelLabel] = model.LabelValue(level)
+				s.labelsStr = levelLabels.String()
+				s.lbs = modelLabelsSetToLabelsList(levelLabels)
+				s.retentionPeriod = tenantsRetention.RetentionPeriodFor(userID, s.lbs)
 			}
-			stats.StreamLabelsSize += int64(labelsSize(logproto.FromLabelsToLabelAdapters(lbs)))
+
+			if _, ok := pushRequestsByStream[s.labelsStr]; !ok {
+				pushRequestsByStream[s.labelsStr] = logproto.Stream{
+					Labels: s.labelsStr,
+				}
+				stats.StreamLabelsSize += int64(labelsSize(logproto.FromLabelsToLabelAdapters(s.lbs)))
+			}
+			levelStreams[level] = s
+			return s
+		}
+		if !otlpConfig.SeverityAsLevel {
+			streamFor("")
 		}
 
-		resourceAttributesAsStructuredMetadataSize := labelsSize(resourceAttributesAsStructuredMetadata)
-		retentionPeriodForUser := tenantsRetention.RetentionPeriodFor(userID, lbs)
+		var resourceAttributesForTemplate map[string]string
+		if otlpConfig.lineTemplate != nil {
+			resourceAttributesForTemplate = attributesToTemplateData(resAttrs)
+		}
 
 		stats.StructuredMetadataBytes[retentionPeriodForUser] += int64(resourceAttributesAsStructuredMetadataSize)
 		stats.ResourceAndSourceMetadataLabels[retentionPeriodForUser] = append(stats.ResourceAndSourceMetadataLabels[retentionPeriodForUser], resourceAttributesAsStructuredMetadata...)
@@ -158,7 +186,7 @@ func otlpToLokiPushRequest(ld plog.Logs, userID string, tenantsRetention Tenants
 			scopeAttrs := scope.Attributes()
 
 			// it would be rare to have multiple scopes so if the entries slice is empty, pre-allocate it for the number of log entries
-			if cap(pushRequestsByStream[labelsStr].Entries) == 0 {
+			if !otlpConfig.SeverityAsLevel && cap(pushRequestsByStream[labelsStr].Entries) == 0 {
 				stream := pushRequestsByStream[labelsStr]
 				stream.Entries = make([]push.Entry, 0, logs.Len())
 				pushRequestsByStream[labelsStr] = stream
@@ -205,7 +233,13 @@ func otlpToLokiPushRequest(ld plog.Logs, userID string, tenantsRetention Tenants
 			for k := 0; k < logs.Len(); k++ {
 				log := logs.At(k)
 
-				entry := otlpLogToPushEntry(log, otlpConfig)
+				entry := otlpLogToPushEntry(log, otlpConfig, resourceAttributesForTemplate)
+
+				var level string
+				if otlpConfig.SeverityAsLevel {
+					level = severityLevel(log.SeverityNumber())
+				}
+				entryStream := streamFor(level)
 
 				// if entry.StructuredMetadata doesn't have capacity to add resource and scope attributes, make a new slice with enough capacity
 				attributesAsStructuredMetadataLen := len(resourceAttributesAsStructuredMetadata) + len(scopeAttributesAsStructuredMetadata)
@@ -217,17 +251,17 @@ func otlpToLokiPushRequest(ld plog.Logs, userID string, tenantsRetention Tenants
 
 				entry.StructuredMetadata = append(entry.StructuredMetadata, resourceAttributesAsStructuredMetadata...)
 				entry.StructuredMetadata = append(entry.StructuredMetadata, scopeAttributesAsStructuredMetadata...)
-				stream := pushRequestsByStream[labelsStr]
+				stream := pushRequestsByStream[entryStream.labelsStr]
 				stream.Entries = append(stream.Entries, entry)
-				pushRequestsByStream[labelsStr] = stream
+				pushRequestsByStream[entryStream.labelsStr] = stream
 
 				metadataSize := int64(labelsSize(entry.StructuredMetadata) - resourceAttributesAsStructuredMetadataSize - scopeAttributesAsStructuredMetadataSize)
-				stats.StructuredMetadataBytes[retentionPeriodForUser] += metadataSize
-				stats.LogLinesBytes[retentionPeriodForUser] += int64(len(entry.Line))
+				stats.StructuredMetadataBytes[entryStream.retentionPeriod] += metadataSize
+				stats.LogLinesBytes[entryStream.retentionPeriod] += int64(len(entry.Line))
 
 				if tracker != nil {
-					tracker.ReceivedBytesAdd(userID, retentionPeriodForUser, lbs, float64(len(entry.Line)))
-					tracker.ReceivedBytesAdd(userID, retentionPeriodForUser, lbs, float64(metadataSize))
+					tracker.ReceivedBytesAdd(userID, entryStream.retentionPeriod, entryStream.lbs, float64(len(entry.Line)))
+					tracker.ReceivedBytesAdd(userID, entryStream.retentionPeriod, entryStream.lbs, float64(metadataSize))
 				}
 
 				stats.NumLines++
@@ -249,8 +283,16 @@ func otlpToLokiPushRequest(ld plog.Logs, userID string, tenantsRetention Tenants
 	return pr
 }
 
+// otlpStream is the stream the log records of a resource are pushed to.
+type otlpStream struct {
+	labelsStr       string
+	lbs             labels.Labels
+	retentionPeriod time.Duration
+}
+
 // otlpLogToPushEntry converts an OTLP log record to a Loki push.Entry.
-func otlpLogToPushEntry(log plog.LogRecord, otlpConfig OTLPConfig) push.Entry {
+// resourceAttributes are the resource attributes made available to the line template.
+func otlpLogToPushEntry(log plog.LogRecord, otlpConfig OTLPConfig, resourceAttributes map[string]string) push.Entry {
 	// copy log attributes and all the fields from log(except log.Body) to structured metadata
 	logAttrs := log.Attributes()
 	structuredMetadata := make(push.LabelsAdapter, 0, logAttrs.Len()+7)
@@ -317,7 +359,7 @@ func otlpLogToPushEntry(log plog.LogRecord, otlpConfig OTLPConfig) push.Entry {
 
 	return push.Entry{
 		Timestamp:          timestampFromLogRecord(log),
-		Line:               log.Body().AsString(),
+		Line:               otlpLogLine(log, otlpConfig, resourceAttributes),
 		StructuredMetadata: structuredMetadata,
 	}
 }
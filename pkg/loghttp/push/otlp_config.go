@@ -3,6 +3,7 @@ package push
 import (
 	"flag"
 	"fmt"
+	"text/template"
 
 	"github.com/grafana/dskit/flagext"
 	"github.com/prometheus/prometheus/model/relabel"
@@ -20,6 +21,16 @@ const (
 	Drop Action = "drop"
 )
 
+// BodyFormat is the format structured OTLP log bodies are flattened into.
+type BodyFormat string
+
+const (
+	// BodyFormatJSON flattens map bodies into a JSON object.
+	BodyFormatJSON BodyFormat = "json"
+	// BodyFormatLogfmt flattens map bodies into logfmt key/value pairs.
+	BodyFormatLogfmt BodyFormat = "logfmt"
+)
+
 var (
 	errUnsupportedAction         = fmt.Errorf("unsupported action, it must be one of: %s, %s, %s", Drop, IndexLabel, StructuredMetadata)
 	errAttributesAndRegexNotSet  = fmt.Errorf("attributes or regex must be set")
@@ -43,6 +54,13 @@ type OTLPConfig struct {
 	ResourceAttributes ResourceAttributesConfig `yaml:"resource_attributes,omitempty" doc:"description=Configuration for resource attributes to store them as index labels or Structured Metadata or drop them altogether"`
 	ScopeAttributes    []AttributesConfig       `yaml:"scope_attributes,omitempty" doc:"description=Configuration for scope attributes to store them as Structured Metadata or drop them altogether"`
 	LogAttributes      []AttributesConfig       `yaml:"log_attributes,omitempty" doc:"description=Configuration for log attributes to store them as Structured Metadata or drop them altogether"`
+
+	DropAttributesRegex relabel.Regexp `yaml:"drop_attributes_regex,omitempty" doc:"description=Regex to drop resource, scope and log attributes whose name matches it, before applying the configuration of each attribute type"`
+	BodyFormat          BodyFormat     `yaml:"body_format,omitempty" doc:"description=Format to flatten the log bodies made of key/value pairs into, one of [json, logfmt]. Nested keys are joined with an underscore. When empty, the bodies are serialized as-is"`
+	SeverityAsLevel     bool           `yaml:"severity_as_level,omitempty" doc:"default=false|description=Add a level index label to the streams, derived from the severity number of the log records: one of trace, debug, info, warn, error or fatal. The log records of a resource are split into one stream per level"`
+	LineTemplate        string         `yaml:"line_template,omitempty" doc:"description=Go template building the log line from the log record. The template is executed with the .Body string, the .Severity text, and the .Attributes and .Resource maps of the log and resource attributes, keyed by their normalized names and regardless of how they are stored. The log body is used when the template fails"`
+
+	lineTemplate *template.Template `yaml:"-" json:"-"`
 }
 
 type GlobalOTLPConfig struct {
@@ -86,6 +104,10 @@ func (c *OTLPConfig) ApplyGlobalOTLPConfig(config GlobalOTLPConfig) {
 }
 
 func (c *OTLPConfig) actionForAttribute(attribute string, cfgs []AttributesConfig) Action {
+	if c.DropAttributesRegex.Regexp != nil && c.DropAttributesRegex.MatchString(attribute) {
+		return Drop
+	}
+
 	for i := 0; i < len(cfgs); i++ {
 		if cfgs[i].Regex.Regexp != nil && cfgs[i].Regex.MatchString(attribute) {
 			return cfgs[i].Action
@@ -125,6 +147,21 @@ func (c *OTLPConfig) Validate() error {
 		}
 	}
 
+	switch c.BodyFormat {
+	case "", BodyFormatJSON, BodyFormatLogfmt:
+	default:
+		return fmt.Errorf("unsupported body_format %q, it must be one of: %s, %s", c.BodyFormat, BodyFormatJSON, BodyFormatLogfmt)
+	}
+
+	c.lineTemplate = nil
+	if c.LineTemplate != "" {
+		tmpl, err := template.New("line").Option("missingkey=zero").Parse(c.LineTemplate)
+		if err != nil {
+			return fmt.Errorf("invalid line_template: %w", err)
+		}
+		c.lineTemplate = tmpl
+	}
+
 	return nil
 }
 
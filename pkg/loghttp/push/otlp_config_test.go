@@ -345,6 +345,46 @@ func TestOTLPConfig(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "drop attributes of every type by regex",
+			otlpConfig: OTLPConfig{
+				ResourceAttributes: ResourceAttributesConfig{
+					AttributesConfig: []AttributesConfig{
+						{
+							Action:     IndexLabel,
+							Attributes: []string{attrServiceName, "k8s.pod.uid"},
+						},
+					},
+				},
+				DropAttributesRegex: relabel.MustNewRegexp(".*uid"),
+			},
+			resAttrs: []attrAndExpAction{
+				{
+					attr:           attrServiceName,
+					expectedAction: IndexLabel,
+				},
+				{
+					attr:           "k8s.pod.uid",
+					expectedAction: Drop,
+				},
+			},
+			scopeAttrs: []attrAndExpAction{
+				{
+					attr:           "session.uid",
+					expectedAction: Drop,
+				},
+			},
+			logAttrs: []attrAndExpAction{
+				{
+					attr:           "user_id",
+					expectedAction: StructuredMetadata,
+				},
+				{
+					attr:           "user.uid",
+					expectedAction: Drop,
+				},
+			},
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			for _, c := range tc.resAttrs {
@@ -361,3 +401,50 @@ func TestOTLPConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestOTLPConfigValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		cfg         OTLPConfig
+		expectedErr string
+	}{
+		{
+			name: "default config",
+			cfg:  DefaultOTLPConfig(defaultGlobalOTLPConfig),
+		},
+		{
+			name: "index label action for log attributes",
+			cfg: OTLPConfig{
+				LogAttributes: []AttributesConfig{{Action: IndexLabel, Attributes: []string{"foo"}}},
+			},
+			expectedErr: "index_label action is only supported for resource_attributes",
+		},
+		{
+			name: "body format and line template",
+			cfg: OTLPConfig{
+				BodyFormat:   BodyFormatLogfmt,
+				LineTemplate: `{{ .Resource.service_name }}: {{ .Body }}`,
+			},
+		},
+		{
+			name:        "unsupported body format",
+			cfg:         OTLPConfig{BodyFormat: "xml"},
+			expectedErr: `unsupported body_format "xml"`,
+		},
+		{
+			name:        "invalid line template",
+			cfg:         OTLPConfig{LineTemplate: `{{ .Body `},
+			expectedErr: "invalid line_template",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cfg.Validate()
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.cfg.LineTemplate != "", tc.cfg.lineTemplate != nil)
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package push
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strings"
+
+	"github.com/go-logfmt/logfmt"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+// levelLabel is the index label the normalized severity of the log records is
+// stored in when the severity is used as level.
+const levelLabel = "level"
+
+// lineTemplateData is the data the line template of a log record is executed with.
+type lineTemplateData struct {
+	Body       string
+	Severity   string
+	Attributes map[string]string
+	Resource   map[string]string
+}
+
+// otlpLogLine builds the log line of a log record, either from the line
+// template or from its body.
+func otlpLogLine(log plog.LogRecord, otlpConfig OTLPConfig, resourceAttributes map[string]string) string {
+	body := otlpLogBody(log.Body(), otlpConfig.BodyFormat)
+	if otlpConfig.lineTemplate == nil {
+		return body
+	}
+
+	var buf strings.Builder
+	err := otlpConfig.lineTemplate.Execute(&buf, lineTemplateData{
+		Body:       body,
+		Severity:   log.SeverityText(),
+		Attributes: attributesToTemplateData(log.Attributes()),
+		Resource:   resourceAttributes,
+	})
+	if err != nil {
+		return body
+	}
+	return buf.String()
+}
+
+// attributesToTemplateData flattens attributes into a map keyed by the
+// normalized attribute names, the same names as the labels they are stored as.
+func attributesToTemplateData(attrs pcommon.Map) map[string]string {
+	data := make(map[string]string, attrs.Len())
+	for _, lbl := range attributesToLabels(attrs, "") {
+		data[lbl.Name] = lbl.Value
+	}
+	return data
+}
+
+// otlpLogBody serializes the body of a log record. The bodies made of
+// key/value pairs are flattened into the given format, keeping the order of
+// their keys.
+func otlpLogBody(body pcommon.Value, format BodyFormat) string {
+	if body.Type() != pcommon.ValueTypeMap || format == "" {
+		return body.AsString()
+	}
+
+	var buf bytes.Buffer
+	switch format {
+	case BodyFormatLogfmt:
+		enc := logfmt.NewEncoder(&buf)
+		flattenMap(body.Map(), "", func(k string, v pcommon.Value) {
+			// keys are normalized, so they can't be rejected by the encoder.
+			_ = enc.EncodeKeyval(k, v.AsString())
+		})
+	case BodyFormatJSON:
+		buf.WriteByte('{')
+		flattenMap(body.Map(), "", func(k string, v pcommon.Value) {
+			if buf.Len() > 1 {
+				buf.WriteByte(',')
+			}
+			writeJSONString(&buf, k)
+			buf.WriteByte(':')
+			writeJSONValue(&buf, v)
+		})
+		buf.WriteByte('}')
+	}
+	return buf.String()
+}
+
+// flattenMap calls fn for every value of m which isn't a map, with the
+// normalized names of its parent keys joined by an underscore.
+func flattenMap(m pcommon.Map, prefix string, fn func(k string, v pcommon.Value)) {
+	m.Range(func(k string, v pcommon.Value) bool {
+		key := k
+		if prefix != "" {
+			key = prefix + "_" + k
+		}
+		if v.Type() == pcommon.ValueTypeMap {
+			flattenMap(v.Map(), key, fn)
+			return true
+		}
+		// normalize the key the same way attributeToLabels does.
+		fn(attributeToLabels(key, v, "")[0].Name, v)
+		return true
+	})
+}
+
+func writeJSONValue(buf *bytes.Buffer, v pcommon.Value) {
+	switch v.Type() {
+	case pcommon.ValueTypeEmpty:
+		buf.WriteString("null")
+	case pcommon.ValueTypeInt, pcommon.ValueTypeBool, pcommon.ValueTypeSlice:
+		buf.WriteString(v.AsString())
+	case pcommon.ValueTypeDouble:
+		if math.IsNaN(v.Double()) || math.IsInf(v.Double(), 0) {
+			writeJSONString(buf, v.AsString())
+			return
+		}
+		buf.WriteString(v.AsString())
+	default:
+		writeJSONString(buf, v.AsString())
+	}
+}
+
+func writeJSONString(buf *bytes.Buffer, s string) {
+	// marshaling a string can't fail.
+	b, _ := json.Marshal(s)
+	buf.Write(b)
+}
+
+// severityLevel normalizes a severity number into a level, or returns an
+// empty string when the severity is unspecified.
+func severityLevel(severity plog.SeverityNumber) string {
+	switch {
+	case severity >= plog.SeverityNumberFatal:
+		return "fatal"
+	case severity >= plog.SeverityNumberError:
+		return "error"
+	case severity >= plog.SeverityNumberWarn:
+		return "warn"
+	case severity >= plog.SeverityNumberInfo:
+		return "info"
+	case severity >= plog.SeverityNumberDebug:
+		return "debug"
+	case severity >= plog.SeverityNumberTrace:
+		return "trace"
+	default:
+		return ""
+	}
+}
@@ -604,12 +604,115 @@ func TestOTLPLogToPushEntry(t *testing.T) {
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			require.Equal(t, tc.expectedResp, otlpLogToPushEntry(tc.buildLogRecord(), DefaultOTLPConfig(defaultGlobalOTLPConfig)))
+			require.Equal(t, tc.expectedResp, otlpLogToPushEntry(tc.buildLogRecord(), DefaultOTLPConfig(defaultGlobalOTLPConfig), nil))
 		})
 	}
 
 }
 
+func TestOTLPLogLine(t *testing.T) {
+	buildLogRecord := func() plog.LogRecord {
+		log := plog.NewLogRecord()
+		body := log.Body().SetEmptyMap()
+		body.PutStr("msg", "request served")
+		body.PutInt("status", 200)
+		body.PutDouble("duration", 0.5)
+		body.PutEmptyMap("http").PutStr("method", "GET")
+		body.PutEmptySlice("tags").AppendEmpty().SetStr("a b")
+		log.SetSeverityText("INFO")
+		log.Attributes().PutStr("user.id", "u1")
+		return log
+	}
+
+	for _, tc := range []struct {
+		name         string
+		otlpConfig   OTLPConfig
+		expectedLine string
+	}{
+		{
+			name:         "body serialized as-is",
+			otlpConfig:   OTLPConfig{},
+			expectedLine: `{"duration":0.5,"http":{"method":"GET"},"msg":"request served","status":200,"tags":["a b"]}`,
+		},
+		{
+			name:         "body flattened into logfmt",
+			otlpConfig:   OTLPConfig{BodyFormat: BodyFormatLogfmt},
+			expectedLine: `msg="request served" status=200 duration=0.5 http_method=GET tags="[\"a b\"]"`,
+		},
+		{
+			name:         "body flattened into json",
+			otlpConfig:   OTLPConfig{BodyFormat: BodyFormatJSON},
+			expectedLine: `{"msg":"request served","status":200,"duration":0.5,"http_method":"GET","tags":["a b"]}`,
+		},
+		{
+			name: "line template",
+			otlpConfig: OTLPConfig{
+				BodyFormat:   BodyFormatLogfmt,
+				LineTemplate: `{{ .Severity }} {{ .Resource.service_name }} user={{ .Attributes.user_id }} missing={{ .Attributes.missing }} {{ .Body }}`,
+			},
+			expectedLine: `INFO checkout user=u1 missing= msg="request served" status=200 duration=0.5 http_method=GET tags="[\"a b\"]"`,
+		},
+		{
+			name: "line template failing",
+			otlpConfig: OTLPConfig{
+				LineTemplate: `{{ index .Body 100 }}`,
+			},
+			expectedLine: `{"duration":0.5,"http":{"method":"GET"},"msg":"request served","status":200,"tags":["a b"]}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.NoError(t, tc.otlpConfig.Validate())
+			line := otlpLogLine(buildLogRecord(), tc.otlpConfig, map[string]string{"service_name": "checkout"})
+			require.Equal(t, tc.expectedLine, line)
+		})
+	}
+}
+
+func TestOTLPToLokiPushRequestSeverityAsLevel(t *testing.T) {
+	now := time.Unix(0, time.Now().UnixNano())
+
+	ld := plog.NewLogs()
+	ld.ResourceLogs().AppendEmpty().Resource().Attributes().PutStr("service.name", "service-1")
+	logs := ld.ResourceLogs().At(0).ScopeLogs().AppendEmpty().LogRecords()
+	for _, severity := range []plog.SeverityNumber{plog.SeverityNumberInfo, plog.SeverityNumberError2, plog.SeverityNumberUnspecified, plog.SeverityNumberInfo4} {
+		log := logs.AppendEmpty()
+		log.Body().SetStr("test body")
+		log.SetTimestamp(pcommon.Timestamp(now.UnixNano()))
+		log.SetSeverityNumber(severity)
+	}
+
+	otlpConfig := DefaultOTLPConfig(defaultGlobalOTLPConfig)
+	otlpConfig.SeverityAsLevel = true
+	stats := newPushStats()
+	pushReq := otlpToLokiPushRequest(ld, "foo", fakeRetention{}, otlpConfig, nil, stats)
+
+	entriesByStream := map[string]int{}
+	for _, stream := range pushReq.Streams {
+		entriesByStream[stream.Labels] = len(stream.Entries)
+	}
+	require.Equal(t, map[string]int{
+		`{level="info", service_name="service-1"}`:  2,
+		`{level="error", service_name="service-1"}`: 1,
+		`{service_name="service-1"}`:                1,
+	}, entriesByStream)
+	require.Equal(t, int64(4), stats.NumLines)
+	require.Equal(t, int64(len("level")+len("info")+len("level")+len("error")+3*len("service_name")+3*len("service-1")), stats.StreamLabelsSize)
+}
+
+func TestSeverityLevel(t *testing.T) {
+	for severity, expected := range map[plog.SeverityNumber]string{
+		plog.SeverityNumberUnspecified: "",
+		plog.SeverityNumberTrace:       "trace",
+		plog.SeverityNumberDebug4:      "debug",
+		plog.SeverityNumberInfo:        "info",
+		plog.SeverityNumberWarn3:       "warn",
+		plog.SeverityNumberError:       "error",
+		plog.SeverityNumberFatal4:      "fatal",
+	} {
+		require.Equal(t, expected, severityLevel(severity), severity.String())
+	}
+}
+
 func TestAttributesToLabels(t *testing.T) {
 	for _, tc := range []struct {
 		name         string
@@ -14,6 +14,7 @@ import (
 
 	"github.com/dustin/go-humanize"
 	"github.com/go-kit/log"
+	"github.com/klauspost/compress/zstd"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/prometheus/model/labels"
@@ -54,7 +55,16 @@ var (
 	linesReceivedStats                   = analytics.NewCounter("distributor_lines_received")
 )
 
-const applicationJSON = "application/json"
+const (
+	applicationJSON = "application/json"
+
+	// protobufCompressionParam is the parameter of the protobuf content type
+	// selecting the compression of the message. The message is snappy
+	// compressed by default, even when the body has a content encoding, and
+	// isn't compressed with "application/x-protobuf; compression=none".
+	protobufCompressionParam = "compression"
+	protobufCompressionNone  = "none"
+)
 
 type TenantsRetention interface {
 	RetentionPeriodFor(userID string, lbs labels.Labels) time.Duration
@@ -62,6 +72,10 @@ type TenantsRetention interface {
 
 type Limits interface {
 	OTLPConfig(userID string) OTLPConfig
+	ElasticsearchConfig(userID string) FieldMappingConfig
+	SplunkHECConfig(userID string) FieldMappingConfig
+	MaxPushBatchSessions(userID string) int
+	MaxPushBatchLabelSets(userID string) int
 }
 
 type EmptyLimits struct{}
@@ -70,6 +84,22 @@ func (EmptyLimits) OTLPConfig(string) OTLPConfig {
 	return DefaultOTLPConfig(GlobalOTLPConfig{})
 }
 
+func (EmptyLimits) ElasticsearchConfig(string) FieldMappingConfig {
+	return FieldMappingConfig{}
+}
+
+func (EmptyLimits) SplunkHECConfig(string) FieldMappingConfig {
+	return FieldMappingConfig{}
+}
+
+func (EmptyLimits) MaxPushBatchSessions(string) int {
+	return defaultMaxPushBatchSessions
+}
+
+func (EmptyLimits) MaxPushBatchLabelSets(string) int {
+	return defaultMaxPushBatchLabelSets
+}
+
 type RequestParser func(userID string, r *http.Request, tenantsRetention TenantsRetention, limits Limits, tracker UsageTracker) (*logproto.PushRequest, *Stats, error)
 type RequestParserWrapper func(inner RequestParser) RequestParser
 
@@ -145,7 +175,7 @@ func ParseRequest(logger log.Logger, userID string, r *http.Request, tenantsRete
 	return req, nil
 }
 
-func ParseLokiRequest(userID string, r *http.Request, tenantsRetention TenantsRetention, _ Limits, tracker UsageTracker) (*logproto.PushRequest, *Stats, error) {
+func ParseLokiRequest(userID string, r *http.Request, tenantsRetention TenantsRetention, limits Limits, tracker UsageTracker) (*logproto.PushRequest, *Stats, error) {
 	// Body
 	var body io.Reader
 	// bodySize should always reflect the compressed size of the request body
@@ -170,6 +200,13 @@ func ParseLokiRequest(userID string, r *http.Request, tenantsRetention TenantsRe
 		flateReader := flate.NewReader(bodySize)
 		defer flateReader.Close()
 		body = flateReader
+	case "zstd":
+		zstdReader, err := zstd.NewReader(bodySize)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer zstdReader.Close()
+		body = zstdReader
 	default:
 		return nil, nil, fmt.Errorf("Content-Encoding %q not supported", contentEncoding)
 	}
@@ -180,7 +217,7 @@ func ParseLokiRequest(userID string, r *http.Request, tenantsRetention TenantsRe
 		pushStats = newPushStats()
 	)
 
-	contentType, _ /* params */, err := mime.ParseMediaType(contentType)
+	contentType, params, err := mime.ParseMediaType(contentType)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -204,10 +241,30 @@ func ParseLokiRequest(userID string, r *http.Request, tenantsRetention TenantsRe
 
 	default:
 		// When no content-type header is set or when it is set to
-		// `application/x-protobuf`: expect snappy compression.
-		if err := util.ParseProtoReader(r.Context(), body, int(r.ContentLength), math.MaxInt32, &req, util.RawSnappy); err != nil {
+		// `application/x-protobuf`: expect snappy compression, unless the
+		// content type explicitly disables it.
+		compression := util.RawSnappy
+		if params[protobufCompressionParam] == protobufCompressionNone {
+			compression = util.NoCompression
+		}
+
+		if params["version"] != batchedPushVersion {
+			if err := util.ParseProtoReader(r.Context(), body, int(r.ContentLength), math.MaxInt32, &req, compression); err != nil {
+				return nil, nil, err
+			}
+			break
+		}
+
+		var batched push.BatchedPushRequest
+		if err := util.ParseProtoReader(r.Context(), body, int(r.ContentLength), math.MaxInt32, &batched, compression); err != nil {
+			return nil, nil, err
+		}
+		decoded, err := batchSessions.Decode(userID, r.Header.Get(pushSessionHeader), limits.MaxPushBatchSessions(userID), limits.MaxPushBatchLabelSets(userID), &batched, time.Now())
+		if err != nil {
 			return nil, nil, err
 		}
+		req = *decoded
+		pushStats.Extra = append(pushStats.Extra, "definedLabelSets", len(batched.LabelSets))
 	}
 
 	pushStats.BodySize = bodySize.Size()
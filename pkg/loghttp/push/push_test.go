@@ -11,11 +11,15 @@ import (
 	"testing"
 	"time"
 
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/push"
 	util_log "github.com/grafana/loki/pkg/util/log"
 )
 
@@ -244,6 +248,138 @@ func TestParseRequest(t *testing.T) {
 	}
 }
 
+func TestParseProtobufRequest(t *testing.T) {
+	req := logproto.PushRequest{Streams: []logproto.Stream{{
+		Labels:  `{foo="bar"}`,
+		Entries: []logproto.Entry{{Timestamp: time.Unix(0, 1).UTC(), Line: "fizzbuzz"}},
+	}}}
+	raw, err := req.Marshal()
+	require.NoError(t, err)
+
+	gzipped := func(b []byte) []byte {
+		var buf bytes.Buffer
+		gw := gzip.NewWriter(&buf)
+		_, err := gw.Write(b)
+		require.NoError(t, err)
+		require.NoError(t, gw.Close())
+		return buf.Bytes()
+	}
+
+	zw, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name            string
+		contentType     string
+		contentEncoding string
+		body            []byte
+	}{
+		{name: "snappy", contentType: "application/x-protobuf", body: snappy.Encode(nil, raw)},
+		{name: "snappy encoding", contentType: "application/x-protobuf", contentEncoding: "snappy", body: snappy.Encode(nil, raw)},
+		{name: "snappy in gzip", contentType: "application/x-protobuf", contentEncoding: "gzip", body: gzipped(snappy.Encode(nil, raw))},
+		{name: "gzip", contentType: "application/x-protobuf; compression=none", contentEncoding: "gzip", body: gzipped(raw)},
+		{name: "zstd", contentType: "application/x-protobuf; compression=none", contentEncoding: "zstd", body: zw.EncodeAll(raw, nil)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest("POST", "/loki/api/v1/push", bytes.NewReader(tc.body))
+			request.Header.Add("Content-Type", tc.contentType)
+			if tc.contentEncoding != "" {
+				request.Header.Add("Content-Encoding", tc.contentEncoding)
+			}
+
+			data, err := ParseRequest(util_log.Logger, "fake", request, nil, nil, ParseLokiRequest, nil)
+			require.NoError(t, err)
+			require.Equal(t, req.Streams, data.Streams)
+		})
+	}
+}
+
+func TestParseBatchedRequest(t *testing.T) {
+	stream := logproto.Stream{
+		Labels:  `{foo="bar"}`,
+		Entries: []logproto.Entry{{Timestamp: time.Unix(0, 1).UTC(), Line: "fizzbuzz"}},
+	}
+	encoder := push.NewBatchEncoder()
+
+	send := func(tenant, session string) (*logproto.PushRequest, error) {
+		body, err := encoder.Encode([]logproto.Stream{stream}).Marshal()
+		require.NoError(t, err)
+
+		request := httptest.NewRequest("POST", "/loki/api/v1/push", bytes.NewReader(snappy.Encode(nil, body)))
+		request.Header.Add("Content-Type", "application/x-protobuf; version=2")
+		if session != "" {
+			request.Header.Add(pushSessionHeader, session)
+		}
+		return ParseRequest(util_log.Logger, tenant, request, nil, EmptyLimits{}, ParseLokiRequest, nil)
+	}
+
+	_, err := send("fake", "")
+	require.Error(t, err)
+	encoder.Reset()
+
+	// The label set is only sent in the first request of the session.
+	for i := 0; i < 2; i++ {
+		data, err := send("fake", "session-1")
+		require.NoError(t, err)
+		require.Equal(t, []logproto.Stream{stream}, data.Streams)
+	}
+
+	// Sessions are per tenant.
+	_, err = send("other", "session-1")
+	require.ErrorIs(t, err, ErrUnknownLabelSet)
+}
+
+func TestBatchSessionStore(t *testing.T) {
+	store := newBatchSessionStore(time.Minute)
+	now := time.Now()
+	req := &push.BatchedPushRequest{LabelSets: []push.LabelSetDefinition{{Id: 1, Labels: `{foo="bar"}`}}}
+
+	_, err := store.Decode("fake", "session-1", 1, 10, req, now)
+	require.NoError(t, err)
+	_, err = store.Decode("fake", "session-2", 1, 10, req, now)
+	require.ErrorIs(t, err, ErrTooManyPushSessions)
+
+	// The sessions are limited per tenant.
+	_, err = store.Decode("other", "session-1", 1, 10, req, now)
+	require.NoError(t, err)
+
+	// Idle sessions are forgotten.
+	_, err = store.Decode("fake", "session-2", 1, 10, req, now.Add(time.Minute))
+	require.NoError(t, err)
+	_, err = store.Decode("fake", "session-1", 1, 10, &push.BatchedPushRequest{Streams: []push.BatchedStream{{LabelSetID: 1}}}, now.Add(time.Minute))
+	require.Error(t, err)
+}
+
+func TestBatchSessionStoreMaxLabelSets(t *testing.T) {
+	store := newBatchSessionStore(time.Minute)
+	now := time.Now()
+	define := func(ids ...uint32) *push.BatchedPushRequest {
+		req := &push.BatchedPushRequest{}
+		for _, id := range ids {
+			req.LabelSets = append(req.LabelSets, push.LabelSetDefinition{Id: id, Labels: fmt.Sprintf(`{id="%d"}`, id)})
+		}
+		return req
+	}
+
+	// The label sets are limited across the sessions of the tenant.
+	_, err := store.Decode("fake", "session-1", 10, 3, define(1, 2), now)
+	require.NoError(t, err)
+	_, err = store.Decode("fake", "session-2", 10, 3, define(1, 2), now)
+	require.ErrorIs(t, err, ErrTooManyPushLabelSets)
+	_, err = store.Decode("fake", "session-2", 10, 3, define(1), now)
+	require.NoError(t, err)
+
+	// Redefining a known label set doesn't count against the limit.
+	_, err = store.Decode("fake", "session-1", 10, 3, define(1, 2), now)
+	require.NoError(t, err)
+	_, err = store.Decode("other", "session-1", 10, 3, define(1, 2, 3), now)
+	require.NoError(t, err)
+
+	// The label sets of the idle sessions are forgotten.
+	_, err = store.Decode("fake", "session-3", 10, 3, define(1, 2, 3), now.Add(time.Minute))
+	require.NoError(t, err)
+}
+
 type MockCustomTracker struct {
 	receivedBytes  map[string]float64
 	discardedBytes map[string]float64
@@ -0,0 +1,72 @@
+package push
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+const (
+	defaultSplunkHECLineField      = "event"
+	defaultSplunkHECTimestampField = "time"
+
+	splunkHECFieldsKey = "fields"
+)
+
+// ParseSplunkHECRequest parses the body of a request to the event endpoint of
+// the Splunk HTTP Event Collector, made of concatenated JSON events. The
+// indexed fields of an event are mapped like its top-level keys. Bodies larger
+// than maxBodySize once decompressed are rejected.
+func ParseSplunkHECRequest(userID string, r *http.Request, maxBodySize int, tenantsRetention TenantsRetention, limits Limits, tracker UsageTracker) (*logproto.PushRequest, *Stats, error) {
+	stats := newPushStats()
+	body, err := readRequestBody(r, maxBodySize, stats)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		mapper = newFieldMapper(limits.SplunkHECConfig(userID), defaultSplunkHECLineField, defaultSplunkHECTimestampField, time.Second)
+		now    = time.Now()
+		docs   []mappedDocument
+		dec    = json.NewDecoder(bytes.NewReader(body))
+	)
+	for {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, nil, fmt.Errorf("invalid event: %w", err)
+		}
+		doc, err := decodeDocument(raw)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid event: %w", err)
+		}
+		if _, ok := doc[defaultSplunkHECLineField]; !ok {
+			return nil, nil, errors.New("event field is required")
+		}
+
+		if fields, ok := doc[splunkHECFieldsKey].(map[string]interface{}); ok {
+			delete(doc, splunkHECFieldsKey)
+			for k, v := range fields {
+				if _, ok := doc[k]; !ok {
+					doc[k] = v
+				}
+			}
+		}
+
+		mapped, err := mapper.Map(doc, raw, now)
+		if err != nil {
+			return nil, nil, err
+		}
+		docs = append(docs, mapped)
+	}
+
+	req, _ := documentsToPushRequest(docs, userID, tenantsRetention, tracker, stats)
+	return req, stats, nil
+}
@@ -0,0 +1,60 @@
+package push
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/push"
+)
+
+func TestParseSplunkHECRequest(t *testing.T) {
+	limits := fieldMappingLimits{splunkHEC: FieldMappingConfig{
+		Fields: []AttributesConfig{
+			{Action: IndexLabel, Attributes: []string{"sourcetype", "env"}},
+			{Action: Drop, Attributes: []string{"index"}},
+		},
+	}}
+
+	body := `{"time": 1426279439.5, "host": "web-1", "sourcetype": "access", "index": "main", "event": "GET /", "fields": {"env": "prod"}}` +
+		`{"time": "1426279440", "sourcetype": "access", "event": {"method": "POST"}, "fields": {"env": "prod"}}`
+	request := httptest.NewRequest("POST", "/services/collector/event", strings.NewReader(body))
+
+	req, stats, err := ParseSplunkHECRequest("fake", request, testMaxBodySize, nil, limits, nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), stats.NumLines)
+	require.Equal(t, []logproto.Stream{
+		{
+			Labels: `{env="prod", sourcetype="access"}`,
+			Entries: []logproto.Entry{
+				{
+					Timestamp:          time.Unix(1426279439, 500000000),
+					Line:               "GET /",
+					StructuredMetadata: push.LabelsAdapter{{Name: "host", Value: "web-1"}},
+				},
+				{
+					Timestamp: time.Unix(1426279440, 0),
+					Line:      `{"method":"POST"}`,
+				},
+			},
+		},
+	}, req.Streams)
+}
+
+func TestParseSplunkHECRequestErrors(t *testing.T) {
+	for name, body := range map[string]string{
+		"missing event":     `{"time": 1426279439}`,
+		"invalid timestamp": `{"time": true, "event": "foo"}`,
+		"invalid json":      `{"event": "foo"`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			request := httptest.NewRequest("POST", "/services/collector/event", strings.NewReader(body))
+			_, _, err := ParseSplunkHECRequest("fake", request, testMaxBodySize, nil, fieldMappingLimits{}, nil)
+			require.Error(t, err)
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	json "github.com/json-iterator/go"
@@ -23,6 +24,8 @@ const (
 type TailResponse struct {
 	Streams        []Stream        `json:"streams,omitempty"`
 	DroppedStreams []DroppedStream `json:"dropped_entries,omitempty"`
+	// Cursor can be used to resume the tail from the last received entry.
+	Cursor string `json:"cursor,omitempty"`
 }
 
 // DroppedStream represents a dropped stream in tail call
@@ -95,12 +98,57 @@ func ParseTailQuery(r *http.Request) (*logproto.TailRequest, error) {
 	if err != nil {
 		return nil, err
 	}
+	req.Cursor, err = tailCursor(r)
+	if err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+
 	req.DelayFor, err = tailDelay(r)
 	if err != nil {
 		return nil, err
 	}
-	if req.DelayFor > maxDelayForInTailing {
-		return nil, fmt.Errorf("delay_for can't be greater than %d", maxDelayForInTailing)
+	if err := ValidateTailRequest(&req); err != nil {
+		return nil, err
 	}
 	return &req, nil
 }
+
+// ValidateTailRequest validates a tail request not parsed by ParseTailQuery,
+// such as the ones of the gRPC tail API, defaulting its limit like
+// ParseTailQuery does.
+func ValidateTailRequest(req *logproto.TailRequest) error {
+	if req.Limit == 0 {
+		req.Limit = defaultQueryLimit
+	}
+	if req.DelayFor > maxDelayForInTailing {
+		return fmt.Errorf("delay_for can't be greater than %d", maxDelayForInTailing)
+	}
+	return nil
+}
+
+// FormatTailCursor returns the string representation of a tail cursor, as sent
+// to clients and expected back in the cursor parameter of a tail request.
+// The format is "<unix nanoseconds>-<stream hash in hex>".
+func FormatTailCursor(c *logproto.TailCursor) string {
+	return strconv.FormatInt(c.Timestamp.UnixNano(), 10) + "-" + strconv.FormatUint(c.StreamHash, 16)
+}
+
+// ParseTailCursor parses a tail cursor formatted by FormatTailCursor.
+func ParseTailCursor(value string) (*logproto.TailCursor, error) {
+	ts, hash, ok := strings.Cut(value, "-")
+	if !ok {
+		return nil, fmt.Errorf("invalid tail cursor %q: expected <timestamp>-<stream hash>", value)
+	}
+	nanos, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tail cursor timestamp %q: %w", ts, err)
+	}
+	streamHash, err := strconv.ParseUint(hash, 16, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tail cursor stream hash %q: %w", hash, err)
+	}
+	return &logproto.TailCursor{
+		Timestamp:  time.Unix(0, nanos),
+		StreamHash: streamHash,
+	}, nil
+}
@@ -44,6 +44,25 @@ func TestParseTailQuery(t *testing.T) {
 					AST: syntax.MustParseExpr(`{foo="bar"}`),
 				},
 			}, false},
+		{"bad cursor",
+			&http.Request{
+				URL: mustParseURL(`?query={foo="bar"}&cursor=1497130944760738998`),
+			}, nil, true},
+		{"resume from cursor",
+			&http.Request{
+				URL: mustParseURL(`?query={foo="bar"}&start=2017-06-10T21:42:24.760738998Z&limit=1000&cursor=1497131944760738998-2a`),
+			}, &logproto.TailRequest{
+				Query: `{foo="bar"}`,
+				Start: time.Date(2017, 06, 10, 21, 42, 24, 760738998, time.UTC),
+				Limit: 1000,
+				Cursor: &logproto.TailCursor{
+					Timestamp:  time.Unix(0, 1497131944760738998),
+					StreamHash: 42,
+				},
+				Plan: &plan.QueryPlan{
+					AST: syntax.MustParseExpr(`{foo="bar"}`),
+				},
+			}, false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -60,3 +79,31 @@ func TestParseTailQuery(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateTailRequest(t *testing.T) {
+	req := &logproto.TailRequest{Query: `{foo="bar"}`}
+	require.NoError(t, ValidateTailRequest(req))
+	require.Equal(t, uint32(defaultQueryLimit), req.Limit)
+
+	req = &logproto.TailRequest{Query: `{foo="bar"}`, Limit: 10, DelayFor: maxDelayForInTailing + 1}
+	require.Error(t, ValidateTailRequest(req))
+}
+
+func TestTailCursorRoundTrip(t *testing.T) {
+	cursor := &logproto.TailCursor{
+		Timestamp:  time.Unix(0, 1497131944760738998),
+		StreamHash: 0xdeadbeef,
+	}
+	formatted := FormatTailCursor(cursor)
+	require.Equal(t, "1497131944760738998-deadbeef", formatted)
+
+	parsed, err := ParseTailCursor(formatted)
+	require.NoError(t, err)
+	require.Equal(t, cursor.StreamHash, parsed.StreamHash)
+	require.True(t, cursor.Timestamp.Equal(parsed.Timestamp))
+
+	_, err = ParseTailCursor("now-deadbeef")
+	require.Error(t, err)
+	_, err = ParseTailCursor("1497131944760738998-xyz")
+	require.Error(t, err)
+}
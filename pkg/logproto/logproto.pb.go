@@ -870,6 +870,9 @@ type TailRequest struct {
 	Limit    uint32                                              `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
 	Start    time.Time                                           `protobuf:"bytes,5,opt,name=start,proto3,stdtime" json:"start"`
 	Plan     *github_com_grafana_loki_pkg_querier_plan.QueryPlan `protobuf:"bytes,6,opt,name=plan,proto3,customtype=github.com/grafana/loki/pkg/querier/plan.QueryPlan" json:"plan,omitempty"`
+	// cursor is the position of the last entry received by a client before it
+	// got disconnected. When set, the tail resumes right after it.
+	Cursor *TailCursor `protobuf:"bytes,7,opt,name=cursor,proto3" json:"cursor,omitempty"`
 }
 
 func (m *TailRequest) Reset()      { *m = TailRequest{} }
@@ -933,6 +936,65 @@ func (m *TailRequest) GetStart() time.Time {
 	return time.Time{}
 }
 
+func (m *TailRequest) GetCursor() *TailCursor {
+	if m != nil {
+		return m.Cursor
+	}
+	return nil
+}
+
+// TailCursor identifies the last entry seen by a tail client.
+type TailCursor struct {
+	Timestamp  time.Time `protobuf:"bytes,1,opt,name=timestamp,proto3,stdtime" json:"timestamp"`
+	StreamHash uint64    `protobuf:"varint,2,opt,name=streamHash,proto3" json:"streamHash,omitempty"`
+}
+
+func (m *TailCursor) Reset()      { *m = TailCursor{} }
+func (*TailCursor) ProtoMessage() {}
+func (*TailCursor) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c28a5f14f1f4c79a, []int{15}
+}
+func (m *TailCursor) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *TailCursor) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_TailCursor.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *TailCursor) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TailCursor.Merge(m, src)
+}
+func (m *TailCursor) XXX_Size() int {
+	return m.Size()
+}
+func (m *TailCursor) XXX_DiscardUnknown() {
+	xxx_messageInfo_TailCursor.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TailCursor proto.InternalMessageInfo
+
+func (m *TailCursor) GetTimestamp() time.Time {
+	if m != nil {
+		return m.Timestamp
+	}
+	return time.Time{}
+}
+
+func (m *TailCursor) GetStreamHash() uint64 {
+	if m != nil {
+		return m.StreamHash
+	}
+	return 0
+}
+
 type TailResponse struct {
 	Stream         *github_com_grafana_loki_pkg_push.Stream `protobuf:"bytes,1,opt,name=stream,proto3,customtype=github.com/grafana/loki/pkg/push.Stream" json:"stream,omitempty"`
 	DroppedStreams []*DroppedStream                         `protobuf:"bytes,2,rep,name=droppedStreams,proto3" json:"droppedStreams,omitempty"`
@@ -941,7 +1003,7 @@ type TailResponse struct {
 func (m *TailResponse) Reset()      { *m = TailResponse{} }
 func (*TailResponse) ProtoMessage() {}
 func (*TailResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{15}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{16}
 }
 func (m *TailResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -977,6 +1039,58 @@ func (m *TailResponse) GetDroppedStreams() []*DroppedStream {
 	return nil
 }
 
+type QuerierTailResponse struct {
+	Streams        []github_com_grafana_loki_pkg_push.Stream `protobuf:"bytes,1,rep,name=streams,proto3,customtype=github.com/grafana/loki/pkg/push.Stream" json:"streams,omitempty"`
+	DroppedStreams []*DroppedStream                          `protobuf:"bytes,2,rep,name=droppedStreams,proto3" json:"droppedStreams,omitempty"`
+	Cursor         *TailCursor                               `protobuf:"bytes,3,opt,name=cursor,proto3" json:"cursor,omitempty"`
+}
+
+func (m *QuerierTailResponse) Reset()      { *m = QuerierTailResponse{} }
+func (*QuerierTailResponse) ProtoMessage() {}
+func (*QuerierTailResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c28a5f14f1f4c79a, []int{17}
+}
+func (m *QuerierTailResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *QuerierTailResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_QuerierTailResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *QuerierTailResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_QuerierTailResponse.Merge(m, src)
+}
+func (m *QuerierTailResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *QuerierTailResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_QuerierTailResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_QuerierTailResponse proto.InternalMessageInfo
+
+func (m *QuerierTailResponse) GetDroppedStreams() []*DroppedStream {
+	if m != nil {
+		return m.DroppedStreams
+	}
+	return nil
+}
+
+func (m *QuerierTailResponse) GetCursor() *TailCursor {
+	if m != nil {
+		return m.Cursor
+	}
+	return nil
+}
+
 type SeriesRequest struct {
 	Start  time.Time `protobuf:"bytes,1,opt,name=start,proto3,stdtime" json:"start"`
 	End    time.Time `protobuf:"bytes,2,opt,name=end,proto3,stdtime" json:"end"`
@@ -987,7 +1101,7 @@ type SeriesRequest struct {
 func (m *SeriesRequest) Reset()      { *m = SeriesRequest{} }
 func (*SeriesRequest) ProtoMessage() {}
 func (*SeriesRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{16}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{18}
 }
 func (m *SeriesRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1051,7 +1165,7 @@ type SeriesResponse struct {
 func (m *SeriesResponse) Reset()      { *m = SeriesResponse{} }
 func (*SeriesResponse) ProtoMessage() {}
 func (*SeriesResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{17}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{19}
 }
 func (m *SeriesResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1094,7 +1208,7 @@ type SeriesIdentifier struct {
 func (m *SeriesIdentifier) Reset()      { *m = SeriesIdentifier{} }
 func (*SeriesIdentifier) ProtoMessage() {}
 func (*SeriesIdentifier) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{18}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{20}
 }
 func (m *SeriesIdentifier) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1138,7 +1252,7 @@ type SeriesIdentifier_LabelsEntry struct {
 func (m *SeriesIdentifier_LabelsEntry) Reset()      { *m = SeriesIdentifier_LabelsEntry{} }
 func (*SeriesIdentifier_LabelsEntry) ProtoMessage() {}
 func (*SeriesIdentifier_LabelsEntry) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{18, 0}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{20, 0}
 }
 func (m *SeriesIdentifier_LabelsEntry) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1190,7 +1304,7 @@ type DroppedStream struct {
 func (m *DroppedStream) Reset()      { *m = DroppedStream{} }
 func (*DroppedStream) ProtoMessage() {}
 func (*DroppedStream) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{19}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{21}
 }
 func (m *DroppedStream) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1248,7 +1362,7 @@ type LabelPair struct {
 func (m *LabelPair) Reset()      { *m = LabelPair{} }
 func (*LabelPair) ProtoMessage() {}
 func (*LabelPair) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{20}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{22}
 }
 func (m *LabelPair) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1301,7 +1415,7 @@ type LegacyLabelPair struct {
 func (m *LegacyLabelPair) Reset()      { *m = LegacyLabelPair{} }
 func (*LegacyLabelPair) ProtoMessage() {}
 func (*LegacyLabelPair) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{21}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{23}
 }
 func (m *LegacyLabelPair) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1351,7 +1465,7 @@ type Chunk struct {
 func (m *Chunk) Reset()      { *m = Chunk{} }
 func (*Chunk) ProtoMessage() {}
 func (*Chunk) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{22}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{24}
 }
 func (m *Chunk) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1393,7 +1507,7 @@ type TailersCountRequest struct {
 func (m *TailersCountRequest) Reset()      { *m = TailersCountRequest{} }
 func (*TailersCountRequest) ProtoMessage() {}
 func (*TailersCountRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{23}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{25}
 }
 func (m *TailersCountRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1429,7 +1543,7 @@ type TailersCountResponse struct {
 func (m *TailersCountResponse) Reset()      { *m = TailersCountResponse{} }
 func (*TailersCountResponse) ProtoMessage() {}
 func (*TailersCountResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{24}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{26}
 }
 func (m *TailersCountResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1474,7 +1588,7 @@ type GetChunkIDsRequest struct {
 func (m *GetChunkIDsRequest) Reset()      { *m = GetChunkIDsRequest{} }
 func (*GetChunkIDsRequest) ProtoMessage() {}
 func (*GetChunkIDsRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{25}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{27}
 }
 func (m *GetChunkIDsRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1531,7 +1645,7 @@ type GetChunkIDsResponse struct {
 func (m *GetChunkIDsResponse) Reset()      { *m = GetChunkIDsResponse{} }
 func (*GetChunkIDsResponse) ProtoMessage() {}
 func (*GetChunkIDsResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{26}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{28}
 }
 func (m *GetChunkIDsResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1584,7 +1698,7 @@ type ChunkRef struct {
 func (m *ChunkRef) Reset()      { *m = ChunkRef{} }
 func (*ChunkRef) ProtoMessage() {}
 func (*ChunkRef) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{27}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{29}
 }
 func (m *ChunkRef) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1645,7 +1759,7 @@ type LabelValuesForMetricNameRequest struct {
 func (m *LabelValuesForMetricNameRequest) Reset()      { *m = LabelValuesForMetricNameRequest{} }
 func (*LabelValuesForMetricNameRequest) ProtoMessage() {}
 func (*LabelValuesForMetricNameRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{28}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{30}
 }
 func (m *LabelValuesForMetricNameRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1704,7 +1818,7 @@ type LabelNamesForMetricNameRequest struct {
 func (m *LabelNamesForMetricNameRequest) Reset()      { *m = LabelNamesForMetricNameRequest{} }
 func (*LabelNamesForMetricNameRequest) ProtoMessage() {}
 func (*LabelNamesForMetricNameRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{29}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{31}
 }
 func (m *LabelNamesForMetricNameRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1747,7 +1861,7 @@ type LineFilter struct {
 func (m *LineFilter) Reset()      { *m = LineFilter{} }
 func (*LineFilter) ProtoMessage() {}
 func (*LineFilter) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{30}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{32}
 }
 func (m *LineFilter) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1795,7 +1909,7 @@ type GetChunkRefRequest struct {
 func (m *GetChunkRefRequest) Reset()      { *m = GetChunkRefRequest{} }
 func (*GetChunkRefRequest) ProtoMessage() {}
 func (*GetChunkRefRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{31}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{33}
 }
 func (m *GetChunkRefRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1838,7 +1952,7 @@ type GetChunkRefResponse struct {
 func (m *GetChunkRefResponse) Reset()      { *m = GetChunkRefResponse{} }
 func (*GetChunkRefResponse) ProtoMessage() {}
 func (*GetChunkRefResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{32}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{34}
 }
 func (m *GetChunkRefResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1883,7 +1997,7 @@ type GetSeriesRequest struct {
 func (m *GetSeriesRequest) Reset()      { *m = GetSeriesRequest{} }
 func (*GetSeriesRequest) ProtoMessage() {}
 func (*GetSeriesRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{33}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{35}
 }
 func (m *GetSeriesRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1926,7 +2040,7 @@ type GetSeriesResponse struct {
 func (m *GetSeriesResponse) Reset()      { *m = GetSeriesResponse{} }
 func (*GetSeriesResponse) ProtoMessage() {}
 func (*GetSeriesResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{34}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{36}
 }
 func (m *GetSeriesResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1970,7 +2084,7 @@ type IndexSeries struct {
 func (m *IndexSeries) Reset()      { *m = IndexSeries{} }
 func (*IndexSeries) ProtoMessage() {}
 func (*IndexSeries) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{35}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{37}
 }
 func (m *IndexSeries) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -2007,7 +2121,7 @@ type QueryIndexResponse struct {
 func (m *QueryIndexResponse) Reset()      { *m = QueryIndexResponse{} }
 func (*QueryIndexResponse) ProtoMessage() {}
 func (*QueryIndexResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{36}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{38}
 }
 func (m *QueryIndexResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -2058,7 +2172,7 @@ type Row struct {
 func (m *Row) Reset()      { *m = Row{} }
 func (*Row) ProtoMessage() {}
 func (*Row) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{37}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{39}
 }
 func (m *Row) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -2108,7 +2222,7 @@ type QueryIndexRequest struct {
 func (m *QueryIndexRequest) Reset()      { *m = QueryIndexRequest{} }
 func (*QueryIndexRequest) ProtoMessage() {}
 func (*QueryIndexRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{38}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{40}
 }
 func (m *QueryIndexRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -2155,7 +2269,7 @@ type IndexQuery struct {
 func (m *IndexQuery) Reset()      { *m = IndexQuery{} }
 func (*IndexQuery) ProtoMessage() {}
 func (*IndexQuery) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{39}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{41}
 }
 func (m *IndexQuery) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -2228,7 +2342,7 @@ type IndexStatsRequest struct {
 func (m *IndexStatsRequest) Reset()      { *m = IndexStatsRequest{} }
 func (*IndexStatsRequest) ProtoMessage() {}
 func (*IndexStatsRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{40}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{42}
 }
 func (m *IndexStatsRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -2274,7 +2388,7 @@ type IndexStatsResponse struct {
 func (m *IndexStatsResponse) Reset()      { *m = IndexStatsResponse{} }
 func (*IndexStatsResponse) ProtoMessage() {}
 func (*IndexStatsResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{41}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{43}
 }
 func (m *IndexStatsResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -2344,7 +2458,7 @@ type VolumeRequest struct {
 func (m *VolumeRequest) Reset()      { *m = VolumeRequest{} }
 func (*VolumeRequest) ProtoMessage() {}
 func (*VolumeRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{42}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{44}
 }
 func (m *VolumeRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -2416,7 +2530,7 @@ type VolumeResponse struct {
 func (m *VolumeResponse) Reset()      { *m = VolumeResponse{} }
 func (*VolumeResponse) ProtoMessage() {}
 func (*VolumeResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{43}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{45}
 }
 func (m *VolumeResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -2467,7 +2581,7 @@ type Volume struct {
 func (m *Volume) Reset()      { *m = Volume{} }
 func (*Volume) ProtoMessage() {}
 func (*Volume) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{44}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{46}
 }
 func (m *Volume) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -2527,7 +2641,9 @@ func init() {
 	proto.RegisterType((*LegacySample)(nil), "logproto.LegacySample")
 	proto.RegisterType((*Series)(nil), "logproto.Series")
 	proto.RegisterType((*TailRequest)(nil), "logproto.TailRequest")
+	proto.RegisterType((*TailCursor)(nil), "logproto.TailCursor")
 	proto.RegisterType((*TailResponse)(nil), "logproto.TailResponse")
+	proto.RegisterType((*QuerierTailResponse)(nil), "logproto.QuerierTailResponse")
 	proto.RegisterType((*SeriesRequest)(nil), "logproto.SeriesRequest")
 	proto.RegisterType((*SeriesResponse)(nil), "logproto.SeriesResponse")
 	proto.RegisterType((*SeriesIdentifier)(nil), "logproto.SeriesIdentifier")
@@ -2563,150 +2679,155 @@ func init() {
 func init() { proto.RegisterFile("pkg/logproto/logproto.proto", fileDescriptor_c28a5f14f1f4c79a) }
 
 var fileDescriptor_c28a5f14f1f4c79a = []byte{
-	// 2278 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xd4, 0x19, 0x4d, 0x6f, 0x1b, 0xc7,
-	0x95, 0x4b, 0x2e, 0xbf, 0x1e, 0x29, 0x59, 0x1e, 0x31, 0x36, 0x41, 0xdb, 0xa4, 0x3c, 0x48, 0x1d,
-	0xc1, 0x71, 0xc8, 0x58, 0x6e, 0xdc, 0xd4, 0x6e, 0xd0, 0x9a, 0x52, 0xec, 0xc8, 0x96, 0x3f, 0x32,
-	0x72, 0xdd, 0xc2, 0x68, 0x6b, 0xac, 0xc4, 0x11, 0x45, 0x88, 0xbb, 0x4b, 0xef, 0x0e, 0x63, 0x0b,
-	0xe8, 0xa1, 0x7f, 0x20, 0x68, 0x6e, 0x45, 0x2f, 0x45, 0x0f, 0x05, 0x52, 0xa0, 0xe8, 0xa5, 0x3f,
-	0xa0, 0xbd, 0xf4, 0xe0, 0xde, 0xdc, 0x5b, 0x90, 0x03, 0x5b, 0xcb, 0x97, 0x42, 0xa7, 0xdc, 0x72,
-	0x2d, 0xe6, 0x6b, 0x77, 0x96, 0xa2, 0xdd, 0x50, 0x75, 0x51, 0xf8, 0xc2, 0x9d, 0x79, 0xf3, 0xe6,
-	0xcd, 0xfb, 0x9a, 0xf7, 0x31, 0x84, 0x13, 0x83, 0x9d, 0x6e, 0xab, 0xef, 0x77, 0x07, 0x81, 0xcf,
-	0xfc, 0x68, 0xd0, 0x14, 0xbf, 0xa8, 0xa0, 0xe7, 0xb5, 0x4a, 0xd7, 0xef, 0xfa, 0x12, 0x87, 0x8f,
-	0xe4, 0x7a, 0xad, 0xd1, 0xf5, 0xfd, 0x6e, 0x9f, 0xb6, 0xc4, 0x6c, 0x63, 0xb8, 0xd5, 0x62, 0x3d,
-	0x97, 0x86, 0xcc, 0x71, 0x07, 0x0a, 0x61, 0x41, 0x51, 0x7f, 0xd8, 0x77, 0xfd, 0x0e, 0xed, 0xb7,
-	0x42, 0xe6, 0xb0, 0x50, 0xfe, 0x2a, 0x8c, 0x79, 0x8e, 0x31, 0x18, 0x86, 0xdb, 0xe2, 0x47, 0x02,
-	0x71, 0x05, 0xd0, 0x3a, 0x0b, 0xa8, 0xe3, 0x12, 0x87, 0xd1, 0x90, 0xd0, 0x87, 0x43, 0x1a, 0x32,
-	0x7c, 0x13, 0xe6, 0x13, 0xd0, 0x70, 0xe0, 0x7b, 0x21, 0x45, 0x17, 0xa1, 0x14, 0xc6, 0xe0, 0xaa,
-	0xb5, 0x90, 0x59, 0x2c, 0x2d, 0x55, 0x9a, 0x91, 0x28, 0xf1, 0x1e, 0x62, 0x22, 0xe2, 0xdf, 0x58,
-	0x00, 0xf1, 0x1a, 0xaa, 0x03, 0xc8, 0xd5, 0x8f, 0x9c, 0x70, 0xbb, 0x6a, 0x2d, 0x58, 0x8b, 0x36,
-	0x31, 0x20, 0xe8, 0x1c, 0x1c, 0x8d, 0x67, 0xb7, 0xfc, 0xf5, 0x6d, 0x27, 0xe8, 0x54, 0xd3, 0x02,
-	0xed, 0xe0, 0x02, 0x42, 0x60, 0x07, 0x0e, 0xa3, 0xd5, 0xcc, 0x82, 0xb5, 0x98, 0x21, 0x62, 0x8c,
-	0x8e, 0x41, 0x8e, 0x51, 0xcf, 0xf1, 0x58, 0xd5, 0x5e, 0xb0, 0x16, 0x8b, 0x44, 0xcd, 0x38, 0x9c,
-	0xcb, 0x4e, 0xc3, 0x6a, 0x76, 0xc1, 0x5a, 0x9c, 0x21, 0x6a, 0x86, 0x3f, 0xcf, 0x40, 0xf9, 0xe3,
-	0x21, 0x0d, 0x76, 0x95, 0x02, 0x50, 0x1d, 0x0a, 0x21, 0xed, 0xd3, 0x4d, 0xe6, 0x07, 0x82, 0xc1,
-	0x62, 0x3b, 0x5d, 0xb5, 0x48, 0x04, 0x43, 0x15, 0xc8, 0xf6, 0x7b, 0x6e, 0x8f, 0x09, 0xb6, 0x66,
-	0x88, 0x9c, 0xa0, 0x4b, 0x90, 0x0d, 0x99, 0x13, 0x30, 0xc1, 0x4b, 0x69, 0xa9, 0xd6, 0x94, 0x46,
-	0x6b, 0x6a, 0xa3, 0x35, 0xef, 0x6a, 0xa3, 0xb5, 0x0b, 0x4f, 0x46, 0x8d, 0xd4, 0x67, 0xff, 0x68,
-	0x58, 0x44, 0x6e, 0x41, 0x17, 0x21, 0x43, 0xbd, 0x8e, 0xe0, 0xf7, 0x9b, 0xee, 0xe4, 0x1b, 0xd0,
-	0x79, 0x28, 0x76, 0x7a, 0x01, 0xdd, 0x64, 0x3d, 0xdf, 0x13, 0x52, 0xcd, 0x2e, 0xcd, 0xc7, 0x16,
-	0x59, 0xd1, 0x4b, 0x24, 0xc6, 0x42, 0xe7, 0x20, 0x17, 0x72, 0xd5, 0x85, 0xd5, 0xfc, 0x42, 0x66,
-	0xb1, 0xd8, 0xae, 0xec, 0x8f, 0x1a, 0x73, 0x12, 0x72, 0xce, 0x77, 0x7b, 0x8c, 0xba, 0x03, 0xb6,
-	0x4b, 0x14, 0x0e, 0x3a, 0x0b, 0xf9, 0x0e, 0xed, 0x53, 0x6e, 0xf0, 0x82, 0x30, 0xf8, 0x9c, 0x41,
-	0x5e, 0x2c, 0x10, 0x8d, 0x80, 0xee, 0x83, 0x3d, 0xe8, 0x3b, 0x5e, 0xb5, 0x28, 0xa4, 0x98, 0x8d,
-	0x11, 0xef, 0xf4, 0x1d, 0xaf, 0x7d, 0xf1, 0xcb, 0x51, 0x63, 0xa9, 0xdb, 0x63, 0xdb, 0xc3, 0x8d,
-	0xe6, 0xa6, 0xef, 0xb6, 0xba, 0x81, 0xb3, 0xe5, 0x78, 0x4e, 0xab, 0xef, 0xef, 0xf4, 0x5a, 0xdc,
-	0x39, 0x1f, 0x0e, 0x69, 0xd0, 0xa3, 0x41, 0x8b, 0xd3, 0x68, 0x0a, 0x7b, 0xf0, 0x7d, 0x44, 0xd0,
-	0xbc, 0x6e, 0x17, 0x72, 0x73, 0x79, 0x3c, 0x4a, 0x03, 0x5a, 0x77, 0xdc, 0x41, 0x9f, 0x4e, 0x65,
-	0xaf, 0xc8, 0x32, 0xe9, 0x43, 0x5b, 0x26, 0x33, 0xad, 0x65, 0x62, 0x35, 0xdb, 0xd3, 0xa9, 0x39,
-	0xfb, 0x4d, 0xd5, 0x9c, 0x7b, 0xf5, 0x6a, 0xc6, 0x55, 0xb0, 0xf9, 0x0c, 0xcd, 0x41, 0x26, 0x70,
-	0x1e, 0x09, 0x65, 0x96, 0x09, 0x1f, 0xe2, 0x35, 0xc8, 0x49, 0x46, 0x50, 0x6d, 0x5c, 0xdb, 0xc9,
-	0x9b, 0x11, 0x6b, 0x3a, 0xa3, 0x75, 0x38, 0x17, 0xeb, 0x30, 0x23, 0xb4, 0x83, 0x7f, 0x6b, 0xc1,
-	0x8c, 0x32, 0xa1, 0x8a, 0x2e, 0x1b, 0x90, 0x97, 0xb7, 0x5b, 0x47, 0x96, 0xe3, 0xe3, 0x91, 0xe5,
-	0x4a, 0xc7, 0x19, 0x30, 0x1a, 0xb4, 0x5b, 0x4f, 0x46, 0x0d, 0xeb, 0xcb, 0x51, 0xe3, 0xad, 0x97,
-	0x49, 0x29, 0x82, 0x9c, 0x8a, 0x3a, 0x9a, 0x30, 0x7a, 0x5b, 0x70, 0xc7, 0x42, 0xe5, 0x07, 0x47,
-	0x9a, 0x32, 0x40, 0xae, 0x7a, 0x5d, 0x1a, 0x72, 0xca, 0x36, 0x37, 0x21, 0x91, 0x38, 0xf8, 0xe7,
-	0x30, 0x9f, 0x70, 0x35, 0xc5, 0xe7, 0xfb, 0x90, 0x0b, 0xb9, 0x02, 0x35, 0x9b, 0x86, 0xa1, 0xd6,
-	0x05, 0xbc, 0x3d, 0xab, 0xf8, 0xcb, 0xc9, 0x39, 0x51, 0xf8, 0xd3, 0x9d, 0xfe, 0x57, 0x0b, 0xca,
-	0x6b, 0xce, 0x06, 0xed, 0x6b, 0x1f, 0x47, 0x60, 0x7b, 0x8e, 0x4b, 0x95, 0xc6, 0xc5, 0x98, 0x07,
-	0xb4, 0x4f, 0x9c, 0xfe, 0x90, 0x4a, 0x92, 0x05, 0xa2, 0x66, 0xd3, 0x46, 0x22, 0xeb, 0xd0, 0x91,
-	0xc8, 0x8a, 0xfd, 0xbd, 0x02, 0x59, 0xee, 0x59, 0xbb, 0x22, 0x0a, 0x15, 0x89, 0x9c, 0xe0, 0xb7,
-	0x60, 0x46, 0x49, 0xa1, 0xd4, 0x17, 0xb3, 0xcc, 0xd5, 0x57, 0xd4, 0x2c, 0x63, 0x17, 0x72, 0x52,
-	0xdb, 0xe8, 0x4d, 0x28, 0x46, 0xd9, 0x4d, 0x48, 0x9b, 0x69, 0xe7, 0xf6, 0x47, 0x8d, 0x34, 0x0b,
-	0x49, 0xbc, 0x80, 0x1a, 0x90, 0x15, 0x3b, 0x85, 0xe4, 0x56, 0xbb, 0xb8, 0x3f, 0x6a, 0x48, 0x00,
-	0x91, 0x1f, 0x74, 0x12, 0xec, 0x6d, 0x9e, 0x60, 0xb8, 0x0a, 0xec, 0x76, 0x61, 0x7f, 0xd4, 0x10,
-	0x73, 0x22, 0x7e, 0xf1, 0x35, 0x28, 0xaf, 0xd1, 0xae, 0xb3, 0xb9, 0xab, 0x0e, 0xad, 0x68, 0x72,
-	0xfc, 0x40, 0x4b, 0xd3, 0x38, 0x0d, 0xe5, 0xe8, 0xc4, 0x07, 0x6e, 0xa8, 0x9c, 0xba, 0x14, 0xc1,
-	0x6e, 0x86, 0xf8, 0xd7, 0x16, 0x28, 0x3b, 0x23, 0x0c, 0xb9, 0x3e, 0x97, 0x35, 0x54, 0x31, 0x08,
-	0xf6, 0x47, 0x0d, 0x05, 0x21, 0xea, 0x8b, 0x2e, 0x43, 0x3e, 0x14, 0x27, 0x72, 0x62, 0xe3, 0xee,
-	0x23, 0x16, 0xda, 0x47, 0xb8, 0x1b, 0xec, 0x8f, 0x1a, 0x1a, 0x91, 0xe8, 0x01, 0x6a, 0x26, 0x32,
-	0xa7, 0x14, 0x6c, 0x76, 0x7f, 0xd4, 0x30, 0xa0, 0x66, 0x26, 0xc5, 0x5f, 0x5b, 0x50, 0xba, 0xeb,
-	0xf4, 0x22, 0x17, 0xaa, 0x6a, 0x13, 0xc5, 0x31, 0x52, 0x02, 0xf8, 0x95, 0xee, 0xd0, 0xbe, 0xb3,
-	0x7b, 0xd5, 0x0f, 0x04, 0xdd, 0x19, 0x12, 0xcd, 0xe3, 0x64, 0x67, 0x4f, 0x4c, 0x76, 0xd9, 0xe9,
-	0x43, 0xea, 0xff, 0x30, 0x80, 0x5d, 0xb7, 0x0b, 0xe9, 0xb9, 0x0c, 0xfe, 0xa3, 0x05, 0x65, 0x29,
-	0xb9, 0x72, 0xbb, 0x9f, 0x40, 0x4e, 0x2a, 0x46, 0xc8, 0xfe, 0x92, 0xe0, 0xf2, 0xf6, 0x34, 0x81,
-	0x45, 0xd1, 0x44, 0xdf, 0x87, 0xd9, 0x4e, 0xe0, 0x0f, 0x06, 0xb4, 0xb3, 0xae, 0x42, 0x58, 0x7a,
-	0x3c, 0x84, 0xad, 0x98, 0xeb, 0x64, 0x0c, 0x1d, 0xff, 0xcd, 0x82, 0x19, 0x15, 0x2d, 0x94, 0xad,
-	0x22, 0xfd, 0x5a, 0x87, 0x4e, 0x59, 0xe9, 0x69, 0x53, 0xd6, 0x31, 0xc8, 0x75, 0x03, 0x7f, 0x38,
-	0x08, 0xab, 0x19, 0x79, 0x37, 0xe5, 0x6c, 0xba, 0x54, 0x86, 0xaf, 0xc3, 0xac, 0x16, 0xe5, 0x05,
-	0x21, 0xb3, 0x36, 0x1e, 0x32, 0x57, 0x3b, 0xd4, 0x63, 0xbd, 0xad, 0x5e, 0x14, 0x04, 0x15, 0x3e,
-	0xfe, 0xa5, 0x05, 0x73, 0xe3, 0x28, 0x68, 0xc5, 0xb8, 0x67, 0x9c, 0xdc, 0x99, 0x17, 0x93, 0x6b,
-	0x8a, 0xe0, 0x13, 0x7e, 0xe8, 0xb1, 0x60, 0x57, 0x93, 0x96, 0x7b, 0x6b, 0xef, 0x41, 0xc9, 0x58,
-	0xe4, 0x29, 0x6a, 0x87, 0xaa, 0x9b, 0x41, 0xf8, 0x30, 0x0e, 0x09, 0x69, 0x19, 0xd0, 0xc4, 0x04,
-	0xff, 0xca, 0x82, 0x99, 0x84, 0x2d, 0xd1, 0xfb, 0x60, 0x6f, 0x05, 0xbe, 0x3b, 0x95, 0xa1, 0xc4,
-	0x0e, 0xf4, 0x6d, 0x48, 0x33, 0x7f, 0x2a, 0x33, 0xa5, 0x99, 0xcf, 0xad, 0xa4, 0xc4, 0xcf, 0xc8,
-	0xea, 0x56, 0xce, 0xf0, 0x7b, 0x50, 0x14, 0x02, 0xdd, 0x71, 0x7a, 0xc1, 0xc4, 0x6c, 0x31, 0x59,
-	0xa0, 0xcb, 0x70, 0x44, 0x46, 0xc2, 0xc9, 0x9b, 0xcb, 0x93, 0x36, 0x97, 0xf5, 0xe6, 0x13, 0x90,
-	0x5d, 0xde, 0x1e, 0x7a, 0x3b, 0x7c, 0x4b, 0xc7, 0x61, 0x8e, 0xde, 0xc2, 0xc7, 0xf8, 0x0d, 0x98,
-	0xe7, 0x77, 0x90, 0x06, 0xe1, 0xb2, 0x3f, 0xf4, 0x98, 0xee, 0x2e, 0xce, 0x41, 0x25, 0x09, 0x56,
-	0x5e, 0x52, 0x81, 0xec, 0x26, 0x07, 0x08, 0x1a, 0x33, 0x44, 0x4e, 0xf0, 0xef, 0x2c, 0x40, 0xd7,
-	0x28, 0x13, 0xa7, 0xac, 0xae, 0x44, 0xd7, 0xa3, 0x06, 0x05, 0xd7, 0x61, 0x9b, 0xdb, 0x34, 0x08,
-	0x75, 0x0d, 0xa2, 0xe7, 0xff, 0x8f, 0x6a, 0x0f, 0x9f, 0x87, 0xf9, 0x04, 0x97, 0x4a, 0xa6, 0x1a,
-	0x14, 0x36, 0x15, 0x4c, 0xe5, 0xbb, 0x68, 0x8e, 0xff, 0x94, 0x86, 0x82, 0xd8, 0x40, 0xe8, 0x16,
-	0x3a, 0x0f, 0xa5, 0xad, 0x9e, 0xd7, 0xa5, 0xc1, 0x20, 0xe8, 0x29, 0x15, 0xd8, 0xed, 0x23, 0xfb,
-	0xa3, 0x86, 0x09, 0x26, 0xe6, 0x04, 0xbd, 0x03, 0xf9, 0x61, 0x48, 0x83, 0x07, 0x3d, 0x79, 0xd3,
-	0x8b, 0xed, 0xca, 0xde, 0xa8, 0x91, 0xfb, 0x61, 0x48, 0x83, 0xd5, 0x15, 0x9e, 0x79, 0x86, 0x62,
-	0x44, 0xe4, 0xb7, 0x83, 0x6e, 0x28, 0x37, 0x15, 0x45, 0x58, 0xfb, 0x3b, 0x9c, 0xfd, 0xb1, 0x50,
-	0x37, 0x08, 0x7c, 0x97, 0xb2, 0x6d, 0x3a, 0x0c, 0x5b, 0x9b, 0xbe, 0xeb, 0xfa, 0x5e, 0x4b, 0xf4,
-	0x92, 0x42, 0x68, 0x9e, 0x3e, 0xf9, 0x76, 0xe5, 0xb9, 0x77, 0x21, 0xcf, 0xb6, 0x03, 0x7f, 0xd8,
-	0xdd, 0x16, 0x59, 0x21, 0xd3, 0xbe, 0x34, 0x3d, 0x3d, 0x4d, 0x81, 0xe8, 0x01, 0x3a, 0xcd, 0xb5,
-	0x45, 0x37, 0x77, 0xc2, 0xa1, 0x2b, 0x3b, 0xb4, 0x76, 0x76, 0x7f, 0xd4, 0xb0, 0xde, 0x21, 0x11,
-	0x18, 0x7f, 0x9a, 0x86, 0x86, 0x70, 0xd4, 0x7b, 0xa2, 0x6c, 0xb8, 0xea, 0x07, 0x37, 0x29, 0x0b,
-	0x7a, 0x9b, 0xb7, 0x1c, 0x97, 0x6a, 0xdf, 0x68, 0x40, 0xc9, 0x15, 0xc0, 0x07, 0xc6, 0x15, 0x00,
-	0x37, 0xc2, 0x43, 0xa7, 0x00, 0xc4, 0x9d, 0x91, 0xeb, 0xf2, 0x36, 0x14, 0x05, 0x44, 0x2c, 0x2f,
-	0x27, 0x34, 0xd5, 0x9a, 0x52, 0x32, 0xa5, 0xa1, 0xd5, 0x71, 0x0d, 0x4d, 0x4d, 0x27, 0x52, 0x8b,
-	0xe9, 0xeb, 0xd9, 0xa4, 0xaf, 0xe3, 0xbf, 0x5b, 0x50, 0x5f, 0xd3, 0x9c, 0x1f, 0x52, 0x1d, 0x5a,
-	0xde, 0xf4, 0x2b, 0x92, 0x37, 0xf3, 0xdf, 0xc9, 0x8b, 0xeb, 0x00, 0x6b, 0x3d, 0x8f, 0x5e, 0xed,
-	0xf5, 0x19, 0x0d, 0x26, 0x74, 0x22, 0x9f, 0x66, 0xe2, 0x90, 0x40, 0xe8, 0x96, 0x96, 0x73, 0xd9,
-	0x88, 0xc3, 0xaf, 0x42, 0x8c, 0xf4, 0x2b, 0x34, 0x5b, 0x66, 0x2c, 0x44, 0xed, 0x40, 0x7e, 0x4b,
-	0x88, 0x27, 0x53, 0x6a, 0xe2, 0x19, 0x25, 0x96, 0xbd, 0x7d, 0x59, 0x1d, 0x7e, 0xe1, 0x65, 0x05,
-	0x89, 0x78, 0xf5, 0x69, 0x85, 0xbb, 0x1e, 0x73, 0x1e, 0x1b, 0x9b, 0x89, 0x3e, 0x01, 0xfd, 0x4c,
-	0x95, 0x5b, 0xd9, 0x89, 0xe5, 0x96, 0xbe, 0xb9, 0x87, 0xef, 0x19, 0x3f, 0x88, 0x63, 0x9f, 0x30,
-	0x87, 0x8a, 0x7d, 0x67, 0xc0, 0x0e, 0xe8, 0x96, 0x4e, 0xd2, 0x28, 0x3e, 0x36, 0xc2, 0x14, 0xeb,
-	0xf8, 0xcf, 0x16, 0xcc, 0x5d, 0xa3, 0x2c, 0x59, 0xfe, 0xbc, 0x46, 0xc6, 0xc4, 0x1f, 0xc1, 0x51,
-	0x83, 0x7f, 0x25, 0xfd, 0x85, 0xb1, 0x9a, 0xe7, 0x8d, 0x58, 0xfe, 0x55, 0xaf, 0x43, 0x1f, 0xab,
-	0x5e, 0x31, 0x59, 0xee, 0xdc, 0x81, 0x92, 0xb1, 0x88, 0xae, 0x8c, 0x15, 0x3a, 0xc6, 0xcb, 0x4e,
-	0x94, 0xac, 0xdb, 0x15, 0x25, 0x93, 0xec, 0x16, 0x55, 0x19, 0x1b, 0x15, 0x05, 0xeb, 0x80, 0x84,
-	0xb9, 0x04, 0x59, 0x33, 0x2d, 0x09, 0xe8, 0x8d, 0xa8, 0xe2, 0x89, 0xe6, 0xe8, 0x34, 0xd8, 0x81,
-	0xff, 0x48, 0x57, 0xb0, 0x33, 0xf1, 0x91, 0xc4, 0x7f, 0x44, 0xc4, 0x12, 0xbe, 0x0c, 0x19, 0xe2,
-	0x3f, 0x42, 0x75, 0x80, 0xc0, 0xf1, 0xba, 0xf4, 0x5e, 0xd4, 0x38, 0x95, 0x89, 0x01, 0x79, 0x41,
-	0xc9, 0xb0, 0x0c, 0x47, 0x4d, 0x8e, 0xa4, 0xb9, 0x9b, 0x90, 0xff, 0x78, 0x68, 0xaa, 0xab, 0x32,
-	0xa6, 0x2e, 0xd9, 0x83, 0x6b, 0x24, 0xee, 0x33, 0x10, 0xc3, 0xd1, 0x49, 0x28, 0x32, 0x67, 0xa3,
-	0x4f, 0x6f, 0xc5, 0x01, 0x2e, 0x06, 0xf0, 0x55, 0xde, 0xf3, 0xdd, 0x33, 0x6a, 0x9f, 0x18, 0x80,
-	0xce, 0xc2, 0x5c, 0xcc, 0xf3, 0x9d, 0x80, 0x6e, 0xf5, 0x1e, 0x0b, 0x0b, 0x97, 0xc9, 0x01, 0x38,
-	0x5a, 0x84, 0x23, 0x31, 0x6c, 0x5d, 0xd4, 0x18, 0xb6, 0x40, 0x1d, 0x07, 0x73, 0xdd, 0x08, 0x71,
-	0x3f, 0x7c, 0x38, 0x74, 0xfa, 0xe2, 0xe6, 0x95, 0x89, 0x01, 0xc1, 0x7f, 0xb1, 0xe0, 0xa8, 0x34,
-	0x35, 0xef, 0xf6, 0x5f, 0x47, 0xaf, 0xff, 0xdc, 0x02, 0x64, 0x4a, 0xa0, 0x5c, 0xeb, 0x5b, 0xe6,
-	0x33, 0x0e, 0x2f, 0x62, 0x4a, 0xa2, 0x95, 0x95, 0xa0, 0xf8, 0x25, 0x06, 0x43, 0x4e, 0x14, 0x42,
-	0xb2, 0xa7, 0xb6, 0x65, 0xaf, 0x2c, 0x21, 0x44, 0x7d, 0x79, 0x8b, 0xbf, 0xb1, 0xcb, 0x68, 0xa8,
-	0x3a, 0x5d, 0xd1, 0xe2, 0x0b, 0x00, 0x91, 0x1f, 0x7e, 0x16, 0xf5, 0x98, 0xf0, 0x1a, 0x3b, 0x3e,
-	0x4b, 0x81, 0x88, 0x1e, 0xe0, 0x3f, 0xa4, 0x61, 0xe6, 0x9e, 0xdf, 0x1f, 0xc6, 0x29, 0xf1, 0x75,
-	0x4a, 0x15, 0x89, 0xf6, 0x3b, 0xab, 0xdb, 0x6f, 0x04, 0x76, 0xc8, 0xe8, 0x40, 0x78, 0x56, 0x86,
-	0x88, 0x31, 0xc2, 0x50, 0x66, 0x4e, 0xd0, 0xa5, 0x4c, 0xf6, 0x35, 0xd5, 0x9c, 0x28, 0x38, 0x13,
-	0x30, 0xb4, 0x00, 0x25, 0xa7, 0xdb, 0x0d, 0x68, 0xd7, 0x61, 0xb4, 0xbd, 0x5b, 0xcd, 0x8b, 0xc3,
-	0x4c, 0x10, 0xfe, 0x31, 0xcc, 0x6a, 0x65, 0x29, 0x93, 0xbe, 0x0b, 0xf9, 0x4f, 0x04, 0x64, 0xc2,
-	0x93, 0x97, 0x44, 0x55, 0x61, 0x4c, 0xa3, 0x25, 0xdf, 0xc7, 0x35, 0xcf, 0xf8, 0x3a, 0xe4, 0x24,
-	0x3a, 0x3a, 0x69, 0x76, 0x27, 0xf2, 0x6d, 0x86, 0xcf, 0x55, 0xab, 0x81, 0x21, 0x27, 0x09, 0x29,
-	0xc3, 0x0b, 0xdf, 0x90, 0x10, 0xa2, 0xbe, 0x67, 0xcf, 0x40, 0x31, 0x7a, 0xdc, 0x46, 0x25, 0xc8,
-	0x5f, 0xbd, 0x4d, 0x7e, 0x74, 0x85, 0xac, 0xcc, 0xa5, 0x50, 0x19, 0x0a, 0xed, 0x2b, 0xcb, 0x37,
-	0xc4, 0xcc, 0x5a, 0xfa, 0xda, 0xd6, 0x91, 0x25, 0x40, 0xdf, 0x83, 0xac, 0x0c, 0x17, 0xc7, 0x62,
-	0xfe, 0xcd, 0x67, 0xe4, 0xda, 0xf1, 0x03, 0x70, 0xa9, 0x01, 0x9c, 0x7a, 0xd7, 0x42, 0xb7, 0xa0,
-	0x24, 0x80, 0xea, 0xc1, 0xe8, 0xe4, 0xf8, 0xbb, 0x4d, 0x82, 0xd2, 0xa9, 0x17, 0xac, 0x1a, 0xf4,
-	0x2e, 0x41, 0x56, 0xd8, 0xc4, 0xe4, 0xc6, 0x7c, 0xf0, 0x33, 0xb9, 0x49, 0x3c, 0xa1, 0xe1, 0x14,
-	0xfa, 0x2e, 0xd8, 0xbc, 0x85, 0x42, 0x46, 0x52, 0x31, 0xde, 0x79, 0x6a, 0xc7, 0xc6, 0xc1, 0xc6,
-	0xb1, 0x1f, 0x44, 0xcf, 0x55, 0xc7, 0xc7, 0xdb, 0x66, 0xbd, 0xbd, 0x7a, 0x70, 0x21, 0x3a, 0xf9,
-	0xb6, 0x7c, 0x57, 0xd1, 0xcd, 0x1b, 0x3a, 0x95, 0x3c, 0x6a, 0xac, 0xd7, 0xab, 0xd5, 0x5f, 0xb4,
-	0x1c, 0x11, 0x5c, 0x83, 0x92, 0xd1, 0x38, 0x99, 0x6a, 0x3d, 0xd8, 0xf5, 0x99, 0x6a, 0x9d, 0xd0,
-	0x6d, 0xe1, 0x14, 0xba, 0x06, 0x05, 0x9e, 0x8a, 0x79, 0x44, 0x42, 0x27, 0xc6, 0x33, 0xae, 0x11,
-	0x69, 0x6b, 0x27, 0x27, 0x2f, 0x46, 0x84, 0x7e, 0x00, 0xc5, 0x6b, 0x94, 0x29, 0x77, 0x3d, 0x3e,
-	0xee, 0xef, 0x13, 0x34, 0x95, 0xbc, 0x33, 0x38, 0xb5, 0xf4, 0x53, 0xfd, 0xa7, 0xd7, 0x8a, 0xc3,
-	0x1c, 0x74, 0x1b, 0x66, 0x05, 0x63, 0xd1, 0xbf, 0x62, 0x09, 0x07, 0x3a, 0xf0, 0x17, 0x5c, 0xc2,
-	0x81, 0x0e, 0xfe, 0x15, 0x87, 0x53, 0xed, 0xfb, 0x4f, 0x9f, 0xd5, 0x53, 0x5f, 0x3c, 0xab, 0xa7,
-	0xbe, 0x7a, 0x56, 0xb7, 0x7e, 0xb1, 0x57, 0xb7, 0x7e, 0xbf, 0x57, 0xb7, 0x9e, 0xec, 0xd5, 0xad,
-	0xa7, 0x7b, 0x75, 0xeb, 0x9f, 0x7b, 0x75, 0xeb, 0x5f, 0x7b, 0xf5, 0xd4, 0x57, 0x7b, 0x75, 0xeb,
-	0xb3, 0xe7, 0xf5, 0xd4, 0xd3, 0xe7, 0xf5, 0xd4, 0x17, 0xcf, 0xeb, 0xa9, 0xfb, 0x6f, 0xfe, 0x87,
-	0x42, 0x52, 0x36, 0xba, 0x39, 0xf1, 0xb9, 0xf0, 0xef, 0x00, 0x00, 0x00, 0xff, 0xff, 0x3e, 0xbe,
-	0x5b, 0x4c, 0xb3, 0x1c, 0x00, 0x00,
+	// 2365 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xd4, 0x39, 0xcd, 0x6f, 0x1b, 0xc7,
+	0xf5, 0x5c, 0x72, 0xf9, 0xf5, 0x48, 0xc9, 0xf2, 0x88, 0xb1, 0x09, 0xda, 0x26, 0xe5, 0x41, 0x7e,
+	0x8e, 0xe0, 0x38, 0x64, 0x2c, 0xff, 0xe2, 0xa6, 0x76, 0x83, 0xc6, 0x94, 0x62, 0x47, 0xb6, 0xfc,
+	0x91, 0x91, 0xeb, 0x16, 0x46, 0x5b, 0x63, 0x45, 0x8e, 0x28, 0x42, 0xdc, 0x5d, 0x7a, 0x77, 0x68,
+	0x5b, 0x40, 0x0f, 0xfd, 0x07, 0x82, 0xe6, 0x56, 0xf4, 0x52, 0xf4, 0xd0, 0x22, 0x05, 0x8a, 0x5e,
+	0xfa, 0x07, 0xb4, 0x97, 0x1e, 0xdc, 0x9b, 0x7b, 0x0b, 0x72, 0x60, 0x6b, 0xf9, 0x12, 0xe8, 0x94,
+	0x5b, 0xaf, 0xc5, 0x7c, 0xed, 0x97, 0x28, 0xc7, 0x74, 0xdc, 0x16, 0xbe, 0x70, 0x67, 0xde, 0xbc,
+	0x79, 0xf3, 0xbe, 0xe6, 0xcd, 0x7b, 0x8f, 0x70, 0x6c, 0xb8, 0xdd, 0x6b, 0x0d, 0xdc, 0xde, 0xd0,
+	0x73, 0x99, 0x1b, 0x0c, 0x9a, 0xe2, 0x17, 0x15, 0xf4, 0xbc, 0x56, 0xe9, 0xb9, 0x3d, 0x57, 0xe2,
+	0xf0, 0x91, 0x5c, 0xaf, 0x35, 0x7a, 0xae, 0xdb, 0x1b, 0xd0, 0x96, 0x98, 0x6d, 0x8c, 0x36, 0x5b,
+	0xac, 0x6f, 0x53, 0x9f, 0x59, 0xf6, 0x50, 0x21, 0x2c, 0x28, 0xea, 0xf7, 0x07, 0xb6, 0xdb, 0xa5,
+	0x83, 0x96, 0xcf, 0x2c, 0xe6, 0xcb, 0x5f, 0x85, 0x31, 0xcf, 0x31, 0x86, 0x23, 0x7f, 0x4b, 0xfc,
+	0x48, 0x20, 0xae, 0x00, 0x5a, 0x67, 0x1e, 0xb5, 0x6c, 0x62, 0x31, 0xea, 0x13, 0x7a, 0x7f, 0x44,
+	0x7d, 0x86, 0xaf, 0xc3, 0x7c, 0x0c, 0xea, 0x0f, 0x5d, 0xc7, 0xa7, 0xe8, 0x3c, 0x94, 0xfc, 0x10,
+	0x5c, 0x35, 0x16, 0x32, 0x8b, 0xa5, 0xa5, 0x4a, 0x33, 0x10, 0x25, 0xdc, 0x43, 0xa2, 0x88, 0xf8,
+	0xd7, 0x06, 0x40, 0xb8, 0x86, 0xea, 0x00, 0x72, 0xf5, 0x63, 0xcb, 0xdf, 0xaa, 0x1a, 0x0b, 0xc6,
+	0xa2, 0x49, 0x22, 0x10, 0x74, 0x06, 0x0e, 0x87, 0xb3, 0x1b, 0xee, 0xfa, 0x96, 0xe5, 0x75, 0xab,
+	0x69, 0x81, 0xb6, 0x7f, 0x01, 0x21, 0x30, 0x3d, 0x8b, 0xd1, 0x6a, 0x66, 0xc1, 0x58, 0xcc, 0x10,
+	0x31, 0x46, 0x47, 0x20, 0xc7, 0xa8, 0x63, 0x39, 0xac, 0x6a, 0x2e, 0x18, 0x8b, 0x45, 0xa2, 0x66,
+	0x1c, 0xce, 0x65, 0xa7, 0x7e, 0x35, 0xbb, 0x60, 0x2c, 0xce, 0x10, 0x35, 0xc3, 0x9f, 0x67, 0xa0,
+	0xfc, 0xc9, 0x88, 0x7a, 0x3b, 0x4a, 0x01, 0xa8, 0x0e, 0x05, 0x9f, 0x0e, 0x68, 0x87, 0xb9, 0x9e,
+	0x60, 0xb0, 0xd8, 0x4e, 0x57, 0x0d, 0x12, 0xc0, 0x50, 0x05, 0xb2, 0x83, 0xbe, 0xdd, 0x67, 0x82,
+	0xad, 0x19, 0x22, 0x27, 0xe8, 0x02, 0x64, 0x7d, 0x66, 0x79, 0x4c, 0xf0, 0x52, 0x5a, 0xaa, 0x35,
+	0xa5, 0xd1, 0x9a, 0xda, 0x68, 0xcd, 0xdb, 0xda, 0x68, 0xed, 0xc2, 0xe3, 0x71, 0x23, 0xf5, 0xd9,
+	0x3f, 0x1a, 0x06, 0x91, 0x5b, 0xd0, 0x79, 0xc8, 0x50, 0xa7, 0x2b, 0xf8, 0x7d, 0xd1, 0x9d, 0x7c,
+	0x03, 0x3a, 0x0b, 0xc5, 0x6e, 0xdf, 0xa3, 0x1d, 0xd6, 0x77, 0x1d, 0x21, 0xd5, 0xec, 0xd2, 0x7c,
+	0x68, 0x91, 0x15, 0xbd, 0x44, 0x42, 0x2c, 0x74, 0x06, 0x72, 0x3e, 0x57, 0x9d, 0x5f, 0xcd, 0x2f,
+	0x64, 0x16, 0x8b, 0xed, 0xca, 0xde, 0xb8, 0x31, 0x27, 0x21, 0x67, 0x5c, 0xbb, 0xcf, 0xa8, 0x3d,
+	0x64, 0x3b, 0x44, 0xe1, 0xa0, 0xd3, 0x90, 0xef, 0xd2, 0x01, 0xe5, 0x06, 0x2f, 0x08, 0x83, 0xcf,
+	0x45, 0xc8, 0x8b, 0x05, 0xa2, 0x11, 0xd0, 0x5d, 0x30, 0x87, 0x03, 0xcb, 0xa9, 0x16, 0x85, 0x14,
+	0xb3, 0x21, 0xe2, 0xad, 0x81, 0xe5, 0xb4, 0xcf, 0x7f, 0x39, 0x6e, 0x2c, 0xf5, 0xfa, 0x6c, 0x6b,
+	0xb4, 0xd1, 0xec, 0xb8, 0x76, 0xab, 0xe7, 0x59, 0x9b, 0x96, 0x63, 0xb5, 0x06, 0xee, 0x76, 0xbf,
+	0xc5, 0x9d, 0xf3, 0xfe, 0x88, 0x7a, 0x7d, 0xea, 0xb5, 0x38, 0x8d, 0xa6, 0xb0, 0x07, 0xdf, 0x47,
+	0x04, 0xcd, 0xab, 0x66, 0x21, 0x37, 0x97, 0xc7, 0xe3, 0x34, 0xa0, 0x75, 0xcb, 0x1e, 0x0e, 0xe8,
+	0x54, 0xf6, 0x0a, 0x2c, 0x93, 0x7e, 0x69, 0xcb, 0x64, 0xa6, 0xb5, 0x4c, 0xa8, 0x66, 0x73, 0x3a,
+	0x35, 0x67, 0x5f, 0x54, 0xcd, 0xb9, 0x57, 0xaf, 0x66, 0x5c, 0x05, 0x93, 0xcf, 0xd0, 0x1c, 0x64,
+	0x3c, 0xeb, 0xa1, 0x50, 0x66, 0x99, 0xf0, 0x21, 0x5e, 0x83, 0x9c, 0x64, 0x04, 0xd5, 0x92, 0xda,
+	0x8e, 0xdf, 0x8c, 0x50, 0xd3, 0x19, 0xad, 0xc3, 0xb9, 0x50, 0x87, 0x19, 0xa1, 0x1d, 0xfc, 0x1b,
+	0x03, 0x66, 0x94, 0x09, 0x55, 0x74, 0xd9, 0x80, 0xbc, 0xbc, 0xdd, 0x3a, 0xb2, 0x1c, 0x4d, 0x46,
+	0x96, 0x4b, 0x5d, 0x6b, 0xc8, 0xa8, 0xd7, 0x6e, 0x3d, 0x1e, 0x37, 0x8c, 0x2f, 0xc7, 0x8d, 0xb7,
+	0x9e, 0x27, 0xa5, 0x08, 0x72, 0x2a, 0xea, 0x68, 0xc2, 0xe8, 0x6d, 0xc1, 0x1d, 0xf3, 0x95, 0x1f,
+	0x1c, 0x6a, 0xca, 0x00, 0xb9, 0xea, 0xf4, 0xa8, 0xcf, 0x29, 0x9b, 0xdc, 0x84, 0x44, 0xe2, 0xe0,
+	0x9f, 0xc1, 0x7c, 0xcc, 0xd5, 0x14, 0x9f, 0xef, 0x43, 0xce, 0xe7, 0x0a, 0xd4, 0x6c, 0x46, 0x0c,
+	0xb5, 0x2e, 0xe0, 0xed, 0x59, 0xc5, 0x5f, 0x4e, 0xce, 0x89, 0xc2, 0x9f, 0xee, 0xf4, 0xbf, 0x1a,
+	0x50, 0x5e, 0xb3, 0x36, 0xe8, 0x40, 0xfb, 0x38, 0x02, 0xd3, 0xb1, 0x6c, 0xaa, 0x34, 0x2e, 0xc6,
+	0x3c, 0xa0, 0x3d, 0xb0, 0x06, 0x23, 0x2a, 0x49, 0x16, 0x88, 0x9a, 0x4d, 0x1b, 0x89, 0x8c, 0x97,
+	0x8e, 0x44, 0x46, 0xe8, 0xef, 0x15, 0xc8, 0x72, 0xcf, 0xda, 0x11, 0x51, 0xa8, 0x48, 0xe4, 0x04,
+	0xbf, 0x05, 0x33, 0x4a, 0x0a, 0xa5, 0xbe, 0x90, 0x65, 0xae, 0xbe, 0xa2, 0x66, 0x19, 0xdb, 0x90,
+	0x93, 0xda, 0x46, 0x6f, 0x42, 0x31, 0x78, 0xdd, 0x84, 0xb4, 0x99, 0x76, 0x6e, 0x6f, 0xdc, 0x48,
+	0x33, 0x9f, 0x84, 0x0b, 0xa8, 0x01, 0x59, 0xb1, 0x53, 0x48, 0x6e, 0xb4, 0x8b, 0x7b, 0xe3, 0x86,
+	0x04, 0x10, 0xf9, 0x41, 0xc7, 0xc1, 0xdc, 0xe2, 0x0f, 0x0c, 0x57, 0x81, 0xd9, 0x2e, 0xec, 0x8d,
+	0x1b, 0x62, 0x4e, 0xc4, 0x2f, 0xbe, 0x02, 0xe5, 0x35, 0xda, 0xb3, 0x3a, 0x3b, 0xea, 0xd0, 0x8a,
+	0x26, 0xc7, 0x0f, 0x34, 0x34, 0x8d, 0x93, 0x50, 0x0e, 0x4e, 0xbc, 0x67, 0xfb, 0xca, 0xa9, 0x4b,
+	0x01, 0xec, 0xba, 0x8f, 0x7f, 0x65, 0x80, 0xb2, 0x33, 0xc2, 0x90, 0x1b, 0x70, 0x59, 0x7d, 0x15,
+	0x83, 0x60, 0x6f, 0xdc, 0x50, 0x10, 0xa2, 0xbe, 0xe8, 0x22, 0xe4, 0x7d, 0x71, 0x22, 0x27, 0x96,
+	0x74, 0x1f, 0xb1, 0xd0, 0x3e, 0xc4, 0xdd, 0x60, 0x6f, 0xdc, 0xd0, 0x88, 0x44, 0x0f, 0x50, 0x33,
+	0xf6, 0x72, 0x4a, 0xc1, 0x66, 0xf7, 0xc6, 0x8d, 0x08, 0x34, 0xfa, 0x92, 0xe2, 0xdf, 0xa5, 0xa1,
+	0x74, 0xdb, 0xea, 0x07, 0x2e, 0x54, 0xd5, 0x26, 0x0a, 0x63, 0xa4, 0x04, 0xf0, 0x2b, 0xdd, 0xa5,
+	0x03, 0x6b, 0xe7, 0xb2, 0xeb, 0x09, 0xba, 0x33, 0x24, 0x98, 0x87, 0x8f, 0x9d, 0x39, 0xf1, 0xb1,
+	0xcb, 0x4e, 0x1f, 0x52, 0xff, 0x83, 0x01, 0x8c, 0x87, 0xdd, 0xce, 0xc8, 0xf3, 0x5d, 0xaf, 0x9a,
+	0x17, 0xd4, 0x23, 0xf9, 0x09, 0x57, 0xc5, 0xb2, 0x58, 0x23, 0x0a, 0xe7, 0xaa, 0x59, 0x48, 0xcf,
+	0x65, 0xf0, 0x10, 0x20, 0x5c, 0x43, 0xed, 0xa4, 0xff, 0xbd, 0xa8, 0x74, 0x11, 0xef, 0x8c, 0xe7,
+	0x38, 0xe9, 0x64, 0x8e, 0x83, 0xff, 0x68, 0x40, 0x59, 0x5a, 0x46, 0x5d, 0x8b, 0x1f, 0x43, 0x4e,
+	0x2e, 0xab, 0x13, 0x0f, 0x0c, 0x7e, 0x6f, 0x4f, 0x13, 0xf8, 0x14, 0x4d, 0xf4, 0x7d, 0x98, 0xed,
+	0x7a, 0xee, 0x70, 0x48, 0xbb, 0xeb, 0x2a, 0xc4, 0xa6, 0x93, 0x21, 0x76, 0x25, 0xba, 0x4e, 0x12,
+	0xe8, 0xf8, 0x2b, 0x03, 0xe6, 0x3f, 0x91, 0x6a, 0x8f, 0xb1, 0xfd, 0xdf, 0x08, 0xda, 0xdf, 0x96,
+	0xf9, 0x88, 0x4b, 0x64, 0xbe, 0xd9, 0x25, 0xf0, 0xdf, 0x0c, 0x98, 0x51, 0x81, 0x5b, 0x5d, 0x9b,
+	0xc0, 0xd5, 0x8d, 0x97, 0xce, 0x1e, 0xd2, 0xd3, 0x66, 0x0f, 0x47, 0x20, 0xd7, 0xf3, 0xdc, 0xd1,
+	0xd0, 0xaf, 0x66, 0x64, 0x98, 0x94, 0xb3, 0xe9, 0xb2, 0x0a, 0x7c, 0x15, 0x66, 0xb5, 0x28, 0x07,
+	0xbc, 0x5e, 0xb5, 0xe4, 0xeb, 0xb5, 0xda, 0xa5, 0x0e, 0xeb, 0x6f, 0xf6, 0x83, 0xf7, 0x48, 0xe1,
+	0xe3, 0x5f, 0x18, 0x30, 0x97, 0x44, 0x41, 0x2b, 0x91, 0x90, 0xc7, 0xc9, 0x9d, 0x3a, 0x98, 0x5c,
+	0x53, 0xbc, 0x03, 0xfe, 0x47, 0x0e, 0xf3, 0x76, 0x34, 0x69, 0xb9, 0xb7, 0xf6, 0x1e, 0x94, 0x22,
+	0x8b, 0x3c, 0x5b, 0xd8, 0xa6, 0x2a, 0x48, 0x11, 0x3e, 0x0c, 0xa3, 0x73, 0x5a, 0xbe, 0x2d, 0x62,
+	0x82, 0x7f, 0x69, 0xc0, 0x4c, 0xcc, 0xf2, 0xe8, 0x7d, 0x30, 0x37, 0x3d, 0xd7, 0x9e, 0xca, 0x50,
+	0x62, 0x07, 0xfa, 0x7f, 0x48, 0x33, 0x77, 0x2a, 0x33, 0xa5, 0x99, 0xcb, 0xad, 0xa4, 0xc4, 0xcf,
+	0xc8, 0x42, 0x43, 0xce, 0xf0, 0x7b, 0x50, 0x14, 0x02, 0xdd, 0xb2, 0xfa, 0xde, 0xc4, 0x87, 0x7b,
+	0xb2, 0x40, 0x17, 0xe1, 0x90, 0x7c, 0x94, 0x26, 0x6f, 0x2e, 0x4f, 0xda, 0x5c, 0xd6, 0x9b, 0x8f,
+	0x41, 0x76, 0x79, 0x6b, 0xe4, 0x6c, 0xf3, 0x2d, 0x5d, 0x8b, 0x59, 0x7a, 0x0b, 0x1f, 0xe3, 0x37,
+	0x60, 0x9e, 0xbb, 0x3a, 0xf5, 0xfc, 0x65, 0x77, 0xe4, 0x30, 0x5d, 0xe8, 0x9d, 0x81, 0x4a, 0x1c,
+	0xac, 0xbc, 0xa4, 0x02, 0xd9, 0x0e, 0x07, 0x08, 0x1a, 0x33, 0x44, 0x4e, 0xf0, 0x6f, 0x0d, 0x40,
+	0x57, 0x28, 0x13, 0xa7, 0xac, 0xae, 0x04, 0xd7, 0xa3, 0x06, 0x05, 0xdb, 0x62, 0x9d, 0x2d, 0xea,
+	0xf9, 0x3a, 0x1d, 0xd4, 0xf3, 0xff, 0x45, 0xe2, 0x8d, 0xcf, 0xc2, 0x7c, 0x8c, 0x4b, 0x25, 0x53,
+	0x0d, 0x0a, 0x1d, 0x05, 0x53, 0xa9, 0x47, 0x30, 0xc7, 0x7f, 0x4a, 0x43, 0x41, 0x6c, 0x20, 0x74,
+	0x13, 0x9d, 0x85, 0xd2, 0x66, 0xdf, 0xe9, 0x51, 0x6f, 0xe8, 0xf5, 0x95, 0x0a, 0xcc, 0xf6, 0xa1,
+	0xbd, 0x71, 0x23, 0x0a, 0x26, 0xd1, 0x09, 0x7a, 0x07, 0xf2, 0x23, 0x9f, 0x7a, 0xf7, 0xfa, 0xf2,
+	0xa6, 0x17, 0xdb, 0x95, 0xdd, 0x71, 0x23, 0xf7, 0x03, 0x9f, 0x7a, 0xab, 0x2b, 0x3c, 0x09, 0x18,
+	0x89, 0x11, 0x91, 0xdf, 0x2e, 0xba, 0xa6, 0xdc, 0x54, 0xe4, 0xc3, 0xed, 0xef, 0x70, 0xf6, 0x13,
+	0x91, 0x71, 0xe8, 0xb9, 0x36, 0x65, 0x5b, 0x74, 0xe4, 0xb7, 0x3a, 0xae, 0x6d, 0xbb, 0x4e, 0x4b,
+	0x94, 0xf5, 0x42, 0x68, 0x9e, 0xc9, 0xf0, 0xed, 0xca, 0x73, 0x6f, 0x43, 0x9e, 0x6d, 0x79, 0xee,
+	0xa8, 0xb7, 0x25, 0x1e, 0xe8, 0x4c, 0xfb, 0xc2, 0xf4, 0xf4, 0x34, 0x05, 0xa2, 0x07, 0xe8, 0x24,
+	0xd7, 0x16, 0xed, 0x6c, 0xfb, 0x23, 0x5b, 0x16, 0xcb, 0xed, 0xec, 0xde, 0xb8, 0x61, 0xbc, 0x43,
+	0x02, 0x30, 0xfe, 0x34, 0x0d, 0x0d, 0xe1, 0xa8, 0x77, 0x44, 0x06, 0x77, 0xd9, 0xf5, 0xae, 0x53,
+	0xe6, 0xf5, 0x3b, 0x37, 0x2c, 0x9b, 0x6a, 0xdf, 0x68, 0x40, 0xc9, 0x16, 0xc0, 0x7b, 0x91, 0x2b,
+	0x00, 0x76, 0x80, 0x87, 0x4e, 0x00, 0x88, 0x3b, 0x23, 0xd7, 0xe5, 0x6d, 0x28, 0x0a, 0x88, 0x58,
+	0x5e, 0x8e, 0x69, 0xaa, 0x35, 0xa5, 0x64, 0x4a, 0x43, 0xab, 0x49, 0x0d, 0x4d, 0x4d, 0x27, 0x50,
+	0x4b, 0xd4, 0xd7, 0xb3, 0x71, 0x5f, 0xc7, 0x7f, 0x37, 0xa0, 0xbe, 0xa6, 0x39, 0x7f, 0x49, 0x75,
+	0x68, 0x79, 0xd3, 0xaf, 0x48, 0xde, 0xcc, 0xb7, 0x93, 0x17, 0xd7, 0x01, 0xd6, 0xfa, 0x0e, 0xbd,
+	0xdc, 0x1f, 0x30, 0xea, 0x4d, 0x28, 0x0a, 0x3f, 0xcd, 0x84, 0x21, 0x81, 0xd0, 0x4d, 0x2d, 0xe7,
+	0x72, 0x24, 0x0e, 0xbf, 0x0a, 0x31, 0xd2, 0xaf, 0xd0, 0x6c, 0x99, 0x44, 0x88, 0xda, 0x86, 0xfc,
+	0xa6, 0x10, 0x4f, 0x3e, 0xa9, 0xb1, 0xf4, 0x20, 0x94, 0xbd, 0x7d, 0x51, 0x1d, 0x7e, 0xee, 0x79,
+	0xf9, 0x8b, 0x68, 0xc0, 0xb5, 0xfc, 0x1d, 0x87, 0x59, 0x8f, 0x22, 0x9b, 0x89, 0x3e, 0x01, 0xfd,
+	0x54, 0x65, 0xbe, 0xd9, 0x89, 0x99, 0xaf, 0xbe, 0xb9, 0x2f, 0x5f, 0xbe, 0x7f, 0x10, 0xc6, 0x3e,
+	0x61, 0x0e, 0x15, 0xfb, 0x4e, 0x81, 0xe9, 0xd1, 0x4d, 0xfd, 0x48, 0xa3, 0xf0, 0xd8, 0x00, 0x53,
+	0xac, 0xe3, 0x3f, 0x1b, 0x30, 0x77, 0x85, 0xb2, 0x78, 0xfa, 0xf3, 0x1a, 0x19, 0x13, 0x7f, 0x0c,
+	0x87, 0x23, 0xfc, 0x2b, 0xe9, 0xcf, 0x25, 0x72, 0x9e, 0x37, 0x42, 0xf9, 0x57, 0x9d, 0x2e, 0x7d,
+	0xa4, 0xca, 0xf6, 0x78, 0xba, 0x73, 0x0b, 0x4a, 0x91, 0x45, 0x74, 0x29, 0x91, 0xe8, 0x44, 0x9a,
+	0x6c, 0xc1, 0x63, 0xdd, 0xae, 0x28, 0x99, 0x64, 0xe1, 0xae, 0x32, 0xdf, 0x20, 0x29, 0x58, 0x07,
+	0x24, 0xcc, 0x25, 0xc8, 0x46, 0x9f, 0x25, 0x01, 0xbd, 0x16, 0x64, 0x3c, 0xc1, 0x1c, 0x9d, 0x04,
+	0xd3, 0x73, 0x1f, 0xea, 0x7c, 0x77, 0x26, 0x3c, 0x92, 0xb8, 0x0f, 0x89, 0x58, 0xc2, 0x17, 0x21,
+	0x43, 0xdc, 0x87, 0xbc, 0xde, 0xf0, 0x2c, 0xa7, 0x47, 0xef, 0x04, 0x35, 0x6c, 0x99, 0x44, 0x20,
+	0x07, 0xa4, 0x0c, 0xcb, 0x70, 0x38, 0xca, 0x91, 0x34, 0x77, 0x13, 0xf2, 0x32, 0xd3, 0x9f, 0xd0,
+	0xe1, 0x15, 0x88, 0xb2, 0x1d, 0xa2, 0x91, 0xb8, 0xcf, 0x40, 0x08, 0x47, 0xc7, 0xa1, 0xc8, 0xac,
+	0x8d, 0x01, 0xbd, 0x11, 0x06, 0xb8, 0x10, 0xc0, 0x57, 0x79, 0xf9, 0x7d, 0x27, 0x92, 0xfb, 0x84,
+	0x00, 0x74, 0x1a, 0xe6, 0x42, 0x9e, 0x6f, 0x79, 0x74, 0xb3, 0xff, 0x48, 0x58, 0xb8, 0x4c, 0xf6,
+	0xc1, 0xd1, 0x22, 0x1c, 0x0a, 0x61, 0xeb, 0x22, 0xc7, 0x30, 0x05, 0x6a, 0x12, 0xcc, 0x75, 0x23,
+	0xc4, 0xfd, 0xe8, 0xfe, 0xc8, 0x1a, 0x88, 0x9b, 0x57, 0x26, 0x11, 0x08, 0xfe, 0x8b, 0x01, 0x87,
+	0xa5, 0xa9, 0x99, 0xc5, 0x5e, 0x4b, 0xaf, 0xff, 0xdc, 0x00, 0x14, 0x95, 0x40, 0xb9, 0xd6, 0xff,
+	0x45, 0x8b, 0x33, 0x9e, 0xc4, 0x94, 0x44, 0x57, 0x41, 0x82, 0xc2, 0xfa, 0x0a, 0x43, 0x4e, 0x24,
+	0x42, 0xb2, 0xbd, 0x61, 0xca, 0xb6, 0x85, 0x84, 0x10, 0xf5, 0x45, 0x0d, 0xc8, 0x6e, 0xec, 0x30,
+	0xea, 0xab, 0xa6, 0x83, 0xe8, 0xb6, 0x08, 0x00, 0x91, 0x1f, 0x7e, 0x16, 0x75, 0x98, 0xf0, 0x1a,
+	0x33, 0x3c, 0x4b, 0x81, 0x88, 0x1e, 0xe0, 0x3f, 0xa4, 0x61, 0xe6, 0x8e, 0x3b, 0x18, 0x85, 0x4f,
+	0xe2, 0xeb, 0xf4, 0x54, 0xc4, 0x3a, 0x21, 0x59, 0xdd, 0x09, 0x41, 0x60, 0xfa, 0x8c, 0x0e, 0x85,
+	0x67, 0x65, 0x88, 0x18, 0x23, 0x0c, 0x65, 0x66, 0x79, 0x3d, 0xca, 0x64, 0x5d, 0x53, 0xcd, 0x89,
+	0x84, 0x33, 0x06, 0x43, 0x0b, 0x50, 0xb2, 0x7a, 0x3d, 0x8f, 0xf6, 0x2c, 0x46, 0xdb, 0x3b, 0xa2,
+	0x5d, 0x51, 0x24, 0x51, 0x10, 0xfe, 0x11, 0xcc, 0x6a, 0x65, 0x29, 0x93, 0xbe, 0x0b, 0xf9, 0x07,
+	0x02, 0x32, 0xa1, 0xfb, 0x28, 0x51, 0x55, 0x18, 0xd3, 0x68, 0xf1, 0xbf, 0x2a, 0x34, 0xcf, 0xf8,
+	0x2a, 0xe4, 0x24, 0x3a, 0x3a, 0x1e, 0xad, 0x4e, 0x64, 0x9b, 0x8c, 0xcf, 0x55, 0xa9, 0x81, 0x21,
+	0x27, 0x09, 0x29, 0xc3, 0x0b, 0xdf, 0x90, 0x10, 0xa2, 0xbe, 0xa7, 0x4f, 0x41, 0x31, 0xf8, 0x9f,
+	0x01, 0x95, 0x20, 0x7f, 0xf9, 0x26, 0xf9, 0xe1, 0x25, 0xb2, 0x32, 0x97, 0x42, 0x65, 0x28, 0xb4,
+	0x2f, 0x2d, 0x5f, 0x13, 0x33, 0x63, 0xe9, 0x5f, 0xa6, 0x8e, 0x2c, 0x1e, 0xfa, 0x1e, 0x64, 0x65,
+	0xb8, 0x38, 0x12, 0xf2, 0x1f, 0xed, 0xe8, 0xd7, 0x8e, 0xee, 0x83, 0x4b, 0x0d, 0xe0, 0xd4, 0xbb,
+	0x06, 0xba, 0x01, 0x25, 0x01, 0x54, 0xbd, 0xbb, 0xe3, 0xc9, 0x16, 0x5a, 0x8c, 0xd2, 0x89, 0x03,
+	0x56, 0x23, 0xf4, 0x2e, 0x40, 0x56, 0xd8, 0x24, 0xca, 0x4d, 0xb4, 0xf7, 0x1a, 0xe5, 0x26, 0xd6,
+	0xcd, 0xc4, 0x29, 0xf4, 0x5d, 0x30, 0x79, 0x09, 0x85, 0xde, 0x88, 0x37, 0x15, 0xf4, 0xce, 0x23,
+	0x49, 0x70, 0xe4, 0xd8, 0x0f, 0x82, 0xce, 0xe1, 0xd1, 0x64, 0xd9, 0xac, 0xb7, 0x57, 0xf7, 0x2f,
+	0x04, 0x27, 0xdf, 0x94, 0x2d, 0x24, 0x5d, 0xbc, 0xa1, 0x13, 0xf1, 0xa3, 0x12, 0xb5, 0x5e, 0xad,
+	0x7e, 0xd0, 0x72, 0x40, 0x70, 0x0d, 0x4a, 0x91, 0xc2, 0x29, 0xaa, 0xd6, 0xfd, 0x55, 0x5f, 0x54,
+	0xad, 0x13, 0xaa, 0x2d, 0x9c, 0x42, 0x57, 0xa0, 0xc0, 0x9f, 0x62, 0x1e, 0x91, 0xd0, 0xb1, 0xe4,
+	0x8b, 0x1b, 0x89, 0xb4, 0xb5, 0xe3, 0x93, 0x17, 0x03, 0x42, 0x1f, 0x42, 0xf1, 0x0a, 0x65, 0xca,
+	0x5d, 0x8f, 0x26, 0xfd, 0x7d, 0x82, 0xa6, 0xe2, 0x77, 0x06, 0xa7, 0x96, 0xd6, 0xa0, 0xb0, 0xd6,
+	0x7f, 0x40, 0x85, 0x9d, 0x3e, 0x7c, 0xbe, 0xbd, 0x4e, 0xc4, 0xfd, 0x2e, 0xd1, 0xef, 0xe2, 0x66,
+	0x5b, 0xfa, 0x89, 0xfe, 0x37, 0x73, 0xc5, 0x62, 0x16, 0xba, 0x09, 0xb3, 0x42, 0xcc, 0xe0, 0xef,
+	0xce, 0x98, 0x3b, 0xee, 0xfb, 0x6f, 0x35, 0xe6, 0x8e, 0xfb, 0xff, 0x63, 0xc5, 0xa9, 0xf6, 0xdd,
+	0x27, 0x4f, 0xeb, 0xa9, 0x2f, 0x9e, 0xd6, 0x53, 0x5f, 0x3f, 0xad, 0x1b, 0x3f, 0xdf, 0xad, 0x1b,
+	0xbf, 0xdf, 0xad, 0x1b, 0x8f, 0x77, 0xeb, 0xc6, 0x93, 0xdd, 0xba, 0xf1, 0xcf, 0xdd, 0xba, 0xf1,
+	0xd5, 0x6e, 0x3d, 0xf5, 0xf5, 0x6e, 0xdd, 0xf8, 0xec, 0x59, 0x3d, 0xf5, 0xe4, 0x59, 0x3d, 0xf5,
+	0xc5, 0xb3, 0x7a, 0xea, 0xee, 0x9b, 0xdf, 0x90, 0x96, 0xca, 0xb2, 0x39, 0x27, 0x3e, 0xe7, 0xfe,
+	0x1d, 0x00, 0x00, 0xff, 0xff, 0x3c, 0xf5, 0x63, 0x1b, 0x8c, 0x1e, 0x00, 0x00,
 }
 
 func (x Direction) String() string {
@@ -3235,6 +3356,36 @@ func (this *TailRequest) Equal(that interface{}) bool {
 	} else if !this.Plan.Equal(*that1.Plan) {
 		return false
 	}
+	if !this.Cursor.Equal(that1.Cursor) {
+		return false
+	}
+	return true
+}
+func (this *TailCursor) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*TailCursor)
+	if !ok {
+		that2, ok := that.(TailCursor)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if !this.Timestamp.Equal(that1.Timestamp) {
+		return false
+	}
+	if this.StreamHash != that1.StreamHash {
+		return false
+	}
 	return true
 }
 func (this *TailResponse) Equal(that interface{}) bool {
@@ -3273,6 +3424,46 @@ func (this *TailResponse) Equal(that interface{}) bool {
 	}
 	return true
 }
+func (this *QuerierTailResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*QuerierTailResponse)
+	if !ok {
+		that2, ok := that.(QuerierTailResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.Streams) != len(that1.Streams) {
+		return false
+	}
+	for i := range this.Streams {
+		if !this.Streams[i].Equal(that1.Streams[i]) {
+			return false
+		}
+	}
+	if len(this.DroppedStreams) != len(that1.DroppedStreams) {
+		return false
+	}
+	for i := range this.DroppedStreams {
+		if !this.DroppedStreams[i].Equal(that1.DroppedStreams[i]) {
+			return false
+		}
+	}
+	if !this.Cursor.Equal(that1.Cursor) {
+		return false
+	}
+	return true
+}
 func (this *SeriesRequest) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
@@ -4374,13 +4565,27 @@ func (this *TailRequest) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 9)
+	s := make([]string, 0, 10)
 	s = append(s, "&logproto.TailRequest{")
 	s = append(s, "Query: "+fmt.Sprintf("%#v", this.Query)+",\n")
 	s = append(s, "DelayFor: "+fmt.Sprintf("%#v", this.DelayFor)+",\n")
 	s = append(s, "Limit: "+fmt.Sprintf("%#v", this.Limit)+",\n")
 	s = append(s, "Start: "+fmt.Sprintf("%#v", this.Start)+",\n")
 	s = append(s, "Plan: "+fmt.Sprintf("%#v", this.Plan)+",\n")
+	if this.Cursor != nil {
+		s = append(s, "Cursor: "+fmt.Sprintf("%#v", this.Cursor)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *TailCursor) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&logproto.TailCursor{")
+	s = append(s, "Timestamp: "+fmt.Sprintf("%#v", this.Timestamp)+",\n")
+	s = append(s, "StreamHash: "+fmt.Sprintf("%#v", this.StreamHash)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -4397,6 +4602,22 @@ func (this *TailResponse) GoString() string {
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
+func (this *QuerierTailResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 7)
+	s = append(s, "&logproto.QuerierTailResponse{")
+	s = append(s, "Streams: "+fmt.Sprintf("%#v", this.Streams)+",\n")
+	if this.DroppedStreams != nil {
+		s = append(s, "DroppedStreams: "+fmt.Sprintf("%#v", this.DroppedStreams)+",\n")
+	}
+	if this.Cursor != nil {
+		s = append(s, "Cursor: "+fmt.Sprintf("%#v", this.Cursor)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
 func (this *SeriesRequest) GoString() string {
 	if this == nil {
 		return "nil"
@@ -5238,56 +5459,155 @@ var _Querier_serviceDesc = grpc.ServiceDesc{
 	Metadata: "pkg/logproto/logproto.proto",
 }
 
-// StreamDataClient is the client API for StreamData service.
+// LiveTailClient is the client API for LiveTail service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
-type StreamDataClient interface {
-	GetStreamRates(ctx context.Context, in *StreamRatesRequest, opts ...grpc.CallOption) (*StreamRatesResponse, error)
+type LiveTailClient interface {
+	Tail(ctx context.Context, in *TailRequest, opts ...grpc.CallOption) (LiveTail_TailClient, error)
 }
 
-type streamDataClient struct {
+type liveTailClient struct {
 	cc *grpc.ClientConn
 }
 
-func NewStreamDataClient(cc *grpc.ClientConn) StreamDataClient {
-	return &streamDataClient{cc}
+func NewLiveTailClient(cc *grpc.ClientConn) LiveTailClient {
+	return &liveTailClient{cc}
 }
 
-func (c *streamDataClient) GetStreamRates(ctx context.Context, in *StreamRatesRequest, opts ...grpc.CallOption) (*StreamRatesResponse, error) {
-	out := new(StreamRatesResponse)
-	err := c.cc.Invoke(ctx, "/logproto.StreamData/GetStreamRates", in, out, opts...)
+func (c *liveTailClient) Tail(ctx context.Context, in *TailRequest, opts ...grpc.CallOption) (LiveTail_TailClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_LiveTail_serviceDesc.Streams[0], "/logproto.LiveTail/Tail", opts...)
 	if err != nil {
 		return nil, err
 	}
-	return out, nil
+	x := &liveTailTailClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
 }
 
-// StreamDataServer is the server API for StreamData service.
-type StreamDataServer interface {
-	GetStreamRates(context.Context, *StreamRatesRequest) (*StreamRatesResponse, error)
+type LiveTail_TailClient interface {
+	Recv() (*QuerierTailResponse, error)
+	grpc.ClientStream
 }
 
-// UnimplementedStreamDataServer can be embedded to have forward compatible implementations.
-type UnimplementedStreamDataServer struct {
+type liveTailTailClient struct {
+	grpc.ClientStream
 }
 
-func (*UnimplementedStreamDataServer) GetStreamRates(ctx context.Context, req *StreamRatesRequest) (*StreamRatesResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method GetStreamRates not implemented")
+func (x *liveTailTailClient) Recv() (*QuerierTailResponse, error) {
+	m := new(QuerierTailResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
 }
 
-func RegisterStreamDataServer(s *grpc.Server, srv StreamDataServer) {
-	s.RegisterService(&_StreamData_serviceDesc, srv)
+// LiveTailServer is the server API for LiveTail service.
+type LiveTailServer interface {
+	Tail(*TailRequest, LiveTail_TailServer) error
 }
 
-func _StreamData_GetStreamRates_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(StreamRatesRequest)
-	if err := dec(in); err != nil {
-		return nil, err
-	}
-	if interceptor == nil {
-		return srv.(StreamDataServer).GetStreamRates(ctx, in)
-	}
-	info := &grpc.UnaryServerInfo{
+// UnimplementedLiveTailServer can be embedded to have forward compatible implementations.
+type UnimplementedLiveTailServer struct {
+}
+
+func (*UnimplementedLiveTailServer) Tail(req *TailRequest, srv LiveTail_TailServer) error {
+	return status.Errorf(codes.Unimplemented, "method Tail not implemented")
+}
+
+func RegisterLiveTailServer(s *grpc.Server, srv LiveTailServer) {
+	s.RegisterService(&_LiveTail_serviceDesc, srv)
+}
+
+func _LiveTail_Tail_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(TailRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LiveTailServer).Tail(m, &liveTailTailServer{stream})
+}
+
+type LiveTail_TailServer interface {
+	Send(*QuerierTailResponse) error
+	grpc.ServerStream
+}
+
+type liveTailTailServer struct {
+	grpc.ServerStream
+}
+
+func (x *liveTailTailServer) Send(m *QuerierTailResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+var _LiveTail_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "logproto.LiveTail",
+	HandlerType: (*LiveTailServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Tail",
+			Handler:       _LiveTail_Tail_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "pkg/logproto/logproto.proto",
+}
+
+// StreamDataClient is the client API for StreamData service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type StreamDataClient interface {
+	GetStreamRates(ctx context.Context, in *StreamRatesRequest, opts ...grpc.CallOption) (*StreamRatesResponse, error)
+}
+
+type streamDataClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewStreamDataClient(cc *grpc.ClientConn) StreamDataClient {
+	return &streamDataClient{cc}
+}
+
+func (c *streamDataClient) GetStreamRates(ctx context.Context, in *StreamRatesRequest, opts ...grpc.CallOption) (*StreamRatesResponse, error) {
+	out := new(StreamRatesResponse)
+	err := c.cc.Invoke(ctx, "/logproto.StreamData/GetStreamRates", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// StreamDataServer is the server API for StreamData service.
+type StreamDataServer interface {
+	GetStreamRates(context.Context, *StreamRatesRequest) (*StreamRatesResponse, error)
+}
+
+// UnimplementedStreamDataServer can be embedded to have forward compatible implementations.
+type UnimplementedStreamDataServer struct {
+}
+
+func (*UnimplementedStreamDataServer) GetStreamRates(ctx context.Context, req *StreamRatesRequest) (*StreamRatesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStreamRates not implemented")
+}
+
+func RegisterStreamDataServer(s *grpc.Server, srv StreamDataServer) {
+	s.RegisterService(&_StreamData_serviceDesc, srv)
+}
+
+func _StreamData_GetStreamRates_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StreamRatesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StreamDataServer).GetStreamRates(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
 		Server:     srv,
 		FullMethod: "/logproto.StreamData/GetStreamRates",
 	}
@@ -5997,6 +6317,18 @@ func (m *TailRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.Cursor != nil {
+		{
+			size, err := m.Cursor.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintLogproto(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x3a
+	}
 	if m.Plan != nil {
 		{
 			size := m.Plan.Size()
@@ -6009,12 +6341,12 @@ func (m *TailRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i--
 		dAtA[i] = 0x32
 	}
-	n12, err12 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.Start, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.Start):])
-	if err12 != nil {
-		return 0, err12
+	n13, err13 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.Start, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.Start):])
+	if err13 != nil {
+		return 0, err13
 	}
-	i -= n12
-	i = encodeVarintLogproto(dAtA, i, uint64(n12))
+	i -= n13
+	i = encodeVarintLogproto(dAtA, i, uint64(n13))
 	i--
 	dAtA[i] = 0x2a
 	if m.Limit != 0 {
@@ -6037,6 +6369,42 @@ func (m *TailRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *TailCursor) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *TailCursor) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *TailCursor) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.StreamHash != 0 {
+		i = encodeVarintLogproto(dAtA, i, uint64(m.StreamHash))
+		i--
+		dAtA[i] = 0x10
+	}
+	n14, err14 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.Timestamp, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.Timestamp):])
+	if err14 != nil {
+		return 0, err14
+	}
+	i -= n14
+	i = encodeVarintLogproto(dAtA, i, uint64(n14))
+	i--
+	dAtA[i] = 0xa
+	return len(dAtA) - i, nil
+}
+
 func (m *TailResponse) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -6086,6 +6454,69 @@ func (m *TailResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *QuerierTailResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *QuerierTailResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *QuerierTailResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Cursor != nil {
+		{
+			size, err := m.Cursor.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintLogproto(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.DroppedStreams) > 0 {
+		for iNdEx := len(m.DroppedStreams) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.DroppedStreams[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintLogproto(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if len(m.Streams) > 0 {
+		for iNdEx := len(m.Streams) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size := m.Streams[iNdEx].Size()
+				i -= size
+				if _, err := m.Streams[iNdEx].MarshalTo(dAtA[i:]); err != nil {
+					return 0, err
+				}
+				i = encodeVarintLogproto(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
 func (m *SeriesRequest) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -6124,20 +6555,20 @@ func (m *SeriesRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 			dAtA[i] = 0x1a
 		}
 	}
-	n14, err14 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.End, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.End):])
-	if err14 != nil {
-		return 0, err14
+	n17, err17 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.End, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.End):])
+	if err17 != nil {
+		return 0, err17
 	}
-	i -= n14
-	i = encodeVarintLogproto(dAtA, i, uint64(n14))
+	i -= n17
+	i = encodeVarintLogproto(dAtA, i, uint64(n17))
 	i--
 	dAtA[i] = 0x12
-	n15, err15 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.Start, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.Start):])
-	if err15 != nil {
-		return 0, err15
+	n18, err18 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.Start, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.Start):])
+	if err18 != nil {
+		return 0, err18
 	}
-	i -= n15
-	i = encodeVarintLogproto(dAtA, i, uint64(n15))
+	i -= n18
+	i = encodeVarintLogproto(dAtA, i, uint64(n18))
 	i--
 	dAtA[i] = 0xa
 	return len(dAtA) - i, nil
@@ -6281,20 +6712,20 @@ func (m *DroppedStream) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i--
 		dAtA[i] = 0x1a
 	}
-	n16, err16 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.To, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.To):])
-	if err16 != nil {
-		return 0, err16
+	n19, err19 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.To, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.To):])
+	if err19 != nil {
+		return 0, err19
 	}
-	i -= n16
-	i = encodeVarintLogproto(dAtA, i, uint64(n16))
+	i -= n19
+	i = encodeVarintLogproto(dAtA, i, uint64(n19))
 	i--
 	dAtA[i] = 0x12
-	n17, err17 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.From, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.From):])
-	if err17 != nil {
-		return 0, err17
+	n20, err20 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.From, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.From):])
+	if err20 != nil {
+		return 0, err20
 	}
-	i -= n17
-	i = encodeVarintLogproto(dAtA, i, uint64(n17))
+	i -= n20
+	i = encodeVarintLogproto(dAtA, i, uint64(n20))
 	i--
 	dAtA[i] = 0xa
 	return len(dAtA) - i, nil
@@ -6475,20 +6906,20 @@ func (m *GetChunkIDsRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
-	n18, err18 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.End, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.End):])
-	if err18 != nil {
-		return 0, err18
+	n21, err21 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.End, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.End):])
+	if err21 != nil {
+		return 0, err21
 	}
-	i -= n18
-	i = encodeVarintLogproto(dAtA, i, uint64(n18))
+	i -= n21
+	i = encodeVarintLogproto(dAtA, i, uint64(n21))
 	i--
 	dAtA[i] = 0x1a
-	n19, err19 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.Start, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.Start):])
-	if err19 != nil {
-		return 0, err19
+	n22, err22 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.Start, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.Start):])
+	if err22 != nil {
+		return 0, err22
 	}
-	i -= n19
-	i = encodeVarintLogproto(dAtA, i, uint64(n19))
+	i -= n22
+	i = encodeVarintLogproto(dAtA, i, uint64(n22))
 	i--
 	dAtA[i] = 0x12
 	if len(m.Matchers) > 0 {
@@ -7642,6 +8073,24 @@ func (m *TailRequest) Size() (n int) {
 		l = m.Plan.Size()
 		n += 1 + l + sovLogproto(uint64(l))
 	}
+	if m.Cursor != nil {
+		l = m.Cursor.Size()
+		n += 1 + l + sovLogproto(uint64(l))
+	}
+	return n
+}
+
+func (m *TailCursor) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = github_com_gogo_protobuf_types.SizeOfStdTime(m.Timestamp)
+	n += 1 + l + sovLogproto(uint64(l))
+	if m.StreamHash != 0 {
+		n += 1 + sovLogproto(uint64(m.StreamHash))
+	}
 	return n
 }
 
@@ -7664,6 +8113,31 @@ func (m *TailResponse) Size() (n int) {
 	return n
 }
 
+func (m *QuerierTailResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Streams) > 0 {
+		for _, e := range m.Streams {
+			l = e.Size()
+			n += 1 + l + sovLogproto(uint64(l))
+		}
+	}
+	if len(m.DroppedStreams) > 0 {
+		for _, e := range m.DroppedStreams {
+			l = e.Size()
+			n += 1 + l + sovLogproto(uint64(l))
+		}
+	}
+	if m.Cursor != nil {
+		l = m.Cursor.Size()
+		n += 1 + l + sovLogproto(uint64(l))
+	}
+	return n
+}
+
 func (m *SeriesRequest) Size() (n int) {
 	if m == nil {
 		return 0
@@ -8421,6 +8895,18 @@ func (this *TailRequest) String() string {
 		`Limit:` + fmt.Sprintf("%v", this.Limit) + `,`,
 		`Start:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.Start), "Timestamp", "types.Timestamp", 1), `&`, ``, 1) + `,`,
 		`Plan:` + fmt.Sprintf("%v", this.Plan) + `,`,
+		`Cursor:` + strings.Replace(this.Cursor.String(), "TailCursor", "TailCursor", 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *TailCursor) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&TailCursor{`,
+		`Timestamp:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.Timestamp), "Timestamp", "types.Timestamp", 1), `&`, ``, 1) + `,`,
+		`StreamHash:` + fmt.Sprintf("%v", this.StreamHash) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -8441,12 +8927,29 @@ func (this *TailResponse) String() string {
 	}, "")
 	return s
 }
-func (this *SeriesRequest) String() string {
+func (this *QuerierTailResponse) String() string {
 	if this == nil {
 		return "nil"
 	}
-	s := strings.Join([]string{`&SeriesRequest{`,
-		`Start:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.Start), "Timestamp", "types.Timestamp", 1), `&`, ``, 1) + `,`,
+	repeatedStringForDroppedStreams := "[]*DroppedStream{"
+	for _, f := range this.DroppedStreams {
+		repeatedStringForDroppedStreams += strings.Replace(f.String(), "DroppedStream", "DroppedStream", 1) + ","
+	}
+	repeatedStringForDroppedStreams += "}"
+	s := strings.Join([]string{`&QuerierTailResponse{`,
+		`Streams:` + fmt.Sprintf("%v", this.Streams) + `,`,
+		`DroppedStreams:` + repeatedStringForDroppedStreams + `,`,
+		`Cursor:` + strings.Replace(this.Cursor.String(), "TailCursor", "TailCursor", 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *SeriesRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&SeriesRequest{`,
+		`Start:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.Start), "Timestamp", "types.Timestamp", 1), `&`, ``, 1) + `,`,
 		`End:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.End), "Timestamp", "types.Timestamp", 1), `&`, ``, 1) + `,`,
 		`Groups:` + fmt.Sprintf("%v", this.Groups) + `,`,
 		`Shards:` + fmt.Sprintf("%v", this.Shards) + `,`,
@@ -10908,6 +11411,147 @@ func (m *TailRequest) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Cursor", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowLogproto
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Cursor == nil {
+				m.Cursor = &TailCursor{}
+			}
+			if err := m.Cursor.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipLogproto(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *TailCursor) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowLogproto
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: TailCursor: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: TailCursor: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Timestamp", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowLogproto
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdTimeUnmarshal(&m.Timestamp, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StreamHash", wireType)
+			}
+			m.StreamHash = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowLogproto
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.StreamHash |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipLogproto(dAtA[iNdEx:])
@@ -11055,6 +11699,163 @@ func (m *TailResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *QuerierTailResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowLogproto
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: QuerierTailResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: QuerierTailResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Streams", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowLogproto
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Streams = append(m.Streams, github_com_grafana_loki_pkg_push.Stream{})
+			if err := m.Streams[len(m.Streams)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DroppedStreams", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowLogproto
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.DroppedStreams = append(m.DroppedStreams, &DroppedStream{})
+			if err := m.DroppedStreams[len(m.DroppedStreams)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Cursor", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowLogproto
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Cursor == nil {
+				m.Cursor = &TailCursor{}
+			}
+			if err := m.Cursor.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipLogproto(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func (m *SeriesRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
@@ -70,6 +70,46 @@ func ExtractLineFilters(e Expr) []LineFilterExpr {
 	return filters
 }
 
+// ExtractLabelFiltersBeforeParsers returns the label filters of a query with
+// a single log selector which precede the first stage of its pipeline
+// extracting or modifying labels. These filters only apply to the stream
+// labels and to the structured metadata of the entries.
+// It returns nil for the queries with several log selectors, since the
+// filters of one selector don't apply to the entries of the others.
+func ExtractLabelFiltersBeforeParsers(e Expr) []log.LabelFilterer {
+	if e == nil {
+		return nil
+	}
+
+	var (
+		selectors int
+		pipeline  *PipelineExpr
+	)
+	e.Walk(func(e Expr) {
+		switch e := e.(type) {
+		case *MatchersExpr:
+			selectors++
+		case *PipelineExpr:
+			pipeline = e
+		}
+	})
+	if selectors != 1 || pipeline == nil {
+		return nil
+	}
+
+	var filters []log.LabelFilterer
+	for _, stage := range pipeline.MultiStages {
+		switch s := stage.(type) {
+		case *LineFilterExpr:
+		case *LabelFilterExpr:
+			filters = append(filters, s.LabelFilterer)
+		default:
+			return filters
+		}
+	}
+	return filters
+}
+
 // implicit holds default implementations
 type implicit struct{}
 
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"hash/fnv"
+	"io"
 	"math"
 	"net"
 	"net/http"
@@ -38,6 +39,7 @@ import (
 
 	"github.com/grafana/loki/pkg/analytics"
 	"github.com/grafana/loki/pkg/bloomgateway"
+	"github.com/grafana/loki/pkg/chunkenc"
 	"github.com/grafana/loki/pkg/compactor"
 	compactorclient "github.com/grafana/loki/pkg/compactor/client"
 	"github.com/grafana/loki/pkg/compactor/client/grpc"
@@ -45,6 +47,7 @@ import (
 	"github.com/grafana/loki/pkg/compactor/generationnumber"
 	"github.com/grafana/loki/pkg/distributor"
 	"github.com/grafana/loki/pkg/ingester"
+	"github.com/grafana/loki/pkg/kafka"
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/logql"
 	"github.com/grafana/loki/pkg/lokifrontend/frontend"
@@ -64,6 +67,7 @@ import (
 	"github.com/grafana/loki/pkg/storage/chunk/client"
 	chunk_util "github.com/grafana/loki/pkg/storage/chunk/client/util"
 	"github.com/grafana/loki/pkg/storage/config"
+	"github.com/grafana/loki/pkg/storage/dictionaries"
 	"github.com/grafana/loki/pkg/storage/stores/series/index"
 	"github.com/grafana/loki/pkg/storage/stores/shipper/bloomshipper"
 	"github.com/grafana/loki/pkg/storage/stores/shipper/indexshipper"
@@ -256,7 +260,9 @@ func (t *Loki) initRuntimeConfig() (services.Service, error) {
 		return nil, nil
 	}
 
-	t.Cfg.RuntimeConfig.Loader = loadRuntimeConfig
+	t.Cfg.RuntimeConfig.Loader = func(r io.Reader) (interface{}, error) {
+		return loadRuntimeConfig(r, t.Cfg.Ingester.MaxChunkAge/2)
+	}
 
 	// make sure to set default limits before we start loading configuration into memory
 	validation.SetDefaultLimitsForYAMLUnmarshalling(t.Cfg.LimitsConfig)
@@ -314,6 +320,19 @@ func (t *Loki) initTenantConfigs() (_ services.Service, err error) {
 func (t *Loki) initDistributor() (services.Service, error) {
 	var err error
 	logger := log.With(util_log.Logger, "component", "distributor")
+
+	if t.Cfg.Kafka.Enabled {
+		if t.Tee != nil {
+			return nil, errors.New("kafka can't be enabled along with another distributor tee")
+		}
+		writer, err := kafka.NewWriter(t.Cfg.Kafka, prometheus.DefaultRegisterer)
+		if err != nil {
+			return nil, err
+		}
+		t.Tee = distributor.NewKafkaTee(writer, t.Cfg.Kafka, logger, prometheus.DefaultRegisterer)
+	}
+
+	t.Cfg.Distributor.MaxRecvMsgSize = t.Cfg.Server.GRPCServerMaxRecvMsgSize
 	t.distributor, err = distributor.New(
 		t.Cfg.Distributor,
 		t.Cfg.IngesterClient,
@@ -347,8 +366,12 @@ func (t *Loki) initDistributor() (services.Service, error) {
 
 	lokiPushHandler := httpPushHandlerMiddleware.Wrap(http.HandlerFunc(t.distributor.PushHandler))
 	otlpPushHandler := httpPushHandlerMiddleware.Wrap(http.HandlerFunc(t.distributor.OTLPPushHandler))
+	elasticsearchBulkHandler := httpPushHandlerMiddleware.Wrap(http.HandlerFunc(t.distributor.ElasticsearchBulkHandler))
+	splunkHECHandler := httpPushHandlerMiddleware.Wrap(http.HandlerFunc(t.distributor.SplunkHECHandler))
 
 	t.Server.HTTP.Path("/distributor/ring").Methods("GET", "POST").Handler(t.distributor)
+	t.Server.HTTP.Path("/distributor/demoted_labels").Methods("GET").Handler(http.HandlerFunc(t.distributor.DemotedLabelsHandler))
+	t.Server.HTTP.Path("/distributor/policies").Methods("GET").Handler(http.HandlerFunc(t.distributor.StreamPoliciesHandler))
 
 	if t.Cfg.InternalServer.Enable {
 		t.InternalServer.HTTP.Path("/distributor/ring").Methods("GET", "POST").Handler(t.distributor)
@@ -357,6 +380,10 @@ func (t *Loki) initDistributor() (services.Service, error) {
 	t.Server.HTTP.Path("/api/prom/push").Methods("POST").Handler(lokiPushHandler)
 	t.Server.HTTP.Path("/loki/api/v1/push").Methods("POST").Handler(lokiPushHandler)
 	t.Server.HTTP.Path("/otlp/v1/logs").Methods("POST").Handler(otlpPushHandler)
+	t.Server.HTTP.Path("/elasticsearch").Methods("GET", "HEAD").Handler(http.HandlerFunc(t.distributor.ElasticsearchInfoHandler))
+	t.Server.HTTP.Path("/elasticsearch/").Methods("GET", "HEAD").Handler(http.HandlerFunc(t.distributor.ElasticsearchInfoHandler))
+	t.Server.HTTP.Path("/elasticsearch/_bulk").Methods("POST", "PUT").Handler(elasticsearchBulkHandler)
+	t.Server.HTTP.Path("/services/collector/event").Methods("POST").Handler(splunkHECHandler)
 	return t.distributor, nil
 }
 
@@ -520,6 +547,7 @@ func (t *Loki) initQuerier() (services.Service, error) {
 	// on the external router.
 	t.Server.HTTP.Path("/loki/api/v1/tail").Methods("GET", "POST").Handler(httpMiddleware.Wrap(http.HandlerFunc(t.querierAPI.TailHandler)))
 	t.Server.HTTP.Path("/api/prom/tail").Methods("GET", "POST").Handler(httpMiddleware.Wrap(http.HandlerFunc(t.querierAPI.TailHandler)))
+	logproto.RegisterLiveTailServer(t.Server.GRPC, t.querierAPI)
 
 	internalMiddlewares := []queryrangebase.Middleware{
 		serverutil.RecoveryMiddleware,
@@ -554,6 +582,7 @@ func (t *Loki) initQuerier() (services.Service, error) {
 func (t *Loki) initIngester() (_ services.Service, err error) {
 	logger := log.With(util_log.Logger, "component", "ingester")
 	t.Cfg.Ingester.LifecyclerConfig.ListenPort = t.Cfg.Server.GRPCListenPort
+	t.Cfg.Ingester.KafkaConfig = t.Cfg.Kafka
 
 	if t.Cfg.Ingester.ShutdownMarkerPath == "" && t.Cfg.Common.PathPrefix != "" {
 		t.Cfg.Ingester.ShutdownMarkerPath = t.Cfg.Common.PathPrefix
@@ -562,6 +591,10 @@ func (t *Loki) initIngester() (_ services.Service, err error) {
 		level.Warn(util_log.Logger).Log("msg", "The config setting shutdown marker path is not set. The /ingester/prepare_shutdown endpoint won't work")
 	}
 
+	if t.zstdDictionaryStore != nil {
+		t.Cfg.Ingester.ZstdDictionaries = dictionaries.NewProvider(t.zstdDictionaryStore, t.Cfg.StorageConfig.ZstdDictionaries.Dictionaries, t.Overrides, t.Cfg.StorageConfig.ZstdDictionaries.RefreshInterval, logger)
+	}
+
 	t.Ingester, err = ingester.New(t.Cfg.Ingester, t.Cfg.IngesterClient, t.Store, t.Overrides, t.tenantConfigs, prometheus.DefaultRegisterer, t.Cfg.Distributor.WriteFailuresLogging, t.Cfg.MetricsNamespace, logger)
 	if err != nil {
 		return
@@ -574,6 +607,7 @@ func (t *Loki) initIngester() (_ services.Service, err error) {
 	logproto.RegisterPusherServer(t.Server.GRPC, t.Ingester)
 	logproto.RegisterQuerierServer(t.Server.GRPC, t.Ingester)
 	logproto.RegisterStreamDataServer(t.Server.GRPC, t.Ingester)
+	ingester.RegisterHandoffServer(t.Server.GRPC, t.Ingester)
 
 	httpMiddleware := middleware.Merge(
 		serverutil.RecoveryHTTPMiddleware,
@@ -642,6 +676,12 @@ func (t *Loki) initStore() (services.Service, error) {
 		}
 	}
 
+	// The chunks compressed with zstd dictionaries are read with the
+	// dictionaries of the store.
+	if err := t.initZstdDictionaryStore(); err != nil {
+		return nil, err
+	}
+
 	store, err := storage.NewStore(t.Cfg.StorageConfig, t.Cfg.ChunkStoreConfig, t.Cfg.SchemaConfig, t.Overrides, t.ClientMetrics, prometheus.DefaultRegisterer, util_log.Logger, t.Cfg.MetricsNamespace)
 	if err != nil {
 		return nil, err
@@ -1285,6 +1325,14 @@ func (t *Loki) initCompactor() (services.Service, error) {
 		objectClients[periodConfig.From] = objectClient
 	}
 
+	if err := t.initZstdDictionaryStore(); err != nil {
+		return nil, err
+	}
+	var opts compactor.Options
+	if t.zstdDictionaryStore != nil {
+		opts.DictionaryStoreClient = t.zstdDictionaryStore.ObjectClient()
+	}
+
 	var deleteRequestStoreClient client.ObjectClient
 	if t.Cfg.CompactorConfig.RetentionEnabled {
 		if deleteStore := t.Cfg.CompactorConfig.DeleteRequestStore; deleteStore != "" {
@@ -1296,7 +1344,13 @@ func (t *Loki) initCompactor() (services.Service, error) {
 		}
 	}
 
-	t.compactor, err = compactor.NewCompactor(t.Cfg.CompactorConfig, objectClients, deleteRequestStoreClient, t.Cfg.SchemaConfig, t.Overrides, prometheus.DefaultRegisterer, t.Cfg.MetricsNamespace)
+	if t.Cfg.StorageConfig.ColdStorage.Enabled() {
+		if opts.ColdStoreClient, err = storage.NewObjectClient(t.Cfg.StorageConfig.ColdStorage.Store, t.Cfg.StorageConfig, t.ClientMetrics); err != nil {
+			return nil, fmt.Errorf("failed to create cold object store client: %w", err)
+		}
+	}
+
+	t.compactor, err = compactor.NewCompactor(t.Cfg.CompactorConfig, objectClients, deleteRequestStoreClient, t.Cfg.SchemaConfig, t.Overrides, prometheus.DefaultRegisterer, t.Cfg.MetricsNamespace, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -1309,10 +1363,14 @@ func (t *Loki) initCompactor() (services.Service, error) {
 		t.InternalServer.HTTP.Path("/compactor/ring").Methods("GET", "POST").Handler(t.compactor)
 	}
 
+	t.Server.HTTP.Path("/compactor/zstd_dictionaries").Methods("GET").Handler(t.HTTPAuthMiddleware.Wrap(http.HandlerFunc(t.compactor.ZstdDictionaryVersionsHandler)))
+	t.Server.HTTP.Path("/compactor/zstd_dictionaries/retrain").Methods("POST").Handler(t.HTTPAuthMiddleware.Wrap(http.HandlerFunc(t.compactor.RetrainZstdDictionaryHandler)))
+
 	if t.Cfg.CompactorConfig.RetentionEnabled {
 		t.Server.HTTP.Path("/loki/api/v1/delete").Methods("PUT", "POST").Handler(t.addCompactorMiddleware(t.compactor.DeleteRequestsHandler.AddDeleteRequestHandler))
 		t.Server.HTTP.Path("/loki/api/v1/delete").Methods("GET").Handler(t.addCompactorMiddleware(t.compactor.DeleteRequestsHandler.GetAllDeleteRequestsHandler))
 		t.Server.HTTP.Path("/loki/api/v1/delete").Methods("DELETE").Handler(t.addCompactorMiddleware(t.compactor.DeleteRequestsHandler.CancelDeleteRequestHandler))
+		t.Server.HTTP.Path("/loki/api/v1/delete/chunks").Methods("GET").Handler(t.addCompactorMiddleware(t.compactor.DeleteRequestsHandler.GetDeletedChunksHandler))
 		t.Server.HTTP.Path("/loki/api/v1/cache/generation_numbers").Methods("GET").Handler(t.addCompactorMiddleware(t.compactor.DeleteRequestsHandler.GetCacheGenerationNumberHandler))
 		grpc.RegisterCompactorServer(t.Server.GRPC, t.compactor.DeleteRequestsGRPCHandler)
 	}
@@ -1320,6 +1378,27 @@ func (t *Loki) initCompactor() (services.Service, error) {
 	return t.compactor, nil
 }
 
+// initZstdDictionaryStore sets up the store of the zstd dictionaries of the
+// tenants, which the dictionaries referenced by the chunks are fetched from.
+func (t *Loki) initZstdDictionaryStore() error {
+	if t.zstdDictionaryStore != nil || !config.UsingObjectStorageIndex(t.Cfg.SchemaConfig.Configs) {
+		return nil
+	}
+
+	objectType := t.Cfg.StorageConfig.ZstdDictionaries.Store
+	if objectType == "" {
+		objectType = t.Cfg.SchemaConfig.Configs[config.ActivePeriodConfig(t.Cfg.SchemaConfig.Configs)].ObjectType
+	}
+	objectClient, err := storage.NewObjectClient(objectType, t.Cfg.StorageConfig, t.ClientMetrics)
+	if err != nil {
+		return fmt.Errorf("failed to create zstd dictionaries object client: %w", err)
+	}
+
+	t.zstdDictionaryStore = dictionaries.NewStore(objectClient)
+	t.Cfg.StorageConfig.ZstdDictionaries.Dictionaries = chunkenc.NewDictionaries(t.zstdDictionaryStore.Fetch)
+	return nil
+}
+
 func (t *Loki) addCompactorMiddleware(h http.HandlerFunc) http.Handler {
 	return t.HTTPAuthMiddleware.Wrap(deletion.TenantMiddleware(t.Overrides, h))
 }
@@ -1414,7 +1493,7 @@ func (t *Loki) initIndexGateway() (services.Service, error) {
 		if err != nil {
 			return nil, err
 		}
-		bloomQuerier = bloomgateway.NewQuerier(bloomGatewayClient, prometheus.DefaultRegisterer, logger)
+		bloomQuerier = bloomgateway.NewQuerier(bloomGatewayClient, t.Overrides, prometheus.DefaultRegisterer, logger)
 	}
 
 	gateway, err := indexgateway.NewIndexGateway(t.Cfg.IndexGateway, logger, prometheus.DefaultRegisterer, t.Store, indexClients, bloomQuerier)
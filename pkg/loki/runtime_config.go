@@ -3,6 +3,7 @@ package loki
 import (
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/go-kit/log/level"
 	"github.com/grafana/dskit/kv"
@@ -27,7 +28,7 @@ type runtimeConfigValues struct {
 	Multi kv.MultiRuntimeConfig `yaml:"multi_kv_config"`
 }
 
-func (r runtimeConfigValues) validate() error {
+func (r runtimeConfigValues) validate(maxOutOfOrderWindow time.Duration) error {
 	for t, c := range r.TenantLimits {
 		if c == nil {
 			level.Warn(util_log.Logger).Log("msg", "skipping empty tenant limit definition", "tenant", t)
@@ -37,11 +38,16 @@ func (r runtimeConfigValues) validate() error {
 		if err := c.Validate(); err != nil {
 			return fmt.Errorf("invalid override for tenant %s: %w", t, err)
 		}
+		if err := c.ValidateOutOfOrderWindows(maxOutOfOrderWindow); err != nil {
+			return fmt.Errorf("invalid override for tenant %s: %w", t, err)
+		}
 	}
 	return nil
 }
 
-func loadRuntimeConfig(r io.Reader) (interface{}, error) {
+// loadRuntimeConfig loads the runtime config, rejecting the out-of-order
+// windows of the tenants larger than maxOutOfOrderWindow.
+func loadRuntimeConfig(r io.Reader, maxOutOfOrderWindow time.Duration) (interface{}, error) {
 	overrides := &runtimeConfigValues{}
 
 	decoder := yaml.NewDecoder(r)
@@ -49,7 +55,7 @@ func loadRuntimeConfig(r io.Reader) (interface{}, error) {
 	if err := decoder.Decode(&overrides); err != nil {
 		return nil, err
 	}
-	if err := overrides.validate(); err != nil {
+	if err := overrides.validate(maxOutOfOrderWindow); err != nil {
 		return nil, err
 	}
 	return overrides, nil
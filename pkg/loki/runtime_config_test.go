@@ -70,7 +70,7 @@ overrides:
             - selector: '{namespace="bar", cluster=~"fo.*|b.+|[1-2]"}'
               period: 24h
               priority: 10
-`))
+`), time.Hour)
 	require.Equal(t, "invalid override for tenant 29: invalid labels matchers: parse error at line 1, col 6: syntax error: unexpected IDENTIFIER, expecting STRING", err.Error())
 	_, err = loadRuntimeConfig(strings.NewReader(
 		`
@@ -80,8 +80,18 @@ overrides:
             - selector: '{app="foo"}'
               period: 5h
               priority: 10
-`))
+`), time.Hour)
 	require.Equal(t, "invalid override for tenant 29: retention period must be >= 24h was 5h", err.Error())
+	_, err = loadRuntimeConfig(strings.NewReader(
+		`
+overrides:
+    "29":
+        out_of_order_window_policies:
+            - name: late
+              selector: '{app="foo"}'
+              window: 2h
+`), time.Hour)
+	require.Equal(t, "invalid override for tenant 29: out-of-order window policy late window 2h must not exceed half of the ingester max_chunk_age (1h)", err.Error())
 }
 
 func Test_DefaultConfig(t *testing.T) {
@@ -117,7 +127,7 @@ func newTestRuntimeconfig(t *testing.T, yaml string) runtime.TenantConfigProvide
 	path := f.Name()
 	// fake loader to load from string instead of file.
 	loader := func(_ io.Reader) (interface{}, error) {
-		return loadRuntimeConfig(strings.NewReader(yaml))
+		return loadRuntimeConfig(strings.NewReader(yaml), time.Hour)
 	}
 	cfg := runtimeconfig.Config{
 		ReloadPeriod: 1 * time.Second,
@@ -150,7 +160,7 @@ func newTestOverrides(t *testing.T, yaml string) *validation.Overrides {
 	path := f.Name()
 	// fake loader to load from string instead of file.
 	loader := func(_ io.Reader) (interface{}, error) {
-		return loadRuntimeConfig(strings.NewReader(yaml))
+		return loadRuntimeConfig(strings.NewReader(yaml), time.Hour)
 	}
 	cfg := runtimeconfig.Config{
 		ReloadPeriod: 1 * time.Second,
@@ -0,0 +1,108 @@
+package push
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrUnknownLabelSet is returned when a batched push request references a
+// label set which was not defined in its session. This happens when the
+// session expired or the request reached another server, and the client must
+// start a new session, sending the label sets again.
+var ErrUnknownLabelSet = errors.New("unknown label set")
+
+// BatchEncoder builds the batched push requests of a session. The label set
+// of a stream is only sent in the first request of the session it is part of.
+//
+// The label sets are considered sent once encoded, so the encoder must be
+// reset when a request fails, in order to start a new session.
+type BatchEncoder struct {
+	ids    map[string]uint32
+	nextID uint32
+}
+
+// NewBatchEncoder creates a BatchEncoder for a new session.
+func NewBatchEncoder() *BatchEncoder {
+	return &BatchEncoder{ids: map[string]uint32{}}
+}
+
+// Encode converts the given streams into a batched push request, defining the
+// label sets not sent in this session yet.
+func (e *BatchEncoder) Encode(streams []Stream) *BatchedPushRequest {
+	req := &BatchedPushRequest{Streams: make([]BatchedStream, 0, len(streams))}
+	for _, s := range streams {
+		id, ok := e.ids[s.Labels]
+		if !ok {
+			id = e.nextID
+			e.nextID++
+			e.ids[s.Labels] = id
+			req.LabelSets = append(req.LabelSets, LabelSetDefinition{Id: id, Labels: s.Labels})
+		}
+		req.Streams = append(req.Streams, BatchedStream{LabelSetID: id, Entries: s.Entries})
+	}
+	return req
+}
+
+// Reset forgets about the label sets sent so far, starting a new session.
+func (e *BatchEncoder) Reset() {
+	e.ids = map[string]uint32{}
+	e.nextID = 0
+}
+
+// BatchDecoder resolves the label sets of the batched push requests of a
+// session. A decoder isn't safe for concurrent use, since the label sets
+// defined by a request can be referenced by the next ones.
+type BatchDecoder struct {
+	labelSets    map[uint32]string
+	maxLabelSets int
+}
+
+// NewBatchDecoder creates a BatchDecoder for a new session, accepting at
+// most maxLabelSets label set definitions. Zero means no limit.
+func NewBatchDecoder(maxLabelSets int) *BatchDecoder {
+	return &BatchDecoder{labelSets: map[uint32]string{}, maxLabelSets: maxLabelSets}
+}
+
+// NewLabelSets returns the number of label sets defined by the request which
+// aren't known to the decoder yet.
+func (d *BatchDecoder) NewLabelSets(req *BatchedPushRequest) int {
+	var (
+		n    int
+		seen map[uint32]struct{}
+	)
+	for _, def := range req.LabelSets {
+		if _, ok := d.labelSets[def.Id]; ok {
+			continue
+		}
+		if _, ok := seen[def.Id]; ok {
+			continue
+		}
+		if seen == nil {
+			seen = map[uint32]struct{}{}
+		}
+		seen[def.Id] = struct{}{}
+		n++
+	}
+	return n
+}
+
+// Decode converts a batched push request into a push request, after
+// recording the label sets it defines.
+func (d *BatchDecoder) Decode(req *BatchedPushRequest) (*PushRequest, error) {
+	for _, def := range req.LabelSets {
+		if _, ok := d.labelSets[def.Id]; !ok && d.maxLabelSets > 0 && len(d.labelSets) >= d.maxLabelSets {
+			return nil, fmt.Errorf("too many label sets defined in the session, the limit is %d", d.maxLabelSets)
+		}
+		d.labelSets[def.Id] = def.Labels
+	}
+
+	res := &PushRequest{Streams: make([]Stream, 0, len(req.Streams))}
+	for _, s := range req.Streams {
+		lbs, ok := d.labelSets[s.LabelSetID]
+		if !ok {
+			return nil, fmt.Errorf("%w: %d", ErrUnknownLabelSet, s.LabelSetID)
+		}
+		res.Streams = append(res.Streams, Stream{Labels: lbs, Entries: s.Entries})
+	}
+	return res, nil
+}
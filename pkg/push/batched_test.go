@@ -0,0 +1,78 @@
+package push
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchEncoderDecoder(t *testing.T) {
+	other := Stream{Labels: `{job="other"}`, Entries: []Entry{{Timestamp: now, Line: "other"}}}
+
+	encoder := NewBatchEncoder()
+	decoder := NewBatchDecoder(0)
+
+	roundTrip := func(streams []Stream) (*BatchedPushRequest, *PushRequest, error) {
+		b, err := encoder.Encode(streams).Marshal()
+		require.NoError(t, err)
+
+		var req BatchedPushRequest
+		require.NoError(t, req.Unmarshal(b))
+		res, err := decoder.Decode(&req)
+		return &req, res, err
+	}
+
+	req, res, err := roundTrip([]Stream{stream, other, stream})
+	require.NoError(t, err)
+	require.Len(t, req.LabelSets, 2)
+	require.Equal(t, []Stream{
+		{Labels: stream.Labels, Entries: stream.Entries},
+		other,
+		{Labels: stream.Labels, Entries: stream.Entries},
+	}, res.Streams)
+
+	// Label sets are only sent once per session.
+	req, res, err = roundTrip([]Stream{other})
+	require.NoError(t, err)
+	require.Empty(t, req.LabelSets)
+	require.Equal(t, []Stream{other}, res.Streams)
+
+	// A new session on the server side doesn't know about the label sets.
+	decoder = NewBatchDecoder(0)
+	_, _, err = roundTrip([]Stream{other})
+	require.True(t, errors.Is(err, ErrUnknownLabelSet))
+
+	encoder.Reset()
+	req, res, err = roundTrip([]Stream{other})
+	require.NoError(t, err)
+	require.Len(t, req.LabelSets, 1)
+	require.Equal(t, []Stream{other}, res.Streams)
+}
+
+func TestBatchDecoderMaxLabelSets(t *testing.T) {
+	decoder := NewBatchDecoder(1)
+
+	_, err := decoder.Decode(&BatchedPushRequest{LabelSets: []LabelSetDefinition{{Id: 0, Labels: `{job="a"}`}}})
+	require.NoError(t, err)
+	// Redefining a label set doesn't count against the limit.
+	_, err = decoder.Decode(&BatchedPushRequest{LabelSets: []LabelSetDefinition{{Id: 0, Labels: `{job="a"}`}}})
+	require.NoError(t, err)
+	_, err = decoder.Decode(&BatchedPushRequest{LabelSets: []LabelSetDefinition{{Id: 1, Labels: `{job="b"}`}}})
+	require.Error(t, err)
+}
+
+func TestBatchDecoderNewLabelSets(t *testing.T) {
+	decoder := NewBatchDecoder(0)
+	req := &BatchedPushRequest{LabelSets: []LabelSetDefinition{
+		{Id: 0, Labels: `{job="a"}`},
+		{Id: 1, Labels: `{job="b"}`},
+		{Id: 1, Labels: `{job="b"}`},
+	}}
+	require.Equal(t, 2, decoder.NewLabelSets(req))
+
+	_, err := decoder.Decode(req)
+	require.NoError(t, err)
+	require.Equal(t, 0, decoder.NewLabelSets(req))
+	require.Equal(t, 1, decoder.NewLabelSets(&BatchedPushRequest{LabelSets: []LabelSetDefinition{{Id: 2, Labels: `{job="c"}`}}}))
+}
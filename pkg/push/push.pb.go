@@ -285,51 +285,213 @@ func (m *EntryAdapter) GetParsed() []LabelPairAdapter {
 	return nil
 }
 
+// BatchedPushRequest is the body of a push using the batched format, where
+// the label set of a stream is sent once per session and then referenced by
+// its ID.
+type BatchedPushRequest struct {
+	// labelSets defines label sets which can be referenced by the streams of
+	// this request and of the next requests of the session.
+	LabelSets []LabelSetDefinition `protobuf:"bytes,1,rep,name=labelSets,proto3" json:"labelSets"`
+	Streams   []BatchedStream      `protobuf:"bytes,2,rep,name=streams,proto3" json:"streams"`
+}
+
+func (m *BatchedPushRequest) Reset()      { *m = BatchedPushRequest{} }
+func (*BatchedPushRequest) ProtoMessage() {}
+func (*BatchedPushRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_35ec442956852c9e, []int{5}
+}
+func (m *BatchedPushRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *BatchedPushRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_BatchedPushRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *BatchedPushRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BatchedPushRequest.Merge(m, src)
+}
+func (m *BatchedPushRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *BatchedPushRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_BatchedPushRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BatchedPushRequest proto.InternalMessageInfo
+
+func (m *BatchedPushRequest) GetLabelSets() []LabelSetDefinition {
+	if m != nil {
+		return m.LabelSets
+	}
+	return nil
+}
+
+func (m *BatchedPushRequest) GetStreams() []BatchedStream {
+	if m != nil {
+		return m.Streams
+	}
+	return nil
+}
+
+type LabelSetDefinition struct {
+	Id     uint32 `protobuf:"varint,1,opt,name=id,proto3" json:"id"`
+	Labels string `protobuf:"bytes,2,opt,name=labels,proto3" json:"labels"`
+}
+
+func (m *LabelSetDefinition) Reset()      { *m = LabelSetDefinition{} }
+func (*LabelSetDefinition) ProtoMessage() {}
+func (*LabelSetDefinition) Descriptor() ([]byte, []int) {
+	return fileDescriptor_35ec442956852c9e, []int{6}
+}
+func (m *LabelSetDefinition) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *LabelSetDefinition) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_LabelSetDefinition.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *LabelSetDefinition) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LabelSetDefinition.Merge(m, src)
+}
+func (m *LabelSetDefinition) XXX_Size() int {
+	return m.Size()
+}
+func (m *LabelSetDefinition) XXX_DiscardUnknown() {
+	xxx_messageInfo_LabelSetDefinition.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LabelSetDefinition proto.InternalMessageInfo
+
+func (m *LabelSetDefinition) GetId() uint32 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *LabelSetDefinition) GetLabels() string {
+	if m != nil {
+		return m.Labels
+	}
+	return ""
+}
+
+type BatchedStream struct {
+	LabelSetID uint32  `protobuf:"varint,1,opt,name=labelSetID,proto3" json:"labelSetID"`
+	Entries    []Entry `protobuf:"bytes,2,rep,name=entries,proto3,customtype=Entry" json:"entries"`
+}
+
+func (m *BatchedStream) Reset()      { *m = BatchedStream{} }
+func (*BatchedStream) ProtoMessage() {}
+func (*BatchedStream) Descriptor() ([]byte, []int) {
+	return fileDescriptor_35ec442956852c9e, []int{7}
+}
+func (m *BatchedStream) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *BatchedStream) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_BatchedStream.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *BatchedStream) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BatchedStream.Merge(m, src)
+}
+func (m *BatchedStream) XXX_Size() int {
+	return m.Size()
+}
+func (m *BatchedStream) XXX_DiscardUnknown() {
+	xxx_messageInfo_BatchedStream.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BatchedStream proto.InternalMessageInfo
+
+func (m *BatchedStream) GetLabelSetID() uint32 {
+	if m != nil {
+		return m.LabelSetID
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*PushRequest)(nil), "logproto.PushRequest")
 	proto.RegisterType((*PushResponse)(nil), "logproto.PushResponse")
 	proto.RegisterType((*StreamAdapter)(nil), "logproto.StreamAdapter")
 	proto.RegisterType((*LabelPairAdapter)(nil), "logproto.LabelPairAdapter")
 	proto.RegisterType((*EntryAdapter)(nil), "logproto.EntryAdapter")
+	proto.RegisterType((*BatchedPushRequest)(nil), "logproto.BatchedPushRequest")
+	proto.RegisterType((*LabelSetDefinition)(nil), "logproto.LabelSetDefinition")
+	proto.RegisterType((*BatchedStream)(nil), "logproto.BatchedStream")
 }
 
 func init() { proto.RegisterFile("pkg/push/push.proto", fileDescriptor_35ec442956852c9e) }
 
 var fileDescriptor_35ec442956852c9e = []byte{
-	// 527 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x53, 0xc1, 0x6e, 0xd3, 0x40,
-	0x10, 0xf5, 0x26, 0x6e, 0xda, 0x6e, 0x4a, 0xa9, 0x96, 0xb6, 0x18, 0xab, 0x5a, 0x47, 0x16, 0x87,
-	0x1c, 0xc0, 0x96, 0xc2, 0x81, 0x0b, 0x97, 0x58, 0x42, 0xea, 0xa1, 0x48, 0x95, 0x41, 0x20, 0x71,
-	0xdb, 0x34, 0x5b, 0xdb, 0xaa, 0xed, 0x35, 0xbb, 0x6b, 0xa4, 0xde, 0xf8, 0x84, 0xf2, 0x17, 0x7c,
-	0x01, 0xdf, 0xd0, 0x63, 0x8e, 0x15, 0x07, 0x43, 0x9c, 0x0b, 0xca, 0xa9, 0x9f, 0x80, 0xbc, 0xb6,
-	0x49, 0x28, 0x48, 0x5c, 0x36, 0x6f, 0x66, 0x67, 0xde, 0x7b, 0x99, 0x1d, 0xc3, 0x07, 0xd9, 0x45,
-	0xe0, 0x66, 0xb9, 0x08, 0xd5, 0xe1, 0x64, 0x9c, 0x49, 0x86, 0xb6, 0x62, 0x16, 0x28, 0x64, 0xee,
-	0x07, 0x2c, 0x60, 0x0a, 0xba, 0x15, 0xaa, 0xef, 0x4d, 0x2b, 0x60, 0x2c, 0x88, 0xa9, 0xab, 0xa2,
-	0x49, 0x7e, 0xee, 0xca, 0x28, 0xa1, 0x42, 0x92, 0x24, 0xab, 0x0b, 0xec, 0x77, 0xb0, 0x7f, 0x9a,
-	0x8b, 0xd0, 0xa7, 0x1f, 0x72, 0x2a, 0x24, 0x3a, 0x86, 0x9b, 0x42, 0x72, 0x4a, 0x12, 0x61, 0x80,
-	0x41, 0x77, 0xd8, 0x1f, 0x3d, 0x74, 0x5a, 0x05, 0xe7, 0xb5, 0xba, 0x18, 0x4f, 0x49, 0x26, 0x29,
-	0xf7, 0x0e, 0xbe, 0x15, 0x56, 0xaf, 0x4e, 0x2d, 0x0b, 0xab, 0xed, 0xf2, 0x5b, 0x60, 0xef, 0xc2,
-	0x9d, 0x9a, 0x58, 0x64, 0x2c, 0x15, 0xd4, 0xfe, 0x0c, 0xe0, 0xbd, 0x3f, 0x18, 0x90, 0x0d, 0x7b,
-	0x31, 0x99, 0xd0, 0xb8, 0x92, 0x02, 0xc3, 0x6d, 0x0f, 0x2e, 0x0b, 0xab, 0xc9, 0xf8, 0xcd, 0x2f,
-	0x1a, 0xc3, 0x4d, 0x9a, 0x4a, 0x1e, 0x51, 0x61, 0x74, 0x94, 0x9f, 0xc3, 0x95, 0x9f, 0x97, 0xa9,
-	0xe4, 0x97, 0xad, 0x9d, 0xfb, 0xd7, 0x85, 0xa5, 0x55, 0x46, 0x9a, 0x72, 0xbf, 0x05, 0xe8, 0x11,
-	0xd4, 0x43, 0x22, 0x42, 0xa3, 0x3b, 0x00, 0x43, 0xdd, 0xdb, 0x58, 0x16, 0x16, 0x78, 0xea, 0xab,
-	0x94, 0xfd, 0x02, 0xee, 0x9d, 0x54, 0x3a, 0xa7, 0x24, 0xe2, 0xad, 0x2b, 0x04, 0xf5, 0x94, 0x24,
-	0xb4, 0xf6, 0xe4, 0x2b, 0x8c, 0xf6, 0xe1, 0xc6, 0x47, 0x12, 0xe7, 0xd4, 0xe8, 0xa8, 0x64, 0x1d,
-	0xd8, 0x5f, 0x3b, 0x70, 0x67, 0xdd, 0x03, 0x3a, 0x86, 0xdb, 0xbf, 0xc7, 0xab, 0xfa, 0xfb, 0x23,
-	0xd3, 0xa9, 0x1f, 0xc0, 0x69, 0x1f, 0xc0, 0x79, 0xd3, 0x56, 0x78, 0xbb, 0x8d, 0xe5, 0x8e, 0x14,
-	0x57, 0xdf, 0x2d, 0xe0, 0xaf, 0x9a, 0xd1, 0x11, 0xd4, 0xe3, 0x28, 0x6d, 0xf4, 0xbc, 0xad, 0x65,
-	0x61, 0xa9, 0xd8, 0x57, 0x27, 0xca, 0x20, 0x12, 0x92, 0xe7, 0x67, 0x32, 0xe7, 0x74, 0xfa, 0x8a,
-	0x4a, 0x32, 0x25, 0x92, 0x18, 0x5d, 0x35, 0x1f, 0x73, 0x35, 0x9f, 0xbb, 0x7f, 0xcd, 0x7b, 0xdc,
-	0x08, 0x1e, 0xfd, 0xdd, 0xfd, 0x84, 0x25, 0x91, 0xa4, 0x49, 0x26, 0x2f, 0xfd, 0x7f, 0x70, 0xa3,
-	0x13, 0xd8, 0xcb, 0x08, 0x17, 0x74, 0x6a, 0xe8, 0xff, 0x55, 0x31, 0x1a, 0x95, 0xbd, 0xba, 0x63,
-	0x8d, 0xb9, 0xe1, 0x18, 0x8d, 0x61, 0xaf, 0x5a, 0x0d, 0xca, 0xd1, 0x73, 0xa8, 0x57, 0x08, 0x1d,
-	0xac, 0xf8, 0xd6, 0xb6, 0xd1, 0x3c, 0xbc, 0x9b, 0x6e, 0x76, 0x49, 0xf3, 0xde, 0xce, 0xe6, 0x58,
-	0xbb, 0x99, 0x63, 0xed, 0x76, 0x8e, 0xc1, 0xa7, 0x12, 0x83, 0x2f, 0x25, 0x06, 0xd7, 0x25, 0x06,
-	0xb3, 0x12, 0x83, 0x1f, 0x25, 0x06, 0x3f, 0x4b, 0xac, 0xdd, 0x96, 0x18, 0x5c, 0x2d, 0xb0, 0x36,
-	0x5b, 0x60, 0xed, 0x66, 0x81, 0xb5, 0xf7, 0x83, 0x20, 0x92, 0x61, 0x3e, 0x71, 0xce, 0x58, 0xe2,
-	0x06, 0x9c, 0x9c, 0x93, 0x94, 0xb8, 0x31, 0xbb, 0x88, 0xdc, 0xf6, 0xd3, 0x9a, 0xf4, 0x94, 0xda,
-	0xb3, 0x5f, 0x01, 0x00, 0x00, 0xff, 0xff, 0x7e, 0xaa, 0x57, 0xd3, 0x6d, 0x03, 0x00, 0x00,
+	// 649 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x54, 0x4d, 0x4f, 0xd4, 0x40,
+	0x18, 0xee, 0x94, 0x65, 0x81, 0x97, 0x0f, 0x71, 0x04, 0x5c, 0x37, 0xa4, 0x25, 0x8d, 0x07, 0x0e,
+	0xda, 0x26, 0x78, 0xf0, 0xe2, 0x85, 0x06, 0x13, 0x34, 0x90, 0x90, 0x62, 0x34, 0xf1, 0x36, 0x4b,
+	0x87, 0xee, 0x84, 0x7e, 0xd9, 0x99, 0x9a, 0x70, 0xf3, 0xee, 0x05, 0x7f, 0x85, 0xfe, 0x02, 0x7f,
+	0x03, 0x47, 0x8e, 0x84, 0x43, 0x95, 0x72, 0x31, 0x7b, 0xe2, 0x27, 0x98, 0x4e, 0x5b, 0x5b, 0x16,
+	0x8c, 0x5e, 0xba, 0xcf, 0xbc, 0x9d, 0xf7, 0x7d, 0x9e, 0x9d, 0xe7, 0x99, 0xc2, 0x83, 0xf8, 0xc8,
+	0xb3, 0xe2, 0x94, 0x0f, 0xe5, 0xc3, 0x8c, 0x93, 0x48, 0x44, 0x78, 0xda, 0x8f, 0x3c, 0x89, 0xfa,
+	0x4b, 0x5e, 0xe4, 0x45, 0x12, 0x5a, 0x05, 0x2a, 0xdf, 0xf7, 0x75, 0x2f, 0x8a, 0x3c, 0x9f, 0x5a,
+	0x72, 0x35, 0x48, 0x0f, 0x2d, 0xc1, 0x02, 0xca, 0x05, 0x09, 0xe2, 0x72, 0x83, 0xf1, 0x0e, 0x66,
+	0xf7, 0x52, 0x3e, 0x74, 0xe8, 0x87, 0x94, 0x72, 0x81, 0xb7, 0x61, 0x8a, 0x8b, 0x84, 0x92, 0x80,
+	0xf7, 0xd0, 0xda, 0xc4, 0xfa, 0xec, 0xc6, 0x43, 0xb3, 0x66, 0x30, 0xf7, 0xe5, 0x8b, 0x4d, 0x97,
+	0xc4, 0x82, 0x26, 0xf6, 0xf2, 0x45, 0xa6, 0x77, 0xcb, 0xd2, 0x28, 0xd3, 0xeb, 0x2e, 0xa7, 0x06,
+	0xc6, 0x02, 0xcc, 0x95, 0x83, 0x79, 0x1c, 0x85, 0x9c, 0x1a, 0x5f, 0x10, 0xcc, 0xdf, 0x98, 0x80,
+	0x0d, 0xe8, 0xfa, 0x64, 0x40, 0xfd, 0x82, 0x0a, 0xad, 0xcf, 0xd8, 0x30, 0xca, 0xf4, 0xaa, 0xe2,
+	0x54, 0xbf, 0x78, 0x13, 0xa6, 0x68, 0x28, 0x12, 0x46, 0x79, 0x4f, 0x95, 0x7a, 0x56, 0x1a, 0x3d,
+	0x2f, 0x43, 0x91, 0x1c, 0xd7, 0x72, 0xee, 0x9d, 0x66, 0xba, 0x52, 0x08, 0xa9, 0xb6, 0x3b, 0x35,
+	0xc0, 0x8f, 0xa0, 0x33, 0x24, 0x7c, 0xd8, 0x9b, 0x58, 0x43, 0xeb, 0x1d, 0x7b, 0x72, 0x94, 0xe9,
+	0xe8, 0xa9, 0x23, 0x4b, 0xc6, 0x0b, 0x58, 0xdc, 0x29, 0x78, 0xf6, 0x08, 0x4b, 0x6a, 0x55, 0x18,
+	0x3a, 0x21, 0x09, 0x68, 0xa9, 0xc9, 0x91, 0x18, 0x2f, 0xc1, 0xe4, 0x47, 0xe2, 0xa7, 0xb4, 0xa7,
+	0xca, 0x62, 0xb9, 0x30, 0xbe, 0xab, 0x30, 0xd7, 0xd6, 0x80, 0xb7, 0x61, 0xe6, 0xcf, 0xf1, 0xca,
+	0xfe, 0xd9, 0x8d, 0xbe, 0x59, 0x1a, 0x60, 0xd6, 0x06, 0x98, 0x6f, 0xea, 0x1d, 0xf6, 0x42, 0x25,
+	0x59, 0x15, 0xfc, 0xe4, 0x87, 0x8e, 0x9c, 0xa6, 0x19, 0xaf, 0x42, 0xc7, 0x67, 0x61, 0xc5, 0x67,
+	0x4f, 0x8f, 0x32, 0x5d, 0xae, 0x1d, 0xf9, 0xc4, 0x31, 0x60, 0x2e, 0x92, 0xf4, 0x40, 0xa4, 0x09,
+	0x75, 0x77, 0xa9, 0x20, 0x2e, 0x11, 0xa4, 0x37, 0x21, 0xcf, 0xa7, 0xdf, 0x9c, 0xcf, 0xf8, 0x5f,
+	0xb3, 0x1f, 0x57, 0x84, 0xab, 0xb7, 0xbb, 0x9f, 0x44, 0x01, 0x13, 0x34, 0x88, 0xc5, 0xb1, 0x73,
+	0xc7, 0x6c, 0xbc, 0x03, 0xdd, 0x98, 0x24, 0x9c, 0xba, 0xbd, 0xce, 0x3f, 0x59, 0x7a, 0x15, 0xcb,
+	0x62, 0xd9, 0xd1, 0x9a, 0x5c, 0xcd, 0x30, 0xbe, 0x22, 0xc0, 0x36, 0x11, 0x07, 0x43, 0xea, 0xb6,
+	0xb3, 0xb7, 0x0b, 0x33, 0xd2, 0xf5, 0x7d, 0x2a, 0xea, 0xf4, 0xad, 0x8e, 0xf1, 0xec, 0x53, 0xb1,
+	0x45, 0x0f, 0x59, 0xc8, 0x04, 0x8b, 0x42, 0xfb, 0x7e, 0xc5, 0xd4, 0xb4, 0x39, 0x0d, 0xc4, 0x76,
+	0x13, 0x65, 0x75, 0x3c, 0xca, 0x15, 0x7b, 0x99, 0xc7, 0x26, 0x3b, 0xb7, 0x42, 0xbc, 0x07, 0xf8,
+	0x36, 0x2f, 0x5e, 0x01, 0x95, 0xb9, 0xd2, 0xe0, 0x79, 0xbb, 0x5b, 0x18, 0xc8, 0x5c, 0x47, 0x65,
+	0x6e, 0x2b, 0xd0, 0xea, 0xdf, 0x02, 0x6d, 0x7c, 0x46, 0x30, 0x7f, 0x83, 0x1d, 0x9b, 0x00, 0xb5,
+	0xe8, 0x57, 0x5b, 0xd5, 0xd4, 0x85, 0x51, 0xa6, 0xb7, 0xaa, 0x4e, 0x0b, 0xe3, 0xd7, 0xff, 0x7b,
+	0x25, 0xa4, 0x11, 0x17, 0x99, 0x3e, 0x29, 0xab, 0x77, 0xdd, 0x8d, 0x8d, 0x4d, 0xe8, 0x16, 0x0e,
+	0xd0, 0x04, 0x3f, 0x87, 0x4e, 0x81, 0xf0, 0x72, 0x33, 0xac, 0xe5, 0x4d, 0x7f, 0x65, 0xbc, 0x5c,
+	0xdd, 0x6a, 0xc5, 0x7e, 0x7b, 0x76, 0xa9, 0x29, 0xe7, 0x97, 0x9a, 0x72, 0x7d, 0xa9, 0xa1, 0x4f,
+	0xb9, 0x86, 0xbe, 0xe5, 0x1a, 0x3a, 0xcd, 0x35, 0x74, 0x96, 0x6b, 0xe8, 0x67, 0xae, 0xa1, 0x5f,
+	0xb9, 0xa6, 0x5c, 0xe7, 0x1a, 0x3a, 0xb9, 0xd2, 0x94, 0xb3, 0x2b, 0x4d, 0x39, 0xbf, 0xd2, 0x94,
+	0xf7, 0x6b, 0x1e, 0x13, 0xc3, 0x74, 0x60, 0x1e, 0x44, 0x81, 0xe5, 0x25, 0xe4, 0x90, 0x84, 0xc4,
+	0xf2, 0xa3, 0x23, 0x66, 0xd5, 0x1f, 0xb9, 0x41, 0x57, 0xb2, 0x3d, 0xfb, 0x1d, 0x00, 0x00, 0xff,
+	0xff, 0x89, 0x1b, 0x98, 0x09, 0xf7, 0x04, 0x00, 0x00,
 }
 
 func (this *PushRequest) Equal(that interface{}) bool {
@@ -487,6 +649,102 @@ func (this *EntryAdapter) Equal(that interface{}) bool {
 	}
 	return true
 }
+func (this *BatchedPushRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*BatchedPushRequest)
+	if !ok {
+		that2, ok := that.(BatchedPushRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.LabelSets) != len(that1.LabelSets) {
+		return false
+	}
+	for i := range this.LabelSets {
+		if !this.LabelSets[i].Equal(&that1.LabelSets[i]) {
+			return false
+		}
+	}
+	if len(this.Streams) != len(that1.Streams) {
+		return false
+	}
+	for i := range this.Streams {
+		if !this.Streams[i].Equal(&that1.Streams[i]) {
+			return false
+		}
+	}
+	return true
+}
+func (this *LabelSetDefinition) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*LabelSetDefinition)
+	if !ok {
+		that2, ok := that.(LabelSetDefinition)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Id != that1.Id {
+		return false
+	}
+	if this.Labels != that1.Labels {
+		return false
+	}
+	return true
+}
+func (this *BatchedStream) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*BatchedStream)
+	if !ok {
+		that2, ok := that.(BatchedStream)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.LabelSetID != that1.LabelSetID {
+		return false
+	}
+	if len(this.Entries) != len(that1.Entries) {
+		return false
+	}
+	for i := range this.Entries {
+		if !this.Entries[i].Equal(that1.Entries[i]) {
+			return false
+		}
+	}
+	return true
+}
 func (this *PushRequest) GoString() string {
 	if this == nil {
 		return "nil"
@@ -560,6 +818,51 @@ func (this *EntryAdapter) GoString() string {
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
+func (this *BatchedPushRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&push.BatchedPushRequest{")
+	if this.LabelSets != nil {
+		vs := make([]*LabelSetDefinition, len(this.LabelSets))
+		for i := range vs {
+			vs[i] = &this.LabelSets[i]
+		}
+		s = append(s, "LabelSets: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	if this.Streams != nil {
+		vs := make([]*BatchedStream, len(this.Streams))
+		for i := range vs {
+			vs[i] = &this.Streams[i]
+		}
+		s = append(s, "Streams: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *LabelSetDefinition) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&push.LabelSetDefinition{")
+	s = append(s, "Id: "+fmt.Sprintf("%#v", this.Id)+",\n")
+	s = append(s, "Labels: "+fmt.Sprintf("%#v", this.Labels)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *BatchedStream) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&push.BatchedStream{")
+	s = append(s, "LabelSetID: "+fmt.Sprintf("%#v", this.LabelSetID)+",\n")
+	s = append(s, "Entries: "+fmt.Sprintf("%#v", this.Entries)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
 func valueToGoStringPush(v interface{}, typ string) string {
 	rv := reflect.ValueOf(v)
 	if rv.IsNil() {
@@ -861,44 +1164,172 @@ func (m *EntryAdapter) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
-func encodeVarintPush(dAtA []byte, offset int, v uint64) int {
-	offset -= sovPush(v)
-	base := offset
-	for v >= 1<<7 {
-		dAtA[offset] = uint8(v&0x7f | 0x80)
-		v >>= 7
-		offset++
+func (m *BatchedPushRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
-	dAtA[offset] = uint8(v)
-	return base
+	return dAtA[:n], nil
 }
-func (m *PushRequest) Size() (n int) {
-	if m == nil {
-		return 0
-	}
+
+func (m *BatchedPushRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *BatchedPushRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
 	var l int
 	_ = l
 	if len(m.Streams) > 0 {
-		for _, e := range m.Streams {
-			l = e.Size()
-			n += 1 + l + sovPush(uint64(l))
+		for iNdEx := len(m.Streams) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Streams[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintPush(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x12
 		}
 	}
-	return n
-}
-
-func (m *PushResponse) Size() (n int) {
-	if m == nil {
-		return 0
+	if len(m.LabelSets) > 0 {
+		for iNdEx := len(m.LabelSets) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.LabelSets[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintPush(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
 	}
-	var l int
-	_ = l
-	return n
+	return len(dAtA) - i, nil
 }
 
-func (m *StreamAdapter) Size() (n int) {
-	if m == nil {
-		return 0
+func (m *LabelSetDefinition) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *LabelSetDefinition) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *LabelSetDefinition) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Labels) > 0 {
+		i -= len(m.Labels)
+		copy(dAtA[i:], m.Labels)
+		i = encodeVarintPush(dAtA, i, uint64(len(m.Labels)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Id != 0 {
+		i = encodeVarintPush(dAtA, i, uint64(m.Id))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *BatchedStream) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *BatchedStream) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *BatchedStream) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Entries) > 0 {
+		for iNdEx := len(m.Entries) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size := m.Entries[iNdEx].Size()
+				i -= size
+				if _, err := m.Entries[iNdEx].MarshalTo(dAtA[i:]); err != nil {
+					return 0, err
+				}
+				i = encodeVarintPush(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if m.LabelSetID != 0 {
+		i = encodeVarintPush(dAtA, i, uint64(m.LabelSetID))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintPush(dAtA []byte, offset int, v uint64) int {
+	offset -= sovPush(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *PushRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Streams) > 0 {
+		for _, e := range m.Streams {
+			l = e.Size()
+			n += 1 + l + sovPush(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *PushResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	return n
+}
+
+func (m *StreamAdapter) Size() (n int) {
+	if m == nil {
+		return 0
 	}
 	var l int
 	_ = l
@@ -962,6 +1393,61 @@ func (m *EntryAdapter) Size() (n int) {
 	return n
 }
 
+func (m *BatchedPushRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.LabelSets) > 0 {
+		for _, e := range m.LabelSets {
+			l = e.Size()
+			n += 1 + l + sovPush(uint64(l))
+		}
+	}
+	if len(m.Streams) > 0 {
+		for _, e := range m.Streams {
+			l = e.Size()
+			n += 1 + l + sovPush(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *LabelSetDefinition) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Id != 0 {
+		n += 1 + sovPush(uint64(m.Id))
+	}
+	l = len(m.Labels)
+	if l > 0 {
+		n += 1 + l + sovPush(uint64(l))
+	}
+	return n
+}
+
+func (m *BatchedStream) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.LabelSetID != 0 {
+		n += 1 + sovPush(uint64(m.LabelSetID))
+	}
+	if len(m.Entries) > 0 {
+		for _, e := range m.Entries {
+			l = e.Size()
+			n += 1 + l + sovPush(uint64(l))
+		}
+	}
+	return n
+}
+
 func sovPush(x uint64) (n int) {
 	return (math_bits.Len64(x|1) + 6) / 7
 }
@@ -1038,6 +1524,49 @@ func (this *EntryAdapter) String() string {
 	}, "")
 	return s
 }
+func (this *BatchedPushRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForLabelSets := "[]LabelSetDefinition{"
+	for _, f := range this.LabelSets {
+		repeatedStringForLabelSets += strings.Replace(strings.Replace(f.String(), "LabelSetDefinition", "LabelSetDefinition", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForLabelSets += "}"
+	repeatedStringForStreams := "[]BatchedStream{"
+	for _, f := range this.Streams {
+		repeatedStringForStreams += strings.Replace(strings.Replace(f.String(), "BatchedStream", "BatchedStream", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForStreams += "}"
+	s := strings.Join([]string{`&BatchedPushRequest{`,
+		`LabelSets:` + repeatedStringForLabelSets + `,`,
+		`Streams:` + repeatedStringForStreams + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *LabelSetDefinition) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&LabelSetDefinition{`,
+		`Id:` + fmt.Sprintf("%v", this.Id) + `,`,
+		`Labels:` + fmt.Sprintf("%v", this.Labels) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *BatchedStream) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&BatchedStream{`,
+		`LabelSetID:` + fmt.Sprintf("%v", this.LabelSetID) + `,`,
+		`Entries:` + fmt.Sprintf("%v", this.Entries) + `,`,
+		`}`,
+	}, "")
+	return s
+}
 func valueToStringPush(v interface{}) string {
 	rv := reflect.ValueOf(v)
 	if rv.IsNil() {
@@ -1627,6 +2156,337 @@ func (m *EntryAdapter) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *BatchedPushRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowPush
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: BatchedPushRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: BatchedPushRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LabelSets", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPush
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthPush
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthPush
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.LabelSets = append(m.LabelSets, LabelSetDefinition{})
+			if err := m.LabelSets[len(m.LabelSets)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Streams", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPush
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthPush
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthPush
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Streams = append(m.Streams, BatchedStream{})
+			if err := m.Streams[len(m.Streams)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipPush(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthPush
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthPush
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *LabelSetDefinition) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowPush
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: LabelSetDefinition: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: LabelSetDefinition: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Id", wireType)
+			}
+			m.Id = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPush
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Id |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Labels", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPush
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthPush
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthPush
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Labels = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipPush(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthPush
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthPush
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *BatchedStream) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowPush
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: BatchedStream: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: BatchedStream: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LabelSetID", wireType)
+			}
+			m.LabelSetID = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPush
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.LabelSetID |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Entries", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPush
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthPush
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthPush
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Entries = append(m.Entries, Entry{})
+			if err := m.Entries[len(m.Entries)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipPush(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthPush
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthPush
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipPush(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
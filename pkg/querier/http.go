@@ -24,6 +24,7 @@ import (
 	"github.com/grafana/loki/pkg/logql/syntax"
 	"github.com/grafana/loki/pkg/logqlmodel"
 	"github.com/grafana/loki/pkg/logqlmodel/stats"
+	"github.com/grafana/loki/pkg/querier/plan"
 	"github.com/grafana/loki/pkg/querier/queryrange"
 	index_stats "github.com/grafana/loki/pkg/storage/stores/index/stats"
 	"github.com/grafana/loki/pkg/util/httpreq"
@@ -137,6 +138,10 @@ func (q *QuerierAPI) TailHandler(w http.ResponseWriter, r *http.Request) {
 		serverutil.WriteError(httpgrpc.Errorf(http.StatusBadRequest, err.Error()), w)
 		return
 	}
+	if err := q.validateMaxEntriesLimits(r.Context(), req.Plan.AST, req.Limit); err != nil {
+		serverutil.WriteError(err, w)
+		return
+	}
 
 	tenantID, err := tenant.TenantID(r.Context())
 	if err != nil {
@@ -248,6 +253,68 @@ func (q *QuerierAPI) TailHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// Tail implements logproto.LiveTailServer. It streams the same responses as
+// the websocket based TailHandler over gRPC.
+func (q *QuerierAPI) Tail(req *logproto.TailRequest, server logproto.LiveTail_TailServer) error {
+	ctx := server.Context()
+	logger := util_log.WithContext(ctx, util_log.Logger)
+
+	if err := loghttp.ValidateTailRequest(req); err != nil {
+		return httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+	if req.Plan == nil {
+		parsed, err := syntax.ParseExpr(req.Query)
+		if err != nil {
+			return httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+		}
+		req.Plan = &plan.QueryPlan{AST: parsed}
+	}
+	if err := q.validateMaxEntriesLimits(ctx, req.Plan.AST, req.Limit); err != nil {
+		return err
+	}
+
+	tailer, err := q.querier.Tail(ctx, req, false)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := tailer.close(); err != nil {
+			level.Error(logger).Log("msg", "Error closing Tailer", "err", err)
+		}
+	}()
+
+	responseChan := tailer.getResponseChan()
+	closeErrChan := tailer.getCloseErrorChan()
+
+	for {
+		select {
+		case response := <-responseChan:
+			if err := server.Send(newQuerierTailResponse(response)); err != nil {
+				return err
+			}
+		case err := <-closeErrChan:
+			return err
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func newQuerierTailResponse(r *loghttp_legacy.TailResponse) *logproto.QuerierTailResponse {
+	resp := &logproto.QuerierTailResponse{
+		Streams: r.Streams,
+		Cursor:  r.Cursor,
+	}
+	for _, dropped := range r.DroppedEntries {
+		resp.DroppedStreams = append(resp.DroppedStreams, &logproto.DroppedStream{
+			From:   dropped.Timestamp,
+			To:     dropped.Timestamp,
+			Labels: dropped.Labels,
+		})
+	}
+	return resp
+}
+
 // SeriesHandler returns the list of time series that match a certain label set.
 // See https://prometheus.io/docs/prometheus/latest/querying/api/#finding-series-by-label-matchers
 func (q *QuerierAPI) SeriesHandler(ctx context.Context, req *logproto.SeriesRequest) (*logproto.SeriesResponse, stats.Result, error) {
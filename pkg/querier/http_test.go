@@ -12,10 +12,12 @@ import (
 	"github.com/stretchr/testify/mock"
 
 	"github.com/grafana/loki/pkg/loghttp"
+	loghttp_legacy "github.com/grafana/loki/pkg/loghttp/legacy"
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/validation"
 
 	"github.com/go-kit/log"
+	"github.com/grafana/dskit/httpgrpc"
 	"github.com/grafana/dskit/user"
 	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/require"
@@ -48,6 +50,61 @@ func TestTailHandler(t *testing.T) {
 	require.Equal(t, "multiple org IDs present", rr.Body.String())
 }
 
+type tailServerMock struct {
+	logproto.LiveTail_TailServer
+	ctx context.Context
+}
+
+func (s *tailServerMock) Context() context.Context { return s.ctx }
+
+func TestTail_Validation(t *testing.T) {
+	limits, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
+	require.NoError(t, err)
+	api := NewQuerierAPI(mockQuerierConfig(), nil, limits, log.NewNopLogger())
+	server := &tailServerMock{ctx: user.InjectOrgID(context.Background(), "test")}
+
+	for _, tc := range []struct {
+		name string
+		req  *logproto.TailRequest
+		err  string
+	}{
+		{name: "too much delay", req: &logproto.TailRequest{Query: `{app="loki"}`, DelayFor: 6}, err: "delay_for can't be greater than 5"},
+		{name: "invalid query", req: &logproto.TailRequest{Query: `{app=}`}, err: "parse error"},
+		{name: "limit exceeded", req: &logproto.TailRequest{Query: `{app="loki"}`, Limit: 10000}, err: "max entries limit per query exceeded"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := api.Tail(tc.req, server)
+			require.ErrorContains(t, err, tc.err)
+			resp, ok := httpgrpc.HTTPResponseFromError(err)
+			require.True(t, ok)
+			require.Equal(t, int32(http.StatusBadRequest), resp.Code)
+		})
+	}
+}
+
+func TestNewQuerierTailResponse(t *testing.T) {
+	cursor := &logproto.TailCursor{Timestamp: time.Unix(2, 0), StreamHash: 42}
+	resp := newQuerierTailResponse(&loghttp_legacy.TailResponse{
+		Streams: []logproto.Stream{
+			{Labels: `{app="loki"}`, Entries: []logproto.Entry{{Timestamp: time.Unix(2, 0), Line: "line"}}},
+		},
+		DroppedEntries: []loghttp_legacy.DroppedEntry{
+			{Timestamp: time.Unix(1, 0), Labels: `{app="loki"}`},
+		},
+		Cursor: cursor,
+	})
+
+	require.Equal(t, &logproto.QuerierTailResponse{
+		Streams: []logproto.Stream{
+			{Labels: `{app="loki"}`, Entries: []logproto.Entry{{Timestamp: time.Unix(2, 0), Line: "line"}}},
+		},
+		DroppedStreams: []*logproto.DroppedStream{
+			{From: time.Unix(1, 0), To: time.Unix(1, 0), Labels: `{app="loki"}`},
+		},
+		Cursor: cursor,
+	}, resp)
+}
+
 type slowConnectionSimulator struct {
 	sleepFor   time.Duration
 	deadline   time.Duration
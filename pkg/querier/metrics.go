@@ -6,9 +6,12 @@ import (
 )
 
 type Metrics struct {
-	tailsActive         prometheus.Gauge
-	tailedStreamsActive prometheus.Gauge
-	tailedBytesTotal    prometheus.Counter
+	tailsActive             prometheus.Gauge
+	tailedStreamsActive     prometheus.Gauge
+	tailedBytesTotal        prometheus.Counter
+	tailsResumedTotal       prometheus.Counter
+	tailDroppedEntriesTotal prometheus.Counter
+	tailLagSeconds          prometheus.Histogram
 }
 
 func NewMetrics(r prometheus.Registerer) *Metrics {
@@ -25,5 +28,18 @@ func NewMetrics(r prometheus.Registerer) *Metrics {
 			Name: "loki_querier_tail_bytes_total",
 			Help: "total bytes tailed",
 		}),
+		tailsResumedTotal: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Name: "loki_querier_tail_resumed_total",
+			Help: "Total number of tail requests resumed from a cursor",
+		}),
+		tailDroppedEntriesTotal: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Name: "loki_querier_tail_dropped_entries_total",
+			Help: "Total number of tailed entries dropped because the client could not keep up",
+		}),
+		tailLagSeconds: promauto.With(r).NewHistogram(prometheus.HistogramOpts{
+			Name:    "loki_querier_tail_lag_seconds",
+			Help:    "Time between the timestamp of the last entry of a tail response and the response being sent, for the live entries",
+			Buckets: prometheus.ExponentialBuckets(0.1, 2, 12),
+		}),
 	}
 }
@@ -250,6 +250,9 @@ func (q *SingleTenantQuerier) deletesForUser(ctx context.Context, startT, endT t
 
 	var deletes []*logproto.Delete
 	for _, del := range d {
+		if del.DryRun {
+			continue
+		}
 		if del.StartTime.UnixNano() <= end && del.EndTime.UnixNano() >= start {
 			deletes = append(deletes, &logproto.Delete{
 				Selector: del.Query,
@@ -459,13 +462,21 @@ func (q *SingleTenantQuerier) Tail(ctx context.Context, req *logproto.TailReques
 		level.Error(spanlogger.FromContext(ctx)).Log("msg", "failed loading deletes for user", "err", err)
 	}
 
+	// A new tail backfills the most recent entries, while a resumed one
+	// backfills the entries right after its cursor.
+	direction := logproto.BACKWARD
+	if req.Cursor != nil {
+		req.Start = req.Cursor.Timestamp
+		direction = logproto.FORWARD
+	}
+
 	histReq := logql.SelectLogParams{
 		QueryRequest: &logproto.QueryRequest{
 			Selector:  req.Query,
 			Start:     req.Start,
 			End:       time.Now(),
 			Limit:     req.Limit,
-			Direction: logproto.BACKWARD,
+			Direction: direction,
 			Deletes:   deletes,
 			Plan:      req.Plan,
 		},
@@ -479,33 +490,28 @@ func (q *SingleTenantQuerier) Tail(ctx context.Context, req *logproto.TailReques
 	// Enforce the query timeout except when tailing, otherwise the tailing
 	// will be terminated once the query timeout is reached
 	tailCtx := ctx
-	tenantID, err := tenant.TenantID(tailCtx)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to load tenant")
-	}
-	queryTimeout := q.limits.QueryTimeout(tailCtx, tenantID)
-	queryCtx, cancelQuery := context.WithDeadline(ctx, time.Now().Add(queryTimeout))
-	defer cancelQuery()
-
 	tailClients, err := q.ingesterQuerier.Tail(tailCtx, req)
 	if err != nil {
 		return nil, err
 	}
 
-	histIterators, err := q.SelectLogs(queryCtx, histReq)
+	historicEntries, err := q.selectTailHistory(tailCtx, histReq)
 	if err != nil {
 		return nil, err
 	}
-
-	reversedIterator, err := iter.NewReversedIter(histIterators, req.Limit, true)
-	if err != nil {
-		return nil, err
+	if direction == logproto.FORWARD {
+		// The client must get all the entries between its cursor and the live
+		// ones, so they are queried by pages of the tail limit.
+		historicEntries = newTailBackfillIterator(historicEntries, histReq, func(req logql.SelectLogParams) (iter.EntryIterator, error) {
+			return q.selectTailHistory(tailCtx, req)
+		}, q.logger)
 	}
 
 	return newTailer(
 		time.Duration(req.DelayFor)*time.Second,
 		tailClients,
-		reversedIterator,
+		historicEntries,
+		req.Cursor,
 		func(connectedIngestersAddr []string) (map[string]logproto.Querier_TailClient, error) {
 			return q.ingesterQuerier.TailDisconnectedIngesters(tailCtx, req, connectedIngestersAddr)
 		},
@@ -517,6 +523,34 @@ func (q *SingleTenantQuerier) Tail(ctx context.Context, req *logproto.TailReques
 	), nil
 }
 
+// selectTailHistory returns the entries of a tail query over a past time
+// range, ordered oldest first.
+func (q *SingleTenantQuerier) selectTailHistory(ctx context.Context, req logql.SelectLogParams) (iter.EntryIterator, error) {
+	tenantID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load tenant")
+	}
+	queryTimeout := q.limits.QueryTimeout(ctx, tenantID)
+	queryCtx, cancelQuery := context.WithDeadline(ctx, time.Now().Add(queryTimeout))
+	defer cancelQuery()
+
+	histIterators, err := q.SelectLogs(queryCtx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Historic entries are preloaded as the query context is cancelled once we return.
+	historicEntries, err := iter.NewReversedIter(histIterators, req.Limit, true)
+	if err != nil {
+		return nil, err
+	}
+	if req.Direction == logproto.FORWARD {
+		// Reverse them back so that they are ordered oldest first.
+		return iter.NewReversedIter(historicEntries, 0, true)
+	}
+	return historicEntries, nil
+}
+
 // Series fetches any matching series for a list of matcher sets
 func (q *SingleTenantQuerier) Series(ctx context.Context, req *logproto.SeriesRequest) (*logproto.SeriesResponse, error) {
 	userID, err := tenant.TenantID(ctx)
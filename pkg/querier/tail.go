@@ -15,6 +15,7 @@ import (
 	"github.com/grafana/loki/pkg/iter"
 	loghttp "github.com/grafana/loki/pkg/loghttp/legacy"
 	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql"
 	util_log "github.com/grafana/loki/pkg/util/log"
 )
 
@@ -41,8 +42,17 @@ type Tailer struct {
 	openStreamIterator iter.HeapIterator
 	streamMtx          sync.Mutex // for synchronizing access to openStreamIterator
 
-	currEntry  logproto.Entry
-	currLabels string
+	currEntry      logproto.Entry
+	currLabels     string
+	currStreamHash uint64
+
+	// cursor is the last entry seen by the client when resuming a tail.
+	// Entries up to it are skipped.
+	cursor *logproto.TailCursor
+
+	// liveFrom is when the historic entries were preloaded. Only the entries
+	// after it are live, the others are backfilled.
+	liveFrom time.Time
 
 	// keep track of the streams for metrics about active streams
 	seenStreams    map[uint64]struct{}
@@ -117,6 +127,7 @@ func (t *Tailer) loop() {
 			// to save the effort
 			if t.isResponseChanBlocked() {
 				droppedEntries = dropEntry(droppedEntries, t.currEntry.Timestamp, t.currLabels)
+				t.metrics.tailDroppedEntriesTotal.Inc()
 				continue
 			}
 
@@ -125,6 +136,10 @@ func (t *Tailer) loop() {
 				Labels:  t.currLabels,
 				Entries: []logproto.Entry{t.currEntry},
 			})
+			tailResponse.Cursor = &logproto.TailCursor{
+				Timestamp:  t.currEntry.Timestamp,
+				StreamHash: t.currStreamHash,
+			}
 		}
 
 		// If all consumed entries have been dropped because the response channel is blocked
@@ -167,11 +182,15 @@ func (t *Tailer) loop() {
 		select {
 		case t.responseChan <- tailResponse:
 			t.metrics.tailedBytesTotal.Add(float64(entriesSize))
+			if tailResponse.Cursor.Timestamp.After(t.liveFrom) {
+				t.metrics.tailLagSeconds.Observe(time.Since(tailResponse.Cursor.Timestamp).Seconds())
+			}
 			if len(droppedEntries) > 0 {
 				droppedEntries = make([]loghttp.DroppedEntry, 0)
 			}
 		default:
 			droppedEntries = dropEntries(droppedEntries, tailResponse.Streams)
+			t.metrics.tailDroppedEntriesTotal.Add(float64(len(tailResponse.Streams)))
 		}
 	}
 }
@@ -255,15 +274,37 @@ func (t *Tailer) next() bool {
 	t.streamMtx.Lock()
 	defer t.streamMtx.Unlock()
 
-	if t.openStreamIterator.IsEmpty() || !time.Now().After(t.openStreamIterator.Peek().Add(t.delayFor)) || !t.openStreamIterator.Next() {
-		return false
-	}
+	for {
+		if t.openStreamIterator.IsEmpty() || !time.Now().After(t.openStreamIterator.Peek().Add(t.delayFor)) || !t.openStreamIterator.Next() {
+			return false
+		}
+
+		entry := t.openStreamIterator.Entry()
+		streamHash := t.openStreamIterator.StreamHash()
+		if t.seenBeforeCursor(entry.Timestamp, streamHash) {
+			continue
+		}
 
-	t.currEntry = t.openStreamIterator.Entry()
-	t.currLabels = t.openStreamIterator.Labels()
-	t.recordStream(t.openStreamIterator.StreamHash())
+		t.currEntry = entry
+		t.currLabels = t.openStreamIterator.Labels()
+		t.currStreamHash = streamHash
+		t.recordStream(streamHash)
 
-	return true
+		return true
+	}
+}
+
+// seenBeforeCursor returns whether the entry has already been sent to the
+// client before it resumed the tail. Entries at the cursor timestamp are only
+// considered seen for the stream the cursor points to.
+func (t *Tailer) seenBeforeCursor(ts time.Time, streamHash uint64) bool {
+	if t.cursor == nil {
+		return false
+	}
+	if ts.Equal(t.cursor.Timestamp) {
+		return streamHash == t.cursor.StreamHash
+	}
+	return ts.Before(t.cursor.Timestamp)
 }
 
 func (t *Tailer) close() error {
@@ -315,6 +356,7 @@ func newTailer(
 	delayFor time.Duration,
 	querierTailClients map[string]logproto.Querier_TailClient,
 	historicEntries iter.EntryIterator,
+	cursor *logproto.TailCursor,
 	tailDisconnectedIngesters func([]string) (map[string]logproto.Querier_TailClient, error),
 	tailMaxDuration time.Duration,
 	waitEntryThrottle time.Duration,
@@ -330,6 +372,8 @@ func newTailer(
 	t := Tailer{
 		openStreamIterator:        iter.NewMergeEntryIterator(context.Background(), []iter.EntryIterator{historicEntriesIter}, logproto.FORWARD),
 		querierTailClients:        querierTailClients,
+		cursor:                    cursor,
+		liveFrom:                  time.Now(),
 		delayFor:                  delayFor,
 		responseChan:              make(chan *loghttp.TailResponse, maxBufferedTailResponses),
 		closeErrChan:              make(chan error),
@@ -343,6 +387,9 @@ func newTailer(
 	}
 
 	t.metrics.tailsActive.Inc()
+	if cursor != nil {
+		t.metrics.tailsResumedTotal.Inc()
+	}
 	t.readTailClients()
 	go t.loop()
 	return &t
@@ -365,3 +412,98 @@ func dropEntries(droppedEntries []loghttp.DroppedEntry, streams []logproto.Strea
 
 	return droppedEntries
 }
+
+// tailBackfillIterator iterates over the entries a resumed tail backfills,
+// from its cursor up to the start of the live tailing. The entries are
+// queried by pages of the tail limit, the next page being queried once the
+// previous one is consumed.
+type tailBackfillIterator struct {
+	// EntryIterator is the current page.
+	iter.EntryIterator
+
+	req    logql.SelectLogParams
+	fetch  func(logql.SelectLogParams) (iter.EntryIterator, error)
+	logger log.Logger
+
+	pageEntries, pageNewEntries uint32
+	// lastTs is the timestamp of the last returned entry, and seen are the
+	// entries returned at that timestamp, which the next page returns again.
+	lastTs time.Time
+	seen   map[tailBackfillEntry]struct{}
+	err    error
+}
+
+type tailBackfillEntry struct {
+	streamHash uint64
+	line       string
+}
+
+func newTailBackfillIterator(firstPage iter.EntryIterator, req logql.SelectLogParams, fetch func(logql.SelectLogParams) (iter.EntryIterator, error), logger log.Logger) *tailBackfillIterator {
+	return &tailBackfillIterator{
+		EntryIterator: firstPage,
+		req:           req,
+		fetch:         fetch,
+		logger:        logger,
+		seen:          map[tailBackfillEntry]struct{}{},
+	}
+}
+
+func (it *tailBackfillIterator) Next() bool {
+	for {
+		for it.EntryIterator.Next() {
+			it.pageEntries++
+			entry := it.EntryIterator.Entry()
+			key := tailBackfillEntry{streamHash: it.EntryIterator.StreamHash(), line: entry.Line}
+			if entry.Timestamp.Equal(it.lastTs) {
+				if _, ok := it.seen[key]; ok {
+					continue
+				}
+			} else {
+				it.lastTs = entry.Timestamp
+				clear(it.seen)
+			}
+			it.seen[key] = struct{}{}
+			it.pageNewEntries++
+			return true
+		}
+		if err := it.EntryIterator.Error(); err != nil {
+			it.err = err
+			return false
+		}
+		// A page with fewer entries than the limit is the last one.
+		if it.req.Limit == 0 || it.pageEntries < it.req.Limit {
+			return false
+		}
+
+		// The next page starts at the last returned entry, since the page may
+		// have ended before the other entries at the same timestamp.
+		next := *it.req.QueryRequest
+		next.Start = it.lastTs
+		if it.pageNewEntries == 0 {
+			// The whole page had the timestamp of the last returned entry.
+			level.Warn(it.logger).Log("msg", "skipping tail backfill entries exceeding the limit at the same timestamp", "timestamp", it.lastTs, "limit", it.req.Limit)
+			next.Start = it.lastTs.Add(time.Nanosecond)
+		}
+		if !next.Start.Before(next.End) {
+			return false
+		}
+
+		_ = it.EntryIterator.Close()
+		page, err := it.fetch(logql.SelectLogParams{QueryRequest: &next})
+		if err != nil {
+			level.Error(it.logger).Log("msg", "failed to query the next page of the tail backfill", "err", err)
+			it.EntryIterator = iter.NoopIterator
+			it.err = err
+			return false
+		}
+		it.EntryIterator = page
+		it.pageEntries, it.pageNewEntries = 0, 0
+	}
+}
+
+func (it *tailBackfillIterator) Error() error {
+	if it.err != nil {
+		return it.err
+	}
+	return it.EntryIterator.Error()
+}
@@ -2,10 +2,13 @@ package querier
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -15,6 +18,7 @@ import (
 	"github.com/grafana/loki/pkg/iter"
 	loghttp "github.com/grafana/loki/pkg/loghttp/legacy"
 	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql"
 )
 
 const (
@@ -165,7 +169,7 @@ func TestTailer(t *testing.T) {
 				tailClients["test"] = test.tailClient
 			}
 
-			tailer := newTailer(0, tailClients, test.historicEntries, tailDisconnectedIngesters, timeout, throttle, false, NewMetrics(nil), gokitlog.NewNopLogger())
+			tailer := newTailer(0, tailClients, test.historicEntries, nil, tailDisconnectedIngesters, timeout, throttle, false, NewMetrics(nil), gokitlog.NewNopLogger())
 			defer tailer.close()
 
 			test.tester(t, tailer, test.tailClient)
@@ -173,6 +177,132 @@ func TestTailer(t *testing.T) {
 	}
 }
 
+func TestTailerResumeFromCursor(t *testing.T) {
+	t.Parallel()
+
+	tailDisconnectedIngesters := func([]string) (map[string]logproto.Querier_TailClient, error) {
+		return map[string]logproto.Querier_TailClient{}, nil
+	}
+
+	historicEntries := iter.NewSortEntryIterator([]iter.EntryIterator{
+		iter.NewStreamIterator(mockStreamWithLabels(1, 5, `{type="test"}`)),
+		iter.NewStreamIterator(logproto.Stream{
+			Labels:  `{type="other"}`,
+			Entries: []logproto.Entry{{Timestamp: time.Unix(3, 0), Line: "other line 3"}},
+			Hash:    1,
+		}),
+	}, logproto.FORWARD)
+
+	// The client already received the entry at 3s of the stream with hash 0,
+	// but not the one of the other stream sharing the same timestamp.
+	cursor := &logproto.TailCursor{Timestamp: time.Unix(3, 0), StreamHash: 0}
+
+	tailer := newTailer(0, map[string]logproto.Querier_TailClient{}, historicEntries, cursor, tailDisconnectedIngesters, timeout, throttle, false, NewMetrics(nil), log.NewNopLogger())
+	defer tailer.close()
+
+	responses, err := readFromTailer(tailer, 3)
+	require.NoError(t, err)
+
+	assert.Equal(t, []logproto.Stream{
+		{Labels: `{type="other"}`, Entries: []logproto.Entry{{Timestamp: time.Unix(3, 0), Line: "other line 3"}}},
+		mockStream(4, 1),
+		mockStream(5, 1),
+	}, flattenStreamsFromResponses(responses))
+
+	last := responses[len(responses)-1]
+	require.NotNil(t, last.Cursor)
+	assert.Equal(t, time.Unix(5, 0), last.Cursor.Timestamp)
+	assert.Equal(t, uint64(0), last.Cursor.StreamHash)
+}
+
+func TestTailBackfillIterator(t *testing.T) {
+	t.Parallel()
+
+	// Two streams with entries every second, with the same timestamps.
+	var streams []logproto.Stream
+	for hash, lbs := range []string{`{type="a"}`, `{type="b"}`} {
+		stream := logproto.Stream{Labels: lbs, Hash: uint64(hash)}
+		for i := int64(1); i <= 5; i++ {
+			stream.Entries = append(stream.Entries, logproto.Entry{Timestamp: time.Unix(i, 0), Line: fmt.Sprintf("%s line %d", lbs, i)})
+		}
+		streams = append(streams, stream)
+	}
+	var queries []time.Time
+	fetch := func(req logql.SelectLogParams) (iter.EntryIterator, error) {
+		queries = append(queries, req.Start)
+		var entries []iter.EntryIterator
+		for _, stream := range streams {
+			for _, entry := range stream.Entries {
+				if !entry.Timestamp.Before(req.Start) && entry.Timestamp.Before(req.End) {
+					entries = append(entries, iter.NewStreamIterator(logproto.Stream{Labels: stream.Labels, Hash: stream.Hash, Entries: []logproto.Entry{entry}}))
+				}
+			}
+		}
+		// The pages are ordered oldest first, like selectTailHistory does.
+		page, err := iter.NewReversedIter(iter.NewSortEntryIterator(entries, logproto.FORWARD), req.Limit, true)
+		if err != nil {
+			return nil, err
+		}
+		return iter.NewReversedIter(page, 0, true)
+	}
+
+	req := logql.SelectLogParams{QueryRequest: &logproto.QueryRequest{Start: time.Unix(1, 0), End: time.Unix(10, 0), Limit: 3, Direction: logproto.FORWARD}}
+	firstPage, err := fetch(req)
+	require.NoError(t, err)
+	it := newTailBackfillIterator(firstPage, req, fetch, log.NewNopLogger())
+	defer it.Close()
+
+	var got []string
+	for it.Next() {
+		got = append(got, it.Entry().Line)
+	}
+	require.NoError(t, it.Error())
+
+	// All the entries are returned once, although the pages end in the middle
+	// of a timestamp.
+	var want []string
+	for i := 1; i <= 5; i++ {
+		want = append(want, fmt.Sprintf(`{type="a"} line %d`, i), fmt.Sprintf(`{type="b"} line %d`, i))
+	}
+	require.Equal(t, want, got)
+	require.Equal(t, time.Unix(1, 0), queries[0])
+	require.Greater(t, len(queries), 1)
+}
+
+func TestTailerLagOnlyObservesLiveEntries(t *testing.T) {
+	t.Parallel()
+
+	tailDisconnectedIngesters := func([]string) (map[string]logproto.Querier_TailClient, error) {
+		return map[string]logproto.Querier_TailClient{}, nil
+	}
+	live := mockTailResponse(logproto.Stream{
+		Labels:  `{type="test"}`,
+		Entries: []logproto.Entry{{Line: "live"}},
+	})
+	tailClient := newTailClientMock().mockRecvWithTrigger(live)
+
+	metrics := NewMetrics(nil)
+	tailer := newTailer(0, map[string]logproto.Querier_TailClient{"test": tailClient}, mockStreamIterator(1, 2), nil, tailDisconnectedIngesters, timeout, throttle, false, metrics, log.NewNopLogger())
+	defer tailer.close()
+
+	lagSamples := func() uint64 {
+		var m dto.Metric
+		require.NoError(t, metrics.tailLagSeconds.(prometheus.Metric).Write(&m))
+		return m.GetHistogram().GetSampleCount()
+	}
+
+	// The backfilled entries don't count towards the lag.
+	_, err := readFromTailer(tailer, 2)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), lagSamples())
+
+	live.Stream.Entries[0].Timestamp = time.Now()
+	tailClient.triggerRecv()
+	_, err = readFromTailer(tailer, 1)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return lagSamples() == 1 }, time.Second, 10*time.Millisecond)
+}
+
 func TestCategorizedLabels(t *testing.T) {
 	t.Parallel()
 
@@ -360,7 +490,7 @@ func TestCategorizedLabels(t *testing.T) {
 				tailClients[k] = v
 			}
 
-			tailer := newTailer(0, tailClients, tc.historicEntries, tailDisconnectedIngesters, timeout, throttle, tc.categorizeLabels, NewMetrics(nil), log.NewNopLogger())
+			tailer := newTailer(0, tailClients, tc.historicEntries, nil, tailDisconnectedIngesters, timeout, throttle, tc.categorizeLabels, NewMetrics(nil), log.NewNopLogger())
 			defer tailer.close()
 
 			// Make tail clients receive their responses
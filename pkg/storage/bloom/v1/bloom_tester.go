@@ -4,6 +4,7 @@ import (
 	"github.com/grafana/regexp"
 	regexpsyntax "github.com/grafana/regexp/syntax"
 	"github.com/prometheus/prometheus/model/labels"
+	"golang.org/x/exp/slices"
 
 	"github.com/grafana/loki/pkg/logql/log"
 	"github.com/grafana/loki/pkg/logql/syntax"
@@ -35,27 +36,30 @@ func (b BloomTests) MatchesWithPrefixBuf(bloom filter.Checker, buf []byte, prefi
 	return true
 }
 
+// FiltersToBloomTest returns the test of the line filters against blooms
+// built with the n-grams of the given builder. Case-insensitive regex filters
+// can only be tested if the blooms contain the lowercased n-grams of the lines.
 // TODO(owen-d): limits the number of bloom lookups run.
 // An arbitrarily high number can overconsume cpu and is a DoS vector.
-func FiltersToBloomTest(b NGramBuilder, filters ...syntax.LineFilterExpr) BloomTest {
+func FiltersToBloomTest(b NGramBuilder, lowercaseNGrams bool, filters ...syntax.LineFilterExpr) BloomTest {
 	tests := make(BloomTests, 0, len(filters))
 	for _, f := range filters {
 		if f.Left != nil {
-			tests = append(tests, FiltersToBloomTest(b, *f.Left))
+			tests = append(tests, FiltersToBloomTest(b, lowercaseNGrams, *f.Left))
 		}
 		if f.Or != nil {
-			left := FiltersToBloomTest(b, *f.Or)
-			right := simpleFilterToBloomTest(b, f.LineFilter)
+			left := FiltersToBloomTest(b, lowercaseNGrams, *f.Or)
+			right := simpleFilterToBloomTest(b, lowercaseNGrams, f.LineFilter)
 			tests = append(tests, newOrTest(left, right))
 			continue
 		}
 
-		tests = append(tests, simpleFilterToBloomTest(b, f.LineFilter))
+		tests = append(tests, simpleFilterToBloomTest(b, lowercaseNGrams, f.LineFilter))
 	}
 	return tests
 }
 
-func simpleFilterToBloomTest(b NGramBuilder, filter syntax.LineFilter) BloomTest {
+func simpleFilterToBloomTest(b NGramBuilder, lowercaseNGrams bool, filter syntax.LineFilter) BloomTest {
 	switch filter.Ty {
 	case labels.MatchEqual, labels.MatchNotEqual:
 		var test BloomTest = newStringTest(b, filter.Match)
@@ -63,7 +67,14 @@ func simpleFilterToBloomTest(b NGramBuilder, filter syntax.LineFilter) BloomTest
 			test = newNotTest(test)
 		}
 		return test
-	case labels.MatchRegexp, labels.MatchNotRegexp:
+	case labels.MatchRegexp:
+		reg, err := regexpsyntax.Parse(filter.Match, regexpsyntax.Perl)
+		if err != nil {
+			// TODO: log error
+			return MatchAll
+		}
+		return regexpTestBuilder{b: b, lowercaseNGrams: lowercaseNGrams}.test(reg.Simplify())
+	case labels.MatchNotRegexp:
 		reg, err := regexpsyntax.Parse(filter.Match, regexpsyntax.Perl)
 		if err != nil {
 			// TODO: log error
@@ -78,14 +89,108 @@ func simpleFilterToBloomTest(b NGramBuilder, filter syntax.LineFilter) BloomTest
 			return MatchAll
 		}
 
-		var test BloomTest = matcherFilterWrapper{filter: matcher}
-		if filter.Ty == labels.MatchNotRegexp {
-			test = newNotTest(test)
+		return newNotTest(matcherFilterWrapper{filter: matcher})
+	default:
+		return MatchAll
+	}
+}
+
+// regexpTestBuilder builds the test of the substrings which are required in
+// the lines matched by a regex: all the parts of a concatenation are required,
+// and any of the alternatives of an alternation.
+type regexpTestBuilder struct {
+	b               NGramBuilder
+	lowercaseNGrams bool
+}
+
+func (r regexpTestBuilder) test(reg *regexpsyntax.Regexp) BloomTest {
+	switch reg.Op {
+	case regexpsyntax.OpCapture, regexpsyntax.OpPlus:
+		return r.test(reg.Sub[0])
+	case regexpsyntax.OpRepeat:
+		if reg.Min > 0 {
+			return r.test(reg.Sub[0])
+		}
+		return MatchAll
+	case regexpsyntax.OpAlternate:
+		var test BloomTest
+		for _, sub := range reg.Sub {
+			subTest := r.test(sub)
+			if subTest == MatchAll {
+				return MatchAll
+			}
+			if test != nil {
+				subTest = newOrTest(test, subTest)
+			}
+			test = subTest
 		}
 		return test
+	case regexpsyntax.OpConcat:
+		// The adjacent parts matching a few values are joined into the
+		// alternative substrings they match together.
+		var (
+			tests  BloomTests
+			values = []string{""}
+			fold   bool
+		)
+		appendTest := func(test BloomTest) {
+			if test != MatchAll {
+				tests = append(tests, test)
+			}
+		}
+		for _, sub := range reg.Sub {
+			subValues, subFold := regexpValues(sub, maxRegexpValues)
+			if subValues != nil && len(values)*len(subValues) <= maxRegexpValues {
+				values, fold = concatValues(values, subValues), fold || subFold
+				continue
+			}
+			appendTest(r.valuesTest(values, fold))
+			values, fold = []string{""}, false
+			appendTest(r.test(sub))
+		}
+		appendTest(r.valuesTest(values, fold))
+		if len(tests) == 0 {
+			return MatchAll
+		}
+		return tests
 	default:
+		if values, fold := regexpValues(reg, maxRegexpValues); values != nil {
+			return r.valuesTest(values, fold)
+		}
+		return MatchAll
+	}
+}
+
+// valuesTest returns the test of any of the substrings, which are tested
+// lowercased if they are matched case-insensitively.
+func (r regexpTestBuilder) valuesTest(values []string, fold bool) BloomTest {
+	if fold && !r.lowercaseNGrams {
 		return MatchAll
 	}
+
+	var test BloomTest
+	for _, v := range values {
+		if v == "" {
+			return MatchAll
+		}
+		if fold {
+			v = lowercase(v)
+		}
+		valueTest := newStringTest(r.b, v)
+		// a substring shorter than the n-grams is not tested
+		if len(valueTest.ngrams) == 0 {
+			return MatchAll
+		}
+		var t BloomTest = valueTest
+		if test != nil {
+			t = newOrTest(test, t)
+		}
+		test = t
+	}
+	if test == nil {
+		return MatchAll
+	}
+	return test
 }
 
 type bloomCheckerWrapper struct {
@@ -244,3 +349,175 @@ func (o orTest) Matches(bloom filter.Checker) bool {
 func (o orTest) MatchesWithPrefixBuf(bloom filter.Checker, buf []byte, prefixLen int) bool {
 	return o.left.MatchesWithPrefixBuf(bloom, buf, prefixLen) || o.right.MatchesWithPrefixBuf(bloom, buf, prefixLen)
 }
+
+// maxRegexpValues limits the number of alternative values of a regex tested
+// against the blooms.
+const maxRegexpValues = 64
+
+// LabelFiltersToBloomTest returns the test of the label filters against the
+// structured metadata key=value pairs added to the blooms for the given keys.
+// Only the equality and the regex filters matching a finite set of non-empty
+// values can be tested, as the entries without a key have the empty value.
+func LabelFiltersToBloomTest(keys []string, filters ...log.LabelFilterer) BloomTest {
+	tests := make(BloomTests, 0, len(filters))
+	for _, f := range filters {
+		tests = append(tests, labelFilterToBloomTest(keys, f))
+	}
+	return tests
+}
+
+// LabelFiltersOnKeys returns whether any of the label filters applies to one
+// of the structured metadata keys added to the blooms.
+func LabelFiltersOnKeys(keys []string, filters ...log.LabelFilterer) bool {
+	for _, f := range filters {
+		if labelFilterOnKeys(keys, f) {
+			return true
+		}
+	}
+	return false
+}
+
+func labelFilterOnKeys(keys []string, filter log.LabelFilterer) bool {
+	switch f := filter.(type) {
+	case *log.BinaryLabelFilter:
+		return labelFilterOnKeys(keys, f.Left) || labelFilterOnKeys(keys, f.Right)
+	case *log.StringLabelFilter:
+		return slices.Contains(keys, f.Name)
+	case *log.LineFilterLabelFilter:
+		return slices.Contains(keys, f.Name)
+	default:
+		return false
+	}
+}
+
+func labelFilterToBloomTest(keys []string, filter log.LabelFilterer) BloomTest {
+	switch f := filter.(type) {
+	case *log.BinaryLabelFilter:
+		left, right := labelFilterToBloomTest(keys, f.Left), labelFilterToBloomTest(keys, f.Right)
+		if f.And {
+			return BloomTests{left, right}
+		}
+		return newOrTest(left, right)
+	case *log.StringLabelFilter:
+		return matcherToBloomTest(keys, f.Matcher)
+	case *log.LineFilterLabelFilter:
+		return matcherToBloomTest(keys, f.Matcher)
+	default:
+		return MatchAll
+	}
+}
+
+func matcherToBloomTest(keys []string, m *labels.Matcher) BloomTest {
+	if !slices.Contains(keys, m.Name) {
+		return MatchAll
+	}
+
+	var values []string
+	switch m.Type {
+	case labels.MatchEqual:
+		values = []string{m.Value}
+	case labels.MatchRegexp:
+		reg, err := regexpsyntax.Parse(m.Value, regexpsyntax.Perl)
+		if err != nil {
+			return MatchAll
+		}
+		var fold bool
+		values, fold = regexpValues(reg.Simplify(), maxRegexpValues)
+		// the values are exact, so they can't be matched case-insensitively
+		if fold {
+			values = nil
+		}
+	}
+	if len(values) == 0 {
+		return MatchAll
+	}
+
+	var test BloomTest
+	for _, v := range values {
+		if v == "" {
+			return MatchAll
+		}
+		var valueTest BloomTest = newStructuredMetadataTest(m.Name, v)
+		if test != nil {
+			valueTest = newOrTest(test, valueTest)
+		}
+		test = valueTest
+	}
+	return test
+}
+
+// regexpValues returns the values fully matched by the regex, or nil if there
+// are more than limit of them or they can't be listed. The values are matched
+// case-insensitively if fold is set.
+func regexpValues(reg *regexpsyntax.Regexp, limit int) (values []string, fold bool) {
+	switch reg.Op {
+	case regexpsyntax.OpEmptyMatch:
+		return []string{""}, false
+	case regexpsyntax.OpLiteral:
+		return []string{string(reg.Rune)}, reg.Flags&regexpsyntax.FoldCase != 0
+	case regexpsyntax.OpCharClass:
+		for i := 0; i < len(reg.Rune); i += 2 {
+			if int(reg.Rune[i+1]-reg.Rune[i])+len(values) >= limit {
+				return nil, false
+			}
+			for r := reg.Rune[i]; r <= reg.Rune[i+1]; r++ {
+				values = append(values, string(r))
+			}
+		}
+		return values, false
+	case regexpsyntax.OpCapture:
+		return regexpValues(reg.Sub[0], limit)
+	case regexpsyntax.OpAlternate:
+		for _, sub := range reg.Sub {
+			subValues, subFold := regexpValues(sub, limit-len(values))
+			if subValues == nil {
+				return nil, false
+			}
+			values, fold = append(values, subValues...), fold || subFold
+		}
+		return values, fold
+	case regexpsyntax.OpConcat:
+		values = []string{""}
+		for _, sub := range reg.Sub {
+			subValues, subFold := regexpValues(sub, limit)
+			if subValues == nil || len(values)*len(subValues) > limit {
+				return nil, false
+			}
+			values, fold = concatValues(values, subValues), fold || subFold
+		}
+		return values, fold
+	default:
+		return nil, false
+	}
+}
+
+// concatValues returns the concatenations of each prefix with each suffix.
+func concatValues(prefixes, suffixes []string) []string {
+	values := make([]string, 0, len(prefixes)*len(suffixes))
+	for _, p := range prefixes {
+		for _, s := range suffixes {
+			values = append(values, p+s)
+		}
+	}
+	return values
+}
+
+// structuredMetadataTest tests the token of a structured metadata key=value
+// pair.
+type structuredMetadataTest struct {
+	token []byte
+}
+
+func newStructuredMetadataTest(key, value string) structuredMetadataTest {
+	return structuredMetadataTest{token: structuredMetadataToken(nil, key, value)}
+}
+
+// Matches implements the BloomTest interface
+func (s structuredMetadataTest) Matches(bloom filter.Checker) bool {
+	return bloom.Test(s.token)
+}
+
+// MatchesWithPrefixBuf implements the BloomTest interface
+func (s structuredMetadataTest) MatchesWithPrefixBuf(bloom filter.Checker, buf []byte, prefixLen int) bool {
+	return bloom.Test(append(buf[:prefixLen], s.token...))
+}
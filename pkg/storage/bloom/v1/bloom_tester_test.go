@@ -1,6 +1,7 @@
 package v1
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -154,19 +155,174 @@ func TestFiltersToBloomTests(t *testing.T) {
 			bloom:       fakeBloom{"foo", "bar", "baz", "fuzz", "noz"},
 			expectMatch: false,
 		},
+		{
+			name:        "regex required substrings match",
+			query:       `{app="fake"} |~ "error.*timeout"`,
+			bloom:       fakeBloom{"error", "timeout"},
+			expectMatch: true,
+		},
+		{
+			name:        "regex required substrings no match",
+			query:       `{app="fake"} |~ "error.*timeout"`,
+			bloom:       fakeBloom{"error", "foo"},
+			expectMatch: false,
+		},
+		{
+			name:        "regex alternation match",
+			query:       `{app="fake"} |~ "(foo|nope) .+ (bar|baz)"`,
+			bloom:       fakeBloom{"nope ", " baz"},
+			expectMatch: true,
+		},
+		{
+			name:        "regex alternation no match",
+			query:       `{app="fake"} |~ "(foo|nope) .+ (bar|baz)"`,
+			bloom:       fakeBloom{"foo ", " fuzz"},
+			expectMatch: false,
+		},
+		{
+			name:        "regex factored alternation match",
+			query:       `{app="fake"} |~ "foobar|foobaz"`,
+			bloom:       fakeBloom{"foobaz"},
+			expectMatch: true,
+		},
+		{
+			name:        "regex factored alternation no match",
+			query:       `{app="fake"} |~ "foobar|foobaz"`,
+			bloom:       fakeBloom{"foobax"},
+			expectMatch: false,
+		},
+		{
+			name:        "regex repetition",
+			query:       `{app="fake"} |~ "(foo)+bar(baz)?"`,
+			bloom:       fakeBloom{"foo", "bar"},
+			expectMatch: true,
+		},
+		{
+			name:        "regex optional alternative",
+			query:       `{app="fake"} |~ "nope|(foo)?"`,
+			bloom:       fakeBloom{"bar"},
+			expectMatch: true,
+		},
+		{
+			name:        "case-insensitive regex without lowercased n-grams",
+			query:       `{app="fake"} |~ "(?i)nope"`,
+			bloom:       fakeBloom{"foo"},
+			expectMatch: true,
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			expr, err := syntax.ParseExpr(tc.query)
 			assert.NoError(t, err)
 			filters := syntax.ExtractLineFilters(expr)
 
-			bloomTests := FiltersToBloomTest(fakeNgramBuilder{}, filters...)
+			bloomTests := FiltersToBloomTest(fakeNgramBuilder{}, false, filters...)
 
 			assert.Equal(t, tc.expectMatch, bloomTests.Matches(tc.bloom))
 		})
 	}
 }
 
+func TestFiltersToBloomTestsLowercaseNGrams(t *testing.T) {
+	bloom := fakeBloom{"error", "timeout", "Warn"}
+	for _, tc := range []struct {
+		query       string
+		expectMatch bool
+	}{
+		{query: `{app="fake"} |~ "(?i)error.*timeout"`, expectMatch: true},
+		{query: `{app="fake"} |~ "(?i)ERROR.*nope"`, expectMatch: false},
+		{query: `{app="fake"} |~ "(?i)err(or|ors)"`, expectMatch: true},
+		{query: `{app="fake"} |~ "(?i)nope|TimeOut"`, expectMatch: true},
+		// case-sensitive parts are tested as is.
+		{query: `{app="fake"} |~ "Warn.*(?i)error"`, expectMatch: true},
+		{query: `{app="fake"} |~ "warn.*(?i)error"`, expectMatch: false},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			expr, err := syntax.ParseExpr(tc.query)
+			assert.NoError(t, err)
+			filters := syntax.ExtractLineFilters(expr)
+
+			bloomTests := FiltersToBloomTest(fakeNgramBuilder{}, true, filters...)
+
+			assert.Equal(t, tc.expectMatch, bloomTests.Matches(bloom))
+		})
+	}
+}
+
+func TestLabelFiltersToBloomTest(t *testing.T) {
+	bloom := fakeBloom{"trace_id=abc", "trace_id=trace5", "user_id=42"}
+	for _, tc := range []struct {
+		query       string
+		expectMatch bool
+	}{
+		{query: `{app="fake"}`, expectMatch: true},
+		{query: `{app="fake"} | trace_id="abc"`, expectMatch: true},
+		{query: `{app="fake"} | trace_id="nope"`, expectMatch: false},
+		{query: `{app="fake"} | trace_id="abc" | user_id="nope"`, expectMatch: false},
+		{query: `{app="fake"} | trace_id="abc", user_id="nope"`, expectMatch: false},
+		{query: `{app="fake"} | trace_id="nope" or user_id="42"`, expectMatch: true},
+		{query: `{app="fake"} | trace_id="nope" or user_id="nope"`, expectMatch: false},
+		{query: `{app="fake"} | trace_id=~"trace(4|5)"`, expectMatch: true},
+		{query: `{app="fake"} | trace_id=~"trace[0-4]|nope"`, expectMatch: false},
+		{query: `{app="fake"} | user_id=~"4[0-1]"`, expectMatch: false},
+		// only the pairs of the configured keys are added to the blooms.
+		{query: `{app="fake"} | other="nope"`, expectMatch: true},
+		// the entries without the key match the empty value.
+		{query: `{app="fake"} | trace_id=""`, expectMatch: true},
+		{query: `{app="fake"} | trace_id=~"nope|"`, expectMatch: true},
+		// negative, case-insensitive, unbounded and numeric filters can't be tested.
+		{query: `{app="fake"} | trace_id!="abc"`, expectMatch: true},
+		{query: `{app="fake"} | trace_id!~"abc"`, expectMatch: true},
+		{query: `{app="fake"} | trace_id=~"(?i)nope"`, expectMatch: true},
+		{query: `{app="fake"} | trace_id=~"nope.*"`, expectMatch: true},
+		{query: `{app="fake"} | user_id > 42`, expectMatch: true},
+		// the label filters after a parser can apply to extracted labels.
+		{query: `{app="fake"} | json | trace_id="nope"`, expectMatch: true},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			expr, err := syntax.ParseExpr(tc.query)
+			assert.NoError(t, err)
+			filters := syntax.ExtractLabelFiltersBeforeParsers(expr)
+
+			bloomTest := LabelFiltersToBloomTest([]string{"trace_id", "user_id"}, filters...)
+
+			assert.Equal(t, tc.expectMatch, bloomTest.Matches(bloom))
+			assert.Equal(t, tc.expectMatch, bloomTest.MatchesWithPrefixBuf(prefixedBloom{bloom}, []byte("prefix"), len("prefix")))
+		})
+	}
+}
+
+func TestLabelFiltersOnKeys(t *testing.T) {
+	for _, tc := range []struct {
+		query  string
+		onKeys bool
+	}{
+		{query: `{app="fake"}`},
+		{query: `{app="fake"} |= "abc"`},
+		{query: `{app="fake"} | level="error"`},
+		{query: `{app="fake"} | trace_id="abc"`, onKeys: true},
+		{query: `{app="fake"} | level="error" or user_id="42"`, onKeys: true},
+		{query: `{app="fake"} | json | trace_id="abc"`},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			expr, err := syntax.ParseExpr(tc.query)
+			assert.NoError(t, err)
+			filters := syntax.ExtractLabelFiltersBeforeParsers(expr)
+
+			assert.Equal(t, tc.onKeys, LabelFiltersOnKeys([]string{"trace_id", "user_id"}, filters...))
+		})
+	}
+}
+
+// prefixedBloom checks the tokens prefixed with "prefix" against the bloom.
+type prefixedBloom struct {
+	fakeBloom
+}
+
+func (p prefixedBloom) Test(data []byte) bool {
+	token, ok := bytes.CutPrefix(data, []byte("prefix"))
+	return ok && p.fakeBloom.Test(token)
+}
+
 type fakeNgramBuilder struct{}
 
 func (f fakeNgramBuilder) Tokens(line string) Iterator[[]byte] {
@@ -3,10 +3,12 @@ package v1
 import (
 	"fmt"
 	"math"
+	"strings"
 	"time"
 
 	"github.com/go-kit/log/level"
 	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
 
 	"github.com/grafana/dskit/multierror"
 
@@ -25,8 +27,10 @@ Bloom filters are utilized for faster lookups of log lines.
 type BloomTokenizer struct {
 	metrics *Metrics
 
-	lineTokenizer *NGramTokenizer
-	cache         map[string]interface{}
+	lineTokenizer          *NGramTokenizer
+	lowercaseNGrams        bool
+	structuredMetadataKeys []string
+	cache                  map[string]interface{}
 }
 
 const cacheSize = 150000
@@ -38,13 +42,17 @@ const eightBits = 8
 // 1) The token slices generated must not be mutated externally
 // 2) The token slice must not be used after the next call to `Tokens()` as it will repopulate the slice.
 // 2) This is not thread safe.
-func NewBloomTokenizer(nGramLen, nGramSkip int, metrics *Metrics) *BloomTokenizer {
+// The lowercased n-grams of the lines and the key=value pairs of the given structured metadata keys are added
+// to the blooms as well if requested.
+func NewBloomTokenizer(nGramLen, nGramSkip int, lowercaseNGrams bool, structuredMetadataKeys []string, metrics *Metrics) *BloomTokenizer {
 	// TODO(chaudum): Replace logger
-	level.Info(util_log.Logger).Log("msg", "create new bloom tokenizer", "ngram length", nGramLen, "ngram skip", nGramSkip)
+	level.Info(util_log.Logger).Log("msg", "create new bloom tokenizer", "ngram length", nGramLen, "ngram skip", nGramSkip, "lowercase ngrams", lowercaseNGrams, "structured metadata keys", strings.Join(structuredMetadataKeys, ","))
 	return &BloomTokenizer{
-		metrics:       metrics,
-		cache:         make(map[string]interface{}, cacheSize),
-		lineTokenizer: NewNGramTokenizer(nGramLen, nGramSkip),
+		metrics:                metrics,
+		cache:                  make(map[string]interface{}, cacheSize),
+		lineTokenizer:          NewNGramTokenizer(nGramLen, nGramSkip),
+		lowercaseNGrams:        lowercaseNGrams,
+		structuredMetadataKeys: structuredMetadataKeys,
 	}
 }
 
@@ -82,10 +90,21 @@ func prefixedToken(ngram int, chk ChunkRef, buf []byte) ([]byte, int) {
 	return enc.Get(), prefixLn
 }
 
+// structuredMetadataToken appends the token of a structured metadata
+// key=value pair to buf.
+func structuredMetadataToken(buf []byte, key, value string) []byte {
+	buf = append(buf, key...)
+	buf = append(buf, '=')
+	return append(buf, value...)
+}
+
 // ChunkRefWithIter is a wrapper around a ChunkRef and an EntryIterator.
 type ChunkRefWithIter struct {
 	Ref ChunkRef
-	Itr iter.EntryIterator
+	// Labels of the stream, used for the structured metadata keys which are
+	// also stream labels.
+	Labels labels.Labels
+	Itr    iter.EntryIterator
 }
 
 // Populate adds the tokens from the given chunks to the given seriesWithBloom.
@@ -117,59 +136,91 @@ func (bt *BloomTokenizer) Populate(swb *SeriesWithBloom, chks Iterator[ChunkRefW
 		)
 		tokenBuf, prefixLn = prefixedToken(bt.lineTokenizer.N, chk.Ref, tokenBuf)
 
+		// Label filters on the structured metadata keys which are also stream
+		// labels apply to the stream labels, which hold for all the entries.
+		var metadataKeys []string
+		for _, key := range bt.structuredMetadataKeys {
+			if chk.Labels.Has(key) {
+				tokenBuf = bt.addStructuredMetadata(swb.Bloom, tokenBuf, prefixLn, key, chk.Labels.Get(key))
+				continue
+			}
+			metadataKeys = append(metadataKeys, key)
+		}
+
 		// Iterate over lines in the chunk
 		for itr.Next() && itr.Error() == nil {
 			// TODO(owen-d): rather than iterate over the line twice, once for prefixed tokenizer & once for
 			// raw tokenizer, we could iterate once and just return (prefix, token) pairs from the tokenizer.
 			// Double points for them being different-ln references to the same data.
-			line := itr.Entry().Line
+			entry := itr.Entry()
+			line := entry.Line
 			sourceBytes += len(line)
-			chunkTokenizer := NewPrefixedTokenIter(tokenBuf, prefixLn, bt.lineTokenizer.Tokens(line))
-			for chunkTokenizer.Next() {
-				tok := chunkTokenizer.At()
-				tokens++
-				// TODO(owen-d): [n]byte this
-				str := string(tok)
-				_, found := bt.cache[str] // A cache is used ahead of the SBF, as it cuts out the costly operations of scaling bloom filters
-				if found {
-					cachedInserts++
-					continue
-				}
-
-				bt.cache[str] = nil
-				collision := swb.Bloom.ScalableBloomFilter.TestAndAdd(tok)
-				if collision {
-					collisionInserts++
-				} else {
-					successfulInserts++
-				}
 
-				if len(bt.cache) >= cacheSize { // While crude, this has proven efficient in performance testing.  This speaks to the similarity in log lines near each other
-					clearCache(bt.cache)
+			for _, key := range metadataKeys {
+				for _, l := range entry.StructuredMetadata {
+					if l.Name == key && l.Value != "" {
+						tokenBuf = bt.addStructuredMetadata(swb.Bloom, tokenBuf, prefixLn, key, l.Value)
+						break
+					}
 				}
 			}
 
-			lineTokenizer := bt.lineTokenizer.Tokens(line)
-			for lineTokenizer.Next() {
-				tok := lineTokenizer.At()
-				tokens++
-				str := string(tok)
-				_, found := bt.cache[str] // A cache is used ahead of the SBF, as it cuts out the costly operations of scaling bloom filters
-				if found {
-					chunkCachedInserts++
-					continue
+			// The lowercased n-grams allow testing case-insensitive line filters.
+			tokenized := [2]string{line}
+			n := 1
+			if bt.lowercaseNGrams {
+				if lowered := lowercase(line); lowered != line {
+					tokenized[1], n = lowered, 2
 				}
-				bt.cache[str] = nil
-
-				collision := swb.Bloom.ScalableBloomFilter.TestAndAdd(tok)
-				if collision {
-					chunkCollisionInserts++
-				} else {
-					chunkSuccessfulInserts++
+			}
+			for _, line := range tokenized[:n] {
+				chunkTokenizer := NewPrefixedTokenIter(tokenBuf, prefixLn, bt.lineTokenizer.Tokens(line))
+				for chunkTokenizer.Next() {
+					tok := chunkTokenizer.At()
+					tokens++
+					// TODO(owen-d): [n]byte this
+					str := string(tok)
+					_, found := bt.cache[str] // A cache is used ahead of the SBF, as it cuts out the costly operations of scaling bloom filters
+					if found {
+						cachedInserts++
+						continue
+					}
+
+					bt.cache[str] = nil
+					collision := swb.Bloom.ScalableBloomFilter.TestAndAdd(tok)
+					if collision {
+						collisionInserts++
+					} else {
+						successfulInserts++
+					}
+
+					if len(bt.cache) >= cacheSize { // While crude, this has proven efficient in performance testing.  This speaks to the similarity in log lines near each other
+						clearCache(bt.cache)
+					}
 				}
 
-				if len(bt.cache) >= cacheSize { // While crude, this has proven efficient in performance testing.  This speaks to the similarity in log lines near each other
-					clearCache(bt.cache)
+				lineTokenizer := bt.lineTokenizer.Tokens(line)
+				for lineTokenizer.Next() {
+					tok := lineTokenizer.At()
+					tokens++
+					str := string(tok)
+					_, found := bt.cache[str] // A cache is used ahead of the SBF, as it cuts out the costly operations of scaling bloom filters
+					if found {
+						chunkCachedInserts++
+						continue
+					}
+					bt.cache[str] = nil
+
+					collision := swb.Bloom.ScalableBloomFilter.TestAndAdd(tok)
+					if collision {
+						chunkCollisionInserts++
+					} else {
+						chunkSuccessfulInserts++
+					}
+
+					if len(bt.cache) >= cacheSize { // While crude, this has proven efficient in performance testing.  This speaks to the similarity in log lines near each other
+						clearCache(bt.cache)
+					}
 				}
 			}
 
@@ -213,6 +264,33 @@ func (bt *BloomTokenizer) Populate(swb *SeriesWithBloom, chks Iterator[ChunkRefW
 	return sourceBytes, nil
 }
 
+// addStructuredMetadata adds the raw and chunk prefixed tokens of a
+// structured metadata key=value pair to the bloom. It returns the buffer of
+// the prefixed token for reuse.
+func (bt *BloomTokenizer) addStructuredMetadata(bloom *Bloom, buf []byte, prefixLn int, key, value string) []byte {
+	buf = structuredMetadataToken(buf[:prefixLn], key, value)
+	for _, tok := range [][]byte{buf[prefixLn:], buf} {
+		bt.metrics.tokensTotal.Inc()
+		str := string(tok)
+		if _, found := bt.cache[str]; found {
+			bt.metrics.insertsTotal.WithLabelValues(tokenTypeStructuredMetadata, collisionTypeCache).Inc()
+			continue
+		}
+		bt.cache[str] = nil
+
+		if bloom.ScalableBloomFilter.TestAndAdd(tok) {
+			bt.metrics.insertsTotal.WithLabelValues(tokenTypeStructuredMetadata, collisionTypeTrue).Inc()
+		} else {
+			bt.metrics.insertsTotal.WithLabelValues(tokenTypeStructuredMetadata, collisionTypeFalse).Inc()
+		}
+
+		if len(bt.cache) >= cacheSize {
+			clearCache(bt.cache)
+		}
+	}
+	return buf
+}
+
 // n ≈ −m ln(1 − p).
 func estimatedCount(m uint, p float64) uint {
 	return uint(-float64(m) * math.Log(1-p))
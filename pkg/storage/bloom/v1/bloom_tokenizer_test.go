@@ -78,7 +78,7 @@ func TestPrefixedKeyCreation(t *testing.T) {
 
 func TestSetLineTokenizer(t *testing.T) {
 	t.Parallel()
-	bt := NewBloomTokenizer(DefaultNGramLength, DefaultNGramSkip, metrics)
+	bt := NewBloomTokenizer(DefaultNGramLength, DefaultNGramSkip, false, nil, metrics)
 
 	// Validate defaults
 	require.Equal(t, bt.lineTokenizer.N, DefaultNGramLength)
@@ -93,7 +93,7 @@ func TestSetLineTokenizer(t *testing.T) {
 func TestTokenizerPopulate(t *testing.T) {
 	t.Parallel()
 	var testLine = "this is a log line"
-	bt := NewBloomTokenizer(DefaultNGramLength, DefaultNGramSkip, metrics)
+	bt := NewBloomTokenizer(DefaultNGramLength, DefaultNGramSkip, false, nil, metrics)
 
 	sbf := filter.NewScalableBloomFilter(1024, 0.01, 0.8)
 	var lbsList []labels.Labels
@@ -134,10 +134,97 @@ func TestTokenizerPopulate(t *testing.T) {
 	}
 }
 
+func TestTokenizerPopulateLowercaseNGrams(t *testing.T) {
+	t.Parallel()
+	bt := NewBloomTokenizer(DefaultNGramLength, DefaultNGramSkip, true, nil, metrics)
+
+	memChunk := chunkenc.NewMemChunk(chunkenc.ChunkFormatV4, chunkenc.EncSnappy, chunkenc.ChunkHeadFormatFor(chunkenc.ChunkFormatV4), 256000, 1500000)
+	require.NoError(t, memChunk.Append(&push.Entry{
+		Timestamp: time.Unix(0, 1),
+		Line:      "Request TIMEOUT",
+	}))
+	itr, err := memChunk.Iterator(
+		context.Background(),
+		time.Unix(0, 0),
+		time.Unix(0, math.MaxInt64),
+		logproto.FORWARD,
+		log.NewNoopPipeline().ForStream(nil),
+	)
+	require.Nil(t, err)
+
+	swb := SeriesWithBloom{
+		Bloom:  &Bloom{ScalableBloomFilter: *filter.NewScalableBloomFilter(1024, 0.01, 0.8)},
+		Series: &Series{},
+	}
+	_, err = bt.Populate(&swb, NewSliceIter([]ChunkRefWithIter{{Ref: ChunkRef{}, Itr: itr}}))
+	require.NoError(t, err)
+
+	// both the n-grams of the line and of the lowercased line are added.
+	for _, line := range []string{"Request TIMEOUT", "request timeout"} {
+		toks := four.Tokens(line)
+		for toks.Next() {
+			require.True(t, swb.Bloom.Test(toks.At()), string(toks.At()))
+		}
+	}
+}
+
+func TestTokenizerPopulateStructuredMetadata(t *testing.T) {
+	t.Parallel()
+	bt := NewBloomTokenizer(DefaultNGramLength, DefaultNGramSkip, false, []string{"trace_id", "pod", "user_id"}, metrics)
+
+	memChunk := chunkenc.NewMemChunk(chunkenc.ChunkFormatV4, chunkenc.EncSnappy, chunkenc.ChunkHeadFormatFor(chunkenc.ChunkFormatV4), 256000, 1500000)
+	for i, metadata := range []labels.Labels{
+		labels.FromStrings("trace_id", "abc", "pod", "metadata"),
+		labels.FromStrings("trace_id", "def", "user_id", ""),
+		labels.FromStrings("other", "ghi"),
+	} {
+		require.NoError(t, memChunk.Append(&push.Entry{
+			Timestamp:          time.Unix(0, int64(i)),
+			Line:               "line",
+			StructuredMetadata: push.LabelsAdapter(logproto.FromLabelsToLabelAdapters(metadata)),
+		}))
+	}
+	itr, err := memChunk.Iterator(
+		context.Background(),
+		time.Unix(0, 0),
+		time.Unix(0, math.MaxInt64),
+		logproto.FORWARD,
+		log.NewNoopPipeline().ForStream(nil),
+	)
+	require.Nil(t, err)
+
+	swb := SeriesWithBloom{
+		Bloom:  &Bloom{ScalableBloomFilter: *filter.NewScalableBloomFilter(1024, 0.01, 0.8)},
+		Series: &Series{},
+	}
+	ref := ChunkRef{From: 1, Through: 2, Checksum: 3}
+	_, err = bt.Populate(&swb, NewSliceIter([]ChunkRefWithIter{{Ref: ref, Labels: labels.FromStrings("pod", "stream"), Itr: itr}}))
+	require.NoError(t, err)
+
+	prefix, prefixLn := prefixedToken(DefaultNGramLength, ref, nil)
+	for _, tc := range []struct {
+		key, value string
+		exp        bool
+	}{
+		{key: "trace_id", value: "abc", exp: true},
+		{key: "trace_id", value: "def", exp: true},
+		// pod is a stream label, so the stream label value is added.
+		{key: "pod", value: "stream", exp: true},
+		{key: "pod", value: "metadata", exp: false},
+		// other is not a configured key and empty values are skipped.
+		{key: "other", value: "ghi", exp: false},
+		{key: "user_id", value: "", exp: false},
+	} {
+		token := structuredMetadataToken(nil, tc.key, tc.value)
+		require.Equal(t, tc.exp, swb.Bloom.Test(token), "%s=%s", tc.key, tc.value)
+		require.Equal(t, tc.exp, swb.Bloom.Test(append(prefix[:prefixLn], token...)), "prefixed %s=%s", tc.key, tc.value)
+	}
+}
+
 func BenchmarkPopulateSeriesWithBloom(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		var testLine = lorem + lorem + lorem
-		bt := NewBloomTokenizer(DefaultNGramLength, DefaultNGramSkip, metrics)
+		bt := NewBloomTokenizer(DefaultNGramLength, DefaultNGramSkip, false, nil, metrics)
 
 		sbf := filter.NewScalableBloomFilter(1024, 0.01, 0.8)
 		var lbsList []labels.Labels
@@ -174,7 +261,7 @@ func BenchmarkPopulateSeriesWithBloom(b *testing.B) {
 }
 
 func BenchmarkMapClear(b *testing.B) {
-	bt := NewBloomTokenizer(DefaultNGramLength, DefaultNGramSkip, metrics)
+	bt := NewBloomTokenizer(DefaultNGramLength, DefaultNGramSkip, false, nil, metrics)
 	for i := 0; i < b.N; i++ {
 		for k := 0; k < cacheSize; k++ {
 			bt.cache[fmt.Sprint(k)] = k
@@ -185,7 +272,7 @@ func BenchmarkMapClear(b *testing.B) {
 }
 
 func BenchmarkNewMap(b *testing.B) {
-	bt := NewBloomTokenizer(DefaultNGramLength, DefaultNGramSkip, metrics)
+	bt := NewBloomTokenizer(DefaultNGramLength, DefaultNGramSkip, false, nil, metrics)
 	for i := 0; i < b.N; i++ {
 		for k := 0; k < cacheSize; k++ {
 			bt.cache[fmt.Sprint(k)] = k
@@ -8,6 +8,7 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/prometheus/common/model"
+	"golang.org/x/exp/slices"
 
 	"github.com/grafana/loki/pkg/chunkenc"
 	"github.com/grafana/loki/pkg/storage/bloom/v1/filter"
@@ -15,7 +16,7 @@ import (
 )
 
 var (
-	DefaultBlockOptions = NewBlockOptions(4, 1, 50<<20) // 50MB
+	DefaultBlockOptions = NewBlockOptions(4, 1, 50<<20, nil, false) // 50MB
 )
 
 type BlockOptions struct {
@@ -38,17 +39,17 @@ func (b BlockOptions) Len() int {
 }
 
 func (b *BlockOptions) DecodeFrom(r io.ReadSeeker) error {
-	buf := make([]byte, b.Len())
+	if err := b.Schema.DecodeFrom(r); err != nil {
+		return errors.Wrap(err, "decoding schema")
+	}
+
+	buf := make([]byte, 3*8)
 	_, err := io.ReadFull(r, buf)
 	if err != nil {
 		return errors.Wrap(err, "reading block options")
 	}
 
 	dec := encoding.DecWith(buf)
-
-	if err := b.Schema.Decode(&dec); err != nil {
-		return errors.Wrap(err, "decoding schema")
-	}
 	b.SeriesPageSize = dec.Be64()
 	b.BloomPageSize = dec.Be64()
 	b.BlockSize = dec.Be64()
@@ -70,12 +71,30 @@ type BlockBuilder struct {
 	blooms *BloomBlockBuilder
 }
 
-func NewBlockOptions(NGramLength, NGramSkip, MaxBlockSizeBytes uint64) BlockOptions {
-	opts := NewBlockOptionsFromSchema(Schema{
-		version:     byte(1),
-		nGramLength: NGramLength,
-		nGramSkip:   NGramSkip,
-	})
+// NewBlockOptions returns the options of the blocks built with the given
+// settings. The structured metadata keys are sorted and deduplicated, so that
+// their order in the configuration doesn't make the schemas incompatible.
+func NewBlockOptions(NGramLength, NGramSkip, MaxBlockSizeBytes uint64, StructuredMetadataKeys []string, LowercaseNGrams bool) BlockOptions {
+	keys := slices.Clone(StructuredMetadataKeys)
+	slices.Sort(keys)
+	keys = slices.Compact(keys)
+	schema := Schema{
+		version:                V1,
+		nGramLength:            NGramLength,
+		nGramSkip:              NGramSkip,
+		structuredMetadataKeys: keys,
+		lowercaseNGrams:        LowercaseNGrams,
+	}
+	// blocks keep the oldest schema version supporting their options
+	switch {
+	case LowercaseNGrams:
+		schema.version = V3
+	case len(keys) > 0:
+		schema.version = V2
+	default:
+		schema.structuredMetadataKeys = nil
+	}
+	opts := NewBlockOptionsFromSchema(schema)
 	opts.BlockSize = MaxBlockSizeBytes
 	return opts
 }
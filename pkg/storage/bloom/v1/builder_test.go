@@ -15,26 +15,70 @@ import (
 
 func TestBlockOptionsRoundTrip(t *testing.T) {
 	t.Parallel()
-	opts := BlockOptions{
-		Schema: Schema{
+	for _, schema := range []Schema{
+		{
 			version:     V1,
 			encoding:    chunkenc.EncSnappy,
 			nGramLength: 10,
 			nGramSkip:   2,
 		},
-		SeriesPageSize: 100,
-		BloomPageSize:  10 << 10,
-		BlockSize:      10 << 20,
-	}
+		{
+			version:                V2,
+			encoding:               chunkenc.EncSnappy,
+			nGramLength:            10,
+			nGramSkip:              2,
+			structuredMetadataKeys: []string{"trace_id", "user_id"},
+		},
+		{
+			version:         V3,
+			encoding:        chunkenc.EncSnappy,
+			nGramLength:     10,
+			nGramSkip:       2,
+			lowercaseNGrams: true,
+		},
+	} {
+		t.Run(schema.String(), func(t *testing.T) {
+			opts := BlockOptions{
+				Schema:         schema,
+				SeriesPageSize: 100,
+				BloomPageSize:  10 << 10,
+				BlockSize:      10 << 20,
+			}
 
-	var enc encoding.Encbuf
-	opts.Encode(&enc)
+			var enc encoding.Encbuf
+			opts.Encode(&enc)
+			require.Equal(t, opts.Len(), enc.Len())
 
-	var got BlockOptions
-	err := got.DecodeFrom(bytes.NewReader(enc.Get()))
-	require.Nil(t, err)
+			var got BlockOptions
+			err := got.DecodeFrom(bytes.NewReader(enc.Get()))
+			require.Nil(t, err)
+
+			require.Equal(t, opts, got)
+			require.True(t, opts.Schema.Compatible(got.Schema))
+		})
+	}
+}
 
-	require.Equal(t, opts, got)
+func TestSchemaCompatible(t *testing.T) {
+	t.Parallel()
+	withoutKeys := NewBlockOptions(4, 1, 0, nil, false).Schema
+	withKeys := NewBlockOptions(4, 1, 0, []string{"trace_id"}, false).Schema
+
+	require.Equal(t, V1, withoutKeys.version)
+	require.Equal(t, V2, withKeys.version)
+	require.True(t, withKeys.Compatible(NewBlockOptions(4, 1, 0, []string{"trace_id"}, false).Schema))
+	require.False(t, withoutKeys.Compatible(withKeys))
+	require.False(t, withKeys.Compatible(NewBlockOptions(4, 1, 0, []string{"trace_id", "user_id"}, false).Schema))
+
+	// The order of the keys and their duplicates don't matter.
+	withTwoKeys := NewBlockOptions(4, 1, 0, []string{"user_id", "trace_id", "user_id"}, false).Schema
+	require.Equal(t, []string{"trace_id", "user_id"}, withTwoKeys.StructuredMetadataKeys())
+	require.True(t, withTwoKeys.Compatible(NewBlockOptions(4, 1, 0, []string{"trace_id", "user_id"}, false).Schema))
+
+	lowercase := NewBlockOptions(4, 1, 0, []string{"trace_id"}, true).Schema
+	require.Equal(t, V3, lowercase.version)
+	require.True(t, lowercase.LowercaseNGrams())
+	require.False(t, withKeys.Compatible(lowercase))
 }
 
 func TestBlockBuilderRoundTrip(t *testing.T) {
@@ -54,6 +98,7 @@ func TestBlockBuilderRoundTrip(t *testing.T) {
 		reader             BlockReader
 		maxBlockSize       uint64
 		iterHasPendingData bool
+		structuredMetadata []string
 	}{
 		{
 			desc:   "in-memory",
@@ -73,6 +118,12 @@ func TestBlockBuilderRoundTrip(t *testing.T) {
 			maxBlockSize:       50 << 10,
 			iterHasPendingData: true,
 		},
+		{
+			desc:               "structured metadata keys",
+			writer:             NewDirectoryBlockWriter(tmpDir),
+			reader:             NewDirectoryBlockReader(tmpDir),
+			structuredMetadata: []string{"trace_id"},
+		},
 	} {
 		t.Run(tc.desc, func(t *testing.T) {
 			schema := Schema{
@@ -81,6 +132,10 @@ func TestBlockBuilderRoundTrip(t *testing.T) {
 				nGramLength: 10,
 				nGramSkip:   2,
 			}
+			if tc.structuredMetadata != nil {
+				schema.version = V2
+				schema.structuredMetadataKeys = tc.structuredMetadata
+			}
 
 			builder, err := NewBlockBuilder(
 				BlockOptions{
@@ -2,30 +2,56 @@ package v1
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
 	"io"
 	"sort"
+	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/prometheus/common/model"
+	"golang.org/x/exp/slices"
 
 	"github.com/grafana/loki/pkg/chunkenc"
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/util/encoding"
 )
 
+// magic number + version + encoding + ngram length + ngram skip
+const schemaHeaderLen = 4 + 1 + 1 + 8 + 8
+
 type Schema struct {
 	version                byte
 	encoding               chunkenc.Encoding
 	nGramLength, nGramSkip uint64
+
+	// structured metadata keys whose key=value pairs are added to the blooms,
+	// only stored from V2.
+	structuredMetadataKeys []string
+	// whether the lowercased n-grams of the lines are added to the blooms,
+	// only stored from V3.
+	lowercaseNGrams bool
 }
 
+// flags of the schema extension from V3
+const (
+	schemaFlagLowercaseNGrams = 1 << iota
+)
+
 func (s Schema) String() string {
+	if s.version >= V2 {
+		return fmt.Sprintf("v%d,encoding=%s,ngram=%d,skip=%d,structured_metadata_keys=%s,lowercase_ngrams=%t", s.version, s.encoding, s.nGramLength, s.nGramSkip, strings.Join(s.structuredMetadataKeys, ","), s.lowercaseNGrams)
+	}
 	return fmt.Sprintf("v%d,encoding=%s,ngram=%d,skip=%d", s.version, s.encoding, s.nGramLength, s.nGramSkip)
 }
 
 func (s Schema) Compatible(other Schema) bool {
-	return s == other
+	return s.version == other.version &&
+		s.encoding == other.encoding &&
+		s.nGramLength == other.nGramLength &&
+		s.nGramSkip == other.nGramSkip &&
+		slices.Equal(s.structuredMetadataKeys, other.structuredMetadataKeys) &&
+		s.lowercaseNGrams == other.lowercaseNGrams
 }
 
 func (s Schema) NGramLen() int {
@@ -36,10 +62,26 @@ func (s Schema) NGramSkip() int {
 	return int(s.nGramSkip)
 }
 
+// StructuredMetadataKeys returns the structured metadata keys whose key=value
+// pairs are added to the blooms.
+func (s Schema) StructuredMetadataKeys() []string {
+	return s.structuredMetadataKeys
+}
+
+// LowercaseNGrams returns whether the lowercased n-grams of the lines are
+// added to the blooms, which allows testing case-insensitive line filters.
+func (s Schema) LowercaseNGrams() bool {
+	return s.lowercaseNGrams
+}
+
 // byte length
 func (s Schema) Len() int {
-	// magic number + version + encoding + ngram length + ngram skip
-	return 4 + 1 + 1 + 8 + 8
+	n := schemaHeaderLen
+	if s.version >= V2 {
+		// length + extension
+		n += 4 + len(s.encodeExtension())
+	}
+	return n
 }
 
 func (s *Schema) DecompressorPool() chunkenc.ReaderPool {
@@ -58,16 +100,52 @@ func (s *Schema) Encode(enc *encoding.Encbuf) {
 	enc.PutBE64(s.nGramLength)
 	enc.PutBE64(s.nGramSkip)
 
+	if s.version >= V2 {
+		ext := s.encodeExtension()
+		enc.PutBE32(uint32(len(ext)))
+		enc.PutBytes(ext)
+	}
+}
+
+// encodeExtension encodes the variable size part of the schema, which follows
+// the fixed size header from V2.
+func (s *Schema) encodeExtension() []byte {
+	enc := encoding.EncWith(nil)
+	enc.PutUvarint(len(s.structuredMetadataKeys))
+	for _, k := range s.structuredMetadataKeys {
+		enc.PutUvarintStr(k)
+	}
+	if s.version >= V3 {
+		var flags byte
+		if s.lowercaseNGrams {
+			flags |= schemaFlagLowercaseNGrams
+		}
+		enc.PutByte(flags)
+	}
+	return enc.Get()
 }
 
 func (s *Schema) DecodeFrom(r io.ReadSeeker) error {
 	// TODO(owen-d): improve allocations
-	schemaBytes := make([]byte, s.Len())
+	schemaBytes := make([]byte, schemaHeaderLen)
 	_, err := io.ReadFull(r, schemaBytes)
 	if err != nil {
 		return errors.Wrap(err, "reading schema")
 	}
 
+	// the extension of V2 follows the fixed size header
+	if schemaBytes[4] >= V2 {
+		extLen := make([]byte, 4)
+		if _, err := io.ReadFull(r, extLen); err != nil {
+			return errors.Wrap(err, "reading schema extension length")
+		}
+		ext := make([]byte, binary.BigEndian.Uint32(extLen))
+		if _, err := io.ReadFull(r, ext); err != nil {
+			return errors.Wrap(err, "reading schema extension")
+		}
+		schemaBytes = append(append(schemaBytes, extLen...), ext...)
+	}
+
 	dec := encoding.DecWith(schemaBytes)
 	return s.Decode(&dec)
 }
@@ -78,8 +156,8 @@ func (s *Schema) Decode(dec *encoding.Decbuf) error {
 		return errors.Errorf("invalid magic number. expected %x, got  %x", magicNumber, number)
 	}
 	s.version = dec.Byte()
-	if s.version != 1 {
-		return errors.Errorf("invalid version. expected %d, got %d", 1, s.version)
+	if s.version < V1 || s.version > V3 {
+		return errors.Errorf("invalid version. expected %d to %d, got %d", V1, V3, s.version)
 	}
 
 	s.encoding = chunkenc.Encoding(dec.Byte())
@@ -90,6 +168,21 @@ func (s *Schema) Decode(dec *encoding.Decbuf) error {
 	s.nGramLength = dec.Be64()
 	s.nGramSkip = dec.Be64()
 
+	s.structuredMetadataKeys = nil
+	s.lowercaseNGrams = false
+	if s.version >= V2 {
+		ext := encoding.DecWith(dec.Bytes(int(dec.Be32())))
+		for n := ext.Uvarint(); n > 0 && ext.Err() == nil; n-- {
+			s.structuredMetadataKeys = append(s.structuredMetadataKeys, ext.UvarintStr())
+		}
+		if s.version >= V3 {
+			s.lowercaseNGrams = ext.Byte()&schemaFlagLowercaseNGrams != 0
+		}
+		if err := ext.Err(); err != nil {
+			return errors.Wrap(err, "decoding schema extension")
+		}
+	}
+
 	return dec.Err()
 }
 
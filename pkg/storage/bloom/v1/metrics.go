@@ -23,9 +23,11 @@ const (
 
 	tokenTypeRaw           = "raw"
 	tokenTypeChunkPrefixed = "chunk_prefixed"
-	collisionTypeFalse     = "false"
-	collisionTypeTrue      = "true"
-	collisionTypeCache     = "cache"
+	// raw and chunk prefixed structured metadata key=value pairs
+	tokenTypeStructuredMetadata = "structured_metadata"
+	collisionTypeFalse          = "false"
+	collisionTypeTrue           = "true"
+	collisionTypeCache          = "cache"
 )
 
 func NewMetrics(r prometheus.Registerer) *Metrics {
@@ -1,6 +1,8 @@
 package v1
 
 import (
+	"strings"
+	"unicode"
 	"unicode/utf8"
 )
 
@@ -18,6 +20,30 @@ func reassemble(buf []rune, ln, pos int, result []byte) []byte {
 	return result
 }
 
+// lowercase lowercases s, mapping all the runes which are equal under simple
+// case folding to the same rune, as they are matched by case-insensitive
+// regexes. Unlike strings.ToLower, it maps for instance the long s to s.
+// Lowercasing rune by rune keeps the lowercase of a substring of a line a
+// substring of the lowercased line.
+func lowercase(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < utf8.RuneSelf {
+			if 'A' <= r && r <= 'Z' {
+				r += 'a' - 'A'
+			}
+			return r
+		}
+		// the smallest rune of the orbit of the equal runes under folding
+		folded := r
+		for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+			if f < folded {
+				folded = f
+			}
+		}
+		return unicode.ToLower(folded)
+	}, s)
+}
+
 // Iterable variants (more performant, less space)
 type NGramTokenizer struct {
 	N, Skip int
@@ -89,6 +89,23 @@ func TestInvalidUTF8(t *testing.T) {
 	require.False(t, itr.Next())
 }
 
+func TestLowercase(t *testing.T) {
+	for _, tc := range []struct {
+		input, exp string
+	}{
+		{input: "", exp: ""},
+		{input: "Error: TIMEOUT", exp: "error: timeout"},
+		{input: "ÉTÉ", exp: "été"},
+		// the runes equal to s and k under simple folding
+		{input: "\u017f\u212a", exp: "sk"},
+		{input: "abc\x80", exp: "abc\ufffd"},
+	} {
+		t.Run(tc.input, func(t *testing.T) {
+			require.Equal(t, tc.exp, lowercase(tc.input))
+		})
+	}
+}
+
 func TestPrefixedIterator(t *testing.T) {
 	t.Parallel()
 	var (
@@ -14,6 +14,10 @@ const (
 	magicNumber = uint32(0xCA7CAFE5)
 	// Add new versions below
 	V1 byte = iota
+	// V2 adds the structured metadata keys to the schema
+	V2
+	// V3 adds the lowercased n-grams flag to the schema
+	V3
 )
 
 const (
@@ -0,0 +1,686 @@
+package cache
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/loki/pkg/logqlmodel/stats"
+	"github.com/grafana/loki/pkg/util/constants"
+)
+
+const (
+	// AdmissionLRU admits all the new entries, evicting the least recently
+	// used ones.
+	AdmissionLRU = "lru"
+	// AdmissionTinyLFU only admits the new entries requested more frequently
+	// than the least recently used entries they would evict.
+	AdmissionTinyLFU = "tinylfu"
+
+	diskCacheEntriesDir = "entries"
+	diskCacheTmpDir     = "tmp"
+	diskCacheIndexFile  = "index"
+
+	diskCacheEntryMagic = "LDC1"
+	diskCacheIndexMagic = "LDI1"
+
+	// The header of the entries is the magic, the time the entry was stored,
+	// the length of the key and of the value, and the checksum of both.
+	diskCacheHeaderSize = 4 + 8 + 4 + 4 + 4
+	// The entries of the index are the hash of the key, the size of the
+	// entry file and the time the entry was stored.
+	diskCacheIndexEntrySize = sha256.Size + 8 + 8
+
+	// diskCacheAvgEntrySize sizes the frequency sketch of the TinyLFU
+	// admission policy for the number of entries the cache can hold.
+	diskCacheAvgEntrySize = 16 << 10
+
+	admissionReason = "admission"
+	corruptReason   = "corrupt"
+)
+
+var (
+	diskCacheCRCTable = crc32.MakeTable(crc32.Castagnoli)
+
+	errDiskCacheCorrupt = errors.New("corrupt disk cache file")
+)
+
+// DiskCacheConfig represents the config of the cache on the local disk.
+type DiskCacheConfig struct {
+	Enabled   bool          `yaml:"enabled,omitempty"`
+	Directory string        `yaml:"directory"`
+	MaxSizeMB int64         `yaml:"max_size_mb"`
+	TTL       time.Duration `yaml:"ttl"`
+	Admission string        `yaml:"admission"`
+
+	// SyncInterval tells how often the index is persisted and the expired
+	// entries are removed. By default it takes `defaultPurgeInterval`.
+	SyncInterval time.Duration `yaml:"-"`
+}
+
+func (cfg *DiskCacheConfig) RegisterFlagsWithPrefix(prefix, description string, f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, prefix+"enabled", false, description+"Whether the disk cache is enabled. The disk cache is used behind the embedded cache and in front of memcached or redis, when they are also enabled.")
+	f.StringVar(&cfg.Directory, prefix+"directory", "", description+"Directory of the disk cache, such as on a local SSD. The directory must not be shared with other caches. The entries in the directory are reused after a restart.")
+	f.Int64Var(&cfg.MaxSizeMB, prefix+"max-size-mb", 10240, description+"Maximum size of the disk cache in MB.")
+	f.DurationVar(&cfg.TTL, prefix+"ttl", 24*time.Hour, description+"The time to live for items in the disk cache before they get purged.")
+	f.StringVar(&cfg.Admission, prefix+"admission", AdmissionTinyLFU, description+"Admission policy of the disk cache once it is full. Supported values are: lru, which admits all the new entries and evicts the least recently used ones, and tinylfu, which only admits the new entries requested more frequently than the least recently used entries they would evict.")
+}
+
+func (cfg *DiskCacheConfig) IsEnabled() bool {
+	return cfg.Enabled
+}
+
+func (cfg *DiskCacheConfig) Validate() error {
+	if cfg.Directory == "" {
+		return errors.New("the directory of the disk cache must be set")
+	}
+	if cfg.MaxSizeMB <= 0 {
+		return errors.New("the maximum size of the disk cache must be greater than 0")
+	}
+	if cfg.Admission != AdmissionLRU && cfg.Admission != AdmissionTinyLFU {
+		return fmt.Errorf("unsupported disk cache admission policy: %s", cfg.Admission)
+	}
+	return nil
+}
+
+type diskCacheHash [sha256.Size]byte
+
+type diskCacheEntry struct {
+	hash   diskCacheHash
+	size   int64
+	stored time.Time
+}
+
+// DiskCache is a cache of byte arrays by key in files on the local disk,
+// which keeps its entries across restarts.
+//
+// Each entry is a file named after the hash of its key, holding the key, the
+// value and their checksum. The entry files are written to a temporary file
+// first and renamed, and the checksum is verified when the entries are read,
+// so the entries torn by a crash are dropped. The index of the entries in LRU
+// order is kept in memory, and periodically persisted to restore the order of
+// the entries after a restart; the entry files left out of the persisted index
+// are added back as the most recently used.
+//
+// The entries are evicted in LRU order once the cache is full. With the
+// TinyLFU admission policy, a count-min sketch estimates how frequently the
+// keys are requested, and new entries are only admitted if they are requested
+// more frequently than the entries they would evict.
+type DiskCache struct {
+	cacheType stats.CacheType
+	logger    log.Logger
+
+	dir          string
+	maxSizeBytes int64
+	ttl          time.Duration
+
+	lock          sync.Mutex
+	entries       map[diskCacheHash]*list.Element
+	lru           *list.List
+	currSizeBytes int64
+	// sketch is nil with the LRU admission policy.
+	sketch *frequencySketch
+
+	done     chan struct{}
+	wg       sync.WaitGroup
+	stopOnce sync.Once
+
+	entriesAddedNew prometheus.Counter
+	entriesEvicted  *prometheus.CounterVec
+	entriesRejected *prometheus.CounterVec
+	entriesCurrent  prometheus.Gauge
+	sizeBytes       prometheus.Gauge
+}
+
+// NewDiskCache returns a new DiskCache, loading the entries already in its
+// directory.
+func NewDiskCache(name string, cfg DiskCacheConfig, reg prometheus.Registerer, logger log.Logger, cacheType stats.CacheType) (*DiskCache, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.SyncInterval == 0 {
+		cfg.SyncInterval = defaultPurgeInterval
+	}
+
+	c := &DiskCache{
+		cacheType: cacheType,
+		logger:    log.With(logger, "cache", name),
+
+		dir:          cfg.Directory,
+		maxSizeBytes: cfg.MaxSizeMB * 1e6,
+		ttl:          cfg.TTL,
+
+		entries: make(map[diskCacheHash]*list.Element),
+		lru:     list.New(),
+
+		done: make(chan struct{}),
+
+		entriesAddedNew: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Namespace:   constants.Loki,
+			Subsystem:   "diskcache",
+			Name:        "added_new_total",
+			Help:        "The total number of new entries added to the cache",
+			ConstLabels: prometheus.Labels{"cache": name},
+		}),
+
+		entriesEvicted: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace:   constants.Loki,
+			Subsystem:   "diskcache",
+			Name:        "evicted_total",
+			Help:        "The total number of evicted entries",
+			ConstLabels: prometheus.Labels{"cache": name},
+		}, []string{"reason"}),
+
+		entriesRejected: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace:   constants.Loki,
+			Subsystem:   "diskcache",
+			Name:        "rejected_total",
+			Help:        "The total number of entries not admitted to the cache",
+			ConstLabels: prometheus.Labels{"cache": name},
+		}, []string{"reason"}),
+
+		entriesCurrent: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Namespace:   constants.Loki,
+			Subsystem:   "diskcache",
+			Name:        "entries",
+			Help:        "Current number of entries in the cache",
+			ConstLabels: prometheus.Labels{"cache": name},
+		}),
+
+		sizeBytes: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Namespace:   constants.Loki,
+			Subsystem:   "diskcache",
+			Name:        "size_bytes",
+			Help:        "The current size of the entry files of the cache in bytes",
+			ConstLabels: prometheus.Labels{"cache": name},
+		}),
+	}
+	if cfg.Admission == AdmissionTinyLFU {
+		c.sketch = newFrequencySketch(int(c.maxSizeBytes / diskCacheAvgEntrySize))
+	}
+
+	// Temporary files are left behind by the writes interrupted by a crash.
+	if err := os.RemoveAll(filepath.Join(c.dir, diskCacheTmpDir)); err != nil {
+		return nil, err
+	}
+	for _, dir := range []string{diskCacheEntriesDir, diskCacheTmpDir} {
+		if err := os.MkdirAll(filepath.Join(c.dir, dir), 0o750); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.load(); err != nil {
+		return nil, fmt.Errorf("failed to load disk cache: %w", err)
+	}
+
+	c.wg.Add(1)
+	go c.runSyncJob(cfg.SyncInterval)
+
+	return c, nil
+}
+
+func hashKey(key string) diskCacheHash {
+	return sha256.Sum256([]byte(key))
+}
+
+func (c *DiskCache) entryPath(hash diskCacheHash) string {
+	name := hex.EncodeToString(hash[:])
+	return filepath.Join(c.dir, diskCacheEntriesDir, name[:2], name)
+}
+
+// Fetch implements Cache.
+func (c *DiskCache) Fetch(_ context.Context, keys []string) (foundKeys []string, foundValues [][]byte, missingKeys []string, err error) {
+	foundKeys, missingKeys, foundValues = make([]string, 0, len(keys)), make([]string, 0, len(keys)), make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		val, ok := c.get(key)
+		if !ok {
+			missingKeys = append(missingKeys, key)
+			continue
+		}
+
+		foundKeys = append(foundKeys, key)
+		foundValues = append(foundValues, val)
+	}
+	return
+}
+
+func (c *DiskCache) get(key string) ([]byte, bool) {
+	hash := hashKey(key)
+
+	c.lock.Lock()
+	if c.sketch != nil {
+		c.sketch.Increment(hash)
+	}
+	element, ok := c.entries[hash]
+	if !ok {
+		c.lock.Unlock()
+		return nil, false
+	}
+	entry := element.Value.(*diskCacheEntry)
+	if c.ttl > 0 && time.Since(entry.stored) > c.ttl {
+		c.remove(element, expiredReason)
+		c.lock.Unlock()
+		return nil, false
+	}
+	c.lru.MoveToFront(element)
+	c.lock.Unlock()
+
+	// The entry file is read without holding the lock. It's either the
+	// previous or the new version of the entry if it's replaced concurrently,
+	// since the entry files are replaced by renaming them.
+	data, err := os.ReadFile(c.entryPath(hash))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			level.Warn(c.logger).Log("msg", "failed to read disk cache entry", "err", err)
+		}
+		c.removeIfCurrent(element, corruptReason)
+		return nil, false
+	}
+	storedKey, value, _, err := decodeDiskCacheEntry(data)
+	if err != nil {
+		level.Warn(c.logger).Log("msg", "dropping corrupt disk cache entry", "err", err)
+		c.removeIfCurrent(element, corruptReason)
+		return nil, false
+	}
+	if storedKey != key {
+		return nil, false
+	}
+	return value, true
+}
+
+// Store implements Cache.
+func (c *DiskCache) Store(_ context.Context, keys []string, values [][]byte) error {
+	for i := range keys {
+		if err := c.put(keys[i], values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *DiskCache) put(key string, value []byte) error {
+	hash := hashKey(key)
+	size := int64(diskCacheHeaderSize + len(key) + len(value))
+	if size > c.maxSizeBytes {
+		c.entriesRejected.WithLabelValues(tooBigReason).Inc()
+		return nil
+	}
+
+	// Check the admission before writing the entry file, and again before
+	// adding it, since other entries can be added in the meantime.
+	c.lock.Lock()
+	admitted := c.admit(hash, size)
+	c.lock.Unlock()
+	if !admitted {
+		c.entriesRejected.WithLabelValues(admissionReason).Inc()
+		return nil
+	}
+
+	stored := time.Now()
+	tmpPath, err := c.writeTemp(key, value, stored)
+	if err != nil {
+		return fmt.Errorf("failed to write disk cache entry: %w", err)
+	}
+	defer os.Remove(tmpPath)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if !c.admit(hash, size) {
+		c.entriesRejected.WithLabelValues(admissionReason).Inc()
+		return nil
+	}
+	path := c.entryPath(hash)
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to write disk cache entry: %w", err)
+	}
+
+	element, replaced := c.entries[hash]
+	if replaced {
+		entry := element.Value.(*diskCacheEntry)
+		c.currSizeBytes += size - entry.size
+		entry.size, entry.stored = size, stored
+		c.lru.MoveToFront(element)
+	} else {
+		c.entries[hash] = c.lru.PushFront(&diskCacheEntry{hash: hash, size: size, stored: stored})
+		c.currSizeBytes += size
+		c.entriesAddedNew.Inc()
+		c.entriesCurrent.Inc()
+	}
+	c.evict()
+	c.sizeBytes.Set(float64(c.currSizeBytes))
+	return nil
+}
+
+// admit returns whether a new entry of the size can be added. With the
+// TinyLFU admission policy, the entry must be requested more frequently than
+// all the entries it would evict.
+func (c *DiskCache) admit(hash diskCacheHash, size int64) bool {
+	if _, ok := c.entries[hash]; ok || c.sketch == nil {
+		return true
+	}
+
+	frequency := c.sketch.Estimate(hash)
+	needed := c.currSizeBytes + size - c.maxSizeBytes
+	for element := c.lru.Back(); element != nil && needed > 0; element = element.Prev() {
+		victim := element.Value.(*diskCacheEntry)
+		if c.sketch.Estimate(victim.hash) >= frequency {
+			return false
+		}
+		needed -= victim.size
+	}
+	return true
+}
+
+// evict removes the least recently used entries until the cache fits its
+// maximum size.
+func (c *DiskCache) evict() {
+	for c.currSizeBytes > c.maxSizeBytes {
+		element := c.lru.Back()
+		if element == nil {
+			return
+		}
+		c.remove(element, fullReason)
+	}
+}
+
+func (c *DiskCache) remove(element *list.Element, reason string) {
+	entry := c.lru.Remove(element).(*diskCacheEntry)
+	delete(c.entries, entry.hash)
+	if err := os.Remove(c.entryPath(entry.hash)); err != nil && !os.IsNotExist(err) {
+		level.Warn(c.logger).Log("msg", "failed to remove disk cache entry", "err", err)
+	}
+	c.currSizeBytes -= entry.size
+	c.entriesCurrent.Dec()
+	c.entriesEvicted.WithLabelValues(reason).Inc()
+	c.sizeBytes.Set(float64(c.currSizeBytes))
+}
+
+// removeIfCurrent removes the entry unless it was already removed or replaced.
+func (c *DiskCache) removeIfCurrent(element *list.Element, reason string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	entry := element.Value.(*diskCacheEntry)
+	if c.entries[entry.hash] == element {
+		c.remove(element, reason)
+	}
+}
+
+func (c *DiskCache) writeTemp(key string, value []byte, stored time.Time) (string, error) {
+	f, err := os.CreateTemp(filepath.Join(c.dir, diskCacheTmpDir), "entry-")
+	if err != nil {
+		return "", err
+	}
+
+	header := make([]byte, diskCacheHeaderSize)
+	copy(header, diskCacheEntryMagic)
+	binary.LittleEndian.PutUint64(header[4:], uint64(stored.UnixNano()))
+	binary.LittleEndian.PutUint32(header[12:], uint32(len(key)))
+	binary.LittleEndian.PutUint32(header[16:], uint32(len(value)))
+	checksum := crc32.Update(crc32.Checksum([]byte(key), diskCacheCRCTable), diskCacheCRCTable, value)
+	binary.LittleEndian.PutUint32(header[20:], checksum)
+
+	for _, b := range [][]byte{header, []byte(key), value} {
+		if _, err = f.Write(b); err != nil {
+			break
+		}
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// decodeDiskCacheEntry decodes an entry file, verifying its checksum.
+func decodeDiskCacheEntry(data []byte) (string, []byte, time.Time, error) {
+	stored, err := decodeDiskCacheHeader(data)
+	if err != nil {
+		return "", nil, time.Time{}, err
+	}
+	keyLen := int(binary.LittleEndian.Uint32(data[12:]))
+	valueLen := int(binary.LittleEndian.Uint32(data[16:]))
+	if len(data) != diskCacheHeaderSize+keyLen+valueLen {
+		return "", nil, time.Time{}, errDiskCacheCorrupt
+	}
+	key := data[diskCacheHeaderSize : diskCacheHeaderSize+keyLen]
+	value := data[diskCacheHeaderSize+keyLen:]
+	checksum := crc32.Update(crc32.Checksum(key, diskCacheCRCTable), diskCacheCRCTable, value)
+	if checksum != binary.LittleEndian.Uint32(data[20:]) {
+		return "", nil, time.Time{}, errDiskCacheCorrupt
+	}
+	return string(key), value, stored, nil
+}
+
+func decodeDiskCacheHeader(header []byte) (time.Time, error) {
+	if len(header) < diskCacheHeaderSize || string(header[:4]) != diskCacheEntryMagic {
+		return time.Time{}, errDiskCacheCorrupt
+	}
+	return time.Unix(0, int64(binary.LittleEndian.Uint64(header[4:]))), nil
+}
+
+func (c *DiskCache) runSyncJob(interval time.Duration) {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			c.pruneExpiredItems()
+			if err := c.writeIndex(); err != nil {
+				level.Warn(c.logger).Log("msg", "failed to persist disk cache index", "err", err)
+			}
+		}
+	}
+}
+
+// pruneExpiredItems removes the entries that exceeded their ttl.
+func (c *DiskCache) pruneExpiredItems() {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for _, element := range c.entries {
+		if time.Since(element.Value.(*diskCacheEntry).stored) > c.ttl {
+			c.remove(element, expiredReason)
+		}
+	}
+}
+
+// writeIndex persists the index of the entries in LRU order, replacing the
+// previous one atomically.
+func (c *DiskCache) writeIndex() error {
+	c.lock.Lock()
+	buf := bytes.NewBuffer(make([]byte, 0, 8+c.lru.Len()*diskCacheIndexEntrySize+4))
+	buf.WriteString(diskCacheIndexMagic)
+	_ = binary.Write(buf, binary.LittleEndian, uint32(c.lru.Len()))
+	for element := c.lru.Front(); element != nil; element = element.Next() {
+		entry := element.Value.(*diskCacheEntry)
+		buf.Write(entry.hash[:])
+		_ = binary.Write(buf, binary.LittleEndian, uint64(entry.size))
+		_ = binary.Write(buf, binary.LittleEndian, entry.stored.UnixNano())
+	}
+	c.lock.Unlock()
+	_ = binary.Write(buf, binary.LittleEndian, crc32.Checksum(buf.Bytes(), diskCacheCRCTable))
+
+	f, err := os.CreateTemp(filepath.Join(c.dir, diskCacheTmpDir), "index-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(buf.Bytes())
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filepath.Join(c.dir, diskCacheIndexFile))
+}
+
+// readIndex reads the persisted index of the entries in LRU order.
+func (c *DiskCache) readIndex() ([]diskCacheEntry, error) {
+	data, err := os.ReadFile(filepath.Join(c.dir, diskCacheIndexFile))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 12 || string(data[:4]) != diskCacheIndexMagic {
+		return nil, errDiskCacheCorrupt
+	}
+	body, checksum := data[:len(data)-4], binary.LittleEndian.Uint32(data[len(data)-4:])
+	count := int(binary.LittleEndian.Uint32(data[4:]))
+	if crc32.Checksum(body, diskCacheCRCTable) != checksum || len(body) != 8+count*diskCacheIndexEntrySize {
+		return nil, errDiskCacheCorrupt
+	}
+
+	entries := make([]diskCacheEntry, 0, count)
+	for b := body[8:]; len(b) > 0; b = b[diskCacheIndexEntrySize:] {
+		var entry diskCacheEntry
+		copy(entry.hash[:], b)
+		entry.size = int64(binary.LittleEndian.Uint64(b[sha256.Size:]))
+		entry.stored = time.Unix(0, int64(binary.LittleEndian.Uint64(b[sha256.Size+8:])))
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// load adds the entry files in the directory of the cache to the index, in
+// the order of the persisted index. The entry files are the source of truth:
+// the entries of the persisted index without an entry file are skipped, and
+// the entry files written after the index was persisted are added as the most
+// recently used.
+func (c *DiskCache) load() error {
+	files := map[diskCacheHash]int64{}
+	err := filepath.WalkDir(filepath.Join(c.dir, diskCacheEntriesDir), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		var hash diskCacheHash
+		if n, err := hex.Decode(hash[:], []byte(d.Name())); err != nil || n != len(hash) {
+			return os.Remove(path)
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files[hash] = info.Size()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	indexed, err := c.readIndex()
+	if err != nil && !os.IsNotExist(err) {
+		level.Warn(c.logger).Log("msg", "ignoring disk cache index, the entries are loaded from the entry files", "err", err)
+	}
+	for _, entry := range indexed {
+		if size, ok := files[entry.hash]; !ok || size != entry.size {
+			continue
+		}
+		delete(files, entry.hash)
+		c.add(entry, false)
+	}
+
+	unindexed := make([]diskCacheEntry, 0, len(files))
+	for hash, size := range files {
+		stored, err := c.readStored(hash)
+		if err != nil {
+			level.Warn(c.logger).Log("msg", "dropping corrupt disk cache entry", "err", err)
+			if err := os.Remove(c.entryPath(hash)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+		unindexed = append(unindexed, diskCacheEntry{hash: hash, size: size, stored: stored})
+	}
+	sort.Slice(unindexed, func(i, j int) bool {
+		return unindexed[i].stored.Before(unindexed[j].stored)
+	})
+	for _, entry := range unindexed {
+		c.add(entry, true)
+	}
+
+	c.evict()
+	level.Info(c.logger).Log("msg", "loaded disk cache", "entries", c.lru.Len(), "size_bytes", c.currSizeBytes)
+	return nil
+}
+
+func (c *DiskCache) add(entry diskCacheEntry, front bool) {
+	if front {
+		c.entries[entry.hash] = c.lru.PushFront(&entry)
+	} else {
+		c.entries[entry.hash] = c.lru.PushBack(&entry)
+	}
+	c.currSizeBytes += entry.size
+	c.entriesCurrent.Inc()
+	c.sizeBytes.Set(float64(c.currSizeBytes))
+}
+
+// readStored reads the time the entry was stored from the header of its
+// file.
+func (c *DiskCache) readStored(hash diskCacheHash) (time.Time, error) {
+	f, err := os.Open(c.entryPath(hash))
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer f.Close()
+
+	header := make([]byte, diskCacheHeaderSize)
+	if _, err := io.ReadFull(f, header); err != nil {
+		return time.Time{}, errDiskCacheCorrupt
+	}
+	return decodeDiskCacheHeader(header)
+}
+
+// Stop implements Cache. It persists the index of the entries, for the cache
+// to restart with the same entries.
+func (c *DiskCache) Stop() {
+	c.stopOnce.Do(func() {
+		close(c.done)
+		c.wg.Wait()
+
+		if err := c.writeIndex(); err != nil {
+			level.Warn(c.logger).Log("msg", "failed to persist disk cache index", "err", err)
+		}
+	})
+}
+
+func (c *DiskCache) GetCacheType() stats.CacheType {
+	return c.cacheType
+}
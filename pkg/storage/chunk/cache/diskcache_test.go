@@ -0,0 +1,212 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// diskCacheValueSize is the size of the values for 4 entries to fit in a disk
+// cache of 1MB.
+const diskCacheValueSize = 240_000
+
+func newTestDiskCache(t *testing.T, dir, admission string) *DiskCache {
+	c, err := NewDiskCache("test", DiskCacheConfig{Directory: dir, MaxSizeMB: 1, Admission: admission}, nil, log.NewNopLogger(), "test")
+	require.NoError(t, err)
+	t.Cleanup(c.Stop)
+	return c
+}
+
+func diskCacheKeys(c *DiskCache) []diskCacheHash {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var hashes []diskCacheHash
+	for element := c.lru.Front(); element != nil; element = element.Next() {
+		hashes = append(hashes, element.Value.(*diskCacheEntry).hash)
+	}
+	return hashes
+}
+
+func storeDiskCacheEntries(t *testing.T, c Cache, keys ...string) {
+	for _, key := range keys {
+		require.NoError(t, c.Store(context.Background(), []string{key}, [][]byte{make([]byte, diskCacheValueSize)}))
+	}
+}
+
+func TestDiskCache(t *testing.T) {
+	ctx := context.Background()
+	c := newTestDiskCache(t, t.TempDir(), AdmissionLRU)
+
+	require.NoError(t, c.Store(ctx, []string{"a", "b"}, [][]byte{[]byte("1"), []byte("2")}))
+	require.NoError(t, c.Store(ctx, []string{"a"}, [][]byte{[]byte("3")}))
+	found, values, missing, err := c.Fetch(ctx, []string{"a", "b", "c"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b"}, found)
+	require.Equal(t, [][]byte{[]byte("3"), []byte("2")}, values)
+	require.Equal(t, []string{"c"}, missing)
+	require.Equal(t, 2.0, testutil.ToFloat64(c.entriesCurrent))
+
+	// Values larger than the cache are not stored.
+	require.NoError(t, c.Store(ctx, []string{"big"}, [][]byte{make([]byte, 2e6)}))
+	require.Equal(t, 1.0, testutil.ToFloat64(c.entriesRejected.WithLabelValues(tooBigReason)))
+}
+
+func TestDiskCacheLRUEviction(t *testing.T) {
+	ctx := context.Background()
+	c := newTestDiskCache(t, t.TempDir(), AdmissionLRU)
+
+	storeDiskCacheEntries(t, c, "a", "b", "c", "d")
+	_, _, missing, err := c.Fetch(ctx, []string{"a"})
+	require.NoError(t, err)
+	require.Empty(t, missing)
+
+	// b is the least recently used entry.
+	storeDiskCacheEntries(t, c, "e")
+	_, _, missing, err = c.Fetch(ctx, []string{"a", "b", "c", "d", "e"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"b"}, missing)
+	require.Equal(t, 1.0, testutil.ToFloat64(c.entriesEvicted.WithLabelValues(fullReason)))
+	require.LessOrEqual(t, c.currSizeBytes, c.maxSizeBytes)
+
+	_, err = os.Stat(c.entryPath(hashKey("b")))
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestDiskCacheTinyLFUAdmission(t *testing.T) {
+	ctx := context.Background()
+	c := newTestDiskCache(t, t.TempDir(), AdmissionTinyLFU)
+
+	storeDiskCacheEntries(t, c, "a", "b", "c", "d")
+	for i := 0; i < 2; i++ {
+		_, _, missing, err := c.Fetch(ctx, []string{"a", "b", "c", "d"})
+		require.NoError(t, err)
+		require.Empty(t, missing)
+	}
+
+	// A key requested once is not admitted in place of the entries requested
+	// twice.
+	_, _, _, err := c.Fetch(ctx, []string{"e"})
+	require.NoError(t, err)
+	storeDiskCacheEntries(t, c, "e")
+	_, _, missing, err := c.Fetch(ctx, []string{"e"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"e"}, missing)
+	require.Equal(t, 1.0, testutil.ToFloat64(c.entriesRejected.WithLabelValues(admissionReason)))
+
+	// Once it's requested more frequently, it's admitted in place of the
+	// least recently used entry.
+	_, _, _, err = c.Fetch(ctx, []string{"e"})
+	require.NoError(t, err)
+	storeDiskCacheEntries(t, c, "e")
+	_, _, missing, err = c.Fetch(ctx, []string{"a", "b", "c", "d", "e"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a"}, missing)
+}
+
+func TestDiskCacheChecksum(t *testing.T) {
+	ctx := context.Background()
+	c := newTestDiskCache(t, t.TempDir(), AdmissionLRU)
+
+	require.NoError(t, c.Store(ctx, []string{"a", "b"}, [][]byte{[]byte("value a"), []byte("value b")}))
+	path := c.entryPath(hashKey("a"))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	data[len(data)-1] ^= 0xff
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	found, _, missing, err := c.Fetch(ctx, []string{"a", "b"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"b"}, found)
+	require.Equal(t, []string{"a"}, missing)
+	require.Equal(t, 1.0, testutil.ToFloat64(c.entriesEvicted.WithLabelValues(corruptReason)))
+	_, err = os.Stat(path)
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestDiskCacheWarmRestart(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	c := newTestDiskCache(t, dir, AdmissionLRU)
+	storeDiskCacheEntries(t, c, "a", "b", "c")
+	_, _, _, err := c.Fetch(ctx, []string{"a"})
+	require.NoError(t, err)
+	order := diskCacheKeys(c)
+	c.Stop()
+
+	// The entries are restored in the same order.
+	c = newTestDiskCache(t, dir, AdmissionLRU)
+	require.Equal(t, order, diskCacheKeys(c))
+	found, values, _, err := c.Fetch(ctx, []string{"a", "b", "c"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b", "c"}, found)
+	require.Len(t, values[0], diskCacheValueSize)
+
+	// After a crash, the entries written after the index was persisted are
+	// the most recently used ones, and the entries removed since then and the
+	// interrupted writes are dropped.
+	storeDiskCacheEntries(t, c, "d")
+	require.NoError(t, c.writeIndex())
+	storeDiskCacheEntries(t, c, "e")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, diskCacheTmpDir, "entry-torn"), []byte("torn"), 0o600))
+	order = diskCacheKeys(c)
+	require.Equal(t, hashKey("e"), order[0])
+	require.Equal(t, 1.0, testutil.ToFloat64(c.entriesEvicted.WithLabelValues(fullReason)))
+
+	c = newTestDiskCache(t, dir, AdmissionLRU)
+	require.Equal(t, order, diskCacheKeys(c))
+	entries, err := os.ReadDir(filepath.Join(dir, diskCacheTmpDir))
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	// A corrupt index is ignored.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, diskCacheIndexFile), []byte("corrupt"), 0o600))
+	c = newTestDiskCache(t, dir, AdmissionLRU)
+	require.ElementsMatch(t, order, diskCacheKeys(c))
+}
+
+func TestDiskCacheTiered(t *testing.T) {
+	ctx := context.Background()
+	disk := newTestDiskCache(t, t.TempDir(), AdmissionLRU)
+	remote := NewMockCache()
+	tiered := NewTiered([]Cache{disk, remote})
+
+	require.NoError(t, remote.Store(ctx, []string{"a"}, [][]byte{[]byte("value")}))
+	for i := 0; i < 2; i++ {
+		found, _, _, err := tiered.Fetch(ctx, []string{"a"})
+		require.NoError(t, err)
+		require.Equal(t, []string{"a"}, found)
+	}
+	// The second fetch is served by the disk cache.
+	require.Equal(t, 1, remote.KeysRequested())
+
+	found, _, _, err := disk.Fetch(ctx, []string{"a"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a"}, found)
+}
+
+func TestFrequencySketch(t *testing.T) {
+	s := newFrequencySketch(100)
+	require.Len(t, s.rows[0], 128)
+
+	for i := 0; i < 20; i++ {
+		s.Increment(hashKey("hot"))
+	}
+	s.Increment(hashKey("cold"))
+	require.Equal(t, uint8(sketchMaxCounter), s.Estimate(hashKey("hot")))
+	require.Equal(t, uint8(1), s.Estimate(hashKey("cold")))
+	require.Equal(t, uint8(0), s.Estimate(hashKey("other")))
+
+	// The counters are halved periodically.
+	for i := 0; s.increments > 0 && i < s.resetAt; i++ {
+		s.Increment(hashKey(fmt.Sprint(i)))
+	}
+	require.Less(t, s.Estimate(hashKey("hot")), uint8(sketchMaxCounter))
+}
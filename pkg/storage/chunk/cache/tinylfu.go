@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+const (
+	sketchDepth      = 4
+	sketchMaxCounter = 15
+	// sketchResetFactor is the number of increments, relative to the width
+	// of the sketch, after which the counters are halved so the estimated
+	// frequencies follow the recent accesses.
+	sketchResetFactor = 10
+)
+
+// frequencySketch is the count-min sketch of the TinyLFU admission policy,
+// estimating the access frequency of the keys with 4-bit counters.
+type frequencySketch struct {
+	rows       [sketchDepth][]uint8
+	mask       uint64
+	increments int
+	resetAt    int
+}
+
+// newFrequencySketch returns a sketch with the width rounded up to a power
+// of two.
+func newFrequencySketch(width int) *frequencySketch {
+	if width < 1 {
+		width = 1
+	}
+	width = 1 << bits.Len(uint(width-1))
+
+	s := &frequencySketch{
+		mask:    uint64(width - 1),
+		resetAt: width * sketchResetFactor,
+	}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, width)
+	}
+	return s
+}
+
+// index returns the counter of the key hash in the row, using a different
+// part of the hash for each row.
+func (s *frequencySketch) index(hash diskCacheHash, row int) uint64 {
+	return binary.LittleEndian.Uint64(hash[row*8:]) & s.mask
+}
+
+// Increment records an access to the key hash.
+func (s *frequencySketch) Increment(hash diskCacheHash) {
+	for i := range s.rows {
+		idx := s.index(hash, i)
+		if s.rows[i][idx] < sketchMaxCounter {
+			s.rows[i][idx]++
+		}
+	}
+
+	s.increments++
+	if s.increments >= s.resetAt {
+		s.reset()
+	}
+}
+
+// Estimate returns the estimated access frequency of the key hash.
+func (s *frequencySketch) Estimate(hash diskCacheHash) uint8 {
+	estimate := uint8(sketchMaxCounter)
+	for i := range s.rows {
+		estimate = min(estimate, s.rows[i][s.index(hash, i)])
+	}
+	return estimate
+}
+
+func (s *frequencySketch) reset() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] >>= 1
+		}
+	}
+	s.increments /= 2
+}
@@ -290,13 +290,25 @@ func (c *Chunk) Encoded() ([]byte, error) {
 
 // DecodeContext holds data that can be re-used between decodes of different chunks
 type DecodeContext struct {
-	reader *snappy.Reader
+	reader  *snappy.Reader
+	newData func(Encoding) (Data, error)
 }
 
 // NewDecodeContext creates a new, blank, DecodeContext
 func NewDecodeContext() *DecodeContext {
 	return &DecodeContext{
-		reader: snappy.NewReader(nil),
+		reader:  snappy.NewReader(nil),
+		newData: NewForEncoding,
+	}
+}
+
+// WithData returns a DecodeContext sharing the buffers of this one, which
+// creates the data of the chunks it decodes with newData. It allows the data
+// to be given the dependencies it is decoded with.
+func (dc *DecodeContext) WithData(newData func(Encoding) (Data, error)) *DecodeContext {
+	return &DecodeContext{
+		reader:  dc.reader,
+		newData: newData,
 	}
 }
 
@@ -340,7 +352,7 @@ func (c *Chunk) Decode(decodeContext *DecodeContext, input []byte) error {
 	*c = tempMetadata
 
 	// Finally, unmarshal the actual chunk data.
-	c.Data, err = NewForEncoding(c.Encoding)
+	c.Data, err = decodeContext.newData(c.Encoding)
 	if err != nil {
 		return errors.Wrap(err, "when creating new chunk")
 	}
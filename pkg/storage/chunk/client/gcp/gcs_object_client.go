@@ -160,6 +160,21 @@ func (s *GCSObjectClient) getObject(ctx context.Context, objectKey string) (rc i
 	return reader, reader.Attrs.Size, nil
 }
 
+// GetObjectRange returns a reader for a byte range of the specified object key from the configured GCS bucket.
+func (s *GCSObjectClient) GetObjectRange(ctx context.Context, objectKey string, offset, length int64) (io.ReadCloser, error) {
+	var cancel context.CancelFunc = func() {}
+	if s.cfg.RequestTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.RequestTimeout)
+	}
+
+	reader, err := s.getsBuckets.Object(objectKey).NewRangeReader(ctx, offset, length)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return util.NewReadCloserWithContextCancelFunc(reader, cancel), nil
+}
+
 // PutObject puts the specified bytes into the configured GCS bucket at the provided key
 func (s *GCSObjectClient) PutObject(ctx context.Context, objectKey string, object io.ReadSeeker) error {
 	writer := s.defaultBucket.Object(objectKey).NewWriter(ctx)
@@ -3,6 +3,7 @@ package local
 import (
 	"bytes"
 	"context"
+	"io"
 	"os"
 	"path"
 	"path/filepath"
@@ -12,6 +13,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/loki/pkg/storage/chunk/client"
 	"github.com/grafana/loki/pkg/storage/chunk/client/util"
 )
 
@@ -203,3 +205,28 @@ func TestFSObjectClient_DeleteObject(t *testing.T) {
 	require.Len(t, commonPrefixes, 0)
 	require.Len(t, files, len(foldersWithFiles["folder2/"]))*/
 }
+
+func TestFSObjectClient_GetObjectRange(t *testing.T) {
+	bucketClient, err := NewFSObjectClient(FSConfig{
+		Directory: t.TempDir(),
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, bucketClient.PutObject(ctx, "outer/file", bytes.NewReader([]byte("0123456789"))))
+
+	rc, err := bucketClient.GetObjectRange(ctx, "outer/file", 3, 4)
+	require.NoError(t, err)
+	buf, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	require.NoError(t, rc.Close())
+	require.Equal(t, "3456", string(buf))
+
+	// The object clients without range reads skip the beginning of the object.
+	rc, err = client.GetObjectRange(ctx, struct{ client.ObjectClient }{bucketClient}, "outer/file", 3, 4)
+	require.NoError(t, err)
+	buf, err = io.ReadAll(rc)
+	require.NoError(t, err)
+	require.NoError(t, rc.Close())
+	require.Equal(t, "3456", string(buf))
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/pkg/errors"
 
+	"github.com/grafana/loki/pkg/chunkenc"
 	"github.com/grafana/loki/pkg/storage/chunk"
 	"github.com/grafana/loki/pkg/storage/chunk/client/util"
 	"github.com/grafana/loki/pkg/storage/config"
@@ -40,6 +41,43 @@ type ObjectClient interface {
 	Stop()
 }
 
+// RangeObjectClient is implemented by the object clients able to read a byte
+// range of an object, such as with HTTP range requests.
+type RangeObjectClient interface {
+	// NOTE: The consumer of GetObjectRange should always call the Close method when it is done reading.
+	GetObjectRange(ctx context.Context, objectKey string, offset, length int64) (io.ReadCloser, error)
+}
+
+// GetObjectRange reads length bytes of an object from offset, with a range read
+// if the object client supports it, or by skipping the beginning of the object
+// otherwise.
+func GetObjectRange(ctx context.Context, store ObjectClient, objectKey string, offset, length int64) (io.ReadCloser, error) {
+	if rangeStore, ok := store.(RangeObjectClient); ok {
+		return rangeStore.GetObjectRange(ctx, objectKey, offset, length)
+	}
+
+	readCloser, _, err := store.GetObject(ctx, objectKey)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.CopyN(io.Discard, readCloser, offset); err != nil {
+		readCloser.Close()
+		return nil, err
+	}
+	return NewLimitedReadCloser(readCloser, length), nil
+}
+
+type limitedReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// NewLimitedReadCloser returns a ReadCloser reading at most n bytes of
+// readCloser, and closing it.
+func NewLimitedReadCloser(readCloser io.ReadCloser, n int64) io.ReadCloser {
+	return limitedReadCloser{Reader: io.LimitReader(readCloser, n), Closer: readCloser}
+}
+
 // StorageObject represents an object being stored in an Object Store
 type StorageObject struct {
 	Key        string
@@ -84,6 +122,7 @@ type client struct {
 	keyEncoder          KeyEncoder
 	getChunkMaxParallel int
 	schema              config.SchemaConfig
+	dictionaries        *chunkenc.Dictionaries
 }
 
 // NewClient wraps the provided ObjectClient with a chunk.Client implementation
@@ -92,11 +131,18 @@ func NewClient(store ObjectClient, encoder KeyEncoder, schema config.SchemaConfi
 }
 
 func NewClientWithMaxParallel(store ObjectClient, encoder KeyEncoder, maxParallel int, schema config.SchemaConfig) Client {
+	return NewClientWithDictionaries(store, encoder, maxParallel, schema, nil)
+}
+
+// NewClientWithDictionaries is like NewClientWithMaxParallel, reading the
+// chunks compressed with zstd dictionaries with the given dictionaries.
+func NewClientWithDictionaries(store ObjectClient, encoder KeyEncoder, maxParallel int, schema config.SchemaConfig, dictionaries *chunkenc.Dictionaries) Client {
 	return &client{
 		store:               store,
 		keyEncoder:          encoder,
 		getChunkMaxParallel: maxParallel,
 		schema:              schema,
+		dictionaries:        dictionaries,
 	}
 }
 
@@ -184,6 +230,9 @@ func (o *client) getChunk(ctx context.Context, decodeContext *chunk.DecodeContex
 		return chunk.Chunk{}, errors.WithStack(err)
 	}
 
+	if o.dictionaries != nil {
+		decodeContext = decodeContext.WithData(o.dictionaries.NewData)
+	}
 	if err := c.Decode(decodeContext, buf.Bytes()); err != nil {
 		return chunk.Chunk{}, errors.WithStack(err)
 	}
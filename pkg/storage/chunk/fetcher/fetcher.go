@@ -12,6 +12,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/prometheus/promql"
 
+	"github.com/grafana/loki/pkg/chunkenc"
 	"github.com/grafana/loki/pkg/logqlmodel/stats"
 	"github.com/grafana/loki/pkg/storage/chunk"
 	"github.com/grafana/loki/pkg/storage/chunk/cache"
@@ -66,6 +67,10 @@ type Fetcher struct {
 
 	l2CacheHandoff time.Duration
 
+	// dictionaries are the zstd dictionaries the cached chunks are decoded
+	// with.
+	dictionaries *chunkenc.Dictionaries
+
 	wait           sync.WaitGroup
 	decodeRequests chan decodeRequest
 
@@ -89,13 +94,14 @@ type decodeResponse struct {
 }
 
 // New makes a new ChunkFetcher.
-func New(cache cache.Cache, cachel2 cache.Cache, cacheStubs bool, schema config.SchemaConfig, storage client.Client, maxAsyncConcurrency int, maxAsyncBufferSize int, l2CacheHandoff time.Duration) (*Fetcher, error) {
+func New(cache cache.Cache, cachel2 cache.Cache, cacheStubs bool, schema config.SchemaConfig, storage client.Client, maxAsyncConcurrency int, maxAsyncBufferSize int, l2CacheHandoff time.Duration, dictionaries *chunkenc.Dictionaries) (*Fetcher, error) {
 	c := &Fetcher{
 		schema:              schema,
 		storage:             storage,
 		cache:               cache,
 		cachel2:             cachel2,
 		l2CacheHandoff:      l2CacheHandoff,
+		dictionaries:        dictionaries,
 		cacheStubs:          cacheStubs,
 		decodeRequests:      make(chan decodeRequest),
 		maxAsyncConcurrency: maxAsyncConcurrency,
@@ -156,6 +162,9 @@ func (c *Fetcher) Stop() {
 func (c *Fetcher) worker() {
 	defer c.wait.Done()
 	decodeContext := chunk.NewDecodeContext()
+	if c.dictionaries != nil {
+		decodeContext = decodeContext.WithData(c.dictionaries.NewData)
+	}
 	for req := range c.decodeRequests {
 		err := req.chunk.Decode(decodeContext, req.buf)
 		if err != nil {
@@ -57,6 +57,7 @@ const (
 
 var (
 	errInvalidSchemaVersion     = errors.New("invalid schema version")
+	errSchemaV14RequiresTSDB    = errors.New("schema v14 requires the tsdb index type")
 	errInvalidTablePeriod       = errors.New("the table period must be a multiple of 24h (1h for schema v1)")
 	errInvalidTableName         = errors.New("invalid table name")
 	errConfigFileNotSet         = errors.New("schema config file needs to be set")
@@ -66,6 +67,7 @@ var (
 	errCurrentBoltdbShipperNon24Hours  = errors.New("boltdb-shipper works best with 24h periodic index config. Either add a new config with future date set to 24h to retain the existing index or change the existing config to use 24h period")
 	errUpcomingBoltdbShipperNon24Hours = errors.New("boltdb-shipper with future date must always have periodic config for index set to 24h")
 	errTSDBNon24HoursIndexPeriod       = errors.New("tsdb must always have periodic config for index set to 24h")
+	errColumnarChunksRequireV13        = errors.New("columnar_chunks requires schema v13 or greater")
 	errZeroLengthConfig                = errors.New("must specify at least one schema configuration")
 
 	// regexp for finding the trailing index table number at the end of the table name
@@ -165,6 +167,8 @@ type PeriodConfig struct {
 	IndexTables IndexPeriodicTableConfig `yaml:"index" doc:"description=Configures how the index is updated and stored."`
 	ChunkTables PeriodicTableConfig      `yaml:"chunks" doc:"description=Configured how the chunks are updated and stored."`
 	RowShards   uint32                   `yaml:"row_shards" doc:"default=16|description=How many shards will be created. Only used if schema is v10 or greater."`
+	// ColumnarChunks selects the chunk format V5 for the period.
+	ColumnarChunks bool `yaml:"columnar_chunks" doc:"description=Experimental. Write the chunks in the columnar format, which stores the timestamps, the lines and each structured metadata key of the entries of a block separately, so that queries not needing the lines don't decompress them. Earlier Loki versions can't read these chunks. Only used if schema is v13 or greater."`
 
 	// Integer representation of schema used for hot path calculation. Populated on unmarshaling.
 	schemaInt *int `yaml:"-"`
@@ -442,6 +446,8 @@ func (cfg *PeriodConfig) ChunkFormat() (byte, chunkenc.HeadBlockFmt, error) {
 	switch {
 	case sver <= 12:
 		return chunkenc.ChunkFormatV3, chunkenc.ChunkHeadFormatFor(chunkenc.ChunkFormatV3), nil
+	case cfg.ColumnarChunks:
+		return chunkenc.ChunkFormatV5, chunkenc.ChunkHeadFormatFor(chunkenc.ChunkFormatV5), nil
 	default: // for v13 and above
 		return chunkenc.ChunkFormatV4, chunkenc.ChunkHeadFormatFor(chunkenc.ChunkFormatV4), nil
 	}
@@ -458,8 +464,10 @@ func (cfg *PeriodConfig) TSDBFormat() (int, error) {
 	switch {
 	case sver <= 12:
 		return index.FormatV2, nil
-	default: // for v13 and above
+	case sver == 13:
 		return index.FormatV3, nil
+	default: // for v14 and above
+		return index.FormatV4, nil
 	}
 }
 
@@ -487,7 +495,16 @@ func (cfg PeriodConfig) validate() error {
 		return err
 	}
 
+	if cfg.ColumnarChunks && v < 13 {
+		return errColumnarChunksRequireV13
+	}
+
 	switch v {
+	case 14:
+		if cfg.IndexType != TSDBType {
+			return errSchemaV14RequiresTSDB
+		}
+		fallthrough
 	case 10, 11, 12, 13:
 		if cfg.RowShards == 0 {
 			return fmt.Errorf("must have row_shards > 0 (current: %d) for schema (%s)", cfg.RowShards, cfg.Schema)
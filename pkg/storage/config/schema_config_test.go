@@ -12,6 +12,7 @@ import (
 	"github.com/stretchr/testify/require"
 	yaml "gopkg.in/yaml.v2"
 
+	"github.com/grafana/loki/pkg/chunkenc"
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/storage/chunk"
 )
@@ -484,6 +485,60 @@ func TestPeriodConfig_Validate(t *testing.T) {
 				ChunkTables: PeriodicTableConfig{Period: 0},
 			},
 		},
+		{
+			desc: "v13 with columnar chunks",
+			in: PeriodConfig{
+				Schema:         "v13",
+				RowShards:      16,
+				ColumnarChunks: true,
+				IndexTables: IndexPeriodicTableConfig{
+					PathPrefix:          "index/",
+					PeriodicTableConfig: PeriodicTableConfig{Period: 0},
+				},
+				ChunkTables: PeriodicTableConfig{Period: 0},
+			},
+		},
+		{
+			desc: "v12 with columnar chunks",
+			in: PeriodConfig{
+				Schema:         "v12",
+				RowShards:      16,
+				ColumnarChunks: true,
+				IndexTables: IndexPeriodicTableConfig{
+					PathPrefix:          "index/",
+					PeriodicTableConfig: PeriodicTableConfig{Period: 0},
+				},
+				ChunkTables: PeriodicTableConfig{Period: 0},
+			},
+			err: "columnar_chunks requires schema v13 or greater",
+		},
+		{
+			desc: "v14",
+			in: PeriodConfig{
+				Schema:    "v14",
+				IndexType: TSDBType,
+				RowShards: 16,
+				IndexTables: IndexPeriodicTableConfig{
+					PathPrefix:          "index/",
+					PeriodicTableConfig: PeriodicTableConfig{Period: ObjectStorageIndexRequiredPeriod},
+				},
+				ChunkTables: PeriodicTableConfig{Period: 0},
+			},
+		},
+		{
+			desc: "error v14 without tsdb",
+			in: PeriodConfig{
+				Schema:    "v14",
+				IndexType: BoltDBShipperType,
+				RowShards: 16,
+				IndexTables: IndexPeriodicTableConfig{
+					PathPrefix:          "index/",
+					PeriodicTableConfig: PeriodicTableConfig{Period: ObjectStorageIndexRequiredPeriod},
+				},
+				ChunkTables: PeriodicTableConfig{Period: 0},
+			},
+			err: "schema v14 requires the tsdb index type",
+		},
 	} {
 		t.Run(tc.desc, func(t *testing.T) {
 			if tc.err == "" {
@@ -671,6 +726,27 @@ func TestVersionAsInt(t *testing.T) {
 	}
 }
 
+func TestPeriodConfig_ChunkFormat(t *testing.T) {
+	for _, tc := range []struct {
+		schema   string
+		columnar bool
+		expected byte
+	}{
+		{schema: "v11", expected: chunkenc.ChunkFormatV3},
+		{schema: "v12", expected: chunkenc.ChunkFormatV3},
+		{schema: "v13", expected: chunkenc.ChunkFormatV4},
+		{schema: "v13", columnar: true, expected: chunkenc.ChunkFormatV5},
+	} {
+		t.Run(fmt.Sprintf("%s columnar=%t", tc.schema, tc.columnar), func(t *testing.T) {
+			cfg := PeriodConfig{Schema: tc.schema, ColumnarChunks: tc.columnar}
+			format, headFormat, err := cfg.ChunkFormat()
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, format)
+			require.Equal(t, chunkenc.ChunkHeadFormatFor(tc.expected), headFormat)
+		})
+	}
+}
+
 func TestUnmarshalPeriodConfig(t *testing.T) {
 	input := `
 from: "2020-07-31"
@@ -25,6 +25,8 @@ type ChunkStoreConfig struct {
 
 	// When DisableIndexDeduplication is true and chunk is already there in cache, only index would be written to the store and not chunk.
 	DisableIndexDeduplication bool `yaml:"-"`
+
+	ContentDeduplication bool `yaml:"content_deduplication"`
 }
 
 func (cfg *ChunkStoreConfig) ChunkCacheStubs() bool {
@@ -39,6 +41,7 @@ func (cfg *ChunkStoreConfig) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&cfg.chunkCacheStubs, "store.chunks-cache.cache-stubs", false, "If true, don't write the full chunk to cache, just a stub entry.")
 	cfg.WriteDedupeCacheConfig.RegisterFlagsWithPrefix("store.index-cache-write.", "", f)
 
+	f.BoolVar(&cfg.ContentDeduplication, "store.content-deduplication", false, "Deduplicate the chunks flushed by the replicas of a stream by the hash of their entries, in addition to their checksum, so that chunks with the same entries over the same time range are written once even when their encoding differs. The hashes are kept in the chunk cache, which must be shared by the ingesters. Hashing the entries requires decompressing the chunks on flush.")
 	f.Var(&cfg.CacheLookupsOlderThan, "store.cache-lookups-older-than", "Cache index entries older than this period. 0 to disable.")
 }
 
@@ -0,0 +1,107 @@
+package dictionaries
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+
+	"github.com/grafana/loki/pkg/chunkenc"
+)
+
+// Limits are the per-tenant limits of the zstd dictionaries.
+type Limits interface {
+	ZstdDictionariesEnabled(userID string) bool
+}
+
+// Provider provides the latest dictionaries of the tenants, which new chunks
+// are compressed with. The latest versions are looked up in the background,
+// so getting the dictionary of a tenant never waits for object storage.
+type Provider struct {
+	store           *Store
+	dictionaries    *chunkenc.Dictionaries
+	limits          Limits
+	refreshInterval time.Duration
+	logger          log.Logger
+
+	mtx     sync.Mutex
+	tenants map[string]*tenantDictionary
+}
+
+type tenantDictionary struct {
+	dict       *chunkenc.Dictionary
+	refreshed  time.Time
+	refreshing bool
+}
+
+func NewProvider(store *Store, dictionaries *chunkenc.Dictionaries, limits Limits, refreshInterval time.Duration, logger log.Logger) *Provider {
+	return &Provider{
+		store:           store,
+		dictionaries:    dictionaries,
+		limits:          limits,
+		refreshInterval: refreshInterval,
+		logger:          logger,
+		tenants:         map[string]*tenantDictionary{},
+	}
+}
+
+// Dictionary returns the latest dictionary of the tenant, or nil if the
+// tenant doesn't use dictionaries or none was trained or looked up yet.
+func (p *Provider) Dictionary(tenant string) *chunkenc.Dictionary {
+	if !p.limits.ZstdDictionariesEnabled(tenant) {
+		return nil
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	td, ok := p.tenants[tenant]
+	if !ok {
+		td = &tenantDictionary{}
+		p.tenants[tenant] = td
+	}
+	if !td.refreshing && time.Since(td.refreshed) >= p.refreshInterval {
+		td.refreshing = true
+		go p.refresh(tenant, td, td.dict)
+	}
+	return td.dict
+}
+
+// Dictionaries returns the dictionaries the latest dictionaries of the
+// tenants are registered in.
+func (p *Provider) Dictionaries() *chunkenc.Dictionaries {
+	return p.dictionaries
+}
+
+func (p *Provider) refresh(tenant string, td *tenantDictionary, current *chunkenc.Dictionary) {
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+	dict, err := p.latest(ctx, tenant, current)
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	td.refreshing = false
+	td.refreshed = time.Now()
+	if err != nil {
+		// Keep using the previous version until the next refresh.
+		level.Warn(p.logger).Log("msg", "failed to look up the latest zstd dictionary", "tenant", tenant, "err", err)
+		return
+	}
+	td.dict = dict
+}
+
+func (p *Provider) latest(ctx context.Context, tenant string, current *chunkenc.Dictionary) (*chunkenc.Dictionary, error) {
+	v, ok, err := p.store.Latest(ctx, tenant)
+	if err != nil || !ok {
+		return nil, err
+	}
+	if current != nil && current.ID() == v.ID {
+		return current, nil
+	}
+	b, err := p.store.Get(ctx, v.ID)
+	if err != nil {
+		return nil, err
+	}
+	return p.dictionaries.Register(b)
+}
@@ -0,0 +1,194 @@
+package dictionaries
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/loki/pkg/chunkenc"
+	"github.com/grafana/loki/pkg/storage/chunk/client"
+)
+
+const (
+	// dictionariesPrefix is the prefix of the objects of the dictionaries.
+	dictionariesPrefix = "zstd-dictionaries/"
+	// versionsPrefix is the prefix of the objects of the versions of the
+	// dictionaries of the tenants.
+	versionsPrefix = dictionariesPrefix + "tenants/"
+
+	// fetchTimeout is the timeout of fetching a dictionary when reading a
+	// chunk compressed with it.
+	fetchTimeout = 30 * time.Second
+
+	// minID is the lowest dictionary ID not reserved by zstd.
+	minID = 1 << 15
+	// newIDAttempts is the number of random IDs tried to find one which isn't
+	// used by a stored dictionary.
+	newIDAttempts = 10
+)
+
+// ErrDictionaryExists is returned when storing a dictionary with the ID of a
+// stored dictionary, which chunks may be compressed with.
+var ErrDictionaryExists = errors.New("a zstd dictionary with the same ID is already stored")
+
+// Config configures the storage of the zstd dictionaries of the tenants.
+type Config struct {
+	Store           string        `yaml:"store"`
+	RefreshInterval time.Duration `yaml:"refresh_interval"`
+
+	// Dictionaries are the dictionaries the chunks are read with, set from
+	// the top-level Loki config.
+	Dictionaries *chunkenc.Dictionaries `yaml:"-"`
+}
+
+// RegisterFlagsWithPrefix registers flags.
+func (cfg *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.StringVar(&cfg.Store, prefix+"zstd-dictionaries.store", "", "Object store of the zstd dictionaries trained by the compactor for the tenants with zstd_dictionaries_enabled. Defaults to the object store of the active schema period.")
+	f.DurationVar(&cfg.RefreshInterval, prefix+"zstd-dictionaries.refresh-interval", 10*time.Minute, "How often the ingesters check whether a newer zstd dictionary was trained for a tenant.")
+}
+
+// Version is a version of the dictionary of a tenant.
+type Version struct {
+	ID      uint32    `json:"id"`
+	Trained time.Time `json:"trained"`
+}
+
+// Store stores the zstd dictionaries of the tenants in object storage.
+//
+// The dictionaries are stored by ID under zstd-dictionaries/<id>, so the
+// chunks referencing them can be read regardless of the tenant. The versions
+// of the dictionary of a tenant are empty objects named
+// zstd-dictionaries/tenants/<tenant>/<trained>-<id>, where trained is the time
+// the version was trained at in nanoseconds, and the latest version is the one
+// new chunks are compressed with.
+type Store struct {
+	client client.ObjectClient
+}
+
+func NewStore(client client.ObjectClient) *Store {
+	return &Store{client: client}
+}
+
+// ObjectClient returns the client of the object store of the dictionaries.
+func (s *Store) ObjectClient() client.ObjectClient {
+	return s.client
+}
+
+// NewID returns a random dictionary ID which isn't used by any stored
+// dictionary, across all the tenants.
+func (s *Store) NewID(ctx context.Context) (uint32, error) {
+	for i := 0; i < newIDAttempts; i++ {
+		id := uint32(minID + rand.Int63n(math.MaxInt32-minID))
+		exists, err := s.client.ObjectExists(ctx, dictionaryKey(id))
+		if err != nil {
+			return 0, fmt.Errorf("checking whether zstd dictionary %d exists: %w", id, err)
+		}
+		if !exists {
+			return id, nil
+		}
+	}
+	return 0, fmt.Errorf("no unused zstd dictionary ID found in %d attempts", newIDAttempts)
+}
+
+// Put stores a new version of the dictionary of the tenant. It fails with
+// ErrDictionaryExists rather than replacing a stored dictionary with the
+// same ID, as the chunks compressed with it couldn't be read anymore.
+func (s *Store) Put(ctx context.Context, tenant string, trained time.Time, dict *chunkenc.Dictionary) (Version, error) {
+	v := Version{ID: dict.ID(), Trained: trained}
+	exists, err := s.client.ObjectExists(ctx, dictionaryKey(v.ID))
+	if err != nil {
+		return Version{}, fmt.Errorf("checking whether zstd dictionary %d exists: %w", v.ID, err)
+	}
+	if exists {
+		return Version{}, fmt.Errorf("storing zstd dictionary %d: %w", v.ID, ErrDictionaryExists)
+	}
+	if err := s.client.PutObject(ctx, dictionaryKey(v.ID), bytes.NewReader(dict.Bytes())); err != nil {
+		return Version{}, fmt.Errorf("storing zstd dictionary %d: %w", v.ID, err)
+	}
+	if err := s.client.PutObject(ctx, versionKey(tenant, v), bytes.NewReader(nil)); err != nil {
+		return Version{}, fmt.Errorf("storing version of the zstd dictionary of tenant %s: %w", tenant, err)
+	}
+	return v, nil
+}
+
+// Get returns the dictionary with the given ID.
+func (s *Store) Get(ctx context.Context, id uint32) ([]byte, error) {
+	r, _, err := s.client.GetObject(ctx, dictionaryKey(id))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// Fetch is a chunkenc.DictionaryFetcher fetching the dictionaries from the
+// store.
+func (s *Store) Fetch(id uint32) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+	return s.Get(ctx, id)
+}
+
+// Versions returns the versions of the dictionary of the tenant, from the
+// oldest to the latest.
+func (s *Store) Versions(ctx context.Context, tenant string) ([]Version, error) {
+	objects, _, err := s.client.List(ctx, versionsPrefix+tenant+"/", "")
+	if err != nil {
+		return nil, fmt.Errorf("listing versions of the zstd dictionary of tenant %s: %w", tenant, err)
+	}
+	versions := make([]Version, 0, len(objects))
+	for _, o := range objects {
+		v, err := parseVersion(path.Base(o.Key))
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Trained.Before(versions[j].Trained)
+	})
+	return versions, nil
+}
+
+// Latest returns the latest version of the dictionary of the tenant, if any.
+func (s *Store) Latest(ctx context.Context, tenant string) (Version, bool, error) {
+	versions, err := s.Versions(ctx, tenant)
+	if err != nil || len(versions) == 0 {
+		return Version{}, false, err
+	}
+	return versions[len(versions)-1], true, nil
+}
+
+func dictionaryKey(id uint32) string {
+	return dictionariesPrefix + strconv.FormatUint(uint64(id), 10)
+}
+
+func versionKey(tenant string, v Version) string {
+	return fmt.Sprintf("%s%s/%d-%d", versionsPrefix, tenant, v.Trained.UnixNano(), v.ID)
+}
+
+func parseVersion(name string) (Version, error) {
+	trained, id, ok := strings.Cut(name, "-")
+	if !ok {
+		return Version{}, fmt.Errorf("invalid zstd dictionary version %q", name)
+	}
+	ns, err := strconv.ParseInt(trained, 10, 64)
+	if err != nil {
+		return Version{}, fmt.Errorf("invalid zstd dictionary version %q: %w", name, err)
+	}
+	n, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		return Version{}, fmt.Errorf("invalid zstd dictionary version %q: %w", name, err)
+	}
+	return Version{ID: uint32(n), Trained: time.Unix(0, ns)}, nil
+}
@@ -0,0 +1,102 @@
+package dictionaries
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/chunkenc"
+	"github.com/grafana/loki/pkg/chunkenc/testdata"
+	"github.com/grafana/loki/pkg/storage/chunk/client/testutils"
+	"github.com/grafana/loki/pkg/util/test"
+)
+
+func newTestDictionary(t *testing.T, id uint32) *chunkenc.Dictionary {
+	b, err := chunkenc.TrainDictionary(id, testdata.LogsBytes, 4<<10)
+	require.NoError(t, err)
+	dict, err := chunkenc.NewDictionary(b)
+	require.NoError(t, err)
+	return dict
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(testutils.NewInMemoryObjectClient())
+
+	_, ok, err := store.Latest(ctx, "tenant")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	first, second := newTestDictionary(t, 100000), newTestDictionary(t, 200000)
+	v1, err := store.Put(ctx, "tenant", time.Unix(0, 10), first)
+	require.NoError(t, err)
+	v2, err := store.Put(ctx, "tenant", time.Unix(0, 20), second)
+	require.NoError(t, err)
+	// A dictionary with the ID of a stored one doesn't replace it, whatever
+	// the tenant.
+	_, err = store.Put(ctx, "other", time.Unix(0, 30), newTestDictionary(t, 100000))
+	require.ErrorIs(t, err, ErrDictionaryExists)
+	_, ok, err = store.Latest(ctx, "other")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	versions, err := store.Versions(ctx, "tenant")
+	require.NoError(t, err)
+	require.Equal(t, []Version{v1, v2}, versions)
+
+	latest, ok, err := store.Latest(ctx, "tenant")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, Version{ID: 200000, Trained: time.Unix(0, 20)}, latest)
+
+	b, err := store.Fetch(200000)
+	require.NoError(t, err)
+	require.Equal(t, second.Bytes(), b)
+	b, err = store.Fetch(100000)
+	require.NoError(t, err)
+	require.Equal(t, first.Bytes(), b)
+
+	id, err := store.NewID(ctx)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, id, uint32(minID))
+	require.NotContains(t, []uint32{100000, 200000}, id)
+}
+
+type enabledLimits map[string]bool
+
+func (l enabledLimits) ZstdDictionariesEnabled(userID string) bool {
+	return l[userID]
+}
+
+func TestProvider(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(testutils.NewInMemoryObjectClient())
+	p := NewProvider(store, chunkenc.NewDictionaries(nil), enabledLimits{"enabled": true, "untrained": true}, 0, log.NewNopLogger())
+
+	_, err := store.Put(ctx, "enabled", time.Unix(0, 10), newTestDictionary(t, 300000))
+	require.NoError(t, err)
+	_, err = store.Put(ctx, "disabled", time.Unix(0, 10), newTestDictionary(t, 300001))
+	require.NoError(t, err)
+
+	require.Nil(t, p.Dictionary("disabled"))
+	test.Poll(t, 5*time.Second, uint32(300000), func() interface{} {
+		if dict := p.Dictionary("enabled"); dict != nil {
+			return dict.ID()
+		}
+		return uint32(0)
+	})
+
+	// A newer version is picked up when the dictionaries are refreshed.
+	_, err = store.Put(ctx, "enabled", time.Unix(0, 20), newTestDictionary(t, 300002))
+	require.NoError(t, err)
+	test.Poll(t, 5*time.Second, uint32(300002), func() interface{} {
+		return p.Dictionary("enabled").ID()
+	})
+
+	for i := 0; i < 3; i++ {
+		require.Nil(t, p.Dictionary("untrained"))
+	}
+}
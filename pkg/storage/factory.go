@@ -30,6 +30,8 @@ import (
 	"github.com/grafana/loki/pkg/storage/chunk/client/openstack"
 	"github.com/grafana/loki/pkg/storage/chunk/client/testutils"
 	"github.com/grafana/loki/pkg/storage/config"
+	"github.com/grafana/loki/pkg/storage/dictionaries"
+	"github.com/grafana/loki/pkg/storage/packs"
 	"github.com/grafana/loki/pkg/storage/stores"
 	"github.com/grafana/loki/pkg/storage/stores/series/index"
 	bloomshipperconfig "github.com/grafana/loki/pkg/storage/stores/shipper/bloomshipper/config"
@@ -38,6 +40,7 @@ import (
 	"github.com/grafana/loki/pkg/storage/stores/shipper/indexshipper/downloads"
 	"github.com/grafana/loki/pkg/storage/stores/shipper/indexshipper/gatewayclient"
 	"github.com/grafana/loki/pkg/storage/stores/shipper/indexshipper/indexgateway"
+	"github.com/grafana/loki/pkg/storage/tiering"
 	"github.com/grafana/loki/pkg/util"
 	"github.com/grafana/loki/pkg/util/constants"
 )
@@ -114,6 +117,7 @@ type StoreLimits interface {
 	stores.StoreLimits
 	indexgateway.Limits
 	CardinalityLimit(string) int
+	TSDBStructuredMetadataIndexKeys(string) []string
 }
 
 // Storage configs defined as Named stores don't get any defaults as they do not
@@ -338,6 +342,10 @@ type Config struct {
 	TSDBShipperConfig   indexshipper.Config       `yaml:"tsdb_shipper" doc:"description=Configures storing index in an Object Store (GCS/S3/Azure/Swift/COS/Filesystem) in a prometheus TSDB-like format. Required fields only required when TSDB is defined in config."`
 	BloomShipperConfig  bloomshipperconfig.Config `yaml:"bloom_shipper" doc:"description=Configures Bloom Shipper."`
 
+	ZstdDictionaries dictionaries.Config `yaml:"zstd_dictionaries" doc:"description=Configures the storage of the zstd dictionaries trained by the compactor for the tenants with zstd_dictionaries_enabled."`
+	ColdStorage      tiering.Config      `yaml:"cold_storage" doc:"description=Configures the cold object store the compactor relocates the chunks of the tenants with cold_storage_after to."`
+	ChunkPacks       packs.Config        `yaml:"chunk_packs" doc:"description=Configures the reads of the small chunks the compactor packs into pack objects."`
+
 	// Config for using AsyncStore when using async index stores like `boltdb-shipper`.
 	// It is required for getting chunk ids of recently flushed chunks from the ingesters.
 	EnableAsyncStore bool          `yaml:"-"`
@@ -369,6 +377,9 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&cfg.MaxChunkBatchSize, "store.max-chunk-batch-size", 50, "The maximum number of chunks to fetch per batch.")
 	cfg.TSDBShipperConfig.RegisterFlagsWithPrefix("tsdb.", f)
 	cfg.BloomShipperConfig.RegisterFlagsWithPrefix("bloom.", f)
+	cfg.ZstdDictionaries.RegisterFlagsWithPrefix("store.", f)
+	cfg.ColdStorage.RegisterFlagsWithPrefix("store.", f)
+	cfg.ChunkPacks.RegisterFlagsWithPrefix("store.", f)
 }
 
 // Validate config and returns error on failure
@@ -511,7 +522,7 @@ func NewChunkClient(name string, cfg Config, schemaCfg config.SchemaConfig, cc c
 			if err != nil {
 				return nil, err
 			}
-			return client.NewClientWithMaxParallel(c, nil, 1, schemaCfg), nil
+			return client.NewClientWithDictionaries(c, nil, 1, schemaCfg, cfg.ZstdDictionaries.Dictionaries), nil
 		}
 
 	case util.StringsContain(supportedStorageTypes, storeType):
@@ -521,14 +532,14 @@ func NewChunkClient(name string, cfg Config, schemaCfg config.SchemaConfig, cc c
 			if err != nil {
 				return nil, err
 			}
-			return client.NewClientWithMaxParallel(c, client.FSEncoder, cfg.MaxParallelGetChunk, schemaCfg), nil
+			return client.NewClientWithDictionaries(c, client.FSEncoder, cfg.MaxParallelGetChunk, schemaCfg, cfg.ZstdDictionaries.Dictionaries), nil
 
 		case config.StorageTypeAWS, config.StorageTypeS3, config.StorageTypeAzure, config.StorageTypeBOS, config.StorageTypeSwift, config.StorageTypeCOS, config.StorageTypeAlibabaCloud:
 			c, err := NewObjectClient(name, cfg, clientMetrics)
 			if err != nil {
 				return nil, err
 			}
-			return client.NewClientWithMaxParallel(c, nil, cfg.MaxParallelGetChunk, schemaCfg), nil
+			return client.NewClientWithDictionaries(c, nil, cfg.MaxParallelGetChunk, schemaCfg, cfg.ZstdDictionaries.Dictionaries), nil
 
 		case config.StorageTypeGCS:
 			c, err := NewObjectClient(name, cfg, clientMetrics)
@@ -540,7 +551,7 @@ func NewChunkClient(name string, cfg Config, schemaCfg config.SchemaConfig, cc c
 			if cfg.CongestionControl.Enabled {
 				c = cc.Wrap(c)
 			}
-			return client.NewClientWithMaxParallel(c, nil, cfg.MaxParallelGetChunk, schemaCfg), nil
+			return client.NewClientWithDictionaries(c, nil, cfg.MaxParallelGetChunk, schemaCfg, cfg.ZstdDictionaries.Dictionaries), nil
 		}
 
 	case util.StringsContain(deprecatedStorageTypes, storeType):
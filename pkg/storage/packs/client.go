@@ -0,0 +1,407 @@
+package packs
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grafana/dskit/concurrency"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/loki/pkg/chunkenc"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/storage/chunk/client"
+	"github.com/grafana/loki/pkg/storage/config"
+	"github.com/grafana/loki/pkg/util/constants"
+	"github.com/grafana/loki/pkg/util/flagext"
+)
+
+const (
+	DefaultMaxRangeGap  = 64 << 10
+	DefaultMaxRangeSize = 16 << 20
+)
+
+// Config configures the reads of the chunks the compactor packs into pack
+// objects.
+type Config struct {
+	Enabled       bool             `yaml:"enabled"`
+	IndexCacheTTL time.Duration    `yaml:"index_cache_ttl"`
+	MaxRangeGap   flagext.ByteSize `yaml:"max_range_gap"`
+	MaxRangeSize  flagext.ByteSize `yaml:"max_range_size"`
+}
+
+// RegisterFlagsWithPrefix registers flags.
+func (cfg *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, prefix+"chunk-packs.enabled", false, "Experimental. Read the chunks the compactor packs into pack objects with range requests. Has to be enabled in all the components reading chunks when the compactor packs chunks.")
+	f.DurationVar(&cfg.IndexCacheTTL, prefix+"chunk-packs.index-cache-ttl", 5*time.Minute, "How long the pack indexes of the tenants in the tables are cached for. The pack index is fetched again when a chunk is not found, since it can have been packed in the meantime.")
+	cfg.MaxRangeGap = DefaultMaxRangeGap
+	f.Var(&cfg.MaxRangeGap, prefix+"chunk-packs.max-range-gap", "Maximum gap between the chunks of a pack read with a single range request.")
+	cfg.MaxRangeSize = DefaultMaxRangeSize
+	f.Var(&cfg.MaxRangeSize, prefix+"chunk-packs.max-range-size", "Maximum size of a range request reading several chunks of a pack.")
+}
+
+type clientMetrics struct {
+	rangeRequests *prometheus.CounterVec
+	fetchedChunks prometheus.Counter
+	fetchedBytes  prometheus.Counter
+}
+
+func newClientMetrics(r prometheus.Registerer) *clientMetrics {
+	return &clientMetrics{
+		rangeRequests: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: constants.Loki,
+			Name:      "chunk_packs_range_requests_total",
+			Help:      "Total range requests reading chunks from pack objects.",
+		}, []string{"status"}),
+		fetchedChunks: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: constants.Loki,
+			Name:      "chunk_packs_fetched_chunks_total",
+			Help:      "Total chunks fetched from pack objects.",
+		}),
+		fetchedBytes: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: constants.Loki,
+			Name:      "chunk_packs_fetched_bytes_total",
+			Help:      "Total bytes read from pack objects, including the gaps between the chunks read with a single range request.",
+		}),
+	}
+}
+
+// Client is a chunk client reading the chunks of the tenants from the pack
+// objects they were packed into, and the other ones from their own objects.
+//
+// Packed chunks are located with the pack indexes, and the chunks close to
+// each other in a pack are read with a single range request. The chunks that
+// are not found are located again with fresh pack indexes, since the
+// compactor deletes their own objects once they are packed.
+type Client struct {
+	chunks       client.Client
+	objects      client.ObjectClient
+	indexes      *Indexes
+	schemaCfg    config.SchemaConfig
+	cfg          Config
+	maxParallel  int
+	dictionaries *chunkenc.Dictionaries
+	metrics      *clientMetrics
+}
+
+func NewClient(chunks client.Client, objects client.ObjectClient, schemaCfg config.SchemaConfig, cfg Config, maxParallel int, dictionaries *chunkenc.Dictionaries, r prometheus.Registerer) *Client {
+	return &Client{
+		chunks:       chunks,
+		objects:      objects,
+		indexes:      NewIndexes(objects, cfg.IndexCacheTTL),
+		schemaCfg:    schemaCfg,
+		cfg:          cfg,
+		maxParallel:  maxParallel,
+		dictionaries: dictionaries,
+		metrics:      newClientMetrics(r),
+	}
+}
+
+// TableFor returns the index table the chunk belongs to for packing, which is
+// the one its start time falls in.
+func TableFor(schemaCfg config.SchemaConfig, chk chunk.Chunk) (string, error) {
+	period, err := schemaCfg.SchemaForTime(chk.From)
+	if err != nil {
+		return "", err
+	}
+	return period.IndexTables.TableFor(chk.From), nil
+}
+
+type packedChunk struct {
+	chk chunk.Chunk
+	loc Location
+}
+
+// locate splits the chunks between the packed ones, grouped by pack object,
+// and the other ones.
+func (c *Client) locate(ctx context.Context, chunks []chunk.Chunk, refresh bool) (map[string][]packedChunk, []chunk.Chunk, error) {
+	packed := map[string][]packedChunk{}
+	var unpacked []chunk.Chunk
+	indexes := map[string]*Index{}
+	for _, chk := range chunks {
+		table, err := TableFor(c.schemaCfg, chk)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		key := table + "/" + chk.UserID
+		idx, ok := indexes[key]
+		if !ok {
+			if refresh {
+				idx, err = c.indexes.Refresh(ctx, table, chk.UserID)
+			} else {
+				idx, err = c.indexes.Get(ctx, table, chk.UserID)
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			indexes[key] = idx
+		}
+
+		loc, ok := idx.Locate(c.schemaCfg.ExternalKey(chk.ChunkRef))
+		if !ok {
+			unpacked = append(unpacked, chk)
+			continue
+		}
+		packKey := PackKey(table, chk.UserID, loc.Pack)
+		packed[packKey] = append(packed[packKey], packedChunk{chk: chk, loc: loc})
+	}
+	return packed, unpacked, nil
+}
+
+func (c *Client) Stop() {
+	c.chunks.Stop()
+}
+
+// PutChunks stores the chunks in their own objects, the compactor packs them
+// later.
+func (c *Client) PutChunks(ctx context.Context, chunks []chunk.Chunk) error {
+	return c.chunks.PutChunks(ctx, chunks)
+}
+
+func (c *Client) GetChunks(ctx context.Context, chunks []chunk.Chunk) ([]chunk.Chunk, error) {
+	packed, unpacked, err := c.locate(ctx, chunks, false)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]chunk.Chunk, 0, len(chunks))
+	var notFoundErr error
+	if len(unpacked) > 0 {
+		fetched, err := c.chunks.GetChunks(ctx, unpacked)
+		if err != nil && !c.chunks.IsChunkNotFoundErr(err) {
+			return nil, err
+		}
+		result = append(result, fetched...)
+
+		if err != nil {
+			// The missing chunks can have been packed since the pack indexes
+			// were cached.
+			missing := c.missing(unpacked, fetched)
+			repacked, stillMissing, refreshErr := c.locate(ctx, missing, true)
+			if refreshErr != nil {
+				return nil, refreshErr
+			}
+			for key, chunks := range repacked {
+				packed[key] = append(packed[key], chunks...)
+			}
+			if len(stillMissing) > 0 {
+				// Report the missing chunks like the chunk client of the
+				// object store would.
+				notFoundErr = err
+			}
+		}
+	}
+
+	fetched, err := c.fetchPacked(ctx, packed)
+	if err != nil {
+		return nil, err
+	}
+	return append(result, fetched...), notFoundErr
+}
+
+// byteRange is a range of a pack object read with a single request.
+type byteRange struct {
+	pack         string
+	offset, end  uint64
+	packedChunks []packedChunk
+}
+
+// ranges coalesces the chunks of the packs into ranges, merging the chunks
+// separated by at most the max range gap while the ranges don't exceed the max
+// range size.
+func (c *Client) ranges(packed map[string][]packedChunk) []byteRange {
+	var ranges []byteRange
+	for pack, chunks := range packed {
+		sort.Slice(chunks, func(i, j int) bool { return chunks[i].loc.Offset < chunks[j].loc.Offset })
+
+		var current *byteRange
+		for _, pc := range chunks {
+			end := pc.loc.Offset + pc.loc.Length
+			if current != nil && pc.loc.Offset <= current.end+uint64(c.cfg.MaxRangeGap) && end-current.offset <= uint64(c.cfg.MaxRangeSize) {
+				current.end = max(current.end, end)
+				current.packedChunks = append(current.packedChunks, pc)
+				continue
+			}
+			ranges = append(ranges, byteRange{pack: pack, offset: pc.loc.Offset, end: end, packedChunks: []packedChunk{pc}})
+			current = &ranges[len(ranges)-1]
+		}
+	}
+	return ranges
+}
+
+func (c *Client) fetchPacked(ctx context.Context, packed map[string][]packedChunk) ([]chunk.Chunk, error) {
+	if len(packed) == 0 {
+		return nil, nil
+	}
+
+	var (
+		mtx    sync.Mutex
+		result []chunk.Chunk
+	)
+	ranges := c.ranges(packed)
+	err := concurrency.ForEachJob(ctx, len(ranges), c.maxParallel, func(ctx context.Context, i int) error {
+		chunks, err := c.fetchRange(ctx, ranges[i])
+		if err != nil {
+			return err
+		}
+		mtx.Lock()
+		result = append(result, chunks...)
+		mtx.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) fetchRange(ctx context.Context, r byteRange) ([]chunk.Chunk, error) {
+	buf, err := c.readRange(ctx, r)
+	if err != nil {
+		c.metrics.rangeRequests.WithLabelValues("failure").Inc()
+		return nil, err
+	}
+	c.metrics.rangeRequests.WithLabelValues("success").Inc()
+	c.metrics.fetchedBytes.Add(float64(len(buf)))
+
+	decodeContext := chunk.NewDecodeContext()
+	if c.dictionaries != nil {
+		decodeContext = decodeContext.WithData(c.dictionaries.NewData)
+	}
+	chunks := make([]chunk.Chunk, 0, len(r.packedChunks))
+	for _, pc := range r.packedChunks {
+		start := pc.loc.Offset - r.offset
+		// Copy the chunk, so it doesn't retain the whole range.
+		data := make([]byte, pc.loc.Length)
+		copy(data, buf[start:start+pc.loc.Length])
+
+		chk := pc.chk
+		if err := chk.Decode(decodeContext, data); err != nil {
+			return nil, errors.Wrapf(err, "decoding chunk '%s' from pack '%s'", c.schemaCfg.ExternalKey(chk.ChunkRef), r.pack)
+		}
+		chunks = append(chunks, chk)
+	}
+	c.metrics.fetchedChunks.Add(float64(len(chunks)))
+	return chunks, nil
+}
+
+func (c *Client) readRange(ctx context.Context, r byteRange) ([]byte, error) {
+	length := int64(r.end - r.offset)
+	rc, err := client.GetObjectRange(ctx, c.objects, r.pack, int64(r.offset), length)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load pack '%s'", r.pack)
+	}
+	defer rc.Close()
+
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(rc, buf); err != nil {
+		return nil, errors.Wrapf(err, "reading pack '%s'", r.pack)
+	}
+	return buf, nil
+}
+
+func (c *Client) missing(requested, fetched []chunk.Chunk) []chunk.Chunk {
+	found := make(map[string]struct{}, len(fetched))
+	for _, chk := range fetched {
+		found[c.schemaCfg.ExternalKey(chk.ChunkRef)] = struct{}{}
+	}
+
+	var missing []chunk.Chunk
+	for _, chk := range requested {
+		if _, ok := found[c.schemaCfg.ExternalKey(chk.ChunkRef)]; !ok {
+			missing = append(missing, chk)
+		}
+	}
+	return missing
+}
+
+// DeleteChunk deletes the own object of the chunk. The packs are deleted by
+// the compactor once none of their chunks are indexed anymore.
+func (c *Client) DeleteChunk(ctx context.Context, userID, chunkID string) error {
+	return c.chunks.DeleteChunk(ctx, userID, chunkID)
+}
+
+func (c *Client) IsChunkNotFoundErr(err error) bool {
+	return c.chunks.IsChunkNotFoundErr(err) || c.objects.IsObjectNotFoundErr(errors.Cause(err))
+}
+
+func (c *Client) IsRetryableErr(err error) bool {
+	return c.chunks.IsRetryableErr(err) || c.objects.IsRetryableErr(errors.Cause(err))
+}
+
+// Indexes returns the pack indexes of the client.
+func (c *Client) Indexes() *Indexes {
+	return c.indexes
+}
+
+// WritePack packs the chunks of the tenant in the table into a new pack
+// object, and returns it. The pack has to be added to the pack index of the
+// tenant before the own objects of the chunks are deleted with DeleteChunks.
+func (c *Client) WritePack(ctx context.Context, table, tenant string, chunks []chunk.Chunk) (Pack, error) {
+	keys := make([]string, 0, len(chunks))
+	bufs := make([][]byte, 0, len(chunks))
+	for _, chk := range chunks {
+		buf, err := chk.Encoded()
+		if err != nil {
+			return Pack{}, err
+		}
+		keys = append(keys, c.schemaCfg.ExternalKey(chk.ChunkRef))
+		bufs = append(bufs, buf)
+	}
+
+	name := ulid.MustNew(ulid.Now(), rand.New(rand.NewSource(time.Now().UnixNano()))).String()
+	object, packed := EncodePack(keys, bufs)
+	if err := c.objects.PutObject(ctx, PackKey(table, tenant, name), bytes.NewReader(object)); err != nil {
+		return Pack{}, fmt.Errorf("storing pack %s of tenant %s in table %s: %w", name, tenant, table, err)
+	}
+	return Pack{Name: name, Chunks: packed}, nil
+}
+
+// ReadPack reads the offset table of a pack object of the tenant in the table.
+func (c *Client) ReadPack(ctx context.Context, table, tenant, name string) (Pack, error) {
+	rc, _, err := c.objects.GetObject(ctx, PackKey(table, tenant, name))
+	if err != nil {
+		return Pack{}, fmt.Errorf("getting pack %s of tenant %s in table %s: %w", name, tenant, table, err)
+	}
+	defer rc.Close()
+
+	buf, err := io.ReadAll(rc)
+	if err != nil {
+		return Pack{}, fmt.Errorf("reading pack %s of tenant %s in table %s: %w", name, tenant, table, err)
+	}
+	chunks, err := DecodePackTable(buf)
+	if err != nil {
+		return Pack{}, fmt.Errorf("pack %s of tenant %s in table %s: %w", name, tenant, table, err)
+	}
+	return Pack{Name: name, Chunks: chunks}, nil
+}
+
+// DeletePack deletes a pack object of the tenant in the table.
+func (c *Client) DeletePack(ctx context.Context, table, tenant, name string) error {
+	err := c.objects.DeleteObject(ctx, PackKey(table, tenant, name))
+	if err != nil && !c.objects.IsObjectNotFoundErr(err) {
+		return fmt.Errorf("deleting pack %s of tenant %s in table %s: %w", name, tenant, table, err)
+	}
+	return nil
+}
+
+// DeleteChunks deletes the own objects of the packed chunks.
+func (c *Client) DeleteChunks(ctx context.Context, chunks []chunk.Chunk) error {
+	for _, chk := range chunks {
+		err := c.chunks.DeleteChunk(ctx, chk.UserID, c.schemaCfg.ExternalKey(chk.ChunkRef))
+		if err != nil && !c.chunks.IsChunkNotFoundErr(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package packs
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/chunkenc"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/storage/chunk/client"
+	"github.com/grafana/loki/pkg/storage/chunk/client/local"
+	"github.com/grafana/loki/pkg/storage/config"
+	"github.com/grafana/loki/pkg/util/flagext"
+)
+
+var schemaCfg = config.SchemaConfig{Configs: []config.PeriodConfig{{
+	From:       config.DayTime{Time: 0},
+	IndexType:  config.TSDBType,
+	ObjectType: "filesystem",
+	Schema:     "v13",
+	IndexTables: config.IndexPeriodicTableConfig{
+		PeriodicTableConfig: config.PeriodicTableConfig{Prefix: "index_", Period: config.ObjectStorageIndexRequiredPeriod},
+	},
+}}}
+
+var day = model.Time(config.ObjectStorageIndexRequiredPeriod.Milliseconds())
+
+func newFSClients(t *testing.T) (client.ObjectClient, client.Client) {
+	objectClient, err := local.NewFSObjectClient(local.FSConfig{Directory: t.TempDir()})
+	require.NoError(t, err)
+	return objectClient, client.NewClient(objectClient, client.FSEncoder, schemaCfg)
+}
+
+func newTestClient(t *testing.T, objects client.ObjectClient, chunks client.Client, maxRangeGap int) *Client {
+	cfg := Config{Enabled: true, IndexCacheTTL: time.Hour, MaxRangeGap: flagext.ByteSize(maxRangeGap), MaxRangeSize: DefaultMaxRangeSize}
+	return NewClient(chunks, objects, schemaCfg, cfg, 10, nil, prometheus.NewRegistry())
+}
+
+func newChunk(t *testing.T, app string, from model.Time) chunk.Chunk {
+	lbs := labels.FromStrings("app", app)
+	c := chunkenc.NewMemChunk(chunkenc.ChunkFormatV4, chunkenc.EncSnappy, chunkenc.UnorderedWithStructuredMetadataHeadBlockFmt, 256<<10, 0)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, c.Append(&logproto.Entry{
+			Timestamp: from.Add(time.Duration(i) * time.Second).Time(),
+			Line:      fmt.Sprint(app, i),
+		}))
+	}
+	require.NoError(t, c.Close())
+	chkFrom, chkThrough := c.Bounds()
+	chk := chunk.NewChunk("user", model.Fingerprint(lbs.Hash()), lbs, chunkenc.NewFacade(c, 0, 0), model.TimeFromUnixNano(chkFrom.UnixNano()), model.TimeFromUnixNano(chkThrough.UnixNano()))
+	require.NoError(t, chk.Encode())
+	return chk
+}
+
+func requireChunks(t *testing.T, expected, actual []chunk.Chunk) {
+	t.Helper()
+	keys := func(chunks []chunk.Chunk) []string {
+		var keys []string
+		for _, chk := range chunks {
+			keys = append(keys, schemaCfg.ExternalKey(chk.ChunkRef))
+		}
+		return keys
+	}
+	require.ElementsMatch(t, keys(expected), keys(actual))
+	for _, chk := range actual {
+		require.NotNil(t, chk.Data)
+	}
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	objects, chunks := newFSClients(t)
+	c := newTestClient(t, objects, chunks, DefaultMaxRangeGap)
+
+	toPack := []chunk.Chunk{newChunk(t, "a", day), newChunk(t, "b", day), newChunk(t, "c", day)}
+	unpacked := newChunk(t, "d", day)
+	all := append(append([]chunk.Chunk{}, toPack...), unpacked)
+	require.NoError(t, c.PutChunks(ctx, all))
+
+	fetched, err := c.GetChunks(ctx, all)
+	require.NoError(t, err)
+	requireChunks(t, all, fetched)
+
+	// The chunks are packed while the client cached the empty pack index, so
+	// it fetches it again once it doesn't find them.
+	packer := newTestClient(t, objects, chunks, DefaultMaxRangeGap)
+	table, err := TableFor(schemaCfg, toPack[0])
+	require.NoError(t, err)
+	pack, err := packer.WritePack(ctx, table, "user", toPack)
+	require.NoError(t, err)
+	idx, err := packer.Indexes().Load(ctx, table, "user")
+	require.NoError(t, err)
+	idx.Add(pack)
+	require.NoError(t, packer.Indexes().Put(ctx, table, "user", idx))
+	require.NoError(t, packer.DeleteChunks(ctx, toPack))
+
+	fetched, err = c.GetChunks(ctx, all)
+	require.NoError(t, err)
+	requireChunks(t, all, fetched)
+	require.Equal(t, 1.0, testutil.ToFloat64(c.metrics.rangeRequests.WithLabelValues("success")))
+	require.Equal(t, 3.0, testutil.ToFloat64(c.metrics.fetchedChunks))
+
+	// The chunks separated by more than the max range gap are read with
+	// separate range requests.
+	noGap := newTestClient(t, objects, chunks, 0)
+	fetched, err = noGap.GetChunks(ctx, []chunk.Chunk{toPack[0], toPack[2]})
+	require.NoError(t, err)
+	requireChunks(t, []chunk.Chunk{toPack[0], toPack[2]}, fetched)
+	require.Equal(t, 2.0, testutil.ToFloat64(noGap.metrics.rangeRequests.WithLabelValues("success")))
+
+	// The chunks that are neither packed nor stored are reported as not found.
+	missing := newChunk(t, "e", day)
+	fetched, err = c.GetChunks(ctx, []chunk.Chunk{toPack[0], missing})
+	require.Error(t, err)
+	require.True(t, c.IsChunkNotFoundErr(err))
+	requireChunks(t, []chunk.Chunk{toPack[0]}, fetched)
+}
+
+func TestIndexEncoding(t *testing.T) {
+	object, packed := EncodePack([]string{"a", "b"}, [][]byte{[]byte("first"), []byte("second")})
+	require.Equal(t, []PackedChunk{{Key: "a", Offset: 0, Length: 5}, {Key: "b", Offset: 5, Length: 6}}, packed)
+	decoded, err := DecodePackTable(object)
+	require.NoError(t, err)
+	require.Equal(t, packed, decoded)
+
+	object[len(object)-packFooterSize-1] ^= 0xff
+	_, err = DecodePackTable(object)
+	require.Error(t, err)
+
+	idx := NewIndex([]Pack{{Name: "pack1", Chunks: packed}})
+	idx.Add(Pack{Name: "pack2", Unreferenced: 1000, Chunks: []PackedChunk{{Key: "c", Offset: 0, Length: 10}}})
+	decodedIdx, err := DecodeIndex(idx.Encode())
+	require.NoError(t, err)
+	require.Equal(t, idx.Packs, decodedIdx.Packs)
+	loc, ok := decodedIdx.Locate("b")
+	require.True(t, ok)
+	require.Equal(t, Location{Pack: "pack1", Offset: 5, Length: 6}, loc)
+
+	// A chunk rewritten into another pack keeps its location once the first
+	// pack is removed.
+	decodedIdx.Add(Pack{Name: "pack3", Chunks: []PackedChunk{{Key: "b", Offset: 0, Length: 6}}})
+	decodedIdx.Remove(map[string]struct{}{"pack1": {}})
+	_, ok = decodedIdx.Locate("a")
+	require.False(t, ok)
+	loc, ok = decodedIdx.Locate("b")
+	require.True(t, ok)
+	require.Equal(t, Location{Pack: "pack3", Offset: 0, Length: 6}, loc)
+	require.Len(t, decodedIdx.Packs, 2)
+
+	encoded := idx.Encode()
+	encoded[len(indexMagic)] ^= 0xff
+	_, err = DecodeIndex(encoded)
+	require.Error(t, err)
+}
@@ -0,0 +1,214 @@
+package packs
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+
+	"github.com/grafana/loki/pkg/util/encoding"
+)
+
+// Pack objects store the chunks concatenated, followed by their offset table,
+// the length of the offset table, its CRC and the pack magic:
+//
+//	chunk 1 ... chunk n | offset table | table length (4b) | CRC (4b) | magic (4b)
+//
+// The offset table lists the external key, offset and length of each chunk,
+// so the pack index can be rebuilt from the pack objects.
+const (
+	packMagic  = "LPK1"
+	indexMagic = "LPI1"
+
+	packFooterSize = 12
+)
+
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Location is the location of a packed chunk.
+type Location struct {
+	Pack   string
+	Offset uint64
+	Length uint64
+}
+
+// PackedChunk is an entry of the offset table of a pack.
+type PackedChunk struct {
+	Key    string
+	Offset uint64
+	Length uint64
+}
+
+// Pack is a pack object and its offset table.
+type Pack struct {
+	Name   string
+	Chunks []PackedChunk
+	// Unreferenced is when the compactor found none of the chunks of the pack
+	// indexed anymore, or rewrote its chunks still indexed into another pack,
+	// in milliseconds since epoch, or 0 while the pack is in use.
+	Unreferenced int64
+}
+
+// Size returns the size of the chunks of the pack.
+func (p Pack) Size() uint64 {
+	var size uint64
+	for _, c := range p.Chunks {
+		size += c.Length
+	}
+	return size
+}
+
+func encodeOffsetTable(enc *encoding.Encbuf, chunks []PackedChunk) {
+	enc.PutUvarint(len(chunks))
+	for _, c := range chunks {
+		enc.PutUvarintStr(c.Key)
+		enc.PutUvarint64(c.Offset)
+		enc.PutUvarint64(c.Length)
+	}
+}
+
+func decodeOffsetTable(dec *encoding.Decbuf) []PackedChunk {
+	n := dec.Uvarint()
+	if dec.Err() != nil {
+		return nil
+	}
+	chunks := make([]PackedChunk, 0, n)
+	for i := 0; i < n && dec.Err() == nil; i++ {
+		chunks = append(chunks, PackedChunk{
+			Key:    dec.UvarintStr(),
+			Offset: dec.Uvarint64(),
+			Length: dec.Uvarint64(),
+		})
+	}
+	return chunks
+}
+
+// EncodePack encodes a pack object from the encoded chunks, keyed by their
+// external keys, and returns it with its offset table.
+func EncodePack(keys []string, chunks [][]byte) ([]byte, []PackedChunk) {
+	enc := encoding.EncWith(nil)
+	packed := make([]PackedChunk, 0, len(chunks))
+	for i, buf := range chunks {
+		packed = append(packed, PackedChunk{Key: keys[i], Offset: uint64(len(enc.B)), Length: uint64(len(buf))})
+		enc.PutBytes(buf)
+	}
+
+	tableStart := len(enc.B)
+	encodeOffsetTable(&enc, packed)
+	table := enc.B[tableStart:]
+	crc := crc32.Checksum(table, castagnoliTable)
+	enc.PutBE32(uint32(len(table)))
+	enc.PutBE32(crc)
+	enc.PutString(packMagic)
+	return enc.Get(), packed
+}
+
+// DecodePackTable decodes the offset table of a pack object.
+func DecodePackTable(pack []byte) ([]PackedChunk, error) {
+	if len(pack) < packFooterSize || string(pack[len(pack)-len(packMagic):]) != packMagic {
+		return nil, fmt.Errorf("invalid pack magic")
+	}
+	footer := pack[len(pack)-packFooterSize:]
+	tableLen := int(binary.BigEndian.Uint32(footer))
+	if tableLen > len(pack)-packFooterSize {
+		return nil, fmt.Errorf("invalid pack offset table length %d", tableLen)
+	}
+	table := pack[len(pack)-packFooterSize-tableLen : len(pack)-packFooterSize]
+	if crc32.Checksum(table, castagnoliTable) != binary.BigEndian.Uint32(footer[4:]) {
+		return nil, fmt.Errorf("pack offset table checksum mismatch")
+	}
+
+	dec := encoding.DecWith(table)
+	chunks := decodeOffsetTable(&dec)
+	if err := dec.Err(); err != nil {
+		return nil, fmt.Errorf("decoding pack offset table: %w", err)
+	}
+	return chunks, nil
+}
+
+// Index is the pack index of a tenant in a table, listing its packs and the
+// location of its packed chunks.
+type Index struct {
+	Packs []Pack
+
+	locations map[string]Location
+}
+
+func NewIndex(packs []Pack) *Index {
+	idx := &Index{Packs: packs, locations: map[string]Location{}}
+	for _, p := range packs {
+		idx.index(p)
+	}
+	return idx
+}
+
+func (idx *Index) index(p Pack) {
+	for _, c := range p.Chunks {
+		idx.locations[c.Key] = Location{Pack: p.Name, Offset: c.Offset, Length: c.Length}
+	}
+}
+
+// Locate returns the location of the chunk with the external key.
+func (idx *Index) Locate(key string) (Location, bool) {
+	loc, ok := idx.locations[key]
+	return loc, ok
+}
+
+// Add adds a pack to the index. The chunks already in another pack are
+// located in the added one.
+func (idx *Index) Add(p Pack) {
+	idx.Packs = append(idx.Packs, p)
+	idx.index(p)
+}
+
+// Remove removes the packs with the names from the index. The chunks also
+// written to another pack keep their location in it.
+func (idx *Index) Remove(names map[string]struct{}) {
+	packs := idx.Packs[:0]
+	for _, p := range idx.Packs {
+		if _, ok := names[p.Name]; ok {
+			for _, c := range p.Chunks {
+				if idx.locations[c.Key].Pack == p.Name {
+					delete(idx.locations, c.Key)
+				}
+			}
+			continue
+		}
+		packs = append(packs, p)
+	}
+	idx.Packs = packs
+}
+
+func (idx *Index) Encode() []byte {
+	enc := encoding.EncWith(nil)
+	enc.PutString(indexMagic)
+	enc.PutUvarint(len(idx.Packs))
+	for _, p := range idx.Packs {
+		enc.PutUvarintStr(p.Name)
+		enc.PutVarint64(p.Unreferenced)
+		encodeOffsetTable(&enc, p.Chunks)
+	}
+	enc.PutBE32(crc32.Checksum(enc.B[len(indexMagic):], castagnoliTable))
+	return enc.Get()
+}
+
+func DecodeIndex(b []byte) (*Index, error) {
+	if len(b) < len(indexMagic) || string(b[:len(indexMagic)]) != indexMagic {
+		return nil, fmt.Errorf("invalid pack index magic")
+	}
+	dec := encoding.DecWith(b[len(indexMagic):])
+	if err := dec.CheckCrc(castagnoliTable); err != nil {
+		return nil, fmt.Errorf("pack index checksum mismatch: %w", err)
+	}
+
+	n := dec.Uvarint()
+	var packs []Pack
+	for i := 0; i < n && dec.Err() == nil; i++ {
+		name := dec.UvarintStr()
+		unreferenced := dec.Varint64()
+		packs = append(packs, Pack{Name: name, Unreferenced: unreferenced, Chunks: decodeOffsetTable(&dec)})
+	}
+	if err := dec.Err(); err != nil {
+		return nil, fmt.Errorf("decoding pack index: %w", err)
+	}
+	return NewIndex(packs), nil
+}
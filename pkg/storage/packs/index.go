@@ -0,0 +1,155 @@
+package packs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/loki/pkg/storage/chunk/client"
+)
+
+const (
+	// packsPrefix is the prefix of the pack objects and the pack indexes in
+	// the object store.
+	packsPrefix = "packs/"
+	indexName   = "index"
+	packSuffix  = ".pack"
+)
+
+func tenantPrefix(table, tenant string) string {
+	return packsPrefix + table + "/" + tenant + "/"
+}
+
+func indexKey(table, tenant string) string {
+	return tenantPrefix(table, tenant) + indexName
+}
+
+// PackKey returns the object key of a pack of the tenant in the table.
+func PackKey(table, tenant, name string) string {
+	return tenantPrefix(table, tenant) + name + packSuffix
+}
+
+// Indexes stores the pack indexes of the tenants in the tables.
+//
+// The packs of a tenant are stored by table, as the objects
+// packs/<table>/<tenant>/<name>.pack, and the pack index
+// packs/<table>/<tenant>/index lists the packs and their offset tables. The
+// chunks are attributed to the table their start time falls in, even when
+// they are also indexed in the next one.
+type Indexes struct {
+	client client.ObjectClient
+	ttl    time.Duration
+	now    func() time.Time
+
+	mtx   sync.Mutex
+	cache map[string]cachedIndex
+}
+
+type cachedIndex struct {
+	idx     *Index
+	fetched time.Time
+}
+
+func NewIndexes(client client.ObjectClient, ttl time.Duration) *Indexes {
+	return &Indexes{
+		client: client,
+		ttl:    ttl,
+		now:    time.Now,
+		cache:  map[string]cachedIndex{},
+	}
+}
+
+// Get returns the pack index of the tenant in the table, cached for the TTL.
+// The returned index must not be modified.
+func (i *Indexes) Get(ctx context.Context, table, tenant string) (*Index, error) {
+	key := indexKey(table, tenant)
+
+	i.mtx.Lock()
+	cached, ok := i.cache[key]
+	i.mtx.Unlock()
+	if ok && i.now().Sub(cached.fetched) < i.ttl {
+		return cached.idx, nil
+	}
+	return i.Refresh(ctx, table, tenant)
+}
+
+// Refresh fetches the pack index of the tenant in the table, bypassing the
+// cache. The returned index must not be modified.
+func (i *Indexes) Refresh(ctx context.Context, table, tenant string) (*Index, error) {
+	idx, err := i.Load(ctx, table, tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	i.mtx.Lock()
+	i.cache[indexKey(table, tenant)] = cachedIndex{idx: idx, fetched: i.now()}
+	i.mtx.Unlock()
+	return idx, nil
+}
+
+// Load fetches the pack index of the tenant in the table without caching it,
+// to be modified and stored with Put. It returns an empty index if the tenant
+// has no packs in the table.
+func (i *Indexes) Load(ctx context.Context, table, tenant string) (*Index, error) {
+	rc, _, err := i.client.GetObject(ctx, indexKey(table, tenant))
+	if err != nil {
+		if i.client.IsObjectNotFoundErr(err) {
+			return NewIndex(nil), nil
+		}
+		return nil, fmt.Errorf("getting pack index of tenant %s in table %s: %w", tenant, table, err)
+	}
+	defer rc.Close()
+
+	buf, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("reading pack index of tenant %s in table %s: %w", tenant, table, err)
+	}
+	idx, err := DecodeIndex(buf)
+	if err != nil {
+		return nil, fmt.Errorf("pack index of tenant %s in table %s: %w", tenant, table, err)
+	}
+	return idx, nil
+}
+
+// Put stores the pack index of the tenant in the table, or deletes it if the
+// tenant has no packs left in the table.
+func (i *Indexes) Put(ctx context.Context, table, tenant string, idx *Index) error {
+	key := indexKey(table, tenant)
+	if len(idx.Packs) == 0 {
+		err := i.client.DeleteObject(ctx, key)
+		if err != nil && !i.client.IsObjectNotFoundErr(err) {
+			return fmt.Errorf("deleting pack index of tenant %s in table %s: %w", tenant, table, err)
+		}
+	} else if err := i.client.PutObject(ctx, key, bytes.NewReader(idx.Encode())); err != nil {
+		return fmt.Errorf("storing pack index of tenant %s in table %s: %w", tenant, table, err)
+	}
+
+	// Cache a copy, since the index can be modified by the caller afterwards.
+	cached := NewIndex(append([]Pack(nil), idx.Packs...))
+	i.mtx.Lock()
+	i.cache[key] = cachedIndex{idx: cached, fetched: i.now()}
+	i.mtx.Unlock()
+	return nil
+}
+
+// ListPacks returns the names of the pack objects of the tenant in the table,
+// including the ones missing from its pack index.
+func (i *Indexes) ListPacks(ctx context.Context, table, tenant string) ([]string, error) {
+	objects, _, err := i.client.List(ctx, tenantPrefix(table, tenant), "")
+	if err != nil {
+		return nil, fmt.Errorf("listing packs of tenant %s in table %s: %w", tenant, table, err)
+	}
+
+	var names []string
+	for _, object := range objects {
+		name := strings.TrimPrefix(object.Key, tenantPrefix(table, tenant))
+		if strings.HasSuffix(name, packSuffix) {
+			names = append(names, strings.TrimSuffix(name, packSuffix))
+		}
+	}
+	return names, nil
+}
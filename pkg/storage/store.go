@@ -18,9 +18,11 @@ import (
 	"github.com/grafana/dskit/tenant"
 
 	"github.com/grafana/loki/pkg/analytics"
+	"github.com/grafana/loki/pkg/chunkenc"
 	"github.com/grafana/loki/pkg/iter"
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/logql/syntax"
 	"github.com/grafana/loki/pkg/logqlmodel/stats"
 	"github.com/grafana/loki/pkg/querier/astmapper"
 	"github.com/grafana/loki/pkg/storage/chunk"
@@ -29,6 +31,7 @@ import (
 	"github.com/grafana/loki/pkg/storage/chunk/client/congestion"
 	"github.com/grafana/loki/pkg/storage/chunk/fetcher"
 	"github.com/grafana/loki/pkg/storage/config"
+	"github.com/grafana/loki/pkg/storage/packs"
 	"github.com/grafana/loki/pkg/storage/stores"
 	"github.com/grafana/loki/pkg/storage/stores/index"
 	"github.com/grafana/loki/pkg/storage/stores/series"
@@ -37,6 +40,7 @@ import (
 	"github.com/grafana/loki/pkg/storage/stores/shipper/indexshipper/gatewayclient"
 	"github.com/grafana/loki/pkg/storage/stores/shipper/indexshipper/indexgateway"
 	"github.com/grafana/loki/pkg/storage/stores/shipper/indexshipper/tsdb"
+	"github.com/grafana/loki/pkg/storage/tiering"
 	"github.com/grafana/loki/pkg/util"
 	"github.com/grafana/loki/pkg/util/deletion"
 )
@@ -97,6 +101,10 @@ type LokiStore struct {
 	pipelineWrapper             lokilog.PipelineWrapper
 	congestionControllerFactory func(cfg congestion.Config, logger log.Logger, metrics *congestion.Metrics) congestion.Controller
 
+	// coldMarkers are the tier markers of the cold object store, shared by
+	// the chunk clients of all the periods when storage tiering is enabled.
+	coldMarkers *tiering.Markers
+
 	metricsNamespace string
 }
 
@@ -197,7 +205,7 @@ func (s *LokiStore) init() error {
 		if err != nil {
 			return err
 		}
-		f, err := fetcher.New(s.chunksCache, s.chunksCacheL2, s.storeCfg.ChunkCacheStubs(), s.schemaCfg, chunkClient, s.storeCfg.ChunkCacheConfig.AsyncCacheWriteBackConcurrency, s.storeCfg.ChunkCacheConfig.AsyncCacheWriteBackBufferSize, s.storeCfg.L2ChunkCacheHandoff)
+		f, err := fetcher.New(s.chunksCache, s.chunksCacheL2, s.storeCfg.ChunkCacheStubs(), s.schemaCfg, chunkClient, s.storeCfg.ChunkCacheConfig.AsyncCacheWriteBackConcurrency, s.storeCfg.ChunkCacheConfig.AsyncCacheWriteBackBufferSize, s.storeCfg.L2ChunkCacheHandoff, s.cfg.ZstdDictionaries.Dictionaries)
 		if err != nil {
 			return err
 		}
@@ -246,6 +254,31 @@ func (s *LokiStore) chunkClientForPeriod(p config.PeriodConfig) (client.Client,
 		return nil, errors.Wrap(err, "error creating object client")
 	}
 
+	if s.cfg.ChunkPacks.Enabled {
+		objects, err := NewObjectClient(objectStoreType, s.cfg, s.clientMetrics)
+		if err != nil {
+			return nil, errors.Wrap(err, "error creating chunk packs object client")
+		}
+		chunks = packs.NewClient(chunks, objects, s.schemaCfg, s.cfg.ChunkPacks, s.cfg.MaxParallelGetChunk, s.cfg.ZstdDictionaries.Dictionaries, chunkClientReg)
+	}
+
+	if s.cfg.ColdStorage.Enabled() {
+		coldChunkClientReg := prometheus.WrapRegistererWith(
+			prometheus.Labels{"component": "cold-chunk-store-" + p.From.String()}, s.registerer)
+		cold, err := NewChunkClient(s.cfg.ColdStorage.Store, s.cfg, s.schemaCfg, cc, coldChunkClientReg, s.clientMetrics, s.logger)
+		if err != nil {
+			return nil, errors.Wrap(err, "error creating cold object client")
+		}
+		if s.coldMarkers == nil {
+			markersClient, err := NewObjectClient(s.cfg.ColdStorage.Store, s.cfg, s.clientMetrics)
+			if err != nil {
+				return nil, errors.Wrap(err, "error creating cold object client")
+			}
+			s.coldMarkers = tiering.NewMarkers(markersClient, s.cfg.ColdStorage.MarkerCacheTTL)
+		}
+		chunks = tiering.NewClient(chunks, cold, s.coldMarkers, s.schemaCfg, chunkClientReg)
+	}
+
 	chunks = client.NewMetricsChunkClient(chunks, s.chunkClientMetrics)
 	return chunks, nil
 }
@@ -301,7 +334,7 @@ func (s *LokiStore) storeForPeriod(p config.PeriodConfig, tableRange config.Tabl
 		}
 
 		indexReaderWriter = index.NewMonitoredReaderWriter(indexReaderWriter, indexClientReg)
-		chunkWriter := stores.NewChunkWriter(f, s.schemaCfg, indexReaderWriter, s.storeCfg.DisableIndexDeduplication)
+		chunkWriter := stores.NewChunkWriter(f, s.schemaCfg, indexReaderWriter, s.storeCfg.DisableIndexDeduplication, s.storeCfg.ContentDeduplication)
 
 		return chunkWriter, indexReaderWriter,
 			func() {
@@ -327,7 +360,7 @@ func (s *LokiStore) storeForPeriod(p config.PeriodConfig, tableRange config.Tabl
 
 	indexReaderWriter := series.NewIndexReaderWriter(s.schemaCfg, schema, idx, f, s.cfg.MaxChunkBatchSize, s.writeDedupeCache)
 	monitoredReaderWriter := index.NewMonitoredReaderWriter(indexReaderWriter, indexClientReg)
-	chunkWriter := stores.NewChunkWriter(f, s.schemaCfg, monitoredReaderWriter, s.storeCfg.DisableIndexDeduplication)
+	chunkWriter := stores.NewChunkWriter(f, s.schemaCfg, monitoredReaderWriter, s.storeCfg.DisableIndexDeduplication, s.storeCfg.ContentDeduplication)
 
 	return chunkWriter,
 		monitoredReaderWriter,
@@ -554,6 +587,13 @@ func (s *LokiStore) SelectSamples(ctx context.Context, req logql.SelectSamplePar
 		return nil, err
 	}
 
+	// The columnar chunks are read without decompressing their lines when the
+	// samples don't depend on them. Delete requests and extractor wrappers can
+	// filter on the lines, so they always get them.
+	if len(req.Deletes) == 0 && s.extractorWrapper == nil && !samplesNeedLines(expr) {
+		extractor = withoutLinesSampleExtractor{extractor}
+	}
+
 	if s.extractorWrapper != nil {
 		userID, err := tenant.TenantID(ctx)
 		if err != nil {
@@ -571,6 +611,42 @@ func (s *LokiStore) SelectSamples(ctx context.Context, req logql.SelectSamplePar
 	return newSampleBatchIterator(ctx, s.schemaCfg, s.chunkMetrics, lazyChunks, s.cfg.MaxChunkBatchSize, matchers, extractor, req.Start, req.End, chunkFilterer)
 }
 
+// samplesNeedLines returns whether the samples extracted by the expression
+// depend on the log lines. They don't when counting the entries of the streams
+// with only label filters, which apply to the stream labels and the structured
+// metadata of the entries without a parser.
+func samplesNeedLines(expr syntax.SampleExpr) bool {
+	needsLines := false
+	expr.Walk(func(e syntax.Expr) {
+		switch e := e.(type) {
+		case *syntax.RangeAggregationExpr:
+			switch e.Operation {
+			case syntax.OpRangeTypeCount, syntax.OpRangeTypeRate, syntax.OpRangeTypeAbsent:
+				needsLines = needsLines || e.Left.Unwrap != nil
+			default:
+				needsLines = true
+			}
+		case *syntax.PipelineExpr:
+			for _, stage := range e.MultiStages {
+				if _, ok := stage.(*syntax.LabelFilterExpr); !ok {
+					needsLines = true
+				}
+			}
+		}
+	})
+	return needsLines
+}
+
+// withoutLinesSampleExtractor reads the samples of the streams without their
+// lines.
+type withoutLinesSampleExtractor struct {
+	lokilog.SampleExtractor
+}
+
+func (e withoutLinesSampleExtractor) ForStream(lbs labels.Labels) lokilog.StreamSampleExtractor {
+	return chunkenc.WithoutLines(e.SampleExtractor.ForStream(lbs))
+}
+
 func (s *LokiStore) GetSchemaConfigs() []config.PeriodConfig {
 	return s.schemaCfg.Configs
 }
@@ -1070,6 +1070,28 @@ func (p *mockStreamExtractor) ProcessString(ts int64, line string, lbs ...labels
 	return p.wrappedSP.ProcessString(ts, line, lbs...)
 }
 
+func Test_samplesNeedLines(t *testing.T) {
+	for _, tc := range []struct {
+		query      string
+		needsLines bool
+	}{
+		{query: `count_over_time({app="foo"}[5m])`},
+		{query: `sum by (level) (rate({app="foo"} | level="error" [5m]))`},
+		{query: `absent_over_time({app="foo"} | trace_id!="" [5m])`},
+		{query: `count_over_time({app="foo"} |= "error" [5m])`, needsLines: true},
+		{query: `count_over_time({app="foo"} | logfmt | level="error" [5m])`, needsLines: true},
+		{query: `bytes_over_time({app="foo"}[5m])`, needsLines: true},
+		{query: `sum_over_time({app="foo"} | unwrap latency [5m])`, needsLines: true},
+		{query: `count_over_time({app="foo"}[5m]) / count_over_time({app="foo"} |= "error" [5m])`, needsLines: true},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			expr, err := syntax.ParseSampleExpr(tc.query)
+			require.NoError(t, err)
+			require.Equal(t, tc.needsLines, samplesNeedLines(expr))
+		})
+	}
+}
+
 func Test_store_GetSeries(t *testing.T) {
 	periodConfig := config.PeriodConfig{
 		From:   config.DayTime{Time: 0},
@@ -2,13 +2,22 @@ package stores
 
 import (
 	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
 
+	"github.com/cespare/xxhash/v2"
 	"github.com/go-kit/log/level"
 	"github.com/opentracing/opentracing-go"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/common/model"
 
+	"github.com/grafana/loki/pkg/chunkenc"
+	"github.com/grafana/loki/pkg/logproto"
+	lokilog "github.com/grafana/loki/pkg/logql/log"
 	"github.com/grafana/loki/pkg/storage/chunk"
 	"github.com/grafana/loki/pkg/storage/chunk/fetcher"
 	"github.com/grafana/loki/pkg/storage/config"
@@ -30,6 +39,12 @@ var (
 		Help:      "Count of bytes from chunks which were not stored because they have already been stored by another replica.",
 	})
 
+	ContentDedupedChunksTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: constants.Loki,
+		Name:      "chunk_store_content_deduped_chunks_total",
+		Help:      "Count of chunks which were not stored because another replica stored a chunk with the same entries but a different encoding.",
+	})
+
 	IndexEntriesPerChunk = promauto.NewHistogram(prometheus.HistogramOpts{
 		Namespace: constants.Loki,
 		Name:      "chunk_store_index_entries_per_chunk",
@@ -41,15 +56,17 @@ var (
 type Writer struct {
 	schemaCfg                 config.SchemaConfig
 	DisableIndexDeduplication bool
+	ContentDeduplication      bool
 
 	indexWriter index.Writer
 	fetcher     *fetcher.Fetcher
 }
 
-func NewChunkWriter(fetcher *fetcher.Fetcher, schemaCfg config.SchemaConfig, indexWriter index.Writer, disableIndexDeduplication bool) ChunkWriter {
+func NewChunkWriter(fetcher *fetcher.Fetcher, schemaCfg config.SchemaConfig, indexWriter index.Writer, disableIndexDeduplication, contentDeduplication bool) ChunkWriter {
 	return &Writer{
 		schemaCfg:                 schemaCfg,
 		DisableIndexDeduplication: disableIndexDeduplication,
+		ContentDeduplication:      contentDeduplication,
 		fetcher:                   fetcher,
 		indexWriter:               indexWriter,
 	}
@@ -97,6 +114,21 @@ func (c *Writer) PutOne(ctx context.Context, from, through model.Time, chk chunk
 
 	}
 
+	// A chunk with the same entries may have been stored by another replica with a different encoding,
+	// in which case the stored chunk is indexed instead of this one.
+	var contentKey string
+	if len(found) == 0 && !overlap && c.ContentDeduplication {
+		var err error
+		if contentKey, err = chunkContentKey(ctx, chk); err != nil {
+			level.Warn(log).Log("msg", "failed to hash chunk entries, cannot deduplicate chunk by content", "err", err)
+		} else if checksum, ok := c.storedChecksum(ctx, contentKey); ok && checksum != chk.Checksum {
+			writeChunk = false
+			ContentDedupedChunksTotal.Inc()
+			DedupedChunksTotal.Inc()
+			chk.ChunkRef.Checksum = checksum
+		}
+	}
+
 	// If we dont have to write the chunk and DisableIndexDeduplication is false, we do not have to do anything.
 	// If we dont have to write the chunk and DisableIndexDeduplication is true, we have to write index and not chunk.
 	// Otherwise write both index and chunk.
@@ -118,12 +150,72 @@ func (c *Writer) PutOne(ctx context.Context, from, through model.Time, chk chunk
 		return err
 	}
 
-	// write chunk to the cache if it's not found.
-	if len(found) == 0 {
+	// write chunk to the cache if it's not found, unless the stored chunk was indexed instead of it.
+	if len(found) == 0 && writeChunk {
 		if cacheErr := c.fetcher.WriteBackCache(ctx, chunks); cacheErr != nil {
 			level.Warn(log).Log("msg", "could not store chunks in chunk cache", "err", cacheErr)
 		}
+		if contentKey != "" {
+			checksum := []byte(strconv.FormatUint(uint64(chk.Checksum), 16))
+			if cacheErr := c.fetcher.Cache().Store(ctx, []string{contentKey}, [][]byte{checksum}); cacheErr != nil {
+				level.Warn(log).Log("msg", "could not store chunk content key in chunk cache", "err", cacheErr)
+			}
+		}
 	}
 
 	return nil
 }
+
+// storedChecksum returns the checksum of the chunk stored with the given content key, if any.
+func (c *Writer) storedChecksum(ctx context.Context, contentKey string) (uint32, bool) {
+	_, bufs, _, err := c.fetcher.Cache().Fetch(ctx, []string{contentKey})
+	if err != nil || len(bufs) == 0 {
+		return 0, false
+	}
+	checksum, err := strconv.ParseUint(string(bufs[0]), 16, 32)
+	if err != nil {
+		return 0, false
+	}
+	return uint32(checksum), true
+}
+
+// chunkContentKey returns the cache key of the entries of a chunk. Chunks of the same stream with the
+// same entries over the same time range have the same key, whatever their encoding and block layout.
+func chunkContentKey(ctx context.Context, chk chunk.Chunk) (string, error) {
+	facade, ok := chk.Data.(*chunkenc.Facade)
+	if !ok {
+		return "", fmt.Errorf("unsupported chunk data %T", chk.Data)
+	}
+	it, err := facade.LokiChunk().Iterator(ctx, time.Unix(0, 0), time.Unix(0, math.MaxInt64), logproto.FORWARD, lokilog.NewNoopPipeline().ForStream(chk.Metric))
+	if err != nil {
+		return "", err
+	}
+	defer it.Close()
+
+	h := xxhash.New()
+	var buf [8]byte
+	for it.Next() {
+		e := it.Entry()
+		binary.LittleEndian.PutUint64(buf[:], uint64(e.Timestamp.UnixNano()))
+		_, _ = h.Write(buf[:])
+		writeHashString(h, e.Line)
+		binary.LittleEndian.PutUint64(buf[:], uint64(len(e.StructuredMetadata)))
+		_, _ = h.Write(buf[:])
+		for _, l := range e.StructuredMetadata {
+			writeHashString(h, l.Name)
+			writeHashString(h, l.Value)
+		}
+	}
+	if err := it.Error(); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("content/%s/%x/%x:%x:%x", chk.UserID, uint64(chk.Fingerprint), int64(chk.From), int64(chk.Through), h.Sum64()), nil
+}
+
+// writeHashString writes a length-prefixed string, so that consecutive strings can't collide.
+func writeHashString(h *xxhash.Digest, s string) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(len(s)))
+	_, _ = h.Write(buf[:])
+	_, _ = h.WriteString(s)
+}
@@ -2,13 +2,16 @@ package stores
 
 import (
 	"context"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/loki/pkg/chunkenc"
+	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/logqlmodel/stats"
 	"github.com/grafana/loki/pkg/storage/chunk"
 	"github.com/grafana/loki/pkg/storage/chunk/fetcher"
@@ -20,8 +23,14 @@ type mockCache struct {
 	data   map[string]string
 }
 
-func (m *mockCache) Store(_ context.Context, _ []string, _ [][]byte) error {
+func (m *mockCache) Store(_ context.Context, keys []string, bufs [][]byte) error {
 	m.called++
+	if m.data == nil {
+		m.data = map[string]string{}
+	}
+	for i, key := range keys {
+		m.data[key] = string(bufs[i])
+	}
 	return nil
 }
 
@@ -44,10 +53,12 @@ func (m *mockCache) GetCacheType() stats.CacheType { return stats.ChunkCache }
 
 type mockIndexWriter struct {
 	called int
+	refs   []logproto.ChunkRef
 }
 
-func (m *mockIndexWriter) IndexChunk(_ context.Context, _, _ model.Time, _ chunk.Chunk) error {
+func (m *mockIndexWriter) IndexChunk(_ context.Context, _, _ model.Time, chk chunk.Chunk) error {
 	m.called++
+	m.refs = append(m.refs, chk.ChunkRef)
 	return nil
 }
 
@@ -155,10 +166,10 @@ func TestChunkWriter_PutOne(t *testing.T) {
 			idx := &mockIndexWriter{}
 			client := &mockChunksClient{}
 
-			f, err := fetcher.New(cache, nil, false, schemaConfig, client, 1, 1, 0)
+			f, err := fetcher.New(cache, nil, false, schemaConfig, client, 1, 1, 0, nil)
 			require.NoError(t, err)
 
-			cw := NewChunkWriter(f, schemaConfig, idx, true)
+			cw := NewChunkWriter(f, schemaConfig, idx, true, false)
 
 			err = cw.PutOne(context.Background(), tc.from, tc.through, chk)
 			require.NoError(t, err)
@@ -168,3 +179,50 @@ func TestChunkWriter_PutOne(t *testing.T) {
 		})
 	}
 }
+
+func TestChunkWriter_PutOneContentDeduplication(t *testing.T) {
+	periodConfig := config.PeriodConfig{
+		From:   config.DayTime{Time: 0},
+		Schema: "v13",
+	}
+
+	schemaConfig := config.SchemaConfig{
+		Configs: []config.PeriodConfig{periodConfig},
+	}
+
+	chunkfmt, headfmt, err := periodConfig.ChunkFormat()
+	require.NoError(t, err)
+
+	// Replicas flush the same entries with different encodings.
+	newChunk := func(enc chunkenc.Encoding, lines int) chunk.Chunk {
+		memchk := chunkenc.NewMemChunk(chunkfmt, enc, headfmt, 256*1024, 0)
+		for i := 0; i < lines; i++ {
+			require.NoError(t, memchk.Append(&logproto.Entry{Timestamp: time.Unix(0, int64(i)*int64(time.Millisecond)), Line: fmt.Sprintf("line %d", i)}))
+		}
+		chk := chunk.NewChunk("fake", model.Fingerprint(0), []labels.Label{{Name: "foo", Value: "bar"}}, chunkenc.NewFacade(memchk, 0, 0), 0, 9)
+		require.NoError(t, chk.Encode())
+		return chk
+	}
+	gzipChk, snappyChk, otherChk := newChunk(chunkenc.EncGZIP, 10), newChunk(chunkenc.EncSnappy, 10), newChunk(chunkenc.EncSnappy, 9)
+	require.NotEqual(t, gzipChk.Checksum, snappyChk.Checksum)
+
+	cache := &mockCache{}
+	idx := &mockIndexWriter{}
+	client := &mockChunksClient{}
+	f, err := fetcher.New(cache, nil, false, schemaConfig, client, 1, 1, 0, nil)
+	require.NoError(t, err)
+	cw := NewChunkWriter(f, schemaConfig, idx, true, true)
+
+	require.NoError(t, cw.PutOne(context.Background(), 0, 9, gzipChk))
+	require.Equal(t, 1, client.called)
+
+	// The chunk with the same entries isn't written, and the stored chunk is indexed instead.
+	require.NoError(t, cw.PutOne(context.Background(), 0, 9, snappyChk))
+	require.Equal(t, 1, client.called)
+	require.Equal(t, []logproto.ChunkRef{gzipChk.ChunkRef, gzipChk.ChunkRef}, idx.refs)
+
+	// A chunk with other entries over the same time range is written.
+	require.NoError(t, cw.PutOne(context.Background(), 0, 9, otherChk))
+	require.Equal(t, 2, client.called)
+	require.Equal(t, otherChk.ChunkRef, idx.refs[2])
+}
@@ -50,6 +50,7 @@ type IndexClientWithRange struct {
 
 type BloomQuerier interface {
 	FilterChunkRefs(ctx context.Context, tenant string, from, through model.Time, chunks []*logproto.ChunkRef, plan plan.QueryPlan) ([]*logproto.ChunkRef, error)
+	FiltersChunks(tenant string, plan plan.QueryPlan) bool
 }
 
 type Gateway struct {
@@ -227,9 +228,9 @@ func (g *Gateway) GetChunkRef(ctx context.Context, req *logproto.GetChunkRefRequ
 		return result, nil
 	}
 
-	// Extract LineFiltersExpr from the plan. If there is none, we can short-circuit and return before making a req
-	// to the bloom-gateway (through the g.bloomQuerier)
-	if len(syntax.ExtractLineFilters(req.Plan.AST)) == 0 {
+	// If the plan has neither line filters nor label filters on the structured metadata keys added to the blooms of the tenant,
+	// we can short-circuit and return before making a req to the bloom-gateway (through the g.bloomQuerier)
+	if !g.bloomQuerier.FiltersChunks(instanceID, req.Plan) {
 		return result, nil
 	}
 
@@ -285,6 +285,7 @@ func newCompactedIndex(ctx context.Context, tableName, userID, workingDir string
 }
 
 // ForEachChunk iterates over all the chunks in the builder and calls the callback function.
+// The chunks lined up for deletion by a previous iteration are skipped.
 func (c *compactedIndex) ForEachChunk(ctx context.Context, callback retention.ChunkEntryCallback) error {
 	schemaCfg := config.SchemaConfig{
 		Configs: []config.PeriodConfig{c.periodConfig},
@@ -303,8 +304,19 @@ func (c *compactedIndex) ForEachChunk(ctx context.Context, callback retention.Ch
 		chunkEntry.SeriesID = getUnsafeBytes(seriesID)
 		chunkEntry.Labels = withoutTenantLabel(stream.labels)
 
+		var deleted map[tsdbindex.ChunkMeta]struct{}
+		if chks := c.deleteChunks[seriesID]; len(chks) > 0 {
+			deleted = make(map[tsdbindex.ChunkMeta]struct{}, len(chks))
+			for _, chk := range chks {
+				deleted[chk] = struct{}{}
+			}
+		}
+
 		for i := 0; i < len(stream.chunks) && ctx.Err() == nil; i++ {
 			chk := stream.chunks[i]
+			if _, ok := deleted[chk]; ok {
+				continue
+			}
 			logprotoChunkRef.From = chk.From()
 			logprotoChunkRef.Through = chk.Through()
 			logprotoChunkRef.Checksum = chk.Checksum
@@ -312,6 +324,8 @@ func (c *compactedIndex) ForEachChunk(ctx context.Context, callback retention.Ch
 			chunkEntry.ChunkID = getUnsafeBytes(schemaCfg.ExternalKey(logprotoChunkRef))
 			chunkEntry.From = logprotoChunkRef.From
 			chunkEntry.Through = logprotoChunkRef.Through
+			chunkEntry.KB = chk.KB
+			chunkEntry.Entries = chk.Entries
 
 			deleteChunk, err := callback(chunkEntry)
 			if err != nil {
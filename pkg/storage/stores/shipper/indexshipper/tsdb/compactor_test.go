@@ -635,6 +635,8 @@ func chunkMetasToChunkEntry(schemaCfg config.SchemaConfig, userID string, lbls l
 				ChunkID:  []byte(schemaCfg.ExternalKey(chunkMetaToChunkRef(userID, chunkMeta, lbls))),
 				From:     chunkMeta.From(),
 				Through:  chunkMeta.Through(),
+				KB:       chunkMeta.KB,
+				Entries:  chunkMeta.Entries,
 			},
 			Labels: lbls,
 		})
@@ -851,6 +853,32 @@ func TestIteratorContextCancelation(t *testing.T) {
 	require.ErrorIs(t, err, context.Canceled)
 }
 
+func TestIteratorSkipsDeletedChunks(t *testing.T) {
+	tc := setupCompactedIndex(t)
+	compactedIndex := tc.buildCompactedIndex()
+
+	deleted := buildChunkMetas(tc.shiftTableStart(3), tc.shiftTableStart(5))
+	err := compactedIndex.ForEachChunk(context.Background(), func(chunkEntry retention.ChunkEntry) (deleteChunk bool, err error) {
+		return string(chunkEntry.SeriesID) == tc.lbls1.String() && int64(chunkEntry.From) >= deleted[0].MinTime && int64(chunkEntry.From) <= deleted[2].MinTime, nil
+	})
+	require.NoError(t, err)
+
+	// the chunks lined up for deletion are not iterated again.
+	foundChunkEntries := map[string][]retention.ChunkEntry{}
+	err = compactedIndex.ForEachChunk(context.Background(), func(chunkEntry retention.ChunkEntry) (deleteChunk bool, err error) {
+		seriesIDStr := string(chunkEntry.SeriesID)
+		foundChunkEntries[seriesIDStr] = append(foundChunkEntries[seriesIDStr], chunkEntry)
+		return false, nil
+	})
+	require.NoError(t, err)
+
+	require.Len(t, foundChunkEntries[tc.lbls1.String()], len(tc.expectedChunkEntries[tc.lbls1.String()])-len(deleted))
+	require.Equal(t, tc.expectedChunkEntries[tc.lbls2.String()], foundChunkEntries[tc.lbls2.String()])
+
+	_, err = compactedIndex.ToIndexFile()
+	require.NoError(t, err)
+}
+
 type testContext struct {
 	lbls1                labels.Labels
 	lbls2                labels.Labels
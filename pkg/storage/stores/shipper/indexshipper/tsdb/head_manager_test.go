@@ -62,6 +62,10 @@ func (m *zeroValueLimits) VolumeMaxSeries(_ string) int {
 	return 0
 }
 
+func (m *zeroValueLimits) TSDBStructuredMetadataIndexKeys(_ string) []string {
+	return nil
+}
+
 func (m *zeroValueLimits) DefaultLimits() *validation.Limits {
 	return &validation.Limits{
 		QueryReadyIndexNumDays: 0,
@@ -39,6 +39,9 @@ const (
 	WalRecordSeries RecordType = iota
 	WalRecordChunks
 	WalRecordSeriesWithFingerprint
+	// WalRecordChunksWithSummaries is used instead of WalRecordChunks
+	// when some chunks have a structured metadata summary.
+	WalRecordChunksWithSummaries
 )
 
 type WALRecord struct {
@@ -85,8 +88,16 @@ func (r *WALRecord) encodeSeriesWithFingerprint(b []byte) []byte {
 }
 
 func (r *WALRecord) encodeChunks(b []byte) []byte {
+	recordType := WalRecordChunks
+	for _, chk := range r.Chks.Chks {
+		if chk.Summary != "" {
+			recordType = WalRecordChunksWithSummaries
+			break
+		}
+	}
+
 	buf := encoding.EncWith(b)
-	buf.PutByte(byte(WalRecordChunks))
+	buf.PutByte(byte(recordType))
 	buf.PutUvarintStr(r.UserID)
 	buf.PutBE64(r.Chks.Ref)
 	buf.PutUvarint(len(r.Chks.Chks))
@@ -97,12 +108,15 @@ func (r *WALRecord) encodeChunks(b []byte) []byte {
 		buf.PutBE32(chk.Checksum)
 		buf.PutBE32(chk.KB)
 		buf.PutBE32(chk.Entries)
+		if recordType == WalRecordChunksWithSummaries {
+			buf.PutUvarintStr(string(chk.Summary))
+		}
 	}
 
 	return buf.Get()
 }
 
-func decodeChunks(b []byte, rec *WALRecord) error {
+func decodeChunks(b []byte, withSummaries bool, rec *WALRecord) error {
 	if len(b) == 0 {
 		return nil
 	}
@@ -122,13 +136,17 @@ func decodeChunks(b []byte, rec *WALRecord) error {
 	rec.Chks.Chks = make(index.ChunkMetas, 0, ln)
 
 	for len(dec.B) > 0 && dec.Err() == nil {
-		rec.Chks.Chks = append(rec.Chks.Chks, index.ChunkMeta{
+		chk := index.ChunkMeta{
 			MinTime:  dec.Be64int64(),
 			MaxTime:  dec.Be64int64(),
 			Checksum: dec.Be32(),
 			KB:       dec.Be32(),
 			Entries:  dec.Be32(),
-		})
+		}
+		if withSummaries {
+			chk.Summary = index.MetadataSummary(dec.UvarintStr())
+		}
+		rec.Chks.Chks = append(rec.Chks.Chks, chk)
 	}
 
 	if err := dec.Err(); err != nil {
@@ -175,9 +193,9 @@ func decodeWALRecord(b []byte, walRec *WALRecord) error {
 		if len(rSeries) == 1 {
 			walRec.Series = rSeries[0]
 		}
-	case WalRecordChunks:
+	case WalRecordChunks, WalRecordChunksWithSummaries:
 		userID = decbuf.UvarintStr()
-		if err := decodeChunks(decbuf.B, walRec); err != nil {
+		if err := decodeChunks(decbuf.B, t == WalRecordChunksWithSummaries, walRec); err != nil {
 			return err
 		}
 	default:
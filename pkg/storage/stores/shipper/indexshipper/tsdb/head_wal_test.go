@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/go-kit/log"
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/tsdb/chunks"
 	"github.com/prometheus/prometheus/tsdb/record"
 	"github.com/stretchr/testify/require"
@@ -76,6 +77,42 @@ func Test_Encoding_Chunks(t *testing.T) {
 	require.Equal(t, record, decoded)
 }
 
+func Test_Encoding_ChunksWithSummaries(t *testing.T) {
+	b := index.NewMetadataSummaryBuilder([]string{"trace_id"})
+	b.Add(labels.FromStrings("trace_id", "abc"))
+
+	record := &WALRecord{
+		UserID: "foo",
+		Chks: ChunkMetasRecord{
+			Ref: 1,
+			Chks: index.ChunkMetas{
+				{
+					Checksum: 1,
+					MinTime:  1,
+					MaxTime:  4,
+					KB:       5,
+					Entries:  6,
+					Summary:  b.Build(),
+				},
+				{
+					Checksum: 2,
+					MinTime:  5,
+					MaxTime:  10,
+					KB:       7,
+					Entries:  8,
+				},
+			},
+		},
+	}
+	buf := record.encodeChunks(nil)
+	require.Equal(t, WalRecordChunksWithSummaries, RecordType(buf[0]))
+	decoded := &WALRecord{}
+
+	err := decodeWALRecord(buf, decoded)
+	require.Nil(t, err)
+	require.Equal(t, record, decoded)
+}
+
 func Test_HeadWALLog(t *testing.T) {
 	dir := t.TempDir()
 	w, err := newHeadWAL(log.NewNopLogger(), dir, time.Now())
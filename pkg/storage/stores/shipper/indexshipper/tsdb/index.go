@@ -20,6 +20,10 @@ type ChunkRef struct {
 	Fingerprint model.Fingerprint
 	Start, End  model.Time
 	Checksum    uint32
+
+	// Summary of selected structured metadata keys, used to skip the chunks
+	// none of whose entries pass the label filters of the queries.
+	Summary index.MetadataSummary
 }
 
 // Compares by (Start, End)
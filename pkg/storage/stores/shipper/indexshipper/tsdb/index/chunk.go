@@ -19,6 +19,9 @@ type ChunkMeta struct {
 	KB uint32
 
 	Entries uint32
+
+	// Summary of selected structured metadata keys, only stored from FormatV4.
+	Summary MetadataSummary
 }
 
 func (c ChunkMeta) From() model.Time                 { return model.Time(c.MinTime) }
@@ -151,7 +154,7 @@ func (c ChunkMetas) Drop(chk ChunkMeta) (ChunkMetas, bool) {
 		return ichk.Checksum >= chk.Checksum
 	})
 
-	if j >= len(c) || c[j] != chk {
+	if j >= len(c) || c[j].MinTime != chk.MinTime || c[j].MaxTime != chk.MaxTime || c[j].Checksum != chk.Checksum {
 		return c, false
 	}
 
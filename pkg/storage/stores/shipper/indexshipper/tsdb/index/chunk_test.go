@@ -419,6 +419,7 @@ func TestChunkEncodingRoundTrip(t *testing.T) {
 	for _, version := range []int{
 		FormatV2,
 		FormatV3,
+		FormatV4,
 	} {
 		for _, nChks := range []int{
 			0,
@@ -433,6 +434,11 @@ func TestChunkEncodingRoundTrip(t *testing.T) {
 			} {
 				t.Run(fmt.Sprintf("version %d nChks %d pageSize %d", version, nChks, pageSize), func(t *testing.T) {
 					chks := mkChks(nChks)
+					if version >= FormatV4 {
+						for i := range chks {
+							chks[i].Summary = MetadataSummary(fmt.Sprint("summary", i))
+						}
+					}
 					var w Writer
 					w.Version = version
 					primary := encoding.EncWrap(tsdb_enc.Encbuf{B: make([]byte, 0)})
@@ -592,7 +598,7 @@ func TestSearchWithPageMarkers(t *testing.T) {
 				decbuf := encoding.DecWrap(tsdb_enc.Decbuf{B: primary.Get()})
 				dec := newDecoder(nil, 0)
 				dst := []ChunkMeta{}
-				require.Nil(t, dec.readChunksV3(&decbuf, tc.mint, tc.maxt, &dst))
+				require.Nil(t, dec.readChunksV3(FormatV3, &decbuf, tc.mint, tc.maxt, &dst))
 				require.Equal(t, tc.exp, dst)
 			})
 		}
@@ -605,6 +611,7 @@ func TestDecoderChunkStats(t *testing.T) {
 		for _, version := range []int{
 			FormatV2,
 			FormatV3,
+			FormatV4,
 		} {
 			for _, tc := range []struct {
 				desc          string
@@ -0,0 +1,295 @@
+package index
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/pkg/util/encoding"
+)
+
+const (
+	// maxSummaryValues is the maximum number of distinct values of a key
+	// listed in a summary. Keys with more values are summarized with a bloom
+	// filter instead.
+	maxSummaryValues = 16
+	// summaryBloomBitsPerValue and summaryBloomHashes give a ~1% false
+	// positive rate until the bloom filter reaches maxSummaryBloomBytes.
+	summaryBloomBitsPerValue = 10
+	summaryBloomHashes       = 7
+	maxSummaryBloomBytes     = 1 << 10
+)
+
+// flags of the optional fields of an encoded KeySummary.
+const (
+	summaryFlagNumeric = 1 << iota
+	summaryFlagValues
+	summaryFlagBloom
+)
+
+// MetadataSummary is the encoded summary of the values taken by selected
+// structured metadata keys in the entries of a chunk. It is kept encoded so
+// that ChunkMeta stays comparable and cheap to copy, and is empty for the
+// chunks indexed without a summary.
+type MetadataSummary string
+
+// KeySummary summarizes the values of a structured metadata key in the
+// entries of a chunk. The entries without the key count as having the empty
+// value, as label filters compare missing labels with the empty value.
+type KeySummary struct {
+	Name string
+
+	// Min and Max are the lexicographic bounds of the values.
+	Min, Max string
+
+	// Values are the sorted distinct values, set when there are at most
+	// maxSummaryValues of them.
+	Values []string
+
+	// Bloom is a bloom filter of the values, set instead of Values when
+	// there are more of them.
+	Bloom []byte
+
+	// Numeric is set when the key is present in some entries and all its
+	// values parse as floats, NumMin and NumMax being their bounds.
+	Numeric        bool
+	NumMin, NumMax float64
+}
+
+// MayContain returns false if no entry of the chunk has the given value.
+func (s *KeySummary) MayContain(value string) bool {
+	if value < s.Min || value > s.Max {
+		return false
+	}
+	if s.Values != nil {
+		i := sort.SearchStrings(s.Values, value)
+		return i < len(s.Values) && s.Values[i] == value
+	}
+	if s.Bloom != nil {
+		return bloomTest(s.Bloom, value)
+	}
+	return true
+}
+
+// MayMatch returns false if no entry of the chunk has a value matched by the
+// matcher. Only equality matchers are checked unless the values are listed.
+func (s *KeySummary) MayMatch(m *labels.Matcher) bool {
+	if m.Type == labels.MatchEqual {
+		return s.MayContain(m.Value)
+	}
+	if s.Values == nil {
+		return true
+	}
+	for _, v := range s.Values {
+		if m.Matches(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// Decode decodes the summaries of the keys. It returns nil for the chunks
+// indexed without a summary.
+func (s MetadataSummary) Decode() ([]KeySummary, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	d := encoding.DecWith([]byte(s))
+	res := make([]KeySummary, d.Uvarint())
+	for i := range res {
+		k := &res[i]
+		k.Name = d.UvarintStr()
+		k.Min = d.UvarintStr()
+		k.Max = d.UvarintStr()
+
+		flags := d.Byte()
+		if flags&summaryFlagNumeric != 0 {
+			k.Numeric = true
+			k.NumMin = math.Float64frombits(d.Be64())
+			k.NumMax = math.Float64frombits(d.Be64())
+		}
+		if flags&summaryFlagValues != 0 {
+			k.Values = make([]string, d.Uvarint())
+			for j := range k.Values {
+				k.Values[j] = d.UvarintStr()
+			}
+		}
+		if flags&summaryFlagBloom != 0 {
+			k.Bloom = []byte(d.UvarintStr())
+		}
+	}
+
+	if d.Err() != nil {
+		return nil, errors.Wrap(d.Err(), "decoding structured metadata summary")
+	}
+	return res, nil
+}
+
+// EncodeMetadataSummary encodes the summaries of the keys.
+func EncodeMetadataSummary(keys []KeySummary) MetadataSummary {
+	if len(keys) == 0 {
+		return ""
+	}
+
+	e := encoding.EncWith(nil)
+	e.PutUvarint(len(keys))
+	for _, k := range keys {
+		e.PutUvarintStr(k.Name)
+		e.PutUvarintStr(k.Min)
+		e.PutUvarintStr(k.Max)
+
+		var flags byte
+		if k.Numeric {
+			flags |= summaryFlagNumeric
+		}
+		if k.Values != nil {
+			flags |= summaryFlagValues
+		}
+		if k.Bloom != nil {
+			flags |= summaryFlagBloom
+		}
+		e.PutByte(flags)
+
+		if k.Numeric {
+			e.PutBE64(math.Float64bits(k.NumMin))
+			e.PutBE64(math.Float64bits(k.NumMax))
+		}
+		if k.Values != nil {
+			e.PutUvarint(len(k.Values))
+			for _, v := range k.Values {
+				e.PutUvarintStr(v)
+			}
+		}
+		if k.Bloom != nil {
+			e.PutUvarintStr(string(k.Bloom))
+		}
+	}
+	return MetadataSummary(e.Get())
+}
+
+// MetadataSummaryBuilder builds the summary of the structured metadata keys
+// of a chunk from the structured metadata of its entries.
+type MetadataSummaryBuilder struct {
+	keys []*keySummaryBuilder
+}
+
+type keySummaryBuilder struct {
+	name   string
+	values map[string]struct{}
+
+	present        bool
+	numeric        bool
+	numMin, numMax float64
+}
+
+func NewMetadataSummaryBuilder(keys []string) *MetadataSummaryBuilder {
+	b := &MetadataSummaryBuilder{}
+	for _, k := range keys {
+		b.keys = append(b.keys, &keySummaryBuilder{
+			name:    k,
+			values:  map[string]struct{}{},
+			numeric: true,
+			numMin:  math.Inf(1),
+			numMax:  math.Inf(-1),
+		})
+	}
+	return b
+}
+
+// Add adds the structured metadata of an entry of the chunk.
+func (b *MetadataSummaryBuilder) Add(metadata labels.Labels) {
+	for _, k := range b.keys {
+		k.add(metadata)
+	}
+}
+
+func (k *keySummaryBuilder) add(metadata labels.Labels) {
+	for _, l := range metadata {
+		if l.Name != k.name {
+			continue
+		}
+
+		k.present = true
+		k.values[l.Value] = struct{}{}
+		if !k.numeric {
+			return
+		}
+		v, err := strconv.ParseFloat(l.Value, 64)
+		if err != nil || math.IsNaN(v) {
+			k.numeric = false
+			return
+		}
+		k.numMin = math.Min(k.numMin, v)
+		k.numMax = math.Max(k.numMax, v)
+		return
+	}
+	k.values[""] = struct{}{}
+}
+
+// Build returns the summary of the keys added so far, or an empty summary if
+// no entry was added.
+func (b *MetadataSummaryBuilder) Build() MetadataSummary {
+	var keys []KeySummary
+	for _, k := range b.keys {
+		if len(k.values) == 0 {
+			continue
+		}
+
+		values := make([]string, 0, len(k.values))
+		for v := range k.values {
+			values = append(values, v)
+		}
+		sort.Strings(values)
+
+		s := KeySummary{
+			Name:    k.name,
+			Min:     values[0],
+			Max:     values[len(values)-1],
+			Numeric: k.present && k.numeric,
+		}
+		if s.Numeric {
+			s.NumMin, s.NumMax = k.numMin, k.numMax
+		}
+		if len(values) <= maxSummaryValues {
+			s.Values = values
+		} else {
+			s.Bloom = newBloom(values)
+		}
+		keys = append(keys, s)
+	}
+	return EncodeMetadataSummary(keys)
+}
+
+func newBloom(values []string) []byte {
+	size := min((len(values)*summaryBloomBitsPerValue+7)/8, maxSummaryBloomBytes)
+	bloom := make([]byte, size)
+	for _, v := range values {
+		h1, h2 := bloomHashes(v)
+		for i := uint32(0); i < summaryBloomHashes; i++ {
+			bit := (h1 + i*h2) % uint32(len(bloom)*8)
+			bloom[bit/8] |= 1 << (bit % 8)
+		}
+	}
+	return bloom
+}
+
+func bloomTest(bloom []byte, value string) bool {
+	h1, h2 := bloomHashes(value)
+	for i := uint32(0); i < summaryBloomHashes; i++ {
+		bit := (h1 + i*h2) % uint32(len(bloom)*8)
+		if bloom[bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func bloomHashes(value string) (uint32, uint32) {
+	h := xxhash.Sum64String(value)
+	return uint32(h), uint32(h >> 32)
+}
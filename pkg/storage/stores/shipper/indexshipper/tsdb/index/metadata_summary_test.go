@@ -0,0 +1,60 @@
+package index
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetadataSummary(t *testing.T) {
+	b := NewMetadataSummaryBuilder([]string{"pod", "trace_id"})
+	for i := 0; i < 100; i++ {
+		metadata := labels.FromStrings("pod", fmt.Sprint("pod-", i%3), "trace_id", fmt.Sprintf("trace-%03d", i))
+		if i == 50 {
+			metadata = labels.FromStrings("pod", "pod-0")
+		}
+		b.Add(metadata)
+	}
+
+	keys, err := b.Build().Decode()
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+
+	pod := keys[0]
+	require.Equal(t, []string{"pod-0", "pod-1", "pod-2"}, pod.Values)
+	require.False(t, pod.Numeric)
+	require.True(t, pod.MayContain("pod-1"))
+	require.False(t, pod.MayContain("pod-3"))
+	require.True(t, pod.MayMatch(labels.MustNewMatcher(labels.MatchRegexp, "pod", "pod-[12]")))
+	require.False(t, pod.MayMatch(labels.MustNewMatcher(labels.MatchEqual, "pod", "")))
+	require.False(t, pod.MayMatch(labels.MustNewMatcher(labels.MatchRegexp, "pod", "other.*")))
+
+	// the trace ids are too many to be listed, and an entry lacks them.
+	trace := keys[1]
+	require.Nil(t, trace.Values)
+	require.NotNil(t, trace.Bloom)
+	require.Equal(t, "", trace.Min)
+	require.Equal(t, "trace-099", trace.Max)
+	for i := 0; i < 100; i++ {
+		if i != 50 {
+			require.True(t, trace.MayContain(fmt.Sprintf("trace-%03d", i)))
+		}
+	}
+	require.True(t, trace.MayContain(""))
+	require.False(t, trace.MayContain("trace-100"))
+	require.True(t, trace.MayMatch(labels.MustNewMatcher(labels.MatchRegexp, "trace_id", "nope.*")))
+
+	var falsePositives int
+	for i := 0; i < 1000; i++ {
+		if trace.MayContain(fmt.Sprintf("trace-0%02d-x", i)) {
+			falsePositives++
+		}
+	}
+	require.Less(t, falsePositives, 50)
+
+	require.Equal(t, MetadataSummary(""), NewMetadataSummaryBuilder([]string{"pod"}).Build())
+	_, err = MetadataSummary("\x05").Decode()
+	require.Error(t, err)
+}
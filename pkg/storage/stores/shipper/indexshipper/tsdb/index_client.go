@@ -117,8 +117,13 @@ func (c *IndexClient) GetChunkRefs(ctx context.Context, userID string, from, thr
 		return nil, err
 	}
 
+	metadataFilter := newStructuredMetadataFilter(predicate.Plan().AST)
+
 	refs := make([]logproto.ChunkRef, 0, len(chks))
 	for _, chk := range chks {
+		if metadataFilter != nil && chk.Summary != "" && !metadataFilter.MayMatch(chk.Summary) {
+			continue
+		}
 		refs = append(refs, logproto.ChunkRef{
 			Fingerprint: uint64(chk.Fingerprint),
 			UserID:      chk.User,
@@ -146,11 +146,14 @@ func (i *MultiIndex) GetChunkRefs(ctx context.Context, userID string, from, thro
 		for _, group := range xs {
 			g := group.([]ChunkRef)
 			for _, ref := range g {
-				_, ok := seen[ref]
+				// the same chunk may be indexed with and without a summary
+				key := ref
+				key.Summary = ""
+				_, ok := seen[key]
 				if ok {
 					continue
 				}
-				seen[ref] = struct{}{}
+				seen[key] = struct{}{}
 				res = append(res, ref)
 			}
 			ChunkRefsPool.Put(g)
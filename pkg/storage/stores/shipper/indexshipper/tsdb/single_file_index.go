@@ -223,6 +223,7 @@ func (i *TSDBIndex) GetChunkRefs(ctx context.Context, userID string, from, throu
 				Start:       chk.From(),
 				End:         chk.Through(),
 				Checksum:    chk.Checksum,
+				Summary:     chk.Summary,
 			})
 		}
 	}, matchers...); err != nil {
@@ -27,10 +27,18 @@ type IndexWriter interface {
 	Append(userID string, ls labels.Labels, fprint uint64, chks tsdbindex.ChunkMetas) error
 }
 
+// StoreLimits are the per-tenant limits used by the store.
+type StoreLimits interface {
+	downloads.Limits
+	TSDBStructuredMetadataIndexKeys(userID string) []string
+}
+
 type store struct {
 	index.Reader
 	indexShipper indexshipper.IndexShipper
 	indexWriter  IndexWriter
+	schemaCfg    config.SchemaConfig
+	limits       StoreLimits
 	logger       log.Logger
 	stopOnce     sync.Once
 }
@@ -42,7 +50,7 @@ func NewStore(
 	schemaCfg config.SchemaConfig,
 	_ *fetcher.Fetcher,
 	objectClient client.ObjectClient,
-	limits downloads.Limits,
+	limits StoreLimits,
 	tableRange config.TableRange,
 	reg prometheus.Registerer,
 	logger log.Logger,
@@ -53,7 +61,9 @@ func NewStore(
 ) {
 
 	storeInstance := &store{
-		logger: logger,
+		schemaCfg: schemaCfg,
+		limits:    limits,
+		logger:    logger,
 	}
 
 	if err := storeInstance.init(name, prefix, indexShipperCfg, schemaCfg, objectClient, limits, tableRange, reg); err != nil {
@@ -149,7 +159,7 @@ func (s *store) Stop() {
 	})
 }
 
-func (s *store) IndexChunk(_ context.Context, _ model.Time, _ model.Time, chk chunk.Chunk) error {
+func (s *store) IndexChunk(ctx context.Context, _ model.Time, _ model.Time, chk chunk.Chunk) error {
 	// Always write the index to benefit durability via replication factor.
 	approxKB := math.Round(float64(chk.Data.UncompressedSize()) / float64(1<<10))
 	metas := tsdbindex.ChunkMetas{
@@ -161,12 +171,30 @@ func (s *store) IndexChunk(_ context.Context, _ model.Time, _ model.Time, chk ch
 			Entries:  uint32(chk.Data.Entries()),
 		},
 	}
+	if keys := s.limits.TSDBStructuredMetadataIndexKeys(chk.UserID); len(keys) > 0 && s.storesSummaries(chk.From) {
+		summary, err := summarizeStructuredMetadata(ctx, chk, keys)
+		if err != nil {
+			return errors.Wrap(err, "summarizing structured metadata")
+		}
+		metas[0].Summary = summary
+	}
 	if err := s.indexWriter.Append(chk.UserID, chk.Metric, chk.ChunkRef.Fingerprint, metas); err != nil {
 		return errors.Wrap(err, "writing index entry")
 	}
 	return nil
 }
 
+// storesSummaries returns whether the index of the period starting before the
+// given time stores the structured metadata summaries of the chunks.
+func (s *store) storesSummaries(t model.Time) bool {
+	periodConfig, err := s.schemaCfg.SchemaForTime(t)
+	if err != nil {
+		return false
+	}
+	format, err := periodConfig.TSDBFormat()
+	return err == nil && format >= tsdbindex.FormatV4
+}
+
 type failingIndexWriter struct{}
 
 func (f failingIndexWriter) Append(_ string, _ labels.Labels, _ uint64, _ tsdbindex.ChunkMetas) error {
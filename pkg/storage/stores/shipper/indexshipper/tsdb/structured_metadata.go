@@ -0,0 +1,153 @@
+package tsdb
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/pkg/chunkenc"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql/log"
+	"github.com/grafana/loki/pkg/logql/syntax"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/storage/stores/shipper/indexshipper/tsdb/index"
+)
+
+// summarizeStructuredMetadata summarizes the values of the given structured
+// metadata keys in the entries of the chunk. The keys which are also labels
+// of the stream are skipped, since label filters on them apply to the stream
+// labels.
+func summarizeStructuredMetadata(ctx context.Context, chk chunk.Chunk, keys []string) (index.MetadataSummary, error) {
+	facade, ok := chk.Data.(*chunkenc.Facade)
+	if !ok {
+		return "", nil
+	}
+
+	summarized := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if !chk.Metric.Has(k) {
+			summarized = append(summarized, k)
+		}
+	}
+	if len(summarized) == 0 {
+		return "", nil
+	}
+
+	it, err := facade.LokiChunk().Iterator(ctx, time.Unix(0, 0), time.Unix(0, math.MaxInt64), logproto.FORWARD, log.NewNoopPipeline().ForStream(chk.Metric))
+	if err != nil {
+		return "", err
+	}
+	defer it.Close()
+
+	builder := index.NewMetadataSummaryBuilder(summarized)
+	for it.Next() {
+		builder.Add(logproto.FromLabelAdaptersToLabels(it.Entry().StructuredMetadata))
+	}
+	if err := it.Error(); err != nil {
+		return "", err
+	}
+	return builder.Build(), nil
+}
+
+// structuredMetadataFilter tells from the structured metadata summary of a
+// chunk whether any of its entries can pass the label filters of a query.
+type structuredMetadataFilter struct {
+	filters []log.LabelFilterer
+}
+
+// newStructuredMetadataFilter returns the filter of the label filters applied
+// to the structured metadata of the entries, or nil if the query has none.
+func newStructuredMetadataFilter(expr syntax.Expr) *structuredMetadataFilter {
+	filters := syntax.ExtractLabelFiltersBeforeParsers(expr)
+	if len(filters) == 0 {
+		return nil
+	}
+	return &structuredMetadataFilter{filters: filters}
+}
+
+// MayMatch returns false if the summary proves that no entry of the chunk
+// passes the label filters.
+func (f *structuredMetadataFilter) MayMatch(summary index.MetadataSummary) bool {
+	keys, err := summary.Decode()
+	if err != nil || len(keys) == 0 {
+		return true
+	}
+
+	// Numeric filters let all the entries through once an entry failed the
+	// conversion of a label in a previous filter, so they can only be used
+	// while no filter can have failed.
+	mayFail := false
+	for _, filter := range f.filters {
+		filterMayFail := labelFilterMayFail(filter, keys)
+		if !labelFilterMayMatch(filter, keys, !mayFail && !filterMayFail) {
+			return false
+		}
+		mayFail = mayFail || filterMayFail
+	}
+	return true
+}
+
+func labelFilterMayMatch(filter log.LabelFilterer, keys []index.KeySummary, numeric bool) bool {
+	switch f := filter.(type) {
+	case *log.BinaryLabelFilter:
+		if f.And {
+			return labelFilterMayMatch(f.Left, keys, numeric) && labelFilterMayMatch(f.Right, keys, numeric)
+		}
+		return labelFilterMayMatch(f.Left, keys, numeric) || labelFilterMayMatch(f.Right, keys, numeric)
+	case *log.StringLabelFilter:
+		return matcherMayMatch(f.Matcher, keys)
+	case *log.LineFilterLabelFilter:
+		return matcherMayMatch(f.Matcher, keys)
+	case *log.NumericLabelFilter:
+		k := findKeySummary(keys, f.Name)
+		if !numeric || k == nil || !k.Numeric {
+			return true
+		}
+		switch f.Type {
+		case log.LabelFilterEqual:
+			return k.NumMin <= f.Value && f.Value <= k.NumMax
+		case log.LabelFilterNotEqual:
+			return k.NumMin != f.Value || k.NumMax != f.Value
+		case log.LabelFilterGreaterThan:
+			return k.NumMax > f.Value
+		case log.LabelFilterGreaterThanOrEqual:
+			return k.NumMax >= f.Value
+		case log.LabelFilterLesserThan:
+			return k.NumMin < f.Value
+		case log.LabelFilterLesserThanOrEqual:
+			return k.NumMin <= f.Value
+		}
+	}
+	return true
+}
+
+// labelFilterMayFail returns true if the filter can fail to convert the
+// labels of some entries of the chunk.
+func labelFilterMayFail(filter log.LabelFilterer, keys []index.KeySummary) bool {
+	switch f := filter.(type) {
+	case *log.BinaryLabelFilter:
+		return labelFilterMayFail(f.Left, keys) || labelFilterMayFail(f.Right, keys)
+	case *log.StringLabelFilter, *log.LineFilterLabelFilter, *log.NoopLabelFilter:
+		return false
+	case *log.NumericLabelFilter:
+		k := findKeySummary(keys, f.Name)
+		return k == nil || !k.Numeric
+	}
+	return true
+}
+
+func matcherMayMatch(m *labels.Matcher, keys []index.KeySummary) bool {
+	k := findKeySummary(keys, m.Name)
+	return k == nil || k.MayMatch(m)
+}
+
+func findKeySummary(keys []index.KeySummary, name string) *index.KeySummary {
+	for i := range keys {
+		if keys[i].Name == name {
+			return &keys[i]
+		}
+	}
+	return nil
+}
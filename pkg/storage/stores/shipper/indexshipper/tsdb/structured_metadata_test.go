@@ -0,0 +1,107 @@
+package tsdb
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/chunkenc"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql/syntax"
+	"github.com/grafana/loki/pkg/querier/plan"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/storage/stores/shipper/indexshipper/tsdb/index"
+)
+
+func TestSummarizeStructuredMetadata(t *testing.T) {
+	lbs := labels.FromStrings("app", "a")
+	c := chunkenc.NewMemChunk(chunkenc.ChunkFormatV4, chunkenc.EncSnappy, chunkenc.UnorderedWithStructuredMetadataHeadBlockFmt, 256<<10, 0)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, c.Append(&logproto.Entry{
+			Timestamp:          time.Unix(int64(i), 0),
+			Line:               fmt.Sprint(i),
+			StructuredMetadata: logproto.FromLabelsToLabelAdapters(labels.FromStrings("app", "b", "status", fmt.Sprint(200+i))),
+		}))
+	}
+	chk := chunk.NewChunk("user", model.Fingerprint(lbs.Hash()), lbs, chunkenc.NewFacade(c, 0, 0), model.Time(0), model.Time(9000))
+
+	summary, err := summarizeStructuredMetadata(context.Background(), chk, []string{"app", "status", "trace_id"})
+	require.NoError(t, err)
+	keys, err := summary.Decode()
+	require.NoError(t, err)
+
+	// app is a stream label, and trace_id is missing from all the entries.
+	require.Len(t, keys, 2)
+	require.Equal(t, "status", keys[0].Name)
+	require.Equal(t, "200", keys[0].Min)
+	require.Equal(t, "209", keys[0].Max)
+	require.True(t, keys[0].Numeric)
+	require.Equal(t, 200.0, keys[0].NumMin)
+	require.Equal(t, 209.0, keys[0].NumMax)
+	require.Equal(t, index.KeySummary{Name: "trace_id", Values: []string{""}}, keys[1])
+}
+
+func TestIndexClient_StructuredMetadataSummaries(t *testing.T) {
+	var chunks index.ChunkMetas
+	for i := 0; i < 20; i++ {
+		chk := index.ChunkMeta{
+			Checksum: uint32(i),
+			MinTime:  int64(i * 10),
+			MaxTime:  int64(i*10 + 5),
+			KB:       1,
+			Entries:  1,
+		}
+		// the last chunk was indexed without a summary.
+		if i < 19 {
+			b := index.NewMetadataSummaryBuilder([]string{"trace_id", "status"})
+			metadata := labels.FromStrings("trace_id", fmt.Sprint("trace", i))
+			if i > 0 {
+				metadata = labels.FromStrings("status", fmt.Sprint(i*10), "trace_id", fmt.Sprint("trace", i))
+			}
+			b.Add(metadata)
+			chk.Summary = b.Build()
+		}
+		chunks = append(chunks, chk)
+	}
+
+	tsdbFile := buildIndexWithVersion(t, t.TempDir(), index.FormatV4, []LoadableSeries{
+		{Labels: mustParseLabels(`{app="a"}`), Chunks: chunks},
+	})
+	require.Equal(t, index.FormatV4, tsdbFile.Index.(*TSDBIndex).reader.(*index.Reader).Version())
+	indexClient := NewIndexClient(tsdbFile, DefaultIndexClientOptions(), &fakeLimits{})
+
+	for _, tc := range []struct {
+		query    string
+		expected int
+	}{
+		{query: `{app="a"}`, expected: 20},
+		{query: `{app="a"} | trace_id="trace5"`, expected: 2},
+		{query: `{app="a"} |= "foo" | trace_id="nope"`, expected: 1},
+		{query: `{app="a"} | trace_id=~"trace(5|6)"`, expected: 3},
+		{query: `{app="a"} | trace_id="trace5" or trace_id="trace6"`, expected: 3},
+		{query: `{app="a"} | trace_id="trace5", status=10`, expected: 1},
+		// the first chunk has no numeric status.
+		{query: `{app="a"} | status >= 150`, expected: 6},
+		{query: `{app="a"} | status != 50`, expected: 19},
+		// a label filter which can fail to convert the labels makes the
+		// following numeric filters let all the entries through.
+		{query: `{app="a"} | bytes > 1KB | status >= 150`, expected: 20},
+		{query: `{app="a"} | json | trace_id="nope"`, expected: 20},
+		{query: `sum(count_over_time({app="a"} | trace_id="nope" [1m])) / sum(count_over_time({app="a"} [1m]))`, expected: 20},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			expr, err := syntax.ParseExpr(tc.query)
+			require.NoError(t, err)
+			predicate := chunk.NewPredicate([]*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "app", "a")}, &plan.QueryPlan{AST: expr})
+
+			refs, err := indexClient.GetChunkRefs(context.Background(), "user", 0, 1000, predicate)
+			require.NoError(t, err)
+			require.Len(t, refs, tc.expected)
+		})
+	}
+}
@@ -18,7 +18,11 @@ type LoadableSeries struct {
 }
 
 func BuildIndex(t testing.TB, dir string, cases []LoadableSeries) *TSDBFile {
-	b := NewBuilder(index.FormatV3)
+	return buildIndexWithVersion(t, dir, index.FormatV3, cases)
+}
+
+func buildIndexWithVersion(t testing.TB, dir string, version int, cases []LoadableSeries) *TSDBFile {
+	b := NewBuilder(version)
 
 	for _, s := range cases {
 		b.AddSeries(s.Labels, model.Fingerprint(s.Labels.Hash()), s.Chunks)
@@ -0,0 +1,274 @@
+package tiering
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/storage/chunk/client"
+	"github.com/grafana/loki/pkg/storage/config"
+	"github.com/grafana/loki/pkg/util/constants"
+)
+
+const (
+	tierHot  = "hot"
+	tierCold = "cold"
+)
+
+type clientMetrics struct {
+	fetchDuration *prometheus.HistogramVec
+	fetchedChunks *prometheus.CounterVec
+	fetchedBytes  *prometheus.CounterVec
+	fallbacks     *prometheus.CounterVec
+}
+
+func newClientMetrics(r prometheus.Registerer) *clientMetrics {
+	return &clientMetrics{
+		fetchDuration: promauto.With(r).NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: constants.Loki,
+			Name:      "storage_tier_fetch_duration_seconds",
+			Help:      "Time spent fetching chunks from each storage tier.",
+			Buckets:   prometheus.ExponentialBuckets(0.005, 4, 8),
+		}, []string{"tier"}),
+		fetchedChunks: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: constants.Loki,
+			Name:      "storage_tier_fetched_chunks_total",
+			Help:      "Total chunks fetched from each storage tier.",
+		}, []string{"tier"}),
+		fetchedBytes: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: constants.Loki,
+			Name:      "storage_tier_fetched_chunk_bytes_total",
+			Help:      "Total bytes of the chunks fetched from each storage tier.",
+		}, []string{"tier"}),
+		fallbacks: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: constants.Loki,
+			Name:      "storage_tier_fallback_fetched_chunks_total",
+			Help:      "Total chunks fetched from a storage tier after they were not found in the tier resolved from their tier marker, such as while they are being relocated.",
+		}, []string{"tier"}),
+	}
+}
+
+// Client is a chunk client reading and writing the chunks of each tenant from
+// the hot or the cold object store, depending on the tier markers of the
+// tables the chunks belong to.
+//
+// The chunks that are not found in the tier resolved from the tier markers
+// are looked up in the other one, since the markers are cached and written
+// before the copies of the chunks in the hot object store are deleted.
+type Client struct {
+	hot, cold client.Client
+	markers   *Markers
+	schemaCfg config.SchemaConfig
+	metrics   *clientMetrics
+}
+
+func NewClient(hot, cold client.Client, markers *Markers, schemaCfg config.SchemaConfig, r prometheus.Registerer) *Client {
+	return &Client{
+		hot:       hot,
+		cold:      cold,
+		markers:   markers,
+		schemaCfg: schemaCfg,
+		metrics:   newClientMetrics(r),
+	}
+}
+
+// TableFor returns the index table the chunk belongs to for tiering, which is
+// the one its start time falls in.
+func TableFor(schemaCfg config.SchemaConfig, chk chunk.Chunk) (string, error) {
+	period, err := schemaCfg.SchemaForTime(chk.From)
+	if err != nil {
+		return "", err
+	}
+	return period.IndexTables.TableFor(chk.From), nil
+}
+
+func (c *Client) isCold(ctx context.Context, chk chunk.Chunk) (bool, error) {
+	table, err := TableFor(c.schemaCfg, chk)
+	if err != nil {
+		return false, err
+	}
+	return c.markers.IsCold(ctx, table, chk.UserID)
+}
+
+func (c *Client) partition(ctx context.Context, chunks []chunk.Chunk) (hot, cold []chunk.Chunk, err error) {
+	for _, chk := range chunks {
+		isCold, err := c.isCold(ctx, chk)
+		if err != nil {
+			return nil, nil, err
+		}
+		if isCold {
+			cold = append(cold, chk)
+		} else {
+			hot = append(hot, chk)
+		}
+	}
+	return hot, cold, nil
+}
+
+func (c *Client) Stop() {
+	c.hot.Stop()
+	c.cold.Stop()
+}
+
+func (c *Client) PutChunks(ctx context.Context, chunks []chunk.Chunk) error {
+	hot, cold, err := c.partition(ctx, chunks)
+	if err != nil {
+		return err
+	}
+	if len(hot) > 0 {
+		if err := c.hot.PutChunks(ctx, hot); err != nil {
+			return err
+		}
+	}
+	if len(cold) > 0 {
+		return c.cold.PutChunks(ctx, cold)
+	}
+	return nil
+}
+
+func (c *Client) GetChunks(ctx context.Context, chunks []chunk.Chunk) ([]chunk.Chunk, error) {
+	hot, cold, err := c.partition(ctx, chunks)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]chunk.Chunk, 0, len(chunks))
+	for _, tier := range []struct {
+		name           string
+		chunks         []chunk.Chunk
+		from, fallback client.Client
+		fallbackName   string
+	}{
+		{name: tierHot, chunks: hot, from: c.hot, fallback: c.cold, fallbackName: tierCold},
+		{name: tierCold, chunks: cold, from: c.cold, fallback: c.hot, fallbackName: tierHot},
+	} {
+		if len(tier.chunks) == 0 {
+			continue
+		}
+		fetched, err := c.fetch(ctx, tier.name, tier.from, tier.chunks)
+		if err != nil && !tier.from.IsChunkNotFoundErr(err) {
+			return nil, err
+		}
+		result = append(result, fetched...)
+		if err == nil {
+			continue
+		}
+
+		missing := c.missing(tier.chunks, fetched)
+		found, fallbackErr := c.fetch(ctx, tier.fallbackName, tier.fallback, missing)
+		c.metrics.fallbacks.WithLabelValues(tier.fallbackName).Add(float64(len(found)))
+		result = append(result, found...)
+		if fallbackErr != nil {
+			if tier.fallback.IsChunkNotFoundErr(fallbackErr) {
+				// Report the chunks missing from both tiers like the chunk
+				// client of a single object store would.
+				return result, err
+			}
+			return nil, fallbackErr
+		}
+	}
+	return result, nil
+}
+
+func (c *Client) fetch(ctx context.Context, tier string, from client.Client, chunks []chunk.Chunk) ([]chunk.Chunk, error) {
+	start := time.Now()
+	fetched, err := from.GetChunks(ctx, chunks)
+	c.metrics.fetchDuration.WithLabelValues(tier).Observe(time.Since(start).Seconds())
+
+	var size int
+	for _, chk := range fetched {
+		if chk.Data != nil {
+			size += chk.Data.Size()
+		}
+	}
+	c.metrics.fetchedChunks.WithLabelValues(tier).Add(float64(len(fetched)))
+	c.metrics.fetchedBytes.WithLabelValues(tier).Add(float64(size))
+	return fetched, err
+}
+
+func (c *Client) missing(requested, fetched []chunk.Chunk) []chunk.Chunk {
+	found := make(map[string]struct{}, len(fetched))
+	for _, chk := range fetched {
+		found[c.schemaCfg.ExternalKey(chk.ChunkRef)] = struct{}{}
+	}
+
+	var missing []chunk.Chunk
+	for _, chk := range requested {
+		if _, ok := found[c.schemaCfg.ExternalKey(chk.ChunkRef)]; !ok {
+			missing = append(missing, chk)
+		}
+	}
+	return missing
+}
+
+// DeleteChunk deletes the chunk from both tiers, since it can be in either of
+// them while being relocated.
+func (c *Client) DeleteChunk(ctx context.Context, userID, chunkID string) error {
+	hotErr := c.hot.DeleteChunk(ctx, userID, chunkID)
+	if hotErr != nil && !c.hot.IsChunkNotFoundErr(hotErr) {
+		return hotErr
+	}
+	coldErr := c.cold.DeleteChunk(ctx, userID, chunkID)
+	if coldErr != nil && !c.cold.IsChunkNotFoundErr(coldErr) {
+		return coldErr
+	}
+	if hotErr != nil && coldErr != nil {
+		return hotErr
+	}
+	return nil
+}
+
+func (c *Client) IsChunkNotFoundErr(err error) bool {
+	return c.hot.IsChunkNotFoundErr(err) || c.cold.IsChunkNotFoundErr(err)
+}
+
+func (c *Client) IsRetryableErr(err error) bool {
+	return c.hot.IsRetryableErr(err) || c.cold.IsRetryableErr(err)
+}
+
+// Relocate copies the chunks to the cold object store, and returns their
+// size. They have to be deleted from the hot object store with DeleteHot once
+// the tier marker of their table is written. The chunks missing from the hot
+// object store are skipped.
+func (c *Client) Relocate(ctx context.Context, chunks []chunk.Chunk) (int, error) {
+	fetched, err := c.hot.GetChunks(ctx, chunks)
+	if err != nil && !c.hot.IsChunkNotFoundErr(err) {
+		return 0, errors.Wrap(err, "fetching chunks from the hot object store")
+	}
+	if len(fetched) == 0 {
+		return 0, nil
+	}
+
+	var size int
+	for _, chk := range fetched {
+		encoded, err := chk.Encoded()
+		if err != nil {
+			return 0, err
+		}
+		size += len(encoded)
+	}
+	if err := c.cold.PutChunks(ctx, fetched); err != nil {
+		return 0, errors.Wrap(err, "storing chunks in the cold object store")
+	}
+	return size, nil
+}
+
+// DeleteHot deletes the copies of the chunks in the hot object store.
+func (c *Client) DeleteHot(ctx context.Context, chunks []chunk.Chunk) error {
+	for _, chk := range chunks {
+		err := c.hot.DeleteChunk(ctx, chk.UserID, c.schemaCfg.ExternalKey(chk.ChunkRef))
+		if err != nil && !c.hot.IsChunkNotFoundErr(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Markers returns the tier markers of the client.
+func (c *Client) Markers() *Markers {
+	return c.markers
+}
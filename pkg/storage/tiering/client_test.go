@@ -0,0 +1,107 @@
+package tiering
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/chunkenc"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/storage/chunk"
+	"github.com/grafana/loki/pkg/storage/chunk/client"
+	"github.com/grafana/loki/pkg/storage/chunk/client/local"
+	"github.com/grafana/loki/pkg/storage/config"
+)
+
+var schemaCfg = config.SchemaConfig{Configs: []config.PeriodConfig{{
+	From:       config.DayTime{Time: 0},
+	IndexType:  config.TSDBType,
+	ObjectType: "filesystem",
+	Schema:     "v13",
+	IndexTables: config.IndexPeriodicTableConfig{
+		PeriodicTableConfig: config.PeriodicTableConfig{Prefix: "index_", Period: config.ObjectStorageIndexRequiredPeriod},
+	},
+}}}
+
+func newFSClients(t *testing.T, dir string) (client.ObjectClient, client.Client) {
+	objectClient, err := local.NewFSObjectClient(local.FSConfig{Directory: dir})
+	require.NoError(t, err)
+	return objectClient, client.NewClient(objectClient, client.FSEncoder, schemaCfg)
+}
+
+func newChunk(t *testing.T, userID string, from model.Time) chunk.Chunk {
+	lbs := labels.FromStrings("app", "foo")
+	c := chunkenc.NewMemChunk(chunkenc.ChunkFormatV4, chunkenc.EncSnappy, chunkenc.UnorderedWithStructuredMetadataHeadBlockFmt, 256<<10, 0)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, c.Append(&logproto.Entry{
+			Timestamp: from.Add(time.Duration(i) * time.Second).Time(),
+			Line:      fmt.Sprint(i),
+		}))
+	}
+	require.NoError(t, c.Close())
+	chkFrom, chkThrough := c.Bounds()
+	chk := chunk.NewChunk(userID, model.Fingerprint(lbs.Hash()), lbs, chunkenc.NewFacade(c, 0, 0), model.TimeFromUnixNano(chkFrom.UnixNano()), model.TimeFromUnixNano(chkThrough.UnixNano()))
+	require.NoError(t, chk.Encode())
+	return chk
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	_, hot := newFSClients(t, filepath.Join(dir, "hot"))
+	coldObjects, cold := newFSClients(t, filepath.Join(dir, "cold"))
+
+	day := model.Time(config.ObjectStorageIndexRequiredPeriod.Milliseconds())
+	hotChunk := newChunk(t, "user", 10*day)
+	coldChunk := newChunk(t, "user", day)
+	table, err := TableFor(schemaCfg, coldChunk)
+	require.NoError(t, err)
+	require.Equal(t, "index_1", table)
+
+	markers := NewMarkers(coldObjects, time.Hour)
+	c := NewClient(hot, cold, markers, schemaCfg, prometheus.NewRegistry())
+
+	require.NoError(t, c.PutChunks(ctx, []chunk.Chunk{hotChunk, coldChunk}))
+	state, err := markers.State(ctx, table, "user")
+	require.NoError(t, err)
+	require.Empty(t, state)
+
+	// The chunks of the table are relocated while the client cached the
+	// absence of the tier marker, so it falls back to the cold object store.
+	_, err = c.Relocate(ctx, []chunk.Chunk{coldChunk})
+	require.NoError(t, err)
+	require.NoError(t, NewMarkers(coldObjects, time.Hour).Mark(ctx, table, "user", MarkerCopied))
+	require.NoError(t, c.DeleteHot(ctx, []chunk.Chunk{coldChunk}))
+
+	chks, err := c.GetChunks(ctx, []chunk.Chunk{hotChunk, coldChunk})
+	require.NoError(t, err)
+	require.Len(t, chks, 2)
+	require.Equal(t, 1.0, testutil.ToFloat64(c.metrics.fallbacks.WithLabelValues(tierCold)))
+	require.Equal(t, 1.0, testutil.ToFloat64(c.metrics.fetchedChunks.WithLabelValues(tierCold)))
+
+	// A client without the cached marker reads the chunks from their tier.
+	fresh := NewClient(hot, cold, NewMarkers(coldObjects, time.Hour), schemaCfg, prometheus.NewRegistry())
+	chks, err = fresh.GetChunks(ctx, []chunk.Chunk{hotChunk, coldChunk})
+	require.NoError(t, err)
+	require.Len(t, chks, 2)
+	require.Equal(t, 0.0, testutil.ToFloat64(fresh.metrics.fallbacks.WithLabelValues(tierCold)))
+	require.Equal(t, 1.0, testutil.ToFloat64(fresh.metrics.fetchedChunks.WithLabelValues(tierHot)))
+	require.Equal(t, 1.0, testutil.ToFloat64(fresh.metrics.fetchedChunks.WithLabelValues(tierCold)))
+
+	// The chunks are deleted from whichever tier they are in.
+	coldKey := schemaCfg.ExternalKey(coldChunk.ChunkRef)
+	require.NoError(t, fresh.DeleteChunk(ctx, "user", coldKey))
+	err = fresh.DeleteChunk(ctx, "user", coldKey)
+	require.True(t, fresh.IsChunkNotFoundErr(err))
+
+	_, err = fresh.GetChunks(ctx, []chunk.Chunk{hotChunk, coldChunk})
+	require.True(t, fresh.IsChunkNotFoundErr(err))
+}
@@ -0,0 +1,141 @@
+package tiering
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/grafana/loki/pkg/storage/chunk/client"
+)
+
+// markersPrefix is the prefix of the objects of the tier markers in the cold
+// object store.
+const markersPrefix = "tier-markers/"
+
+// The states of the relocation of the chunks of a tenant in a table, stored
+// as the content of its tier marker.
+const (
+	// MarkerCopied is the state once the chunks are copied to the cold object
+	// store, while their copies in the hot object store are being deleted.
+	MarkerCopied = "copied"
+	// MarkerComplete is the state once the copies of the chunks in the hot
+	// object store are deleted.
+	MarkerComplete = "complete"
+)
+
+// Config configures the cold object store the compactor relocates the chunks
+// of the tenants with cold_storage_after to.
+type Config struct {
+	Store          string        `yaml:"store"`
+	MarkerCacheTTL time.Duration `yaml:"marker_cache_ttl"`
+}
+
+// RegisterFlagsWithPrefix registers flags.
+func (cfg *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.StringVar(&cfg.Store, prefix+"cold-storage.store", "", "Experimental. Object store the compactor relocates the chunks of the tenants with cold_storage_after to, such as a named store configured with a cheaper bucket or storage class. Chunks are read from the tier they were relocated to. Leave empty to disable storage tiering.")
+	f.DurationVar(&cfg.MarkerCacheTTL, prefix+"cold-storage.marker-cache-ttl", 5*time.Minute, "How long the absence of the tier marker of the chunks of a tenant in a table is cached for, before checking again whether the chunks were relocated to the cold object store.")
+}
+
+// Enabled returns whether storage tiering is enabled.
+func (cfg *Config) Enabled() bool {
+	return cfg.Store != ""
+}
+
+// Markers stores the tier markers of the tables of the tenants in the cold
+// object store.
+//
+// The chunks of a tenant are relocated to the cold object store table by
+// table, and the tier marker of the tenant in the table is the object
+// tier-markers/<table>/<tenant>, which exists once its chunks are in the cold
+// object store. The chunks are attributed to the table their start time falls
+// in, even when they are also indexed in the next one.
+type Markers struct {
+	client client.ObjectClient
+	ttl    time.Duration
+	now    func() time.Time
+
+	mtx   sync.Mutex
+	cache map[string]cachedMarker
+}
+
+type cachedMarker struct {
+	cold    bool
+	checked time.Time
+}
+
+func NewMarkers(client client.ObjectClient, ttl time.Duration) *Markers {
+	return &Markers{
+		client: client,
+		ttl:    ttl,
+		now:    time.Now,
+		cache:  map[string]cachedMarker{},
+	}
+}
+
+func markerKey(table, tenant string) string {
+	return markersPrefix + table + "/" + tenant
+}
+
+// IsCold returns whether the chunks of the tenant in the table are in the cold
+// object store. Chunks never move back to the hot object store, so only the
+// absence of the marker is cached for the TTL.
+func (m *Markers) IsCold(ctx context.Context, table, tenant string) (bool, error) {
+	key := markerKey(table, tenant)
+
+	m.mtx.Lock()
+	cached, ok := m.cache[key]
+	m.mtx.Unlock()
+	if ok && (cached.cold || m.now().Sub(cached.checked) < m.ttl) {
+		return cached.cold, nil
+	}
+
+	// The object clients return an error for the objects that don't exist.
+	cold, err := m.client.ObjectExists(ctx, key)
+	if err != nil && m.client.IsObjectNotFoundErr(err) {
+		cold, err = false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("checking tier marker of tenant %s in table %s: %w", tenant, table, err)
+	}
+	m.mtx.Lock()
+	m.cache[key] = cachedMarker{cold: cold, checked: m.now()}
+	m.mtx.Unlock()
+	return cold, nil
+}
+
+// State returns the state of the relocation of the chunks of the tenant in the
+// table, or an empty string if they are in the hot object store.
+func (m *Markers) State(ctx context.Context, table, tenant string) (string, error) {
+	rc, _, err := m.client.GetObject(ctx, markerKey(table, tenant))
+	if err != nil {
+		if m.client.IsObjectNotFoundErr(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("getting tier marker of tenant %s in table %s: %w", tenant, table, err)
+	}
+	defer rc.Close()
+
+	state, err := io.ReadAll(rc)
+	if err != nil {
+		return "", fmt.Errorf("reading tier marker of tenant %s in table %s: %w", tenant, table, err)
+	}
+	return string(state), nil
+}
+
+// Mark sets the state of the relocation of the chunks of the tenant in the
+// table, marking them as in the cold object store.
+func (m *Markers) Mark(ctx context.Context, table, tenant, state string) error {
+	key := markerKey(table, tenant)
+	if err := m.client.PutObject(ctx, key, bytes.NewReader([]byte(state))); err != nil {
+		return fmt.Errorf("storing tier marker of tenant %s in table %s: %w", tenant, table, err)
+	}
+
+	m.mtx.Lock()
+	m.cache[key] = cachedMarker{cold: true, checked: m.now()}
+	m.mtx.Unlock()
+	return nil
+}
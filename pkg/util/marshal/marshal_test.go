@@ -503,6 +503,38 @@ var tailTests = []struct {
 			]
 		}`,
 	},
+	{
+		legacy.TailResponse{
+			Streams: []logproto.Stream{
+				{
+					Entries: []logproto.Entry{
+						{
+							Timestamp: time.Unix(0, 123456789012345),
+							Line:      "super line",
+						},
+					},
+					Labels: "{test=\"test\"}",
+				},
+			},
+			Cursor: &logproto.TailCursor{
+				Timestamp:  time.Unix(0, 123456789012345),
+				StreamHash: 0xabcdef,
+			},
+		},
+		`{
+			"streams": [
+				{
+					"stream": {
+						"test": "test"
+					},
+					"values":[
+						[ "123456789012345", "super line"]
+					]
+				}
+			],
+			"cursor": "123456789012345-abcdef"
+		}`,
+	},
 }
 
 var tailTestWithEncodingFlags = []struct {
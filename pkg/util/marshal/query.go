@@ -209,6 +209,12 @@ func EncodeTailResult(data legacy.TailResponse, s *jsoniter.Stream, encodeFlags
 		}
 	}
 
+	if data.Cursor != nil {
+		s.WriteMore()
+		s.WriteObjectField("cursor")
+		s.WriteString(loghttp.FormatTailCursor(data.Cursor))
+	}
+
 	if len(encodeFlags) > 0 {
 		s.WriteMore()
 		s.WriteObjectField("encodingFlags")
@@ -19,7 +19,10 @@ import (
 	"golang.org/x/time/rate"
 	"gopkg.in/yaml.v2"
 
+	"github.com/grafana/loki/pkg/chunkenc"
 	"github.com/grafana/loki/pkg/compactor/deletionmode"
+	"github.com/grafana/loki/pkg/distributor/droprules"
+	"github.com/grafana/loki/pkg/distributor/relabel"
 	"github.com/grafana/loki/pkg/distributor/shardstreams"
 	"github.com/grafana/loki/pkg/loghttp/push"
 	"github.com/grafana/loki/pkg/logql/syntax"
@@ -78,6 +81,20 @@ type Limits struct {
 	MaxLineSizeTruncate         bool             `yaml:"max_line_size_truncate" json:"max_line_size_truncate"`
 	IncrementDuplicateTimestamp bool             `yaml:"increment_duplicate_timestamp" json:"increment_duplicate_timestamp"`
 
+	StreamRelabelConfigs []*util.RelabelConfig `yaml:"relabel_configs,omitempty" json:"relabel_configs,omitempty" doc:"description=List of Prometheus-style relabel configurations applied by the distributor to the labels of every pushed stream, before the labels are validated. Supported actions are the Prometheus ones (e.g. drop, keep, replace, labeldrop, hashmod) plus 'structured_metadata', which moves the labels whose names match 'regex' into the structured metadata of every entry of the stream."`
+	StreamRelabelRules   relabel.Rules         `yaml:"-" json:"-"` // populated during validation.
+
+	IngestDropRules         []string        `yaml:"ingest_drop_rules,omitempty" json:"ingest_drop_rules,omitempty" doc:"description=List of LogQL expressions, each made of a stream selector optionally followed by line filters. The distributor drops the pushed lines that match any of the expressions, and reports them as discarded with the 'ingest_drop_rule' reason. A selector without line filters drops all the lines of the matching streams."`
+	IngestDropRulesCompiled droprules.Rules `yaml:"-" json:"-"` // populated during validation.
+
+	DemoteHighCardinalityLabelsThreshold int            `yaml:"demote_high_cardinality_labels_threshold" json:"demote_high_cardinality_labels_threshold"`
+	DemoteHighCardinalityLabelsWindow    model.Duration `yaml:"demote_high_cardinality_labels_window" json:"demote_high_cardinality_labels_window"`
+
+	MaxPushBatchSessions  int `yaml:"max_push_batch_sessions" json:"max_push_batch_sessions"`
+	MaxPushBatchLabelSets int `yaml:"max_push_batch_label_sets" json:"max_push_batch_label_sets"`
+
+	TeeRules []TeeRule `yaml:"tee_rules,omitempty" json:"tee_rules,omitempty" doc:"description=Rules routing the pushed streams of the tenant to the tees configured in the distributor 'tees' block. A stream matching the selector of a rule is duplicated to the tee of the rule, in addition to being pushed to the ingesters."`
+
 	// Ingester enforced limits.
 	MaxLocalStreamsPerUser  int              `yaml:"max_streams_per_user" json:"max_streams_per_user"`
 	MaxGlobalStreamsPerUser int              `yaml:"max_global_streams_per_user" json:"max_global_streams_per_user"`
@@ -85,6 +102,11 @@ type Limits struct {
 	PerStreamRateLimit      flagext.ByteSize `yaml:"per_stream_rate_limit" json:"per_stream_rate_limit"`
 	PerStreamRateLimitBurst flagext.ByteSize `yaml:"per_stream_rate_limit_burst" json:"per_stream_rate_limit_burst"`
 
+	OutOfOrderWindow         model.Duration           `yaml:"out_of_order_window" json:"out_of_order_window"`
+	OutOfOrderWindowPolicies []OutOfOrderWindowPolicy `yaml:"out_of_order_window_policies,omitempty" json:"out_of_order_window_policies,omitempty" doc:"description=Out-of-order windows applied to the streams matching a selector, instead of out_of_order_window. The first policy whose selector matches a stream applies to it."`
+
+	StreamRateLimitPolicies []StreamRateLimitPolicy `yaml:"stream_rate_limit_policies,omitempty" json:"stream_rate_limit_policies,omitempty" doc:"description=Per-stream rate limits applied to the streams matching a selector, instead of per_stream_rate_limit and per_stream_rate_limit_burst. The first policy whose selector matches a stream applies to it. The policies are only enforced by the ingesters, the distributors only report the rate of the streams matching them."`
+
 	// Querier enforced limits.
 	MaxChunksPerQuery          int              `yaml:"max_chunks_per_query" json:"max_chunks_per_query"`
 	MaxQuerySeries             int              `yaml:"max_query_series" json:"max_query_series"`
@@ -170,7 +192,17 @@ type Limits struct {
 
 	// Global and per tenant retention
 	RetentionPeriod model.Duration    `yaml:"retention_period" json:"retention_period"`
-	StreamRetention []StreamRetention `yaml:"retention_stream,omitempty" json:"retention_stream,omitempty" doc:"description=Per-stream retention to apply, if the retention is enable on the compactor side.\nExample:\n retention_stream:\n - selector: '{namespace=\"dev\"}'\n priority: 1\n period: 24h\n- selector: '{container=\"nginx\"}'\n priority: 1\n period: 744h\nSelector is a Prometheus labels matchers that will apply the 'period' retention only if the stream is matching. In case multiple stream are matching, the highest priority will be picked. If no rule is matched the 'retention_period' is used."`
+	StreamRetention []StreamRetention `yaml:"retention_stream,omitempty" json:"retention_stream,omitempty" doc:"description=Per-stream retention to apply, if the retention is enable on the compactor side.\nExample:\n retention_stream:\n - selector: '{namespace=\"dev\"}'\n priority: 1\n period: 24h\n- selector: '{container=\"nginx\"}'\n priority: 1\n period: 744h\nSelector is a Prometheus labels matchers that will apply the 'period' retention only if the stream is matching. In case multiple stream are matching, the highest priority will be picked. If no rule is matched the 'retention_period' is used.\nThe selector can be followed by line filters and label filters on structured metadata, for example '{namespace=\"dev\"} | level=\"debug\"', in which case the 'period' retention only applies to the matching log lines of the stream, and the compactor rewrites the chunks to drop the expired lines."`
+
+	// Per tenant zstd dictionaries
+	ZstdDictionariesEnabled bool `yaml:"zstd_dictionaries_enabled" json:"zstd_dictionaries_enabled"`
+
+	// Per tenant chunk merging
+	ChunkMergeRateMB   float64 `yaml:"compactor_chunk_merge_rate_mb" json:"compactor_chunk_merge_rate_mb"`
+	ChunkMergeEncoding string  `yaml:"compactor_chunk_merge_encoding" json:"compactor_chunk_merge_encoding"`
+
+	// Per tenant storage tiering
+	ColdStorageAfter model.Duration `yaml:"cold_storage_after" json:"cold_storage_after"`
 
 	// Config for overrides, convenient if it goes here.
 	PerTenantOverrideConfig string         `yaml:"per_tenant_override_config" json:"per_tenant_override_config"`
@@ -195,23 +227,105 @@ type Limits struct {
 	BloomCompactorEnabled                    bool             `yaml:"bloom_compactor_enable_compaction" json:"bloom_compactor_enable_compaction"`
 	BloomNGramLength                         int              `yaml:"bloom_ngram_length" json:"bloom_ngram_length"`
 	BloomNGramSkip                           int              `yaml:"bloom_ngram_skip" json:"bloom_ngram_skip"`
+	BloomLowercaseNGrams                     bool             `yaml:"bloom_lowercase_ngrams" json:"bloom_lowercase_ngrams"`
 	BloomFalsePositiveRate                   float64          `yaml:"bloom_false_positive_rate" json:"bloom_false_positive_rate"`
 	BloomGatewayBlocksDownloadingParallelism int              `yaml:"bloom_gateway_blocks_downloading_parallelism" json:"bloom_gateway_blocks_downloading_parallelism"`
 	BloomGatewayCacheKeyInterval             time.Duration    `yaml:"bloom_gateway_cache_key_interval" json:"bloom_gateway_cache_key_interval"`
 	BloomCompactorMaxBlockSize               flagext.ByteSize `yaml:"bloom_compactor_max_block_size" json:"bloom_compactor_max_block_size"`
+	BloomStructuredMetadataKeys              []string         `yaml:"bloom_structured_metadata_keys,omitempty" json:"bloom_structured_metadata_keys,omitempty" doc:"description=Structured metadata keys whose key=value pairs are added to the blooms next to the n-grams of the log lines. The bloom gateway uses them to filter out the chunks for the label filters on these keys placed before any parser. Changing the keys makes the bloom compactor rebuild the blocks, while reordering or repeating them doesn't."`
 
 	AllowStructuredMetadata           bool                  `yaml:"allow_structured_metadata,omitempty" json:"allow_structured_metadata,omitempty" doc:"description=Allow user to send structured metadata in push payload."`
 	MaxStructuredMetadataSize         flagext.ByteSize      `yaml:"max_structured_metadata_size" json:"max_structured_metadata_size" doc:"description=Maximum size accepted for structured metadata per log line."`
 	MaxStructuredMetadataEntriesCount int                   `yaml:"max_structured_metadata_entries_count" json:"max_structured_metadata_entries_count" doc:"description=Maximum number of structured metadata entries per log line."`
+	TSDBStructuredMetadataIndexKeys   []string              `yaml:"tsdb_structured_metadata_index_keys,omitempty" json:"tsdb_structured_metadata_index_keys,omitempty" doc:"description=Structured metadata keys summarized in the TSDB index for each chunk, with the bounds and the set of their values. Chunks whose summary proves that no entry passes the label filters of a query, placed before any parser, are skipped without being fetched. Only stored for the periods using schema v14, whose TSDB index files are unreadable by the versions not supporting the summaries."`
 	OTLPConfig                        push.OTLPConfig       `yaml:"otlp_config" json:"otlp_config" doc:"description=OTLP log ingestion configurations"`
 	GlobalOTLPConfig                  push.GlobalOTLPConfig `yaml:"-" json:"-"`
+
+	ElasticsearchConfig push.FieldMappingConfig `yaml:"elasticsearch_config" json:"elasticsearch_config" doc:"description=Configuration of the conversion of the documents pushed to the Elasticsearch bulk API endpoint into log entries. By default the 'message' field is used as the log line and the '@timestamp' field as the timestamp, in milliseconds when numeric. The index of a bulk action is available as the 'elasticsearch.index' field."`
+	SplunkHECConfig     push.FieldMappingConfig `yaml:"splunk_hec_config" json:"splunk_hec_config" doc:"description=Configuration of the conversion of the events pushed to the Splunk HTTP Event Collector endpoint into log entries. By default the 'event' field is used as the log line and the 'time' field as the timestamp, in seconds when numeric. The indexed fields of an event are mapped like its top-level fields."`
+}
+
+type TeeRule struct {
+	Tee      string            `yaml:"tee" json:"tee" doc:"description:Name of the tee the matching streams are duplicated to."`
+	Selector string            `yaml:"selector" json:"selector" doc:"description:Stream selector expression."`
+	Matchers []*labels.Matcher `yaml:"-" json:"-"` // populated during validation.
+}
+
+type StreamRateLimitPolicy struct {
+	Name     string            `yaml:"name" json:"name" doc:"description:Name of the policy, used as the policy label of the metrics."`
+	Selector string            `yaml:"selector" json:"selector" doc:"description:Stream selector expression."`
+	Rate     flagext.ByteSize  `yaml:"rate" json:"rate" doc:"description:Maximum byte rate per second of each matching stream."`
+	Burst    flagext.ByteSize  `yaml:"burst" json:"burst" doc:"description:Maximum burst bytes of each matching stream. Defaults to the rate."`
+	Matchers []*labels.Matcher `yaml:"-" json:"-"` // populated during validation.
+}
+
+// RateLimit returns the per-stream rate limit of the policy.
+func (p *StreamRateLimitPolicy) RateLimit() RateLimit {
+	return RateLimit{
+		Limit: rate.Limit(float64(p.Rate.Val())),
+		Burst: p.Burst.Val(),
+	}
+}
+
+// Matches returns true if the given stream labels match the selector of the policy.
+func (p *StreamRateLimitPolicy) Matches(lbs labels.Labels) bool {
+	for _, m := range p.Matchers {
+		if !m.Matches(lbs.Get(m.Name)) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchStreamRateLimitPolicy returns the first of the policies matching the
+// given stream labels, or nil if none does.
+func MatchStreamRateLimitPolicy(policies []StreamRateLimitPolicy, lbs labels.Labels) *StreamRateLimitPolicy {
+	for i := range policies {
+		if policies[i].Matches(lbs) {
+			return &policies[i]
+		}
+	}
+	return nil
+}
+
+// OutOfOrderWindowPolicy sets the out-of-order window of the streams matching
+// a selector.
+type OutOfOrderWindowPolicy struct {
+	Name     string            `yaml:"name" json:"name" doc:"description:Name of the policy, used as the policy label of the out-of-order lateness metric."`
+	Selector string            `yaml:"selector" json:"selector" doc:"description:Stream selector expression."`
+	Window   model.Duration    `yaml:"window" json:"window" doc:"description:How far behind the newest entry of a matching stream an entry can be and still be accepted."`
+	Matchers []*labels.Matcher `yaml:"-" json:"-"` // populated during validation.
+}
+
+// Matches returns true if the given stream labels match the selector of the policy.
+func (p *OutOfOrderWindowPolicy) Matches(lbs labels.Labels) bool {
+	for _, m := range p.Matchers {
+		if !m.Matches(lbs.Get(m.Name)) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchOutOfOrderWindowPolicy returns the first of the policies matching the
+// given stream labels, or nil if none does.
+func MatchOutOfOrderWindowPolicy(policies []OutOfOrderWindowPolicy, lbs labels.Labels) *OutOfOrderWindowPolicy {
+	for i := range policies {
+		if policies[i].Matches(lbs) {
+			return &policies[i]
+		}
+	}
+	return nil
 }
 
 type StreamRetention struct {
 	Period   model.Duration    `yaml:"period" json:"period" doc:"description:Retention period applied to the log lines matching the selector."`
 	Priority int               `yaml:"priority" json:"priority" doc:"description:The larger the value, the higher the priority."`
-	Selector string            `yaml:"selector" json:"selector" doc:"description:Stream selector expression."`
+	Selector string            `yaml:"selector" json:"selector" doc:"description:Stream selector expression, optionally followed by line filters and label filters on structured metadata."`
 	Matchers []*labels.Matcher `yaml:"-" json:"-"` // populated during validation.
+	// LogSelector is populated during validation when the selector has filters,
+	// in which case the period only applies to the log lines matching them.
+	LogSelector syntax.LogSelectorExpr `yaml:"-" json:"-"`
 }
 
 // LimitError are errors that do not comply with the limits specified.
@@ -236,6 +350,13 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&l.RejectOldSamples, "validation.reject-old-samples", true, "Whether or not old samples will be rejected.")
 	f.BoolVar(&l.IncrementDuplicateTimestamp, "validation.increment-duplicate-timestamps", false, "Alter the log line timestamp during ingestion when the timestamp is the same as the previous entry for the same stream. When enabled, if a log line in a push request has the same timestamp as the previous line for the same stream, one nanosecond is added to the log line. This will preserve the received order of log lines with the exact same timestamp when they are queried, by slightly altering their stored timestamp. NOTE: This is imperfect, because Loki accepts out of order writes, and another push request for the same stream could contain duplicate timestamps to existing entries and they will not be incremented.")
 
+	f.IntVar(&l.DemoteHighCardinalityLabelsThreshold, "distributor.demote-high-cardinality-labels-threshold", 0, "Number of distinct values of a stream label, estimated per tenant and per distributor over 'demote_high_cardinality_labels_window', above which the distributor demotes the label to structured metadata instead of creating new streams for every value. Demoted labels stay demoted until the distributor restarts. Requires 'allow_structured_metadata'. 0 to disable.")
+	_ = l.DemoteHighCardinalityLabelsWindow.Set("1h")
+	f.Var(&l.DemoteHighCardinalityLabelsWindow, "distributor.demote-high-cardinality-labels-window", "Window over which the number of distinct values of every stream label is estimated to detect high-cardinality labels.")
+
+	f.IntVar(&l.MaxPushBatchSessions, "distributor.max-push-batch-sessions", 1000, "Maximum number of sessions of batched push requests whose label sets a distributor keeps for the tenant. Requests starting a new session are rejected once reached, until the idle sessions are forgotten.")
+	f.IntVar(&l.MaxPushBatchLabelSets, "distributor.max-push-batch-label-sets", 10000, "Maximum number of label sets a distributor keeps for the batched push sessions of the tenant, in total. Requests defining new label sets are rejected once reached, until the idle sessions are forgotten.")
+
 	_ = l.RejectOldSamplesMaxAge.Set("7d")
 	f.Var(&l.RejectOldSamplesMaxAge, "validation.reject-old-samples.max-age", "Maximum accepted sample age before rejecting.")
 	_ = l.CreationGracePeriod.Set("10m")
@@ -248,6 +369,8 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	// TODO(ashwanth) Deprecated. This will be removed with the next major release and out-of-order writes would be accepted by default.
 	f.BoolVar(&l.UnorderedWrites, "ingester.unordered-writes", true, "Deprecated. When true, out-of-order writes are accepted.")
 
+	f.Var(&l.OutOfOrderWindow, "ingester.out-of-order-window", "How far behind the newest entry of a stream an entry can be and still be accepted, when out-of-order writes are accepted. 0 means half of the ingester max chunk age, which is also the largest window allowed, since larger windows lead to overlapping chunks.")
+
 	_ = l.PerStreamRateLimit.Set(strconv.Itoa(defaultPerStreamRateLimit))
 	f.Var(&l.PerStreamRateLimit, "ingester.per-stream-rate-limit", "Maximum byte rate per second per stream, also expressible in human readable forms (1MB, 256KB, etc).")
 	_ = l.PerStreamRateLimitBurst.Set(strconv.Itoa(defaultPerStreamBurstLimit))
@@ -302,6 +425,14 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	_ = l.RetentionPeriod.Set("0s")
 	f.Var(&l.RetentionPeriod, "store.retention", "Retention period to apply to stored data, only applies if retention_enabled is true in the compactor config. As of version 2.8.0, a zero value of 0 or 0s disables retention. In previous releases, Loki did not properly honor a zero value to disable retention and a really large value should be used instead.")
 
+	f.BoolVar(&l.ZstdDictionariesEnabled, "compactor.zstd-dictionaries-enabled", false, "Experimental. Train a zstd dictionary from the chunks of the tenant in the compactor, and compress the new chunks of the tenant with it when the ingesters use the zstd chunk encoding. This improves the compression of the small blocks of low volume streams.")
+
+	f.Float64Var(&l.ChunkMergeRateMB, "compactor.chunk-merge-rate-mb", 4, "Experimental. Maximum rate, in MB of uncompressed chunk data per second, at which the compactor merges the small chunks of the tenant when chunk merging is enabled. 0 disables chunk merging for the tenant.")
+	f.StringVar(&l.ChunkMergeEncoding, "compactor.chunk-merge-encoding", chunkenc.EncGZIP.String(), fmt.Sprintf("Experimental. The algorithm to compress the chunks merged by the compactor for the tenant with. (%s)", chunkenc.SupportedEncoding()))
+
+	_ = l.ColdStorageAfter.Set("0s")
+	f.Var(&l.ColdStorageAfter, "compactor.cold-storage-after", "Experimental. Age after which the compactor relocates the chunks of the tenant to the cold object store configured in cold_storage of the storage_config. The chunks are relocated a whole index table at a time, once the end of the table is older than this, while applying retention, so it requires retention_enabled in the compactor. 0 disables storage tiering for the tenant.")
+
 	_ = l.PerTenantOverridePeriod.Set("10s")
 	f.Var(&l.PerTenantOverridePeriod, "limits.per-user-override-period", "Feature renamed to 'runtime configuration'; flag deprecated in favor of -runtime-config.reload-period (runtime_config.period in YAML).")
 
@@ -335,6 +466,7 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&l.BloomCompactorEnabled, "bloom-compactor.enable-compaction", false, "Whether to compact chunks into bloom filters.")
 	f.IntVar(&l.BloomNGramLength, "bloom-compactor.ngram-length", 4, "Length of the n-grams created when computing blooms from log lines.")
 	f.IntVar(&l.BloomNGramSkip, "bloom-compactor.ngram-skip", 1, "Skip factor for the n-grams created when computing blooms from log lines.")
+	f.BoolVar(&l.BloomLowercaseNGrams, "bloom-compactor.lowercase-ngrams", false, "Whether to also add the n-grams of the lowercased log lines to the blooms, so the bloom gateway can filter chunks for case-insensitive regex line filters. Increases the size of the blooms when log lines contain uppercase characters.")
 	f.Float64Var(&l.BloomFalsePositiveRate, "bloom-compactor.false-positive-rate", 0.01, "Scalable Bloom Filter desired false-positive rate.")
 	f.IntVar(&l.BloomGatewayBlocksDownloadingParallelism, "bloom-gateway.blocks-downloading-parallelism", 50, "Maximum number of blocks will be downloaded in parallel by the Bloom Gateway.")
 	f.DurationVar(&l.BloomGatewayCacheKeyInterval, "bloom-gateway.cache-key-interval", 15*time.Minute, "Interval for computing the cache key in the Bloom Gateway.")
@@ -393,9 +525,80 @@ func (l *Limits) UnmarshalYAML(unmarshal func(interface{}) error) error {
 
 // Validate validates that this limits config is valid.
 func (l *Limits) Validate() error {
+	rules, err := relabel.NewRules(l.StreamRelabelConfigs)
+	if err != nil {
+		return fmt.Errorf("invalid relabel_configs: %w", err)
+	}
+	l.StreamRelabelRules = rules
+
+	dropRules, err := droprules.NewRules(l.IngestDropRules)
+	if err != nil {
+		return fmt.Errorf("invalid ingest_drop_rules: %w", err)
+	}
+	l.IngestDropRulesCompiled = dropRules
+
+	for i, rule := range l.TeeRules {
+		if rule.Tee == "" {
+			return fmt.Errorf("tee rule at index %d has no tee", i)
+		}
+		matchers, err := syntax.ParseMatchers(rule.Selector, true)
+		if err != nil {
+			return fmt.Errorf("invalid tee rule selector: %w", err)
+		}
+		l.TeeRules[i].Matchers = matchers
+	}
+
+	policyNames := make(map[string]struct{}, len(l.StreamRateLimitPolicies))
+	for i, policy := range l.StreamRateLimitPolicies {
+		if policy.Name == "" {
+			return fmt.Errorf("stream rate limit policy at index %d has no name", i)
+		}
+		if _, ok := policyNames[policy.Name]; ok {
+			return fmt.Errorf("duplicate stream rate limit policy %s", policy.Name)
+		}
+		policyNames[policy.Name] = struct{}{}
+		matchers, err := syntax.ParseMatchers(policy.Selector, true)
+		if err != nil {
+			return fmt.Errorf("invalid stream rate limit policy %s selector: %w", policy.Name, err)
+		}
+		l.StreamRateLimitPolicies[i].Matchers = matchers
+		if policy.Rate.Val() <= 0 {
+			return fmt.Errorf("stream rate limit policy %s rate must be greater than 0", policy.Name)
+		}
+		// A limiter without burst rejects every push.
+		if policy.Burst.Val() <= 0 {
+			l.StreamRateLimitPolicies[i].Burst = policy.Rate
+		}
+	}
+
+	windowPolicyNames := make(map[string]struct{}, len(l.OutOfOrderWindowPolicies))
+	for i, policy := range l.OutOfOrderWindowPolicies {
+		if policy.Name == "" {
+			return fmt.Errorf("out-of-order window policy at index %d has no name", i)
+		}
+		if _, ok := windowPolicyNames[policy.Name]; ok {
+			return fmt.Errorf("duplicate out-of-order window policy %s", policy.Name)
+		}
+		windowPolicyNames[policy.Name] = struct{}{}
+		if policy.Window <= 0 {
+			return fmt.Errorf("out-of-order window policy %s window must be > 0", policy.Name)
+		}
+		matchers, err := syntax.ParseMatchers(policy.Selector, true)
+		if err != nil {
+			return fmt.Errorf("invalid out-of-order window policy %s selector: %w", policy.Name, err)
+		}
+		l.OutOfOrderWindowPolicies[i].Matchers = matchers
+	}
+
+	if l.ChunkMergeEncoding != "" {
+		if _, err := chunkenc.ParseEncoding(l.ChunkMergeEncoding); err != nil {
+			return fmt.Errorf("invalid compactor_chunk_merge_encoding: %w", err)
+		}
+	}
+
 	if l.StreamRetention != nil {
 		for i, rule := range l.StreamRetention {
-			matchers, err := syntax.ParseMatchers(rule.Selector, true)
+			logSelector, err := syntax.ParseLogSelector(rule.Selector, true)
 			if err != nil {
 				return fmt.Errorf("invalid labels matchers: %w", err)
 			}
@@ -403,7 +606,10 @@ func (l *Limits) Validate() error {
 				return fmt.Errorf("retention period must be >= 24h was %s", rule.Period)
 			}
 			// populate matchers during validation
-			l.StreamRetention[i].Matchers = matchers
+			l.StreamRetention[i].Matchers = logSelector.Matchers()
+			if logSelector.HasFilter() {
+				l.StreamRetention[i].LogSelector = logSelector
+			}
 		}
 	}
 
@@ -429,6 +635,29 @@ func (l *Limits) Validate() error {
 		return err
 	}
 
+	if err := l.ElasticsearchConfig.Validate(); err != nil {
+		return fmt.Errorf("invalid elasticsearch_config: %w", err)
+	}
+
+	if err := l.SplunkHECConfig.Validate(); err != nil {
+		return fmt.Errorf("invalid splunk_hec_config: %w", err)
+	}
+
+	return nil
+}
+
+// ValidateOutOfOrderWindows checks that the out-of-order windows don't exceed
+// maxWindow, half of the ingester max chunk age, beyond which the chunks of a
+// stream overlap.
+func (l *Limits) ValidateOutOfOrderWindows(maxWindow time.Duration) error {
+	if time.Duration(l.OutOfOrderWindow) > maxWindow {
+		return fmt.Errorf("out_of_order_window %s must not exceed half of the ingester max_chunk_age (%s)", l.OutOfOrderWindow, model.Duration(maxWindow))
+	}
+	for _, policy := range l.OutOfOrderWindowPolicies {
+		if time.Duration(policy.Window) > maxWindow {
+			return fmt.Errorf("out-of-order window policy %s window %s must not exceed half of the ingester max_chunk_age (%s)", policy.Name, policy.Window, model.Duration(maxWindow))
+		}
+	}
 	return nil
 }
 
@@ -828,6 +1057,22 @@ func (o *Overrides) StreamRetention(userID string) []StreamRetention {
 	return o.getOverridesForUser(userID).StreamRetention
 }
 
+func (o *Overrides) ZstdDictionariesEnabled(userID string) bool {
+	return o.getOverridesForUser(userID).ZstdDictionariesEnabled
+}
+
+func (o *Overrides) ChunkMergeRateBytes(userID string) float64 {
+	return o.getOverridesForUser(userID).ChunkMergeRateMB * bytesInMB
+}
+
+func (o *Overrides) ChunkMergeEncoding(userID string) string {
+	return o.getOverridesForUser(userID).ChunkMergeEncoding
+}
+
+func (o *Overrides) ColdStorageAfter(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).ColdStorageAfter)
+}
+
 func (o *Overrides) UnorderedWrites(userID string) bool {
 	return o.getOverridesForUser(userID).UnorderedWrites
 }
@@ -865,6 +1110,20 @@ func (o *Overrides) PerStreamRateLimit(userID string) RateLimit {
 	}
 }
 
+// OutOfOrderWindow returns the out-of-order window of the streams of the tenant
+// not matching an out-of-order window policy.
+func (o *Overrides) OutOfOrderWindow(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).OutOfOrderWindow)
+}
+
+func (o *Overrides) OutOfOrderWindowPolicies(userID string) []OutOfOrderWindowPolicy {
+	return o.getOverridesForUser(userID).OutOfOrderWindowPolicies
+}
+
+func (o *Overrides) StreamRateLimitPolicies(userID string) []StreamRateLimitPolicy {
+	return o.getOverridesForUser(userID).StreamRateLimitPolicies
+}
+
 func (o *Overrides) IncrementDuplicateTimestamps(userID string) bool {
 	return o.getOverridesForUser(userID).IncrementDuplicateTimestamp
 }
@@ -914,6 +1173,10 @@ func (o *Overrides) BloomNGramSkip(userID string) int {
 	return o.getOverridesForUser(userID).BloomNGramSkip
 }
 
+func (o *Overrides) BloomLowercaseNGrams(userID string) bool {
+	return o.getOverridesForUser(userID).BloomLowercaseNGrams
+}
+
 func (o *Overrides) BloomCompactorMaxBlockSize(userID string) int {
 	return o.getOverridesForUser(userID).BloomCompactorMaxBlockSize.Val()
 }
@@ -922,6 +1185,10 @@ func (o *Overrides) BloomFalsePositiveRate(userID string) float64 {
 	return o.getOverridesForUser(userID).BloomFalsePositiveRate
 }
 
+func (o *Overrides) BloomStructuredMetadataKeys(userID string) []string {
+	return o.getOverridesForUser(userID).BloomStructuredMetadataKeys
+}
+
 func (o *Overrides) AllowStructuredMetadata(userID string) bool {
 	return o.getOverridesForUser(userID).AllowStructuredMetadata
 }
@@ -934,10 +1201,52 @@ func (o *Overrides) MaxStructuredMetadataCount(userID string) int {
 	return o.getOverridesForUser(userID).MaxStructuredMetadataEntriesCount
 }
 
+func (o *Overrides) TSDBStructuredMetadataIndexKeys(userID string) []string {
+	return o.getOverridesForUser(userID).TSDBStructuredMetadataIndexKeys
+}
+
 func (o *Overrides) OTLPConfig(userID string) push.OTLPConfig {
 	return o.getOverridesForUser(userID).OTLPConfig
 }
 
+func (o *Overrides) ElasticsearchConfig(userID string) push.FieldMappingConfig {
+	return o.getOverridesForUser(userID).ElasticsearchConfig
+}
+
+func (o *Overrides) SplunkHECConfig(userID string) push.FieldMappingConfig {
+	return o.getOverridesForUser(userID).SplunkHECConfig
+}
+
+// StreamRelabelRules returns the relabeling rules applied by the distributor to the pushed streams of a given user.
+func (o *Overrides) StreamRelabelRules(userID string) relabel.Rules {
+	return o.getOverridesForUser(userID).StreamRelabelRules
+}
+
+func (o *Overrides) DemoteHighCardinalityLabelsThreshold(userID string) int {
+	return o.getOverridesForUser(userID).DemoteHighCardinalityLabelsThreshold
+}
+
+func (o *Overrides) DemoteHighCardinalityLabelsWindow(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).DemoteHighCardinalityLabelsWindow)
+}
+
+func (o *Overrides) MaxPushBatchSessions(userID string) int {
+	return o.getOverridesForUser(userID).MaxPushBatchSessions
+}
+
+func (o *Overrides) MaxPushBatchLabelSets(userID string) int {
+	return o.getOverridesForUser(userID).MaxPushBatchLabelSets
+}
+
+func (o *Overrides) TeeRules(userID string) []TeeRule {
+	return o.getOverridesForUser(userID).TeeRules
+}
+
+// IngestDropRules returns the rules used by the distributor to drop pushed lines of a given user.
+func (o *Overrides) IngestDropRules(userID string) droprules.Rules {
+	return o.getOverridesForUser(userID).IngestDropRulesCompiled
+}
+
 func (o *Overrides) getOverridesForUser(userID string) *Limits {
 	if o.tenantLimits != nil {
 		l := o.tenantLimits.TenantLimits(userID)
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v2"
@@ -324,3 +325,61 @@ func TestLimitsValidation_deletionMode(t *testing.T) {
 		})
 	}
 }
+
+func TestLimitsValidation_fieldMapping(t *testing.T) {
+	limits := Limits{
+		DeletionMode:    "disabled",
+		SplunkHECConfig: push.FieldMappingConfig{Fields: []push.AttributesConfig{{Action: "drp", Attributes: []string{"host"}}}},
+	}
+	require.ErrorContains(t, limits.Validate(), "invalid splunk_hec_config: unsupported action")
+}
+
+func TestLimitsValidation_streamRateLimitPolicies(t *testing.T) {
+	limits := Limits{
+		DeletionMode:            "disabled",
+		StreamRateLimitPolicies: []StreamRateLimitPolicy{{Name: "no-rate", Selector: `{app="foo"}`, Burst: 1 << 20}},
+	}
+	require.ErrorContains(t, limits.Validate(), "stream rate limit policy no-rate rate must be greater than 0")
+
+	limits.StreamRateLimitPolicies = []StreamRateLimitPolicy{{Name: "no-burst", Selector: `{app="foo"}`, Rate: 1 << 20}}
+	require.NoError(t, limits.Validate())
+	require.Equal(t, RateLimit{Limit: 1 << 20, Burst: 1 << 20}, limits.StreamRateLimitPolicies[0].RateLimit())
+}
+
+func TestLimitsValidation_outOfOrderWindows(t *testing.T) {
+	limits := Limits{OutOfOrderWindow: model.Duration(time.Hour)}
+	require.NoError(t, limits.ValidateOutOfOrderWindows(time.Hour))
+	require.EqualError(t, limits.ValidateOutOfOrderWindows(30*time.Minute), "out_of_order_window 1h must not exceed half of the ingester max_chunk_age (30m)")
+
+	limits = Limits{OutOfOrderWindowPolicies: []OutOfOrderWindowPolicy{{Name: "late", Selector: `{app="foo"}`, Window: model.Duration(2 * time.Hour)}}}
+	require.EqualError(t, limits.ValidateOutOfOrderWindows(time.Hour), "out-of-order window policy late window 2h must not exceed half of the ingester max_chunk_age (1h)")
+}
+
+func TestLimitsValidation_streamRetention(t *testing.T) {
+	for _, tc := range []struct {
+		selector   string
+		hasFilters bool
+		err        string
+	}{
+		{selector: `{app="foo"}`},
+		{selector: `{app="foo"} | level="debug"`, hasFilters: true},
+		{selector: `{app="foo"} |= "healthcheck" | level=~"debug|info"`, hasFilters: true},
+		{selector: `{app=""}`, err: "invalid labels matchers"},
+		{selector: `sum(rate({app="foo"}[1m]))`, err: "invalid labels matchers"},
+	} {
+		t.Run(tc.selector, func(t *testing.T) {
+			limits := Limits{
+				DeletionMode:    "disabled",
+				StreamRetention: []StreamRetention{{Period: model.Duration(24 * time.Hour), Selector: tc.selector}},
+			}
+			err := limits.Validate()
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "app", "foo")}, limits.StreamRetention[0].Matchers)
+			require.Equal(t, tc.hasFilters, limits.StreamRetention[0].LogSelector != nil)
+		})
+	}
+}
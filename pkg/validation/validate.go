@@ -40,8 +40,8 @@ const (
 	// TooFarBehind is a reason for discarding lines when Loki accepts
 	// unordered ingest  (parameter `-ingester.unordered-writes` is set to
 	// `true`, which is the default) and the lines in question are older than
-	// half of `-ingester.max-chunk-age` compared to the newest line in the
-	// stream.
+	// the out-of-order window of the stream compared to the newest line in the
+	// stream. The window defaults to half of `-ingester.max-chunk-age`.
 	TooFarBehind = "too_far_behind"
 	// GreaterThanMaxSampleAge is a reason for discarding log lines which are older than the current time - `reject_old_samples_max_age`
 	GreaterThanMaxSampleAge         = "greater_than_max_sample_age"
@@ -67,6 +67,10 @@ const (
 	StructuredMetadataTooLargeErrorMsg   = "stream '%s' has structured metadata too large: '%d' bytes, limit: '%d' bytes. Please see `limits_config.structured_metadata_max_size` or contact your Loki administrator to increase it."
 	StructuredMetadataTooMany            = "structured_metadata_too_many"
 	StructuredMetadataTooManyErrorMsg    = "stream '%s' has too many structured metadata labels: '%d', limit: '%d'. Please see `limits_config.max_structured_metadata_entries_count` or contact your Loki administrator to increase it."
+	// RelabelDropped is a reason for discarding log lines of streams dropped by the relabel rules of the tenant.
+	RelabelDropped = "relabel_dropped"
+	// IngestDropRuleMatched is a reason for discarding log lines matching an ingest drop rule of the tenant.
+	IngestDropRuleMatched = "ingest_drop_rule"
 )
 
 type ErrStreamRateLimit struct {